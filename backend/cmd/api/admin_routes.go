@@ -1,6 +1,7 @@
 package main
 
 import (
+	"backend/internal/auth"
 	"backend/internal/config"
 	"backend/internal/handler"
 	"backend/internal/middleware"
@@ -8,15 +9,21 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// setupAdminRoutes configures admin API routes with RBAC protection
-func setupAdminRoutes(r *gin.Engine, handlers *AdminHandlers, cfg *config.Config) {
-	// Admin API group with authentication and authorization
+// setupAdminRoutes configures admin API routes. Each group or route is
+// guarded by the resource and action it needs, checked against the caller's
+// roles in rbac, and only active staff accounts get through. Every change
+// made through them is recorded by auditor
+func setupAdminRoutes(r *gin.Engine, handlers *AdminHandlers, cfg *config.Config, rbac *auth.RBAC, staff middleware.StaffChecker, auditor middleware.Auditor) {
+	// Admin API group with authentication and auditing; authorization is
+	// per resource
 	admin := r.Group("/api/v1/admin")
 	admin.Use(middleware.JWTAuth(&cfg.JWT))
-	admin.Use(middleware.RequireRole("super_admin", "operations"))
+	admin.Use(middleware.AdminAudit(auditor, "/api/v1/admin"))
+	admin.Use(middleware.RequireActiveStaff(staff))
 	{
 		// Cruise management
 		cruises := admin.Group("/cruises")
+		cruises.Use(middleware.RequireResource(rbac, auth.ResourceCruises))
 		{
 			cruises.GET("", handlers.AdminCruise.List)
 			cruises.POST("", handlers.AdminCruise.Create)
@@ -30,6 +37,7 @@ func setupAdminRoutes(r *gin.Engine, handlers *AdminHandlers, cfg *config.Config
 
 		// Cabin type management
 		cabinTypes := admin.Group("/cabin-types")
+		cabinTypes.Use(middleware.RequireResource(rbac, auth.ResourceCabinTypes))
 		{
 			cabinTypes.GET("", handlers.AdminCabinType.List)
 			cabinTypes.POST("", handlers.AdminCabinType.Create)
@@ -42,6 +50,7 @@ func setupAdminRoutes(r *gin.Engine, handlers *AdminHandlers, cfg *config.Config
 
 		// Facility management
 		facilities := admin.Group("/facilities")
+		facilities.Use(middleware.RequireResource(rbac, auth.ResourceFacilities))
 		{
 			facilities.GET("", handlers.AdminFacility.List)
 			facilities.POST("", handlers.AdminFacility.Create)
@@ -52,12 +61,161 @@ func setupAdminRoutes(r *gin.Engine, handlers *AdminHandlers, cfg *config.Config
 
 		// Facility category management
 		categories := admin.Group("/facility-categories")
+		categories.Use(middleware.RequireResource(rbac, auth.ResourceFacilities))
 		{
 			categories.GET("", handlers.AdminFacilityCategory.ListCategories)
 			categories.POST("", handlers.AdminFacilityCategory.CreateCategory)
 			categories.PUT("/:id", handlers.AdminFacilityCategory.UpdateCategory)
 			categories.DELETE("/:id", handlers.AdminFacilityCategory.DeleteCategory)
 		}
+
+		// Dynamic pricing
+		pricing := admin.Group("/pricing")
+		pricing.Use(middleware.RequireResource(rbac, auth.ResourcePricing))
+		{
+			pricing.GET("/rules", handlers.AdminPricing.ListRules)
+			pricing.POST("/rules", handlers.AdminPricing.CreateRule)
+			pricing.GET("/rules/:id", handlers.AdminPricing.GetRule)
+			pricing.PUT("/rules/:id", handlers.AdminPricing.UpdateRule)
+			pricing.DELETE("/rules/:id", handlers.AdminPricing.DeleteRule)
+			pricing.GET("/voyages/:id/preview", handlers.AdminPricing.Preview)
+			pricing.POST("/voyages/:id/apply", handlers.AdminPricing.Apply)
+			pricing.PUT("/prices/:id/override", handlers.AdminPricing.SetManualOverride)
+			pricing.POST("/bulk/preview", handlers.AdminBulkPrice.Preview)
+			pricing.POST("/bulk/apply", handlers.AdminBulkPrice.Apply)
+			pricing.GET("/bulk/operations", handlers.AdminBulkPrice.ListOperations)
+		}
+
+		// Coupons
+		coupons := admin.Group("/coupons")
+		coupons.Use(middleware.RequireResource(rbac, auth.ResourcePromotions))
+		{
+			coupons.GET("", handlers.AdminCoupon.List)
+			coupons.POST("", handlers.AdminCoupon.Create)
+			coupons.GET("/:id", handlers.AdminCoupon.Get)
+			coupons.PUT("/:id", handlers.AdminCoupon.Update)
+			coupons.DELETE("/:id", handlers.AdminCoupon.Delete)
+			coupons.POST("/:id/issue", handlers.AdminCoupon.Issue)
+		}
+
+		// Promotion campaigns
+		promotions := admin.Group("/promotions")
+		promotions.Use(middleware.RequireResource(rbac, auth.ResourcePromotions))
+		{
+			promotions.GET("", handlers.AdminPromotion.List)
+			promotions.POST("", handlers.AdminPromotion.Create)
+			promotions.GET("/calendar", handlers.AdminPromotion.Calendar)
+			promotions.GET("/:id", handlers.AdminPromotion.Get)
+			promotions.PUT("/:id", handlers.AdminPromotion.Update)
+			promotions.POST("/:id/cancel", handlers.AdminPromotion.Cancel)
+			promotions.GET("/:id/prices", handlers.AdminPromotion.ListPrices)
+			promotions.GET("/:id/uptake", handlers.AdminPromotion.Uptake)
+		}
+
+		// Refund policies
+		refundPolicies := admin.Group("/refund-policies")
+		refundPolicies.Use(middleware.RequireResource(rbac, auth.ResourceRefundPolicies))
+		{
+			refundPolicies.GET("", handlers.AdminRefundPolicy.List)
+			refundPolicies.POST("", handlers.AdminRefundPolicy.Create)
+			refundPolicies.GET("/:id", handlers.AdminRefundPolicy.Get)
+			refundPolicies.PUT("/:id", handlers.AdminRefundPolicy.Update)
+			refundPolicies.PUT("/:id/status", handlers.AdminRefundPolicy.SetStatus)
+		}
+
+		// Outbox events that could not be published
+		outbox := admin.Group("/outbox/events")
+		outbox.Use(middleware.RequireResource(rbac, auth.ResourceOutbox))
+		{
+			outbox.GET("", handlers.AdminOutbox.List)
+			outbox.GET("/:id", handlers.AdminOutbox.Get)
+			outbox.POST("/:id/retry", handlers.AdminOutbox.Retry)
+		}
+
+		// Orders
+		orders := admin.Group("/orders")
+		{
+			orders.GET("", middleware.RequirePermission(rbac, auth.ResourceOrders, auth.PermRead), handlers.AdminOrder.ListOrders)
+			orders.GET("/statistics", middleware.RequirePermission(rbac, auth.ResourceAnalytics, auth.PermRead), handlers.AdminOrder.GetOrderStatistics)
+			orders.GET("/:id", middleware.RequirePermission(rbac, auth.ResourceOrders, auth.PermRead), handlers.AdminOrder.GetOrderDetail)
+			orders.PUT("/:id/status", middleware.RequirePermission(rbac, auth.ResourceOrders, auth.PermUpdate), handlers.AdminOrder.UpdateOrderStatus)
+			// Customer service files refunds on the customer's behalf
+			orders.GET("/:id/refund-preview", middleware.RequirePermission(rbac, auth.ResourceRefundRequests, auth.PermProcess), handlers.AdminOrder.PreviewRefund)
+			orders.POST("/:id/refunds", middleware.RequirePermission(rbac, auth.ResourceRefundRequests, auth.PermProcess), handlers.AdminOrder.CreateRefund)
+		}
+
+		// Refund review; approved refunds are paid out by finance
+		refunds := admin.Group("/refunds")
+		{
+			refunds.GET("", middleware.RequirePermission(rbac, auth.ResourceRefundRequests, auth.PermRead), handlers.AdminOrder.ListRefunds)
+			refunds.GET("/:id", middleware.RequirePermission(rbac, auth.ResourceRefundRequests, auth.PermRead), handlers.AdminOrder.GetRefundDetail)
+			refunds.POST("/:id/approve", middleware.RequirePermission(rbac, auth.ResourceRefundRequests, auth.PermApprove), handlers.AdminOrder.ApproveRefund)
+			refunds.POST("/:id/reject", middleware.RequirePermission(rbac, auth.ResourceRefundRequests, auth.PermApprove), handlers.AdminOrder.RejectRefund)
+			refunds.POST("/:id/process", middleware.RequirePermission(rbac, auth.ResourceOrders, auth.PermRefund), handlers.AdminOrder.ProcessRefund)
+		}
+
+		// Customer lookup
+		users := admin.Group("/users")
+		users.Use(middleware.RequirePermission(rbac, auth.ResourceUsers, auth.PermRead))
+		{
+			users.GET("", handlers.AdminUser.List)
+			users.GET("/:id", handlers.AdminUser.Get)
+		}
+
+		// Reconciliation
+		reconciliation := admin.Group("/reconciliation")
+		reconciliation.Use(middleware.RequireResource(rbac, auth.ResourceReconciliation))
+		{
+			reconciliation.GET("/runs", handlers.AdminReconciliation.ListRuns)
+			reconciliation.POST("/runs", handlers.AdminReconciliation.Import)
+			reconciliation.GET("/runs/:id", handlers.AdminReconciliation.GetRun)
+			reconciliation.GET("/runs/:id/items", handlers.AdminReconciliation.ListItems)
+			reconciliation.GET("/runs/:id/export", handlers.AdminReconciliation.Export)
+		}
+
+		// Invoice review
+		invoices := admin.Group("/invoices")
+		{
+			invoices.GET("", middleware.RequirePermission(rbac, auth.ResourceInvoices, auth.PermRead), handlers.AdminInvoice.List)
+			invoices.GET("/:id", middleware.RequirePermission(rbac, auth.ResourceInvoices, auth.PermRead), handlers.AdminInvoice.Get)
+			invoices.POST("/:id/approve", middleware.RequirePermission(rbac, auth.ResourceInvoices, auth.PermApprove), handlers.AdminInvoice.Approve)
+			invoices.POST("/:id/reject", middleware.RequirePermission(rbac, auth.ResourceInvoices, auth.PermApprove), handlers.AdminInvoice.Reject)
+		}
+
+		// Payment callback inspection and replay
+		paymentCallbacks := admin.Group("/payment-callbacks")
+		{
+			paymentCallbacks.GET("", middleware.RequirePermission(rbac, auth.ResourcePayments, auth.PermRead), handlers.AdminPaymentCallback.List)
+			paymentCallbacks.GET("/:id", middleware.RequirePermission(rbac, auth.ResourcePayments, auth.PermRead), handlers.AdminPaymentCallback.Get)
+			paymentCallbacks.POST("/:id/replay", middleware.RequirePermission(rbac, auth.ResourcePayments, auth.PermProcess), handlers.AdminPaymentCallback.Replay)
+		}
+
+		// Staff accounts and roles
+		access := admin.Group("/access")
+		access.Use(middleware.RequireResource(rbac, auth.ResourceStaff))
+		{
+			access.GET("/staff", handlers.AdminStaff.List)
+			access.POST("/staff", handlers.AdminStaff.Create)
+			access.GET("/staff/:id", handlers.AdminStaff.Get)
+			access.PUT("/staff/:id", handlers.AdminStaff.Update)
+			access.PUT("/staff/:id/password", handlers.AdminStaff.ResetPassword)
+			access.DELETE("/staff/:id", handlers.AdminStaff.Delete)
+			access.GET("/roles", handlers.AdminRole.List)
+			access.POST("/roles", handlers.AdminRole.Create)
+			access.GET("/roles/:name", handlers.AdminRole.Get)
+			access.PUT("/roles/:name", handlers.AdminRole.Update)
+			access.DELETE("/roles/:name", handlers.AdminRole.Delete)
+			access.GET("/permissions", handlers.AdminRole.ListPermissions)
+		}
+
+		// Audit log of back-office changes
+		auditLogs := admin.Group("/audit-logs")
+		auditLogs.Use(middleware.RequirePermission(rbac, auth.ResourceAuditLogs, auth.PermRead))
+		{
+			auditLogs.GET("", handlers.AdminAuditLog.List)
+			auditLogs.GET("/export", handlers.AdminAuditLog.Export)
+			auditLogs.GET("/:id", handlers.AdminAuditLog.Get)
+		}
 	}
 }
 
@@ -67,4 +225,18 @@ type AdminHandlers struct {
 	AdminCabinType        *handler.AdminCabinTypeHandler
 	AdminFacility         *handler.AdminFacilityHandler
 	AdminFacilityCategory *handler.AdminFacilityCategoryHandler
+	AdminPricing          *handler.AdminPricingHandler
+	AdminBulkPrice        *handler.AdminBulkPriceHandler
+	AdminCoupon           *handler.AdminCouponHandler
+	AdminPromotion        *handler.AdminPromotionHandler
+	AdminReconciliation   *handler.AdminReconciliationHandler
+	AdminRefundPolicy     *handler.AdminRefundPolicyHandler
+	AdminOutbox           *handler.AdminOutboxHandler
+	AdminInvoice          *handler.AdminInvoiceHandler
+	AdminPaymentCallback  *handler.AdminPaymentCallbackHandler
+	AdminOrder            *handler.AdminOrderHandler
+	AdminUser             *handler.AdminUserHandler
+	AdminStaff            *handler.AdminStaffHandler
+	AdminRole             *handler.AdminRoleHandler
+	AdminAuditLog         *handler.AdminAuditLogHandler
 }
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"backend/internal/auth"
+	"backend/internal/config"
+	"backend/internal/domain"
+	"backend/internal/middleware"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nopAuditor records nothing
+type nopAuditor struct{}
+
+func (nopAuditor) Snapshot(context.Context, string, string) []byte { return nil }
+
+func (nopAuditor) Record(context.Context, *domain.AdminAuditLog) error { return nil }
+
+// activeStaff treats every caller as an active staff account
+type activeStaff struct{}
+
+func (activeStaff) IsActive(context.Context, string) (bool, error) { return true, nil }
+
+// newAdminRouter sets up the admin API with handlers that have no services,
+// and one staff account per built-in role. A request that gets past
+// authorization fails in its handler, which is recovered as 418 so the table
+// below only tells apart reaching the handler from being refused
+func newAdminRouter(t *testing.T, cfg *config.Config) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	rbac, err := auth.NewRBAC()
+	require.NoError(t, err)
+	for _, role := range auth.AllRoles {
+		require.NoError(t, rbac.SetRolesForUser("staff-"+role, []string{role}))
+	}
+
+	handlers := &AdminHandlers{}
+	fields := reflect.ValueOf(handlers).Elem()
+	for i := 0; i < fields.NumField(); i++ {
+		fields.Field(i).Set(reflect.New(fields.Field(i).Type().Elem()))
+	}
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		defer func() {
+			if recover() != nil {
+				c.AbortWithStatus(http.StatusTeapot)
+			}
+		}()
+		c.Next()
+	})
+	setupAdminRoutes(r, handlers, cfg, rbac, activeStaff{}, nopAuditor{})
+	return r
+}
+
+func TestAdminRoutes_Roles(t *testing.T) {
+	cfg := &config.Config{JWT: config.JWTConfig{Secret: "test-secret", ExpireHours: 1}}
+	router := newAdminRouter(t, cfg)
+
+	routes := []struct {
+		method, path string
+	}{
+		{http.MethodGet, "/api/v1/admin/cruises"},
+		{http.MethodPost, "/api/v1/admin/cruises"},
+		{http.MethodGet, "/api/v1/admin/pricing/rules"},
+		{http.MethodPut, "/api/v1/admin/orders/o-1/status"},
+		{http.MethodPost, "/api/v1/admin/orders/o-1/refunds"},
+		{http.MethodGet, "/api/v1/admin/refunds"},
+		{http.MethodPost, "/api/v1/admin/refunds/r-1/approve"},
+		{http.MethodPost, "/api/v1/admin/refunds/r-1/process"},
+		{http.MethodGet, "/api/v1/admin/reconciliation/runs"},
+		{http.MethodPost, "/api/v1/admin/reconciliation/runs"},
+		{http.MethodPost, "/api/v1/admin/payment-callbacks/c-1/replay"},
+		{http.MethodGet, "/api/v1/admin/users"},
+		{http.MethodGet, "/api/v1/admin/access/staff"},
+		{http.MethodGet, "/api/v1/admin/audit-logs"},
+	}
+
+	// allowed lists, per role, the routes above it may reach
+	tests := []struct {
+		role    string
+		allowed []bool
+	}{
+		{auth.RoleSuperAdmin, []bool{true, true, true, true, true, true, true, true, true, true, true, true, true, true}},
+		{auth.RoleOperations, []bool{true, true, true, false, false, false, false, false, false, false, false, false, false, false}},
+		{auth.RoleFinance, []bool{false, false, false, false, false, true, true, true, true, true, true, false, false, false}},
+		{auth.RoleCustomerService, []bool{false, false, false, true, true, true, false, false, false, false, false, true, false, false}},
+		{"user", []bool{false, false, false, false, false, false, false, false, false, false, false, false, false, false}},
+	}
+
+	for _, tt := range tests {
+		token, err := middleware.GenerateToken("staff-"+tt.role, tt.role, tt.role, &cfg.JWT)
+		require.NoError(t, err)
+
+		for i, route := range routes {
+			t.Run(tt.role+" "+route.method+" "+route.path, func(t *testing.T) {
+				req := httptest.NewRequest(route.method, route.path, nil)
+				req.Header.Set("Authorization", "Bearer "+token)
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+
+				if tt.allowed[i] {
+					assert.NotContains(t, []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound}, w.Code)
+				} else {
+					assert.Equal(t, http.StatusForbidden, w.Code)
+				}
+			})
+		}
+	}
+}
+
+func TestAdminRoutes_RequireToken(t *testing.T) {
+	router := newAdminRouter(t, &config.Config{JWT: config.JWTConfig{Secret: "test-secret", ExpireHours: 1}})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/refunds", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -1,17 +1,26 @@
 package main
 
 import (
+	"backend/internal/analytics"
 	"backend/internal/auth"
 	"backend/internal/cache"
 	"backend/internal/config"
+	"backend/internal/currency"
 	"backend/internal/handler"
+	"backend/internal/invoice"
+	"backend/internal/jobs"
 	"backend/internal/messaging"
 	"backend/internal/middleware"
+	"backend/internal/notification"
 	"backend/internal/payment"
+	"backend/internal/realtime"
 	"backend/internal/repository"
 	"backend/internal/service"
 	"backend/internal/storage"
+	"context"
+	"log"
 	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -30,6 +39,19 @@ func setupRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config) {
 	inventoryRepo := repository.NewInventoryRepository(db)
 	orderRepo := repository.NewOrderRepository(db)
 	userRepo := repository.NewUserRepository(db)
+	pricingRuleRepo := repository.NewPricingRuleRepository(db)
+	priceBulkRepo := repository.NewPriceBulkRepository(db)
+	couponRepo := repository.NewCouponRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	priceWatchRepo := repository.NewPriceWatchRepository(db)
+	promotionRepo := repository.NewPromotionRepository(db)
+	reconciliationRepo := repository.NewReconciliationRepository(db)
+	refundPolicyRepo := repository.NewRefundPolicyRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	invoiceRepo := repository.NewInvoiceRepository(db)
+	paymentCallbackRepo := repository.NewPaymentCallbackRepository(db)
+	auditLogRepo := repository.NewAdminAuditLogRepository(db)
+	staffRepo := repository.NewStaffRepository(db)
 
 	// Initialize infrastructure clients (best-effort)
 	var redisClient *cache.RedisClient
@@ -42,6 +64,28 @@ func setupRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config) {
 		natsConn = client
 	}
 
+	// Realtime availability hub; inventory changes are broadcast through it
+	var hub *realtime.Hub
+	if natsConn != nil {
+		hub = realtime.NewHub(natsConn.GetConn())
+	} else {
+		hub = realtime.NewHub(nil)
+	}
+	if err := hub.Start(); err != nil {
+		// Availability pushes are a convenience; without the NATS fan-out
+		// each replica still reaches its own clients
+		log.Printf("Failed to start realtime hub, broadcasting in-process only: %v", err)
+		hub.Stop()
+		hub = realtime.NewHub(nil)
+	}
+	var presence realtime.Presence
+	if redisClient != nil {
+		presence = realtime.NewPresence(redisClient.GetClient())
+	} else {
+		presence = realtime.NewPresence()
+	}
+	inventoryRepo = realtime.NewPublishingInventoryRepository(inventoryRepo, hub)
+
 	// Initialize services
 	cruiseService := service.NewCruiseService(cruiseRepo)
 	cabinTypeService := service.NewCabinTypeService(cabinTypeRepo)
@@ -60,26 +104,88 @@ func setupRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config) {
 		tokenBlacklist = middleware.NewTokenBlacklist(redisClient.GetClient())
 	}
 
-	rbac, _ := auth.NewRBAC()
+	// Staff roles and permissions are stored in PostgreSQL; other instances
+	// reload the policy when it changes. Starting with the default policy
+	// instead would silently drop every role assignment, so we panic
+	rbac, err := auth.NewPersistentRBAC(repository.NewCasbinAdapter(db))
+	if err != nil {
+		panic(err)
+	}
+	if natsConn != nil {
+		if watcher, err := messaging.NewPolicyWatcher(natsConn.GetConn()); err == nil {
+			if err := rbac.SetWatcher(watcher); err != nil {
+				log.Printf("Failed to watch RBAC policy: %v", err)
+			}
+		}
+	}
+	staffService := service.NewStaffService(staffRepo, rbac)
+	if cfg.Admin.Username != "" && cfg.Admin.Password != "" {
+		if err := staffService.EnsureAdmin(context.Background(), cfg.Admin.Username, cfg.Admin.Password); err != nil {
+			log.Printf("Failed to create admin account: %v", err)
+		}
+	}
+
+	// Exchange rates come from the rate file. Without one, development uses
+	// the built-in reference rates; elsewhere only the base currency is
+	// quoted rather than charging at made-up rates
+	var currencyProviders []currency.Provider
+	switch {
+	case cfg.Currency.RatesFile != "":
+		currencyProviders = []currency.Provider{currency.NewFileProvider(cfg.Currency.RatesFile)}
+	case cfg.Environment == "development":
+		currencyProviders = []currency.Provider{currency.NewStaticProvider(currency.DefaultRates, time.Time{})}
+	default:
+		log.Printf("[WARN] currency.rates_file is not set; only %s prices are available", currency.Base)
+	}
+	currencyConfig := currency.Config{
+		RefreshInterval: time.Duration(cfg.Currency.RefreshMinutes) * time.Minute,
+		MaxStaleness:    time.Duration(cfg.Currency.MaxStaleHours) * time.Hour,
+	}
+	var currencyService currency.Converter
+	if redisClient != nil {
+		currencyService = currency.NewService(currencyConfig, currencyProviders, redisClient.GetClient())
+	} else {
+		currencyService = currency.NewService(currencyConfig, currencyProviders)
+	}
+
+	couponService := service.NewCouponService(couponRepo)
+
+	// Notifications; price writes evaluate price watches and alert subscribers
+	notificationService := service.NewNotificationService(notificationRepo, userRepo,
+		notification.NewWechatTemplateSender(cfg.Wechat.AppID, os.Getenv("WECHAT_APP_SECRET")), nil)
+	priceWatchService := service.NewPriceWatchService(priceWatchRepo, priceRepo, voyageRepo, notificationService)
+	priceRepo = service.NewWatchedPriceRepository(priceRepo, priceWatchService)
+
+	// Refund policies are snapshotted onto orders at booking
+	refundPolicyService := service.NewRefundPolicyService(refundPolicyRepo)
 
 	orderService := func() service.OrderService {
 		if redisClient != nil {
-			return service.NewOrderService(orderRepo, voyageRepo, cabinRepo, priceRepo, inventoryRepo, redisClient.GetClient())
+			return service.NewOrderService(orderRepo, voyageRepo, cabinRepo, priceRepo, inventoryRepo, currencyService, couponService, refundPolicyService, redisClient.GetClient())
 		}
-		return service.NewOrderService(orderRepo, voyageRepo, cabinRepo, priceRepo, inventoryRepo)
+		return service.NewOrderService(orderRepo, voyageRepo, cabinRepo, priceRepo, inventoryRepo, currencyService, couponService, refundPolicyService)
 	}()
+	priceService := service.NewPriceService(priceRepo, voyageRepo, cabinTypeRepo, currencyService)
+
+	// Dynamic pricing engine, re-evaluated on a schedule
+	pricingEngine := service.NewPricingEngine(pricingRuleRepo, priceRepo, voyageRepo, inventoryRepo)
+	jobs.NewDynamicPricingJob(pricingEngine).Start(15 * time.Minute)
+	bulkPriceService := service.NewBulkPriceService(priceRepo, voyageRepo, priceBulkRepo)
+
+	// Promotion campaigns, switched on and off at their window boundaries
+	var promotionService service.PromotionService
+	if redisClient != nil {
+		promotionService = service.NewPromotionService(promotionRepo, voyageRepo, priceWatchService, redisClient.GetClient())
+	} else {
+		promotionService = service.NewPromotionService(promotionRepo, voyageRepo, priceWatchService)
+	}
+	jobs.NewPromotionSchedulerJob(promotionService).Start(time.Minute)
 
 	paymentService := func() payment.PaymentService {
-		if natsConn != nil && redisClient != nil {
-			return payment.NewPaymentService(orderRepo, natsConn.GetConn(), redisClient.GetClient())
-		}
-		if natsConn != nil {
-			return payment.NewPaymentService(orderRepo, natsConn.GetConn())
-		}
 		if redisClient != nil {
-			return payment.NewPaymentService(orderRepo, nil, redisClient.GetClient())
+			return payment.NewPaymentService(orderRepo, redisClient.GetClient())
 		}
-		return payment.NewPaymentService(orderRepo, nil)
+		return payment.NewPaymentService(orderRepo)
 	}()
 
 	wechatConfig := payment.WechatPayConfig{
@@ -124,6 +230,62 @@ func setupRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config) {
 
 	paymentService.RegisterProvider("wechat", wechatProvider)
 
+	alipayConfig := payment.AlipayConfig{
+		AppID: cfg.Alipay.AppID,
+		NotifyURL: func() string {
+			if v := os.Getenv("ALIPAY_NOTIFY_URL"); v != "" {
+				return v
+			}
+			return "http://localhost:8080/api/v1/payments/callback/alipay"
+		}(),
+		ReturnURL: cfg.Alipay.ReturnURL,
+		Sandbox:   cfg.Alipay.Sandbox,
+		PrivateKey: func() string {
+			if cfg.Alipay.KeyPath == "" {
+				return ""
+			}
+			b, err := os.ReadFile(cfg.Alipay.KeyPath)
+			if err != nil {
+				return ""
+			}
+			return string(b)
+		}(),
+		AlipayPublicKey: func() string {
+			if cfg.Alipay.PublicKeyPath == "" {
+				return ""
+			}
+			b, err := os.ReadFile(cfg.Alipay.PublicKeyPath)
+			if err != nil {
+				return ""
+			}
+			return string(b)
+		}(),
+	}
+	paymentService.RegisterProvider("alipay", payment.NewAlipay(alipayConfig, orderRepo))
+	paymentService.RegisterProvider("gift_card", payment.NewGiftCard(orderRepo))
+	paymentService.RegisterProvider("points", payment.NewPoints(payment.StoredValueConfig{PointsPerUnit: cfg.Points.PerUnit}, orderRepo))
+	identityService := service.NewIdentityService(userRepo, wechatAuthService, smsService, payment.NewAlipayAuth(alipayConfig))
+
+	// Local sandbox provider so the pay flow can run without real credentials
+	var sandboxPaymentHandler *handler.SandboxPaymentHandler
+	if cfg.Sandbox.Enabled && cfg.Environment != "production" {
+		sandboxProvider := payment.NewSandbox(payment.SandboxConfig{Secret: cfg.Sandbox.Secret}, orderRepo)
+		paymentService.RegisterProvider("sandbox", sandboxProvider)
+		sandboxPaymentHandler = handler.NewSandboxPaymentHandler(sandboxProvider, payment.NewSandboxSimulator(paymentService, sandboxProvider))
+	}
+
+	// Poll providers for payments whose callback never arrived, and query
+	// in-flight payments again before an expired order is cancelled
+	jobs.NewPaymentPollJob(orderRepo, paymentService).Start(time.Minute)
+	orderTimeoutJob := func() *jobs.OrderTimeoutJob {
+		stateService := service.NewOrderStateService(orderRepo, inventoryRepo)
+		if natsConn != nil {
+			return jobs.NewOrderTimeoutJob(orderRepo, inventoryRepo, stateService, couponService, paymentService, natsConn.GetConn())
+		}
+		return jobs.NewOrderTimeoutJob(orderRepo, inventoryRepo, stateService, couponService, paymentService, nil)
+	}()
+	orderTimeoutJob.Start(time.Minute)
+
 	// Initialize MinIO client
 	minioClient, err := storage.New(cfg.MinIO)
 	if err != nil {
@@ -133,16 +295,106 @@ func setupRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config) {
 	}
 	storageService := service.NewStorageService(minioClient, cfg.MinIO.Endpoint)
 
+	// Electronic invoices; refunds reverse invoices already issued
+	invoiceProvider, err := invoice.NewFileProvider(cfg.Invoice.Dir, invoice.Seller{Name: cfg.Invoice.SellerName, TaxID: cfg.Invoice.SellerTaxID})
+	if err != nil {
+		panic(err)
+	}
+	invoiceService := service.NewInvoiceService(invoiceRepo, orderRepo, invoiceProvider, storageService)
+
+	// Refund outcomes arrive by notification; overdue ones are queried
+	refundService := service.NewRefundService(orderRepo, paymentService, orderService, notificationService, invoiceService)
+	jobs.NewRefundSyncJob(refundService).Start(5 * time.Minute)
+
+	// Events are written to the outbox with the change they describe and
+	// relayed to JetStream from there
+	var publisher messaging.Publisher
+	if natsConn != nil {
+		if err := natsConn.EnsureStream(); err != nil {
+			log.Printf("Failed to set up event stream: %v", err)
+		}
+		publisher = natsConn
+	}
+	outboxService := service.NewOutboxService(outboxRepo, publisher)
+	jobs.NewOutboxRelayJob(outboxService).Start(5 * time.Second)
+
+	paymentCallbackService := service.NewPaymentCallbackService(paymentCallbackRepo, paymentService, refundService)
+	roleService := service.NewRoleService(staffRepo, rbac)
+
+	// Back-office audit log; targets are snapshotted before and after changes
+	auditService := service.NewAdminAuditService(auditLogRepo, time.Duration(cfg.Audit.RetentionDays)*24*time.Hour, map[string]service.AuditLoader{
+		"cruises": func(ctx context.Context, id string) (interface{}, error) {
+			return cruiseService.GetByID(ctx, id)
+		},
+		"cabin-types": func(ctx context.Context, id string) (interface{}, error) {
+			return cabinTypeService.GetByID(ctx, id)
+		},
+		"facilities": func(ctx context.Context, id string) (interface{}, error) {
+			return facilityService.GetByID(ctx, id)
+		},
+		"facility-categories": func(ctx context.Context, id string) (interface{}, error) {
+			return facilityCategoryService.GetByID(ctx, id)
+		},
+		"pricing/rules": func(ctx context.Context, id string) (interface{}, error) {
+			return pricingEngine.GetRule(ctx, id)
+		},
+		"pricing/prices": func(ctx context.Context, id string) (interface{}, error) {
+			return priceService.GetByID(ctx, id)
+		},
+		"coupons": func(ctx context.Context, id string) (interface{}, error) {
+			return couponService.GetCoupon(ctx, id)
+		},
+		"promotions": func(ctx context.Context, id string) (interface{}, error) {
+			return promotionService.GetCampaign(ctx, id)
+		},
+		"refund-policies": func(ctx context.Context, id string) (interface{}, error) {
+			return refundPolicyService.GetPolicy(ctx, id)
+		},
+		"outbox/events": func(ctx context.Context, id string) (interface{}, error) {
+			return outboxService.GetEvent(ctx, id)
+		},
+		"orders": func(ctx context.Context, id string) (interface{}, error) {
+			return orderService.GetByID(ctx, id)
+		},
+		"refunds": func(ctx context.Context, id string) (interface{}, error) {
+			return refundService.GetRefundByID(ctx, id)
+		},
+		"invoices": func(ctx context.Context, id string) (interface{}, error) {
+			return invoiceService.GetInvoice(ctx, id)
+		},
+		"payment-callbacks": func(ctx context.Context, id string) (interface{}, error) {
+			return paymentCallbackService.GetCallback(ctx, id)
+		},
+		"access/staff": func(ctx context.Context, id string) (interface{}, error) {
+			return staffService.GetStaff(ctx, id)
+		},
+		"access/roles": func(ctx context.Context, id string) (interface{}, error) {
+			return roleService.GetRole(ctx, id)
+		},
+	})
+	if cfg.Audit.RetentionDays > 0 {
+		jobs.NewAuditRetentionJob(auditService).Start(24 * time.Hour)
+	}
+
 	// Initialize handlers
-	cruiseHandler := handler.NewCruiseHandler(cruiseService)
+	cruiseHandler := handler.NewCruiseHandler(cruiseService, currencyService)
 	cabinTypeHandler := handler.NewCabinTypeHandler(cabinTypeService)
-	facilityHandler := handler.NewFacilityHandler(facilityService)
+	facilityHandler := handler.NewFacilityHandler(facilityService, currencyService)
 	facilityCategoryHandler := handler.NewFacilityCategoryHandler(facilityCategoryService)
-	authHandler := handler.NewAuthHandler(&cfg.JWT, userRepo, rbac, tokenBlacklist)
+	authHandler := handler.NewAuthHandler(&cfg.JWT, staffService, tokenBlacklist)
 	userHandler := handler.NewUserHandler(wechatAuthService, smsService, userRepo)
+	identityHandler := handler.NewIdentityHandler(identityService)
 	orderHandler := handler.NewOrderHandler(orderService)
 	orderQueryHandler := handler.NewOrderQueryHandler(orderService, orderRepo)
-	paymentHandler := handler.NewPaymentHandler(paymentService)
+	paymentHandler := handler.NewPaymentHandler(paymentService, paymentCallbackService)
+	refundCallbackHandler := handler.NewRefundCallbackHandler(paymentCallbackService)
+	refundHandler := handler.NewRefundHandler(refundService, orderService)
+	invoiceHandler := handler.NewInvoiceHandler(invoiceService, orderService)
+	realtimeHandler := handler.NewRealtimeHandler(hub, presence, inventoryRepo, &cfg.CORS)
+	currencyHandler := handler.NewCurrencyHandler(currencyService, priceService)
+	couponHandler := handler.NewCouponHandler(couponService)
+	priceWatchHandler := handler.NewPriceWatchHandler(priceWatchService)
+	notificationHandler := handler.NewNotificationHandler(notificationService)
 
 	// Initialize admin handlers
 	adminHandlers := &AdminHandlers{
@@ -150,10 +402,24 @@ func setupRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config) {
 		AdminCabinType:        handler.NewAdminCabinTypeHandler(cabinTypeService),
 		AdminFacility:         handler.NewAdminFacilityHandler(facilityService),
 		AdminFacilityCategory: handler.NewAdminFacilityCategoryHandler(facilityCategoryService),
+		AdminPricing:          handler.NewAdminPricingHandler(pricingEngine),
+		AdminBulkPrice:        handler.NewAdminBulkPriceHandler(bulkPriceService),
+		AdminCoupon:           handler.NewAdminCouponHandler(couponService),
+		AdminPromotion:        handler.NewAdminPromotionHandler(promotionService, analytics.NewCampaignAnalysis(promotionRepo)),
+		AdminReconciliation:   handler.NewAdminReconciliationHandler(service.NewReconciliationService(reconciliationRepo)),
+		AdminRefundPolicy:     handler.NewAdminRefundPolicyHandler(refundPolicyService),
+		AdminOutbox:           handler.NewAdminOutboxHandler(outboxService),
+		AdminPaymentCallback:  handler.NewAdminPaymentCallbackHandler(paymentCallbackService),
+		AdminInvoice:          handler.NewAdminInvoiceHandler(invoiceService),
+		AdminOrder:            handler.NewAdminOrderHandler(orderService, refundService, orderRepo),
+		AdminUser:             handler.NewAdminUserHandler(userRepo),
+		AdminStaff:            handler.NewAdminStaffHandler(staffService),
+		AdminAuditLog:         handler.NewAdminAuditLogHandler(auditService),
+		AdminRole:             handler.NewAdminRoleHandler(roleService),
 	}
 
 	// Setup admin routes
-	setupAdminRoutes(r, adminHandlers, cfg)
+	setupAdminRoutes(r, adminHandlers, cfg, rbac, staffService, auditService)
 
 	// API v1 group
 	v1 := r.Group("/api/v1")
@@ -198,23 +464,48 @@ func setupRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config) {
 			orders.POST("/:id/cancel", orderHandler.Cancel)
 			orders.POST("/:id/confirm", orderHandler.Confirm)
 			orders.POST("/:id/complete", orderHandler.Complete)
+			orders.GET("/:id/refund-preview", refundHandler.Preview)
+			orders.POST("/:id/refunds", refundHandler.Create)
+			orders.GET("/:id/invoices", invoiceHandler.List)
+			orders.POST("/:id/invoices", invoiceHandler.Request)
 			orders.DELETE("/:id", orderHandler.Delete)
 		}
 
 		payments := v1.Group("/payments")
 		{
 			payments.POST("/callback/wechat", paymentHandler.WechatCallback)
+			payments.POST("/callback/alipay", paymentHandler.AlipayCallback)
+			payments.POST("/callback/wechat/refund", refundCallbackHandler.WechatCallback)
+			payments.POST("/callback/alipay/refund", refundCallbackHandler.AlipayCallback)
 
 			paymentsProtected := payments.Group("")
 			paymentsProtected.Use(middleware.JWTAuth(&cfg.JWT))
 			{
 				paymentsProtected.POST("", paymentHandler.Create)
 				paymentsProtected.GET("/:id", paymentHandler.Query)
+				paymentsProtected.GET("/:id/qrcode", paymentHandler.QRCode)
 				paymentsProtected.GET("/order/:orderId", paymentHandler.GetByOrder)
+				paymentsProtected.GET("/order/:orderId/all", paymentHandler.ListByOrder)
 				paymentsProtected.POST("/:id/refund", paymentHandler.Refund)
 			}
 		}
 
+		// Dev-only sandbox payment controls
+		if sandboxPaymentHandler != nil {
+			dev := v1.Group("/dev/payments")
+			{
+				dev.POST("/:paymentNo/simulate", sandboxPaymentHandler.Simulate)
+				dev.POST("/:paymentNo/refund-outcome", sandboxPaymentHandler.SetRefundOutcome)
+			}
+		}
+
+		// Currencies and converted catalog prices
+		v1.GET("/currencies", currencyHandler.List)
+		v1.GET("/voyages/:id/prices", currencyHandler.ListVoyagePrices)
+
+		// Realtime availability and viewer counts
+		v1.GET("/ws/voyages/:id", realtimeHandler.VoyageStream)
+
 		user := v1.Group("/user")
 		user.Use(middleware.JWTAuth(&cfg.JWT))
 		{
@@ -224,8 +515,21 @@ func setupRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config) {
 			user.POST("/passengers", userHandler.CreateFrequentPassenger)
 			user.PUT("/passengers/:id", userHandler.UpdateFrequentPassenger)
 			user.DELETE("/passengers/:id", userHandler.DeleteFrequentPassenger)
+			user.GET("/identities", identityHandler.List)
+			user.GET("/identities/logs", identityHandler.Logs)
+			user.POST("/identities/wechat", identityHandler.BindWechat)
+			user.POST("/identities/phone", identityHandler.BindPhone)
+			user.POST("/identities/alipay", identityHandler.BindAlipay)
+			user.DELETE("/identities/:type", identityHandler.Unbind)
+			user.GET("/coupons", couponHandler.ListWallet)
+			user.POST("/coupons/claim", couponHandler.Claim)
+			user.GET("/price-watches", priceWatchHandler.List)
+			user.POST("/price-watches", priceWatchHandler.Create)
+			user.DELETE("/price-watches/:id", priceWatchHandler.Delete)
 		}
 
+		notificationHandler.RegisterRoutes(v1, middleware.JWTAuth(&cfg.JWT))
+
 		// Cruise routes
 		cruises := v1.Group("/cruises")
 		{
@@ -0,0 +1,120 @@
+// Command backtest replays the price forecaster over departed voyages and
+// reports its error against what actually happened.
+//
+//	go run ./cmd/backtest -from 2025-01-01 -to 2026-01-01 -horizon 7 -cutoffs 120,90,60,30,14
+package main
+
+import (
+	"backend/internal/analytics"
+	"backend/internal/config"
+	"backend/internal/database"
+	"backend/internal/logger"
+	"backend/internal/repository"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+func main() {
+	now := time.Now()
+	from := flag.String("from", now.AddDate(-1, 0, 0).Format("2006-01-02"), "first departure date (inclusive)")
+	to := flag.String("to", now.Format("2006-01-02"), "last departure date (exclusive)")
+	horizon := flag.Int("horizon", analytics.DefaultForecastHorizonDays, "forecast horizon in days")
+	cutoffs := flag.String("cutoffs", joinInts(analytics.DefaultBacktestCutoffs), "comma-separated lead times in days")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	flag.Parse()
+
+	cfg := config.Load()
+	l := logger.New(cfg.LogLevel)
+	defer l.Sync()
+
+	req := analytics.BacktestRequest{HorizonDays: *horizon}
+	var err error
+	if req.From, err = time.ParseInLocation("2006-01-02", *from, time.Local); err != nil {
+		l.Fatalw("Invalid -from date", "error", err)
+	}
+	if req.To, err = time.ParseInLocation("2006-01-02", *to, time.Local); err != nil {
+		l.Fatalw("Invalid -to date", "error", err)
+	}
+	if req.Cutoffs, err = parseInts(*cutoffs); err != nil {
+		l.Fatalw("Invalid -cutoffs", "error", err)
+	}
+
+	db, err := database.New(cfg.Database)
+	if err != nil {
+		l.Fatalw("Failed to connect to database", "error", err)
+	}
+
+	forecaster := analytics.NewPriceForecaster(
+		repository.NewVoyageRepository(db),
+		repository.NewPriceRepository(db),
+		repository.NewPriceHistoryRepository(db),
+		repository.NewBookingHistoryRepository(db),
+	)
+
+	report, err := forecaster.Backtest(context.Background(), req)
+	if err != nil {
+		l.Fatalw("Backtest failed", "error", err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			l.Fatalw("Failed to encode report", "error", err)
+		}
+		return
+	}
+
+	printReport(report)
+}
+
+// printReport writes the report as a table
+func printReport(report *analytics.BacktestReport) {
+	fmt.Printf("Departures %s to %s, %d voyages, %d-day horizon, %.0f%% intervals\n\n",
+		report.From, report.To, report.Voyages, report.HorizonDays, report.IntervalLevel*100)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "lead\tforecasts\tskipped\tMAE\tMAPE%\tnaive MAPE%\tcoverage\tconfidence\twithin 5%\tsell-out\tBrier\t")
+	for _, c := range report.Cutoffs {
+		printRow(w, strconv.Itoa(c.LeadDays), c.BacktestMetrics)
+	}
+	printRow(w, "all", report.Overall)
+	w.Flush()
+}
+
+func printRow(w *tabwriter.Writer, label string, m analytics.BacktestMetrics) {
+	fmt.Fprintf(w, "%s\t%d\t%d\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\t%.4f\t\n",
+		label, m.Forecasts, m.Skipped, m.PriceMAE, m.PriceMAPE, m.NaiveMAPE,
+		m.IntervalCoverage, m.MeanConfidence, m.WithinTolerance, m.SellOutRate, m.SellOutBrier)
+}
+
+func parseInts(s string) ([]int, error) {
+	values := make([]int, 0)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		v, err := strconv.Atoi(part)
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("invalid lead time %q", part)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ",")
+}
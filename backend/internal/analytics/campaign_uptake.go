@@ -0,0 +1,105 @@
+package analytics
+
+import (
+	"backend/internal/repository"
+	"context"
+	"fmt"
+	"time"
+)
+
+// CampaignUptake reports bookings made on a promotion campaign's prices
+// while the campaign was running
+type CampaignUptake struct {
+	CampaignID     string               `json:"campaign_id"`
+	Name           string               `json:"name"`
+	Status         string               `json:"status"`
+	StartsAt       time.Time            `json:"starts_at"`
+	EndsAt         time.Time            `json:"ends_at"`
+	PromotedPrices int                  `json:"promoted_prices"`
+	BookedPrices   int                  `json:"booked_prices"`
+	UptakeRate     float64              `json:"uptake_rate"` // share of promoted prices with at least one booking
+	Orders         int64                `json:"orders"`
+	Cabins         int64                `json:"cabins"`
+	Passengers     int64                `json:"passengers"`
+	Revenue        float64              `json:"revenue"`
+	DiscountAmount float64              `json:"discount_amount"` // revenue given up against pre-campaign prices
+	Breakdown      []CampaignUptakeLine `json:"breakdown"`
+}
+
+// CampaignUptakeLine is the uptake of one promoted voyage and cabin type
+type CampaignUptakeLine struct {
+	VoyageID       string  `json:"voyage_id"`
+	CabinTypeID    string  `json:"cabin_type_id"`
+	Orders         int64   `json:"orders"`
+	Cabins         int64   `json:"cabins"`
+	Passengers     int64   `json:"passengers"`
+	Revenue        float64 `json:"revenue"`
+	DiscountAmount float64 `json:"discount_amount"`
+}
+
+// CampaignAnalysis defines the interface for promotion campaign analytics
+type CampaignAnalysis interface {
+	// Uptake reports the bookings attributed to a campaign
+	Uptake(ctx context.Context, campaignID string) (*CampaignUptake, error)
+}
+
+// campaignAnalysis implements CampaignAnalysis
+type campaignAnalysis struct {
+	promotionRepo repository.PromotionRepository
+}
+
+// NewCampaignAnalysis creates a new campaign analysis
+func NewCampaignAnalysis(promotionRepo repository.PromotionRepository) CampaignAnalysis {
+	return &campaignAnalysis{promotionRepo: promotionRepo}
+}
+
+func (a *campaignAnalysis) Uptake(ctx context.Context, campaignID string) (*CampaignUptake, error) {
+	campaign, err := a.promotionRepo.GetByID(ctx, campaignID)
+	if err != nil {
+		return nil, fmt.Errorf("campaign not found: %w", err)
+	}
+
+	prices, err := a.promotionRepo.ListPromotionPrices(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := a.promotionRepo.Uptake(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	uptake := &CampaignUptake{
+		CampaignID:     campaign.ID.String(),
+		Name:           campaign.Name,
+		Status:         campaign.Status,
+		StartsAt:       campaign.StartsAt,
+		EndsAt:         campaign.EndsAt,
+		PromotedPrices: len(prices),
+		BookedPrices:   len(rows),
+		Breakdown:      make([]CampaignUptakeLine, 0, len(rows)),
+	}
+	for _, row := range rows {
+		uptake.Orders += row.Orders
+		uptake.Cabins += row.Cabins
+		uptake.Passengers += row.Passengers
+		uptake.Revenue += row.Revenue
+		uptake.DiscountAmount += row.DiscountAmount
+		uptake.Breakdown = append(uptake.Breakdown, CampaignUptakeLine{
+			VoyageID:       row.VoyageID,
+			CabinTypeID:    row.CabinTypeID,
+			Orders:         row.Orders,
+			Cabins:         row.Cabins,
+			Passengers:     row.Passengers,
+			Revenue:        roundPrice(row.Revenue),
+			DiscountAmount: roundPrice(row.DiscountAmount),
+		})
+	}
+	uptake.Revenue = roundPrice(uptake.Revenue)
+	uptake.DiscountAmount = roundPrice(uptake.DiscountAmount)
+	if uptake.PromotedPrices > 0 {
+		uptake.UptakeRate = roundProbability(float64(uptake.BookedPrices) / float64(uptake.PromotedPrices))
+	}
+
+	return uptake, nil
+}
@@ -0,0 +1,265 @@
+package analytics
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// DefaultForecastHorizonDays is how far ahead prices are forecast
+	DefaultForecastHorizonDays = 7
+	// forecastHistoryWindow is how far back comparable voyages are taken from
+	forecastHistoryWindow = 2 * 365 * 24 * time.Hour
+)
+
+// DefaultBacktestCutoffs are the lead times (days before departure) forecasts are replayed at
+var DefaultBacktestCutoffs = []int{120, 90, 60, 30, 14}
+
+// ErrInvalidBacktestRange is returned when a backtest range is empty
+var ErrInvalidBacktestRange = errors.New("backtest range is empty")
+
+// PriceForecaster forecasts prices and sell-out from historical bookings and price history
+type PriceForecaster interface {
+	// Forecast forecasts the price of a voyage DefaultForecastHorizonDays ahead
+	Forecast(ctx context.Context, voyageID string) (*PriceForecast, error)
+
+	// Backtest replays the forecaster over departed voyages to measure its error
+	Backtest(ctx context.Context, req BacktestRequest) (*BacktestReport, error)
+}
+
+// BacktestRequest selects the voyages and lead times to backtest
+type BacktestRequest struct {
+	From        time.Time // first departure date, inclusive
+	To          time.Time // last departure date, exclusive
+	HorizonDays int
+	Cutoffs     []int
+}
+
+// priceForecaster implements PriceForecaster
+type priceForecaster struct {
+	voyageRepo  repository.VoyageRepository
+	priceRepo   repository.PriceRepository
+	historyRepo repository.PriceHistoryRepository
+	bookingRepo repository.BookingHistoryRepository
+	now         func() time.Time
+}
+
+// NewPriceForecaster creates a new price forecaster
+func NewPriceForecaster(
+	voyageRepo repository.VoyageRepository,
+	priceRepo repository.PriceRepository,
+	historyRepo repository.PriceHistoryRepository,
+	bookingRepo repository.BookingHistoryRepository,
+) PriceForecaster {
+	return &priceForecaster{
+		voyageRepo:  voyageRepo,
+		priceRepo:   priceRepo,
+		historyRepo: historyRepo,
+		bookingRepo: bookingRepo,
+		now:         time.Now,
+	}
+}
+
+// Forecast forecasts the price of a voyage DefaultForecastHorizonDays ahead
+func (f *priceForecaster) Forecast(ctx context.Context, voyageID string) (*PriceForecast, error) {
+	voyage, err := f.voyageRepo.GetByID(ctx, voyageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get voyage: %w", err)
+	}
+
+	now := f.now()
+	horizon := DefaultForecastHorizonDays
+	forecast := &PriceForecast{
+		VoyageID:      voyageID,
+		IntervalLevel: forecastIntervalLevel,
+		Trend:         "stable",
+		Factors:       []ForecastFactor{},
+		CabinTypes:    []CabinTypePriceForecast{},
+		ForecastDate:  now.AddDate(0, 0, horizon).Format("2006-01-02"),
+	}
+
+	// The current price comes from the live rows so a voyage without history still reports it
+	prices, err := f.priceRepo.ListByVoyage(ctx, voyageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prices: %w", err)
+	}
+	for _, p := range prices {
+		if forecast.CurrentPrice == 0 || p.AdultPrice < forecast.CurrentPrice {
+			forecast.CurrentPrice = p.AdultPrice
+		}
+	}
+	forecast.ForecastPrice = forecast.CurrentPrice
+
+	comparables, err := f.bookingRepo.ListVoyages(ctx, "",
+		now.Add(-forecastHistoryWindow).Format("2006-01-02"), now.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comparable voyages: %w", err)
+	}
+	observations, err := f.loadObservations(ctx, append(comparables, voyage))
+	if err != nil {
+		return nil, err
+	}
+
+	var lead *forecastResult
+	for i := range observations {
+		target := &observations[i]
+		if target.VoyageID != voyageID {
+			continue
+		}
+		result, ok := forecastObservation(target.asOf(now), observations, now, horizon)
+		if !ok {
+			continue
+		}
+		forecast.CabinTypes = append(forecast.CabinTypes, CabinTypePriceForecast{
+			CabinTypeID:        target.CabinTypeID,
+			CurrentPrice:       result.CurrentPrice,
+			ForecastPrice:      roundPrice(result.ForecastPrice),
+			ForecastLow:        roundPrice(result.Lower),
+			ForecastHigh:       roundPrice(result.Upper),
+			Confidence:         roundProbability(result.Confidence),
+			SellOutProbability: roundProbability(result.SellOutProbability),
+			ExpectedLoadFactor: roundProbability(result.ExpectedLoad),
+			Samples:            result.Samples,
+			Segment:            result.Segment,
+		})
+		if lead == nil || result.CurrentPrice < lead.CurrentPrice {
+			r := result
+			lead = &r
+		}
+	}
+
+	sort.Slice(forecast.CabinTypes, func(i, j int) bool {
+		return forecast.CabinTypes[i].CurrentPrice < forecast.CabinTypes[j].CurrentPrice
+	})
+
+	// Without enough comparable history the forecast stays at the current
+	// price with zero confidence rather than guessing
+	if lead == nil {
+		return forecast, nil
+	}
+
+	forecast.CurrentPrice = lead.CurrentPrice
+	forecast.ForecastPrice = roundPrice(lead.ForecastPrice)
+	forecast.ForecastLow = roundPrice(lead.Lower)
+	forecast.ForecastHigh = roundPrice(lead.Upper)
+	forecast.Confidence = roundProbability(lead.Confidence)
+	forecast.Trend = trendDirection(lead.CurrentPrice, lead.ForecastPrice)
+	forecast.SellOutProbability = roundProbability(lead.SellOutProbability)
+	forecast.ExpectedLoadFactor = roundProbability(lead.ExpectedLoad)
+	forecast.Samples = lead.Samples
+	forecast.Segment = lead.Segment
+	forecast.Factors = []ForecastFactor{
+		{Factor: "booking_pace", Impact: roundProbability(math.Tanh(lead.PaceDeviation / 2))},
+		{Factor: "price_elasticity", Impact: roundProbability(lead.Elasticity / 3)},
+		{Factor: "lead_time_drift", Impact: roundProbability(math.Tanh(lead.PriceDrift * 10))},
+	}
+
+	return forecast, nil
+}
+
+// Backtest replays the forecaster over departed voyages to measure its error
+func (f *priceForecaster) Backtest(ctx context.Context, req BacktestRequest) (*BacktestReport, error) {
+	if !req.From.Before(req.To) {
+		return nil, ErrInvalidBacktestRange
+	}
+	if req.HorizonDays <= 0 {
+		req.HorizonDays = DefaultForecastHorizonDays
+	}
+	if len(req.Cutoffs) == 0 {
+		req.Cutoffs = DefaultBacktestCutoffs
+	}
+
+	voyages, err := f.bookingRepo.ListVoyages(ctx, "",
+		req.From.Add(-forecastHistoryWindow).Format("2006-01-02"), req.To.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get voyages: %w", err)
+	}
+	observations, err := f.loadObservations(ctx, voyages)
+	if err != nil {
+		return nil, err
+	}
+
+	return backtest(observations, req.From, req.To, req.Cutoffs, req.HorizonDays, forecastHistoryWindow), nil
+}
+
+// loadObservations loads capacity, bookings and price history per voyage and cabin type
+func (f *priceForecaster) loadObservations(ctx context.Context, voyages []*domain.Voyage) ([]voyageObservation, error) {
+	ids := make([]string, 0, len(voyages))
+	byID := make(map[string]*domain.Voyage, len(voyages))
+	for _, v := range voyages {
+		id := v.ID.String()
+		if _, seen := byID[id]; seen {
+			continue
+		}
+		ids = append(ids, id)
+		byID[id] = v
+	}
+
+	inventories, err := f.bookingRepo.ListCapacity(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get capacity: %w", err)
+	}
+	bookings, err := f.bookingRepo.ListBookings(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bookings: %w", err)
+	}
+	history, err := f.historyRepo.ListByVoyages(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price history: %w", err)
+	}
+
+	bookedAt := make(map[string][]time.Time)
+	for _, b := range bookings {
+		key := b.VoyageID + "|" + b.CabinTypeID
+		bookedAt[key] = append(bookedAt[key], b.BookedAt)
+	}
+	versions := make(map[string][]*domain.CabinPriceHistory)
+	for _, h := range history {
+		key := h.VoyageID + "|" + h.CabinTypeID
+		versions[key] = append(versions[key], h)
+	}
+
+	observations := make([]voyageObservation, 0, len(inventories))
+	for _, inv := range inventories {
+		voyage, ok := byID[inv.VoyageID]
+		if !ok {
+			continue
+		}
+		departure, err := parseDate(voyage.DepartureDate)
+		if err != nil {
+			continue
+		}
+
+		key := inv.VoyageID + "|" + inv.CabinTypeID
+		times := bookedAt[key]
+		sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+		observations = append(observations, voyageObservation{
+			VoyageID:    inv.VoyageID,
+			RouteID:     voyage.RouteID,
+			CabinTypeID: inv.CabinTypeID,
+			Departure:   departure,
+			Capacity:    inv.TotalCabins,
+			Bookings:    times,
+			Prices:      buildPriceSeries(versions[key]),
+		})
+	}
+
+	return observations, nil
+}
+
+// roundPrice rounds to cents
+func roundPrice(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// roundProbability rounds to four decimals
+func roundProbability(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
@@ -0,0 +1,442 @@
+package analytics
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// minForecastSamples is the fewest comparable voyages a forecast is fitted on
+	minForecastSamples = 3
+	// minRegressionSamples is the fewest voyages a slope is estimated from
+	minRegressionSamples = 5
+	// forecastIntervalLevel is the nominal coverage of the price interval
+	forecastIntervalLevel = 0.8
+	// forecastIntervalZ is the normal quantile for forecastIntervalLevel
+	forecastIntervalZ = 1.2816
+	// forecastTolerance is the relative error Confidence is measured against
+	forecastTolerance = 0.05
+)
+
+// Forecast segments, from most to least specific
+const (
+	SegmentRouteCabinType = "route_cabin_type"
+	SegmentRoute          = "route"
+	SegmentCabinType      = "cabin_type"
+)
+
+// voyageObservation is the booking and price history of one cabin type on one voyage
+type voyageObservation struct {
+	VoyageID    string
+	RouteID     string
+	CabinTypeID string
+	Departure   time.Time
+	Capacity    int
+	Bookings    []time.Time  // sorted booking times, one per cabin
+	Prices      []PricePoint // daily lowest adult price
+}
+
+// loadAt returns the share of capacity booked by the given time
+func (o *voyageObservation) loadAt(at time.Time) float64 {
+	if o.Capacity <= 0 {
+		return 0
+	}
+	n := sort.Search(len(o.Bookings), func(i int) bool { return o.Bookings[i].After(at) })
+	return float64(n) / float64(o.Capacity)
+}
+
+// leadTime returns the moment the given number of days before departure
+func (o *voyageObservation) leadTime(days int) time.Time {
+	return o.Departure.AddDate(0, 0, -days)
+}
+
+// asOf returns the observation as it looked at the given time
+func (o *voyageObservation) asOf(at time.Time) voyageObservation {
+	seen := *o
+	n := sort.Search(len(o.Bookings), func(i int) bool { return o.Bookings[i].After(at) })
+	seen.Bookings = o.Bookings[:n]
+	day := at.Format("2006-01-02")
+	p := sort.Search(len(o.Prices), func(i int) bool { return o.Prices[i].Date > day })
+	seen.Prices = o.Prices[:p]
+	return seen
+}
+
+// meanPrice returns the average of the daily prices seen so far
+func (o *voyageObservation) meanPrice() float64 {
+	values := make([]float64, len(o.Prices))
+	for i, p := range o.Prices {
+		values[i] = p.Price
+	}
+	return mean(values)
+}
+
+// forecastResult is the model output for one cabin type of one voyage
+type forecastResult struct {
+	CurrentPrice       float64
+	ForecastPrice      float64
+	Lower              float64
+	Upper              float64
+	Confidence         float64
+	ExpectedLoad       float64
+	SellOutProbability float64
+	PaceDeviation      float64 // booking pace versus comparable voyages, in standard deviations
+	Elasticity         float64 // relative change in pickup per log change in relative price
+	PriceDrift         float64 // expected log price change over the horizon
+	Samples            int
+	Segment            string
+}
+
+// comparableSample is a historical voyage evaluated at the target's lead time
+type comparableSample struct {
+	load     float64 // load at the lead time
+	pickup   float64 // load gained from the lead time to departure
+	relPrice float64 // price at the lead time relative to the voyage mean
+	drift    float64 // log price change over the horizon
+	hasPrice bool
+}
+
+// forecastObservation forecasts a target voyage as seen at asOf from voyages
+// that had already departed. It fits three things on comparable voyages at
+// the same lead time: the booking curve pickup to departure, the price
+// elasticity of that pickup, and the price drift over the horizon.
+func forecastObservation(target voyageObservation, history []voyageObservation, asOf time.Time, horizonDays int) (forecastResult, bool) {
+	current, ok := priceAt(target.Prices, asOf)
+	if !ok || current <= 0 || target.Capacity <= 0 || !asOf.Before(target.Departure) {
+		return forecastResult{}, false
+	}
+
+	lead := int(target.Departure.Sub(asOf).Hours() / 24)
+	if horizonDays > lead {
+		horizonDays = lead
+	}
+
+	comparables, segment := selectComparables(target, history, asOf)
+	if len(comparables) < minForecastSamples {
+		return forecastResult{}, false
+	}
+
+	samples := make([]comparableSample, 0, len(comparables))
+	for i := range comparables {
+		h := &comparables[i]
+		at := h.leadTime(lead)
+		s := comparableSample{
+			load:   h.loadAt(at),
+			pickup: h.loadAt(h.Departure) - h.loadAt(at),
+		}
+		if p, ok := priceAt(h.Prices, at); ok && p > 0 {
+			if later, ok := priceAt(h.Prices, h.leadTime(lead-horizonDays)); ok && later > 0 {
+				s.drift = math.Log(later / p)
+				s.hasPrice = true
+			}
+			if avg := h.meanPrice(); avg > 0 {
+				s.relPrice = p / avg
+			}
+		}
+		samples = append(samples, s)
+	}
+
+	result := forecastResult{
+		CurrentPrice: current,
+		Samples:      len(samples),
+		Segment:      segment,
+	}
+
+	// Booking pace relative to the booking curve at this lead time
+	loads := make([]float64, len(samples))
+	pickups := make([]float64, len(samples))
+	for i, s := range samples {
+		loads[i] = s.load
+		pickups[i] = s.pickup
+	}
+	load := target.loadAt(asOf)
+	pace := load - mean(loads)
+	result.PaceDeviation = pace / math.Max(sampleStd(loads), 0.01)
+
+	// Elasticity of pickup to the price position, never positive: dynamic
+	// pricing raises prices on strong demand, which would bias it upwards
+	targetRel := 1.0
+	if avg := target.meanPrice(); avg > 0 {
+		targetRel = current / avg
+	}
+	meanPickup := mean(pickups)
+	if meanPickup > 0 {
+		var xs, ys []float64
+		for _, s := range samples {
+			if s.relPrice > 0 {
+				xs = append(xs, math.Log(s.relPrice))
+				ys = append(ys, s.pickup/meanPickup-1)
+			}
+		}
+		if len(xs) >= minRegressionSamples {
+			if _, slope, _, ok := linearFit(xs, ys); ok {
+				result.Elasticity = math.Max(-3, math.Min(0, slope))
+			}
+		}
+	}
+
+	// Sell-out probability from the price-adjusted pickup distribution
+	expectedPickup := math.Max(0, meanPickup*(1+result.Elasticity*math.Log(targetRel)))
+	final := load + expectedPickup
+	pickupSD := math.Max(sampleStd(pickups)*math.Sqrt(1+1/float64(len(pickups))), 0.01)
+	result.ExpectedLoad = math.Min(final, 1)
+	if load >= 1 {
+		result.SellOutProbability = 1
+	} else {
+		result.SellOutProbability = 1 - normalCDF((1-final)/pickupSD)
+	}
+
+	// Price drift over the horizon, adjusted for booking pace when there is
+	// enough data to estimate the slope
+	var paces, drifts []float64
+	for _, s := range samples {
+		if s.hasPrice {
+			paces = append(paces, s.load-mean(loads))
+			drifts = append(drifts, s.drift)
+		}
+	}
+	if len(drifts) < minForecastSamples {
+		return forecastResult{}, false
+	}
+
+	drift := mean(drifts)
+	residualSD := sampleStd(drifts)
+	if len(drifts) >= minRegressionSamples {
+		if intercept, slope, sse, ok := linearFit(paces, drifts); ok {
+			drift = intercept + slope*pace
+			residualSD = math.Sqrt(sse / float64(len(drifts)-2))
+		}
+	}
+	// Floor the spread so a handful of identical voyages is not taken as certainty
+	predictionSD := math.Max(residualSD*math.Sqrt(1+1/float64(len(drifts))), 0.005)
+
+	result.PriceDrift = drift
+	result.ForecastPrice = current * math.Exp(drift)
+	result.Lower = current * math.Exp(drift-forecastIntervalZ*predictionSD)
+	result.Upper = current * math.Exp(drift+forecastIntervalZ*predictionSD)
+	result.Confidence = normalCDF(math.Log(1+forecastTolerance)/predictionSD) -
+		normalCDF(math.Log(1-forecastTolerance)/predictionSD)
+	result.Samples = len(drifts)
+
+	return result, true
+}
+
+// selectComparables returns the departed voyages of the most specific segment
+// that has enough samples
+func selectComparables(target voyageObservation, history []voyageObservation, asOf time.Time) ([]voyageObservation, string) {
+	segments := []struct {
+		name  string
+		match func(h *voyageObservation) bool
+	}{
+		{SegmentRouteCabinType, func(h *voyageObservation) bool {
+			return h.RouteID == target.RouteID && h.CabinTypeID == target.CabinTypeID
+		}},
+		{SegmentRoute, func(h *voyageObservation) bool { return h.RouteID == target.RouteID }},
+		{SegmentCabinType, func(h *voyageObservation) bool { return h.CabinTypeID == target.CabinTypeID }},
+	}
+
+	for _, segment := range segments {
+		matched := make([]voyageObservation, 0)
+		for i := range history {
+			h := &history[i]
+			if h.VoyageID == target.VoyageID || h.Capacity <= 0 || !h.Departure.Before(asOf) {
+				continue
+			}
+			if segment.match(h) {
+				matched = append(matched, *h)
+			}
+		}
+		if len(matched) >= minForecastSamples {
+			return matched, segment.name
+		}
+	}
+	return nil, ""
+}
+
+// BacktestMetrics summarises forecast error over a set of forecasts
+type BacktestMetrics struct {
+	Forecasts        int     `json:"forecasts"`
+	Skipped          int     `json:"skipped"`           // not enough history or no actual price
+	PriceMAE         float64 `json:"price_mae"`         // mean absolute error
+	PriceMAPE        float64 `json:"price_mape"`        // mean absolute percentage error
+	NaiveMAPE        float64 `json:"naive_mape"`        // MAPE of assuming no price change
+	IntervalCoverage float64 `json:"interval_coverage"` // share of actual prices inside the interval
+	SellOutBrier     float64 `json:"sell_out_brier"`    // Brier score of the sell-out probability
+	SellOutRate      float64 `json:"sell_out_rate"`     // observed share of sold-out cabin types
+	MeanConfidence   float64 `json:"mean_confidence"`   // average stated confidence
+	WithinTolerance  float64 `json:"within_tolerance"`  // observed share within the confidence tolerance
+}
+
+// BacktestCutoff holds the metrics of forecasts made at one lead time
+type BacktestCutoff struct {
+	LeadDays int `json:"lead_days"`
+	BacktestMetrics
+}
+
+// BacktestReport is the result of replaying the forecaster over past voyages
+type BacktestReport struct {
+	From          string           `json:"from"`
+	To            string           `json:"to"`
+	HorizonDays   int              `json:"horizon_days"`
+	IntervalLevel float64          `json:"interval_level"`
+	Voyages       int              `json:"voyages"`
+	Cutoffs       []BacktestCutoff `json:"cutoffs"`
+	Overall       BacktestMetrics  `json:"overall"`
+}
+
+// metricsAccumulator sums forecast errors before averaging
+type metricsAccumulator struct {
+	n, skipped                                     int
+	absErr, absPctErr, naivePctErr, covered, brier float64
+	soldOut, confidence, withinTolerance           float64
+}
+
+func (m *metricsAccumulator) add(r forecastResult, actualPrice float64, soldOut bool) {
+	m.n++
+	m.absErr += math.Abs(r.ForecastPrice - actualPrice)
+	m.absPctErr += math.Abs(r.ForecastPrice-actualPrice) / actualPrice
+	m.naivePctErr += math.Abs(r.CurrentPrice-actualPrice) / actualPrice
+	if actualPrice >= r.Lower && actualPrice <= r.Upper {
+		m.covered++
+	}
+	outcome := 0.0
+	if soldOut {
+		outcome = 1
+		m.soldOut++
+	}
+	m.brier += (r.SellOutProbability - outcome) * (r.SellOutProbability - outcome)
+	m.confidence += r.Confidence
+	if math.Abs(actualPrice/r.ForecastPrice-1) < forecastTolerance {
+		m.withinTolerance++
+	}
+}
+
+func (m *metricsAccumulator) metrics() BacktestMetrics {
+	metrics := BacktestMetrics{Forecasts: m.n, Skipped: m.skipped}
+	if m.n == 0 {
+		return metrics
+	}
+	n := float64(m.n)
+	metrics.PriceMAE = m.absErr / n
+	metrics.PriceMAPE = m.absPctErr / n * 100
+	metrics.NaiveMAPE = m.naivePctErr / n * 100
+	metrics.IntervalCoverage = m.covered / n
+	metrics.SellOutBrier = m.brier / n
+	metrics.SellOutRate = m.soldOut / n
+	metrics.MeanConfidence = m.confidence / n
+	metrics.WithinTolerance = m.withinTolerance / n
+	return metrics
+}
+
+// backtest replays forecasts for voyages departing in [from, to) at each
+// cutoff lead time, using only data that existed at the cutoff
+func backtest(observations []voyageObservation, from, to time.Time, cutoffs []int, horizonDays int, window time.Duration) *BacktestReport {
+	report := &BacktestReport{
+		From:          from.Format("2006-01-02"),
+		To:            to.Format("2006-01-02"),
+		HorizonDays:   horizonDays,
+		IntervalLevel: forecastIntervalLevel,
+	}
+
+	overall := &metricsAccumulator{}
+	byCutoff := make([]*metricsAccumulator, len(cutoffs))
+	for i := range byCutoff {
+		byCutoff[i] = &metricsAccumulator{}
+	}
+
+	voyages := make(map[string]bool)
+	for i := range observations {
+		target := &observations[i]
+		if target.Departure.Before(from) || !target.Departure.Before(to) || target.Capacity <= 0 {
+			continue
+		}
+		voyages[target.VoyageID] = true
+
+		for c, lead := range cutoffs {
+			if lead <= horizonDays {
+				continue
+			}
+			asOf := target.leadTime(lead)
+			history := make([]voyageObservation, 0)
+			for j := range observations {
+				h := &observations[j]
+				if h.Departure.Before(asOf) && !h.Departure.Before(asOf.Add(-window)) {
+					history = append(history, *h)
+				}
+			}
+
+			result, ok := forecastObservation(target.asOf(asOf), history, asOf, horizonDays)
+			actual, hasActual := priceAt(target.Prices, asOf.AddDate(0, 0, horizonDays))
+			if !ok || !hasActual || actual <= 0 {
+				byCutoff[c].skipped++
+				overall.skipped++
+				continue
+			}
+
+			soldOut := target.loadAt(target.Departure) >= 1
+			byCutoff[c].add(result, actual, soldOut)
+			overall.add(result, actual, soldOut)
+		}
+	}
+
+	report.Voyages = len(voyages)
+	for i, lead := range cutoffs {
+		report.Cutoffs = append(report.Cutoffs, BacktestCutoff{LeadDays: lead, BacktestMetrics: byCutoff[i].metrics()})
+	}
+	report.Overall = overall.metrics()
+	return report
+}
+
+// mean returns the arithmetic mean, or 0 for no values
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// sampleStd returns the sample standard deviation, or 0 for fewer than two values
+func sampleStd(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	m := mean(values)
+	var ss float64
+	for _, v := range values {
+		ss += (v - m) * (v - m)
+	}
+	return math.Sqrt(ss / float64(len(values)-1))
+}
+
+// linearFit fits y = intercept + slope*x by least squares and returns the
+// residual sum of squares; ok is false when x has no variance
+func linearFit(xs, ys []float64) (intercept, slope, sse float64, ok bool) {
+	if len(xs) != len(ys) || len(xs) < 3 {
+		return 0, 0, 0, false
+	}
+	mx, my := mean(xs), mean(ys)
+	var sxx, sxy float64
+	for i := range xs {
+		sxx += (xs[i] - mx) * (xs[i] - mx)
+		sxy += (xs[i] - mx) * (ys[i] - my)
+	}
+	if sxx < 1e-12 {
+		return 0, 0, 0, false
+	}
+	slope = sxy / sxx
+	intercept = my - slope*mx
+	for i := range xs {
+		r := ys[i] - intercept - slope*xs[i]
+		sse += r * r
+	}
+	return intercept, slope, sse, true
+}
+
+// normalCDF is the standard normal cumulative distribution function
+func normalCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
@@ -0,0 +1,83 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// syntheticVoyage books one cabin every 10 days from 95 days out and raises
+// the price by 10% 25 days before departure
+func syntheticVoyage(routeID, cabinTypeID string, departure time.Time, bookings int) voyageObservation {
+	obs := voyageObservation{
+		VoyageID:    uuid.New().String(),
+		RouteID:     routeID,
+		CabinTypeID: cabinTypeID,
+		Departure:   departure,
+		Capacity:    10,
+		Prices: []PricePoint{
+			{Date: departure.AddDate(0, 0, -120).Format("2006-01-02"), Price: 1000},
+			{Date: departure.AddDate(0, 0, -25).Format("2006-01-02"), Price: 1100},
+		},
+	}
+	for i := 0; i < bookings; i++ {
+		obs.Bookings = append(obs.Bookings, departure.AddDate(0, 0, -95+i*10))
+	}
+	return obs
+}
+
+func TestForecastObservation(t *testing.T) {
+	routeID, cabinTypeID := uuid.New().String(), uuid.New().String()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local)
+
+	history := make([]voyageObservation, 0)
+	for i := 0; i < 6; i++ {
+		// Alternate full and nearly full sailings so pickup has spread
+		history = append(history, syntheticVoyage(routeID, cabinTypeID, start.AddDate(0, 0, i*7), 9+i%2))
+	}
+	target := syntheticVoyage(routeID, cabinTypeID, start.AddDate(1, 0, 0), 10)
+	asOf := target.leadTime(30)
+
+	t.Run("fits drift and pickup on comparable voyages", func(t *testing.T) {
+		result, ok := forecastObservation(target.asOf(asOf), history, asOf, 7)
+
+		assert.True(t, ok)
+		assert.Equal(t, SegmentRouteCabinType, result.Segment)
+		assert.Equal(t, 6, result.Samples)
+		assert.Equal(t, 1000.0, result.CurrentPrice)
+		assert.InDelta(t, 1100.0, result.ForecastPrice, 0.01)
+		assert.LessOrEqual(t, result.Lower, result.ForecastPrice)
+		assert.GreaterOrEqual(t, result.Upper, result.ForecastPrice)
+		assert.InDelta(t, 0.95, result.ExpectedLoad, 0.001)
+		assert.Greater(t, result.SellOutProbability, 0.0)
+		assert.Less(t, result.SellOutProbability, 1.0)
+	})
+
+	t.Run("ignores voyages that had not departed", func(t *testing.T) {
+		_, ok := forecastObservation(target.asOf(asOf), history, start.AddDate(0, 0, 10), 7)
+
+		assert.False(t, ok)
+	})
+
+	t.Run("falls back to the route segment", func(t *testing.T) {
+		other := syntheticVoyage(routeID, uuid.New().String(), target.Departure, 10)
+
+		result, ok := forecastObservation(other.asOf(asOf), history, asOf, 7)
+
+		assert.True(t, ok)
+		assert.Equal(t, SegmentRoute, result.Segment)
+	})
+
+	t.Run("backtest replays departed voyages", func(t *testing.T) {
+		observations := append(history, syntheticVoyage(routeID, cabinTypeID, start.AddDate(0, 3, 0), 10))
+
+		report := backtest(observations, start.AddDate(0, 2, 0), start.AddDate(0, 4, 0), []int{60, 30}, 7, forecastHistoryWindow)
+
+		assert.Equal(t, 1, report.Voyages)
+		assert.Equal(t, 2, report.Overall.Forecasts)
+		assert.Equal(t, 1.0, report.Overall.IntervalCoverage)
+		assert.Equal(t, 1.0, report.Overall.SellOutRate)
+	})
+}
@@ -0,0 +1,199 @@
+package analytics
+
+import (
+	"backend/internal/domain"
+	"sort"
+	"time"
+)
+
+// leadWindows are the booking lead-time buckets (days before departure)
+// compared by GetBestBookingWindow
+var leadWindows = [][2]int{
+	{0, 14},
+	{15, 30},
+	{31, 60},
+	{61, 90},
+	{91, 180},
+	{181, 365},
+}
+
+// leadWindow is the price behaviour observed in one lead-time bucket
+type leadWindow struct {
+	MinDays    int
+	MaxDays    int
+	Samples    int     // voyages contributing to the bucket
+	AvgPrice   float64 // average lowest price within the bucket
+	SavingsPct float64 // saving versus the average over all buckets
+}
+
+// priceEvent is the start or end of a price version
+type priceEvent struct {
+	at      time.Time
+	priceID string
+	price   float64
+	end     bool
+}
+
+// buildPriceSeries turns price versions into a daily series of the lowest
+// adult price across all cabin prices of a voyage
+func buildPriceSeries(history []*domain.CabinPriceHistory) []PricePoint {
+	events := make([]priceEvent, 0, len(history)*2)
+	for _, h := range history {
+		events = append(events, priceEvent{at: h.EffectiveFrom, priceID: h.PriceID, price: h.AdultPrice})
+		if h.EffectiveTo != nil {
+			events = append(events, priceEvent{at: *h.EffectiveTo, priceID: h.PriceID, end: true})
+		}
+	}
+
+	// Ends sort before starts at the same instant so a new version replaces the old
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].at.Equal(events[j].at) {
+			return events[i].end && !events[j].end
+		}
+		return events[i].at.Before(events[j].at)
+	})
+
+	current := make(map[string]float64)
+	points := make([]PricePoint, 0)
+	for i, ev := range events {
+		if ev.end {
+			delete(current, ev.priceID)
+		} else {
+			current[ev.priceID] = ev.price
+		}
+
+		// Emit once all events at this instant are applied
+		if i+1 < len(events) && events[i+1].at.Equal(ev.at) {
+			continue
+		}
+		if len(current) == 0 {
+			continue
+		}
+
+		var low float64
+		for _, p := range current {
+			if low == 0 || p < low {
+				low = p
+			}
+		}
+
+		point := PricePoint{Date: ev.at.Format("2006-01-02"), Price: low}
+		if n := len(points); n > 0 && points[n-1].Date == point.Date {
+			points[n-1] = point // keep the end-of-day price
+		} else {
+			points = append(points, point)
+		}
+	}
+
+	return points
+}
+
+// priceAt returns the series price in effect on the given day
+func priceAt(series []PricePoint, at time.Time) (float64, bool) {
+	day := at.Format("2006-01-02")
+	idx := sort.Search(len(series), func(i int) bool { return series[i].Date > day })
+	if idx == 0 {
+		return 0, false
+	}
+	return series[idx-1].Price, true
+}
+
+// trendDirection classifies a change, ignoring moves under 1%
+func trendDirection(from, to float64) string {
+	if from <= 0 {
+		return "stable"
+	}
+	change := (to - from) / from
+	switch {
+	case change > 0.01:
+		return "up"
+	case change < -0.01:
+		return "down"
+	default:
+		return "stable"
+	}
+}
+
+// parseDate parses a DATE column value ("2006-01-02" or RFC3339)
+func parseDate(value string) (time.Time, error) {
+	if len(value) > 10 {
+		value = value[:10]
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
+
+// bestLeadWindow finds the lead-time bucket with the lowest relative price.
+// Each voyage is normalised by its own average price so expensive and cheap
+// sailings contribute equally.
+func bestLeadWindow(series map[string][]PricePoint, departures map[string]time.Time, now time.Time) (leadWindow, bool) {
+	ratioSum := make([]float64, len(leadWindows))
+	priceSum := make([]float64, len(leadWindows))
+	samples := make([]int, len(leadWindows))
+
+	for voyageID, points := range series {
+		departure, ok := departures[voyageID]
+		if !ok || len(points) == 0 {
+			continue
+		}
+
+		bucketAvg := make([]float64, len(leadWindows))
+		var total float64
+		var buckets int
+		for i, w := range leadWindows {
+			var sum float64
+			var days int
+			for d := w[0]; d <= w[1]; d++ {
+				day := departure.AddDate(0, 0, -d)
+				if day.After(now) {
+					continue
+				}
+				if p, ok := priceAt(points, day); ok {
+					sum += p
+					days++
+				}
+			}
+			if days > 0 {
+				bucketAvg[i] = sum / float64(days)
+				total += bucketAvg[i]
+				buckets++
+			}
+		}
+
+		// A voyage needs at least two observed buckets to say anything
+		if buckets < 2 {
+			continue
+		}
+		mean := total / float64(buckets)
+		for i, avg := range bucketAvg {
+			if avg == 0 {
+				continue
+			}
+			ratioSum[i] += avg / mean
+			priceSum[i] += avg
+			samples[i]++
+		}
+	}
+
+	best := -1
+	var bestRatio float64
+	for i := range leadWindows {
+		if samples[i] == 0 {
+			continue
+		}
+		ratio := ratioSum[i] / float64(samples[i])
+		if best == -1 || ratio < bestRatio {
+			best, bestRatio = i, ratio
+		}
+	}
+	if best == -1 {
+		return leadWindow{}, false
+	}
+
+	return leadWindow{
+		MinDays:    leadWindows[best][0],
+		MaxDays:    leadWindows[best][1],
+		Samples:    samples[best],
+		AvgPrice:   priceSum[best] / float64(samples[best]),
+		SavingsPct: (1 - bestRatio) * 100,
+	}, true
+}
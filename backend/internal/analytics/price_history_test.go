@@ -0,0 +1,204 @@
+package analytics
+
+import (
+	"backend/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// version is a price version effective from one day to another; to < 0
+// leaves it open
+func version(priceID string, price float64, start time.Time, from, to int) *domain.CabinPriceHistory {
+	h := &domain.CabinPriceHistory{PriceID: priceID, AdultPrice: price, EffectiveFrom: start.AddDate(0, 0, from)}
+	if to >= 0 {
+		end := start.AddDate(0, 0, to)
+		h.EffectiveTo = &end
+	}
+	return h
+}
+
+func TestBuildPriceSeries(t *testing.T) {
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name    string
+		history []*domain.CabinPriceHistory
+		want    []PricePoint
+	}{
+		{
+			name: "no history",
+			want: []PricePoint{},
+		},
+		{
+			name:    "one open version",
+			history: []*domain.CabinPriceHistory{version("p1", 1000, start, 0, -1)},
+			want:    []PricePoint{{Date: "2026-03-01", Price: 1000}},
+		},
+		{
+			name: "a new version replaces the old one at the same instant",
+			history: []*domain.CabinPriceHistory{
+				version("p1", 1000, start, 0, 5),
+				version("p1", 1200, start, 5, -1),
+			},
+			want: []PricePoint{{Date: "2026-03-01", Price: 1000}, {Date: "2026-03-06", Price: 1200}},
+		},
+		{
+			name: "the lowest of the voyage's prices",
+			history: []*domain.CabinPriceHistory{
+				version("p1", 1000, start, 0, -1),
+				version("p2", 800, start, 2, 4),
+			},
+			want: []PricePoint{
+				{Date: "2026-03-01", Price: 1000},
+				{Date: "2026-03-03", Price: 800},
+				{Date: "2026-03-05", Price: 1000},
+			},
+		},
+		{
+			name: "several changes on one day keep the end-of-day price",
+			history: []*domain.CabinPriceHistory{
+				version("p1", 1000, start, 0, 3),
+				{PriceID: "p1", AdultPrice: 900, EffectiveFrom: start.AddDate(0, 0, 3), EffectiveTo: ptrTime(start.AddDate(0, 0, 3).Add(time.Hour))},
+				version("p1", 950, start.Add(time.Hour), 3, -1),
+			},
+			want: []PricePoint{{Date: "2026-03-01", Price: 1000}, {Date: "2026-03-04", Price: 950}},
+		},
+		{
+			name: "no point while no price is on sale",
+			history: []*domain.CabinPriceHistory{
+				version("p1", 1000, start, 0, 2),
+				version("p1", 1100, start, 4, -1),
+			},
+			want: []PricePoint{{Date: "2026-03-01", Price: 1000}, {Date: "2026-03-05", Price: 1100}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, buildPriceSeries(tt.history))
+		})
+	}
+}
+
+func TestPriceAt(t *testing.T) {
+	series := []PricePoint{
+		{Date: "2026-03-01", Price: 1000},
+		{Date: "2026-03-06", Price: 1200},
+	}
+
+	tests := []struct {
+		name   string
+		series []PricePoint
+		at     time.Time
+		want   float64
+		wantOK bool
+	}{
+		{"before the first point", series, time.Date(2026, 2, 28, 23, 0, 0, 0, time.Local), 0, false},
+		{"on the first day", series, time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local), 1000, true},
+		{"between points", series, time.Date(2026, 3, 5, 12, 0, 0, 0, time.Local), 1000, true},
+		{"on a change day", series, time.Date(2026, 3, 6, 8, 0, 0, 0, time.Local), 1200, true},
+		{"after the last point", series, time.Date(2026, 4, 1, 0, 0, 0, 0, time.Local), 1200, true},
+		{"empty series", nil, time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price, ok := priceAt(tt.series, tt.at)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, price)
+		})
+	}
+}
+
+func TestBestLeadWindow(t *testing.T) {
+	departure := time.Date(2026, 9, 1, 0, 0, 0, 0, time.Local)
+	day := func(daysBefore int) string {
+		return departure.AddDate(0, 0, -daysBefore).Format("2006-01-02")
+	}
+
+	// Cheapest 31-60 days out: 1200 until then, 1000 in the window, 1500 after
+	earlyBird := []PricePoint{
+		{Date: day(120), Price: 1200},
+		{Date: day(60), Price: 1000},
+		{Date: day(30), Price: 1500},
+	}
+	// Twice as expensive, same shape
+	premium := []PricePoint{
+		{Date: day(120), Price: 2400},
+		{Date: day(60), Price: 2000},
+		{Date: day(30), Price: 3000},
+	}
+
+	tests := []struct {
+		name       string
+		series     map[string][]PricePoint
+		departures map[string]time.Time
+		now        time.Time
+		wantOK     bool
+		wantMin    int
+		wantMax    int
+		wantAvg    float64
+		wantSample int
+	}{
+		{
+			name:       "finds the cheapest bucket",
+			series:     map[string][]PricePoint{"v1": earlyBird},
+			departures: map[string]time.Time{"v1": departure},
+			now:        departure,
+			wantOK:     true,
+			wantMin:    31,
+			wantMax:    60,
+			wantAvg:    1000,
+			wantSample: 1,
+		},
+		{
+			name:       "voyages count equally whatever their price",
+			series:     map[string][]PricePoint{"v1": earlyBird, "v2": premium},
+			departures: map[string]time.Time{"v1": departure, "v2": departure},
+			now:        departure,
+			wantOK:     true,
+			wantMin:    31,
+			wantMax:    60,
+			wantAvg:    1500,
+			wantSample: 2,
+		},
+		{
+			name:       "days after now are not observed yet",
+			series:     map[string][]PricePoint{"v1": earlyBird},
+			departures: map[string]time.Time{"v1": departure},
+			now:        departure.AddDate(0, 0, -100),
+		},
+		{
+			name:       "voyages without a departure are skipped",
+			series:     map[string][]PricePoint{"v1": earlyBird},
+			departures: map[string]time.Time{},
+			now:        departure,
+		},
+		{
+			name:       "no series",
+			departures: map[string]time.Time{"v1": departure},
+			now:        departure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window, ok := bestLeadWindow(tt.series, tt.departures, tt.now)
+			assert.Equal(t, tt.wantOK, ok)
+			if !tt.wantOK {
+				return
+			}
+			assert.Equal(t, tt.wantMin, window.MinDays)
+			assert.Equal(t, tt.wantMax, window.MaxDays)
+			assert.Equal(t, tt.wantSample, window.Samples)
+			assert.InDelta(t, tt.wantAvg, window.AvgPrice, 0.01)
+			assert.Greater(t, window.SavingsPct, 0.0)
+		})
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
@@ -5,6 +5,7 @@ import (
 	"backend/internal/repository"
 	"context"
 	"fmt"
+	"math"
 	"sort"
 	"time"
 )
@@ -127,13 +128,35 @@ type BookingWindow struct {
 
 // PriceForecast represents a price forecast
 type PriceForecast struct {
-	VoyageID      string           `json:"voyage_id"`
-	CurrentPrice  float64          `json:"current_price"`
-	ForecastPrice float64          `json:"forecast_price"`
-	Confidence    float64          `json:"confidence"` // 0-1
-	Trend         string           `json:"trend"`      // up, down, stable
-	Factors       []ForecastFactor `json:"factors"`
-	ForecastDate  string           `json:"forecast_date"`
+	VoyageID           string                   `json:"voyage_id"`
+	CurrentPrice       float64                  `json:"current_price"`
+	ForecastPrice      float64                  `json:"forecast_price"`
+	ForecastLow        float64                  `json:"forecast_low"`
+	ForecastHigh       float64                  `json:"forecast_high"`
+	IntervalLevel      float64                  `json:"interval_level"` // nominal coverage of low-high
+	Confidence         float64                  `json:"confidence"`     // probability the price lands within 5% of the forecast
+	Trend              string                   `json:"trend"`          // up, down, stable
+	SellOutProbability float64                  `json:"sell_out_probability"`
+	ExpectedLoadFactor float64                  `json:"expected_load_factor"`
+	Samples            int                      `json:"samples"` // comparable voyages the model was fitted on
+	Segment            string                   `json:"segment,omitempty"`
+	Factors            []ForecastFactor         `json:"factors"`
+	CabinTypes         []CabinTypePriceForecast `json:"cabin_types"`
+	ForecastDate       string                   `json:"forecast_date"`
+}
+
+// CabinTypePriceForecast is the forecast for one cabin type of a voyage
+type CabinTypePriceForecast struct {
+	CabinTypeID        string  `json:"cabin_type_id"`
+	CurrentPrice       float64 `json:"current_price"`
+	ForecastPrice      float64 `json:"forecast_price"`
+	ForecastLow        float64 `json:"forecast_low"`
+	ForecastHigh       float64 `json:"forecast_high"`
+	Confidence         float64 `json:"confidence"`
+	SellOutProbability float64 `json:"sell_out_probability"`
+	ExpectedLoadFactor float64 `json:"expected_load_factor"`
+	Samples            int     `json:"samples"`
+	Segment            string  `json:"segment"`
 }
 
 // ForecastFactor represents a factor affecting the forecast
@@ -157,25 +180,31 @@ type VoyagePriceComparison struct {
 type priceTrendAnalyzer struct {
 	voyageRepo    repository.VoyageRepository
 	priceRepo     repository.PriceRepository
+	historyRepo   repository.PriceHistoryRepository
 	cabinRepo     repository.CabinRepository
 	cabinTypeRepo repository.CabinTypeRepository
 	cruiseRepo    repository.CruiseRepository
+	forecaster    PriceForecaster
 }
 
 // NewPriceTrendAnalysis creates a new price trend analyzer
 func NewPriceTrendAnalysis(
 	voyageRepo repository.VoyageRepository,
 	priceRepo repository.PriceRepository,
+	historyRepo repository.PriceHistoryRepository,
 	cabinRepo repository.CabinRepository,
 	cabinTypeRepo repository.CabinTypeRepository,
 	cruiseRepo repository.CruiseRepository,
+	forecaster PriceForecaster,
 ) PriceTrendAnalysis {
 	return &priceTrendAnalyzer{
 		voyageRepo:    voyageRepo,
 		priceRepo:     priceRepo,
+		historyRepo:   historyRepo,
 		cabinRepo:     cabinRepo,
 		cabinTypeRepo: cabinTypeRepo,
 		cruiseRepo:    cruiseRepo,
+		forecaster:    forecaster,
 	}
 }
 
@@ -187,6 +216,18 @@ func (a *priceTrendAnalyzer) GetPriceTrend(ctx context.Context, voyageID string)
 		return nil, fmt.Errorf("failed to get voyage: %w", err)
 	}
 
+	history, err := a.historyRepo.ListByVoyages(ctx, []string{voyageID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price history: %w", err)
+	}
+
+	return a.buildTrend(ctx, voyage, history, time.Now())
+}
+
+// buildTrend combines current prices with the recorded history of a voyage
+func (a *priceTrendAnalyzer) buildTrend(ctx context.Context, voyage *domain.Voyage, history []*domain.CabinPriceHistory, now time.Time) (*PriceTrend, error) {
+	voyageID := voyage.ID.String()
+
 	// Get cruise details
 	cruise, err := a.cruiseRepo.GetByID(ctx, voyage.CruiseID)
 	if err != nil {
@@ -199,9 +240,21 @@ func (a *priceTrendAnalyzer) GetPriceTrend(ctx context.Context, voyageID string)
 		return nil, fmt.Errorf("failed to get prices: %w", err)
 	}
 
+	// Historical first/lowest adult price per cabin type
+	originalByType := make(map[string]float64)
+	lowestByType := make(map[string]float64)
+	for _, h := range history {
+		if _, ok := originalByType[h.CabinTypeID]; !ok {
+			originalByType[h.CabinTypeID] = h.AdultPrice
+		}
+		if low, ok := lowestByType[h.CabinTypeID]; !ok || h.AdultPrice < low {
+			lowestByType[h.CabinTypeID] = h.AdultPrice
+		}
+	}
+
 	// Build cabin type price info
 	cabinPrices := make([]CabinPriceInfo, 0, len(prices))
-	var currentPrice, lowestPrice, highestPrice float64
+	var currentPrice float64
 
 	for _, price := range prices {
 		cabinType, err := a.cabinTypeRepo.GetByID(ctx, price.CabinTypeID)
@@ -213,39 +266,59 @@ func (a *priceTrendAnalyzer) GetPriceTrend(ctx context.Context, voyageID string)
 			CabinTypeID:   price.CabinTypeID,
 			CabinTypeName: cabinType.Name,
 			CurrentPrice:  price.AdultPrice,
-			OriginalPrice: price.AdultPrice, // In real implementation, get from price history
+			OriginalPrice: price.AdultPrice,
 			LowestPrice:   price.AdultPrice,
 		}
+		if original, ok := originalByType[price.CabinTypeID]; ok {
+			cp.OriginalPrice = original
+		}
+		if low, ok := lowestByType[price.CabinTypeID]; ok && low < cp.LowestPrice {
+			cp.LowestPrice = low
+		}
 
 		cabinPrices = append(cabinPrices, cp)
 
-		// Track overall min/max
 		if currentPrice == 0 || price.AdultPrice < currentPrice {
 			currentPrice = price.AdultPrice
 		}
-		if lowestPrice == 0 || price.AdultPrice < lowestPrice {
-			lowestPrice = price.AdultPrice
-		}
-		if price.AdultPrice > highestPrice {
-			highestPrice = price.AdultPrice
+	}
+
+	series := buildPriceSeries(history)
+	originalPrice, lowestPrice, highestPrice := currentPrice, currentPrice, currentPrice
+	if len(series) > 0 {
+		originalPrice = series[0].Price
+		for _, p := range series {
+			if lowestPrice == 0 || p.Price < lowestPrice {
+				lowestPrice = p.Price
+			}
+			if p.Price > highestPrice {
+				highestPrice = p.Price
+			}
 		}
 	}
 
-	// Calculate trend direction
+	// Direction compares against the price a week ago, or the first price
+	reference := originalPrice
+	if p, ok := priceAt(series, now.AddDate(0, 0, -7)); ok {
+		reference = p
+	}
+
 	trend := &PriceTrend{
 		VoyageID:        voyageID,
 		VoyageNumber:    voyage.VoyageNumber,
 		CruiseName:      cruise.NameCN,
 		DepartureDate:   voyage.DepartureDate,
 		CurrentPrice:    currentPrice,
-		OriginalPrice:   currentPrice,
+		OriginalPrice:   originalPrice,
 		LowestPrice:     lowestPrice,
 		HighestPrice:    highestPrice,
-		PriceChange:     0,
-		PriceChangePct:  0,
-		TrendDirection:  "stable",
+		PriceChange:     currentPrice - originalPrice,
+		TrendDirection:  trendDirection(reference, currentPrice),
 		CabinTypePrices: cabinPrices,
-		PriceHistory:    []PricePoint{}, // Would come from price history table
+		PriceHistory:    series,
+	}
+	if originalPrice > 0 {
+		trend.PriceChangePct = math.Round((currentPrice-originalPrice)/originalPrice*10000) / 100
 	}
 
 	return trend, nil
@@ -277,7 +350,6 @@ func (a *priceTrendAnalyzer) GetCruisePriceTrends(ctx context.Context, cruiseID
 
 // GetBestBookingWindow recommends optimal booking window
 func (a *priceTrendAnalyzer) GetBestBookingWindow(ctx context.Context, cruiseID string, departureMonth int) (*BookingWindow, error) {
-	// Simplified logic - recommend booking 60-90 days in advance for best prices
 	now := time.Now()
 	targetDate := time.Date(now.Year(), time.Month(departureMonth), 1, 0, 0, 0, 0, time.Local)
 
@@ -286,18 +358,67 @@ func (a *priceTrendAnalyzer) GetBestBookingWindow(ctx context.Context, cruiseID
 		targetDate = targetDate.AddDate(1, 0, 0)
 	}
 
-	// Optimal booking is 60 days before departure
-	optimalBookBy := targetDate.AddDate(0, 0, -60)
+	window := &BookingWindow{
+		CruiseID:       cruiseID,
+		DepartureMonth: departureMonth,
+		Urgency:        "low",
+	}
+
+	// Learn from the recorded price history of voyages departing in the same month
+	voyages, err := a.voyageRepo.ListByCruise(ctx, cruiseID)
+	if err != nil {
+		return nil, err
+	}
+	voyageIDs := make([]string, 0, len(voyages))
+	departures := make(map[string]time.Time, len(voyages))
+	for _, voyage := range voyages {
+		departure, err := parseDate(voyage.DepartureDate)
+		if err != nil || int(departure.Month()) != departureMonth {
+			continue
+		}
+		voyageIDs = append(voyageIDs, voyage.ID.String())
+		departures[voyage.ID.String()] = departure
+	}
+
+	history, err := a.historyRepo.ListByVoyages(ctx, voyageIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price history: %w", err)
+	}
+	byVoyage := make(map[string][]*domain.CabinPriceHistory)
+	for _, h := range history {
+		byVoyage[h.VoyageID] = append(byVoyage[h.VoyageID], h)
+	}
+	series := make(map[string][]PricePoint, len(byVoyage))
+	for voyageID, versions := range byVoyage {
+		series[voyageID] = buildPriceSeries(versions)
+	}
 
-	return &BookingWindow{
-		CruiseID:           cruiseID,
-		DepartureMonth:     departureMonth,
-		OptimalBookBy:      optimalBookBy.Format("2006-01-02"),
-		ExpectedSavings:    200.0,
-		ExpectedSavingsPct: 5.0,
-		Urgency:            "low",
-		Reason:             "提前60天预订可获得较优价格",
-	}, nil
+	best, ok := bestLeadWindow(series, departures, now)
+	if !ok {
+		// Not enough history yet - fall back to booking 60 days ahead
+		window.OptimalBookBy = targetDate.AddDate(0, 0, -60).Format("2006-01-02")
+		window.Reason = "历史价格数据不足，建议提前60天预订"
+		return window, nil
+	}
+
+	windowStart := targetDate.AddDate(0, 0, -best.MaxDays)
+	windowEnd := targetDate.AddDate(0, 0, -best.MinDays)
+	window.OptimalBookBy = windowEnd.Format("2006-01-02")
+	if best.SavingsPct > 0 {
+		window.ExpectedSavingsPct = math.Round(best.SavingsPct*100) / 100
+		// AvgPrice is the in-window price; savings are against the all-window mean
+		window.ExpectedSavings = math.Round(best.AvgPrice*best.SavingsPct/(100-best.SavingsPct)*100) / 100
+	}
+
+	switch {
+	case !now.Before(windowStart):
+		window.Urgency = "high"
+	case windowStart.Sub(now) <= 30*24*time.Hour:
+		window.Urgency = "medium"
+	}
+	window.Reason = fmt.Sprintf("根据%d个历史航次，出发前%d-%d天预订价格最低", best.Samples, best.MinDays, best.MaxDays)
+
+	return window, nil
 }
 
 // GetPriceCalendar generates price calendar for cruise/route
@@ -463,57 +584,31 @@ func (a *priceTrendAnalyzer) GetPriceCalendar(ctx context.Context, cruiseID, rou
 
 // GetPriceForecast forecasts future price for a voyage
 func (a *priceTrendAnalyzer) GetPriceForecast(ctx context.Context, voyageID string) (*PriceForecast, error) {
-	// Get current trend
-	trend, err := a.GetPriceTrend(ctx, voyageID)
+	return a.forecaster.Forecast(ctx, voyageID)
+}
+
+// CompareVoyagePrices compares prices across multiple voyages
+func (a *priceTrendAnalyzer) CompareVoyagePrices(ctx context.Context, voyageIDs []string) ([]VoyagePriceComparison, error) {
+	history, err := a.historyRepo.ListByVoyages(ctx, voyageIDs)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to get price history: %w", err)
 	}
 
-	// Simple heuristic-based forecast
-	forecastPrice := trend.CurrentPrice
-	trendDirection := "stable"
-
-	// Parse departure date
-	voyage, _ := a.voyageRepo.GetByID(ctx, voyageID)
-	if voyage != nil {
-		depDate, err := time.Parse("2006-01-02", voyage.DepartureDate)
-		if err == nil {
-			daysUntil := int(depDate.Sub(time.Now()).Hours() / 24)
-
-			// Prices tend to increase as departure approaches
-			if daysUntil < 30 {
-				trendDirection = "up"
-				forecastPrice = trend.CurrentPrice * 1.05
-			} else if daysUntil < 60 {
-				trendDirection = "stable"
-			} else {
-				trendDirection = "down"
-				forecastPrice = trend.CurrentPrice * 0.95
-			}
-		}
+	byVoyage := make(map[string][]*domain.CabinPriceHistory, len(voyageIDs))
+	for _, h := range history {
+		byVoyage[h.VoyageID] = append(byVoyage[h.VoyageID], h)
 	}
 
-	return &PriceForecast{
-		VoyageID:      voyageID,
-		CurrentPrice:  trend.CurrentPrice,
-		ForecastPrice: forecastPrice,
-		Confidence:    0.7,
-		Trend:         trendDirection,
-		Factors: []ForecastFactor{
-			{Factor: "booking_lead_time", Impact: 0.3},
-			{Factor: "seasonality", Impact: 0.2},
-			{Factor: "inventory_level", Impact: 0.2},
-		},
-		ForecastDate: time.Now().AddDate(0, 0, 7).Format("2006-01-02"),
-	}, nil
-}
-
-// CompareVoyagePrices compares prices across multiple voyages
-func (a *priceTrendAnalyzer) CompareVoyagePrices(ctx context.Context, voyageIDs []string) ([]VoyagePriceComparison, error) {
+	now := time.Now()
 	comparisons := make([]VoyagePriceComparison, 0, len(voyageIDs))
 
 	for _, voyageID := range voyageIDs {
-		trend, err := a.GetPriceTrend(ctx, voyageID)
+		voyage, err := a.voyageRepo.GetByID(ctx, voyageID)
+		if err != nil {
+			continue
+		}
+
+		trend, err := a.buildTrend(ctx, voyage, byVoyage[voyageID], now)
 		if err != nil {
 			continue
 		}
@@ -1,19 +1,17 @@
 package auth
 
 import (
+	"log"
+
 	"github.com/casbin/casbin/v2"
 	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
 )
 
-// RBAC represents the RBAC system
-type RBAC struct {
-	enforcer *casbin.Enforcer
-}
-
-// NewRBAC creates a new RBAC instance with Casbin
-func NewRBAC() (*RBAC, error) {
-	// Define RBAC model
-	m, err := model.NewModelFromString(`
+// rbacModel grants roles actions on resources. Subjects are role names and
+// staff IDs: p rules give a role an action on a resource ("*" for any) and
+// g rules assign roles to staff
+const rbacModel = `
 [request_definition]
 r = sub, obj, act
 
@@ -27,13 +25,28 @@ g = _, _
 e = some(where (p.eft == allow))
 
 [matchers]
-m = g(r.sub, p.sub) && r.obj == p.obj && r.act == p.act
-`)
+m = g(r.sub, p.sub) && (p.obj == "*" || r.obj == p.obj) && (p.act == "*" || r.act == p.act)
+`
+
+// RBAC represents the RBAC system
+type RBAC struct {
+	enforcer *casbin.SyncedEnforcer
+}
+
+// Permission is an action on a resource
+type Permission struct {
+	Resource string `json:"resource"`
+	Action   string `json:"action"`
+}
+
+// NewRBAC creates a new in-memory RBAC instance with the default policies
+func NewRBAC() (*RBAC, error) {
+	m, err := model.NewModelFromString(rbacModel)
 	if err != nil {
 		return nil, err
 	}
 
-	e, err := casbin.NewEnforcer(m)
+	e, err := casbin.NewSyncedEnforcer(m)
 	if err != nil {
 		return nil, err
 	}
@@ -46,8 +59,34 @@ m = g(r.sub, p.sub) && r.obj == p.obj && r.act == p.act
 	return &RBAC{enforcer: e}, nil
 }
 
+// NewPersistentRBAC creates an RBAC instance whose policies are kept by
+// adapter. An empty store is seeded with the default policies
+func NewPersistentRBAC(adapter persist.Adapter) (*RBAC, error) {
+	m, err := model.NewModelFromString(rbacModel)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := casbin.NewSyncedEnforcer(m, adapter)
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := e.GetPolicy()
+	if err != nil {
+		return nil, err
+	}
+	if len(policies) == 0 {
+		if err := loadDefaultPolicies(e); err != nil {
+			return nil, err
+		}
+	}
+
+	return &RBAC{enforcer: e}, nil
+}
+
 // loadDefaultPolicies sets up default RBAC policies
-func loadDefaultPolicies(e *casbin.Enforcer) error {
+func loadDefaultPolicies(e *casbin.SyncedEnforcer) error {
 	// Super Admin - full access
 	policies := [][]string{
 		{"super_admin", "*", "*"},
@@ -65,6 +104,14 @@ func loadDefaultPolicies(e *casbin.Enforcer) error {
 		{"operations", "cabins", "write"},
 		{"operations", "orders", "read"},
 		{"operations", "analytics", "read"},
+		{"operations", "pricing", "read"},
+		{"operations", "pricing", "write"},
+		{"operations", "promotions", "read"},
+		{"operations", "promotions", "write"},
+		{"operations", "refund-policies", "read"},
+		{"operations", "refund-policies", "write"},
+		{"operations", "outbox", "read"},
+		{"operations", "outbox", "write"},
 		{"finance", "orders", "read"},
 		{"finance", "orders", "refund"},
 		{"finance", "payments", "read"},
@@ -72,6 +119,10 @@ func loadDefaultPolicies(e *casbin.Enforcer) error {
 		{"finance", "refund-requests", "approve"},
 		{"finance", "analytics", "read"},
 		{"finance", "reconciliation", "read"},
+		{"finance", "reconciliation", "write"},
+		{"finance", "payments", "process"},
+		{"finance", "invoices", "read"},
+		{"finance", "invoices", "approve"},
 		{"customer_service", "orders", "read"},
 		{"customer_service", "orders", "update"},
 		{"customer_service", "users", "read"},
@@ -80,19 +131,21 @@ func loadDefaultPolicies(e *casbin.Enforcer) error {
 	}
 
 	_, err := e.AddPolicies(policies)
-	if err != nil {
-		return err
-	}
+	return err
+}
 
-	groupingPolicies := [][]string{
-		{"operations", "super_admin"},
-		{"finance", "super_admin"},
-		{"customer_service", "super_admin"},
-		{"finance", "operations"},
+// SetWatcher reloads the policies whenever watcher reports a change made by
+// another instance, and notifies it of changes made here
+func (r *RBAC) SetWatcher(watcher persist.Watcher) error {
+	if err := r.enforcer.SetWatcher(watcher); err != nil {
+		return err
 	}
-
-	_, err = e.AddGroupingPolicies(groupingPolicies)
-	return err
+	// The default callback reloads without holding the enforcer's lock
+	return watcher.SetUpdateCallback(func(string) {
+		if err := r.enforcer.LoadPolicy(); err != nil {
+			log.Printf("Failed to reload RBAC policies: %v", err)
+		}
+	})
 }
 
 // CheckPermission checks if a subject has permission
@@ -109,3 +162,57 @@ func (r *RBAC) AddRoleForUser(user, role string) (bool, error) {
 func (r *RBAC) GetRolesForUser(user string) ([]string, error) {
 	return r.enforcer.GetRolesForUser(user)
 }
+
+// SetRolesForUser replaces the roles of a user
+func (r *RBAC) SetRolesForUser(user string, roles []string) error {
+	if _, err := r.enforcer.DeleteRolesForUser(user); err != nil {
+		return err
+	}
+	if len(roles) == 0 {
+		return nil
+	}
+	_, err := r.enforcer.AddRolesForUser(user, roles)
+	return err
+}
+
+// GetUsersForRole gets the users a role is assigned to
+func (r *RBAC) GetUsersForRole(role string) ([]string, error) {
+	return r.enforcer.GetUsersForRole(role)
+}
+
+// GetPermissionsForRole gets the permissions granted to a role
+func (r *RBAC) GetPermissionsForRole(role string) ([]Permission, error) {
+	rules, err := r.enforcer.GetFilteredPolicy(0, role)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions := make([]Permission, 0, len(rules))
+	for _, rule := range rules {
+		permissions = append(permissions, Permission{Resource: rule[1], Action: rule[2]})
+	}
+	return permissions, nil
+}
+
+// SetPermissionsForRole replaces the permissions granted to a role
+func (r *RBAC) SetPermissionsForRole(role string, permissions []Permission) error {
+	if _, err := r.enforcer.RemoveFilteredPolicy(0, role); err != nil {
+		return err
+	}
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	rules := make([][]string, 0, len(permissions))
+	for _, permission := range permissions {
+		rules = append(rules, []string{role, permission.Resource, permission.Action})
+	}
+	_, err := r.enforcer.AddPolicies(rules)
+	return err
+}
+
+// DeleteRole removes a role's permissions and assignments
+func (r *RBAC) DeleteRole(role string) error {
+	_, err := r.enforcer.DeleteRole(role)
+	return err
+}
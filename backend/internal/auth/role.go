@@ -82,4 +82,59 @@ const (
 	ResourceUsers          = "users"
 	ResourceAnalytics      = "analytics"
 	ResourceReconciliation = "reconciliation"
+	ResourcePricing        = "pricing"
+	ResourcePromotions     = "promotions"
+	ResourceRefundPolicies = "refund-policies"
+	ResourceInvoices       = "invoices"
+	ResourceOutbox         = "outbox"
+	ResourceStaff          = "staff"
+	ResourceAuditLogs      = "audit-logs"
 )
+
+// Resources lists the resources permissions can be granted on
+var Resources = []string{
+	ResourceCruises,
+	ResourceCabinTypes,
+	ResourceFacilities,
+	ResourceRoutes,
+	ResourceVoyages,
+	ResourceCabins,
+	ResourceOrders,
+	ResourcePayments,
+	ResourceRefundRequests,
+	ResourceUsers,
+	ResourceAnalytics,
+	ResourceReconciliation,
+	ResourcePricing,
+	ResourcePromotions,
+	ResourceRefundPolicies,
+	ResourceInvoices,
+	ResourceOutbox,
+	ResourceStaff,
+	ResourceAuditLogs,
+}
+
+// Permissions lists the actions permissions can grant
+var Permissions = []string{
+	PermRead,
+	PermWrite,
+	PermDelete,
+	PermUpdate,
+	PermRefund,
+	PermApprove,
+	PermProcess,
+}
+
+// IsValidPermission checks if a permission names a known resource and action
+func IsValidPermission(permission Permission) bool {
+	return contains(Resources, permission.Resource) && contains(Permissions, permission.Action)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -18,6 +19,14 @@ type Config struct {
 	NATS        NATSConfig     `mapstructure:"nats"`
 	JWT         JWTConfig      `mapstructure:"jwt"`
 	Wechat      WechatConfig   `mapstructure:"wechat"`
+	Alipay      AlipayConfig   `mapstructure:"alipay"`
+	Sandbox     SandboxConfig  `mapstructure:"payment_sandbox"`
+	Points      PointsConfig   `mapstructure:"points"`
+	Currency    CurrencyConfig `mapstructure:"currency"`
+	Invoice     InvoiceConfig  `mapstructure:"invoice"`
+	Audit       AuditConfig    `mapstructure:"audit"`
+	Admin       AdminConfig    `mapstructure:"admin"`
+	CORS        CORSConfig     `mapstructure:"cors"`
 }
 
 // ServerConfig holds HTTP server configuration
@@ -74,6 +83,68 @@ type WechatConfig struct {
 	KeyPath  string `mapstructure:"key_path"`
 }
 
+// AlipayConfig holds Alipay configuration
+type AlipayConfig struct {
+	AppID         string `mapstructure:"app_id"`
+	KeyPath       string `mapstructure:"key_path"`        // application private key
+	PublicKeyPath string `mapstructure:"public_key_path"` // Alipay public key
+	ReturnURL     string `mapstructure:"return_url"`
+	Sandbox       bool   `mapstructure:"sandbox"`
+}
+
+// SandboxConfig holds the local sandbox payment provider configuration
+type SandboxConfig struct {
+	Enabled bool   `mapstructure:"enabled"` // never honoured in production
+	Secret  string `mapstructure:"secret"`  // notification signing key; random when empty
+}
+
+// PointsConfig holds loyalty points configuration
+type PointsConfig struct {
+	PerUnit int64 `mapstructure:"per_unit"` // points that pay one unit of the base currency
+}
+
+// CurrencyConfig holds exchange rate configuration
+type CurrencyConfig struct {
+	RatesFile      string `mapstructure:"rates_file"`      // JSON rate file; without one only the base currency is quoted outside development
+	RefreshMinutes int    `mapstructure:"refresh_minutes"` // how long fetched rates are reused
+	MaxStaleHours  int    `mapstructure:"max_stale_hours"` // how old rates may be, from their as-of time
+}
+
+// InvoiceConfig holds electronic invoice configuration
+type InvoiceConfig struct {
+	Dir         string `mapstructure:"dir"`           // where the file-based provider keeps issued invoices
+	SellerName  string `mapstructure:"seller_name"`   // merchant name printed on invoices
+	SellerTaxID string `mapstructure:"seller_tax_id"` // merchant taxpayer identification number
+}
+
+// AuditConfig holds admin audit log configuration
+type AuditConfig struct {
+	RetentionDays int `mapstructure:"retention_days"` // how long entries are kept; forever when 0
+}
+
+// AdminConfig holds the initial super admin account, created only while
+// there are no staff accounts
+type AdminConfig struct {
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+// CORSConfig holds the browser origins allowed to call the API from other
+// sites, e.g. "https://www.example.com"; "*" allows any
+type CORSConfig struct {
+	AllowedOrigins []string `mapstructure:"allowed_origins"`
+}
+
+// AllowsOrigin checks if origin is one of the allowed origins
+func (c *CORSConfig) AllowsOrigin(origin string) bool {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
 // Load reads configuration from environment variables and config files
 func Load() *Config {
 	viper.SetConfigName("config")
@@ -95,9 +166,18 @@ func Load() *Config {
 	viper.SetDefault("jwt.expire_hours", 24)
 	viper.SetDefault("minio.bucket", "cruisebooking")
 	viper.SetDefault("minio.use_ssl", false)
+	viper.SetDefault("currency.refresh_minutes", 60)
+	viper.SetDefault("currency.max_stale_hours", 24)
+	viper.SetDefault("invoice.dir", "./data/invoices")
+	viper.SetDefault("audit.retention_days", 365)
+	viper.SetDefault("points.per_unit", 100)
 
 	// Enable environment variable override
 	viper.AutomaticEnv()
+	_ = viper.BindEnv("admin.username", "ADMIN_USERNAME")
+	_ = viper.BindEnv("admin.password", "ADMIN_PASSWORD")
+	_ = viper.BindEnv("cors.allowed_origins", "CORS_ALLOWED_ORIGINS")
+	_ = viper.BindEnv("points.per_unit", "POINTS_PER_UNIT")
 
 	// Read config file (optional - env vars take precedence)
 	if err := viper.ReadInConfig(); err != nil {
@@ -0,0 +1,112 @@
+package currency
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Base is the currency prices are maintained and reconciled in
+const Base = "CNY"
+
+// Supported currency codes
+const (
+	CNY = "CNY"
+	USD = "USD"
+	HKD = "HKD"
+	JPY = "JPY"
+)
+
+// minorDigits is the number of decimal places per supported currency
+var minorDigits = map[string]int{
+	CNY: 2,
+	USD: 2,
+	HKD: 2,
+	JPY: 0,
+}
+
+var (
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	ErrRateUnavailable     = errors.New("exchange rate unavailable")
+	ErrRatesStale          = errors.New("exchange rates are stale")
+)
+
+// Normalize upper-cases a currency code and checks it is supported;
+// an empty code means the base currency
+func Normalize(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return Base, nil
+	}
+	if _, ok := minorDigits[code]; !ok {
+		return "", ErrUnsupportedCurrency
+	}
+	return code, nil
+}
+
+// Supported returns the supported currency codes, base first
+func Supported() []string {
+	codes := make([]string, 0, len(minorDigits))
+	for code := range minorDigits {
+		if code != Base {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	return append([]string{Base}, codes...)
+}
+
+// Round rounds an amount to the minor unit of the currency
+func Round(amount float64, code string) float64 {
+	scale := math.Pow10(minorDigits[code])
+	return math.Round(amount*scale) / scale
+}
+
+// ToMinor converts an amount to integer minor units (cents, or yen for JPY)
+func ToMinor(amount float64, code string) int64 {
+	return int64(math.Round(amount * math.Pow10(minorDigits[code])))
+}
+
+// FromMinor converts integer minor units back to an amount
+func FromMinor(minor int64, code string) float64 {
+	return float64(minor) / math.Pow10(minorDigits[code])
+}
+
+// Rates are the units of each currency per one unit of Base
+type Rates struct {
+	Base      string             `json:"base"`
+	Rates     map[string]float64 `json:"rates"`
+	Source    string             `json:"source"`
+	AsOf      time.Time          `json:"as_of"`
+	FetchedAt time.Time          `json:"fetched_at"`
+}
+
+// rate returns units of code per unit of base
+func (r *Rates) rate(code string) (float64, bool) {
+	if code == r.Base {
+		return 1, true
+	}
+	v, ok := r.Rates[code]
+	return v, ok && v > 0
+}
+
+// Quote is an exchange rate between two currencies
+type Quote struct {
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	Rate   float64   `json:"rate"` // units of To per unit of From
+	Source string    `json:"source"`
+	AsOf   time.Time `json:"as_of"`
+}
+
+// Convert converts an amount in From to To, rounded to the minor unit of To
+func (q Quote) Convert(amount float64) float64 {
+	return Round(amount*q.Rate, q.To)
+}
+
+// Identity is the quote of a currency to itself
+func Identity(code string) Quote {
+	return Quote{From: code, To: code, Rate: 1, Source: "identity"}
+}
@@ -0,0 +1,104 @@
+package currency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Provider fetches exchange rates against Base
+type Provider interface {
+	// Name identifies the provider in quotes and logs
+	Name() string
+
+	// Fetch returns the latest rates
+	Fetch(ctx context.Context) (*Rates, error)
+}
+
+// DefaultRates are reference rates for development only; they are not kept
+// up to date
+var DefaultRates = map[string]float64{
+	USD: 0.1390,
+	HKD: 1.0850,
+	JPY: 20.80,
+}
+
+// staticProvider serves a fixed set of rates
+type staticProvider struct {
+	rates map[string]float64
+	asOf  time.Time
+}
+
+// NewStaticProvider creates a provider that always returns the given rates.
+// A zero asOf serves them as current, for development
+func NewStaticProvider(rates map[string]float64, asOf time.Time) Provider {
+	return &staticProvider{rates: rates, asOf: asOf}
+}
+
+func (p *staticProvider) Name() string {
+	return "static"
+}
+
+func (p *staticProvider) Fetch(ctx context.Context) (*Rates, error) {
+	rates := make(map[string]float64, len(p.rates))
+	for code, rate := range p.rates {
+		rates[code] = rate
+	}
+	asOf := p.asOf
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+	return &Rates{Base: Base, Rates: rates, Source: p.Name(), AsOf: asOf}, nil
+}
+
+// rateFile is the JSON layout read by the file provider:
+//
+//	{"base": "CNY", "as_of": "2026-01-01T00:00:00Z", "rates": {"USD": 0.139, "JPY": 20.8}}
+type rateFile struct {
+	Base  string             `json:"base"`
+	AsOf  time.Time          `json:"as_of"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// fileProvider reads rates from a JSON file on every fetch so it can be
+// updated without a restart
+type fileProvider struct {
+	path string
+}
+
+// NewFileProvider creates a provider that reads rates from a JSON file
+func NewFileProvider(path string) Provider {
+	return &fileProvider{path: path}
+}
+
+func (p *fileProvider) Name() string {
+	return "file"
+}
+
+func (p *fileProvider) Fetch(ctx context.Context) (*Rates, error) {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("read rate file: %w", err)
+	}
+
+	var file rateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse rate file: %w", err)
+	}
+	if file.AsOf.IsZero() {
+		return nil, errors.New("rate file has no as_of")
+	}
+	if file.Base != "" && file.Base != Base {
+		return nil, fmt.Errorf("rate file base %s, expected %s", file.Base, Base)
+	}
+	for code, rate := range file.Rates {
+		if _, err := Normalize(code); err != nil || rate <= 0 {
+			return nil, fmt.Errorf("invalid rate for %s in rate file", code)
+		}
+	}
+
+	return &Rates{Base: Base, Rates: file.Rates, Source: p.Name(), AsOf: file.AsOf}, nil
+}
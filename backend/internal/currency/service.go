@@ -0,0 +1,205 @@
+package currency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ratesCacheKey is the Redis key holding the latest rates
+const ratesCacheKey = "currency:rates:" + Base
+
+// Converter quotes and converts between supported currencies
+type Converter interface {
+	// Quote returns the rate from one currency to another
+	Quote(ctx context.Context, from, to string) (Quote, error)
+
+	// Convert converts an amount and returns the quote it used
+	Convert(ctx context.Context, amount float64, from, to string) (float64, Quote, error)
+
+	// Rates returns the current rates against Base
+	Rates(ctx context.Context) (*Rates, error)
+}
+
+// Config controls rate refresh and staleness
+type Config struct {
+	// RefreshInterval is how long fetched rates are used before refetching
+	RefreshInterval time.Duration
+	// MaxStaleness is how old rates may be, counted from their as-of time,
+	// before they are rejected, whether just fetched or cached while every
+	// provider fails
+	MaxStaleness time.Duration
+}
+
+// service implements Converter
+type service struct {
+	config    Config
+	providers []Provider
+	redis     *redis.Client
+	now       func() time.Time
+
+	mu     sync.Mutex
+	cached *Rates
+}
+
+// NewService creates a currency service that tries providers in order
+func NewService(config Config, providers []Provider, redisClients ...*redis.Client) Converter {
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = time.Hour
+	}
+	if config.MaxStaleness < config.RefreshInterval {
+		config.MaxStaleness = 24 * time.Hour
+	}
+
+	var redisClient *redis.Client
+	if len(redisClients) > 0 {
+		redisClient = redisClients[0]
+	}
+
+	return &service{
+		config:    config,
+		providers: providers,
+		redis:     redisClient,
+		now:       time.Now,
+	}
+}
+
+// Quote returns the rate from one currency to another
+func (s *service) Quote(ctx context.Context, from, to string) (Quote, error) {
+	from, err := Normalize(from)
+	if err != nil {
+		return Quote{}, err
+	}
+	to, err = Normalize(to)
+	if err != nil {
+		return Quote{}, err
+	}
+	if from == to {
+		return Identity(from), nil
+	}
+
+	rates, err := s.Rates(ctx)
+	if err != nil {
+		return Quote{}, err
+	}
+	fromRate, ok := rates.rate(from)
+	if !ok {
+		return Quote{}, fmt.Errorf("%w: %s", ErrRateUnavailable, from)
+	}
+	toRate, ok := rates.rate(to)
+	if !ok {
+		return Quote{}, fmt.Errorf("%w: %s", ErrRateUnavailable, to)
+	}
+
+	return Quote{
+		From:   from,
+		To:     to,
+		Rate:   toRate / fromRate,
+		Source: rates.Source,
+		AsOf:   rates.AsOf,
+	}, nil
+}
+
+// Convert converts an amount and returns the quote it used
+func (s *service) Convert(ctx context.Context, amount float64, from, to string) (float64, Quote, error) {
+	quote, err := s.Quote(ctx, from, to)
+	if err != nil {
+		return 0, Quote{}, err
+	}
+	return quote.Convert(amount), quote, nil
+}
+
+// Rates returns cached rates while fresh, otherwise refetches them. When
+// every provider fails, cached rates are served until they are MaxStaleness
+// past their as-of time.
+func (s *service) Rates(ctx context.Context) (*Rates, error) {
+	now := s.now()
+	current := func(rates *Rates) bool {
+		return now.Sub(rates.AsOf) < s.config.MaxStaleness
+	}
+
+	cached := s.load(ctx)
+	if cached != nil && now.Sub(cached.FetchedAt) < s.config.RefreshInterval && current(cached) {
+		return cached, nil
+	}
+
+	fetchErr := errors.New("no rate provider configured")
+	if len(s.providers) > 0 {
+		fetchErr = nil
+	}
+	for _, provider := range s.providers {
+		rates, err := provider.Fetch(ctx)
+		if err != nil {
+			fetchErr = errors.Join(fetchErr, fmt.Errorf("%s: %w", provider.Name(), err))
+			continue
+		}
+		if !current(rates) {
+			fetchErr = errors.Join(fetchErr, fmt.Errorf("%s: %w: as of %s", provider.Name(), ErrRatesStale, rates.AsOf.Format(time.RFC3339)))
+			continue
+		}
+		rates.Base = Base
+		rates.FetchedAt = now
+		if rates.Source == "" {
+			rates.Source = provider.Name()
+		}
+		s.store(ctx, rates)
+		return rates, nil
+	}
+
+	if cached != nil && current(cached) {
+		log.Printf("[WARN] Serving cached exchange rates as of %s: %v", cached.AsOf.Format(time.RFC3339), fetchErr)
+		return cached, nil
+	}
+	if cached != nil {
+		return nil, fmt.Errorf("%w: as of %s", ErrRatesStale, cached.AsOf.Format(time.RFC3339))
+	}
+	return nil, fmt.Errorf("%w: %v", ErrRateUnavailable, fetchErr)
+}
+
+// load returns the most recent rates from Redis or memory
+func (s *service) load(ctx context.Context) *Rates {
+	s.mu.Lock()
+	cached := s.cached
+	s.mu.Unlock()
+
+	if s.redis == nil {
+		return cached
+	}
+	data, err := s.redis.Get(ctx, ratesCacheKey).Bytes()
+	if err != nil {
+		return cached
+	}
+	var rates Rates
+	if err := json.Unmarshal(data, &rates); err != nil {
+		return cached
+	}
+	// Another instance may have refreshed more recently than this one
+	if cached == nil || rates.FetchedAt.After(cached.FetchedAt) {
+		return &rates
+	}
+	return cached
+}
+
+// store keeps rates in memory and in Redis until they are too stale to use
+func (s *service) store(ctx context.Context, rates *Rates) {
+	s.mu.Lock()
+	s.cached = rates
+	s.mu.Unlock()
+
+	if s.redis == nil {
+		return
+	}
+	data, err := json.Marshal(rates)
+	if err != nil {
+		return
+	}
+	if err := s.redis.Set(ctx, ratesCacheKey, data, s.config.MaxStaleness).Err(); err != nil {
+		log.Printf("[WARN] Failed to cache exchange rates: %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyProvider serves rates as of asOf until failing is set
+type flakyProvider struct {
+	asOf    time.Time
+	failing bool
+}
+
+func (p *flakyProvider) Name() string { return "flaky" }
+
+func (p *flakyProvider) Fetch(ctx context.Context) (*Rates, error) {
+	if p.failing {
+		return nil, errors.New("provider down")
+	}
+	return &Rates{Rates: map[string]float64{USD: 0.14, JPY: 21}, AsOf: p.asOf}, nil
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	provider := &flakyProvider{asOf: now}
+	svc := NewService(Config{RefreshInterval: time.Hour, MaxStaleness: 24 * time.Hour}, []Provider{provider}).(*service)
+	svc.now = func() time.Time { return now }
+
+	t.Run("converts from the base currency", func(t *testing.T) {
+		amount, quote, err := svc.Convert(ctx, 1000, CNY, USD)
+
+		require.NoError(t, err)
+		assert.Equal(t, 140.0, amount)
+		assert.Equal(t, "flaky", quote.Source)
+	})
+
+	t.Run("cross rates round to the target minor unit", func(t *testing.T) {
+		amount, _, err := svc.Convert(ctx, 10, USD, JPY)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1500.0, amount)
+	})
+
+	t.Run("rejects unsupported currencies", func(t *testing.T) {
+		_, err := svc.Quote(ctx, CNY, "EUR")
+
+		assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+	})
+
+	t.Run("serves cached rates while providers fail", func(t *testing.T) {
+		provider.failing = true
+		now = now.Add(12 * time.Hour)
+
+		_, err := svc.Quote(ctx, CNY, USD)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("rejects rates past the staleness limit", func(t *testing.T) {
+		now = now.Add(13 * time.Hour)
+
+		_, err := svc.Quote(ctx, CNY, USD)
+
+		assert.ErrorIs(t, err, ErrRatesStale)
+	})
+
+	t.Run("rejects freshly fetched rates that are too old", func(t *testing.T) {
+		provider.failing = false
+
+		_, err := svc.Quote(ctx, CNY, USD)
+
+		assert.ErrorIs(t, err, ErrRatesStale)
+	})
+
+	t.Run("the base currency needs no rates", func(t *testing.T) {
+		svc := NewService(Config{}, nil)
+
+		_, err := svc.Quote(ctx, CNY, CNY)
+		require.NoError(t, err)
+		_, err = svc.Quote(ctx, CNY, USD)
+		assert.ErrorIs(t, err, ErrRateUnavailable)
+	})
+}
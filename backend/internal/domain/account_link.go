@@ -0,0 +1,140 @@
+package domain
+
+import (
+	"strings"
+
+	"gorm.io/datatypes"
+)
+
+// Login identity types a user can bind
+const (
+	IdentityWechat = "wechat" // WeChat OpenID, with the UnionID when the app has one
+	IdentityPhone  = "phone"
+	IdentityAlipay = "alipay" // Alipay user ID
+)
+
+// IdentityTypes lists the bindable identity types
+var IdentityTypes = []string{IdentityWechat, IdentityPhone, IdentityAlipay}
+
+// Account link actions
+const (
+	AccountLinkBind   = "bind"
+	AccountLinkUnbind = "unbind"
+	AccountLinkMerge  = "merge"
+)
+
+// AccountLinkLog is the audit record of binding or unbinding an identity,
+// or of merging the account that held an identity into the user's.
+//
+// On a merge, MergedUserID is the account merged away and the counts say
+// what moved; identities it held that the user already had a different one
+// of are dropped and listed in Detail.
+type AccountLinkLog struct {
+	BaseModel
+	UserID             string         `gorm:"not null;index" json:"user_id"`
+	Action             string         `gorm:"not null" json:"action"`
+	IdentityType       string         `gorm:"not null" json:"identity_type"`
+	Identity           string         `json:"identity"` // masked
+	MergedUserID       *string        `gorm:"index" json:"merged_user_id,omitempty"`
+	OrdersMoved        int            `gorm:"not null;default:0" json:"orders_moved"`
+	PassengersMoved    int            `gorm:"not null;default:0" json:"passengers_moved"`
+	NotificationsMoved int            `gorm:"not null;default:0" json:"notifications_moved"`
+	RefundsMoved       int            `gorm:"not null;default:0" json:"refunds_moved"`
+	InvoicesMoved      int            `gorm:"not null;default:0" json:"invoices_moved"`
+	CouponsMoved       int            `gorm:"not null;default:0" json:"coupons_moved"` // wallet entries
+	PriceWatchesMoved  int            `gorm:"not null;default:0" json:"price_watches_moved"`
+	PointsMoved        int64          `gorm:"not null;default:0" json:"points_moved"`
+	Detail             datatypes.JSON `gorm:"type:jsonb" json:"detail,omitempty"`
+}
+
+// TableName returns the table name for AccountLinkLog
+func (AccountLinkLog) TableName() string {
+	return "account_link_logs"
+}
+
+// ValidIdentityType reports whether kind is a bindable identity type
+func ValidIdentityType(kind string) bool {
+	for _, t := range IdentityTypes {
+		if t == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Identity returns the user's identity of the given type, or "" if none is
+// bound
+func (u *User) Identity(kind string) string {
+	switch kind {
+	case IdentityWechat:
+		return u.WechatOpenID
+	case IdentityPhone:
+		return u.Phone
+	case IdentityAlipay:
+		if u.AlipayUserID != nil {
+			return *u.AlipayUserID
+		}
+	}
+	return ""
+}
+
+// LoginMethods counts the ways the user can log in: bound identities, and
+// email with a password
+func (u *User) LoginMethods() int {
+	count := 0
+	for _, kind := range IdentityTypes {
+		if u.Identity(kind) != "" {
+			count++
+		}
+	}
+	if u.Email != "" && u.PasswordHash != "" {
+		count++
+	}
+	return count
+}
+
+// ClearIdentity unbinds the user's identity of the given type
+func (u *User) ClearIdentity(kind string) {
+	switch kind {
+	case IdentityWechat:
+		u.WechatOpenID = ""
+		u.WechatUnionID = ""
+	case IdentityPhone:
+		u.Phone = ""
+		u.PhoneVerified = false
+	case IdentityAlipay:
+		u.AlipayUserID = nil
+	}
+}
+
+// TakeIdentity moves other's identity of the given type to the user
+func (u *User) TakeIdentity(kind string, other *User) {
+	switch kind {
+	case IdentityWechat:
+		u.WechatOpenID = other.WechatOpenID
+		u.WechatUnionID = other.WechatUnionID
+		if u.WechatNickname == "" {
+			u.WechatNickname = other.WechatNickname
+			u.WechatAvatarURL = other.WechatAvatarURL
+		}
+	case IdentityPhone:
+		u.Phone = other.Phone
+		u.PhoneVerified = other.PhoneVerified
+	case IdentityAlipay:
+		u.AlipayUserID = other.AlipayUserID
+	}
+	other.ClearIdentity(kind)
+}
+
+// MaskIdentity hides the middle of an identity for display and audit:
+// 138****5678 for a phone number, the first and last four characters of
+// anything else
+func MaskIdentity(kind, value string) string {
+	if kind == IdentityPhone && len(value) == 11 {
+		return value[:3] + "****" + value[7:]
+	}
+	if len(value) <= 8 {
+		return strings.Repeat("*", len(value))
+	}
+	return value[:4] + "****" + value[len(value)-4:]
+}
@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Admin audit results
+const (
+	AuditResultSuccess = "success"
+	AuditResultFailure = "failure"
+)
+
+// AdminAuditLog records a mutating back-office request: who made it, what it
+// targeted, how the target changed and how the request ended.
+//
+// The log is append-only. Entries have no update or soft delete columns, and
+// the table refuses updates and deletes other than the retention purge.
+type AdminAuditLog struct {
+	ID         uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ActorID    string         `gorm:"index" json:"actor_id"`
+	ActorName  string         `json:"actor_name"`
+	ActorRole  string         `json:"actor_role"`
+	IP         string         `gorm:"column:ip" json:"ip"`
+	UserAgent  string         `json:"user_agent,omitempty"`
+	Method     string         `gorm:"not null" json:"method"`
+	Route      string         `gorm:"not null" json:"route"` // route pattern, e.g. /api/v1/admin/cruises/:id
+	Path       string         `gorm:"not null" json:"path"`
+	Resource   string         `gorm:"index" json:"resource"`
+	TargetID   string         `gorm:"index" json:"target_id,omitempty"`
+	Request    datatypes.JSON `gorm:"type:jsonb" json:"request,omitempty"` // JSON body with secrets redacted
+	Before     datatypes.JSON `gorm:"type:jsonb" json:"before,omitempty"`
+	After      datatypes.JSON `gorm:"type:jsonb" json:"after,omitempty"`
+	Diff       datatypes.JSON `gorm:"type:jsonb" json:"diff,omitempty"` // changed fields, {"field": {"from": .., "to": ..}}
+	StatusCode int            `gorm:"not null" json:"status_code"`
+	Result     string         `gorm:"not null" json:"result"`
+	Error      string         `json:"error,omitempty"`
+	DurationMs int64          `json:"duration_ms"`
+	CreatedAt  time.Time      `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+// TableName returns the table name for AdminAuditLog
+func (AdminAuditLog) TableName() string {
+	return "admin_audit_logs"
+}
+
+// BeforeCreate hook to generate UUID
+func (l *AdminAuditLog) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
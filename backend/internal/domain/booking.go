@@ -128,6 +128,15 @@ func (CabinInventory) TableName() string {
 	return "cabin_inventory"
 }
 
+// InventoryAction constants describe what changed a CabinInventory row
+const (
+	InventoryActionLock    = "lock"
+	InventoryActionUnlock  = "unlock"
+	InventoryActionConfirm = "confirm"
+	InventoryActionCancel  = "cancel"
+	InventoryActionUpdate  = "update"
+)
+
 // CabinPrice represents pricing for a cabin type on a specific voyage
 type CabinPrice struct {
 	BaseModel
@@ -147,6 +156,16 @@ type CabinPrice struct {
 	PromotionEndDate   string    `json:"promotion_end_date,omitempty"`
 	MinPassengers      int       `gorm:"default:1" json:"min_passengers"`
 	MaxPassengers      int       `gorm:"default:4" json:"max_passengers"`
+	ExtraAdultPrice    float64   `gorm:"default:0" json:"extra_adult_price,omitempty"` // 3rd/4th guest, 0 = adult price
+	ExtraChildPrice    float64   `gorm:"default:0" json:"extra_child_price,omitempty"` // 3rd/4th guest, 0 = child price
+
+	// Dynamic pricing: base prices set by admins, rules reprice from these
+	BaseAdultPrice  float64 `json:"base_adult_price,omitempty"`
+	BaseChildPrice  float64 `json:"base_child_price,omitempty"`
+	BaseInfantPrice float64 `json:"base_infant_price,omitempty"`
+	ManualOverride  bool    `gorm:"default:false" json:"manual_override"`
+	Version         int     `gorm:"not null;default:1" json:"version"`
+	LastRepricedAt  string  `json:"last_repriced_at,omitempty"`
 }
 
 // TableName returns the table name for CabinPrice
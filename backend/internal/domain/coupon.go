@@ -0,0 +1,238 @@
+package domain
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Coupon is a discount that customers redeem with a code.
+//
+// Scope lists (routes, cruises, cabin types) are empty when unrestricted.
+// The discount applies to the subtotal of the order items in scope; items
+// outside the scope pay full price.
+type Coupon struct {
+	BaseModel
+	Code          string   `gorm:"not null;uniqueIndex" json:"code"`
+	Name          string   `gorm:"not null" json:"name"`
+	Description   string   `json:"description,omitempty"`
+	DiscountType  string   `gorm:"not null;default:fixed" json:"discount_type"`
+	DiscountValue float64  `gorm:"not null" json:"discount_value"` // 10 = 10% off (or 10 CNY off)
+	MaxDiscount   *float64 `json:"max_discount,omitempty"`         // cap for percentage coupons
+	MinSpend      float64  `gorm:"default:0" json:"min_spend"`     // on the in-scope subtotal
+
+	// Scope
+	RouteIDs      datatypes.JSON `gorm:"default:'[]'" json:"route_ids"`
+	CruiseIDs     datatypes.JSON `gorm:"default:'[]'" json:"cruise_ids"`
+	CabinTypeIDs  datatypes.JSON `gorm:"default:'[]'" json:"cabin_type_ids"`
+	DepartureFrom *string        `json:"departure_from,omitempty"` // YYYY-MM-DD, inclusive
+	DepartureTo   *string        `json:"departure_to,omitempty"`   // YYYY-MM-DD, inclusive
+
+	// Eligibility and limits
+	NewUsersOnly   bool       `gorm:"not null;default:false" json:"new_users_only"`
+	RequiresClaim  bool       `gorm:"not null;default:false" json:"requires_claim"` // only redeemable from the wallet
+	PerUserLimit   int        `gorm:"not null;default:1" json:"per_user_limit"`     // 0 = unlimited
+	TotalLimit     int        `gorm:"not null;default:0" json:"total_limit"`        // 0 = unlimited
+	RedeemedCount  int        `gorm:"not null;default:0" json:"redeemed_count"`
+	Stackable      bool       `gorm:"not null;default:false" json:"stackable"`
+	ValidFrom      *time.Time `json:"valid_from,omitempty"`
+	ValidUntil     *time.Time `json:"valid_until,omitempty"`
+	ClaimValidDays int        `gorm:"not null;default:0" json:"claim_valid_days"` // wallet expiry after issue; 0 = ValidUntil
+	IsActive       bool       `gorm:"not null;default:true" json:"is_active"`
+}
+
+// TableName returns the table name for Coupon
+func (Coupon) TableName() string {
+	return "coupons"
+}
+
+// CouponDiscountType constants
+const (
+	CouponDiscountFixed   = "fixed"
+	CouponDiscountPercent = "percent"
+)
+
+// GetRouteIDs returns the routes the coupon is limited to
+func (c *Coupon) GetRouteIDs() []string {
+	return decodeIDs(c.RouteIDs)
+}
+
+// GetCruiseIDs returns the cruises the coupon is limited to
+func (c *Coupon) GetCruiseIDs() []string {
+	return decodeIDs(c.CruiseIDs)
+}
+
+// GetCabinTypeIDs returns the cabin types the coupon is limited to
+func (c *Coupon) GetCabinTypeIDs() []string {
+	return decodeIDs(c.CabinTypeIDs)
+}
+
+// SetScope sets the route, cruise and cabin type scope lists
+func (c *Coupon) SetScope(routeIDs, cruiseIDs, cabinTypeIDs []string) {
+	c.RouteIDs = encodeIDs(routeIDs)
+	c.CruiseIDs = encodeIDs(cruiseIDs)
+	c.CabinTypeIDs = encodeIDs(cabinTypeIDs)
+}
+
+// ActiveAt checks whether the coupon can be redeemed at t
+func (c *Coupon) ActiveAt(t time.Time) bool {
+	if !c.IsActive {
+		return false
+	}
+	if c.ValidFrom != nil && t.Before(*c.ValidFrom) {
+		return false
+	}
+	if c.ValidUntil != nil && t.After(*c.ValidUntil) {
+		return false
+	}
+	return true
+}
+
+// Exhausted checks whether the global usage cap has been reached
+func (c *Coupon) Exhausted() bool {
+	return c.TotalLimit > 0 && c.RedeemedCount >= c.TotalLimit
+}
+
+// MatchesVoyage checks the route, cruise and departure window of the coupon.
+// A nil voyage only matches coupons without voyage conditions.
+func (c *Coupon) MatchesVoyage(v *Voyage) bool {
+	routes, cruises := c.GetRouteIDs(), c.GetCruiseIDs()
+	if v == nil {
+		return len(routes) == 0 && len(cruises) == 0 && c.DepartureFrom == nil && c.DepartureTo == nil
+	}
+	if len(routes) > 0 && !containsID(routes, v.RouteID) {
+		return false
+	}
+	if len(cruises) > 0 && !containsID(cruises, v.CruiseID) {
+		return false
+	}
+	departure := v.DepartureDate
+	if len(departure) > 10 {
+		departure = departure[:10]
+	}
+	if c.DepartureFrom != nil && departure < *c.DepartureFrom {
+		return false
+	}
+	if c.DepartureTo != nil && departure > *c.DepartureTo {
+		return false
+	}
+	return true
+}
+
+// AppliesToCabinType checks whether items of the cabin type are in scope
+func (c *Coupon) AppliesToCabinType(cabinTypeID string) bool {
+	cabinTypes := c.GetCabinTypeIDs()
+	return len(cabinTypes) == 0 || containsID(cabinTypes, cabinTypeID)
+}
+
+// Discount returns the discount on an in-scope subtotal, never more than the
+// subtotal itself
+func (c *Coupon) Discount(eligible float64) float64 {
+	if eligible <= 0 {
+		return 0
+	}
+	var discount float64
+	switch c.DiscountType {
+	case CouponDiscountPercent:
+		discount = eligible * c.DiscountValue / 100
+		if c.MaxDiscount != nil && discount > *c.MaxDiscount {
+			discount = *c.MaxDiscount
+		}
+	default:
+		discount = c.DiscountValue
+	}
+	discount = math.Min(discount, eligible)
+	return math.Round(discount*100) / 100
+}
+
+// UserCoupon is a coupon held in a user's wallet
+type UserCoupon struct {
+	BaseModel
+	UserID      string     `gorm:"not null;index" json:"user_id"`
+	CouponID    string     `gorm:"not null;index" json:"coupon_id"`
+	Coupon      Coupon     `gorm:"foreignKey:CouponID" json:"coupon,omitempty"`
+	Status      string     `gorm:"not null;default:available" json:"status"`
+	Source      string     `gorm:"not null;default:issued" json:"source"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	UsedOrderID *string    `json:"used_order_id,omitempty"`
+	UsedAt      *time.Time `json:"used_at,omitempty"`
+}
+
+// TableName returns the table name for UserCoupon
+func (UserCoupon) TableName() string {
+	return "user_coupons"
+}
+
+// UserCouponStatus constants
+const (
+	UserCouponStatusAvailable = "available"
+	UserCouponStatusUsed      = "used"
+	UserCouponStatusExpired   = "expired"
+)
+
+// UserCouponSource constants
+const (
+	UserCouponSourceIssued  = "issued"
+	UserCouponSourceClaimed = "claimed"
+)
+
+// UsableAt checks whether the wallet entry can be redeemed at t
+func (uc *UserCoupon) UsableAt(t time.Time) bool {
+	if uc.Status != UserCouponStatusAvailable {
+		return false
+	}
+	return uc.ExpiresAt == nil || !t.After(*uc.ExpiresAt)
+}
+
+// CouponRedemption records a coupon applied to an order. Redemptions are
+// released when the order is cancelled or times out, which frees the usage
+// caps and returns the wallet entry.
+type CouponRedemption struct {
+	BaseModel
+	CouponID       string     `gorm:"not null;index" json:"coupon_id"`
+	UserCouponID   *string    `gorm:"index" json:"user_coupon_id,omitempty"`
+	UserID         *string    `gorm:"index" json:"user_id,omitempty"`
+	OrderID        string     `gorm:"not null;index" json:"order_id"`
+	Code           string     `gorm:"not null" json:"code"`
+	DiscountAmount float64    `gorm:"not null" json:"discount_amount"` // in the base currency
+	Status         string     `gorm:"not null;default:applied" json:"status"`
+	ReleasedAt     *time.Time `json:"released_at,omitempty"`
+}
+
+// TableName returns the table name for CouponRedemption
+func (CouponRedemption) TableName() string {
+	return "coupon_redemptions"
+}
+
+// CouponRedemptionStatus constants
+const (
+	CouponRedemptionApplied  = "applied"
+	CouponRedemptionReleased = "released"
+)
+
+func decodeIDs(data datatypes.JSON) []string {
+	var ids []string
+	if len(data) > 0 {
+		_ = json.Unmarshal(data, &ids)
+	}
+	return ids
+}
+
+func encodeIDs(ids []string) datatypes.JSON {
+	if ids == nil {
+		ids = []string{}
+	}
+	data, _ := json.Marshal(ids)
+	return datatypes.JSON(data)
+}
+
+func containsID(ids []string, id string) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
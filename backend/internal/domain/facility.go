@@ -29,6 +29,7 @@ type Facility struct {
 	OpenTime     string           `json:"open_time,omitempty"`
 	IsFree       bool             `gorm:"default:true" json:"is_free"`
 	Price        float64          `json:"price,omitempty"`
+	Currency     string           `gorm:"-" json:"currency,omitempty"` // Currency Price is shown in; stored prices are in the base currency
 	Description  string           `json:"description,omitempty"`
 	Images       datatypes.JSON   `gorm:"default:'[]'" json:"images"`
 	SuitableTags datatypes.JSON   `gorm:"default:'[]'" json:"suitable_tags"`
@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+// GiftCard is a prepaid card redeemed towards orders by its code. Payments
+// made with it take their amount off Balance and refunds put it back.
+type GiftCard struct {
+	BaseModel
+	Code      string     `gorm:"not null;uniqueIndex;size:32" json:"code"`
+	Currency  string     `gorm:"not null;default:CNY" json:"currency"`
+	Balance   float64    `gorm:"not null;default:0" json:"balance"`
+	Status    string     `gorm:"not null;default:active" json:"status"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// TableName returns the table name for GiftCard
+func (GiftCard) TableName() string {
+	return "gift_cards"
+}
+
+// GiftCardStatus constants
+const (
+	GiftCardStatusActive   = "active"
+	GiftCardStatusDisabled = "disabled"
+)
+
+// UsableAt checks whether the card can pay at t
+func (g *GiftCard) UsableAt(t time.Time) bool {
+	return g.Status == GiftCardStatusActive && (g.ExpiresAt == nil || t.Before(*g.ExpiresAt))
+}
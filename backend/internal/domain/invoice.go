@@ -0,0 +1,144 @@
+package domain
+
+import (
+	"regexp"
+	"time"
+)
+
+// Invoice kinds. A red-letter invoice reverses a blue one when the order
+// is refunded after it was issued
+const (
+	InvoiceKindBlue = "blue"
+	InvoiceKindRed  = "red"
+)
+
+// Invoice buyer types
+const (
+	InvoiceBuyerPersonal = "personal"
+	InvoiceBuyerCompany  = "company"
+)
+
+// Invoice statuses
+const (
+	InvoiceStatusPending  = "pending"  // awaiting finance review
+	InvoiceStatusRejected = "rejected" // declined by finance
+	InvoiceStatusIssued   = "issued"
+	InvoiceStatusFailed   = "failed"   // the provider refused it; finance may retry
+	InvoiceStatusReversed = "reversed" // a blue invoice offset by a red-letter one
+)
+
+// taxIDPattern matches a unified social credit code, or the older 15, 17
+// or 20 character taxpayer numbers
+var taxIDPattern = regexp.MustCompile(`^([0-9A-HJ-NPQRTUWXY]{2}\d{6}[0-9A-HJ-NPQRTUWXY]{10}|[0-9A-Z]{15}|[0-9A-Z]{17}|[0-9A-Z]{20})$`)
+
+// ValidTaxID reports whether id looks like a taxpayer identification number
+func ValidTaxID(id string) bool {
+	return taxIDPattern.MatchString(id)
+}
+
+// Invoice is an electronic invoice (fapiao) requested for a paid order
+type Invoice struct {
+	BaseModel
+	OrderID      string     `gorm:"not null;index" json:"order_id"`
+	UserID       *string    `gorm:"index" json:"user_id,omitempty"`
+	Kind         string     `gorm:"not null;default:blue" json:"kind"`
+	OriginalID   *string    `gorm:"index" json:"original_id,omitempty"` // the blue invoice a red-letter one reverses
+	BuyerType    string     `gorm:"not null" json:"buyer_type"`
+	Title        string     `gorm:"not null" json:"title"`
+	TaxID        string     `json:"tax_id,omitempty"`
+	Email        string     `gorm:"not null" json:"email"`
+	Amount       float64    `gorm:"not null" json:"amount"` // negative on red-letter invoices
+	Currency     string     `gorm:"default:CNY" json:"currency"`
+	Status       string     `gorm:"not null;default:pending" json:"status"`
+	ReviewedBy   *string    `json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+	ReviewNote   string     `json:"review_note,omitempty"`
+	Provider     string     `json:"provider,omitempty"`
+	InvoiceNo    string     `gorm:"index" json:"invoice_no,omitempty"`
+	IssuedAt     *time.Time `json:"issued_at,omitempty"`
+	FileURL      string     `json:"file_url,omitempty"`
+	ErrorMessage string     `json:"error_message,omitempty"`
+}
+
+// TableName returns the table name for Invoice
+func (Invoice) TableName() string {
+	return "invoices"
+}
+
+// IsOpen reports whether the invoice still covers its order, which then
+// cannot be invoiced again
+func (i *Invoice) IsOpen() bool {
+	switch i.Status {
+	case InvoiceStatusPending, InvoiceStatusIssued, InvoiceStatusFailed:
+		return true
+	}
+	return false
+}
+
+// CanIssue reports whether the invoice may be sent to the provider
+func (i *Invoice) CanIssue() bool {
+	return i.Status == InvoiceStatusPending || i.Status == InvoiceStatusFailed
+}
+
+// Review records the finance reviewer
+func (i *Invoice) Review(reviewerID, note string, at time.Time) {
+	i.ReviewedBy = &reviewerID
+	i.ReviewedAt = &at
+	i.ReviewNote = note
+}
+
+// Reject declines the invoice request
+func (i *Invoice) Reject(reviewerID, note string, at time.Time) {
+	i.Review(reviewerID, note, at)
+	i.Status = InvoiceStatusRejected
+}
+
+// MarkIssued records the issued document
+func (i *Invoice) MarkIssued(provider, invoiceNo, fileURL string, at time.Time) {
+	i.Status = InvoiceStatusIssued
+	i.Provider = provider
+	i.InvoiceNo = invoiceNo
+	i.FileURL = fileURL
+	i.IssuedAt = &at
+	i.ErrorMessage = ""
+}
+
+// MarkFailed records a failed issue
+func (i *Invoice) MarkFailed(err error) {
+	i.Status = InvoiceStatusFailed
+	i.ErrorMessage = err.Error()
+}
+
+// Reversal builds the red-letter invoice offsetting an issued blue one
+func (i *Invoice) Reversal() *Invoice {
+	id := i.ID.String()
+	return &Invoice{
+		OrderID:    i.OrderID,
+		UserID:     i.UserID,
+		Kind:       InvoiceKindRed,
+		OriginalID: &id,
+		BuyerType:  i.BuyerType,
+		Title:      i.Title,
+		TaxID:      i.TaxID,
+		Email:      i.Email,
+		Amount:     -i.Amount,
+		Currency:   i.Currency,
+		Status:     InvoiceStatusPending,
+	}
+}
+
+// Reissue builds a new blue invoice request for the same buyer
+func (i *Invoice) Reissue(amount float64) *Invoice {
+	return &Invoice{
+		OrderID:   i.OrderID,
+		UserID:    i.UserID,
+		Kind:      InvoiceKindBlue,
+		BuyerType: i.BuyerType,
+		Title:     i.Title,
+		TaxID:     i.TaxID,
+		Email:     i.Email,
+		Amount:    amount,
+		Currency:  i.Currency,
+		Status:    InvoiceStatusPending,
+	}
+}
@@ -1,32 +1,47 @@
 package domain
 
-import "time"
+import (
+	"encoding/json"
+	"math"
+	"time"
+
+	"gorm.io/datatypes"
+)
 
 // Order represents a booking order
 type Order struct {
 	BaseModel
-	OrderNumber    string  `gorm:"not null;uniqueIndex" json:"order_number"`
-	UserID         *string `gorm:"index" json:"user_id,omitempty"`
-	VoyageID       string  `gorm:"not null;index" json:"voyage_id"`
-	Voyage         Voyage  `gorm:"foreignKey:VoyageID" json:"voyage,omitempty"`
-	CruiseID       string  `gorm:"not null;index" json:"cruise_id"`
-	Cruise         Cruise  `gorm:"foreignKey:CruiseID" json:"cruise,omitempty"`
-	TotalAmount    float64 `gorm:"not null;default:0" json:"total_amount"`
-	DiscountAmount float64 `gorm:"default:0" json:"discount_amount"`
-	PaidAmount     float64 `gorm:"default:0" json:"paid_amount"`
-	Currency       string  `gorm:"default:CNY" json:"currency"`
-	Status         string  `gorm:"default:pending" json:"status"`
-	PaymentStatus  string  `gorm:"default:unpaid" json:"payment_status"`
-	PassengerCount int     `gorm:"not null;default:1" json:"passenger_count"`
-	CabinCount     int     `gorm:"not null;default:1" json:"cabin_count"`
-	ContactName    string  `json:"contact_name,omitempty"`
-	ContactPhone   string  `json:"contact_phone,omitempty"`
-	ContactEmail   string  `json:"contact_email,omitempty"`
-	Remark         string  `json:"remark,omitempty"`
-	BookedAt       *string `json:"booked_at,omitempty"`
-	PaidAt         *string `json:"paid_at,omitempty"`
-	ConfirmedAt    *string `json:"confirmed_at,omitempty"`
-	ExpiresAt      string  `gorm:"not null" json:"expires_at"`
+	OrderNumber     string  `gorm:"not null;uniqueIndex" json:"order_number"`
+	UserID          *string `gorm:"index" json:"user_id,omitempty"`
+	VoyageID        string  `gorm:"not null;index" json:"voyage_id"`
+	Voyage          Voyage  `gorm:"foreignKey:VoyageID" json:"voyage,omitempty"`
+	CruiseID        string  `gorm:"not null;index" json:"cruise_id"`
+	Cruise          Cruise  `gorm:"foreignKey:CruiseID" json:"cruise,omitempty"`
+	TotalAmount     float64 `gorm:"not null;default:0" json:"total_amount"`
+	DiscountAmount  float64 `gorm:"default:0" json:"discount_amount"`
+	PaidAmount      float64 `gorm:"default:0" json:"paid_amount"`
+	Currency        string  `gorm:"default:CNY" json:"currency"`
+	BaseCurrency    string  `gorm:"default:CNY" json:"base_currency"`
+	ExchangeRate    float64 `gorm:"default:1" json:"exchange_rate"`     // Currency units per BaseCurrency unit, locked at creation
+	BaseTotalAmount float64 `gorm:"default:0" json:"base_total_amount"` // Sum of item subtotals, which are in BaseCurrency
+	RateSource      string  `json:"rate_source,omitempty"`
+	RateLockedAt    *string `json:"rate_locked_at,omitempty"`
+	Status          string  `gorm:"default:pending" json:"status"`
+	PaymentStatus   string  `gorm:"default:unpaid" json:"payment_status"`
+	PassengerCount  int     `gorm:"not null;default:1" json:"passenger_count"`
+	CabinCount      int     `gorm:"not null;default:1" json:"cabin_count"`
+	ContactName     string  `json:"contact_name,omitempty"`
+	ContactPhone    string  `json:"contact_phone,omitempty"`
+	ContactEmail    string  `json:"contact_email,omitempty"`
+	Remark          string  `json:"remark,omitempty"`
+	BookedAt        *string `json:"booked_at,omitempty"`
+	PaidAt          *string `json:"paid_at,omitempty"`
+	ConfirmedAt     *string `json:"confirmed_at,omitempty"`
+	ExpiresAt       string  `gorm:"not null" json:"expires_at"`
+
+	// Refund terms in force when the order was booked
+	RefundPolicyID *string        `gorm:"index" json:"refund_policy_id,omitempty"`
+	RefundPolicy   datatypes.JSON `gorm:"type:jsonb" json:"refund_policy,omitempty"`
 
 	// Relations
 	Items      []OrderItem `gorm:"foreignKey:OrderID" json:"items,omitempty"`
@@ -62,11 +77,37 @@ func (o *Order) IsPaid() bool {
 	return o.PaymentStatus == PaymentStatusPaid
 }
 
+// PayableAmount returns what the customer owes for the order, which one or
+// more payments together settle
+func (o *Order) PayableAmount() float64 {
+	return o.TotalAmount - o.DiscountAmount
+}
+
 // CanCancel checks if order can be cancelled
 func (o *Order) CanCancel() bool {
 	return o.Status == OrderStatusPending || o.Status == OrderStatusPaid
 }
 
+// GetRefundPolicy returns the refund policy snapshot taken at booking; ok is
+// false for orders booked without one
+func (o *Order) GetRefundPolicy() (policy RefundPolicySnapshot, ok bool) {
+	if len(o.RefundPolicy) == 0 {
+		return policy, false
+	}
+	if err := json.Unmarshal(o.RefundPolicy, &policy); err != nil {
+		return policy, false
+	}
+	return policy, true
+}
+
+// SetRefundPolicy snapshots the refund policy the order is booked under
+func (o *Order) SetRefundPolicy(p *RefundPolicy) {
+	id := p.ID.String()
+	data, _ := json.Marshal(p.Snapshot())
+	o.RefundPolicyID = &id
+	o.RefundPolicy = datatypes.JSON(data)
+}
+
 // IsExpired checks if order has expired
 func (o *Order) IsExpired() bool {
 	if o.ExpiresAt == "" {
@@ -82,26 +123,29 @@ func (o *Order) IsExpired() bool {
 // OrderItem represents a line item in an order
 type OrderItem struct {
 	BaseModel
-	OrderID       string    `gorm:"not null;index" json:"order_id"`
-	Order         Order     `gorm:"foreignKey:OrderID" json:"order,omitempty"`
-	CabinID       string    `gorm:"not null;index" json:"cabin_id"`
-	Cabin         Cabin     `gorm:"foreignKey:CabinID" json:"cabin,omitempty"`
-	CabinTypeID   string    `gorm:"not null;index" json:"cabin_type_id"`
-	CabinType     CabinType `gorm:"foreignKey:CabinTypeID" json:"cabin_type,omitempty"`
-	VoyageID      string    `gorm:"not null;index" json:"voyage_id"`
-	Voyage        Voyage    `gorm:"foreignKey:VoyageID" json:"voyage,omitempty"`
-	CabinNumber   string    `json:"cabin_number,omitempty"`
-	PriceSnapshot float64   `gorm:"not null" json:"price_snapshot"`
-	AdultCount    int       `gorm:"not null;default:2" json:"adult_count"`
-	ChildCount    int       `gorm:"default:0" json:"child_count"`
-	InfantCount   int       `gorm:"default:0" json:"infant_count"`
-	AdultPrice    float64   `gorm:"not null" json:"adult_price"`
-	ChildPrice    float64   `json:"child_price,omitempty"`
-	InfantPrice   float64   `json:"infant_price,omitempty"`
-	PortFee       float64   `gorm:"default:0" json:"port_fee"`
-	ServiceFee    float64   `gorm:"default:0" json:"service_fee"`
-	Subtotal      float64   `gorm:"not null" json:"subtotal"`
-	Status        string    `gorm:"default:confirmed" json:"status"`
+	OrderID          string    `gorm:"not null;index" json:"order_id"`
+	Order            Order     `gorm:"foreignKey:OrderID" json:"order,omitempty"`
+	CabinID          string    `gorm:"not null;index" json:"cabin_id"`
+	Cabin            Cabin     `gorm:"foreignKey:CabinID" json:"cabin,omitempty"`
+	CabinTypeID      string    `gorm:"not null;index" json:"cabin_type_id"`
+	CabinType        CabinType `gorm:"foreignKey:CabinTypeID" json:"cabin_type,omitempty"`
+	VoyageID         string    `gorm:"not null;index" json:"voyage_id"`
+	Voyage           Voyage    `gorm:"foreignKey:VoyageID" json:"voyage,omitempty"`
+	CabinNumber      string    `json:"cabin_number,omitempty"`
+	PriceSnapshot    float64   `gorm:"not null" json:"price_snapshot"`
+	AdultCount       int       `gorm:"not null;default:2" json:"adult_count"`
+	ChildCount       int       `gorm:"default:0" json:"child_count"`
+	InfantCount      int       `gorm:"default:0" json:"infant_count"`
+	AdultPrice       float64   `gorm:"not null" json:"adult_price"`
+	ChildPrice       float64   `json:"child_price,omitempty"`
+	InfantPrice      float64   `json:"infant_price,omitempty"`
+	PortFee          float64   `gorm:"default:0" json:"port_fee"`
+	ServiceFee       float64   `gorm:"default:0" json:"service_fee"`
+	SingleSupplement float64   `gorm:"default:0" json:"single_supplement"`
+	ExtraGuestCount  int       `gorm:"default:0" json:"extra_guest_count"`
+	ExtraGuestAmount float64   `gorm:"default:0" json:"extra_guest_amount"`
+	Subtotal         float64   `gorm:"not null" json:"subtotal"`
+	Status           string    `gorm:"default:confirmed" json:"status"`
 
 	// Relations
 	Passengers []Passenger `gorm:"foreignKey:OrderItemID" json:"passengers,omitempty"`
@@ -180,6 +224,9 @@ type Payment struct {
 	PaymentChannel          string  `json:"payment_channel,omitempty"`
 	Amount                  float64 `gorm:"not null" json:"amount"`
 	Currency                string  `gorm:"default:CNY" json:"currency"`
+	BaseCurrency            string  `gorm:"default:CNY" json:"base_currency"`
+	BaseAmount              float64 `gorm:"default:0" json:"base_amount"`   // Amount in BaseCurrency for reconciliation
+	ExchangeRate            float64 `gorm:"default:1" json:"exchange_rate"` // Currency units per BaseCurrency unit
 	Status                  string  `gorm:"default:pending" json:"status"`
 	ThirdPartyTransactionID string  `gorm:"index" json:"third_party_transaction_id,omitempty"`
 	ThirdPartyResponse      string  `json:"third_party_response,omitempty"`
@@ -188,6 +235,9 @@ type Payment struct {
 	NotifyData              string  `json:"notify_data,omitempty"`
 	RetryCount              int     `gorm:"default:0" json:"retry_count"`
 	ErrorMessage            string  `json:"error_message,omitempty"`
+	RefundedAmount          float64 `gorm:"default:0" json:"refunded_amount"`  // Sum of successful refunds of this payment
+	TenderRef               string  `gorm:"size:64" json:"-"`                  // Gift card code or user ID a stored value payment draws on; card codes are bearer secrets
+	Points                  int64   `gorm:"default:0" json:"points,omitempty"` // Points redeemed by a points payment
 }
 
 // TableName returns the table name for Payment
@@ -195,11 +245,27 @@ func (Payment) TableName() string {
 	return "payments"
 }
 
+// ApplyExchangeRate records the base currency equivalent of the payment
+func (p *Payment) ApplyExchangeRate(baseCurrency string, rate float64) {
+	if baseCurrency == "" || rate <= 0 {
+		baseCurrency, rate = p.Currency, 1
+	}
+	p.BaseCurrency = baseCurrency
+	p.ExchangeRate = rate
+	p.BaseAmount = math.Round(p.Amount/rate*100) / 100
+}
+
 // PaymentMethod constants
 const (
-	PaymentMethodWechat = "wechat"
-	PaymentMethodAlipay = "alipay"
-	PaymentMethodCard   = "card"
+	PaymentMethodWechat  = "wechat"
+	PaymentMethodAlipay  = "alipay"
+	PaymentMethodCard    = "card"
+	PaymentMethodSandbox = "sandbox" // local provider for development and tests
+
+	// Stored value tenders are balances held by the platform and charged when
+	// the payment is created
+	PaymentMethodGiftCard = "gift_card"
+	PaymentMethodPoints   = "points"
 )
 
 // PaymentStatus constants
@@ -216,6 +282,25 @@ func (p *Payment) IsSuccessful() bool {
 	return p.Status == PaymentStatusSuccess
 }
 
+// IsInFlight checks if the provider may still report a result for the payment
+func (p *Payment) IsInFlight() bool {
+	return p.Status == PaymentStatusPending || p.Status == PaymentStatusProcessing
+}
+
+// IsSettled checks if the payment has already been applied to its order
+func (p *Payment) IsSettled() bool {
+	return p.Status == PaymentStatusSuccess || p.Status == PaymentStatusRefunded
+}
+
+// RefundableAmount returns how much of a successful payment has not been
+// refunded yet
+func (p *Payment) RefundableAmount() float64 {
+	if p.Status != PaymentStatusSuccess {
+		return 0
+	}
+	return math.Max(p.Amount-p.RefundedAmount, 0)
+}
+
 // CanRetry checks if payment can be retried
 func (p *Payment) CanRetry() bool {
 	return p.Status == PaymentStatusFailed && p.RetryCount < 3
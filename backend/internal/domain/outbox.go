@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// OutboxEvent is a domain event stored in the same transaction as the change
+// it describes and published to NATS afterwards by the outbox relay.
+//
+// Events of one aggregate are published in Sequence order; an event that
+// keeps failing is moved to the dead letters and no longer holds back the
+// events after it.
+type OutboxEvent struct {
+	BaseModel
+	Sequence      int64          `gorm:"->" json:"sequence"` // assigned by the database
+	AggregateType string         `gorm:"not null" json:"aggregate_type"`
+	AggregateID   string         `gorm:"not null" json:"aggregate_id"`
+	Subject       string         `gorm:"not null" json:"subject"`
+	Payload       datatypes.JSON `gorm:"type:jsonb;not null" json:"payload"`
+	Status        string         `gorm:"not null;default:pending" json:"status"`
+	Attempts      int            `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time      `gorm:"not null" json:"next_attempt_at"`
+	PublishedAt   *time.Time     `json:"published_at,omitempty"`
+	LastError     string         `json:"last_error,omitempty"`
+}
+
+// TableName returns the table name for OutboxEvent
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+// OutboxStatus constants
+const (
+	OutboxStatusPending   = "pending"
+	OutboxStatusPublished = "published"
+	OutboxStatusDead      = "dead"
+)
+
+// AggregateOrder is the aggregate of order, payment and refund events, so
+// that a consumer sees an order's payment and refund events in order
+const AggregateOrder = "order"
+
+// NewOutboxEvent creates a pending event with a JSON payload
+func NewOutboxEvent(aggregateType, aggregateID, subject string, payload interface{}) (*OutboxEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &OutboxEvent{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Subject:       subject,
+		Payload:       datatypes.JSON(data),
+		Status:        OutboxStatusPending,
+		NextAttemptAt: time.Now(),
+	}, nil
+}
+
+// AggregateKey identifies the aggregate whose events must stay in order
+func (e *OutboxEvent) AggregateKey() string {
+	return e.AggregateType + ":" + e.AggregateID
+}
+
+// MarkPublished records a successful publish
+func (e *OutboxEvent) MarkPublished(now time.Time) {
+	e.Status = OutboxStatusPublished
+	e.Attempts++
+	e.PublishedAt = &now
+	e.LastError = ""
+}
+
+// MarkFailed records a failed publish, retrying at next or moving the event
+// to the dead letters once maxAttempts is reached
+func (e *OutboxEvent) MarkFailed(err error, next time.Time, maxAttempts int) {
+	e.Attempts++
+	e.LastError = err.Error()
+	if e.Attempts >= maxAttempts {
+		e.Status = OutboxStatusDead
+		return
+	}
+	e.NextAttemptAt = next
+}
+
+// Requeue puts a dead event back in line for publishing
+func (e *OutboxEvent) Requeue(now time.Time) {
+	e.Status = OutboxStatusPending
+	e.Attempts = 0
+	e.NextAttemptAt = now
+}
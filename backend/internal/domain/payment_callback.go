@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Payment callback kinds
+const (
+	CallbackKindPayment = "payment"
+	CallbackKindRefund  = "refund"
+)
+
+// Payment callback statuses
+const (
+	CallbackStatusReceived  = "received" // logged, not yet processed
+	CallbackStatusProcessed = "processed"
+	CallbackStatusFailed    = "failed"
+)
+
+// PaymentCallback is an inbound provider notification as it was received,
+// with the outcome of processing it.
+//
+// A callback replayed by staff is logged again as a new row pointing at the
+// original, which keeps the log append-only and records who replayed it.
+type PaymentCallback struct {
+	BaseModel
+	Provider    string         `gorm:"not null" json:"provider"`
+	Kind        string         `gorm:"not null" json:"kind"`
+	Headers     datatypes.JSON `gorm:"type:jsonb" json:"headers"`
+	Body        string         `gorm:"not null" json:"body"`
+	Signature   string         `json:"signature,omitempty"` // signature metadata handed to the provider
+	Verified    bool           `gorm:"not null;default:false" json:"verified"`
+	Reference   string         `gorm:"index" json:"reference,omitempty"` // payment or refund number
+	Status      string         `gorm:"not null;default:received" json:"status"`
+	Error       string         `json:"error,omitempty"`
+	ProcessedAt *time.Time     `json:"processed_at,omitempty"`
+	ReplayOfID  *string        `gorm:"index" json:"replay_of_id,omitempty"`
+	ReplayedBy  *string        `json:"replayed_by,omitempty"`
+	ReplayNote  string         `json:"replay_note,omitempty"`
+}
+
+// TableName returns the table name for PaymentCallback
+func (PaymentCallback) TableName() string {
+	return "payment_callbacks"
+}
+
+// MarkProcessed records the outcome of processing the callback
+func (c *PaymentCallback) MarkProcessed(err error, now time.Time) {
+	c.ProcessedAt = &now
+	if err != nil {
+		c.Status = CallbackStatusFailed
+		c.Error = err.Error()
+		return
+	}
+	c.Status = CallbackStatusProcessed
+	c.Error = ""
+}
@@ -0,0 +1,28 @@
+package domain
+
+import "gorm.io/datatypes"
+
+// PriceBulkOperation is the audit record of a bulk price edit
+type PriceBulkOperation struct {
+	BaseModel
+	OperatorID    string         `gorm:"index" json:"operator_id"`
+	Mode          string         `gorm:"not null" json:"mode"`
+	PriceType     string         `gorm:"not null" json:"price_type"`
+	DepartureFrom string         `gorm:"not null" json:"departure_from"`
+	DepartureTo   string         `gorm:"not null" json:"departure_to"`
+	Request       datatypes.JSON `gorm:"type:jsonb" json:"request"`
+	VoyageCount   int            `gorm:"not null;default:0" json:"voyage_count"`
+	CreatedCount  int            `gorm:"not null;default:0" json:"created_count"`
+	UpdatedCount  int            `gorm:"not null;default:0" json:"updated_count"`
+}
+
+// TableName returns the table name for PriceBulkOperation
+func (PriceBulkOperation) TableName() string {
+	return "price_bulk_operations"
+}
+
+// BulkPriceMode constants
+const (
+	BulkPriceModeSet    = "set"    // write an explicit price matrix
+	BulkPriceModeAdjust = "adjust" // adjust existing prices by percentage
+)
@@ -0,0 +1,64 @@
+package domain
+
+import "time"
+
+// CabinPriceHistory is an immutable snapshot of a CabinPrice version.
+// The open version of a price has a nil EffectiveTo.
+type CabinPriceHistory struct {
+	BaseModel
+	PriceID          string     `gorm:"not null;index" json:"price_id"`
+	VoyageID         string     `gorm:"not null;index" json:"voyage_id"`
+	CabinTypeID      string     `gorm:"not null;index" json:"cabin_type_id"`
+	PriceType        string     `gorm:"not null" json:"price_type"`
+	Version          int        `gorm:"not null" json:"version"`
+	AdultPrice       float64    `gorm:"not null" json:"adult_price"`
+	ChildPrice       float64    `json:"child_price"`
+	InfantPrice      float64    `json:"infant_price"`
+	ExtraAdultPrice  float64    `json:"extra_adult_price"` // 3rd/4th guest, 0 = adult price
+	ExtraChildPrice  float64    `json:"extra_child_price"` // 3rd/4th guest, 0 = child price
+	SingleSupplement float64    `json:"single_supplement"`
+	PortFee          float64    `json:"port_fee"`
+	ServiceFee       float64    `json:"service_fee"`
+	IsPromotion      bool       `json:"is_promotion"`
+	EffectiveFrom    time.Time  `gorm:"not null" json:"effective_from"`
+	EffectiveTo      *time.Time `json:"effective_to,omitempty"`
+	Source           string     `gorm:"not null;default:manual" json:"source"`
+	ActorID          *string    `json:"actor_id,omitempty"`
+	Note             string     `json:"note,omitempty"`
+}
+
+// TableName returns the table name for CabinPriceHistory
+func (CabinPriceHistory) TableName() string {
+	return "cabin_price_history"
+}
+
+// PriceSource constants
+const (
+	PriceSourceManual    = "manual"
+	PriceSourceRule      = "rule"
+	PriceSourcePromotion = "promotion"
+)
+
+// NewCabinPriceHistory snapshots a price as a new open history version
+func NewCabinPriceHistory(p *CabinPrice, source string, actorID *string, note string, at time.Time) *CabinPriceHistory {
+	return &CabinPriceHistory{
+		PriceID:          p.ID.String(),
+		VoyageID:         p.VoyageID,
+		CabinTypeID:      p.CabinTypeID,
+		PriceType:        p.PriceType,
+		Version:          p.Version,
+		AdultPrice:       p.AdultPrice,
+		ChildPrice:       p.ChildPrice,
+		InfantPrice:      p.InfantPrice,
+		ExtraAdultPrice:  p.ExtraAdultPrice,
+		ExtraChildPrice:  p.ExtraChildPrice,
+		SingleSupplement: p.SingleSupplement,
+		PortFee:          p.PortFee,
+		ServiceFee:       p.ServiceFee,
+		IsPromotion:      p.IsPromotion,
+		EffectiveFrom:    at,
+		Source:           source,
+		ActorID:          actorID,
+		Note:             note,
+	}
+}
@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// PriceWatch is a user's subscription to price changes of a cabin type,
+// either on one voyage or on any voyage of a route.
+//
+// A drop watch fires when the adult price reaches TargetPrice or falls at
+// least ThresholdPercent below BaselinePrice, a rise watch when it reaches
+// TargetPrice or climbs at least ThresholdPercent above BaselinePrice, and an
+// either watch on a move of ThresholdPercent each way. Once it has fired it
+// only fires again for a price further in its direction than
+// LastNotifiedPrice, or further from the baseline for an either watch.
+type PriceWatch struct {
+	BaseModel
+	UserID            string     `gorm:"not null;index" json:"user_id"`
+	VoyageID          *string    `gorm:"index" json:"voyage_id,omitempty"`
+	RouteID           *string    `gorm:"index" json:"route_id,omitempty"`
+	CabinTypeID       string     `gorm:"not null;index" json:"cabin_type_id"`
+	Direction         string     `gorm:"not null;default:drop" json:"direction"`
+	ThresholdPercent  *float64   `json:"threshold_percent,omitempty"` // 10 = notify after a 10% move
+	TargetPrice       *float64   `json:"target_price,omitempty"`
+	BaselinePrice     *float64   `json:"baseline_price,omitempty"` // adult price when the watch was created
+	LastNotifiedPrice *float64   `json:"last_notified_price,omitempty"`
+	LastNotifiedAt    *time.Time `json:"last_notified_at,omitempty"`
+	IsActive          bool       `gorm:"not null;default:true" json:"is_active"`
+}
+
+// TableName returns the table name for PriceWatch
+func (PriceWatch) TableName() string {
+	return "price_watches"
+}
+
+// PriceWatchSourceType is the notification source type of price alerts
+const PriceWatchSourceType = "price_watch"
+
+// PriceWatchDirection constants
+const (
+	PriceWatchDirectionDrop   = "drop"
+	PriceWatchDirectionRise   = "rise"
+	PriceWatchDirectionEither = "either" // threshold only; a target would always be met
+)
+
+// Triggered checks whether an adult price satisfies the watch conditions,
+// ignoring prices that were already notified
+func (w *PriceWatch) Triggered(price float64) bool {
+	if !w.IsActive || price <= 0 || !w.beyondNotified(price) {
+		return false
+	}
+
+	switch w.Direction {
+	case PriceWatchDirectionRise:
+		if w.TargetPrice != nil && price >= *w.TargetPrice {
+			return true
+		}
+		return w.changePercent(price) >= w.threshold()
+	case PriceWatchDirectionEither:
+		change := w.changePercent(price)
+		return change >= w.threshold() || -change >= w.threshold()
+	default:
+		if w.TargetPrice != nil && price <= *w.TargetPrice {
+			return true
+		}
+		return -w.changePercent(price) >= w.threshold()
+	}
+}
+
+// beyondNotified reports whether price moved further in the watch's
+// direction than the last notified price
+func (w *PriceWatch) beyondNotified(price float64) bool {
+	if w.LastNotifiedPrice == nil {
+		return true
+	}
+	last := *w.LastNotifiedPrice
+	switch w.Direction {
+	case PriceWatchDirectionRise:
+		return price > last
+	case PriceWatchDirectionEither:
+		if w.BaselinePrice == nil {
+			return false
+		}
+		return math.Abs(price-*w.BaselinePrice) > math.Abs(last-*w.BaselinePrice)
+	default:
+		return price < last
+	}
+}
+
+// changePercent is the change of price from the baseline in percent, or 0
+// without a baseline
+func (w *PriceWatch) changePercent(price float64) float64 {
+	if w.BaselinePrice == nil || *w.BaselinePrice <= 0 {
+		return 0
+	}
+	return (price - *w.BaselinePrice) / *w.BaselinePrice * 100
+}
+
+// threshold is the percentage that fires the watch; without one no change
+// is large enough
+func (w *PriceWatch) threshold() float64 {
+	if w.ThresholdPercent == nil || w.BaselinePrice == nil || *w.BaselinePrice <= 0 {
+		return math.Inf(1)
+	}
+	return *w.ThresholdPercent
+}
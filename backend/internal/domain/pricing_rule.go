@@ -0,0 +1,121 @@
+package domain
+
+// PricingRule is a declarative revenue-management rule evaluated against
+// cabin inventory and departure lead time.
+//
+// All non-nil conditions must hold for a rule to match. Matching rules are
+// applied in priority order (lowest first) to the base price of the cabin.
+type PricingRule struct {
+	BaseModel
+	Name        string  `gorm:"not null" json:"name"`
+	Description string  `json:"description,omitempty"`
+	VoyageID    *string `gorm:"index" json:"voyage_id,omitempty"`
+	CabinTypeID *string `gorm:"index" json:"cabin_type_id,omitempty"`
+	Priority    int     `gorm:"not null;default:100" json:"priority"`
+	IsActive    bool    `gorm:"not null;default:true" json:"is_active"`
+	// Exclusive stops evaluation of lower priority rules once this one matches
+	Exclusive bool `gorm:"not null;default:false" json:"exclusive"`
+
+	// Conditions (ratios are 0-1)
+	AvailabilityBelow *float64 `json:"availability_below,omitempty"` // available / total
+	AvailabilityAbove *float64 `json:"availability_above,omitempty"`
+	LoadFactorBelow   *float64 `json:"load_factor_below,omitempty"` // (booked + locked) / total
+	LoadFactorAbove   *float64 `json:"load_factor_above,omitempty"`
+	DaysToDepartureLE *int     `gorm:"column:days_to_departure_le" json:"days_to_departure_le,omitempty"`
+	DaysToDepartureGE *int     `gorm:"column:days_to_departure_ge" json:"days_to_departure_ge,omitempty"`
+
+	// Action
+	AdjustmentType  string  `gorm:"not null;default:percent" json:"adjustment_type"`
+	AdjustmentValue float64 `gorm:"not null" json:"adjustment_value"` // +10 = raise 10% (or 10 currency units)
+
+	// Bounds applied to the adult price after this rule
+	FloorPrice   *float64 `json:"floor_price,omitempty"`
+	CeilingPrice *float64 `json:"ceiling_price,omitempty"`
+}
+
+// TableName returns the table name for PricingRule
+func (PricingRule) TableName() string {
+	return "pricing_rules"
+}
+
+// AdjustmentType constants
+const (
+	AdjustmentTypePercent = "percent"
+	AdjustmentTypeFixed   = "fixed"
+)
+
+// PricingSnapshot is the inventory state a rule is evaluated against
+type PricingSnapshot struct {
+	TotalCabins     int
+	AvailableCabins int
+	BookedCabins    int
+	LockedCabins    int
+	DaysToDeparture int
+}
+
+// AvailabilityRatio returns available / total cabins
+func (s PricingSnapshot) AvailabilityRatio() float64 {
+	if s.TotalCabins <= 0 {
+		return 0
+	}
+	return float64(s.AvailableCabins) / float64(s.TotalCabins)
+}
+
+// LoadFactor returns (booked + locked) / total cabins
+func (s PricingSnapshot) LoadFactor() float64 {
+	if s.TotalCabins <= 0 {
+		return 0
+	}
+	return float64(s.BookedCabins+s.LockedCabins) / float64(s.TotalCabins)
+}
+
+// Matches checks whether every condition of the rule holds for the snapshot
+func (r *PricingRule) Matches(s PricingSnapshot) bool {
+	if r.AvailabilityBelow != nil && !(s.AvailabilityRatio() < *r.AvailabilityBelow) {
+		return false
+	}
+	if r.AvailabilityAbove != nil && !(s.AvailabilityRatio() > *r.AvailabilityAbove) {
+		return false
+	}
+	if r.LoadFactorBelow != nil && !(s.LoadFactor() < *r.LoadFactorBelow) {
+		return false
+	}
+	if r.LoadFactorAbove != nil && !(s.LoadFactor() > *r.LoadFactorAbove) {
+		return false
+	}
+	if r.DaysToDepartureLE != nil && s.DaysToDeparture > *r.DaysToDepartureLE {
+		return false
+	}
+	if r.DaysToDepartureGE != nil && s.DaysToDeparture < *r.DaysToDepartureGE {
+		return false
+	}
+	return true
+}
+
+// Apply adjusts a price according to the rule action
+func (r *PricingRule) Apply(price float64) float64 {
+	if price <= 0 {
+		return price
+	}
+	switch r.AdjustmentType {
+	case AdjustmentTypeFixed:
+		price += r.AdjustmentValue
+	default:
+		price *= 1 + r.AdjustmentValue/100
+	}
+	if price < 0 {
+		price = 0
+	}
+	return price
+}
+
+// AppliesTo checks whether the rule is scoped to the voyage and cabin type
+func (r *PricingRule) AppliesTo(voyageID, cabinTypeID string) bool {
+	if r.VoyageID != nil && *r.VoyageID != voyageID {
+		return false
+	}
+	if r.CabinTypeID != nil && *r.CabinTypeID != cabinTypeID {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"math"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// PromotionCampaign is a named, scheduled discount on a set of voyages and
+// cabin types. The scheduler applies it to the matching cabin prices at
+// StartsAt and restores the original prices at EndsAt.
+type PromotionCampaign struct {
+	BaseModel
+	Name          string         `gorm:"not null" json:"name"`
+	Description   string         `json:"description,omitempty"`
+	VoyageIDs     datatypes.JSON `gorm:"not null;default:'[]'" json:"voyage_ids"`
+	CabinTypeIDs  datatypes.JSON `gorm:"not null;default:'[]'" json:"cabin_type_ids"` // empty = all cabin types
+	DiscountType  string         `gorm:"not null;default:percent" json:"discount_type"`
+	DiscountValue float64        `gorm:"not null" json:"discount_value"` // 15 = 15% off (or 15 currency units off)
+	StartsAt      time.Time      `gorm:"not null" json:"starts_at"`
+	EndsAt        time.Time      `gorm:"not null" json:"ends_at"`
+	Status        string         `gorm:"not null;default:scheduled" json:"status"`
+	ActivatedAt   *time.Time     `json:"activated_at,omitempty"`
+	EndedAt       *time.Time     `json:"ended_at,omitempty"`
+	AppliedCount  int            `gorm:"not null;default:0" json:"applied_count"`
+	SkippedCount  int            `gorm:"not null;default:0" json:"skipped_count"` // prices already on promotion
+	CreatedBy     *string        `json:"created_by,omitempty"`
+}
+
+// TableName returns the table name for PromotionCampaign
+func (PromotionCampaign) TableName() string {
+	return "promotion_campaigns"
+}
+
+// PromotionCampaignStatus constants
+const (
+	PromotionStatusScheduled = "scheduled"
+	PromotionStatusActive    = "active"
+	PromotionStatusEnded     = "ended"
+	PromotionStatusCancelled = "cancelled"
+)
+
+// GetVoyageIDs returns the voyages the campaign targets
+func (c *PromotionCampaign) GetVoyageIDs() []string {
+	return decodeIDs(c.VoyageIDs)
+}
+
+// GetCabinTypeIDs returns the cabin types the campaign is limited to
+func (c *PromotionCampaign) GetCabinTypeIDs() []string {
+	return decodeIDs(c.CabinTypeIDs)
+}
+
+// SetTargets sets the voyage and cabin type lists
+func (c *PromotionCampaign) SetTargets(voyageIDs, cabinTypeIDs []string) {
+	c.VoyageIDs = encodeIDs(voyageIDs)
+	c.CabinTypeIDs = encodeIDs(cabinTypeIDs)
+}
+
+// DueAt checks whether a scheduled campaign should be switched on at t
+func (c *PromotionCampaign) DueAt(t time.Time) bool {
+	return c.Status == PromotionStatusScheduled && !t.Before(c.StartsAt) && t.Before(c.EndsAt)
+}
+
+// ExpiredAt checks whether the campaign window has closed at t
+func (c *PromotionCampaign) ExpiredAt(t time.Time) bool {
+	return !t.Before(c.EndsAt)
+}
+
+// Overlaps checks whether the campaign window intersects [from, to)
+func (c *PromotionCampaign) Overlaps(from, to time.Time) bool {
+	return c.StartsAt.Before(to) && c.EndsAt.After(from)
+}
+
+// Discount returns the promotional adult, child and infant prices. Child and
+// infant prices move by the same factor as the adult price, like they do
+// under pricing rules.
+func (c *PromotionCampaign) Discount(adult, child, infant float64) (float64, float64, float64) {
+	if adult <= 0 {
+		return adult, child, infant
+	}
+	discounted := adult
+	switch c.DiscountType {
+	case AdjustmentTypeFixed:
+		discounted -= c.DiscountValue
+	default:
+		discounted *= 1 - c.DiscountValue/100
+	}
+	factor := math.Max(discounted, 0) / adult
+	return roundCents(adult * factor), roundCents(child * factor), roundCents(infant * factor)
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// PromotionPrice records a cabin price changed by a campaign, with the
+// prices to restore when the campaign ends
+type PromotionPrice struct {
+	BaseModel
+	CampaignID          string     `gorm:"not null;index" json:"campaign_id"`
+	PriceID             string     `gorm:"not null;index" json:"price_id"`
+	VoyageID            string     `gorm:"not null;index" json:"voyage_id"`
+	CabinTypeID         string     `gorm:"not null" json:"cabin_type_id"`
+	OriginalAdultPrice  float64    `gorm:"not null" json:"original_adult_price"`
+	OriginalChildPrice  float64    `json:"original_child_price"`
+	OriginalInfantPrice float64    `json:"original_infant_price"`
+	PromoAdultPrice     float64    `gorm:"not null" json:"promo_adult_price"`
+	PromoChildPrice     float64    `json:"promo_child_price"`
+	PromoInfantPrice    float64    `json:"promo_infant_price"`
+	PromoVersion        int        `gorm:"not null" json:"promo_version"` // price version written by the campaign
+	Status              string     `gorm:"not null;default:applied" json:"status"`
+	AppliedAt           time.Time  `gorm:"not null" json:"applied_at"`
+	RestoredAt          *time.Time `json:"restored_at,omitempty"`
+}
+
+// TableName returns the table name for PromotionPrice
+func (PromotionPrice) TableName() string {
+	return "promotion_prices"
+}
+
+// PromotionPriceStatus constants
+const (
+	PromotionPriceApplied  = "applied"
+	PromotionPriceRestored = "restored"
+)
@@ -0,0 +1,93 @@
+package domain
+
+// Reconciliation item kinds
+const (
+	ReconciliationKindTrade  = "trade"
+	ReconciliationKindRefund = "refund"
+)
+
+// Reconciliation item results
+const (
+	ReconciliationMatched        = "matched"
+	ReconciliationMissingLocal   = "missing_local"  // on the statement, unknown locally
+	ReconciliationMissingRemote  = "missing_remote" // settled locally, absent from the statement
+	ReconciliationAmountMismatch = "amount_mismatch"
+	ReconciliationStatusMismatch = "status_mismatch"
+)
+
+// Reconciliation run statuses
+const (
+	ReconciliationStatusBalanced   = "balanced"
+	ReconciliationStatusUnbalanced = "unbalanced"
+)
+
+// ReconciliationRun is the comparison of one provider's daily statement
+// with local payments and refunds
+type ReconciliationRun struct {
+	BaseModel
+	Provider            string  `gorm:"not null" json:"provider"`
+	StatementDate       string  `gorm:"type:date;not null" json:"statement_date"`
+	Status              string  `gorm:"not null" json:"status"`
+	TradeCount          int     `gorm:"default:0" json:"trade_count"`
+	RefundCount         int     `gorm:"default:0" json:"refund_count"`
+	TradeAmount         float64 `gorm:"default:0" json:"trade_amount"`  // statement trade total
+	RefundAmount        float64 `gorm:"default:0" json:"refund_amount"` // statement refund total
+	FeeAmount           float64 `gorm:"default:0" json:"fee_amount"`    // provider fees net of refunded fees
+	NetAmount           float64 `gorm:"default:0" json:"net_amount"`    // trades less refunds and fees
+	LocalTradeAmount    float64 `gorm:"default:0" json:"local_trade_amount"`
+	LocalRefundAmount   float64 `gorm:"default:0" json:"local_refund_amount"`
+	MatchedCount        int     `gorm:"default:0" json:"matched_count"`
+	MissingLocalCount   int     `gorm:"default:0" json:"missing_local_count"`
+	MissingRemoteCount  int     `gorm:"default:0" json:"missing_remote_count"`
+	AmountMismatchCount int     `gorm:"default:0" json:"amount_mismatch_count"`
+	StatusMismatchCount int     `gorm:"default:0" json:"status_mismatch_count"`
+	CreatedBy           *string `gorm:"type:uuid" json:"created_by,omitempty"`
+}
+
+// TableName returns the table name for ReconciliationRun
+func (ReconciliationRun) TableName() string {
+	return "reconciliation_runs"
+}
+
+// MismatchCount returns the number of items that did not match
+func (r *ReconciliationRun) MismatchCount() int {
+	return r.MissingLocalCount + r.MissingRemoteCount + r.AmountMismatchCount + r.StatusMismatchCount
+}
+
+// Count tallies an item result on the run
+func (r *ReconciliationRun) Count(result string) {
+	switch result {
+	case ReconciliationMatched:
+		r.MatchedCount++
+	case ReconciliationMissingLocal:
+		r.MissingLocalCount++
+	case ReconciliationMissingRemote:
+		r.MissingRemoteCount++
+	case ReconciliationAmountMismatch:
+		r.AmountMismatchCount++
+	case ReconciliationStatusMismatch:
+		r.StatusMismatchCount++
+	}
+}
+
+// ReconciliationItem is one statement line or local record and how it
+// compared
+type ReconciliationItem struct {
+	BaseModel
+	RunID           string   `gorm:"type:uuid;not null;index" json:"run_id"`
+	Kind            string   `gorm:"not null" json:"kind"`
+	Result          string   `gorm:"not null" json:"result"`
+	TransactionID   string   `json:"transaction_id,omitempty"` // provider trade or refund number
+	MerchantNo      string   `json:"merchant_no,omitempty"`    // our payment or refund number
+	LocalID         *string  `gorm:"type:uuid" json:"local_id,omitempty"`
+	StatementAmount *float64 `json:"statement_amount,omitempty"`
+	LocalAmount     *float64 `json:"local_amount,omitempty"`
+	Fee             float64  `gorm:"default:0" json:"fee"`
+	StatementStatus string   `json:"statement_status,omitempty"`
+	LocalStatus     string   `json:"local_status,omitempty"`
+}
+
+// TableName returns the table name for ReconciliationItem
+func (ReconciliationItem) TableName() string {
+	return "reconciliation_items"
+}
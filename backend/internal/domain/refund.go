@@ -1,6 +1,10 @@
 package domain
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
 
 // RefundRequest represents a refund application from customer
 type RefundRequest struct {
@@ -26,6 +30,9 @@ type RefundRequest struct {
 	BankAccount        string     `json:"bank_account,omitempty"`
 	AccountHolder      string     `json:"account_holder,omitempty"`
 	CancellationReason string     `json:"cancellation_reason,omitempty"`
+
+	// Breakdown is the refund quote the amount was checked against
+	Breakdown datatypes.JSON `gorm:"type:jsonb" json:"breakdown,omitempty"`
 }
 
 // TableName returns the table name for RefundRequest
@@ -126,3 +133,42 @@ func (r *RefundRequest) MarkFailed() {
 	r.Status = RefundStatusFailed
 	r.UpdatedAt = time.Now()
 }
+
+// PaymentRefund is one refund attempt sent to a payment provider. Providers
+// settle refunds asynchronously, so an attempt stays processing until a
+// refund notification or a refund query reports its outcome
+type PaymentRefund struct {
+	BaseModel
+	PaymentID       string     `gorm:"not null;index" json:"payment_id"`
+	RefundRequestID *string    `gorm:"index" json:"refund_request_id,omitempty"`
+	RefundNo        string     `gorm:"not null;uniqueIndex" json:"refund_no"`
+	ThirdPartyID    string     `json:"third_party_id,omitempty"`
+	Provider        string     `gorm:"not null" json:"provider"`
+	Amount          float64    `gorm:"not null" json:"amount"`
+	Currency        string     `gorm:"default:CNY" json:"currency"`
+	Reason          string     `json:"reason,omitempty"`
+	Status          string     `gorm:"default:processing" json:"status"`
+	ProviderStatus  string     `json:"provider_status,omitempty"`
+	SucceededAt     *time.Time `json:"succeeded_at,omitempty"`
+	LastQueriedAt   *time.Time `json:"last_queried_at,omitempty"`
+	QueryCount      int        `gorm:"default:0" json:"query_count"`
+	NotifyData      string     `json:"-"`
+	ErrorMessage    string     `json:"error_message,omitempty"`
+}
+
+// TableName returns the table name for PaymentRefund
+func (PaymentRefund) TableName() string {
+	return "payment_refunds"
+}
+
+// PaymentRefundStatus constants
+const (
+	PaymentRefundStatusProcessing = "processing"
+	PaymentRefundStatusSuccess    = "success"
+	PaymentRefundStatusFailed     = "failed"
+)
+
+// IsSettled checks if the provider has reported the refund's outcome
+func (r *PaymentRefund) IsSettled() bool {
+	return r.Status != PaymentRefundStatusProcessing
+}
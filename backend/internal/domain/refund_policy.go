@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"encoding/json"
+	"sort"
+
+	"gorm.io/datatypes"
+)
+
+// RefundPolicy is a cancellation schedule: the share of the fare refunded
+// depending on how many days before departure the refund is requested.
+//
+// Policies are versioned. Editing a policy publishes a new version under the
+// same Code and retires the old one; orders keep a snapshot of the version
+// they were booked under, so later edits never change an existing booking.
+type RefundPolicy struct {
+	BaseModel
+	Code        string  `gorm:"not null;index" json:"code"` // shared by every version of the policy
+	Version     int     `gorm:"not null;default:1" json:"version"`
+	Name        string  `gorm:"not null" json:"name"`
+	Description string  `json:"description,omitempty"`
+	RouteID     *string `gorm:"index" json:"route_id,omitempty"`
+	CruiseID    *string `gorm:"index" json:"cruise_id,omitempty"`
+	PriceType   *string `json:"price_type,omitempty"`
+	Priority    int     `gorm:"not null;default:100" json:"priority"`
+	IsActive    bool    `gorm:"not null;default:true" json:"is_active"`
+
+	// Tiers, see RefundTier
+	Tiers datatypes.JSON `gorm:"type:jsonb;not null;default:'[]'" json:"tiers"`
+
+	// Port and service fees are refunded in full when refundable, and kept
+	// entirely otherwise; the tier percentage only applies to the fare
+	PortFeeRefundable    bool `gorm:"not null;default:false" json:"port_fee_refundable"`
+	ServiceFeeRefundable bool `gorm:"not null;default:false" json:"service_fee_refundable"`
+
+	SupersededBy *string `json:"superseded_by,omitempty"` // the version that replaced this one
+	CreatedBy    *string `json:"created_by,omitempty"`
+}
+
+// TableName returns the table name for RefundPolicy
+func (RefundPolicy) TableName() string {
+	return "refund_policies"
+}
+
+// RefundTier refunds Percent of the fare when the refund is requested at
+// least MinDays before departure
+type RefundTier struct {
+	MinDays int     `json:"min_days"`
+	Percent float64 `json:"percent"` // 0-100
+}
+
+// GetTiers returns the tiers, longest notice first
+func (p *RefundPolicy) GetTiers() []RefundTier {
+	var tiers []RefundTier
+	if len(p.Tiers) > 0 {
+		_ = json.Unmarshal(p.Tiers, &tiers)
+	}
+	return sortTiers(tiers)
+}
+
+// SetTiers sets the tiers
+func (p *RefundPolicy) SetTiers(tiers []RefundTier) {
+	data, _ := json.Marshal(sortTiers(tiers))
+	p.Tiers = datatypes.JSON(data)
+}
+
+// IsCurrent checks whether this is the latest version of the policy
+func (p *RefundPolicy) IsCurrent() bool {
+	return p.SupersededBy == nil
+}
+
+// AppliesTo checks whether the policy is scoped to the route, cruise and
+// price type
+func (p *RefundPolicy) AppliesTo(routeID, cruiseID, priceType string) bool {
+	if p.RouteID != nil && *p.RouteID != routeID {
+		return false
+	}
+	if p.CruiseID != nil && *p.CruiseID != cruiseID {
+		return false
+	}
+	if p.PriceType != nil && *p.PriceType != priceType {
+		return false
+	}
+	return true
+}
+
+// Specificity counts the scopes the policy is limited to; among policies of
+// equal priority the most specific one wins
+func (p *RefundPolicy) Specificity() int {
+	n := 0
+	for _, scope := range []*string{p.RouteID, p.CruiseID, p.PriceType} {
+		if scope != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Snapshot captures the terms an order is booked under
+func (p *RefundPolicy) Snapshot() RefundPolicySnapshot {
+	return RefundPolicySnapshot{
+		PolicyID:             p.ID.String(),
+		Code:                 p.Code,
+		Name:                 p.Name,
+		Version:              p.Version,
+		Tiers:                p.GetTiers(),
+		PortFeeRefundable:    p.PortFeeRefundable,
+		ServiceFeeRefundable: p.ServiceFeeRefundable,
+	}
+}
+
+// RefundPolicySnapshot is the copy of a refund policy stored on an order
+type RefundPolicySnapshot struct {
+	PolicyID             string       `json:"policy_id"`
+	Code                 string       `json:"code"`
+	Name                 string       `json:"name"`
+	Version              int          `json:"version"`
+	Tiers                []RefundTier `json:"tiers"`
+	PortFeeRefundable    bool         `json:"port_fee_refundable"`
+	ServiceFeeRefundable bool         `json:"service_fee_refundable"`
+}
+
+// TierAt returns the tier that applies daysToDeparture days before
+// departure; ok is false when the notice is shorter than every tier
+func (s RefundPolicySnapshot) TierAt(daysToDeparture int) (tier RefundTier, ok bool) {
+	for _, t := range sortTiers(s.Tiers) {
+		if daysToDeparture >= t.MinDays {
+			return t, true
+		}
+	}
+	return RefundTier{}, false
+}
+
+func sortTiers(tiers []RefundTier) []RefundTier {
+	if tiers == nil {
+		return []RefundTier{}
+	}
+	sorted := append([]RefundTier(nil), tiers...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].MinDays > sorted[j].MinDays
+	})
+	return sorted
+}
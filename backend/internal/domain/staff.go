@@ -0,0 +1,60 @@
+package domain
+
+import "time"
+
+// Staff is an admin console account. Staff are kept apart from customer
+// users; their roles are assigned through the RBAC policies, keyed by the
+// staff ID
+type Staff struct {
+	BaseModel
+	Username     string     `gorm:"uniqueIndex;size:50;not null" json:"username"`
+	PasswordHash string     `gorm:"size:255;not null" json:"-"`
+	Name         string     `gorm:"size:100;not null" json:"name"`
+	Email        string     `gorm:"size:100" json:"email,omitempty"`
+	Phone        string     `gorm:"size:20" json:"phone,omitempty"`
+	Status       string     `gorm:"size:20;not null;default:active" json:"status"`
+	LastLoginAt  *time.Time `json:"last_login_at,omitempty"`
+	LastLoginIP  string     `json:"last_login_ip,omitempty"`
+	Roles        []string   `gorm:"-" json:"roles"`
+}
+
+// TableName returns the table name for Staff
+func (Staff) TableName() string {
+	return "staff_users"
+}
+
+// Staff status constants
+const (
+	StaffStatusActive   = "active"
+	StaffStatusDisabled = "disabled"
+)
+
+// CanLogin checks if the staff account can log in
+func (s *Staff) CanLogin() bool {
+	return s.Status == StaffStatusActive
+}
+
+// PrimaryRole is the role carried in the staff's access token, the first
+// one assigned
+func (s *Staff) PrimaryRole() string {
+	if len(s.Roles) == 0 {
+		return ""
+	}
+	return s.Roles[0]
+}
+
+// StaffRole describes a role staff can be assigned. What the role may do
+// is kept in the RBAC policies under its name. Built-in roles are seeded
+// with the system and cannot be deleted
+type StaffRole struct {
+	BaseModel
+	Name        string `gorm:"uniqueIndex;size:50;not null" json:"name"`
+	DisplayName string `gorm:"size:100;not null" json:"display_name"`
+	Description string `json:"description,omitempty"`
+	Builtin     bool   `gorm:"not null;default:false" json:"builtin"`
+}
+
+// TableName returns the table name for StaffRole
+func (StaffRole) TableName() string {
+	return "staff_roles"
+}
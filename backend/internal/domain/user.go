@@ -12,6 +12,7 @@ type User struct {
 	WechatUnionID      string              `gorm:"size:100;column:wechat_unionid" json:"wechat_unionid,omitempty"`
 	WechatNickname     string              `gorm:"size:100;column:wechat_nickname" json:"wechat_nickname,omitempty"`
 	WechatAvatarURL    string              `gorm:"column:wechat_avatar_url" json:"wechat_avatar_url,omitempty"`
+	AlipayUserID       *string             `gorm:"uniqueIndex;size:64;column:alipay_user_id" json:"alipay_user_id,omitempty"`
 	Nickname           string              `gorm:"size:100" json:"nickname,omitempty"`
 	AvatarURL          string              `gorm:"column:avatar_url" json:"avatar_url,omitempty"`
 	RealName           string              `gorm:"size:100;column:real_name" json:"real_name,omitempty"`
@@ -26,6 +27,7 @@ type User struct {
 	IDBackImage        string              `gorm:"column:id_back_image" json:"id_back_image,omitempty"`
 	LastLoginAt        *time.Time          `gorm:"column:last_login_at" json:"last_login_at,omitempty"`
 	LastLoginIP        string              `gorm:"column:last_login_ip" json:"last_login_ip,omitempty"`
+	Points             int64               `gorm:"not null;default:0" json:"points"` // Loyalty points balance, redeemable towards orders
 	FrequentPassengers []FrequentPassenger `gorm:"foreignKey:UserID" json:"frequent_passengers,omitempty"`
 }
 
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"backend/internal/pagination"
+	"backend/internal/repository"
+	"backend/internal/response"
+	"backend/internal/service"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuditLogHandler handles the back-office audit log
+type AdminAuditLogHandler struct {
+	service service.AdminAuditService
+}
+
+// NewAdminAuditLogHandler creates a new admin audit log handler
+func NewAdminAuditLogHandler(service service.AdminAuditService) *AdminAuditLogHandler {
+	return &AdminAuditLogHandler{service: service}
+}
+
+// List godoc
+// @Summary List audit log entries (Admin)
+// @Description Search the log of back-office changes, newest first
+// @Tags admin-audit-logs
+// @Produce json
+// @Param actor_id query string false "Staff ID"
+// @Param resource query string false "Resource, e.g. cruises or access/staff"
+// @Param target_id query string false "Target ID"
+// @Param method query string false "HTTP method"
+// @Param result query string false "Result: success or failure"
+// @Param keyword query string false "Path contains"
+// @Param from query string false "From (YYYY-MM-DD)"
+// @Param to query string false "Up to and including (YYYY-MM-DD)"
+// @Param page query int false "Page number (default: 1)"
+// @Param page_size query int false "Page size (default: 20, max: 100)"
+// @Success 200 {object} response.Response{data=[]domain.AdminAuditLog,pagination=pagination.Paginator}
+// @Failure 400 {object} response.Response
+// @Router /admin/audit-logs [get]
+func (h *AdminAuditLogHandler) List(c *gin.Context) {
+	filters, ok := auditLogFilters(c)
+	if !ok {
+		return
+	}
+	paginator := pagination.NewPaginator(c)
+
+	entries, err := h.service.ListLogs(c.Request.Context(), filters, paginator)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, pagination.Result{Data: entries, Pagination: *paginator})
+}
+
+// Get godoc
+// @Summary Get an audit log entry (Admin)
+// @Tags admin-audit-logs
+// @Produce json
+// @Param id path string true "Entry ID"
+// @Success 200 {object} response.Response{data=domain.AdminAuditLog}
+// @Failure 404 {object} response.Response
+// @Router /admin/audit-logs/{id} [get]
+func (h *AdminAuditLogHandler) Get(c *gin.Context) {
+	entry, err := h.service.GetLog(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, service.ErrAuditLogNotFound) {
+			response.NotFound(c, "审计记录不存在")
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, entry)
+}
+
+// Export godoc
+// @Summary Export audit log entries as CSV (Admin)
+// @Description Export the entries matching the list filters, oldest first
+// @Tags admin-audit-logs
+// @Produce text/csv
+// @Param actor_id query string false "Staff ID"
+// @Param resource query string false "Resource"
+// @Param target_id query string false "Target ID"
+// @Param method query string false "HTTP method"
+// @Param result query string false "Result: success or failure"
+// @Param keyword query string false "Path contains"
+// @Param from query string false "From (YYYY-MM-DD)"
+// @Param to query string false "Up to and including (YYYY-MM-DD)"
+// @Success 200 {file} file
+// @Failure 400 {object} response.Response
+// @Router /admin/audit-logs/export [get]
+func (h *AdminAuditLogHandler) Export(c *gin.Context) {
+	filters, ok := auditLogFilters(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=audit-logs-%s.csv", time.Now().Format("20060102150405")))
+	if err := h.service.Export(c.Request.Context(), filters, c.Writer); err != nil {
+		c.Error(err)
+	}
+}
+
+// auditLogFilters reads the audit log filters from the query, responding
+// with an error when a date is invalid
+func auditLogFilters(c *gin.Context) (repository.AdminAuditLogFilters, bool) {
+	filters := repository.AdminAuditLogFilters{
+		ActorID:  c.Query("actor_id"),
+		Resource: c.Query("resource"),
+		TargetID: c.Query("target_id"),
+		Method:   c.Query("method"),
+		Result:   c.Query("result"),
+		Keyword:  c.Query("keyword"),
+	}
+	if v := c.Query("from"); v != "" {
+		from, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			response.BadRequest(c, "from 参数格式应为 YYYY-MM-DD")
+			return filters, false
+		}
+		filters.From = &from
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			response.BadRequest(c, "to 参数格式应为 YYYY-MM-DD")
+			return filters, false
+		}
+		to = to.AddDate(0, 0, 1)
+		filters.To = &to
+	}
+	return filters, true
+}
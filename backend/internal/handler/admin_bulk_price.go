@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"backend/internal/pagination"
+	"backend/internal/response"
+	"backend/internal/service"
+	"backend/internal/validator"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminBulkPriceHandler handles bulk price edits across voyages
+type AdminBulkPriceHandler struct {
+	service service.BulkPriceService
+}
+
+// NewAdminBulkPriceHandler creates a new admin bulk price handler
+func NewAdminBulkPriceHandler(service service.BulkPriceService) *AdminBulkPriceHandler {
+	return &AdminBulkPriceHandler{service: service}
+}
+
+// Preview godoc
+// @Summary Preview bulk price edit (Admin)
+// @Description Show the voyages and prices a bulk edit would change without writing
+// @Tags admin-pricing
+// @Accept json
+// @Produce json
+// @Param request body service.BulkPriceRequest true "Bulk price request"
+// @Success 200 {object} response.Response{data=service.BulkPriceResult}
+// @Failure 400 {object} response.Response
+// @Router /admin/pricing/bulk/preview [post]
+func (h *AdminBulkPriceHandler) Preview(c *gin.Context) {
+	var req service.BulkPriceRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	result, err := h.service.Preview(c.Request.Context(), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// Apply godoc
+// @Summary Apply bulk price edit (Admin)
+// @Description Apply a bulk price edit in one audited transaction
+// @Tags admin-pricing
+// @Accept json
+// @Produce json
+// @Param request body service.BulkPriceRequest true "Bulk price request"
+// @Success 200 {object} response.Response{data=service.BulkPriceResult}
+// @Failure 400 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /admin/pricing/bulk/apply [post]
+func (h *AdminBulkPriceHandler) Apply(c *gin.Context) {
+	var req service.BulkPriceRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	operatorID := c.GetString("userID")
+	result, err := h.service.Apply(c.Request.Context(), req, operatorID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// ListOperations godoc
+// @Summary List bulk price edits (Admin)
+// @Description List the audit trail of applied bulk price edits
+// @Tags admin-pricing
+// @Produce json
+// @Param page query int false "Page number (default: 1)"
+// @Param page_size query int false "Page size (default: 20, max: 100)"
+// @Success 200 {object} response.Response{data=[]domain.PriceBulkOperation,pagination=pagination.Paginator}
+// @Router /admin/pricing/bulk/operations [get]
+func (h *AdminBulkPriceHandler) ListOperations(c *gin.Context) {
+	result, err := h.service.ListOperations(c.Request.Context(), pagination.NewPaginator(c))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+func (h *AdminBulkPriceHandler) bind(c *gin.Context, req *service.BulkPriceRequest) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		response.BadRequest(c, err.Error())
+		return false
+	}
+	if err := validator.ValidateStruct(req); err != nil {
+		response.BadRequest(c, err.Error())
+		return false
+	}
+	return true
+}
+
+// handleError maps bulk price service errors to responses
+func (h *AdminBulkPriceHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidBulkPriceRequest):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, service.ErrBulkPriceNoVoyages):
+		response.NotFound(c, "没有符合条件的航次")
+	case errors.Is(err, service.ErrBulkPriceTooManyVoyages):
+		response.BadRequest(c, "匹配的航次过多，请缩小日期范围")
+	case errors.Is(err, service.ErrBulkPriceConflict):
+		response.Error(c, http.StatusConflict, "价格已被修改，请重新预览后再提交")
+	default:
+		response.Error(c, http.StatusInternalServerError, err.Error())
+	}
+}
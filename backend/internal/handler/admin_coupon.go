@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"backend/internal/repository"
+	"backend/internal/response"
+	"backend/internal/service"
+	"backend/internal/validator"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminCouponHandler handles coupon management and issuance
+type AdminCouponHandler struct {
+	service service.CouponService
+}
+
+// NewAdminCouponHandler creates a new admin coupon handler
+func NewAdminCouponHandler(service service.CouponService) *AdminCouponHandler {
+	return &AdminCouponHandler{service: service}
+}
+
+// List godoc
+// @Summary List coupons (Admin)
+// @Tags admin-coupons
+// @Produce json
+// @Param code query string false "Code contains"
+// @Param is_active query bool false "Active flag"
+// @Success 200 {object} response.Response{data=[]domain.Coupon}
+// @Router /admin/coupons [get]
+func (h *AdminCouponHandler) List(c *gin.Context) {
+	filters := repository.CouponFilters{Code: c.Query("code")}
+	if v := c.Query("is_active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			response.BadRequest(c, "is_active 参数无效")
+			return
+		}
+		filters.IsActive = &active
+	}
+
+	coupons, err := h.service.ListCoupons(c.Request.Context(), filters)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, coupons)
+}
+
+// Create godoc
+// @Summary Create coupon (Admin)
+// @Tags admin-coupons
+// @Accept json
+// @Produce json
+// @Param request body service.CouponRequest true "Coupon"
+// @Success 201 {object} response.Response{data=domain.Coupon}
+// @Failure 400 {object} response.Response
+// @Router /admin/coupons [post]
+func (h *AdminCouponHandler) Create(c *gin.Context) {
+	var req service.CouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if err := validator.ValidateStruct(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	coupon, err := h.service.CreateCoupon(c.Request.Context(), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, coupon)
+}
+
+// Get godoc
+// @Summary Get coupon (Admin)
+// @Tags admin-coupons
+// @Produce json
+// @Param id path string true "Coupon ID"
+// @Success 200 {object} response.Response{data=domain.Coupon}
+// @Failure 404 {object} response.Response
+// @Router /admin/coupons/{id} [get]
+func (h *AdminCouponHandler) Get(c *gin.Context) {
+	coupon, err := h.service.GetCoupon(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, coupon)
+}
+
+// Update godoc
+// @Summary Update coupon (Admin)
+// @Tags admin-coupons
+// @Accept json
+// @Produce json
+// @Param id path string true "Coupon ID"
+// @Param request body service.CouponRequest true "Coupon"
+// @Success 200 {object} response.Response{data=domain.Coupon}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /admin/coupons/{id} [put]
+func (h *AdminCouponHandler) Update(c *gin.Context) {
+	var req service.CouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if err := validator.ValidateStruct(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	coupon, err := h.service.UpdateCoupon(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, coupon)
+}
+
+// Delete godoc
+// @Summary Delete coupon (Admin)
+// @Tags admin-coupons
+// @Produce json
+// @Param id path string true "Coupon ID"
+// @Success 200 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /admin/coupons/{id} [delete]
+func (h *AdminCouponHandler) Delete(c *gin.Context) {
+	if err := h.service.DeleteCoupon(c.Request.Context(), c.Param("id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+// Issue godoc
+// @Summary Issue coupon to users (Admin)
+// @Description Put a coupon into the wallets of the given users
+// @Tags admin-coupons
+// @Accept json
+// @Produce json
+// @Param id path string true "Coupon ID"
+// @Param request body service.IssueCouponRequest true "Users"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /admin/coupons/{id}/issue [post]
+func (h *AdminCouponHandler) Issue(c *gin.Context) {
+	var req service.IssueCouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if err := validator.ValidateStruct(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	issued, err := h.service.IssueCoupon(c.Request.Context(), c.Param("id"), req.UserIDs)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{"issued": issued})
+}
+
+func (h *AdminCouponHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrCouponNotFound):
+		response.NotFound(c, "优惠券不存在")
+	case errors.Is(err, service.ErrInvalidCouponData), errors.Is(err, service.ErrCouponInactive):
+		response.BadRequest(c, err.Error())
+	default:
+		response.Error(c, http.StatusInternalServerError, err.Error())
+	}
+}
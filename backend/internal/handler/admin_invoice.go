@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"backend/internal/domain"
+	"backend/internal/pagination"
+	"backend/internal/repository"
+	"backend/internal/response"
+	"backend/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminInvoiceHandler handles the finance invoice review queue
+type AdminInvoiceHandler struct {
+	service service.InvoiceService
+}
+
+// NewAdminInvoiceHandler creates a new admin invoice handler
+func NewAdminInvoiceHandler(service service.InvoiceService) *AdminInvoiceHandler {
+	return &AdminInvoiceHandler{service: service}
+}
+
+// ReviewInvoiceRequest represents a finance review of an invoice
+type ReviewInvoiceRequest struct {
+	Note string `json:"note"`
+}
+
+// List godoc
+// @Summary List invoices (Finance)
+// @Description List invoices awaiting review by default, oldest first
+// @Tags admin-invoices
+// @Produce json
+// @Param status query string false "Status: pending, rejected, issued, failed or reversed (default: pending)"
+// @Param kind query string false "Kind: blue or red"
+// @Param buyer_type query string false "Buyer type: personal or company"
+// @Param order_id query string false "Order ID"
+// @Param page query int false "Page number (default: 1)"
+// @Param page_size query int false "Page size (default: 20, max: 100)"
+// @Success 200 {object} response.Response{data=[]domain.Invoice,pagination=pagination.Paginator}
+// @Router /admin/invoices [get]
+func (h *AdminInvoiceHandler) List(c *gin.Context) {
+	filters := repository.InvoiceFilters{
+		Status:    c.DefaultQuery("status", domain.InvoiceStatusPending),
+		Kind:      c.Query("kind"),
+		BuyerType: c.Query("buyer_type"),
+		OrderID:   c.Query("order_id"),
+	}
+	paginator := pagination.NewPaginator(c)
+
+	invoices, err := h.service.ListInvoices(c.Request.Context(), filters, paginator)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, pagination.Result{Data: invoices, Pagination: *paginator})
+}
+
+// Get godoc
+// @Summary Get an invoice (Finance)
+// @Tags admin-invoices
+// @Produce json
+// @Param id path string true "Invoice ID"
+// @Success 200 {object} response.Response{data=domain.Invoice}
+// @Failure 404 {object} response.Response
+// @Router /admin/invoices/{id} [get]
+func (h *AdminInvoiceHandler) Get(c *gin.Context) {
+	invoice, err := h.service.GetInvoice(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, invoice)
+}
+
+// Approve godoc
+// @Summary Approve and issue an invoice (Finance)
+// @Description Issue a pending invoice, or retry one the provider refused
+// @Tags admin-invoices
+// @Accept json
+// @Produce json
+// @Param id path string true "Invoice ID"
+// @Param request body ReviewInvoiceRequest true "Review"
+// @Success 200 {object} response.Response{data=domain.Invoice}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 502 {object} response.Response
+// @Router /admin/invoices/{id}/approve [post]
+func (h *AdminInvoiceHandler) Approve(c *gin.Context) {
+	var req ReviewInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	invoice, err := h.service.Approve(c.Request.Context(), c.Param("id"), c.GetString("userID"), req.Note)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, invoice)
+}
+
+// Reject godoc
+// @Summary Reject an invoice request (Finance)
+// @Tags admin-invoices
+// @Accept json
+// @Produce json
+// @Param id path string true "Invoice ID"
+// @Param request body ReviewInvoiceRequest true "Review"
+// @Success 200 {object} response.Response{data=domain.Invoice}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /admin/invoices/{id}/reject [post]
+func (h *AdminInvoiceHandler) Reject(c *gin.Context) {
+	var req ReviewInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if req.Note == "" {
+		response.BadRequest(c, "请填写驳回原因")
+		return
+	}
+
+	invoice, err := h.service.Reject(c.Request.Context(), c.Param("id"), c.GetString("userID"), req.Note)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, invoice)
+}
+
+func (h *AdminInvoiceHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvoiceNotFound):
+		response.NotFound(c, "发票不存在")
+	case errors.Is(err, service.ErrInvoiceNotReviewable):
+		response.BadRequest(c, "该发票无法审核")
+	case errors.Is(err, service.ErrInvoiceIssueFailed):
+		response.Error(c, http.StatusBadGateway, err.Error())
+	default:
+		response.Error(c, http.StatusInternalServerError, err.Error())
+	}
+}
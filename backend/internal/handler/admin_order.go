@@ -310,6 +310,76 @@ func (h *AdminOrderHandler) ProcessRefund(c *gin.Context) {
 	response.Success(c, refund)
 }
 
+// PreviewRefund godoc
+// @Summary Preview an order refund (Admin)
+// @Description Itemize what cancelling the order now would refund, for filing a refund on the customer's behalf
+// @Tags admin-refunds
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} response.Response{data=service.RefundQuote}
+// @Failure 404 {object} response.Response
+// @Router /admin/orders/{id}/refund-preview [get]
+func (h *AdminOrderHandler) PreviewRefund(c *gin.Context) {
+	quote, err := h.refundService.PreviewRefund(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleRefundError(c, err)
+		return
+	}
+
+	response.Success(c, quote)
+}
+
+// CreateRefund godoc
+// @Summary File a refund request for a customer (Admin)
+// @Description Customer service files a refund request on the customer's behalf; it is reviewed like one the customer filed
+// @Tags admin-refunds
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param request body AdminRefundRequest true "Refund request"
+// @Success 201 {object} response.Response{data=domain.RefundRequest}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /admin/orders/{id}/refunds [post]
+func (h *AdminOrderHandler) CreateRefund(c *gin.Context) {
+	var req AdminRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if err := validator.ValidateStruct(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	order, err := h.orderService.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleRefundError(c, err)
+		return
+	}
+	if req.CancellationReason == "" {
+		req.CancellationReason = domain.CancellationReasonCustomerRequest
+	}
+
+	refund, err := h.refundService.CreateRefundRequest(c.Request.Context(), service.CreateRefundRequest{
+		OrderID:            c.Param("id"),
+		UserID:             order.UserID,
+		RefundAmount:       req.RefundAmount,
+		RefundReason:       req.RefundReason,
+		RefundMethod:       req.RefundMethod,
+		BankName:           req.BankName,
+		BankAccount:        req.BankAccount,
+		AccountHolder:      req.AccountHolder,
+		CancellationReason: req.CancellationReason,
+	})
+	if err != nil {
+		handleRefundError(c, err)
+		return
+	}
+
+	response.Created(c, refund)
+}
+
 // GetOrderStatistics godoc
 // @Summary Get order statistics (Admin)
 // @Description Get order statistics for dashboard
@@ -339,6 +409,13 @@ type UpdateOrderStatusRequest struct {
 	Note   string `json:"note,omitempty"`
 }
 
+// AdminRefundRequest represents a refund filed by customer service on a
+// customer's behalf
+type AdminRefundRequest struct {
+	CustomerRefundRequest
+	CancellationReason string `json:"cancellation_reason" validate:"omitempty,oneof=customer_request voyage_cancelled cabin_upgrade other"`
+}
+
 // ReviewRefundRequest represents a refund review request
 type ReviewRefundRequest struct {
 	Note string `json:"note"`
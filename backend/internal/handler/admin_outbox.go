@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"backend/internal/domain"
+	"backend/internal/pagination"
+	"backend/internal/repository"
+	"backend/internal/response"
+	"backend/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminOutboxHandler handles inspection of outbox events and dead letters
+type AdminOutboxHandler struct {
+	service service.OutboxService
+}
+
+// NewAdminOutboxHandler creates a new admin outbox handler
+func NewAdminOutboxHandler(service service.OutboxService) *AdminOutboxHandler {
+	return &AdminOutboxHandler{service: service}
+}
+
+// List godoc
+// @Summary List outbox events (Admin)
+// @Description List events that could not be published (dead letters) by default, or events of another status
+// @Tags admin-outbox
+// @Produce json
+// @Param status query string false "Status: pending, published or dead (default: dead)"
+// @Param aggregate_id query string false "Aggregate (order) ID"
+// @Param subject query string false "Subject"
+// @Param page query int false "Page number (default: 1)"
+// @Param page_size query int false "Page size (default: 20, max: 100)"
+// @Success 200 {object} response.Response{data=[]domain.OutboxEvent,pagination=pagination.Paginator}
+// @Router /admin/outbox/events [get]
+func (h *AdminOutboxHandler) List(c *gin.Context) {
+	filters := repository.OutboxFilters{
+		Status:      c.DefaultQuery("status", domain.OutboxStatusDead),
+		AggregateID: c.Query("aggregate_id"),
+		Subject:     c.Query("subject"),
+	}
+	paginator := pagination.NewPaginator(c)
+
+	events, err := h.service.ListEvents(c.Request.Context(), filters, paginator)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, pagination.Result{Data: events, Pagination: *paginator})
+}
+
+// Get godoc
+// @Summary Get an outbox event (Admin)
+// @Tags admin-outbox
+// @Produce json
+// @Param id path string true "Event ID"
+// @Success 200 {object} response.Response{data=domain.OutboxEvent}
+// @Failure 404 {object} response.Response
+// @Router /admin/outbox/events/{id} [get]
+func (h *AdminOutboxHandler) Get(c *gin.Context) {
+	event, err := h.service.GetEvent(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, event)
+}
+
+// Retry godoc
+// @Summary Retry a dead outbox event (Admin)
+// @Description Put a dead event back in line; the relay publishes it on its next run
+// @Tags admin-outbox
+// @Produce json
+// @Param id path string true "Event ID"
+// @Success 200 {object} response.Response{data=domain.OutboxEvent}
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /admin/outbox/events/{id}/retry [post]
+func (h *AdminOutboxHandler) Retry(c *gin.Context) {
+	event, err := h.service.RetryEvent(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, event)
+}
+
+func (h *AdminOutboxHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrOutboxEventNotFound):
+		response.NotFound(c, "事件不存在")
+	case errors.Is(err, service.ErrOutboxEventNotDead):
+		response.Error(c, http.StatusConflict, "只能重试发布失败的事件")
+	default:
+		response.Error(c, http.StatusInternalServerError, err.Error())
+	}
+}
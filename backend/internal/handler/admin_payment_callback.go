@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"backend/internal/pagination"
+	"backend/internal/repository"
+	"backend/internal/response"
+	"backend/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminPaymentCallbackHandler handles the finance payment callback inspector
+type AdminPaymentCallbackHandler struct {
+	service service.PaymentCallbackService
+}
+
+// NewAdminPaymentCallbackHandler creates a new admin payment callback handler
+func NewAdminPaymentCallbackHandler(service service.PaymentCallbackService) *AdminPaymentCallbackHandler {
+	return &AdminPaymentCallbackHandler{service: service}
+}
+
+// ReplayPaymentCallbackRequest represents a manual replay of a callback
+type ReplayPaymentCallbackRequest struct {
+	Note string `json:"note" binding:"required,max=500"` // why the callback is replayed
+}
+
+// List godoc
+// @Summary List payment callbacks (Finance)
+// @Description Search the log of inbound payment and refund callbacks
+// @Tags admin-payment-callbacks
+// @Produce json
+// @Param provider query string false "Provider: wechat or alipay"
+// @Param kind query string false "Kind: payment or refund"
+// @Param status query string false "Status: received, processed or failed"
+// @Param verified query bool false "Passed signature verification"
+// @Param reference query string false "Payment or refund number"
+// @Param keyword query string false "Body contains"
+// @Param replay_of query string false "Replays of this callback ID"
+// @Param from query string false "Received from (YYYY-MM-DD)"
+// @Param to query string false "Received up to and including (YYYY-MM-DD)"
+// @Param page query int false "Page number (default: 1)"
+// @Param page_size query int false "Page size (default: 20, max: 100)"
+// @Success 200 {object} response.Response{data=[]domain.PaymentCallback,pagination=pagination.Paginator}
+// @Failure 400 {object} response.Response
+// @Router /admin/payment-callbacks [get]
+func (h *AdminPaymentCallbackHandler) List(c *gin.Context) {
+	filters := repository.PaymentCallbackFilters{
+		Provider:   c.Query("provider"),
+		Kind:       c.Query("kind"),
+		Status:     c.Query("status"),
+		Reference:  c.Query("reference"),
+		Keyword:    c.Query("keyword"),
+		ReplayOfID: c.Query("replay_of"),
+	}
+	if v := c.Query("verified"); v != "" {
+		verified, err := strconv.ParseBool(v)
+		if err != nil {
+			response.BadRequest(c, "verified 参数无效")
+			return
+		}
+		filters.Verified = &verified
+	}
+	if v := c.Query("from"); v != "" {
+		from, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			response.BadRequest(c, "from 参数格式应为 YYYY-MM-DD")
+			return
+		}
+		filters.From = &from
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			response.BadRequest(c, "to 参数格式应为 YYYY-MM-DD")
+			return
+		}
+		to = to.AddDate(0, 0, 1)
+		filters.To = &to
+	}
+	paginator := pagination.NewPaginator(c)
+
+	callbacks, err := h.service.ListCallbacks(c.Request.Context(), filters, paginator)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, pagination.Result{Data: callbacks, Pagination: *paginator})
+}
+
+// Get godoc
+// @Summary Get a payment callback (Finance)
+// @Tags admin-payment-callbacks
+// @Produce json
+// @Param id path string true "Callback ID"
+// @Success 200 {object} response.Response{data=domain.PaymentCallback}
+// @Failure 404 {object} response.Response
+// @Router /admin/payment-callbacks/{id} [get]
+func (h *AdminPaymentCallbackHandler) Get(c *gin.Context) {
+	callback, err := h.service.GetCallback(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, callback)
+}
+
+// Replay godoc
+// @Summary Replay a payment callback (Finance)
+// @Description Process a stored callback that failed again, e.g. after a fix. The replay is logged as a new callback
+// @Description with the operator and note, and its outcome is returned; settled payments and refunds are not changed twice
+// @Tags admin-payment-callbacks
+// @Accept json
+// @Produce json
+// @Param id path string true "Callback ID"
+// @Param request body ReplayPaymentCallbackRequest true "Replay"
+// @Success 200 {object} response.Response{data=domain.PaymentCallback}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /admin/payment-callbacks/{id}/replay [post]
+func (h *AdminPaymentCallbackHandler) Replay(c *gin.Context) {
+	var req ReplayPaymentCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	callback, err := h.service.ReplayCallback(c.Request.Context(), c.Param("id"), c.GetString("userID"), req.Note)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, callback)
+}
+
+func (h *AdminPaymentCallbackHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrPaymentCallbackNotFound):
+		response.NotFound(c, "回调记录不存在")
+	case errors.Is(err, service.ErrPaymentCallbackProcessed):
+		response.Error(c, http.StatusConflict, "该回调已处理成功，无需重放")
+	default:
+		response.Error(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"backend/internal/response"
+	"backend/internal/service"
+	"backend/internal/validator"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminPricingHandler handles dynamic pricing rules and repricing
+type AdminPricingHandler struct {
+	engine service.PricingEngine
+}
+
+// NewAdminPricingHandler creates a new admin pricing handler
+func NewAdminPricingHandler(engine service.PricingEngine) *AdminPricingHandler {
+	return &AdminPricingHandler{engine: engine}
+}
+
+// ListRules godoc
+// @Summary List pricing rules (Admin)
+// @Description List dynamic pricing rules ordered by priority
+// @Tags admin-pricing
+// @Produce json
+// @Param voyage_id query string false "Voyage ID"
+// @Param cabin_type_id query string false "Cabin type ID"
+// @Param is_active query bool false "Active flag"
+// @Success 200 {object} response.Response{data=[]domain.PricingRule}
+// @Router /admin/pricing/rules [get]
+func (h *AdminPricingHandler) ListRules(c *gin.Context) {
+	filters := repository.PricingRuleFilters{
+		VoyageID:    c.Query("voyage_id"),
+		CabinTypeID: c.Query("cabin_type_id"),
+	}
+	if v := c.Query("is_active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			response.BadRequest(c, "is_active 参数无效")
+			return
+		}
+		filters.IsActive = &active
+	}
+
+	rules, err := h.engine.ListRules(c.Request.Context(), filters)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, rules)
+}
+
+// CreateRule godoc
+// @Summary Create pricing rule (Admin)
+// @Description Create a dynamic pricing rule
+// @Tags admin-pricing
+// @Accept json
+// @Produce json
+// @Param request body service.PricingRuleRequest true "Pricing rule"
+// @Success 201 {object} response.Response{data=domain.PricingRule}
+// @Failure 400 {object} response.Response
+// @Router /admin/pricing/rules [post]
+func (h *AdminPricingHandler) CreateRule(c *gin.Context) {
+	var req service.PricingRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if err := validator.ValidateStruct(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	rule, err := h.engine.CreateRule(c.Request.Context(), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, rule)
+}
+
+// GetRule godoc
+// @Summary Get pricing rule (Admin)
+// @Tags admin-pricing
+// @Produce json
+// @Param id path string true "Rule ID"
+// @Success 200 {object} response.Response{data=domain.PricingRule}
+// @Failure 404 {object} response.Response
+// @Router /admin/pricing/rules/{id} [get]
+func (h *AdminPricingHandler) GetRule(c *gin.Context) {
+	rule, err := h.engine.GetRule(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, rule)
+}
+
+// UpdateRule godoc
+// @Summary Update pricing rule (Admin)
+// @Tags admin-pricing
+// @Accept json
+// @Produce json
+// @Param id path string true "Rule ID"
+// @Param request body service.PricingRuleRequest true "Pricing rule"
+// @Success 200 {object} response.Response{data=domain.PricingRule}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /admin/pricing/rules/{id} [put]
+func (h *AdminPricingHandler) UpdateRule(c *gin.Context) {
+	var req service.PricingRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if err := validator.ValidateStruct(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	rule, err := h.engine.UpdateRule(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, rule)
+}
+
+// DeleteRule godoc
+// @Summary Delete pricing rule (Admin)
+// @Tags admin-pricing
+// @Produce json
+// @Param id path string true "Rule ID"
+// @Success 200 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /admin/pricing/rules/{id} [delete]
+func (h *AdminPricingHandler) DeleteRule(c *gin.Context) {
+	if err := h.engine.DeleteRule(c.Request.Context(), c.Param("id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+// Preview godoc
+// @Summary Preview repricing (Admin)
+// @Description Dry-run the pricing rules for a voyage without writing prices
+// @Tags admin-pricing
+// @Produce json
+// @Param id path string true "Voyage ID"
+// @Success 200 {object} response.Response{data=service.RepricingResult}
+// @Failure 404 {object} response.Response
+// @Router /admin/pricing/voyages/{id}/preview [get]
+func (h *AdminPricingHandler) Preview(c *gin.Context) {
+	result, err := h.engine.Preview(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// Apply godoc
+// @Summary Apply repricing (Admin)
+// @Description Evaluate the pricing rules for a voyage and write new price versions
+// @Tags admin-pricing
+// @Produce json
+// @Param id path string true "Voyage ID"
+// @Success 200 {object} response.Response{data=service.RepricingResult}
+// @Router /admin/pricing/voyages/{id}/apply [post]
+func (h *AdminPricingHandler) Apply(c *gin.Context) {
+	result, err := h.engine.Apply(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// SetManualOverride godoc
+// @Summary Pin or release a cabin price (Admin)
+// @Description Manually overridden prices are skipped by the pricing engine
+// @Tags admin-pricing
+// @Accept json
+// @Produce json
+// @Param id path string true "Price ID"
+// @Param request body service.ManualOverrideRequest true "Override request"
+// @Success 200 {object} response.Response{data=domain.CabinPrice}
+// @Failure 404 {object} response.Response
+// @Router /admin/pricing/prices/{id}/override [put]
+func (h *AdminPricingHandler) SetManualOverride(c *gin.Context) {
+	var req service.ManualOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if err := validator.ValidateStruct(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	ctx := repository.WithPriceChange(c.Request.Context(), domain.PriceSourceManual, c.GetString("userID"), "manual override")
+	price, err := h.engine.SetManualOverride(ctx, c.Param("id"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, price)
+}
+
+// handleError maps pricing service errors to responses
+func (h *AdminPricingHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrPricingRuleNotFound):
+		response.NotFound(c, "定价规则不存在")
+	case errors.Is(err, service.ErrPriceNotFound):
+		response.NotFound(c, "价格不存在")
+	case errors.Is(err, service.ErrInvalidPricingRule):
+		response.BadRequest(c, err.Error())
+	default:
+		response.Error(c, http.StatusInternalServerError, err.Error())
+	}
+}
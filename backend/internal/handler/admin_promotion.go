@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"backend/internal/analytics"
+	"backend/internal/repository"
+	"backend/internal/response"
+	"backend/internal/service"
+	"backend/internal/validator"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminPromotionHandler handles scheduled promotion campaigns
+type AdminPromotionHandler struct {
+	service  service.PromotionService
+	analysis analytics.CampaignAnalysis
+}
+
+// NewAdminPromotionHandler creates a new admin promotion handler
+func NewAdminPromotionHandler(service service.PromotionService, analysis analytics.CampaignAnalysis) *AdminPromotionHandler {
+	return &AdminPromotionHandler{service: service, analysis: analysis}
+}
+
+// List godoc
+// @Summary List promotion campaigns (Admin)
+// @Tags admin-promotions
+// @Produce json
+// @Param status query string false "scheduled, active, ended or cancelled"
+// @Success 200 {object} response.Response{data=[]domain.PromotionCampaign}
+// @Router /admin/promotions [get]
+func (h *AdminPromotionHandler) List(c *gin.Context) {
+	campaigns, err := h.service.ListCampaigns(c.Request.Context(), repository.PromotionFilters{Status: c.Query("status")})
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, campaigns)
+}
+
+// Create godoc
+// @Summary Create a promotion campaign (Admin)
+// @Description Schedule a discount on voyages and cabin types; it is applied and removed automatically
+// @Tags admin-promotions
+// @Accept json
+// @Produce json
+// @Param request body service.PromotionCampaignRequest true "Campaign"
+// @Success 201 {object} response.Response{data=domain.PromotionCampaign}
+// @Failure 400 {object} response.Response
+// @Router /admin/promotions [post]
+func (h *AdminPromotionHandler) Create(c *gin.Context) {
+	var req service.PromotionCampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if err := validator.ValidateStruct(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	campaign, err := h.service.CreateCampaign(c.Request.Context(), c.GetString("userID"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, campaign)
+}
+
+// Get godoc
+// @Summary Get a promotion campaign (Admin)
+// @Tags admin-promotions
+// @Produce json
+// @Param id path string true "Campaign ID"
+// @Success 200 {object} response.Response{data=domain.PromotionCampaign}
+// @Failure 404 {object} response.Response
+// @Router /admin/promotions/{id} [get]
+func (h *AdminPromotionHandler) Get(c *gin.Context) {
+	campaign, err := h.service.GetCampaign(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, campaign)
+}
+
+// Update godoc
+// @Summary Update a scheduled promotion campaign (Admin)
+// @Tags admin-promotions
+// @Accept json
+// @Produce json
+// @Param id path string true "Campaign ID"
+// @Param request body service.PromotionCampaignRequest true "Campaign"
+// @Success 200 {object} response.Response{data=domain.PromotionCampaign}
+// @Failure 400 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /admin/promotions/{id} [put]
+func (h *AdminPromotionHandler) Update(c *gin.Context) {
+	var req service.PromotionCampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if err := validator.ValidateStruct(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	campaign, err := h.service.UpdateCampaign(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, campaign)
+}
+
+// Cancel godoc
+// @Summary Cancel a promotion campaign (Admin)
+// @Description Cancel a scheduled campaign, or end a running one now and restore its prices
+// @Tags admin-promotions
+// @Produce json
+// @Param id path string true "Campaign ID"
+// @Success 200 {object} response.Response{data=domain.PromotionCampaign}
+// @Failure 409 {object} response.Response
+// @Router /admin/promotions/{id}/cancel [post]
+func (h *AdminPromotionHandler) Cancel(c *gin.Context) {
+	campaign, err := h.service.CancelCampaign(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, campaign)
+}
+
+// ListPrices godoc
+// @Summary List prices changed by a campaign (Admin)
+// @Tags admin-promotions
+// @Produce json
+// @Param id path string true "Campaign ID"
+// @Success 200 {object} response.Response{data=[]domain.PromotionPrice}
+// @Router /admin/promotions/{id}/prices [get]
+func (h *AdminPromotionHandler) ListPrices(c *gin.Context) {
+	prices, err := h.service.ListCampaignPrices(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, prices)
+}
+
+// Uptake godoc
+// @Summary Campaign uptake (Admin)
+// @Description Bookings, revenue and discount attributed to a campaign
+// @Tags admin-promotions
+// @Produce json
+// @Param id path string true "Campaign ID"
+// @Success 200 {object} response.Response{data=analytics.CampaignUptake}
+// @Failure 404 {object} response.Response
+// @Router /admin/promotions/{id}/uptake [get]
+func (h *AdminPromotionHandler) Uptake(c *gin.Context) {
+	if _, err := h.service.GetCampaign(c.Request.Context(), c.Param("id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	uptake, err := h.analysis.Uptake(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, uptake)
+}
+
+// Calendar godoc
+// @Summary Promotion calendar (Admin)
+// @Description Campaigns running on each day of a date range (at most 92 days)
+// @Tags admin-promotions
+// @Produce json
+// @Param from query string true "Start date (YYYY-MM-DD)"
+// @Param to query string true "End date (YYYY-MM-DD)"
+// @Success 200 {object} response.Response{data=service.PromotionCalendar}
+// @Failure 400 {object} response.Response
+// @Router /admin/promotions/calendar [get]
+func (h *AdminPromotionHandler) Calendar(c *gin.Context) {
+	from, err := time.ParseInLocation("2006-01-02", c.Query("from"), time.Local)
+	if err != nil {
+		response.BadRequest(c, "from 参数格式应为 YYYY-MM-DD")
+		return
+	}
+	to, err := time.ParseInLocation("2006-01-02", c.Query("to"), time.Local)
+	if err != nil {
+		response.BadRequest(c, "to 参数格式应为 YYYY-MM-DD")
+		return
+	}
+
+	calendar, err := h.service.Calendar(c.Request.Context(), from, to)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, calendar)
+}
+
+func (h *AdminPromotionHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrPromotionNotFound):
+		response.NotFound(c, "促销活动不存在")
+	case errors.Is(err, service.ErrInvalidPromotion):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, service.ErrPromotionNotEditable):
+		response.Error(c, http.StatusConflict, "仅待生效的活动可以修改")
+	case errors.Is(err, service.ErrPromotionFinished):
+		response.Error(c, http.StatusConflict, "活动已结束或已取消")
+	default:
+		response.Error(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"backend/internal/repository"
+	"backend/internal/response"
+	"backend/internal/service"
+	"backend/internal/validator"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxStatementSize bounds an uploaded statement file
+const maxStatementSize = 32 << 20
+
+// AdminReconciliationHandler handles daily payment reconciliation
+type AdminReconciliationHandler struct {
+	service service.ReconciliationService
+}
+
+// NewAdminReconciliationHandler creates a new admin reconciliation handler
+func NewAdminReconciliationHandler(service service.ReconciliationService) *AdminReconciliationHandler {
+	return &AdminReconciliationHandler{service: service}
+}
+
+// Import godoc
+// @Summary Import a provider statement (Finance)
+// @Description Compare a provider's daily trade and refund statements (CSV) with local payments and refunds
+// @Tags admin-reconciliation
+// @Accept multipart/form-data
+// @Produce json
+// @Param provider formData string true "wechat or alipay"
+// @Param date formData string true "Statement date (YYYY-MM-DD)"
+// @Param trade_file formData file true "Trade statement, or WeChat's combined bill"
+// @Param refund_file formData file false "Refund statement"
+// @Success 201 {object} response.Response{data=domain.ReconciliationRun}
+// @Failure 400 {object} response.Response
+// @Router /admin/reconciliation/runs [post]
+func (h *AdminReconciliationHandler) Import(c *gin.Context) {
+	req := service.ImportStatementRequest{
+		Provider: c.PostForm("provider"),
+		Date:     c.PostForm("date"),
+	}
+	if err := validator.ValidateStruct(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	trades, err := openStatement(c, "trade_file")
+	if err != nil {
+		response.BadRequest(c, "请上传交易对账单")
+		return
+	}
+	defer trades.Close()
+	req.Trades = io.LimitReader(trades, maxStatementSize)
+
+	if refunds, err := openStatement(c, "refund_file"); err == nil {
+		defer refunds.Close()
+		req.Refunds = io.LimitReader(refunds, maxStatementSize)
+	}
+
+	run, err := h.service.Import(c.Request.Context(), c.GetString("userID"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, run)
+}
+
+// ListRuns godoc
+// @Summary List reconciliation runs (Finance)
+// @Tags admin-reconciliation
+// @Produce json
+// @Param provider query string false "wechat or alipay"
+// @Param from query string false "First statement date (YYYY-MM-DD)"
+// @Param to query string false "Last statement date (YYYY-MM-DD)"
+// @Success 200 {object} response.Response{data=[]domain.ReconciliationRun}
+// @Router /admin/reconciliation/runs [get]
+func (h *AdminReconciliationHandler) ListRuns(c *gin.Context) {
+	runs, err := h.service.ListRuns(c.Request.Context(), repository.ReconciliationFilters{
+		Provider: c.Query("provider"),
+		From:     c.Query("from"),
+		To:       c.Query("to"),
+	})
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, runs)
+}
+
+// GetRun godoc
+// @Summary Get a reconciliation run (Finance)
+// @Tags admin-reconciliation
+// @Produce json
+// @Param id path string true "Run ID"
+// @Success 200 {object} response.Response{data=domain.ReconciliationRun}
+// @Failure 404 {object} response.Response
+// @Router /admin/reconciliation/runs/{id} [get]
+func (h *AdminReconciliationHandler) GetRun(c *gin.Context) {
+	run, err := h.service.GetRun(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, run)
+}
+
+// ListItems godoc
+// @Summary List reconciliation items (Finance)
+// @Tags admin-reconciliation
+// @Produce json
+// @Param id path string true "Run ID"
+// @Param result query string false "matched, missing_local, missing_remote, amount_mismatch or status_mismatch"
+// @Success 200 {object} response.Response{data=[]domain.ReconciliationItem}
+// @Failure 404 {object} response.Response
+// @Router /admin/reconciliation/runs/{id}/items [get]
+func (h *AdminReconciliationHandler) ListItems(c *gin.Context) {
+	items, err := h.service.ListItems(c.Request.Context(), c.Param("id"), c.Query("result"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, items)
+}
+
+// Export godoc
+// @Summary Export a reconciliation run as CSV (Finance)
+// @Tags admin-reconciliation
+// @Produce text/csv
+// @Param id path string true "Run ID"
+// @Success 200 {file} file
+// @Failure 404 {object} response.Response
+// @Router /admin/reconciliation/runs/{id}/export [get]
+func (h *AdminReconciliationHandler) Export(c *gin.Context) {
+	run, err := h.service.GetRun(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=reconciliation-%s-%s.csv", run.Provider, run.StatementDate))
+	if err := h.service.Export(c.Request.Context(), run.ID.String(), c.Writer); err != nil {
+		c.Error(err)
+	}
+}
+
+func (h *AdminReconciliationHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrReconciliationNotFound):
+		response.NotFound(c, "对账记录不存在")
+	case errors.Is(err, service.ErrInvalidReconciliation):
+		response.BadRequest(c, err.Error())
+	default:
+		response.Error(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func openStatement(c *gin.Context, field string) (multipart.File, error) {
+	header, err := c.FormFile(field)
+	if err != nil {
+		return nil, err
+	}
+	return header.Open()
+}
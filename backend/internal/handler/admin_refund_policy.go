@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"backend/internal/repository"
+	"backend/internal/response"
+	"backend/internal/service"
+	"backend/internal/validator"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminRefundPolicyHandler handles refund policy management
+type AdminRefundPolicyHandler struct {
+	service service.RefundPolicyService
+}
+
+// NewAdminRefundPolicyHandler creates a new admin refund policy handler
+func NewAdminRefundPolicyHandler(service service.RefundPolicyService) *AdminRefundPolicyHandler {
+	return &AdminRefundPolicyHandler{service: service}
+}
+
+// SetRefundPolicyStatusRequest represents a request to enable or disable a policy
+type SetRefundPolicyStatusRequest struct {
+	IsActive bool `json:"is_active"`
+}
+
+// List godoc
+// @Summary List refund policies (Admin)
+// @Description List the current version of each refund policy, or every version of one policy
+// @Tags admin-refund-policies
+// @Produce json
+// @Param code query string false "Policy code"
+// @Param route_id query string false "Route ID"
+// @Param cruise_id query string false "Cruise ID"
+// @Param is_active query bool false "Active flag"
+// @Param all_versions query bool false "Include superseded versions"
+// @Success 200 {object} response.Response{data=[]domain.RefundPolicy}
+// @Router /admin/refund-policies [get]
+func (h *AdminRefundPolicyHandler) List(c *gin.Context) {
+	filters := repository.RefundPolicyFilters{
+		Code:        c.Query("code"),
+		RouteID:     c.Query("route_id"),
+		CruiseID:    c.Query("cruise_id"),
+		AllVersions: c.Query("all_versions") == "true",
+	}
+	if v := c.Query("is_active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			response.BadRequest(c, "is_active 参数无效")
+			return
+		}
+		filters.IsActive = &active
+	}
+
+	policies, err := h.service.ListPolicies(c.Request.Context(), filters)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, policies)
+}
+
+// Create godoc
+// @Summary Create a refund policy (Admin)
+// @Description Create a tiered refund policy scoped to a route, cruise and/or price type
+// @Tags admin-refund-policies
+// @Accept json
+// @Produce json
+// @Param request body service.RefundPolicyRequest true "Refund policy"
+// @Success 201 {object} response.Response{data=domain.RefundPolicy}
+// @Failure 400 {object} response.Response
+// @Router /admin/refund-policies [post]
+func (h *AdminRefundPolicyHandler) Create(c *gin.Context) {
+	var req service.RefundPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if err := validator.ValidateStruct(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	policy, err := h.service.CreatePolicy(c.Request.Context(), c.GetString("userID"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, policy)
+}
+
+// Get godoc
+// @Summary Get a refund policy (Admin)
+// @Tags admin-refund-policies
+// @Produce json
+// @Param id path string true "Policy ID"
+// @Success 200 {object} response.Response{data=domain.RefundPolicy}
+// @Failure 404 {object} response.Response
+// @Router /admin/refund-policies/{id} [get]
+func (h *AdminRefundPolicyHandler) Get(c *gin.Context) {
+	policy, err := h.service.GetPolicy(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, policy)
+}
+
+// Update godoc
+// @Summary Publish a new version of a refund policy (Admin)
+// @Description The edited terms become a new version; orders already booked keep the version they were booked under
+// @Tags admin-refund-policies
+// @Accept json
+// @Produce json
+// @Param id path string true "Policy ID"
+// @Param request body service.RefundPolicyRequest true "Refund policy"
+// @Success 200 {object} response.Response{data=domain.RefundPolicy}
+// @Failure 400 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /admin/refund-policies/{id} [put]
+func (h *AdminRefundPolicyHandler) Update(c *gin.Context) {
+	var req service.RefundPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if err := validator.ValidateStruct(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	policy, err := h.service.UpdatePolicy(c.Request.Context(), c.GetString("userID"), c.Param("id"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, policy)
+}
+
+// SetStatus godoc
+// @Summary Enable or disable a refund policy (Admin)
+// @Tags admin-refund-policies
+// @Accept json
+// @Produce json
+// @Param id path string true "Policy ID"
+// @Param request body SetRefundPolicyStatusRequest true "Status"
+// @Success 200 {object} response.Response{data=domain.RefundPolicy}
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /admin/refund-policies/{id}/status [put]
+func (h *AdminRefundPolicyHandler) SetStatus(c *gin.Context) {
+	var req SetRefundPolicyStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	policy, err := h.service.SetActive(c.Request.Context(), c.Param("id"), req.IsActive)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, policy)
+}
+
+func (h *AdminRefundPolicyHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrRefundPolicyNotFound):
+		response.NotFound(c, "退改规则不存在")
+	case errors.Is(err, service.ErrInvalidRefundPolicy):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, service.ErrRefundPolicySuperseded):
+		response.Error(c, http.StatusConflict, "该版本已被新版本替代，请修改最新版本")
+	default:
+		response.Error(c, http.StatusInternalServerError, err.Error())
+	}
+}
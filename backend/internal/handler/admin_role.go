@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"backend/internal/response"
+	"backend/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminRoleHandler handles staff role management
+type AdminRoleHandler struct {
+	service service.RoleService
+}
+
+// NewAdminRoleHandler creates a new admin role handler
+func NewAdminRoleHandler(service service.RoleService) *AdminRoleHandler {
+	return &AdminRoleHandler{service: service}
+}
+
+// List godoc
+// @Summary List roles (Super Admin)
+// @Description List built-in and custom roles with their permissions and assigned staff
+// @Tags admin-access
+// @Produce json
+// @Success 200 {object} response.Response{data=[]service.RoleDetail}
+// @Router /admin/access/roles [get]
+func (h *AdminRoleHandler) List(c *gin.Context) {
+	roles, err := h.service.ListRoles(c.Request.Context())
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, roles)
+}
+
+// Get godoc
+// @Summary Get a role (Super Admin)
+// @Tags admin-access
+// @Produce json
+// @Param name path string true "Role name"
+// @Success 200 {object} response.Response{data=service.RoleDetail}
+// @Failure 404 {object} response.Response
+// @Router /admin/access/roles/{name} [get]
+func (h *AdminRoleHandler) Get(c *gin.Context) {
+	role, err := h.service.GetRole(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, role)
+}
+
+// Create godoc
+// @Summary Create a custom role (Super Admin)
+// @Description Role names are lowercase letters, digits and underscores. Permissions take effect on every instance at once
+// @Tags admin-access
+// @Accept json
+// @Produce json
+// @Param request body service.RoleRequest true "Role"
+// @Success 201 {object} response.Response{data=service.RoleDetail}
+// @Failure 400 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /admin/access/roles [post]
+func (h *AdminRoleHandler) Create(c *gin.Context) {
+	var req service.RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	role, err := h.service.CreateRole(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, role)
+}
+
+// Update godoc
+// @Summary Update a role (Super Admin)
+// @Description Update the description and, when given, replace the permissions. Super admin permissions cannot be changed
+// @Tags admin-access
+// @Accept json
+// @Produce json
+// @Param name path string true "Role name"
+// @Param request body service.RoleRequest true "Role"
+// @Success 200 {object} response.Response{data=service.RoleDetail}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /admin/access/roles/{name} [put]
+func (h *AdminRoleHandler) Update(c *gin.Context) {
+	var req service.RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	role, err := h.service.UpdateRole(c.Request.Context(), c.Param("name"), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, role)
+}
+
+// Delete godoc
+// @Summary Delete a custom role (Super Admin)
+// @Tags admin-access
+// @Produce json
+// @Param name path string true "Role name"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /admin/access/roles/{name} [delete]
+func (h *AdminRoleHandler) Delete(c *gin.Context) {
+	if err := h.service.DeleteRole(c.Request.Context(), c.Param("name")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{"message": "角色已删除"})
+}
+
+// ListPermissions godoc
+// @Summary List grantable permissions (Super Admin)
+// @Tags admin-access
+// @Produce json
+// @Success 200 {object} response.Response{data=service.PermissionCatalog}
+// @Router /admin/access/permissions [get]
+func (h *AdminRoleHandler) ListPermissions(c *gin.Context) {
+	response.Success(c, h.service.ListPermissions())
+}
+
+func (h *AdminRoleHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrRoleNotFound):
+		response.NotFound(c, "角色不存在")
+	case errors.Is(err, service.ErrRoleExists):
+		response.Error(c, http.StatusConflict, "角色已存在")
+	case errors.Is(err, service.ErrRoleInUse):
+		response.Error(c, http.StatusConflict, "角色仍分配给员工，无法删除")
+	case errors.Is(err, service.ErrRoleBuiltin):
+		response.BadRequest(c, "内置角色不能删除")
+	case errors.Is(err, service.ErrRoleImmutable):
+		response.BadRequest(c, "超级管理员的权限不能修改")
+	case errors.Is(err, service.ErrInvalidRoleName):
+		response.BadRequest(c, "角色名只能包含小写字母、数字和下划线，且以字母开头")
+	case errors.Is(err, service.ErrInvalidPermission):
+		response.BadRequest(c, err.Error())
+	default:
+		response.Error(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"backend/internal/pagination"
+	"backend/internal/repository"
+	"backend/internal/response"
+	"backend/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminStaffHandler handles staff account management
+type AdminStaffHandler struct {
+	service service.StaffService
+}
+
+// NewAdminStaffHandler creates a new admin staff handler
+func NewAdminStaffHandler(service service.StaffService) *AdminStaffHandler {
+	return &AdminStaffHandler{service: service}
+}
+
+// ResetStaffPasswordRequest represents a password reset by an administrator
+type ResetStaffPasswordRequest struct {
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+// List godoc
+// @Summary List staff accounts (Super Admin)
+// @Tags admin-access
+// @Produce json
+// @Param status query string false "Status: active or disabled"
+// @Param keyword query string false "Username or name contains"
+// @Param page query int false "Page number (default: 1)"
+// @Param page_size query int false "Page size (default: 20, max: 100)"
+// @Success 200 {object} response.Response{data=[]domain.Staff,pagination=pagination.Paginator}
+// @Router /admin/access/staff [get]
+func (h *AdminStaffHandler) List(c *gin.Context) {
+	filters := repository.StaffFilters{
+		Status:  c.Query("status"),
+		Keyword: c.Query("keyword"),
+	}
+	paginator := pagination.NewPaginator(c)
+
+	staff, err := h.service.ListStaff(c.Request.Context(), filters, paginator)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, pagination.Result{Data: staff, Pagination: *paginator})
+}
+
+// Get godoc
+// @Summary Get a staff account (Super Admin)
+// @Tags admin-access
+// @Produce json
+// @Param id path string true "Staff ID"
+// @Success 200 {object} response.Response{data=domain.Staff}
+// @Failure 404 {object} response.Response
+// @Router /admin/access/staff/{id} [get]
+func (h *AdminStaffHandler) Get(c *gin.Context) {
+	staff, err := h.service.GetStaff(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, staff)
+}
+
+// Create godoc
+// @Summary Create a staff account (Super Admin)
+// @Tags admin-access
+// @Accept json
+// @Produce json
+// @Param request body service.CreateStaffRequest true "Staff account"
+// @Success 201 {object} response.Response{data=domain.Staff}
+// @Failure 400 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /admin/access/staff [post]
+func (h *AdminStaffHandler) Create(c *gin.Context) {
+	var req service.CreateStaffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	staff, err := h.service.CreateStaff(c.Request.Context(), &req, c.GetString("userID"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, staff)
+}
+
+// Update godoc
+// @Summary Update a staff account (Super Admin)
+// @Description Update profile fields, disable or enable the account, or replace its roles.
+// @Description Role changes apply at once
+// @Tags admin-access
+// @Accept json
+// @Produce json
+// @Param id path string true "Staff ID"
+// @Param request body service.UpdateStaffRequest true "Changes"
+// @Success 200 {object} response.Response{data=domain.Staff}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /admin/access/staff/{id} [put]
+func (h *AdminStaffHandler) Update(c *gin.Context) {
+	var req service.UpdateStaffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	staff, err := h.service.UpdateStaff(c.Request.Context(), c.Param("id"), &req, c.GetString("userID"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, staff)
+}
+
+// ResetPassword godoc
+// @Summary Reset a staff password (Super Admin)
+// @Tags admin-access
+// @Accept json
+// @Produce json
+// @Param id path string true "Staff ID"
+// @Param request body ResetStaffPasswordRequest true "New password"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /admin/access/staff/{id}/password [put]
+func (h *AdminStaffHandler) ResetPassword(c *gin.Context) {
+	var req ResetStaffPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.service.ResetPassword(c.Request.Context(), c.Param("id"), req.Password); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{"message": "密码已重置"})
+}
+
+// Delete godoc
+// @Summary Delete a staff account (Super Admin)
+// @Tags admin-access
+// @Produce json
+// @Param id path string true "Staff ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /admin/access/staff/{id} [delete]
+func (h *AdminStaffHandler) Delete(c *gin.Context) {
+	if err := h.service.DeleteStaff(c.Request.Context(), c.Param("id"), c.GetString("userID")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{"message": "员工账号已删除"})
+}
+
+func (h *AdminStaffHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrStaffNotFound):
+		response.NotFound(c, "员工账号不存在")
+	case errors.Is(err, service.ErrStaffUsernameTaken):
+		response.Error(c, http.StatusConflict, "用户名已被使用")
+	case errors.Is(err, service.ErrStaffSelfDelete):
+		response.BadRequest(c, "不能删除自己的账号")
+	case errors.Is(err, service.ErrStaffSelfDisable):
+		response.BadRequest(c, "不能停用自己的账号")
+	case errors.Is(err, service.ErrStaffLastSuperAdmin):
+		response.BadRequest(c, "至少需要保留一个启用的超级管理员")
+	case errors.Is(err, service.ErrRoleNotGrantable):
+		response.Forbidden(c, "不能分配或变更超出自身权限的角色")
+	case errors.Is(err, service.ErrRoleNotFound):
+		response.BadRequest(c, "角色不存在")
+	default:
+		response.Error(c, http.StatusInternalServerError, err.Error())
+	}
+}
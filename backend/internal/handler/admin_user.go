@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"backend/internal/pagination"
+	"backend/internal/repository"
+	"backend/internal/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminUserHandler handles customer lookup for the back office
+type AdminUserHandler struct {
+	repo repository.UserRepository
+}
+
+// NewAdminUserHandler creates a new admin user handler
+func NewAdminUserHandler(repo repository.UserRepository) *AdminUserHandler {
+	return &AdminUserHandler{repo: repo}
+}
+
+// List godoc
+// @Summary Look up customers (Admin)
+// @Description Find customers by phone, email or status. Their orders are listed with /admin/orders?user_id=
+// @Tags admin-users
+// @Produce json
+// @Param phone query string false "Phone"
+// @Param email query string false "Email"
+// @Param status query string false "Status"
+// @Param page query int false "Page number (default: 1)"
+// @Param page_size query int false "Page size (default: 20, max: 100)"
+// @Success 200 {object} response.Response{data=[]domain.User,pagination=pagination.Paginator}
+// @Router /admin/users [get]
+func (h *AdminUserHandler) List(c *gin.Context) {
+	filters := repository.UserFilters{
+		Phone:  c.Query("phone"),
+		Email:  c.Query("email"),
+		Status: c.Query("status"),
+	}
+	paginator := pagination.NewPaginator(c)
+
+	total, err := h.repo.Count(c.Request.Context(), filters)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	paginator.SetTotal(total)
+
+	users, err := h.repo.List(c.Request.Context(), filters, paginator)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, pagination.Result{Data: users, Pagination: *paginator})
+}
+
+// Get godoc
+// @Summary Get a customer (Admin)
+// @Description Get a customer with their frequent passengers
+// @Tags admin-users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} response.Response{data=domain.User}
+// @Failure 404 {object} response.Response
+// @Router /admin/users/{id} [get]
+func (h *AdminUserHandler) Get(c *gin.Context) {
+	user, err := h.repo.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		response.NotFound(c, "用户不存在")
+		return
+	}
+
+	response.Success(c, user)
+}
@@ -1,11 +1,12 @@
 package handler
 
 import (
-	"backend/internal/auth"
 	"backend/internal/config"
+	"backend/internal/domain"
 	"backend/internal/middleware"
-	"backend/internal/repository"
 	"backend/internal/response"
+	"backend/internal/service"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
@@ -37,22 +38,19 @@ type UserInfo struct {
 // AuthHandler handles authentication
 type AuthHandler struct {
 	jwtConfig      *config.JWTConfig
-	userRepo       repository.UserRepository
-	rbac           *auth.RBAC
+	staffService   service.StaffService
 	tokenBlacklist middleware.TokenBlacklist
 }
 
 // NewAuthHandler creates a new auth handler
 func NewAuthHandler(
 	jwtConfig *config.JWTConfig,
-	userRepo repository.UserRepository,
-	rbac *auth.RBAC,
+	staffService service.StaffService,
 	tokenBlacklist middleware.TokenBlacklist,
 ) *AuthHandler {
 	return &AuthHandler{
 		jwtConfig:      jwtConfig,
-		userRepo:       userRepo,
-		rbac:           rbac,
+		staffService:   staffService,
 		tokenBlacklist: tokenBlacklist,
 	}
 }
@@ -75,50 +73,18 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// SEC-001: Look up user by username (phone/email/nickname)
-	user, err := h.userRepo.GetByUsername(c.Request.Context(), req.Username)
+	// SEC-001: Look up the staff account and verify its password
+	staff, err := h.staffService.Authenticate(c.Request.Context(), req.Username, req.Password, c.ClientIP())
 	if err != nil {
+		if errors.Is(err, service.ErrStaffDisabled) {
+			response.Forbidden(c, "Account is disabled")
+			return
+		}
 		response.Unauthorized(c, "Invalid username or password")
 		return
 	}
 
-	// Verify password using bcrypt
-	if err := auth.CheckPassword(req.Password, user.PasswordHash); err != nil {
-		response.Unauthorized(c, "Invalid username or password")
-		return
-	}
-
-	// Determine user role (default to customer if not set)
-	role := "customer"
-	if user.Nickname != "" {
-		// In a real system, roles would be stored in a separate table
-		// For now, use a simplified role lookup
-		role = "operations"
-	}
-
-	// Generate tokens
-	token, err := middleware.GenerateToken(user.ID.String(), user.Nickname, role, h.jwtConfig)
-	if err != nil {
-		response.InternalServerError(c, "Failed to generate token")
-		return
-	}
-
-	refreshToken, err := middleware.GenerateRefreshToken(user.ID.String(), h.jwtConfig)
-	if err != nil {
-		response.InternalServerError(c, "Failed to generate refresh token")
-		return
-	}
-
-	response.Success(c, LoginResponse{
-		Token:        token,
-		RefreshToken: refreshToken,
-		User: UserInfo{
-			ID:       user.ID.String(),
-			Username: user.Nickname,
-			Name:     user.Nickname,
-			Role:     role,
-		},
-	})
+	h.issueTokens(c, staff)
 }
 
 // Refresh godoc
@@ -164,21 +130,31 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 		return
 	}
 
-	// Look up user to verify they still exist and are active
-	user, err := h.userRepo.GetByID(c.Request.Context(), claims.UserID)
+	// Look up staff to verify they still exist and are active; the role is
+	// read again so role changes apply from the next refresh
+	staff, err := h.staffService.GetStaff(c.Request.Context(), claims.UserID)
 	if err != nil {
 		response.Unauthorized(c, "User not found")
 		return
 	}
+	if !staff.CanLogin() {
+		response.Unauthorized(c, "Account is disabled")
+		return
+	}
+
+	h.issueTokens(c, staff)
+}
 
-	// Generate new token pair
-	token, err := middleware.GenerateToken(user.ID.String(), claims.Username, claims.Role, h.jwtConfig)
+// issueTokens responds with a new token pair for staff
+func (h *AuthHandler) issueTokens(c *gin.Context, staff *domain.Staff) {
+	role := staff.PrimaryRole()
+	token, err := middleware.GenerateToken(staff.ID.String(), staff.Username, role, h.jwtConfig)
 	if err != nil {
 		response.InternalServerError(c, "Failed to generate token")
 		return
 	}
 
-	refreshToken, err := middleware.GenerateRefreshToken(user.ID.String(), h.jwtConfig)
+	refreshToken, err := middleware.GenerateRefreshToken(staff.ID.String(), h.jwtConfig)
 	if err != nil {
 		response.InternalServerError(c, "Failed to generate refresh token")
 		return
@@ -188,10 +164,10 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 		Token:        token,
 		RefreshToken: refreshToken,
 		User: UserInfo{
-			ID:       user.ID.String(),
-			Username: user.Nickname,
-			Name:     user.Nickname,
-			Role:     claims.Role,
+			ID:       staff.ID.String(),
+			Username: staff.Username,
+			Name:     staff.Name,
+			Role:     role,
 		},
 	})
 }
@@ -264,29 +240,16 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	// SEC-001: Get current user
-	userID, _ := c.Get("userID")
-	user, err := h.userRepo.GetByID(c.Request.Context(), userID.(string))
-	if err != nil {
+	// SEC-001: Verify the old password and save the new one
+	err := h.staffService.ChangePassword(c.Request.Context(), c.GetString("userID"), req.OldPassword, req.NewPassword)
+	switch {
+	case errors.Is(err, service.ErrStaffNotFound):
 		response.Error(c, http.StatusNotFound, "User not found")
 		return
-	}
-
-	// Verify old password
-	if err := auth.CheckPassword(req.OldPassword, user.PasswordHash); err != nil {
+	case errors.Is(err, service.ErrStaffPasswordMismatch):
 		response.Unauthorized(c, "Old password is incorrect")
 		return
-	}
-
-	// Hash and save new password
-	hashedPassword, err := auth.HashPassword(req.NewPassword)
-	if err != nil {
-		response.InternalServerError(c, "Failed to hash password")
-		return
-	}
-
-	user.PasswordHash = hashedPassword
-	if err := h.userRepo.Update(c.Request.Context(), user); err != nil {
+	case err != nil:
 		response.InternalServerError(c, "Failed to update password")
 		return
 	}
@@ -297,7 +260,7 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 // ChangePasswordRequest represents password change request
 type ChangePasswordRequest struct {
 	OldPassword string `json:"old_password" binding:"required"`
-	NewPassword string `json:"new_password" binding:"required,min=6"`
+	NewPassword string `json:"new_password" binding:"required,min=8"`
 }
 
 // WeChatLogin godoc
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"backend/internal/response"
+	"backend/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CouponHandler handles the user's coupon wallet
+type CouponHandler struct {
+	service service.CouponService
+}
+
+// NewCouponHandler creates a new coupon handler
+func NewCouponHandler(service service.CouponService) *CouponHandler {
+	return &CouponHandler{service: service}
+}
+
+// ClaimCouponRequest represents a request to claim a coupon by code
+type ClaimCouponRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// ListWallet godoc
+// @Summary List my coupons
+// @Description List coupons in the current user's wallet
+// @Tags coupons
+// @Produce json
+// @Param status query string false "available, used or expired"
+// @Success 200 {object} response.Response{data=[]domain.UserCoupon}
+// @Router /user/coupons [get]
+func (h *CouponHandler) ListWallet(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		response.Unauthorized(c, "请先登录")
+		return
+	}
+
+	wallet, err := h.service.ListWallet(c.Request.Context(), userID, c.Query("status"))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, wallet)
+}
+
+// Claim godoc
+// @Summary Claim a coupon
+// @Description Add a coupon to the current user's wallet by code
+// @Tags coupons
+// @Accept json
+// @Produce json
+// @Param request body ClaimCouponRequest true "Coupon code"
+// @Success 201 {object} response.Response{data=domain.UserCoupon}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /user/coupons/claim [post]
+func (h *CouponHandler) Claim(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		response.Unauthorized(c, "请先登录")
+		return
+	}
+
+	var req ClaimCouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	userCoupon, err := h.service.ClaimCoupon(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrCouponNotFound):
+			response.NotFound(c, "优惠券不存在")
+		case errors.Is(err, service.ErrCouponInactive):
+			response.BadRequest(c, "优惠券未生效或已过期")
+		case errors.Is(err, service.ErrCouponAlreadyClaimed):
+			response.Error(c, http.StatusConflict, "已领取过该优惠券")
+		default:
+			response.Error(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	response.Created(c, userCoupon)
+}
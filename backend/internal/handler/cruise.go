@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"backend/internal/currency"
 	"backend/internal/pagination"
 	"backend/internal/response"
 	"backend/internal/service"
@@ -12,12 +13,15 @@ import (
 
 // CruiseHandler handles HTTP requests for cruises
 type CruiseHandler struct {
-	service service.CruiseService
+	service   service.CruiseService
+	converter currency.Converter
 }
 
-// NewCruiseHandler creates a new cruise handler
-func NewCruiseHandler(service service.CruiseService) *CruiseHandler {
-	return &CruiseHandler{service: service}
+// NewCruiseHandler creates a new cruise handler. Facility prices are shown in
+// the currency requested with converter; a nil converter only shows the base
+// currency
+func NewCruiseHandler(service service.CruiseService, converter currency.Converter) *CruiseHandler {
+	return &CruiseHandler{service: service, converter: converter}
 }
 
 // Create godoc
@@ -63,12 +67,19 @@ func (h *CruiseHandler) Create(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "Cruise ID"
+// @Param currency query string false "Display currency of facility prices (CNY, USD, HKD, JPY); also read from the X-Currency header"
 // @Success 200 {object} response.Response{data=domain.Cruise}
+// @Failure 400 {object} response.Response
 // @Failure 404 {object} response.Response
 // @Router /cruises/{id} [get]
 func (h *CruiseHandler) GetByID(c *gin.Context) {
 	id := c.Param("id")
 
+	quote, ok := displayQuote(c, h.converter)
+	if !ok {
+		return
+	}
+
 	cruise, err := h.service.GetByID(c.Request.Context(), id)
 	if err != nil {
 		if err == service.ErrCruiseNotFound {
@@ -79,6 +90,9 @@ func (h *CruiseHandler) GetByID(c *gin.Context) {
 		return
 	}
 
+	for i := range cruise.Facilities {
+		displayFacility(&cruise.Facilities[i], quote)
+	}
 	response.Success(c, cruise)
 }
 
@@ -89,12 +103,19 @@ func (h *CruiseHandler) GetByID(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param code path string true "Cruise code"
+// @Param currency query string false "Display currency of facility prices (CNY, USD, HKD, JPY); also read from the X-Currency header"
 // @Success 200 {object} response.Response{data=domain.Cruise}
+// @Failure 400 {object} response.Response
 // @Failure 404 {object} response.Response
 // @Router /cruises/code/{code} [get]
 func (h *CruiseHandler) GetByCode(c *gin.Context) {
 	code := c.Param("code")
 
+	quote, ok := displayQuote(c, h.converter)
+	if !ok {
+		return
+	}
+
 	cruise, err := h.service.GetByCode(c.Request.Context(), code)
 	if err != nil {
 		if err == service.ErrCruiseNotFound {
@@ -105,6 +126,9 @@ func (h *CruiseHandler) GetByCode(c *gin.Context) {
 		return
 	}
 
+	for i := range cruise.Facilities {
+		displayFacility(&cruise.Facilities[i], quote)
+	}
 	response.Success(c, cruise)
 }
 
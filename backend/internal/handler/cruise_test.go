@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"backend/internal/currency"
 	"backend/internal/domain"
 	"backend/internal/pagination"
 	"backend/internal/service"
@@ -92,7 +93,7 @@ func setupTestRouter() *gin.Engine {
 func TestCruiseHandler_GetByID(t *testing.T) {
 	router := setupTestRouter()
 	mockService := new(MockCruiseService)
-	handler := NewCruiseHandler(mockService)
+	handler := NewCruiseHandler(mockService, nil)
 
 	router.GET("/cruises/:id", handler.GetByID)
 
@@ -137,10 +138,74 @@ func TestCruiseHandler_GetByID(t *testing.T) {
 	})
 }
 
+func TestCruiseHandler_GetByIDCurrency(t *testing.T) {
+	router := setupTestRouter()
+	mockService := new(MockCruiseService)
+	converter := currency.NewService(
+		currency.Config{RefreshInterval: time.Hour, MaxStaleness: 24 * time.Hour},
+		[]currency.Provider{currency.NewStaticProvider(map[string]float64{currency.USD: 0.14}, time.Now())},
+	)
+	handler := NewCruiseHandler(mockService, converter)
+
+	router.GET("/cruises/:id", handler.GetByID)
+
+	cruise := func() *domain.Cruise {
+		return &domain.Cruise{
+			BaseModel:  domain.BaseModel{ID: uuid.New()},
+			NameCN:     "测试邮轮",
+			Facilities: []domain.Facility{{Name: "SPA", Price: 500}},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		query        string
+		header       string
+		wantCode     int
+		wantPrice    float64
+		wantCurrency string
+	}{
+		{name: "base currency by default", wantCode: http.StatusOK, wantPrice: 500, wantCurrency: currency.CNY},
+		{name: "currency query", query: "?currency=usd", wantCode: http.StatusOK, wantPrice: 70, wantCurrency: currency.USD},
+		{name: "currency header", header: currency.USD, wantCode: http.StatusOK, wantPrice: 70, wantCurrency: currency.USD},
+		{name: "unsupported currency", query: "?currency=EUR", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantCode == http.StatusOK {
+				mockService.On("GetByID", mock.Anything, "cruise-1").Return(cruise(), nil).Once()
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/cruises/cruise-1"+tt.query, nil)
+			if tt.header != "" {
+				req.Header.Set(CurrencyHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			var body struct {
+				Data domain.Cruise `json:"data"`
+			}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			if assert.Len(t, body.Data.Facilities, 1) {
+				assert.Equal(t, tt.wantPrice, body.Data.Facilities[0].Price)
+				assert.Equal(t, tt.wantCurrency, body.Data.Facilities[0].Currency)
+			}
+		})
+	}
+	mockService.AssertExpectations(t)
+}
+
 func TestCruiseHandler_List(t *testing.T) {
 	router := setupTestRouter()
 	mockService := new(MockCruiseService)
-	handler := NewCruiseHandler(mockService)
+	handler := NewCruiseHandler(mockService, nil)
 
 	router.GET("/cruises", handler.List)
 
@@ -183,7 +248,7 @@ func TestCruiseHandler_List(t *testing.T) {
 func TestCruiseHandler_Create(t *testing.T) {
 	router := setupTestRouter()
 	mockService := new(MockCruiseService)
-	handler := NewCruiseHandler(mockService)
+	handler := NewCruiseHandler(mockService, nil)
 
 	router.POST("/cruises", handler.Create)
 
@@ -239,7 +304,7 @@ func TestCruiseHandler_Create(t *testing.T) {
 func TestCruiseHandler_Update(t *testing.T) {
 	router := setupTestRouter()
 	mockService := new(MockCruiseService)
-	handler := NewCruiseHandler(mockService)
+	handler := NewCruiseHandler(mockService, nil)
 
 	router.PUT("/cruises/:id", handler.Update)
 
@@ -291,7 +356,7 @@ func TestCruiseHandler_Update(t *testing.T) {
 func TestCruiseHandler_Delete(t *testing.T) {
 	router := setupTestRouter()
 	mockService := new(MockCruiseService)
-	handler := NewCruiseHandler(mockService)
+	handler := NewCruiseHandler(mockService, nil)
 
 	router.DELETE("/cruises/:id", handler.Delete)
 
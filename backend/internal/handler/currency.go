@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"backend/internal/currency"
+	"backend/internal/domain"
+	"backend/internal/response"
+	"backend/internal/service"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CurrencyHeader lets clients choose a display currency without a query parameter
+const CurrencyHeader = "X-Currency"
+
+// CurrencyHandler handles currency and converted price requests
+type CurrencyHandler struct {
+	converter    currency.Converter
+	priceService service.PriceService
+}
+
+// NewCurrencyHandler creates a new currency handler
+func NewCurrencyHandler(converter currency.Converter, priceService service.PriceService) *CurrencyHandler {
+	return &CurrencyHandler{converter: converter, priceService: priceService}
+}
+
+// CurrencyList lists the supported currencies and their rates against the base
+type CurrencyList struct {
+	Base       string         `json:"base"`
+	Currencies []CurrencyRate `json:"currencies"`
+	Source     string         `json:"source"`
+	AsOf       string         `json:"as_of,omitempty"`
+	FetchedAt  string         `json:"fetched_at,omitempty"`
+}
+
+// CurrencyRate is the rate of one currency against the base
+type CurrencyRate struct {
+	Code string  `json:"code"`
+	Rate float64 `json:"rate"` // units per base unit
+}
+
+// List godoc
+// @Summary List supported currencies
+// @Description List supported display and settlement currencies with current rates against CNY
+// @Tags currencies
+// @Produce json
+// @Success 200 {object} response.Response{data=CurrencyList}
+// @Failure 503 {object} response.Response
+// @Router /currencies [get]
+func (h *CurrencyHandler) List(c *gin.Context) {
+	rates, err := h.converter.Rates(c.Request.Context())
+	if err != nil {
+		response.Error(c, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+
+	list := CurrencyList{Base: rates.Base, Source: rates.Source}
+	if !rates.AsOf.IsZero() {
+		list.AsOf = rates.AsOf.Format(time.RFC3339)
+	}
+	if !rates.FetchedAt.IsZero() {
+		list.FetchedAt = rates.FetchedAt.Format(time.RFC3339)
+	}
+	for _, code := range currency.Supported() {
+		quote, err := h.converter.Quote(c.Request.Context(), currency.Base, code)
+		if err != nil {
+			continue
+		}
+		list.Currencies = append(list.Currencies, CurrencyRate{Code: code, Rate: quote.Rate})
+	}
+
+	response.Success(c, list)
+}
+
+// ListVoyagePrices godoc
+// @Summary List voyage prices in a currency
+// @Description List the cabin prices of a voyage converted to the requested currency
+// @Tags currencies
+// @Produce json
+// @Param id path string true "Voyage ID"
+// @Param currency query string false "Display currency (CNY, USD, HKD, JPY); also read from the X-Currency header"
+// @Success 200 {object} response.Response{data=service.VoyagePriceList}
+// @Failure 400 {object} response.Response
+// @Failure 503 {object} response.Response
+// @Router /voyages/{id}/prices [get]
+func (h *CurrencyHandler) ListVoyagePrices(c *gin.Context) {
+	list, err := h.priceService.ListVoyagePrices(c.Request.Context(), c.Param("id"), requestCurrency(c))
+	if err != nil {
+		respondCurrencyError(c, err)
+		return
+	}
+
+	response.Success(c, list)
+}
+
+// requestCurrency returns the currency requested by query or header
+func requestCurrency(c *gin.Context) string {
+	if code := c.Query("currency"); code != "" {
+		return code
+	}
+	return c.GetHeader(CurrencyHeader)
+}
+
+// displayQuote returns the rate from the base currency to the one requested
+// by query or header, responding with the error when it cannot be served
+func displayQuote(c *gin.Context, converter currency.Converter) (currency.Quote, bool) {
+	quote, err := service.QuoteFromBase(c.Request.Context(), converter, requestCurrency(c))
+	if err != nil {
+		respondCurrencyError(c, err)
+		return currency.Quote{}, false
+	}
+	return quote, true
+}
+
+// displayFacility converts a facility's price to the quoted currency
+func displayFacility(facility *domain.Facility, quote currency.Quote) {
+	facility.Price = quote.Convert(facility.Price)
+	facility.Currency = quote.To
+}
+
+// respondCurrencyError maps currency errors to status codes, falling back to 500
+func respondCurrencyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, currency.ErrUnsupportedCurrency):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, currency.ErrRateUnavailable), errors.Is(err, currency.ErrRatesStale):
+		response.Error(c, http.StatusServiceUnavailable, err.Error())
+	default:
+		response.Error(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// isCurrencyError reports whether err came from currency conversion
+func isCurrencyError(err error) bool {
+	return errors.Is(err, currency.ErrUnsupportedCurrency) ||
+		errors.Is(err, currency.ErrRateUnavailable) ||
+		errors.Is(err, currency.ErrRatesStale)
+}
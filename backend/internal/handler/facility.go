@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"backend/internal/currency"
+	"backend/internal/domain"
 	"backend/internal/pagination"
 	"backend/internal/response"
 	"backend/internal/service"
@@ -11,7 +13,8 @@ import (
 
 // FacilityHandler handles HTTP requests for facilities
 type FacilityHandler struct {
-	service service.FacilityService
+	service   service.FacilityService
+	converter currency.Converter
 }
 
 // FacilityCategoryHandler handles HTTP requests for facility categories
@@ -19,9 +22,11 @@ type FacilityCategoryHandler struct {
 	service service.FacilityCategoryService
 }
 
-// NewFacilityHandler creates a new facility handler
-func NewFacilityHandler(service service.FacilityService) *FacilityHandler {
-	return &FacilityHandler{service: service}
+// NewFacilityHandler creates a new facility handler. Prices are shown in the
+// currency requested with converter; a nil converter only shows the base
+// currency
+func NewFacilityHandler(service service.FacilityService, converter currency.Converter) *FacilityHandler {
+	return &FacilityHandler{service: service, converter: converter}
 }
 
 // NewFacilityCategoryHandler creates a new facility category handler
@@ -66,12 +71,19 @@ func (h *FacilityHandler) Create(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "Facility ID"
+// @Param currency query string false "Display currency (CNY, USD, HKD, JPY); also read from the X-Currency header"
 // @Success 200 {object} response.Response{data=domain.Facility}
+// @Failure 400 {object} response.Response
 // @Failure 404 {object} response.Response
 // @Router /facilities/{id} [get]
 func (h *FacilityHandler) GetByID(c *gin.Context) {
 	id := c.Param("id")
 
+	quote, ok := displayQuote(c, h.converter)
+	if !ok {
+		return
+	}
+
 	facility, err := h.service.GetByID(c.Request.Context(), id)
 	if err != nil {
 		if err == service.ErrFacilityNotFound {
@@ -82,6 +94,7 @@ func (h *FacilityHandler) GetByID(c *gin.Context) {
 		return
 	}
 
+	displayFacility(facility, quote)
 	response.Success(c, facility)
 }
 
@@ -98,7 +111,9 @@ func (h *FacilityHandler) GetByID(c *gin.Context) {
 // @Param deck_number query int false "Deck number"
 // @Param page query int false "Page number"
 // @Param page_size query int false "Page size"
+// @Param currency query string false "Display currency (CNY, USD, HKD, JPY); also read from the X-Currency header"
 // @Success 200 {object} response.Response{data=[]domain.Facility,pagination=pagination.Paginator}
+// @Failure 400 {object} response.Response
 // @Router /facilities [get]
 func (h *FacilityHandler) List(c *gin.Context) {
 	var req service.ListFacilitiesRequest
@@ -107,6 +122,11 @@ func (h *FacilityHandler) List(c *gin.Context) {
 		return
 	}
 
+	quote, ok := displayQuote(c, h.converter)
+	if !ok {
+		return
+	}
+
 	req.Paginator = *pagination.NewPaginator(c)
 
 	result, err := h.service.List(c.Request.Context(), req)
@@ -115,6 +135,11 @@ func (h *FacilityHandler) List(c *gin.Context) {
 		return
 	}
 
+	if facilities, ok := result.Data.([]*domain.Facility); ok {
+		for _, facility := range facilities {
+			displayFacility(facility, quote)
+		}
+	}
 	response.Success(c, result)
 }
 
@@ -125,17 +150,27 @@ func (h *FacilityHandler) List(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param cruise_id path string true "Cruise ID"
+// @Param currency query string false "Display currency (CNY, USD, HKD, JPY); also read from the X-Currency header"
 // @Success 200 {object} response.Response{data=[]domain.Facility}
+// @Failure 400 {object} response.Response
 // @Router /cruises/{cruise_id}/facilities [get]
 func (h *FacilityHandler) ListByCruise(c *gin.Context) {
 	cruiseID := c.Param("cruise_id")
 
+	quote, ok := displayQuote(c, h.converter)
+	if !ok {
+		return
+	}
+
 	facilities, err := h.service.ListByCruise(c.Request.Context(), cruiseID)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	for _, facility := range facilities {
+		displayFacility(facility, quote)
+	}
 	response.Success(c, facilities)
 }
 
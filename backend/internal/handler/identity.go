@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"backend/internal/payment"
+	"backend/internal/response"
+	"backend/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdentityHandler handles binding login identities to the current user
+type IdentityHandler struct {
+	service service.IdentityService
+}
+
+// NewIdentityHandler creates a new identity handler
+func NewIdentityHandler(service service.IdentityService) *IdentityHandler {
+	return &IdentityHandler{service: service}
+}
+
+// BindWechatRequest binds the WeChat account of a mini program login
+type BindWechatRequest struct {
+	Code  string `json:"code" binding:"required"` // from wx.login
+	Merge bool   `json:"merge"`                   // merge the account the identity is bound to
+}
+
+// BindPhoneRequest binds a phone number verified by SMS
+type BindPhoneRequest struct {
+	Phone string `json:"phone" binding:"required,len=11"`
+	Code  string `json:"code" binding:"required,len=6"` // sent by /auth/sms/send
+	Merge bool   `json:"merge"`
+}
+
+// BindAlipayRequest binds the Alipay account of an Alipay authorization
+type BindAlipayRequest struct {
+	AuthCode string `json:"auth_code" binding:"required"`
+	Merge    bool   `json:"merge"`
+}
+
+// List godoc
+// @Summary List login identities
+// @Description WeChat, phone and Alipay identities of the current user, masked
+// @Tags user
+// @Produce json
+// @Success 200 {object} response.Response{data=[]service.IdentityView}
+// @Router /user/identities [get]
+func (h *IdentityHandler) List(c *gin.Context) {
+	identities, err := h.service.ListIdentities(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, identities)
+}
+
+// BindWechat godoc
+// @Summary Bind WeChat
+// @Description Bind the WeChat account that logged in to the mini program. If it belongs to another account,
+// @Description retry with merge to merge that account into the current one
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body BindWechatRequest true "Bind request"
+// @Success 200 {object} response.Response{data=service.BindIdentityResult}
+// @Failure 401 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /user/identities/wechat [post]
+func (h *IdentityHandler) BindWechat(c *gin.Context) {
+	var req BindWechatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.service.BindWechat(c.Request.Context(), c.GetString("userID"), req.Code, req.Merge)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// BindPhone godoc
+// @Summary Bind phone number
+// @Description Bind a phone number verified by SMS code. If it belongs to another account,
+// @Description retry with merge to merge that account into the current one
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body BindPhoneRequest true "Bind request"
+// @Success 200 {object} response.Response{data=service.BindIdentityResult}
+// @Failure 401 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /user/identities/phone [post]
+func (h *IdentityHandler) BindPhone(c *gin.Context) {
+	var req BindPhoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.service.BindPhone(c.Request.Context(), c.GetString("userID"), req.Phone, req.Code, req.Merge)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// BindAlipay godoc
+// @Summary Bind Alipay
+// @Description Bind the Alipay account that granted auth_code. If it belongs to another account,
+// @Description retry with merge to merge that account into the current one
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body BindAlipayRequest true "Bind request"
+// @Success 200 {object} response.Response{data=service.BindIdentityResult}
+// @Failure 401 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /user/identities/alipay [post]
+func (h *IdentityHandler) BindAlipay(c *gin.Context) {
+	var req BindAlipayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.service.BindAlipay(c.Request.Context(), c.GetString("userID"), req.AuthCode, req.Merge)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// Unbind godoc
+// @Summary Unbind a login identity
+// @Description Detach a WeChat, phone or Alipay identity; the last way to log in cannot be removed
+// @Tags user
+// @Produce json
+// @Param type path string true "Identity type: wechat, phone or alipay"
+// @Success 200 {object} response.Response{data=domain.User}
+// @Failure 400 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /user/identities/{type} [delete]
+func (h *IdentityHandler) Unbind(c *gin.Context) {
+	user, err := h.service.Unbind(c.Request.Context(), c.GetString("userID"), c.Param("type"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	user.PasswordHash = ""
+	response.Success(c, user)
+}
+
+// Logs godoc
+// @Summary Account link history
+// @Description Identity bindings, unbindings and account merges of the current user
+// @Tags user
+// @Produce json
+// @Success 200 {object} response.Response{data=[]domain.AccountLinkLog}
+// @Router /user/identities/logs [get]
+func (h *IdentityHandler) Logs(c *gin.Context) {
+	logs, err := h.service.ListLinkLogs(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, logs)
+}
+
+func (h *IdentityHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		response.NotFound(c, "用户不存在")
+	case errors.Is(err, service.ErrInvalidIdentityType):
+		response.BadRequest(c, "身份类型无效")
+	case errors.Is(err, service.ErrWechatAuthFailed):
+		response.Error(c, http.StatusUnauthorized, "微信授权失败")
+	case errors.Is(err, payment.ErrAlipayAuthFailed):
+		response.Error(c, http.StatusUnauthorized, "支付宝授权失败")
+	case errors.Is(err, service.ErrInvalidCode), errors.Is(err, service.ErrCodeNotFound):
+		response.Error(c, http.StatusUnauthorized, "验证码错误")
+	case errors.Is(err, service.ErrCodeExpired):
+		response.Error(c, http.StatusUnauthorized, "验证码已过期")
+	case errors.Is(err, service.ErrTooManyRequests):
+		response.Error(c, http.StatusTooManyRequests, "请求过于频繁，请稍后再试")
+	case errors.Is(err, service.ErrIdentityInUse):
+		response.Error(c, http.StatusConflict, "该身份已绑定其他账号，确认合并账号后可重新绑定")
+	case errors.Is(err, service.ErrIdentityTypeBound):
+		response.Error(c, http.StatusConflict, "已绑定同类身份，请先解绑")
+	case errors.Is(err, service.ErrIdentityNotBound):
+		response.Error(c, http.StatusConflict, "未绑定该身份")
+	case errors.Is(err, service.ErrLastLoginMethod):
+		response.Error(c, http.StatusConflict, "不能解绑唯一的登录方式")
+	case errors.Is(err, service.ErrAccountMergeBlocked):
+		response.Error(c, http.StatusForbidden, "该账号无法合并")
+	default:
+		response.Error(c, http.StatusInternalServerError, err.Error())
+	}
+}
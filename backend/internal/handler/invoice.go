@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"backend/internal/response"
+	"backend/internal/service"
+	"backend/internal/validator"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InvoiceHandler handles customer electronic invoice requests
+type InvoiceHandler struct {
+	service      service.InvoiceService
+	orderService service.OrderService
+}
+
+// NewInvoiceHandler creates a new invoice handler
+func NewInvoiceHandler(service service.InvoiceService, orderService service.OrderService) *InvoiceHandler {
+	return &InvoiceHandler{service: service, orderService: orderService}
+}
+
+// Request godoc
+// @Summary Apply for an electronic invoice
+// @Description Request an electronic invoice for a paid order; it is issued once finance approves it
+// @Tags invoices
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param request body service.InvoiceRequest true "Invoice request"
+// @Success 201 {object} response.Response{data=domain.Invoice}
+// @Failure 400 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /orders/{id}/invoices [post]
+func (h *InvoiceHandler) Request(c *gin.Context) {
+	var req service.InvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if err := validator.ValidateStruct(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if !h.authorize(c) {
+		return
+	}
+
+	invoice, err := h.service.RequestInvoice(c.Request.Context(), c.GetString("userID"), c.Param("id"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, invoice)
+}
+
+// List godoc
+// @Summary List an order's invoices
+// @Description List the invoices of an order, including red-letter reversals
+// @Tags invoices
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} response.Response{data=[]domain.Invoice}
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /orders/{id}/invoices [get]
+func (h *InvoiceHandler) List(c *gin.Context) {
+	if !h.authorize(c) {
+		return
+	}
+
+	invoices, err := h.service.ListOrderInvoices(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, invoices)
+}
+
+// authorize checks that the order belongs to the authenticated user
+func (h *InvoiceHandler) authorize(c *gin.Context) bool {
+	order, err := h.orderService.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return false
+	}
+	if order.UserID == nil || *order.UserID != c.GetString("userID") {
+		response.Forbidden(c, "无权操作此订单")
+		return false
+	}
+	return true
+}
+
+func (h *InvoiceHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrOrderNotFound):
+		response.NotFound(c, "订单不存在")
+	case errors.Is(err, service.ErrInvalidInvoice):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, service.ErrOrderNotInvoiceable):
+		response.BadRequest(c, "订单未支付或已全额退款，无法开票")
+	case errors.Is(err, service.ErrInvoiceExists):
+		response.Error(c, http.StatusConflict, "该订单已申请发票")
+	default:
+		response.Error(c, http.StatusInternalServerError, err.Error())
+	}
+}
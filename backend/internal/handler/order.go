@@ -27,6 +27,7 @@ func NewOrderHandler(service service.OrderService) *OrderHandler {
 // @Accept json
 // @Produce json
 // @Param request body service.CreateOrderRequest true "Create order request"
+// @Param currency query string false "Settlement currency when not set in the body; also read from the X-Currency header"
 // @Success 201 {object} response.Response{data=domain.Order}
 // @Failure 400 {object} response.Response
 // @Failure 500 {object} response.Response
@@ -48,9 +49,17 @@ func (h *OrderHandler) Create(c *gin.Context) {
 		req.UserID = userID.(string)
 	}
 
+	if req.Currency == "" {
+		req.Currency = requestCurrency(c)
+	}
+
 	order, err := h.service.Create(c.Request.Context(), req)
 	if err != nil {
-		if err == service.ErrInvalidOrderData || err == service.ErrInvalidPassengerCount {
+		if isCurrencyError(err) {
+			respondCurrencyError(c, err)
+			return
+		}
+		if err == service.ErrInvalidOrderData || err == service.ErrInvalidPassengerCount || service.IsFareRuleError(err) || service.IsCouponError(err) {
 			response.BadRequest(c, err.Error())
 			return
 		}
@@ -72,6 +81,7 @@ func (h *OrderHandler) Create(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param request body CalculateRequest true "Calculate request"
+// @Param currency query string false "Display currency (CNY, USD, HKD, JPY); also read from the X-Currency header"
 // @Success 200 {object} response.Response{data=service.OrderCalculation}
 // @Failure 400 {object} response.Response
 // @Router /orders/calculate [post]
@@ -82,8 +92,18 @@ func (h *OrderHandler) Calculate(c *gin.Context) {
 		return
 	}
 
-	calculation, err := h.service.CalculateTotal(c.Request.Context(), req.Items)
+	calculation, err := h.service.CalculateTotal(c.Request.Context(), service.CalculateOrderRequest{
+		UserID:      c.GetString("userID"),
+		VoyageID:    req.VoyageID,
+		Items:       req.Items,
+		CouponCodes: req.CouponCodes,
+		Currency:    requestCurrency(c),
+	})
 	if err != nil {
+		if isCurrencyError(err) {
+			respondCurrencyError(c, err)
+			return
+		}
 		response.BadRequest(c, err.Error())
 		return
 	}
@@ -391,5 +411,7 @@ func (h *OrderHandler) Delete(c *gin.Context) {
 
 // CalculateRequest represents a calculation request
 type CalculateRequest struct {
-	Items []service.OrderItemRequest `json:"items" binding:"required,min=1,dive"`
+	VoyageID    string                     `json:"voyage_id"`
+	Items       []service.OrderItemRequest `json:"items" binding:"required,min=1,dive"`
+	CouponCodes []string                   `json:"coupon_codes,omitempty"`
 }
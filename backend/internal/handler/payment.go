@@ -1,30 +1,50 @@
 package handler
 
 import (
+	"backend/internal/domain"
 	"backend/internal/payment"
 	"backend/internal/response"
+	"backend/internal/service"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/skip2/go-qrcode"
 )
 
 // PaymentHandler handles HTTP requests for payments
 type PaymentHandler struct {
-	service payment.PaymentService
+	service   payment.PaymentService
+	callbacks service.PaymentCallbackService
 }
 
 // NewPaymentHandler creates a new payment handler
-func NewPaymentHandler(service payment.PaymentService) *PaymentHandler {
-	return &PaymentHandler{service: service}
+func NewPaymentHandler(service payment.PaymentService, callbacks service.PaymentCallbackService) *PaymentHandler {
+	return &PaymentHandler{service: service, callbacks: callbacks}
 }
 
+// Client platforms a payment can be started from
+const (
+	platformMiniProgram = "miniprogram"
+	platformDesktop     = "desktop"
+	platformMobile      = "mobile"
+)
+
+// qrCodeSize is the width and height of rendered payment QR codes in pixels
+const qrCodeSize = 256
+
 // Create godoc
 // @Summary Create a payment
-// @Description Create a payment for an order
+// @Description Create a payment for an order. Unless a channel is given, the trade type follows the client platform:
+// @Description WeChat Pay uses JSAPI in the mini program, Native (QR code) on desktop and H5 in mobile browsers;
+// @Description Alipay uses page checkout on desktop and WAP checkout in mobile browsers.
+// @Description An order can be split across several payments: amount pays part of it, and by default a payment covers what is left.
+// @Description Gift card (gift_card_code) and points payments are charged at once and return already successful
 // @Tags payments
 // @Accept json
 // @Produce json
@@ -40,16 +60,25 @@ func (h *PaymentHandler) Create(c *gin.Context) {
 		return
 	}
 
-	// Get user ID from context
-	userID, exists := c.Get("userID")
-	if exists {
-		_ = userID
-		// Could add user verification here
+	ctx := c.Request.Context()
+	channel := req.Channel
+	if channel == "" {
+		channel = tradeTypeFor(req.Method, clientPlatform(c, req.Platform))
+	}
+	if channel != "" {
+		ctx = payment.WithChannel(ctx, channel)
+	}
+	ctx = payment.WithPayer(ctx, payment.Payer{ClientIP: c.ClientIP(), OpenID: req.OpenID, UserID: c.GetString("userID")})
+	if req.Amount > 0 {
+		ctx = payment.WithAmount(ctx, req.Amount)
+	}
+	if req.GiftCardCode != "" {
+		ctx = payment.WithGiftCard(ctx, req.GiftCardCode)
 	}
 
-	payment, err := h.service.CreatePayment(c.Request.Context(), req.OrderID, req.Method, req.Description)
+	payment, err := h.service.CreatePayment(ctx, req.OrderID, req.Method, req.Description)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, err.Error())
+		h.handleError(c, err)
 		return
 	}
 
@@ -74,45 +103,46 @@ func (h *PaymentHandler) WechatCallback(c *gin.Context) {
 	}
 
 	// Get signature from headers
-	signature := c.GetHeader("Wechatpay-Signature")
-	timestamp := c.GetHeader("Wechatpay-Timestamp")
-	nonce := c.GetHeader("Wechatpay-Nonce")
-	serial := c.GetHeader("Wechatpay-Serial")
-
-	if signature == "" || timestamp == "" || nonce == "" || serial == "" {
+	meta, ok := wechatNotifyMeta(c)
+	if !ok {
+		h.callbacks.RecordRejected(c.Request.Context(), domain.CallbackKindPayment, "wechat", callbackHeaders(c), body, errNotifyMeta)
 		c.String(http.StatusBadRequest, "fail")
 		return
 	}
 
-	ts, err := strconv.ParseInt(timestamp, 10, 64)
-	if err != nil {
+	// Process callback
+	if err := h.callbacks.HandleCallback(c.Request.Context(), domain.CallbackKindPayment, "wechat", callbackHeaders(c), body, meta); err != nil {
 		c.String(http.StatusBadRequest, "fail")
 		return
 	}
 
-	if delta := time.Now().Unix() - ts; delta > 300 || delta < -300 {
-		c.String(http.StatusBadRequest, "fail")
-		return
-	}
+	// Return success to WeChat
+	c.String(http.StatusOK, "success")
+}
 
-	meta, err := json.Marshal(gin.H{
-		"signature": signature,
-		"timestamp": timestamp,
-		"nonce":     nonce,
-		"serial":    serial,
-	})
+// AlipayCallback godoc
+// @Summary Alipay callback
+// @Description Handle Alipay asynchronous notification
+// @Tags payments
+// @Accept x-www-form-urlencoded
+// @Produce plain
+// @Success 200 {string} string "success"
+// @Failure 400 {string} string "failure"
+// @Router /payments/callback/alipay [post]
+func (h *PaymentHandler) AlipayCallback(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
 	if err != nil {
-		c.String(http.StatusBadRequest, "fail")
+		c.String(http.StatusBadRequest, "failure")
 		return
 	}
 
-	// Process callback
-	if err := h.service.ProcessCallback(c.Request.Context(), "wechat", body, string(meta)); err != nil {
-		c.String(http.StatusBadRequest, "fail")
+	// The notification carries its own signature in the sign field
+	if err := h.callbacks.HandleCallback(c.Request.Context(), domain.CallbackKindPayment, "alipay", callbackHeaders(c), body, ""); err != nil {
+		c.String(http.StatusBadRequest, "failure")
 		return
 	}
 
-	// Return success to WeChat
+	// Alipay retries until it receives exactly "success"
 	c.String(http.StatusOK, "success")
 }
 
@@ -138,9 +168,40 @@ func (h *PaymentHandler) Query(c *gin.Context) {
 	response.Success(c, result)
 }
 
+// QRCode godoc
+// @Summary Payment QR code
+// @Description Render the QR code of a pending Native (WeChat Pay) or QR (Alipay) payment as a PNG
+// @Tags payments
+// @Produce png
+// @Param id path string true "Payment ID"
+// @Success 200 {file} binary
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /payments/{id}/qrcode [get]
+func (h *PaymentHandler) QRCode(c *gin.Context) {
+	checkout, err := h.service.GetCheckout(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	if checkout.QRCodeURL == "" {
+		response.NotFound(c, "该支付没有二维码")
+		return
+	}
+
+	png, err := qrcode.Encode(checkout.QRCodeURL, qrcode.Medium, qrCodeSize)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "image/png", png)
+}
+
 // GetByOrder godoc
 // @Summary Get payment by order
-// @Description Get payment information for an order
+// @Description Get the most recent payment of an order
 // @Tags payments
 // @Accept json
 // @Produce json
@@ -160,6 +221,25 @@ func (h *PaymentHandler) GetByOrder(c *gin.Context) {
 	response.Success(c, payment)
 }
 
+// ListByOrder godoc
+// @Summary List payments of an order
+// @Description List every payment made towards an order with the paid and outstanding amounts
+// @Tags payments
+// @Produce json
+// @Param orderId path string true "Order ID"
+// @Success 200 {object} response.Response{data=payment.OrderPayments}
+// @Failure 404 {object} response.Response
+// @Router /payments/order/{orderId}/all [get]
+func (h *PaymentHandler) ListByOrder(c *gin.Context) {
+	payments, err := h.service.GetOrderPayments(c.Request.Context(), c.Param("orderId"))
+	if err != nil {
+		response.NotFound(c, "order not found")
+		return
+	}
+
+	response.Success(c, payments)
+}
+
 // Refund godoc
 // @Summary Refund a payment
 // @Description Process a refund for a payment
@@ -168,7 +248,7 @@ func (h *PaymentHandler) GetByOrder(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Payment ID"
 // @Param request body RefundRequest true "Refund request"
-// @Success 200 {object} response.Response
+// @Success 200 {object} response.Response{data=domain.PaymentRefund}
 // @Failure 400 {object} response.Response
 // @Failure 404 {object} response.Response
 // @Router /payments/{id}/refund [post]
@@ -181,19 +261,137 @@ func (h *PaymentHandler) Refund(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.Refund(c.Request.Context(), id, req.Amount, req.Reason); err != nil {
+	refund, err := h.service.Refund(c.Request.Context(), id, req.Amount, req.Reason, "")
+	if err != nil {
 		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	response.Success(c, nil)
+	response.Success(c, refund)
+}
+
+func (h *PaymentHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, payment.ErrUnsupportedTradeType):
+		response.BadRequest(c, "该支付方式不支持当前的支付场景")
+	case errors.Is(err, payment.ErrPaymentNotFound):
+		response.NotFound(c, "支付不存在")
+	case errors.Is(err, payment.ErrPaymentAlreadyPaid):
+		response.BadRequest(c, "该支付已完成")
+	case errors.Is(err, payment.ErrAmountExceedsBalance):
+		response.BadRequest(c, "支付金额超过订单待付金额")
+	case errors.Is(err, payment.ErrTenderUnavailable):
+		response.BadRequest(c, "礼品卡或积分不可用于该订单")
+	case errors.Is(err, payment.ErrInsufficientBalance):
+		response.BadRequest(c, "礼品卡余额或积分不足")
+	default:
+		response.Error(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// clientPlatform returns the platform the client declared, or guesses it
+// from the User-Agent
+func clientPlatform(c *gin.Context, declared string) string {
+	if declared != "" {
+		return declared
+	}
+
+	ua := strings.ToLower(c.GetHeader("User-Agent"))
+	switch {
+	case strings.Contains(ua, "miniprogram"):
+		return platformMiniProgram
+	case strings.Contains(ua, "mobile"), strings.Contains(ua, "android"), strings.Contains(ua, "iphone"):
+		return platformMobile
+	default:
+		return platformDesktop
+	}
+}
+
+// tradeTypeFor picks the trade type of a payment method on a platform, or
+// "" for the provider's default
+func tradeTypeFor(method, platform string) string {
+	switch method {
+	case domain.PaymentMethodWechat:
+		switch platform {
+		case platformDesktop:
+			return payment.WechatTradeNative
+		case platformMobile:
+			return payment.WechatTradeH5
+		}
+		return payment.WechatTradeJSAPI
+	case domain.PaymentMethodAlipay:
+		if platform == platformMobile {
+			return payment.AlipayChannelWAP
+		}
+		return payment.AlipayChannelPage
+	}
+	return ""
+}
+
+// wechatNotifyMeta collects the signature headers of a WeChat Pay
+// notification, rejecting stale timestamps
+// errNotifyMeta is logged for WeChat callbacks turned away by wechatNotifyMeta
+const errNotifyMeta = "missing or stale Wechatpay signature headers"
+
+// callbackSecretHeaders are left out of the callback log
+var callbackSecretHeaders = map[string]bool{
+	"Authorization": true,
+	"Cookie":        true,
+}
+
+// callbackHeaders returns a callback's request headers for the callback log
+func callbackHeaders(c *gin.Context) map[string]string {
+	headers := make(map[string]string, len(c.Request.Header))
+	for name, values := range c.Request.Header {
+		if callbackSecretHeaders[name] {
+			continue
+		}
+		headers[name] = strings.Join(values, ", ")
+	}
+	return headers
+}
+
+func wechatNotifyMeta(c *gin.Context) (string, bool) {
+	signature := c.GetHeader("Wechatpay-Signature")
+	timestamp := c.GetHeader("Wechatpay-Timestamp")
+	nonce := c.GetHeader("Wechatpay-Nonce")
+	serial := c.GetHeader("Wechatpay-Serial")
+
+	if signature == "" || timestamp == "" || nonce == "" || serial == "" {
+		return "", false
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", false
+	}
+
+	if delta := time.Now().Unix() - ts; delta > 300 || delta < -300 {
+		return "", false
+	}
+
+	meta, err := json.Marshal(gin.H{
+		"signature": signature,
+		"timestamp": timestamp,
+		"nonce":     nonce,
+		"serial":    serial,
+	})
+	if err != nil {
+		return "", false
+	}
+	return string(meta), true
 }
 
 // CreatePaymentRequest represents a create payment request
 type CreatePaymentRequest struct {
-	OrderID     string `json:"order_id" binding:"required"`
-	Method      string `json:"method" binding:"required,oneof=wechat alipay card"`
-	Description string `json:"description"`
+	OrderID      string  `json:"order_id" binding:"required"`
+	Method       string  `json:"method" binding:"required,oneof=wechat alipay card sandbox gift_card points"`
+	Description  string  `json:"description"`
+	Channel      string  `json:"channel" binding:"omitempty,oneof=page wap qr jsapi native h5"` // trade type, chosen from the platform by default
+	Platform     string  `json:"platform" binding:"omitempty,oneof=miniprogram desktop mobile"` // guessed from the User-Agent by default
+	OpenID       string  `json:"openid"`                                                        // payer's openid, for WeChat Pay in the mini program
+	Amount       float64 `json:"amount" binding:"omitempty,gt=0"`                               // part of the order to pay, the outstanding balance by default
+	GiftCardCode string  `json:"gift_card_code" binding:"required_if=Method gift_card"`         // card to pay with, for gift card payments
 }
 
 // RefundRequest represents a refund request
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"backend/internal/payment"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaymentTradeType(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		method    string
+		platform  string
+		userAgent string
+		want      string
+	}{
+		{name: "wechat in the mini program", method: "wechat", userAgent: "Mozilla/5.0 (iPhone) MicroMessenger/8.0.40 miniProgram", want: payment.WechatTradeJSAPI},
+		{name: "wechat on desktop", method: "wechat", userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) Chrome/126.0", want: payment.WechatTradeNative},
+		{name: "wechat in a mobile browser", method: "wechat", userAgent: "Mozilla/5.0 (Linux; Android 14) Mobile Safari/537.36", want: payment.WechatTradeH5},
+		{name: "declared platform wins", method: "wechat", platform: platformDesktop, userAgent: "Mozilla/5.0 (iPhone) Mobile", want: payment.WechatTradeNative},
+		{name: "alipay on desktop", method: "alipay", userAgent: "Mozilla/5.0 (Macintosh) Safari/605.1.15", want: payment.AlipayChannelPage},
+		{name: "alipay in a mobile browser", method: "alipay", userAgent: "Mozilla/5.0 (iPhone) Mobile/15E148", want: payment.AlipayChannelWAP},
+		{name: "sandbox keeps its default", method: "sandbox", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("POST", "/api/v1/payments", nil)
+			c.Request.Header.Set("User-Agent", tt.userAgent)
+
+			assert.Equal(t, tt.want, tradeTypeFor(tt.method, clientPlatform(c, tt.platform)))
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"backend/internal/response"
+	"backend/internal/service"
+	"backend/internal/validator"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PriceWatchHandler handles the user's price change subscriptions
+type PriceWatchHandler struct {
+	service service.PriceWatchService
+}
+
+// NewPriceWatchHandler creates a new price watch handler
+func NewPriceWatchHandler(service service.PriceWatchService) *PriceWatchHandler {
+	return &PriceWatchHandler{service: service}
+}
+
+// List godoc
+// @Summary List my price watches
+// @Description List the current user's price change subscriptions
+// @Tags price-watches
+// @Produce json
+// @Success 200 {object} response.Response{data=[]domain.PriceWatch}
+// @Router /user/price-watches [get]
+func (h *PriceWatchHandler) List(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		response.Unauthorized(c, "请先登录")
+		return
+	}
+
+	watches, err := h.service.List(c.Request.Context(), userID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Success(c, watches)
+}
+
+// Create godoc
+// @Summary Watch a price
+// @Description Subscribe to price drops, rises or either of a cabin type on a voyage or route
+// @Tags price-watches
+// @Accept json
+// @Produce json
+// @Param request body service.CreatePriceWatchRequest true "Watch"
+// @Success 201 {object} response.Response{data=domain.PriceWatch}
+// @Failure 400 {object} response.Response
+// @Router /user/price-watches [post]
+func (h *PriceWatchHandler) Create(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		response.Unauthorized(c, "请先登录")
+		return
+	}
+
+	var req service.CreatePriceWatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if err := validator.ValidateStruct(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	watch, err := h.service.Create(c.Request.Context(), userID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, watch)
+}
+
+// Delete godoc
+// @Summary Delete a price watch
+// @Description Unsubscribe from a price watch
+// @Tags price-watches
+// @Produce json
+// @Param id path string true "Price watch ID"
+// @Success 200 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /user/price-watches/{id} [delete]
+func (h *PriceWatchHandler) Delete(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		response.Unauthorized(c, "请先登录")
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), userID, c.Param("id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+func (h *PriceWatchHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrPriceWatchNotFound):
+		response.NotFound(c, "降价提醒不存在")
+	case errors.Is(err, service.ErrInvalidPriceWatch):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, service.ErrPriceWatchLimit):
+		response.BadRequest(c, "降价提醒数量已达上限")
+	default:
+		response.Error(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"backend/internal/config"
+	"backend/internal/realtime"
+	"backend/internal/repository"
+	"backend/internal/response"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsWriteWait  = 10 * time.Second
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = (wsPongWait * 9) / 10
+)
+
+// Client message types accepted on the WebSocket
+const (
+	wsClientSubscribe = "subscribe"
+	wsClientHeartbeat = "heartbeat"
+)
+
+// RealtimeClientMessage is a message sent by a WebSocket client
+type RealtimeClientMessage struct {
+	Type        string `json:"type"`
+	CabinTypeID string `json:"cabin_type_id,omitempty"`
+}
+
+// RealtimeHandler streams live availability and viewer counts over WebSocket
+type RealtimeHandler struct {
+	hub           *realtime.Hub
+	presence      realtime.Presence
+	inventoryRepo repository.InventoryRepository
+	upgrader      websocket.Upgrader
+}
+
+// NewRealtimeHandler creates a new realtime handler. Browsers may open the
+// WebSocket from the API's own origin or one allowed by cors
+func NewRealtimeHandler(hub *realtime.Hub, presence realtime.Presence, inventoryRepo repository.InventoryRepository, cors *config.CORSConfig) *RealtimeHandler {
+	return &RealtimeHandler{
+		hub:           hub,
+		presence:      presence,
+		inventoryRepo: inventoryRepo,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin:     func(r *http.Request) bool { return checkOrigin(r, cors) },
+		},
+	}
+}
+
+// checkOrigin accepts requests without an Origin, which do not come from a
+// browser, same-origin requests and the allowed cross-origin ones
+func checkOrigin(r *http.Request, cors *config.CORSConfig) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return cors.AllowsOrigin(origin)
+}
+
+// VoyageStream godoc
+// @Summary Subscribe to voyage availability
+// @Description Upgrade to a WebSocket that pushes inventory deltas and viewer counts for a voyage.
+// @Description Clients send {"type":"subscribe","cabin_type_id":"..."} to focus a cabin type and {"type":"heartbeat"} to stay counted as a viewer.
+// @Tags realtime
+// @Param id path string true "Voyage ID"
+// @Success 101 {string} string "Switching Protocols"
+// @Router /ws/voyages/{id} [get]
+func (h *RealtimeHandler) VoyageStream(c *gin.Context) {
+	voyageID := c.Param("id")
+	if _, err := uuid.Parse(voyageID); err != nil {
+		response.BadRequest(c, "无效的航次ID")
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("[WARN] WebSocket upgrade failed: %v", err)
+		return
+	}
+
+	client := realtime.NewClient(uuid.New().String(), voyageID)
+	h.hub.Register(client)
+
+	ctx := context.Background()
+	var cabinTypeID string
+	h.touch(ctx, client, "", "")
+	h.sendSnapshot(ctx, conn, voyageID)
+
+	go h.writePump(conn, client)
+
+	defer func() {
+		h.hub.Unregister(client)
+		if err := h.presence.Leave(ctx, voyageID, cabinTypeID, client.ID); err != nil {
+			log.Printf("[WARN] Failed to remove viewer presence: %v", err)
+		}
+		h.publishViewers(ctx, voyageID, cabinTypeID)
+		conn.Close()
+	}()
+
+	conn.SetReadLimit(1024)
+	_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+
+		var msg RealtimeClientMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+
+		switch msg.Type {
+		case wsClientSubscribe:
+			if msg.CabinTypeID == cabinTypeID {
+				continue
+			}
+			if cabinTypeID != "" {
+				_ = h.presence.Leave(ctx, voyageID, cabinTypeID, client.ID)
+				h.publishViewers(ctx, voyageID, cabinTypeID)
+			}
+			h.touch(ctx, client, msg.CabinTypeID, cabinTypeID)
+			cabinTypeID = msg.CabinTypeID
+		case wsClientHeartbeat:
+			if err := h.presence.Touch(ctx, voyageID, cabinTypeID, client.ID); err != nil {
+				log.Printf("[WARN] Failed to refresh viewer presence: %v", err)
+			}
+		}
+	}
+}
+
+// touch records presence and publishes new counts when the viewer moved
+func (h *RealtimeHandler) touch(ctx context.Context, client *realtime.Client, cabinTypeID, previous string) {
+	if err := h.presence.Touch(ctx, client.VoyageID, cabinTypeID, client.ID); err != nil {
+		log.Printf("[WARN] Failed to record viewer presence: %v", err)
+		return
+	}
+	if previous == "" {
+		h.publishViewers(ctx, client.VoyageID, "")
+	}
+	if cabinTypeID != "" {
+		h.publishViewers(ctx, client.VoyageID, cabinTypeID)
+	}
+}
+
+// publishViewers broadcasts the current voyage and cabin type viewer counts
+func (h *RealtimeHandler) publishViewers(ctx context.Context, voyageID, cabinTypeID string) {
+	keys := []string{""}
+	if cabinTypeID != "" {
+		keys = append(keys, cabinTypeID)
+	}
+	for _, key := range keys {
+		count, err := h.presence.Count(ctx, voyageID, key)
+		if err != nil {
+			log.Printf("[WARN] Failed to count viewers: %v", err)
+			continue
+		}
+		h.hub.PublishViewers(realtime.ViewerCount{VoyageID: voyageID, CabinTypeID: key, Count: count})
+	}
+}
+
+// sendSnapshot writes the current inventory of the voyage to a new client
+func (h *RealtimeHandler) sendSnapshot(ctx context.Context, conn *websocket.Conn, voyageID string) {
+	inventories, err := h.inventoryRepo.ListInventoryByVoyage(ctx, voyageID)
+	if err != nil {
+		log.Printf("[WARN] Failed to load inventory snapshot: %v", err)
+		return
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	for _, inv := range inventories {
+		_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		_ = conn.WriteJSON(realtime.Message{
+			Type:     realtime.MessageTypeInventory,
+			VoyageID: voyageID,
+			Data: realtime.InventoryDelta{
+				VoyageID:        inv.VoyageID,
+				CabinTypeID:     inv.CabinTypeID,
+				TotalCabins:     inv.TotalCabins,
+				AvailableCabins: inv.AvailableCabins,
+				LockedCabins:    inv.LockedCabins,
+				BookedCabins:    inv.BookedCabins,
+			},
+			Timestamp: now,
+		})
+	}
+}
+
+// writePump forwards hub messages to the socket and keeps it alive with pings
+func (h *RealtimeHandler) writePump(conn *websocket.Conn, client *realtime.Client) {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+	}()
+
+	for {
+		select {
+		case payload, ok := <-client.Send():
+			_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if !ok {
+				_ = conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				return
+			}
+		case <-ticker.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
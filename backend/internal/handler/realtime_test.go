@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"backend/internal/config"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckOrigin(t *testing.T) {
+	cors := &config.CORSConfig{AllowedOrigins: []string{"https://www.example.com"}}
+
+	tests := []struct {
+		name   string
+		origin string
+		want   bool
+	}{
+		{"no origin", "", true},
+		{"same origin", "https://api.example.com", true},
+		{"allowed origin", "https://www.example.com", true},
+		{"other origin", "https://evil.example.net", false},
+		{"allowed host on another scheme", "http://www.example.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "https://api.example.com/api/v1/ws/voyages/1", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+
+			assert.Equal(t, tt.want, checkOrigin(r, cors))
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"backend/internal/domain"
+	"backend/internal/response"
+	"backend/internal/service"
+	"backend/internal/validator"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RefundHandler handles customer refund requests
+type RefundHandler struct {
+	service      service.RefundService
+	orderService service.OrderService
+}
+
+// NewRefundHandler creates a new refund handler
+func NewRefundHandler(service service.RefundService, orderService service.OrderService) *RefundHandler {
+	return &RefundHandler{service: service, orderService: orderService}
+}
+
+// CustomerRefundRequest represents a customer's request to cancel and refund an order
+type CustomerRefundRequest struct {
+	RefundAmount  float64 `json:"refund_amount" validate:"omitempty,gt=0"` // defaults to the refundable amount
+	RefundReason  string  `json:"refund_reason" validate:"required"`
+	RefundMethod  string  `json:"refund_method" validate:"omitempty,oneof=original bank alipay wechat"`
+	BankName      string  `json:"bank_name,omitempty"`
+	BankAccount   string  `json:"bank_account,omitempty"`
+	AccountHolder string  `json:"account_holder,omitempty"`
+}
+
+// Preview godoc
+// @Summary Preview an order refund
+// @Description Itemize what cancelling now would refund under the order's refund policy
+// @Tags refunds
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} response.Response{data=service.RefundQuote}
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /orders/{id}/refund-preview [get]
+func (h *RefundHandler) Preview(c *gin.Context) {
+	if !h.authorize(c) {
+		return
+	}
+
+	quote, err := h.service.PreviewRefund(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleRefundError(c, err)
+		return
+	}
+
+	response.Success(c, quote)
+}
+
+// Create godoc
+// @Summary Request an order refund
+// @Description Apply to cancel a paid order; the amount is checked against the order's refund policy
+// @Tags refunds
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param request body CustomerRefundRequest true "Refund request"
+// @Success 201 {object} response.Response{data=domain.RefundRequest}
+// @Failure 400 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /orders/{id}/refunds [post]
+func (h *RefundHandler) Create(c *gin.Context) {
+	var req CustomerRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if err := validator.ValidateStruct(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if !h.authorize(c) {
+		return
+	}
+
+	userID := c.GetString("userID")
+	refund, err := h.service.CreateRefundRequest(c.Request.Context(), service.CreateRefundRequest{
+		OrderID:            c.Param("id"),
+		UserID:             &userID,
+		RefundAmount:       req.RefundAmount,
+		RefundReason:       req.RefundReason,
+		RefundMethod:       req.RefundMethod,
+		BankName:           req.BankName,
+		BankAccount:        req.BankAccount,
+		AccountHolder:      req.AccountHolder,
+		CancellationReason: domain.CancellationReasonCustomerRequest,
+	})
+	if err != nil {
+		handleRefundError(c, err)
+		return
+	}
+
+	response.Created(c, refund)
+}
+
+// authorize checks that the order belongs to the authenticated user
+func (h *RefundHandler) authorize(c *gin.Context) bool {
+	order, err := h.orderService.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleRefundError(c, err)
+		return false
+	}
+	if order.UserID == nil || *order.UserID != c.GetString("userID") {
+		response.Forbidden(c, "无权操作此订单")
+		return false
+	}
+	return true
+}
+
+// handleRefundError maps refund request errors to responses
+func handleRefundError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrOrderNotFound):
+		response.NotFound(c, "订单不存在")
+	case errors.Is(err, service.ErrOrderNotRefundable):
+		response.BadRequest(c, "订单当前状态不可退款")
+	case errors.Is(err, service.ErrNothingRefundable):
+		response.BadRequest(c, "根据退改规则，该订单当前无可退金额")
+	case errors.Is(err, service.ErrRefundAmountInvalid):
+		response.BadRequest(c, "退款金额超过可退金额")
+	default:
+		response.Error(c, http.StatusInternalServerError, err.Error())
+	}
+}
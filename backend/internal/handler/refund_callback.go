@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"backend/internal/domain"
+	"backend/internal/service"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RefundCallbackHandler handles asynchronous refund result notifications
+type RefundCallbackHandler struct {
+	callbacks service.PaymentCallbackService
+}
+
+// NewRefundCallbackHandler creates a new refund callback handler. Refund
+// results are logged and handed to the refund service by callbacks
+func NewRefundCallbackHandler(callbacks service.PaymentCallbackService) *RefundCallbackHandler {
+	return &RefundCallbackHandler{callbacks: callbacks}
+}
+
+// WechatCallback godoc
+// @Summary WeChat Pay refund callback
+// @Description Handle WeChat Pay refund result notification (REFUND.SUCCESS, REFUND.ABNORMAL, REFUND.CLOSED)
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Success 200 {string} string "success"
+// @Failure 400 {string} string "fail"
+// @Router /payments/callback/wechat/refund [post]
+func (h *RefundCallbackHandler) WechatCallback(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.String(http.StatusBadRequest, "fail")
+		return
+	}
+
+	meta, ok := wechatNotifyMeta(c)
+	if !ok {
+		h.callbacks.RecordRejected(c.Request.Context(), domain.CallbackKindRefund, "wechat", callbackHeaders(c), body, errNotifyMeta)
+		c.String(http.StatusBadRequest, "fail")
+		return
+	}
+
+	if err := h.callbacks.HandleCallback(c.Request.Context(), domain.CallbackKindRefund, "wechat", callbackHeaders(c), body, meta); err != nil {
+		c.String(http.StatusBadRequest, "fail")
+		return
+	}
+
+	c.String(http.StatusOK, "success")
+}
+
+// AlipayCallback godoc
+// @Summary Alipay refund callback
+// @Description Handle Alipay asynchronous notification of a settled refund
+// @Tags payments
+// @Accept x-www-form-urlencoded
+// @Produce plain
+// @Success 200 {string} string "success"
+// @Failure 400 {string} string "failure"
+// @Router /payments/callback/alipay/refund [post]
+func (h *RefundCallbackHandler) AlipayCallback(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.String(http.StatusBadRequest, "failure")
+		return
+	}
+
+	// The notification carries its own signature in the sign field
+	if err := h.callbacks.HandleCallback(c.Request.Context(), domain.CallbackKindRefund, "alipay", callbackHeaders(c), body, ""); err != nil {
+		c.String(http.StatusBadRequest, "failure")
+		return
+	}
+
+	c.String(http.StatusOK, "success")
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"backend/internal/payment"
+	"backend/internal/response"
+	"backend/internal/validator"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SandboxPaymentHandler drives the sandbox payment provider. Its routes are
+// only registered outside production when the sandbox is enabled.
+type SandboxPaymentHandler struct {
+	sandbox   payment.SandboxProvider
+	simulator *payment.SandboxSimulator
+}
+
+// NewSandboxPaymentHandler creates a new sandbox payment handler
+func NewSandboxPaymentHandler(sandbox payment.SandboxProvider, simulator *payment.SandboxSimulator) *SandboxPaymentHandler {
+	return &SandboxPaymentHandler{sandbox: sandbox, simulator: simulator}
+}
+
+// SandboxSimulateRequest represents a sandbox simulation request
+type SandboxSimulateRequest struct {
+	Outcome      string `json:"outcome" validate:"required,oneof=success failure delay duplicate"`
+	DelaySeconds int    `json:"delay_seconds" validate:"gte=0,lte=3600"`
+}
+
+// SandboxRefundOutcomeRequest represents a sandbox refund outcome request
+type SandboxRefundOutcomeRequest struct {
+	Outcome      string `json:"outcome" validate:"required,oneof=success failure delay"`
+	DelaySeconds int    `json:"delay_seconds" validate:"gte=0,lte=3600"`
+}
+
+// Simulate godoc
+// @Summary Simulate a sandbox payment notification (dev only)
+// @Description Deliver a signed success, failure, delayed or duplicated notification through the payment callback flow
+// @Tags payments-sandbox
+// @Accept json
+// @Produce json
+// @Param paymentNo path string true "Payment number"
+// @Param request body SandboxSimulateRequest true "Outcome"
+// @Success 200 {object} response.Response{data=payment.SandboxSimulation}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /dev/payments/{paymentNo}/simulate [post]
+func (h *SandboxPaymentHandler) Simulate(c *gin.Context) {
+	var req SandboxSimulateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if err := validator.ValidateStruct(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	simulation, err := h.simulator.Simulate(c.Request.Context(), c.Param("paymentNo"), req.Outcome, time.Duration(req.DelaySeconds)*time.Second)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, simulation)
+}
+
+// SetRefundOutcome godoc
+// @Summary Set how the next sandbox refund settles (dev only)
+// @Tags payments-sandbox
+// @Accept json
+// @Produce json
+// @Param paymentNo path string true "Payment number"
+// @Param request body SandboxRefundOutcomeRequest true "Outcome"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Router /dev/payments/{paymentNo}/refund-outcome [post]
+func (h *SandboxPaymentHandler) SetRefundOutcome(c *gin.Context) {
+	var req SandboxRefundOutcomeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if err := validator.ValidateStruct(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.sandbox.SetRefundOutcome(c.Param("paymentNo"), req.Outcome, time.Duration(req.DelaySeconds)*time.Second); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{"payment_no": c.Param("paymentNo"), "outcome": req.Outcome})
+}
+
+func (h *SandboxPaymentHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, payment.ErrPaymentNotFound):
+		response.NotFound(c, "支付记录不存在")
+	default:
+		response.Error(c, http.StatusBadRequest, err.Error())
+	}
+}
@@ -0,0 +1,115 @@
+package invoice
+
+import (
+	"backend/internal/domain"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fileProvider is a local stand-in for a tax platform. It numbers invoices
+// from a sequence file, renders them as PDFs and keeps a copy of each in
+// its directory.
+type fileProvider struct {
+	dir    string
+	seller Seller
+	now    func() time.Time
+	mu     sync.Mutex
+}
+
+// NewFileProvider creates a file-based invoice provider writing to dir
+func NewFileProvider(dir string, seller Seller) (Provider, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create invoice directory: %w", err)
+	}
+	return &fileProvider{dir: dir, seller: seller, now: time.Now}, nil
+}
+
+func (p *fileProvider) Name() string {
+	return "file"
+}
+
+func (p *fileProvider) Issue(ctx context.Context, req IssueRequest) (*IssueResult, error) {
+	if req.Invoice.Kind == domain.InvoiceKindRed && (req.Original == nil || req.Original.InvoiceNo == "") {
+		return nil, fmt.Errorf("red-letter invoice %s has no issued original", req.Invoice.ID)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	seq, err := p.nextSequence()
+	if err != nil {
+		return nil, err
+	}
+
+	issuedAt := p.now()
+	invoiceNo := fmt.Sprintf("%s%08d", issuedAt.Format("20060102"), seq)
+	pdf := renderPDF(p.lines(req, invoiceNo, issuedAt))
+
+	if err := os.WriteFile(filepath.Join(p.dir, invoiceNo+".pdf"), pdf, 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write invoice %s: %w", invoiceNo, err)
+	}
+
+	return &IssueResult{InvoiceNo: invoiceNo, IssuedAt: issuedAt, PDF: pdf}, nil
+}
+
+// nextSequence increments the sequence file and returns the new number
+func (p *fileProvider) nextSequence() (int64, error) {
+	path := filepath.Join(p.dir, "sequence")
+
+	var seq int64
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		seq, err = strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("corrupt invoice sequence: %w", err)
+		}
+	case !os.IsNotExist(err):
+		return 0, fmt.Errorf("failed to read invoice sequence: %w", err)
+	}
+
+	seq++
+	if err := os.WriteFile(path, []byte(strconv.FormatInt(seq, 10)), 0o644); err != nil {
+		return 0, fmt.Errorf("failed to write invoice sequence: %w", err)
+	}
+	return seq, nil
+}
+
+// lines lays out the printed invoice
+func (p *fileProvider) lines(req IssueRequest, invoiceNo string, issuedAt time.Time) []string {
+	inv := req.Invoice
+
+	title := "电子发票（普通发票）"
+	if inv.Kind == domain.InvoiceKindRed {
+		title = "电子发票（红字）"
+	}
+
+	lines := []string{
+		title,
+		"发票号码：" + invoiceNo,
+		"开票日期：" + issuedAt.Format("2006年01月02日"),
+		"购买方名称：" + inv.Title,
+	}
+	if inv.TaxID != "" {
+		lines = append(lines, "购买方纳税人识别号："+inv.TaxID)
+	}
+	lines = append(lines,
+		"销售方名称："+p.seller.Name,
+		"销售方纳税人识别号："+p.seller.TaxID,
+		"项目：旅游服务*邮轮船票",
+	)
+	if req.Order != nil {
+		lines = append(lines, "订单号："+req.Order.OrderNumber)
+	}
+	lines = append(lines, fmt.Sprintf("价税合计：%s %.2f", inv.Currency, inv.Amount))
+	if req.Original != nil {
+		lines = append(lines, "对应蓝字发票号码："+req.Original.InvoiceNo)
+	}
+	return lines
+}
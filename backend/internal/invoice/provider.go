@@ -0,0 +1,37 @@
+package invoice
+
+import (
+	"backend/internal/domain"
+	"context"
+	"time"
+)
+
+// Provider issues electronic invoices (fapiao) through a tax platform
+type Provider interface {
+	// Name identifies the provider on issued invoices
+	Name() string
+
+	// Issue issues an invoice and returns the document. A red-letter
+	// invoice carries the blue invoice it reverses in req.Original.
+	Issue(ctx context.Context, req IssueRequest) (*IssueResult, error)
+}
+
+// Seller is the merchant printed on issued invoices
+type Seller struct {
+	Name  string
+	TaxID string
+}
+
+// IssueRequest represents an invoice to issue
+type IssueRequest struct {
+	Invoice  *domain.Invoice
+	Original *domain.Invoice // set for red-letter invoices
+	Order    *domain.Order
+}
+
+// IssueResult represents an issued invoice
+type IssueResult struct {
+	InvoiceNo string
+	IssuedAt  time.Time
+	PDF       []byte
+}
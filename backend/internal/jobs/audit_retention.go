@@ -0,0 +1,67 @@
+package jobs
+
+import (
+	"backend/internal/service"
+	"context"
+	"log"
+	"time"
+)
+
+// AuditRetentionJob purges audit log entries older than the configured
+// retention period
+type AuditRetentionJob struct {
+	audit  service.AdminAuditService
+	ticker *time.Ticker
+	quit   chan bool
+}
+
+// NewAuditRetentionJob creates a new audit retention job
+func NewAuditRetentionJob(audit service.AdminAuditService) *AuditRetentionJob {
+	return &AuditRetentionJob{
+		audit: audit,
+		quit:  make(chan bool),
+	}
+}
+
+// Start starts the audit retention job
+func (j *AuditRetentionJob) Start(interval time.Duration) {
+	j.ticker = time.NewTicker(interval)
+
+	go func() {
+		for {
+			select {
+			case <-j.ticker.C:
+				j.run()
+			case <-j.quit:
+				j.ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	log.Println("Audit retention job started")
+}
+
+// Stop stops the audit retention job
+func (j *AuditRetentionJob) Stop() {
+	close(j.quit)
+	log.Println("Audit retention job stopped")
+}
+
+// run purges expired entries
+func (j *AuditRetentionJob) run() {
+	purged, err := j.audit.Purge(context.Background())
+	if err != nil {
+		log.Printf("Audit retention run failed: %v", err)
+		return
+	}
+
+	if purged > 0 {
+		log.Printf("Audit retention purged %d entries", purged)
+	}
+}
+
+// RunOnce runs the job once for testing
+func (j *AuditRetentionJob) RunOnce() {
+	j.run()
+}
@@ -0,0 +1,72 @@
+package jobs
+
+import (
+	"backend/internal/service"
+	"context"
+	"log"
+	"time"
+)
+
+// DynamicPricingJob periodically re-evaluates pricing rules for open voyages
+type DynamicPricingJob struct {
+	engine service.PricingEngine
+	ticker *time.Ticker
+	quit   chan bool
+}
+
+// NewDynamicPricingJob creates a new dynamic pricing job
+func NewDynamicPricingJob(engine service.PricingEngine) *DynamicPricingJob {
+	return &DynamicPricingJob{
+		engine: engine,
+		quit:   make(chan bool),
+	}
+}
+
+// Start starts the dynamic pricing job
+func (j *DynamicPricingJob) Start(interval time.Duration) {
+	j.ticker = time.NewTicker(interval)
+
+	go func() {
+		for {
+			select {
+			case <-j.ticker.C:
+				j.reprice()
+			case <-j.quit:
+				j.ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	log.Println("Dynamic pricing job started")
+}
+
+// Stop stops the dynamic pricing job
+func (j *DynamicPricingJob) Stop() {
+	close(j.quit)
+	log.Println("Dynamic pricing job stopped")
+}
+
+// reprice applies pricing rules to all open voyages
+func (j *DynamicPricingJob) reprice() {
+	results, err := j.engine.RunAll(context.Background())
+	if err != nil {
+		log.Printf("Dynamic pricing run failed: %v", err)
+	}
+
+	changed := 0
+	for _, result := range results {
+		for _, change := range result.Changes {
+			if change.Changed {
+				changed++
+			}
+		}
+	}
+
+	log.Printf("Dynamic pricing evaluated %d voyages, repriced %d cabin prices", len(results), changed)
+}
+
+// RunOnce runs the job once for testing
+func (j *DynamicPricingJob) RunOnce() {
+	j.reprice()
+}
@@ -3,6 +3,7 @@ package jobs
 import (
 	"backend/internal/domain"
 	"backend/internal/pagination"
+	"backend/internal/payment"
 	"backend/internal/repository"
 	"backend/internal/service"
 	"context"
@@ -12,16 +13,24 @@ import (
 	"time"
 
 	"github.com/nats-io/nats.go"
+	"gorm.io/gorm"
 )
 
+// paymentHoldWindow is how long an expired order is kept while one of its
+// payments is still in flight. Providers close unpaid trades after 30
+// minutes; anything paid later is refunded by the payment service
+const paymentHoldWindow = 35 * time.Minute
+
 // OrderTimeoutJob handles order timeout and cleanup
 type OrderTimeoutJob struct {
-	orderRepo     repository.OrderRepository
-	inventoryRepo repository.InventoryRepository
-	stateService  service.OrderStateService
-	natsConn      *nats.Conn
-	ticker        *time.Ticker
-	quit          chan bool
+	orderRepo      repository.OrderRepository
+	inventoryRepo  repository.InventoryRepository
+	stateService   service.OrderStateService
+	couponService  service.CouponService
+	paymentService payment.PaymentService
+	natsConn       *nats.Conn
+	ticker         *time.Ticker
+	quit           chan bool
 }
 
 // NewOrderTimeoutJob creates a new order timeout job
@@ -29,14 +38,18 @@ func NewOrderTimeoutJob(
 	orderRepo repository.OrderRepository,
 	inventoryRepo repository.InventoryRepository,
 	stateService service.OrderStateService,
+	couponService service.CouponService,
+	paymentService payment.PaymentService,
 	natsConn *nats.Conn,
 ) *OrderTimeoutJob {
 	return &OrderTimeoutJob{
-		orderRepo:     orderRepo,
-		inventoryRepo: inventoryRepo,
-		stateService:  stateService,
-		natsConn:      natsConn,
-		quit:          make(chan bool),
+		orderRepo:      orderRepo,
+		inventoryRepo:  inventoryRepo,
+		stateService:   stateService,
+		couponService:  couponService,
+		paymentService: paymentService,
+		natsConn:       natsConn,
+		quit:           make(chan bool),
 	}
 }
 
@@ -134,8 +147,53 @@ func (j *OrderTimeoutJob) isOrderExpired(order *domain.Order, now time.Time) boo
 
 // cancelExpiredOrder cancels an expired order and releases inventory
 func (j *OrderTimeoutJob) cancelExpiredOrder(ctx context.Context, order *domain.Order) error {
+	if j.holdForPayment(ctx, order) {
+		return nil
+	}
+
 	log.Printf("Cancelling expired order: %s (OrderNumber: %s)", order.ID, order.OrderNumber)
 
+	// Update order status to cancelled and record the event with it
+	event, err := domain.NewOutboxEvent(domain.AggregateOrder, order.ID.String(), "order.cancelled", map[string]interface{}{
+		"type":         "order.cancelled",
+		"order_id":     order.ID,
+		"order_number": order.OrderNumber,
+		"reason":       "expired",
+		"timestamp":    time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build order cancelled event: %w", err)
+	}
+	cancelled := false
+	err = j.orderRepo.WithTransaction(ctx, func(txRepo repository.OrderRepository, _ *gorm.DB) error {
+		// A callback may have settled the order since it was listed. Locking
+		// the row orders this cancellation against settleOrder, which locks
+		// it too, so only an order still pending is cancelled
+		locked, err := txRepo.GetByIDForUpdate(ctx, order.ID.String())
+		if err != nil {
+			return fmt.Errorf("failed to lock order: %w", err)
+		}
+		if locked.Status != domain.OrderStatusPending {
+			return nil
+		}
+
+		cancelled, err = txRepo.UpdateStatusFrom(ctx, order.ID.String(), domain.OrderStatusPending, domain.OrderStatusCancelled)
+		if err != nil {
+			return fmt.Errorf("failed to update order status: %w", err)
+		}
+		if !cancelled {
+			return nil
+		}
+		return txRepo.AddOutboxEvent(ctx, event)
+	})
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		log.Printf("Expired order %s is no longer pending, not cancelling", order.ID)
+		return nil
+	}
+
 	// Get order items
 	items, err := j.orderRepo.ListOrderItemsByOrder(ctx, order.ID.String())
 	if err != nil {
@@ -150,36 +208,62 @@ func (j *OrderTimeoutJob) cancelExpiredOrder(ctx context.Context, order *domain.
 		}
 	}
 
-	// Update order status to cancelled
-	if err := j.orderRepo.UpdateStatus(ctx, order.ID.String(), domain.OrderStatusCancelled); err != nil {
-		return fmt.Errorf("failed to update order status: %w", err)
+	// Give back coupons held by the order
+	if j.couponService != nil {
+		if err := j.couponService.ReleaseOrder(ctx, order.ID.String()); err != nil {
+			log.Printf("Failed to release coupons for order %s: %v", order.ID, err)
+		}
 	}
 
-	// Publish event
-	j.publishOrderCancelled(order)
+	// Payments towards an order that was split and never fully paid go back
+	if j.paymentService != nil {
+		if err := j.paymentService.RefundCancelledOrder(ctx, order.ID.String()); err != nil {
+			log.Printf("Failed to refund payments of cancelled order %s: %v", order.ID, err)
+		}
+	}
 
 	log.Printf("Successfully cancelled expired order: %s", order.ID)
 	return nil
 }
 
-// publishOrderCancelled publishes order cancelled event
-func (j *OrderTimeoutJob) publishOrderCancelled(order *domain.Order) {
-	if j.natsConn == nil {
-		return
+// holdForPayment queries the provider for the order's in-flight payments
+// before it is cancelled, so an order whose callback was lost is settled
+// rather than cancelled. It reports whether the order should be left alone
+func (j *OrderTimeoutJob) holdForPayment(ctx context.Context, order *domain.Order) bool {
+	if j.paymentService == nil {
+		return false
 	}
 
-	event := map[string]interface{}{
-		"type":         "order.cancelled",
-		"order_id":     order.ID,
-		"order_number": order.OrderNumber,
-		"reason":       "expired",
-		"timestamp":    time.Now().Format(time.RFC3339),
+	details, err := j.orderRepo.GetOrderWithDetails(ctx, order.ID.String())
+	if err != nil {
+		log.Printf("Failed to load payments of expired order %s: %v", order.ID, err)
+		return true
 	}
 
-	data, _ := json.Marshal(event)
-	if err := j.natsConn.Publish("order.cancelled", data); err != nil {
-		log.Printf("Failed to publish order cancelled event: %v", err)
+	now := time.Now()
+	hold := false
+	for _, p := range details.Payments {
+		if !p.IsInFlight() {
+			continue
+		}
+
+		result, err := j.paymentService.QueryPayment(ctx, p.ID.String())
+		switch {
+		case err != nil:
+			log.Printf("Failed to query payment %s of expired order %s: %v", p.PaymentNo, order.ID, err)
+		case result.IsSuccessful():
+			log.Printf("Expired order %s was paid by %s, not cancelling", order.ID, p.PaymentNo)
+			return true
+		case !result.IsInFlight():
+			continue
+		}
+
+		if now.Sub(p.CreatedAt) < paymentHoldWindow {
+			hold = true
+		}
 	}
+
+	return hold
 }
 
 // publishMetrics publishes job metrics
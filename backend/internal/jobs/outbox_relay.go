@@ -0,0 +1,66 @@
+package jobs
+
+import (
+	"backend/internal/service"
+	"context"
+	"log"
+	"time"
+)
+
+// OutboxRelayJob publishes events recorded in the outbox to JetStream
+type OutboxRelayJob struct {
+	outbox service.OutboxService
+	ticker *time.Ticker
+	quit   chan bool
+}
+
+// NewOutboxRelayJob creates a new outbox relay job
+func NewOutboxRelayJob(outbox service.OutboxService) *OutboxRelayJob {
+	return &OutboxRelayJob{
+		outbox: outbox,
+		quit:   make(chan bool),
+	}
+}
+
+// Start starts the outbox relay job
+func (j *OutboxRelayJob) Start(interval time.Duration) {
+	j.ticker = time.NewTicker(interval)
+
+	go func() {
+		for {
+			select {
+			case <-j.ticker.C:
+				j.run()
+			case <-j.quit:
+				j.ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	log.Println("Outbox relay job started")
+}
+
+// Stop stops the outbox relay job
+func (j *OutboxRelayJob) Stop() {
+	close(j.quit)
+	log.Println("Outbox relay job stopped")
+}
+
+// run publishes due outbox events
+func (j *OutboxRelayJob) run() {
+	published, err := j.outbox.RelayPending(context.Background())
+	if err != nil {
+		log.Printf("Outbox relay run failed: %v", err)
+		return
+	}
+
+	if published > 0 {
+		log.Printf("Outbox relay published %d events", published)
+	}
+}
+
+// RunOnce runs the job once for testing
+func (j *OutboxRelayJob) RunOnce() {
+	j.run()
+}
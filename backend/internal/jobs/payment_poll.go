@@ -0,0 +1,127 @@
+package jobs
+
+import (
+	"backend/internal/payment"
+	"backend/internal/repository"
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	// paymentPollDelay gives the provider callback a chance to arrive before
+	// the first query
+	paymentPollDelay = time.Minute
+
+	// paymentPollMaxAge stops polling payments the provider has long expired
+	paymentPollMaxAge = 24 * time.Hour
+
+	// paymentPollBatch bounds the provider queries made per run
+	paymentPollBatch = 200
+)
+
+// PaymentPollJob queries the provider for payments still waiting on a
+// callback, so a lost notification does not leave a paid order pending
+type PaymentPollJob struct {
+	orderRepo      repository.OrderRepository
+	paymentService payment.PaymentService
+	lastPolled     map[string]time.Time
+	now            func() time.Time
+	ticker         *time.Ticker
+	quit           chan bool
+}
+
+// NewPaymentPollJob creates a new payment poll job
+func NewPaymentPollJob(orderRepo repository.OrderRepository, paymentService payment.PaymentService) *PaymentPollJob {
+	return &PaymentPollJob{
+		orderRepo:      orderRepo,
+		paymentService: paymentService,
+		lastPolled:     make(map[string]time.Time),
+		now:            time.Now,
+		quit:           make(chan bool),
+	}
+}
+
+// Start starts the payment poll job
+func (j *PaymentPollJob) Start(interval time.Duration) {
+	j.ticker = time.NewTicker(interval)
+
+	go func() {
+		for {
+			select {
+			case <-j.ticker.C:
+				j.run()
+			case <-j.quit:
+				j.ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	log.Println("Payment poll job started")
+}
+
+// Stop stops the payment poll job
+func (j *PaymentPollJob) Stop() {
+	close(j.quit)
+	log.Println("Payment poll job stopped")
+}
+
+// run queries the in-flight payments that are due
+func (j *PaymentPollJob) run() {
+	ctx := context.Background()
+	now := j.now()
+
+	payments, err := j.orderRepo.ListPendingPayments(ctx, now.Add(-paymentPollMaxAge), now.Add(-paymentPollDelay), paymentPollBatch)
+	if err != nil {
+		log.Printf("Failed to list pending payments: %v", err)
+		return
+	}
+
+	polled := make(map[string]time.Time, len(payments))
+	settled := 0
+	for _, p := range payments {
+		id := p.ID.String()
+		last, seen := j.lastPolled[id]
+		if seen && now.Sub(last) < pollInterval(now.Sub(p.CreatedAt)) {
+			polled[id] = last
+			continue
+		}
+
+		polled[id] = now
+		result, err := j.paymentService.QueryPayment(ctx, id)
+		if err != nil {
+			log.Printf("Failed to query payment %s: %v", p.PaymentNo, err)
+			continue
+		}
+		if !result.IsInFlight() {
+			settled++
+		}
+	}
+	// Forget payments that are no longer pending
+	j.lastPolled = polled
+
+	if settled > 0 {
+		log.Printf("Payment poll settled %d of %d pending payments", settled, len(payments))
+	}
+}
+
+// pollInterval backs off queries as a payment ages; most callbacks that are
+// going to arrive do so within minutes
+func pollInterval(age time.Duration) time.Duration {
+	switch {
+	case age < 5*time.Minute:
+		return time.Minute
+	case age < 30*time.Minute:
+		return 5 * time.Minute
+	case age < 2*time.Hour:
+		return 15 * time.Minute
+	default:
+		return time.Hour
+	}
+}
+
+// RunOnce runs the job once for testing
+func (j *PaymentPollJob) RunOnce() {
+	j.run()
+}
@@ -0,0 +1,72 @@
+package jobs
+
+import (
+	"backend/internal/service"
+	"context"
+	"log"
+	"time"
+)
+
+// PromotionSchedulerJob switches promotion campaigns on and off at their
+// window boundaries
+type PromotionSchedulerJob struct {
+	promotions service.PromotionService
+	ticker     *time.Ticker
+	quit       chan bool
+}
+
+// NewPromotionSchedulerJob creates a new promotion scheduler job
+func NewPromotionSchedulerJob(promotions service.PromotionService) *PromotionSchedulerJob {
+	return &PromotionSchedulerJob{
+		promotions: promotions,
+		quit:       make(chan bool),
+	}
+}
+
+// Start starts the promotion scheduler job
+func (j *PromotionSchedulerJob) Start(interval time.Duration) {
+	j.ticker = time.NewTicker(interval)
+
+	go func() {
+		// Catch up on boundaries missed while the service was down
+		j.run()
+		for {
+			select {
+			case <-j.ticker.C:
+				j.run()
+			case <-j.quit:
+				j.ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	log.Println("Promotion scheduler job started")
+}
+
+// Stop stops the promotion scheduler job
+func (j *PromotionSchedulerJob) Stop() {
+	close(j.quit)
+	log.Println("Promotion scheduler job stopped")
+}
+
+// run activates and expires due campaigns
+func (j *PromotionSchedulerJob) run() {
+	result, err := j.promotions.RunSchedule(context.Background())
+	if err != nil {
+		log.Printf("Promotion scheduler run failed: %v", err)
+	}
+	if result == nil {
+		return
+	}
+
+	if len(result.Activated) > 0 || len(result.Ended) > 0 || result.StaleCleared > 0 {
+		log.Printf("Promotion scheduler activated %d campaigns (%d prices), ended %d (%d prices restored), cleared %d stale promotions",
+			len(result.Activated), result.PricesApplied, len(result.Ended), result.PricesRestored, result.StaleCleared)
+	}
+}
+
+// RunOnce runs the job once for testing
+func (j *PromotionSchedulerJob) RunOnce() {
+	j.run()
+}
@@ -0,0 +1,67 @@
+package jobs
+
+import (
+	"backend/internal/service"
+	"context"
+	"log"
+	"time"
+)
+
+// RefundSyncJob queries providers for refunds whose result notification is
+// overdue, so a lost notification does not leave a refund processing forever
+type RefundSyncJob struct {
+	refunds service.RefundService
+	ticker  *time.Ticker
+	quit    chan bool
+}
+
+// NewRefundSyncJob creates a new refund sync job
+func NewRefundSyncJob(refunds service.RefundService) *RefundSyncJob {
+	return &RefundSyncJob{
+		refunds: refunds,
+		quit:    make(chan bool),
+	}
+}
+
+// Start starts the refund sync job
+func (j *RefundSyncJob) Start(interval time.Duration) {
+	j.ticker = time.NewTicker(interval)
+
+	go func() {
+		for {
+			select {
+			case <-j.ticker.C:
+				j.run()
+			case <-j.quit:
+				j.ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	log.Println("Refund sync job started")
+}
+
+// Stop stops the refund sync job
+func (j *RefundSyncJob) Stop() {
+	close(j.quit)
+	log.Println("Refund sync job stopped")
+}
+
+// run settles overdue refunds
+func (j *RefundSyncJob) run() {
+	settled, err := j.refunds.SyncProcessingRefunds(context.Background())
+	if err != nil {
+		log.Printf("Refund sync run failed: %v", err)
+		return
+	}
+
+	if settled > 0 {
+		log.Printf("Refund sync settled %d refunds", settled)
+	}
+}
+
+// RunOnce runs the job once for testing
+func (j *RefundSyncJob) RunOnce() {
+	j.run()
+}
@@ -2,11 +2,28 @@ package messaging
 
 import (
 	"backend/internal/config"
+	"context"
+	"errors"
 	"fmt"
 
 	"github.com/nats-io/nats.go"
 )
 
+// EventStream is the JetStream stream holding order, payment and refund events
+const EventStream = "EVENTS"
+
+// eventSubjects are the subjects captured by EventStream
+var eventSubjects = []string{"order.>", "payment.>", "refund.>"}
+
+// Publisher publishes events durably
+type Publisher interface {
+	// Publish publishes data to subject; msgID lets the server drop
+	// duplicates of a message that is published again
+	Publish(ctx context.Context, subject string, data []byte, msgID string) error
+	// Connected reports whether the server is reachable
+	Connected() bool
+}
+
 // NATSClient wraps nats.Conn
 type NATSClient struct {
 	conn *nats.Conn
@@ -41,6 +58,39 @@ func (n *NATSClient) GetJetStream() nats.JetStreamContext {
 	return n.js
 }
 
+// EnsureStream creates the event stream if it does not exist yet
+func (n *NATSClient) EnsureStream() error {
+	_, err := n.js.StreamInfo(EventStream)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, nats.ErrStreamNotFound) {
+		return fmt.Errorf("failed to look up stream %s: %w", EventStream, err)
+	}
+
+	_, err = n.js.AddStream(&nats.StreamConfig{
+		Name:     EventStream,
+		Subjects: eventSubjects,
+		Storage:  nats.FileStorage,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create stream %s: %w", EventStream, err)
+	}
+	return nil
+}
+
+// Publish publishes data to subject through JetStream and waits for the
+// server to store it
+func (n *NATSClient) Publish(ctx context.Context, subject string, data []byte, msgID string) error {
+	_, err := n.js.Publish(subject, data, nats.MsgId(msgID), nats.Context(ctx))
+	return err
+}
+
+// Connected reports whether the connection is up
+func (n *NATSClient) Connected() bool {
+	return n.conn.IsConnected()
+}
+
 // Close closes the NATS connection
 func (n *NATSClient) Close() {
 	n.conn.Close()
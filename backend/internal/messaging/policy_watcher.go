@@ -0,0 +1,72 @@
+package messaging
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+)
+
+// PolicySubject carries notices that the RBAC policies changed. It is a
+// plain NATS subject outside the event stream: a replica that misses a
+// notice while disconnected reloads the policies when it restarts
+const PolicySubject = "rbac.policy.updated"
+
+// PolicyWatcher tells other replicas to reload the RBAC policies after one
+// of them changes them. It implements the Casbin watcher interface
+type PolicyWatcher struct {
+	conn       *nats.Conn
+	sub        *nats.Subscription
+	instanceID string
+
+	mu       sync.Mutex
+	callback func(string)
+}
+
+// NewPolicyWatcher creates a policy watcher subscribed to PolicySubject
+func NewPolicyWatcher(conn *nats.Conn) (*PolicyWatcher, error) {
+	w := &PolicyWatcher{conn: conn, instanceID: uuid.NewString()}
+
+	sub, err := conn.Subscribe(PolicySubject, w.handle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", PolicySubject, err)
+	}
+	w.sub = sub
+	return w, nil
+}
+
+// SetUpdateCallback sets the function called when another replica changes
+// the policies
+func (w *PolicyWatcher) SetUpdateCallback(callback func(string)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callback = callback
+	return nil
+}
+
+// Update tells the other replicas that the policies changed
+func (w *PolicyWatcher) Update() error {
+	return w.conn.Publish(PolicySubject, []byte(w.instanceID))
+}
+
+// Close stops watching
+func (w *PolicyWatcher) Close() {
+	if w.sub != nil {
+		_ = w.sub.Unsubscribe()
+	}
+}
+
+func (w *PolicyWatcher) handle(msg *nats.Msg) {
+	// This replica's own changes are applied already
+	if string(msg.Data) == w.instanceID {
+		return
+	}
+
+	w.mu.Lock()
+	callback := w.callback
+	w.mu.Unlock()
+	if callback != nil {
+		callback(string(msg.Data))
+	}
+}
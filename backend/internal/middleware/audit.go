@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"backend/internal/domain"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Auditor records back-office requests in the audit log
+type Auditor interface {
+	// Snapshot returns the current state of a target as JSON, or nil
+	Snapshot(ctx context.Context, resource, targetID string) []byte
+	Record(ctx context.Context, entry *domain.AdminAuditLog) error
+}
+
+// auditBodyLimit caps how much of a request or response body is kept
+const auditBodyLimit = 64 << 10
+
+// auditRedacted replaces secrets in recorded request bodies
+const auditRedacted = "***"
+
+// AdminAudit records every mutating request under prefix with the actor,
+// the target and its state before and after, and the result. It runs
+// before authorization so refused attempts are recorded too
+func AdminAudit(auditor Auditor, prefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		// The entry is recorded even if the client goes away mid-request
+		ctx := context.WithoutCancel(c.Request.Context())
+		entry := &domain.AdminAuditLog{
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			Method:    c.Request.Method,
+			Route:     c.FullPath(),
+			Path:      c.Request.URL.Path,
+			Resource:  auditResource(c.FullPath(), prefix),
+			Request:   auditRequestBody(c),
+		}
+		if len(c.Params) > 0 {
+			entry.TargetID = c.Params[0].Value
+			entry.Before = auditor.Snapshot(ctx, entry.Resource, entry.TargetID)
+		}
+
+		writer := &auditWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		entry.ActorID = c.GetString("userID")
+		entry.ActorName = c.GetString("username")
+		entry.ActorRole = c.GetString("role")
+		entry.StatusCode = writer.Status()
+		entry.DurationMs = time.Since(start).Milliseconds()
+
+		var resp struct {
+			Message string          `json:"message"`
+			Error   string          `json:"error"`
+			Data    json.RawMessage `json:"data"`
+		}
+		_ = json.Unmarshal(writer.body.Bytes(), &resp)
+
+		if entry.StatusCode < http.StatusBadRequest {
+			entry.Result = domain.AuditResultSuccess
+			if entry.TargetID == "" {
+				var created struct {
+					ID string `json:"id"`
+				}
+				if json.Unmarshal(resp.Data, &created) == nil {
+					entry.TargetID = created.ID
+				}
+			}
+			if entry.Method != http.MethodDelete {
+				entry.After = auditor.Snapshot(ctx, entry.Resource, entry.TargetID)
+				if entry.After == nil && len(resp.Data) > 0 && string(resp.Data) != "null" {
+					entry.After = []byte(resp.Data)
+				}
+			}
+		} else {
+			entry.Result = domain.AuditResultFailure
+			entry.Error = resp.Error
+			if entry.Error == "" {
+				entry.Error = resp.Message
+			}
+		}
+
+		if err := auditor.Record(ctx, entry); err != nil {
+			log.Printf("Failed to record audit log for %s %s: %v", entry.Method, entry.Path, err)
+		}
+	}
+}
+
+// auditResource names what a route acts on: its path below prefix up to
+// the first parameter, e.g. "cruises" for /api/v1/admin/cruises/:id/status
+func auditResource(route, prefix string) string {
+	route = strings.Trim(strings.TrimPrefix(route, prefix), "/")
+	if i := strings.Index(route, "/:"); i >= 0 {
+		route = route[:i]
+	}
+	return route
+}
+
+// auditRequestBody returns a JSON request body with secrets redacted, and
+// leaves the body for the handler to read
+func auditRequestBody(c *gin.Context) []byte {
+	if c.Request.Body == nil || !strings.HasPrefix(c.ContentType(), "application/json") {
+		return nil
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil || len(body) == 0 || len(body) > auditBodyLimit {
+		return nil
+	}
+
+	var value interface{}
+	if json.Unmarshal(body, &value) != nil {
+		return nil
+	}
+	redacted, err := json.Marshal(redactSecrets(value))
+	if err != nil {
+		return nil
+	}
+	return redacted
+}
+
+// redactSecrets replaces password, secret and token fields in a decoded
+// JSON value
+func redactSecrets(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			name := strings.ToLower(key)
+			if strings.Contains(name, "password") || strings.Contains(name, "secret") || strings.Contains(name, "token") {
+				v[key] = auditRedacted
+				continue
+			}
+			v[key] = redactSecrets(field)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactSecrets(item)
+		}
+	}
+	return value
+}
+
+// auditWriter keeps a copy of the response body for the audit log
+type auditWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *auditWriter) Write(data []byte) (int, error) {
+	w.keep(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *auditWriter) WriteString(s string) (int, error) {
+	w.keep([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *auditWriter) keep(data []byte) {
+	if room := auditBodyLimit - w.body.Len(); room > 0 {
+		if len(data) > room {
+			data = data[:room]
+		}
+		w.body.Write(data)
+	}
+}
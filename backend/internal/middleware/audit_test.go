@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"backend/internal/domain"
+	"backend/internal/response"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAuditor snapshots targets from a map and keeps recorded entries
+type fakeAuditor struct {
+	state   map[string]string // resource/id -> JSON
+	entries []*domain.AdminAuditLog
+}
+
+func (a *fakeAuditor) Snapshot(ctx context.Context, resource, targetID string) []byte {
+	if v, ok := a.state[resource+"/"+targetID]; ok {
+		return []byte(v)
+	}
+	return nil
+}
+
+func (a *fakeAuditor) Record(ctx context.Context, entry *domain.AdminAuditLog) error {
+	a.entries = append(a.entries, entry)
+	return nil
+}
+
+func newAuditRouter(auditor *fakeAuditor) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	admin := r.Group("/api/v1/admin")
+	admin.Use(func(c *gin.Context) {
+		c.Set("userID", "staff-1")
+		c.Set("username", "alice")
+		c.Set("role", "operations")
+	})
+	admin.Use(AdminAudit(auditor, "/api/v1/admin"))
+	admin.GET("/cruises/:id", func(c *gin.Context) { response.Success(c, nil) })
+	admin.PUT("/cruises/:id/status", func(c *gin.Context) {
+		auditor.state["cruises/"+c.Param("id")] = `{"id":"c-1","status":"inactive"}`
+		response.Success(c, gin.H{"id": c.Param("id")})
+	})
+	admin.POST("/access/staff", func(c *gin.Context) {
+		var body map[string]string
+		_ = c.ShouldBindJSON(&body)
+		response.Created(c, gin.H{"id": "s-9", "username": body["username"]})
+	})
+	admin.DELETE("/cruises/:id", func(c *gin.Context) {
+		response.NotFound(c, "邮轮不存在")
+	})
+	return r
+}
+
+func TestAdminAudit_RecordsUpdateWithSnapshots(t *testing.T) {
+	auditor := &fakeAuditor{state: map[string]string{"cruises/c-1": `{"id":"c-1","status":"active"}`}}
+	router := newAuditRouter(auditor)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/cruises/c-1/status", strings.NewReader(`{"status":"inactive"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	require.Len(t, auditor.entries, 1)
+	entry := auditor.entries[0]
+	assert.Equal(t, "staff-1", entry.ActorID)
+	assert.Equal(t, "alice", entry.ActorName)
+	assert.Equal(t, "operations", entry.ActorRole)
+	assert.Equal(t, "/api/v1/admin/cruises/:id/status", entry.Route)
+	assert.Equal(t, "cruises", entry.Resource)
+	assert.Equal(t, "c-1", entry.TargetID)
+	assert.JSONEq(t, `{"id":"c-1","status":"active"}`, string(entry.Before))
+	assert.JSONEq(t, `{"id":"c-1","status":"inactive"}`, string(entry.After))
+	assert.JSONEq(t, `{"status":"inactive"}`, string(entry.Request))
+	assert.Equal(t, domain.AuditResultSuccess, entry.Result)
+	assert.Equal(t, http.StatusOK, entry.StatusCode)
+}
+
+func TestAdminAudit_CreateRedactsSecretsAndTakesIDFromResponse(t *testing.T) {
+	auditor := &fakeAuditor{state: map[string]string{}}
+	router := newAuditRouter(auditor)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/access/staff", strings.NewReader(`{"username":"bob","password":"s3cret-pass"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	var resp struct {
+		Data map[string]string `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "bob", resp.Data["username"], "the handler still reads the body")
+
+	require.Len(t, auditor.entries, 1)
+	entry := auditor.entries[0]
+	assert.Equal(t, "access/staff", entry.Resource)
+	assert.Equal(t, "s-9", entry.TargetID)
+	assert.JSONEq(t, `{"username":"bob","password":"***"}`, string(entry.Request))
+	assert.JSONEq(t, `{"id":"s-9","username":"bob"}`, string(entry.After))
+}
+
+func TestAdminAudit_RecordsFailure(t *testing.T) {
+	auditor := &fakeAuditor{state: map[string]string{}}
+	router := newAuditRouter(auditor)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/v1/admin/cruises/c-2", nil))
+
+	require.Len(t, auditor.entries, 1)
+	entry := auditor.entries[0]
+	assert.Equal(t, domain.AuditResultFailure, entry.Result)
+	assert.Equal(t, http.StatusNotFound, entry.StatusCode)
+	assert.Equal(t, "邮轮不存在", entry.Error)
+	assert.Nil(t, entry.After)
+}
+
+func TestAdminAudit_SkipsReads(t *testing.T) {
+	auditor := &fakeAuditor{state: map[string]string{}}
+	router := newAuditRouter(auditor)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/cruises/c-1", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, auditor.entries)
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"backend/internal/auth"
+	"backend/internal/response"
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission checks that one of the caller's roles is granted action
+// on resource. Roles are looked up by the caller's ID, so role changes apply
+// at once rather than when the token is refreshed
+func RequirePermission(rbac *auth.RBAC, resource, action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetString("userID")
+		if userID == "" {
+			response.Unauthorized(c, "User not found in context")
+			c.Abort()
+			return
+		}
+
+		allowed, err := rbac.CheckPermission(userID, resource, action)
+		if err != nil {
+			response.InternalServerError(c, "Failed to check permissions")
+			c.Abort()
+			return
+		}
+		if !allowed {
+			response.Error(c, http.StatusForbidden, "Insufficient permissions")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireResource checks permission on resource with the action taken from
+// the request method: read for GET and HEAD, write for anything else
+func RequireResource(rbac *auth.RBAC, resource string) gin.HandlerFunc {
+	read := RequirePermission(rbac, resource, auth.PermRead)
+	write := RequirePermission(rbac, resource, auth.PermWrite)
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead:
+			read(c)
+		default:
+			write(c)
+		}
+	}
+}
+
+// StaffChecker tells whether a staff account may still use the back office
+type StaffChecker interface {
+	IsActive(ctx context.Context, id string) (bool, error)
+}
+
+// RequireActiveStaff rejects callers whose staff account was disabled or
+// removed. Tokens outlive such changes and disabled staff keep their roles,
+// so the account is checked on every request
+func RequireActiveStaff(staff StaffChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetString("userID")
+		if userID == "" {
+			response.Unauthorized(c, "User not found in context")
+			c.Abort()
+			return
+		}
+
+		active, err := staff.IsActive(c.Request.Context(), userID)
+		if err != nil {
+			response.InternalServerError(c, "Failed to check staff account")
+			c.Abort()
+			return
+		}
+		if !active {
+			response.Error(c, http.StatusForbidden, "Staff account is disabled")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"backend/internal/auth"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequirePermission_NoCaller(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rbac, err := auth.NewRBAC()
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.GET("/refunds", RequirePermission(rbac, auth.ResourceRefundRequests, auth.PermRead), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/refunds", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestRequirePermission_RoleChangeAppliesAtOnce(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rbac, err := auth.NewRBAC()
+	require.NoError(t, err)
+	require.NoError(t, rbac.SetRolesForUser("staff-1", []string{auth.RoleFinance}))
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("userID", "staff-1") })
+	r.GET("/refunds", RequirePermission(rbac, auth.ResourceRefundRequests, auth.PermRead), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/refunds", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	require.NoError(t, rbac.SetRolesForUser("staff-1", []string{auth.RoleOperations}))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/refunds", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+// staffStatus is a StaffChecker backed by a map of staff ID to active
+type staffStatus map[string]bool
+
+func (s staffStatus) IsActive(_ context.Context, id string) (bool, error) {
+	if id == "staff-broken" {
+		return false, errors.New("connection refused")
+	}
+	return s[id], nil
+}
+
+func TestRequireActiveStaff(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rbac, err := auth.NewRBAC()
+	require.NoError(t, err)
+	staff := staffStatus{"staff-active": true, "staff-disabled": false}
+
+	tests := []struct {
+		userID string
+		want   int
+	}{
+		{"staff-active", http.StatusOK},
+		{"staff-disabled", http.StatusForbidden},
+		{"staff-removed", http.StatusForbidden},
+		{"staff-broken", http.StatusInternalServerError},
+		{"", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run("caller "+tt.userID, func(t *testing.T) {
+			// Disabled staff keep their roles; the account check still stops them
+			if tt.userID != "" {
+				require.NoError(t, rbac.SetRolesForUser(tt.userID, []string{auth.RoleSuperAdmin}))
+			}
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				if tt.userID != "" {
+					c.Set("userID", tt.userID)
+				}
+			})
+			r.GET("/cruises", RequireActiveStaff(staff), RequireResource(rbac, auth.ResourceCruises), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/cruises", nil))
+
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
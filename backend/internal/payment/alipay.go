@@ -0,0 +1,586 @@
+package payment
+
+import (
+	"backend/internal/currency"
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Alipay payment channels
+const (
+	AlipayChannelPage = "page" // desktop web checkout, redirects to Alipay
+	AlipayChannelWAP  = "wap"  // mobile web checkout, opens the Alipay app
+	AlipayChannelQR   = "qr"   // pre-created order paid by scanning a QR code
+)
+
+const (
+	alipayGateway        = "https://openapi.alipay.com/gateway.do"
+	alipaySandboxGateway = "https://openapi-sandbox.dl.alipaydev.com/gateway.do"
+	alipayTimeLayout     = "2006-01-02 15:04:05"
+	alipayCodeSuccess    = "10000"
+)
+
+// alipayLocation is the timezone Alipay timestamps are expressed in
+var alipayLocation = time.FixedZone("CST", 8*3600)
+
+// AlipayConfig represents Alipay open platform configuration
+type AlipayConfig struct {
+	AppID           string
+	PrivateKey      string // application RSA2 private key (PEM, PKCS#1 or PKCS#8)
+	AlipayPublicKey string // Alipay RSA2 public key used to verify responses and notifications (PEM)
+	NotifyURL       string
+	ReturnURL       string
+	GatewayURL      string // overrides the production/sandbox gateway when set
+	Sandbox         bool
+}
+
+type channelKey struct{}
+
+// WithChannel selects the checkout channel, or trade type, used by
+// providers that offer more than one, such as Alipay page, WAP and QR
+// payments or WeChat Pay JSAPI, Native and H5 payments
+func WithChannel(ctx context.Context, channel string) context.Context {
+	return context.WithValue(ctx, channelKey{}, channel)
+}
+
+// channelFrom returns the checkout channel requested on the context
+func channelFrom(ctx context.Context) string {
+	channel, _ := ctx.Value(channelKey{}).(string)
+	return channel
+}
+
+// Payer describes the customer starting a payment, as some trade types
+// require
+type Payer struct {
+	ClientIP string // H5 payments
+	OpenID   string // WeChat JSAPI payments
+	UserID   string // points payments, which only pay the holder's orders
+}
+
+type amountKey struct{}
+
+// WithAmount sets how much of the order a payment covers, for orders paid
+// with more than one payment. Without it a payment covers the whole order
+func WithAmount(ctx context.Context, amount float64) context.Context {
+	return context.WithValue(ctx, amountKey{}, amount)
+}
+
+// amountFrom returns the amount requested on the context, or the order's
+// payable amount
+func amountFrom(ctx context.Context, order *domain.Order) float64 {
+	if amount, ok := ctx.Value(amountKey{}).(float64); ok && amount > 0 {
+		return amount
+	}
+	return order.PayableAmount()
+}
+
+type payerKey struct{}
+
+// WithPayer attaches the paying customer to the context
+func WithPayer(ctx context.Context, payer Payer) context.Context {
+	return context.WithValue(ctx, payerKey{}, payer)
+}
+
+// payerFrom returns the paying customer on the context
+func payerFrom(ctx context.Context) Payer {
+	payer, _ := ctx.Value(payerKey{}).(Payer)
+	return payer
+}
+
+// withDefaultGateway fills in the production or sandbox gateway unless one
+// is configured
+func (c AlipayConfig) withDefaultGateway() AlipayConfig {
+	if c.GatewayURL == "" {
+		c.GatewayURL = alipayGateway
+		if c.Sandbox {
+			c.GatewayURL = alipaySandboxGateway
+		}
+	}
+	return c
+}
+
+// alipay implements PaymentProvider for Alipay
+type alipay struct {
+	config      AlipayConfig
+	client      *http.Client
+	paymentRepo repository.OrderRepository
+	now         func() time.Time
+}
+
+// NewAlipay creates a new Alipay provider
+func NewAlipay(config AlipayConfig, paymentRepo repository.OrderRepository) PaymentProvider {
+	return &alipay{
+		config:      config.withDefaultGateway(),
+		client:      &http.Client{Timeout: 30 * time.Second},
+		paymentRepo: paymentRepo,
+		now:         time.Now,
+	}
+}
+
+// CreatePayment creates an Alipay page, WAP or QR payment. Page and WAP
+// payments are signed redirect URLs; QR payments are pre-created on the
+// gateway. The URL or QR code is kept in the payment's ThirdPartyResponse.
+func (a *alipay) CreatePayment(ctx context.Context, order *domain.Order, description string) (*PaymentResult, error) {
+	code := currencyOrBase(order.Currency)
+	if code != currency.Base {
+		return nil, fmt.Errorf("%w: Alipay only accepts %s payments", ErrPaymentFailed, currency.Base)
+	}
+
+	channel := channelFrom(ctx)
+	if channel == "" {
+		channel = AlipayChannelPage
+	}
+
+	paymentNo := generatePaymentNo()
+	amount := amountFrom(ctx, order)
+	expiresAt := a.now().Add(30 * time.Minute)
+
+	biz := map[string]interface{}{
+		"out_trade_no": paymentNo,
+		"total_amount": formatAlipayAmount(amount),
+		"subject":      description,
+		"time_expire":  expiresAt.In(alipayLocation).Format(alipayTimeLayout),
+	}
+
+	result := &PaymentResult{
+		PaymentNo: paymentNo,
+		TradeType: channel,
+		AppID:     a.config.AppID,
+		SignType:  "RSA2",
+		ExpiresAt: expiresAt.Format(time.RFC3339),
+	}
+
+	switch channel {
+	case AlipayChannelPage, AlipayChannelWAP:
+		method := "alipay.trade.page.pay"
+		biz["product_code"] = "FAST_INSTANT_TRADE_PAY"
+		if channel == AlipayChannelWAP {
+			method = "alipay.trade.wap.pay"
+			biz["product_code"] = "QUICK_WAP_WAY"
+			biz["quit_url"] = a.config.ReturnURL
+		}
+		params, err := a.buildParams(method, biz, true)
+		if err != nil {
+			return nil, err
+		}
+		result.PayURL = a.config.GatewayURL + "?" + params.Encode()
+	case AlipayChannelQR:
+		resp, err := a.request(ctx, "alipay.trade.precreate", biz)
+		if err != nil {
+			return nil, err
+		}
+		result.QRCodeURL, _ = resp["qr_code"].(string)
+	default:
+		return nil, fmt.Errorf("%w: Alipay %s", ErrUnsupportedTradeType, channel)
+	}
+
+	raw, _ := json.Marshal(result)
+	payment := &domain.Payment{
+		OrderID:            order.ID.String(),
+		PaymentNo:          paymentNo,
+		PaymentMethod:      domain.PaymentMethodAlipay,
+		PaymentChannel:     channel,
+		Amount:             amount,
+		Currency:           code,
+		Status:             domain.PaymentStatusPending,
+		ThirdPartyResponse: string(raw),
+	}
+	payment.ApplyExchangeRate(order.BaseCurrency, order.ExchangeRate)
+
+	if err := a.paymentRepo.CreatePayment(ctx, payment); err != nil {
+		return nil, fmt.Errorf("failed to create payment record: %w", err)
+	}
+
+	return result, nil
+}
+
+// TradeTypes lists the Alipay checkout channels, page first
+func (a *alipay) TradeTypes() []string {
+	return []string{AlipayChannelPage, AlipayChannelWAP, AlipayChannelQR}
+}
+
+// QueryPayment queries Alipay trade status
+func (a *alipay) QueryPayment(ctx context.Context, paymentNo string) (*PaymentQueryResult, error) {
+	resp, err := a.request(ctx, "alipay.trade.query", map[string]interface{}{
+		"out_trade_no": paymentNo,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tradeStatus, _ := resp["trade_status"].(string)
+	tradeNo, _ := resp["trade_no"].(string)
+	paidAt, _ := resp["send_pay_date"].(string)
+	totalAmount, _ := resp["total_amount"].(string)
+	amount, _ := strconv.ParseFloat(totalAmount, 64)
+
+	return &PaymentQueryResult{
+		Status:       a.mapTradeStatus(tradeStatus),
+		Amount:       amount,
+		ThirdPartyID: tradeNo,
+		PaidAt:       a.formatTime(paidAt),
+	}, nil
+}
+
+// ProcessCallback processes an Alipay asynchronous notification. The body
+// is the form-encoded notification, which carries its own signature.
+func (a *alipay) ProcessCallback(ctx context.Context, body []byte, signature string) (*CallbackResult, error) {
+	if !a.VerifySignature(body, signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	values, _ := url.ParseQuery(string(body))
+	if values.Get("app_id") != a.config.AppID {
+		return nil, ErrInvalidSignature
+	}
+
+	amount, err := strconv.ParseFloat(values.Get("total_amount"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notification amount: %w", err)
+	}
+
+	paidAt := values.Get("gmt_payment")
+	if paidAt == "" {
+		paidAt = values.Get("notify_time")
+	}
+
+	return &CallbackResult{
+		PaymentNo:    values.Get("out_trade_no"),
+		ThirdPartyID: values.Get("trade_no"),
+		Amount:       amount,
+		Status:       a.mapTradeStatus(values.Get("trade_status")),
+		PaidAt:       a.formatTime(paidAt),
+	}, nil
+}
+
+// VerifySignature verifies the RSA2 signature of an Alipay notification.
+// The signature is read from the notification's sign field unless given.
+func (a *alipay) VerifySignature(body []byte, signature string) bool {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return false
+	}
+	if signature == "" {
+		signature = values.Get("sign")
+	}
+	if signature == "" || (values.Get("sign_type") != "" && values.Get("sign_type") != "RSA2") {
+		return false
+	}
+
+	values.Del("sign")
+	values.Del("sign_type")
+	return a.verify(canonicalAlipayParams(values), signature) == nil
+}
+
+// Refund requests an Alipay refund. Alipay settles most refunds
+// synchronously; when funds have not moved yet the refund is PROCESSING.
+func (a *alipay) Refund(ctx context.Context, payment *domain.Payment, amount float64, reason string) (*RefundResult, error) {
+	refundNo := generateRefundNo()
+
+	resp, err := a.request(ctx, "alipay.trade.refund", map[string]interface{}{
+		"out_trade_no":   payment.PaymentNo,
+		"out_request_no": refundNo,
+		"refund_amount":  formatAlipayAmount(amount),
+		"refund_reason":  reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	status := "PROCESSING"
+	if fundChange, _ := resp["fund_change"].(string); fundChange == "Y" {
+		status = "SUCCESS"
+	}
+	tradeNo, _ := resp["trade_no"].(string)
+
+	return &RefundResult{
+		RefundNo:     refundNo,
+		ThirdPartyID: tradeNo,
+		Status:       status,
+	}, nil
+}
+
+// ProcessRefundCallback processes the notification Alipay sends when a
+// refund's funds have moved. out_biz_no carries the refund request number.
+// The amount is left unset: refund_fee is the trade's cumulative refund, not
+// this refund's.
+func (a *alipay) ProcessRefundCallback(ctx context.Context, body []byte, signature string) (*RefundResult, error) {
+	if !a.VerifySignature(body, signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	values, _ := url.ParseQuery(string(body))
+	if values.Get("app_id") != a.config.AppID {
+		return nil, ErrInvalidSignature
+	}
+	if values.Get("out_biz_no") == "" {
+		return nil, fmt.Errorf("notification is not a refund notification")
+	}
+
+	status := "PROCESSING"
+	if values.Get("gmt_refund") != "" {
+		status = "SUCCESS"
+	}
+
+	return &RefundResult{
+		RefundNo:     values.Get("out_biz_no"),
+		ThirdPartyID: values.Get("trade_no"),
+		Status:       status,
+		PaymentNo:    values.Get("out_trade_no"),
+		SucceededAt:  a.formatTime(values.Get("gmt_refund")),
+		RawData:      string(body),
+	}, nil
+}
+
+// QueryRefund queries the state of an Alipay refund
+func (a *alipay) QueryRefund(ctx context.Context, payment *domain.Payment, refundNo string) (*RefundResult, error) {
+	resp, err := a.request(ctx, "alipay.trade.fastpay.refund.query", map[string]interface{}{
+		"out_trade_no":   payment.PaymentNo,
+		"out_request_no": refundNo,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// An empty answer means Alipay has no refund under this request number
+	status := "NOTFOUND"
+	refundStatus, _ := resp["refund_status"].(string)
+	if _, ok := resp["out_request_no"]; ok {
+		status = "PROCESSING"
+	}
+	if refundStatus == "REFUND_SUCCESS" {
+		status = "SUCCESS"
+	}
+	tradeNo, _ := resp["trade_no"].(string)
+
+	return &RefundResult{
+		RefundNo:     refundNo,
+		ThirdPartyID: tradeNo,
+		Status:       status,
+	}, nil
+}
+
+// Helper methods
+
+// buildParams assembles and signs the common request parameters
+func (a *alipay) buildParams(method string, biz map[string]interface{}, withReturn bool) (url.Values, error) {
+	bizContent, err := json.Marshal(biz)
+	if err != nil {
+		return nil, err
+	}
+
+	params := a.commonParams(method)
+	params.Set("biz_content", string(bizContent))
+	if a.config.NotifyURL != "" {
+		params.Set("notify_url", a.config.NotifyURL)
+	}
+	if withReturn && a.config.ReturnURL != "" {
+		params.Set("return_url", a.config.ReturnURL)
+	}
+
+	if err := a.signParams(params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// commonParams returns the public parameters of a gateway request
+func (a *alipay) commonParams(method string) url.Values {
+	params := url.Values{}
+	params.Set("app_id", a.config.AppID)
+	params.Set("method", method)
+	params.Set("format", "JSON")
+	params.Set("charset", "utf-8")
+	params.Set("sign_type", "RSA2")
+	params.Set("timestamp", a.now().In(alipayLocation).Format(alipayTimeLayout))
+	params.Set("version", "1.0")
+	return params
+}
+
+// signParams adds the signature of params
+func (a *alipay) signParams(params url.Values) error {
+	signature, err := a.sign(canonicalAlipayParams(params))
+	if err != nil {
+		return err
+	}
+	params.Set("sign", signature)
+	return nil
+}
+
+// request calls a gateway API and returns its verified response node
+func (a *alipay) request(ctx context.Context, method string, biz map[string]interface{}) (map[string]interface{}, error) {
+	params, err := a.buildParams(method, biz, false)
+	if err != nil {
+		return nil, err
+	}
+	return a.send(ctx, method, params)
+}
+
+// send posts signed params to the gateway and returns the verified
+// response node
+func (a *alipay) send(ctx context.Context, method string, params url.Values) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.config.GatewayURL, strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded;charset=utf-8")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Alipay API error: %s", string(respBody))
+	}
+
+	// The signature covers the raw bytes of the response node
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(respBody, &envelope); err != nil {
+		return nil, fmt.Errorf("invalid Alipay response: %w", err)
+	}
+	node := envelope[strings.ReplaceAll(method, ".", "_")+"_response"]
+	if node == nil {
+		// Some APIs answer errors in a generic node
+		node = envelope["error_response"]
+	}
+	if node == nil {
+		return nil, fmt.Errorf("invalid Alipay response: %s", string(respBody))
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(node, &result); err != nil {
+		return nil, fmt.Errorf("invalid Alipay response: %w", err)
+	}
+
+	var signature string
+	_ = json.Unmarshal(envelope["sign"], &signature)
+	// Successful OAuth responses carry no code
+	if code, ok := result["code"].(string); ok && code != alipayCodeSuccess {
+		// Error responses are not always signed
+		msg, _ := result["sub_msg"].(string)
+		if msg == "" {
+			msg, _ = result["msg"].(string)
+		}
+		return nil, fmt.Errorf("Alipay API error %v: %s", result["code"], msg)
+	}
+	if err := a.verify(string(node), signature); err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	return result, nil
+}
+
+func (a *alipay) sign(content string) (string, error) {
+	block, _ := pem.Decode([]byte(a.config.PrivateKey))
+	if block == nil {
+		return "", errors.New("invalid Alipay private key")
+	}
+
+	var privateKey *rsa.PrivateKey
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		privateKey, _ = key.(*rsa.PrivateKey)
+	} else if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		privateKey = key
+	}
+	if privateKey == nil {
+		return "", errors.New("invalid Alipay private key")
+	}
+
+	hash := sha256.Sum256([]byte(content))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+func (a *alipay) verify(content, signature string) error {
+	block, _ := pem.Decode([]byte(a.config.AlipayPublicKey))
+	if block == nil {
+		return errors.New("invalid Alipay public key")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return err
+	}
+	publicKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return errors.New("invalid Alipay public key")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256([]byte(content))
+	return rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hash[:], sig)
+}
+
+func (a *alipay) mapTradeStatus(status string) string {
+	switch status {
+	case "TRADE_SUCCESS", "TRADE_FINISHED":
+		return domain.PaymentStatusSuccess
+	case "WAIT_BUYER_PAY":
+		return domain.PaymentStatusPending
+	case "TRADE_CLOSED":
+		return domain.PaymentStatusCancelled
+	default:
+		return domain.PaymentStatusPending
+	}
+}
+
+// formatTime converts an Alipay local timestamp to RFC 3339
+func (a *alipay) formatTime(value string) string {
+	t, err := time.ParseInLocation(alipayTimeLayout, value, alipayLocation)
+	if err != nil {
+		return value
+	}
+	return t.Format(time.RFC3339)
+}
+
+// canonicalAlipayParams builds the string Alipay signs: non-empty
+// parameters sorted by key and joined as k=v pairs with &
+func canonicalAlipayParams(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if values.Get(key) != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + values.Get(key)
+	}
+	return strings.Join(pairs, "&")
+}
+
+// formatAlipayAmount formats an amount in yuan with two decimals
+func formatAlipayAmount(amount float64) string {
+	return strconv.FormatFloat(currency.Round(amount, currency.Base), 'f', 2, 64)
+}
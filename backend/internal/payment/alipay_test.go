@@ -0,0 +1,246 @@
+package payment
+
+import (
+	"backend/internal/domain"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// alipayStub is a local stand-in for the Alipay gateway. It checks request
+// signatures against the app key and signs its responses with its own key.
+type alipayStub struct {
+	t          *testing.T
+	appKey     *rsa.PrivateKey
+	gatewayKey *rsa.PrivateKey
+	responses  map[string]map[string]interface{}
+	requests   map[string]map[string]interface{}
+}
+
+func newAlipayStub(t *testing.T) (*alipayStub, *httptest.Server) {
+	appKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	gatewayKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	stub := &alipayStub{
+		t:          t,
+		appKey:     appKey,
+		gatewayKey: gatewayKey,
+		responses:  make(map[string]map[string]interface{}),
+		requests:   make(map[string]map[string]interface{}),
+	}
+	server := httptest.NewServer(http.HandlerFunc(stub.serve))
+	t.Cleanup(server.Close)
+	return stub, server
+}
+
+func (s *alipayStub) serve(w http.ResponseWriter, r *http.Request) {
+	require.NoError(s.t, r.ParseForm())
+	params := r.PostForm
+	signature := params.Get("sign")
+	params.Del("sign")
+	assert.NoError(s.t, verifyWith(&s.appKey.PublicKey, canonicalAlipayParams(params), signature), "request signature")
+
+	method := params.Get("method")
+	var biz map[string]interface{}
+	if bizContent := params.Get("biz_content"); bizContent != "" {
+		require.NoError(s.t, json.Unmarshal([]byte(bizContent), &biz))
+	} else {
+		// OAuth APIs take their arguments as public parameters
+		biz = map[string]interface{}{"grant_type": params.Get("grant_type"), "code": params.Get("code")}
+	}
+	s.requests[method] = biz
+
+	node, _ := json.Marshal(s.responses[method])
+	fmt.Fprintf(w, `{"%s_response":%s,"sign":"%s"}`,
+		strings.ReplaceAll(method, ".", "_"), node, signWith(s.gatewayKey, string(node)))
+}
+
+func (s *alipayStub) config(gatewayURL string) AlipayConfig {
+	return AlipayConfig{
+		AppID:           "2021000000000001",
+		PrivateKey:      string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(s.appKey)})),
+		AlipayPublicKey: publicKeyPEM(&s.gatewayKey.PublicKey),
+		NotifyURL:       "https://example.com/api/v1/payments/callback/alipay",
+		ReturnURL:       "https://example.com/orders",
+		GatewayURL:      gatewayURL,
+	}
+}
+
+func publicKeyPEM(key *rsa.PublicKey) string {
+	der, _ := x509.MarshalPKIXPublicKey(key)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func signWith(key *rsa.PrivateKey, content string) string {
+	hash := sha256.Sum256([]byte(content))
+	sig, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+func verifyWith(key *rsa.PublicKey, content, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256([]byte(content))
+	return rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], sig)
+}
+
+func TestAlipay_CreatePayment(t *testing.T) {
+	stub, server := newAlipayStub(t)
+	order := &domain.Order{TotalAmount: 12800, DiscountAmount: 800, Currency: "CNY"}
+	order.ID = uuid.New()
+
+	t.Run("page payment is a signed gateway URL", func(t *testing.T) {
+		repo := new(MockPaymentOrderRepository)
+		repo.On("CreatePayment", mock.Anything, mock.MatchedBy(func(p *domain.Payment) bool {
+			return p.PaymentMethod == domain.PaymentMethodAlipay && p.PaymentChannel == AlipayChannelPage && p.Amount == 12000
+		})).Return(nil)
+		provider := NewAlipay(stub.config(server.URL), repo)
+
+		result, err := provider.CreatePayment(context.Background(), order, "Cruise booking")
+
+		require.NoError(t, err)
+		payURL, err := url.Parse(result.PayURL)
+		require.NoError(t, err)
+		query := payURL.Query()
+		assert.Equal(t, "alipay.trade.page.pay", query.Get("method"))
+		assert.Contains(t, query.Get("biz_content"), `"total_amount":"12000.00"`)
+		signature := query.Get("sign")
+		query.Del("sign")
+		assert.NoError(t, verifyWith(&stub.appKey.PublicKey, canonicalAlipayParams(query), signature))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("QR payment is pre-created on the gateway", func(t *testing.T) {
+		stub.responses["alipay.trade.precreate"] = map[string]interface{}{
+			"code": "10000", "msg": "Success", "qr_code": "https://qr.alipay.com/bax01234",
+		}
+		repo := new(MockPaymentOrderRepository)
+		repo.On("CreatePayment", mock.Anything, mock.MatchedBy(func(p *domain.Payment) bool {
+			return p.PaymentChannel == AlipayChannelQR && strings.Contains(p.ThirdPartyResponse, "bax01234")
+		})).Return(nil)
+		provider := NewAlipay(stub.config(server.URL), repo)
+
+		result, err := provider.CreatePayment(WithChannel(context.Background(), AlipayChannelQR), order, "Cruise booking")
+
+		require.NoError(t, err)
+		assert.Equal(t, "https://qr.alipay.com/bax01234", result.QRCodeURL)
+		assert.Equal(t, result.PaymentNo, stub.requests["alipay.trade.precreate"]["out_trade_no"])
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("gateway errors are surfaced", func(t *testing.T) {
+		stub.responses["alipay.trade.precreate"] = map[string]interface{}{
+			"code": "40004", "msg": "Business Failed", "sub_msg": "交易已经关闭",
+		}
+		repo := new(MockPaymentOrderRepository)
+		provider := NewAlipay(stub.config(server.URL), repo)
+
+		_, err := provider.CreatePayment(WithChannel(context.Background(), AlipayChannelQR), order, "Cruise booking")
+
+		assert.ErrorContains(t, err, "交易已经关闭")
+		repo.AssertNotCalled(t, "CreatePayment", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAlipay_ProcessCallback(t *testing.T) {
+	stub, server := newAlipayStub(t)
+	provider := NewAlipay(stub.config(server.URL), new(MockPaymentOrderRepository))
+
+	notification := url.Values{}
+	notification.Set("app_id", "2021000000000001")
+	notification.Set("notify_time", "2026-06-01 10:00:05")
+	notification.Set("out_trade_no", "PAY20260601abc")
+	notification.Set("trade_no", "2026060122001400001")
+	notification.Set("trade_status", "TRADE_SUCCESS")
+	notification.Set("total_amount", "12000.00")
+	notification.Set("gmt_payment", "2026-06-01 10:00:00")
+	notification.Set("sign", signWith(stub.gatewayKey, canonicalAlipayParams(notification)))
+	notification.Set("sign_type", "RSA2")
+
+	result, err := provider.ProcessCallback(context.Background(), []byte(notification.Encode()), "")
+
+	require.NoError(t, err)
+	assert.Equal(t, "PAY20260601abc", result.PaymentNo)
+	assert.Equal(t, "2026060122001400001", result.ThirdPartyID)
+	assert.Equal(t, 12000.0, result.Amount)
+	assert.Equal(t, domain.PaymentStatusSuccess, result.Status)
+	assert.Equal(t, "2026-06-01T10:00:00+08:00", result.PaidAt)
+
+	notification.Set("total_amount", "1.00")
+	_, err = provider.ProcessCallback(context.Background(), []byte(notification.Encode()), "")
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+}
+
+func TestAlipay_QueryAndRefund(t *testing.T) {
+	stub, server := newAlipayStub(t)
+	provider := NewAlipay(stub.config(server.URL), new(MockPaymentOrderRepository))
+	ctx := context.Background()
+	payment := &domain.Payment{PaymentNo: "PAY20260601abc", Amount: 12000, Currency: "CNY"}
+
+	stub.responses["alipay.trade.query"] = map[string]interface{}{
+		"code": "10000", "msg": "Success", "trade_no": "2026060122001400001",
+		"trade_status": "TRADE_SUCCESS", "total_amount": "12000.00", "send_pay_date": "2026-06-01 10:00:00",
+	}
+	query, err := provider.QueryPayment(ctx, payment.PaymentNo)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusSuccess, query.Status)
+	assert.Equal(t, 12000.0, query.Amount)
+
+	stub.responses["alipay.trade.refund"] = map[string]interface{}{
+		"code": "10000", "msg": "Success", "trade_no": "2026060122001400001", "fund_change": "Y", "refund_fee": "500.00",
+	}
+	refund, err := provider.Refund(ctx, payment, 500, "行程变更")
+	require.NoError(t, err)
+	assert.Equal(t, "SUCCESS", refund.Status)
+	assert.Equal(t, "500.00", stub.requests["alipay.trade.refund"]["refund_amount"])
+	assert.Equal(t, refund.RefundNo, stub.requests["alipay.trade.refund"]["out_request_no"])
+
+	stub.responses["alipay.trade.fastpay.refund.query"] = map[string]interface{}{
+		"code": "10000", "msg": "Success", "out_request_no": refund.RefundNo, "refund_status": "REFUND_SUCCESS",
+	}
+	refundQuery, err := provider.(RefundQuerier).QueryRefund(ctx, payment, refund.RefundNo)
+	require.NoError(t, err)
+	assert.Equal(t, "SUCCESS", refundQuery.Status)
+}
+
+func TestAlipayAuth_UserID(t *testing.T) {
+	stub, server := newAlipayStub(t)
+	auth := NewAlipayAuth(stub.config(server.URL))
+	ctx := context.Background()
+
+	stub.responses["alipay.system.oauth.token"] = map[string]interface{}{
+		"user_id": "2088102150477652", "access_token": "authusrB0c1", "expires_in": 1296000,
+	}
+	userID, err := auth.UserID(ctx, "4b203fe6c11548bcabd8da5bb087a83b")
+	require.NoError(t, err)
+	assert.Equal(t, "2088102150477652", userID)
+	assert.Equal(t, "authorization_code", stub.requests["alipay.system.oauth.token"]["grant_type"])
+	assert.Equal(t, "4b203fe6c11548bcabd8da5bb087a83b", stub.requests["alipay.system.oauth.token"]["code"])
+
+	stub.responses["alipay.system.oauth.token"] = map[string]interface{}{
+		"code": "40002", "msg": "Invalid Arguments", "sub_code": "isv.code-invalid", "sub_msg": "授权码code无效",
+	}
+	_, err = auth.UserID(ctx, "expired")
+	assert.ErrorIs(t, err, ErrAlipayAuthFailed)
+}
@@ -0,0 +1,41 @@
+package payment
+
+import "context"
+
+// CallbackTrace collects what processing a provider callback found out, for
+// callers that keep a log of callbacks
+type CallbackTrace struct {
+	Verified  bool   // the provider authenticated and decoded the callback
+	Reference string // payment or refund number the callback is about
+}
+
+type callbackTraceKey struct{}
+
+// WithCallbackTrace asks ProcessCallback and ProcessRefundCallback to fill
+// in trace
+func WithCallbackTrace(ctx context.Context, trace *CallbackTrace) context.Context {
+	return context.WithValue(ctx, callbackTraceKey{}, trace)
+}
+
+// traceCallback records a verified callback on the context's trace, if any
+func traceCallback(ctx context.Context, reference string) {
+	if trace, ok := ctx.Value(callbackTraceKey{}).(*CallbackTrace); ok {
+		trace.Verified = true
+		trace.Reference = reference
+	}
+}
+
+type replayKey struct{}
+
+// WithReplay marks a callback as a stored notification processed again by
+// staff. Its signature is still verified, but providers do not reject it as a
+// repeated delivery; the payment service's idempotency checks still apply
+func WithReplay(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replayKey{}, true)
+}
+
+// isReplay reports whether the callback on the context is a replay
+func isReplay(ctx context.Context) bool {
+	replay, _ := ctx.Value(replayKey{}).(bool)
+	return replay
+}
@@ -0,0 +1,382 @@
+package payment
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Sandbox outcomes for simulated callbacks and refunds
+const (
+	SandboxOutcomeSuccess   = "success"
+	SandboxOutcomeFailure   = "failure"
+	SandboxOutcomeDelay     = "delay"     // settles successfully after a delay
+	SandboxOutcomeDuplicate = "duplicate" // success notification delivered twice
+)
+
+// defaultSandboxDelay is used for delayed outcomes without an explicit delay
+const defaultSandboxDelay = 5 * time.Second
+
+// SandboxConfig represents the local sandbox provider configuration
+type SandboxConfig struct {
+	Secret string // HMAC key for simulated notifications; random when empty
+}
+
+// SandboxProvider is a local PaymentProvider for development and end-to-end
+// tests. It never leaves the process: prepay IDs are fake and notifications
+// are produced on demand with valid signatures.
+type SandboxProvider interface {
+	PaymentProvider
+	RefundQuerier
+
+	// Callback builds a signed notification reporting a payment's status
+	Callback(ctx context.Context, paymentNo string, status string) (body []byte, signature string, err error)
+
+	// SetRefundOutcome decides how the next refund of a payment settles
+	SetRefundOutcome(paymentNo string, outcome string, delay time.Duration) error
+}
+
+// sandboxRefund is a refund issued by the sandbox
+type sandboxRefund struct {
+	settleAt time.Time
+}
+
+// sandboxRefundOutcome is a pending decision for the next refund
+type sandboxRefundOutcome struct {
+	outcome string
+	delay   time.Duration
+}
+
+// sandbox implements SandboxProvider
+type sandbox struct {
+	secret      []byte
+	paymentRepo repository.OrderRepository
+	now         func() time.Time
+
+	mu             sync.Mutex
+	trades         map[string]string // payment no → transaction id
+	statuses       map[string]string // payment no → last simulated status
+	refundOutcomes map[string]sandboxRefundOutcome
+	refunds        map[string]sandboxRefund
+}
+
+// NewSandbox creates a new sandbox payment provider
+func NewSandbox(config SandboxConfig, paymentRepo repository.OrderRepository) SandboxProvider {
+	secret := []byte(config.Secret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		_, _ = rand.Read(secret)
+	}
+
+	return &sandbox{
+		secret:         secret,
+		paymentRepo:    paymentRepo,
+		now:            time.Now,
+		trades:         make(map[string]string),
+		statuses:       make(map[string]string),
+		refundOutcomes: make(map[string]sandboxRefundOutcome),
+		refunds:        make(map[string]sandboxRefund),
+	}
+}
+
+// CreatePayment creates a sandbox payment with a fake prepay ID
+func (s *sandbox) CreatePayment(ctx context.Context, order *domain.Order, description string) (*PaymentResult, error) {
+	paymentNo := generatePaymentNo()
+
+	payment := &domain.Payment{
+		OrderID:        order.ID.String(),
+		PaymentNo:      paymentNo,
+		PaymentMethod:  domain.PaymentMethodSandbox,
+		PaymentChannel: domain.PaymentMethodSandbox,
+		Amount:         amountFrom(ctx, order),
+		Currency:       currencyOrBase(order.Currency),
+		Status:         domain.PaymentStatusPending,
+	}
+	payment.ApplyExchangeRate(order.BaseCurrency, order.ExchangeRate)
+
+	if err := s.paymentRepo.CreatePayment(ctx, payment); err != nil {
+		return nil, fmt.Errorf("failed to create payment record: %w", err)
+	}
+
+	prepayID := "sandbox_prepay_" + uuid.New().String()
+	return &PaymentResult{
+		PaymentNo: paymentNo,
+		PrepayID:  prepayID,
+		PayURL:    "sandbox://pay/" + paymentNo,
+		Timestamp: fmt.Sprintf("%d", s.now().Unix()),
+		NonceStr:  generateNonceStr(),
+		Package:   "prepay_id=" + prepayID,
+		SignType:  "HMAC-SHA256",
+		ExpiresAt: s.now().Add(30 * time.Minute).Format(time.RFC3339),
+	}, nil
+}
+
+// TradeTypes reports no trade types: every sandbox payment is paid through
+// the simulator
+func (s *sandbox) TradeTypes() []string {
+	return nil
+}
+
+// QueryPayment reports the last simulated status of a payment
+func (s *sandbox) QueryPayment(ctx context.Context, paymentNo string) (*PaymentQueryResult, error) {
+	payment, err := s.paymentRepo.GetPaymentByNo(ctx, paymentNo)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPaymentNotFound, err)
+	}
+
+	s.mu.Lock()
+	status, simulated := s.statuses[paymentNo]
+	transactionID := s.trades[paymentNo]
+	s.mu.Unlock()
+	if !simulated {
+		status = payment.Status
+	}
+
+	return &PaymentQueryResult{
+		Status:       status,
+		Amount:       payment.Amount,
+		ThirdPartyID: transactionID,
+	}, nil
+}
+
+// sandboxNotification is the body of a simulated notification
+type sandboxNotification struct {
+	PaymentNo     string  `json:"payment_no"`
+	TransactionID string  `json:"transaction_id"`
+	Amount        float64 `json:"amount"`
+	Status        string  `json:"status"`
+	PaidAt        string  `json:"paid_at"`
+}
+
+// Callback builds a signed notification. The transaction ID is stable per
+// payment, so repeated success notifications are true duplicates.
+func (s *sandbox) Callback(ctx context.Context, paymentNo string, status string) ([]byte, string, error) {
+	payment, err := s.paymentRepo.GetPaymentByNo(ctx, paymentNo)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrPaymentNotFound, err)
+	}
+	if payment.PaymentMethod != domain.PaymentMethodSandbox {
+		return nil, "", fmt.Errorf("payment %s is not a sandbox payment", paymentNo)
+	}
+
+	s.mu.Lock()
+	transactionID, ok := s.trades[paymentNo]
+	if !ok {
+		transactionID = fmt.Sprintf("SANDBOX%s", uuid.New().String()[:20])
+		s.trades[paymentNo] = transactionID
+	}
+	s.statuses[paymentNo] = status
+	s.mu.Unlock()
+
+	body, err := json.Marshal(sandboxNotification{
+		PaymentNo:     paymentNo,
+		TransactionID: transactionID,
+		Amount:        payment.Amount,
+		Status:        status,
+		PaidAt:        s.now().Format(time.RFC3339),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return body, s.sign(body), nil
+}
+
+// ProcessCallback processes a simulated notification
+func (s *sandbox) ProcessCallback(ctx context.Context, body []byte, signature string) (*CallbackResult, error) {
+	if !s.VerifySignature(body, signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	var notification sandboxNotification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return nil, fmt.Errorf("failed to parse notification: %w", err)
+	}
+
+	return &CallbackResult{
+		PaymentNo:    notification.PaymentNo,
+		ThirdPartyID: notification.TransactionID,
+		Amount:       notification.Amount,
+		Status:       notification.Status,
+		PaidAt:       notification.PaidAt,
+	}, nil
+}
+
+// VerifySignature verifies the HMAC-SHA256 signature of a notification
+func (s *sandbox) VerifySignature(body []byte, signature string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(expected, s.mac(body))
+}
+
+// SetRefundOutcome decides how the next refund of a payment settles:
+// success settles at once, failure is rejected and delay stays PROCESSING
+// until the delay has passed
+func (s *sandbox) SetRefundOutcome(paymentNo string, outcome string, delay time.Duration) error {
+	switch outcome {
+	case SandboxOutcomeSuccess, SandboxOutcomeFailure:
+	case SandboxOutcomeDelay:
+		if delay <= 0 {
+			delay = defaultSandboxDelay
+		}
+	default:
+		return fmt.Errorf("unsupported refund outcome: %s", outcome)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refundOutcomes[paymentNo] = sandboxRefundOutcome{outcome: outcome, delay: delay}
+	return nil
+}
+
+// Refund settles a refund according to the outcome set for the payment,
+// succeeding immediately by default
+func (s *sandbox) Refund(ctx context.Context, payment *domain.Payment, amount float64, reason string) (*RefundResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	outcome := s.refundOutcomes[payment.PaymentNo]
+	delete(s.refundOutcomes, payment.PaymentNo)
+	if outcome.outcome == SandboxOutcomeFailure {
+		return nil, fmt.Errorf("%w: sandbox refund rejected", ErrPaymentFailed)
+	}
+
+	refundNo := generateRefundNo()
+	s.refunds[refundNo] = sandboxRefund{settleAt: s.now().Add(outcome.delay)}
+
+	status := "SUCCESS"
+	if outcome.outcome == SandboxOutcomeDelay {
+		status = "PROCESSING"
+	}
+
+	return &RefundResult{
+		RefundNo:     refundNo,
+		ThirdPartyID: "SANDBOX" + refundNo,
+		Status:       status,
+	}, nil
+}
+
+// QueryRefund reports a delayed refund as settled once its delay has passed
+func (s *sandbox) QueryRefund(ctx context.Context, payment *domain.Payment, refundNo string) (*RefundResult, error) {
+	s.mu.Lock()
+	refund, ok := s.refunds[refundNo]
+	s.mu.Unlock()
+
+	result := &RefundResult{RefundNo: refundNo, Status: "NOTFOUND"}
+	if !ok {
+		return result, nil
+	}
+
+	result.ThirdPartyID = "SANDBOX" + refundNo
+	result.Status = "PROCESSING"
+	if !s.now().Before(refund.settleAt) {
+		result.Status = "SUCCESS"
+	}
+	return result, nil
+}
+
+func (s *sandbox) sign(body []byte) string {
+	return hex.EncodeToString(s.mac(body))
+}
+
+func (s *sandbox) mac(body []byte) []byte {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+// SandboxSimulation reports the notifications delivered for a simulation
+type SandboxSimulation struct {
+	PaymentNo   string     `json:"payment_no"`
+	Outcome     string     `json:"outcome"`
+	Deliveries  []string   `json:"deliveries"` // "ok" or the processing error of each delivery
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+}
+
+// SandboxSimulator delivers simulated sandbox notifications through the
+// payment service, exactly as a gateway callback would arrive
+type SandboxSimulator struct {
+	service   PaymentService
+	sandbox   SandboxProvider
+	afterFunc func(time.Duration, func())
+}
+
+// NewSandboxSimulator creates a new sandbox simulator; the sandbox must be
+// registered with the service as "sandbox"
+func NewSandboxSimulator(service PaymentService, sandbox SandboxProvider) *SandboxSimulator {
+	return &SandboxSimulator{
+		service: service,
+		sandbox: sandbox,
+		afterFunc: func(d time.Duration, f func()) {
+			time.AfterFunc(d, f)
+		},
+	}
+}
+
+// Simulate delivers a success, failure, delayed or duplicated notification
+// for a sandbox payment
+func (s *SandboxSimulator) Simulate(ctx context.Context, paymentNo string, outcome string, delay time.Duration) (*SandboxSimulation, error) {
+	simulation := &SandboxSimulation{PaymentNo: paymentNo, Outcome: outcome, Deliveries: []string{}}
+
+	switch outcome {
+	case SandboxOutcomeSuccess:
+		err := s.deliver(ctx, paymentNo, domain.PaymentStatusSuccess, 1, simulation)
+		return simulation, err
+	case SandboxOutcomeFailure:
+		err := s.deliver(ctx, paymentNo, domain.PaymentStatusFailed, 1, simulation)
+		return simulation, err
+	case SandboxOutcomeDuplicate:
+		err := s.deliver(ctx, paymentNo, domain.PaymentStatusSuccess, 2, simulation)
+		return simulation, err
+	case SandboxOutcomeDelay:
+		if delay <= 0 {
+			delay = defaultSandboxDelay
+		}
+		// Queries report the payment in progress until the notification arrives
+		if _, _, err := s.sandbox.Callback(ctx, paymentNo, domain.PaymentStatusProcessing); err != nil {
+			return nil, err
+		}
+
+		scheduledAt := time.Now().Add(delay)
+		simulation.ScheduledAt = &scheduledAt
+		s.afterFunc(delay, func() {
+			result := &SandboxSimulation{PaymentNo: paymentNo, Outcome: outcome}
+			if err := s.deliver(context.Background(), paymentNo, domain.PaymentStatusSuccess, 1, result); err != nil {
+				log.Printf("[WARN] Delayed sandbox callback for %s failed: %v", paymentNo, err)
+			}
+		})
+		return simulation, nil
+	default:
+		return nil, fmt.Errorf("unsupported sandbox outcome: %s", outcome)
+	}
+}
+
+// deliver sends the same signed notification the given number of times
+func (s *SandboxSimulator) deliver(ctx context.Context, paymentNo, status string, times int, simulation *SandboxSimulation) error {
+	body, signature, err := s.sandbox.Callback(ctx, paymentNo, status)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < times; i++ {
+		if err := s.service.ProcessCallback(ctx, domain.PaymentMethodSandbox, body, signature); err != nil {
+			simulation.Deliveries = append(simulation.Deliveries, err.Error())
+			continue
+		}
+		simulation.Deliveries = append(simulation.Deliveries, "ok")
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package payment
+
+import (
+	"backend/internal/domain"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSandbox_SimulateCallbacks(t *testing.T) {
+	ctx := context.Background()
+	newFixture := func() (*MockPaymentOrderRepository, *domain.Payment, *SandboxSimulator) {
+		payment := &domain.Payment{
+			OrderID:       "order-1",
+			PaymentNo:     "PAY20260601sandbox",
+			PaymentMethod: domain.PaymentMethodSandbox,
+			Amount:        12000,
+			Currency:      "CNY",
+			Status:        domain.PaymentStatusPending,
+		}
+		repo := new(MockPaymentOrderRepository)
+		repo.On("GetPaymentByNo", ctx, payment.PaymentNo).Return(payment, nil)
+		repo.On("GetByIDForUpdate", mock.Anything, "order-1").Return(&domain.Order{Status: domain.OrderStatusPending, TotalAmount: 12000}, nil)
+		repo.On("GetPaymentByIDForUpdate", mock.Anything, payment.ID.String()).Return(storedPayment(payment), nil)
+		repo.On("AddOutboxEvent", mock.Anything, outboxEvent("payment.success")).Return(nil)
+
+		service := NewPaymentService(repo)
+		sandbox := NewSandbox(SandboxConfig{Secret: "test"}, repo)
+		service.RegisterProvider("sandbox", sandbox)
+		return repo, payment, NewSandboxSimulator(service, sandbox)
+	}
+
+	t.Run("duplicate success is applied once", func(t *testing.T) {
+		repo, payment, simulator := newFixture()
+		repo.On("UpdatePayment", ctx, payment).Return(nil).Once()
+		repo.On("UpdatePaymentStatus", ctx, "order-1", domain.PaymentStatusPaid, 12000.0).Return(nil).Once()
+
+		simulation, err := simulator.Simulate(ctx, payment.PaymentNo, SandboxOutcomeDuplicate, 0)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ok", "ok"}, simulation.Deliveries)
+		assert.Equal(t, domain.PaymentStatusSuccess, payment.Status)
+		assert.NotEmpty(t, payment.ThirdPartyTransactionID)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("failure leaves the order unpaid", func(t *testing.T) {
+		repo, payment, simulator := newFixture()
+		repo.On("UpdatePayment", ctx, payment).Return(nil).Once()
+
+		_, err := simulator.Simulate(ctx, payment.PaymentNo, SandboxOutcomeFailure, 0)
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.PaymentStatusFailed, payment.Status)
+		repo.AssertNotCalled(t, "UpdatePaymentStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("delay reports processing until the notification arrives", func(t *testing.T) {
+		repo, payment, simulator := newFixture()
+		repo.On("UpdatePayment", mock.Anything, payment).Return(nil).Once()
+		repo.On("UpdatePaymentStatus", mock.Anything, "order-1", domain.PaymentStatusPaid, 12000.0).Return(nil).Once()
+		var pending func()
+		simulator.afterFunc = func(d time.Duration, f func()) {
+			assert.Equal(t, 30*time.Second, d)
+			pending = f
+		}
+
+		simulation, err := simulator.Simulate(ctx, payment.PaymentNo, SandboxOutcomeDelay, 30*time.Second)
+		require.NoError(t, err)
+		assert.NotNil(t, simulation.ScheduledAt)
+		query, err := simulator.sandbox.QueryPayment(ctx, payment.PaymentNo)
+		require.NoError(t, err)
+		assert.Equal(t, domain.PaymentStatusProcessing, query.Status)
+
+		pending()
+		assert.Equal(t, domain.PaymentStatusSuccess, payment.Status)
+	})
+
+	t.Run("forged notifications are rejected", func(t *testing.T) {
+		_, payment, simulator := newFixture()
+		body, _, err := simulator.sandbox.Callback(ctx, payment.PaymentNo, domain.PaymentStatusSuccess)
+		require.NoError(t, err)
+
+		err = simulator.service.ProcessCallback(ctx, "sandbox", body, "00")
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+}
+
+func TestSandbox_Refund(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC)
+	provider := NewSandbox(SandboxConfig{}, new(MockPaymentOrderRepository)).(*sandbox)
+	provider.now = func() time.Time { return now }
+	payment := &domain.Payment{PaymentNo: "PAY20260601sandbox", Amount: 12000}
+
+	result, err := provider.Refund(ctx, payment, 500, "")
+	require.NoError(t, err)
+	assert.Equal(t, "SUCCESS", result.Status)
+
+	require.NoError(t, provider.SetRefundOutcome(payment.PaymentNo, SandboxOutcomeFailure, 0))
+	_, err = provider.Refund(ctx, payment, 500, "")
+	assert.ErrorIs(t, err, ErrPaymentFailed)
+
+	require.NoError(t, provider.SetRefundOutcome(payment.PaymentNo, SandboxOutcomeDelay, time.Minute))
+	result, err = provider.Refund(ctx, payment, 500, "")
+	require.NoError(t, err)
+	assert.Equal(t, "PROCESSING", result.Status)
+
+	query, err := provider.QueryRefund(ctx, payment, result.RefundNo)
+	require.NoError(t, err)
+	assert.Equal(t, "PROCESSING", query.Status)
+
+	now = now.Add(time.Minute)
+	query, err = provider.QueryRefund(ctx, payment, result.RefundNo)
+	require.NoError(t, err)
+	assert.Equal(t, "SUCCESS", query.Status)
+}
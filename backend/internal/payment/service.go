@@ -1,19 +1,34 @@
 package payment
 
 import (
+	"backend/internal/currency"
 	"backend/internal/domain"
 	"backend/internal/repository"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"math"
+	"slices"
 	"time"
 
-	"github.com/nats-io/nats.go"
 	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// Reasons recorded on refunds the payment service starts by itself
+const (
+	// lateRefundReason covers payments that arrived after their order was
+	// cancelled
+	lateRefundReason = "订单已取消，支付到账后自动退款"
+	// overpaidRefundReason covers payments that arrived after other payments
+	// had already settled the order
+	overpaidRefundReason = "订单已付清，多付款项自动退款"
+	// cancelledRefundReason covers payments made towards an order that was
+	// cancelled before it was paid in full
+	cancelledRefundReason = "订单未付清已取消，已付款项自动退款"
 )
 
 // PaymentService provides high-level payment operations
@@ -21,7 +36,9 @@ type PaymentService interface {
 	// RegisterProvider registers a payment provider implementation
 	RegisterProvider(name string, provider PaymentProvider)
 
-	// CreatePayment creates a payment for an order
+	// CreatePayment creates a payment for an order. An order may be paid with
+	// several payments; each covers the amount set with WithAmount, or the
+	// rest of the order
 	CreatePayment(ctx context.Context, orderID string, method string, description string) (*domain.Payment, error)
 
 	// ProcessCallback processes payment provider callback
@@ -30,23 +47,58 @@ type PaymentService interface {
 	// QueryPayment queries payment status
 	QueryPayment(ctx context.Context, paymentID string) (*domain.Payment, error)
 
-	// Refund processes a refund
-	Refund(ctx context.Context, paymentID string, amount float64, reason string) error
+	// Refund starts a refund and records the attempt. The returned record
+	// stays processing until the provider reports the outcome; a non-empty
+	// refundRequestID links it to the customer's refund request
+	Refund(ctx context.Context, paymentID string, amount float64, reason string, refundRequestID string) (*domain.PaymentRefund, error)
+
+	// ProcessRefundCallback processes a provider refund notification
+	ProcessRefundCallback(ctx context.Context, provider string, body []byte, signature string) (*domain.PaymentRefund, error)
+
+	// SyncRefund queries the provider for the outcome of a processing refund
+	SyncRefund(ctx context.Context, refundID string) (*domain.PaymentRefund, error)
+
+	// RefundOrder refunds an amount of an order, allocated across its
+	// successful payments in the order they were made. It returns the
+	// provider refunds started, which may be fewer than planned on error
+	RefundOrder(ctx context.Context, orderID string, amount float64, reason string, refundRequestID string) ([]*domain.PaymentRefund, error)
 
-	// GetPaymentByOrder gets payment by order ID
+	// RefundCancelledOrder refunds the payments of an order cancelled before
+	// it was paid in full
+	RefundCancelledOrder(ctx context.Context, orderID string) error
+
+	// GetPaymentByOrder gets the most recent payment of an order
 	GetPaymentByOrder(ctx context.Context, orderID string) (*domain.Payment, error)
+
+	// GetOrderPayments lists an order's payments with what remains to pay
+	GetOrderPayments(ctx context.Context, orderID string) (*OrderPayments, error)
+
+	// GetCheckout returns what the customer needs to complete a pending
+	// payment, such as its QR code or H5 URL
+	GetCheckout(ctx context.Context, paymentID string) (*PaymentResult, error)
+}
+
+// OrderPayments summarizes the payments made towards an order
+type OrderPayments struct {
+	OrderID           string            `json:"order_id"`
+	Currency          string            `json:"currency"`
+	PaymentStatus     string            `json:"payment_status"`
+	PayableAmount     float64           `json:"payable_amount"`
+	PaidAmount        float64           `json:"paid_amount"`
+	OutstandingAmount float64           `json:"outstanding_amount"`
+	Payments          []*domain.Payment `json:"payments"`
 }
 
 // paymentService implements PaymentService
 type paymentService struct {
 	orderRepo repository.OrderRepository
 	providers map[string]PaymentProvider
-	natsConn  *nats.Conn
 	redis     *redis.Client
 }
 
-// NewPaymentService creates a new payment service
-func NewPaymentService(orderRepo repository.OrderRepository, natsConn *nats.Conn, redisClients ...*redis.Client) PaymentService {
+// NewPaymentService creates a new payment service. Payment events are
+// written to the outbox and published by the outbox relay.
+func NewPaymentService(orderRepo repository.OrderRepository, redisClients ...*redis.Client) PaymentService {
 	var redisClient *redis.Client
 	if len(redisClients) > 0 {
 		redisClient = redisClients[0]
@@ -55,7 +107,6 @@ func NewPaymentService(orderRepo repository.OrderRepository, natsConn *nats.Conn
 	return &paymentService{
 		orderRepo: orderRepo,
 		providers: make(map[string]PaymentProvider),
-		natsConn:  natsConn,
 		redis:     redisClient,
 	}
 }
@@ -83,9 +134,27 @@ func (s *paymentService) CreatePayment(ctx context.Context, orderID string, meth
 	if !exists {
 		return nil, fmt.Errorf("unsupported payment method: %s", method)
 	}
+	if tradeType := channelFrom(ctx); tradeType != "" && !slices.Contains(provider.TradeTypes(), tradeType) {
+		return nil, fmt.Errorf("%w: %s does not offer %s", ErrUnsupportedTradeType, method, tradeType)
+	}
+
+	// Earlier payments of a split order leave less to pay
+	code := currencyOrBase(order.Currency)
+	outstanding := currency.ToMinor(order.PayableAmount(), code) - currency.ToMinor(order.PaidAmount, code)
+	if outstanding <= 0 {
+		return nil, ErrPaymentAlreadyPaid
+	}
+	amount := outstanding
+	if requested, ok := ctx.Value(amountKey{}).(float64); ok && requested > 0 {
+		amount = currency.ToMinor(requested, code)
+		if amount > outstanding {
+			return nil, fmt.Errorf("%w: %0.2f requested, %0.2f outstanding", ErrAmountExceedsBalance,
+				requested, currency.FromMinor(outstanding, code))
+		}
+	}
 
 	// Create payment with provider
-	result, err := provider.CreatePayment(ctx, order, description)
+	result, err := provider.CreatePayment(WithAmount(ctx, currency.FromMinor(amount, code)), order, description)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create payment: %w", err)
 	}
@@ -96,11 +165,21 @@ func (s *paymentService) CreatePayment(ctx context.Context, orderID string, meth
 		return nil, err
 	}
 
+	// Gift card and points payments are charged already and settle at once
+	if result.Status == domain.PaymentStatusSuccess {
+		now := time.Now().Format(time.RFC3339)
+		payment.Status = domain.PaymentStatusSuccess
+		payment.PaidAt = &now
+		if err := s.settleOrder(ctx, payment, payment.Amount); err != nil {
+			return nil, fmt.Errorf("failed to settle payment %s: %w", payment.PaymentNo, err)
+		}
+	}
+
 	return payment, nil
 }
 
 // ProcessCallback processes payment callback
-func (s *paymentService) ProcessCallback(ctx context.Context, provider string, body []byte, signature string) error {
+func (s *paymentService) ProcessCallback(ctx context.Context, provider string, body []byte, signature string) (err error) {
 	// Get provider
 	prov, exists := s.providers[provider]
 	if !exists {
@@ -112,6 +191,7 @@ func (s *paymentService) ProcessCallback(ctx context.Context, provider string, b
 	if err != nil {
 		return fmt.Errorf("failed to process callback: %w", err)
 	}
+	traceCallback(ctx, result.PaymentNo)
 
 	// Get payment
 	payment, err := s.orderRepo.GetPaymentByNo(ctx, result.PaymentNo)
@@ -120,7 +200,7 @@ func (s *paymentService) ProcessCallback(ctx context.Context, provider string, b
 	}
 
 	// SEC-004/FR-035: Strict amount verification before any state changes
-	if toCents(result.Amount) != toCents(payment.Amount) {
+	if currency.ToMinor(result.Amount, payment.Currency) != currency.ToMinor(payment.Amount, payment.Currency) {
 		return fmt.Errorf("payment amount mismatch: callback=%0.2f order=%0.2f", result.Amount, payment.Amount)
 	}
 
@@ -133,7 +213,8 @@ func (s *paymentService) ProcessCallback(ctx context.Context, provider string, b
 
 	if s.redis != nil {
 		idempotencyKey := buildIdempotencyValue(payment.OrderID, payment.PaymentMethod, result.PaidAt, result.ThirdPartyID)
-		redisKey := fmt.Sprintf("payment:idempotent:%s", payment.OrderID)
+		// Keyed by payment, as each payment of a split order settles once
+		redisKey := fmt.Sprintf("payment:idempotent:%s", payment.ID)
 
 		ok, redisErr := s.redis.SetNX(ctx, redisKey, idempotencyKey, 24*time.Hour).Result()
 		if redisErr != nil {
@@ -142,6 +223,13 @@ func (s *paymentService) ProcessCallback(ctx context.Context, provider string, b
 		if !ok {
 			return nil
 		}
+
+		// A callback that fails to apply can be delivered or replayed again
+		defer func() {
+			if err != nil {
+				s.redis.Del(context.WithoutCancel(ctx), redisKey)
+			}
+		}()
 	}
 
 	// Update payment status
@@ -149,18 +237,16 @@ func (s *paymentService) ProcessCallback(ctx context.Context, provider string, b
 	payment.ThirdPartyTransactionID = result.ThirdPartyID
 	payment.PaidAt = &result.PaidAt
 
-	if err := s.orderRepo.UpdatePayment(ctx, payment); err != nil {
-		return fmt.Errorf("failed to update payment: %w", err)
-	}
-
-	// If payment successful, update order
+	// If payment successful, it is saved together with the order
 	if result.Status == domain.PaymentStatusSuccess {
-		if err := s.orderRepo.UpdatePaymentStatus(ctx, payment.OrderID, domain.PaymentStatusPaid, result.Amount); err != nil {
+		if err := s.settleOrder(ctx, payment, result.Amount); err != nil {
 			return fmt.Errorf("failed to update order payment status: %w", err)
 		}
+		return nil
+	}
 
-		// Publish payment success event
-		s.publishPaymentEvent("payment.success", payment)
+	if err := s.orderRepo.UpdatePayment(ctx, payment); err != nil {
+		return fmt.Errorf("failed to update payment: %w", err)
 	}
 
 	return nil
@@ -194,14 +280,15 @@ func (s *paymentService) QueryPayment(ctx context.Context, paymentID string) (*d
 		if result.Status == domain.PaymentStatusSuccess {
 			now := time.Now().Format(time.RFC3339)
 			payment.PaidAt = &now
+		}
 
-			// Update order
-			if err := s.orderRepo.UpdatePaymentStatus(ctx, payment.OrderID, domain.PaymentStatusPaid, result.Amount); err != nil {
+		// Update order. The payment was read unlocked, so a callback may have
+		// settled it since; both paths recheck it under a row lock
+		if result.Status == domain.PaymentStatusSuccess {
+			if err := s.settleOrder(ctx, payment, result.Amount); err != nil {
 				return nil, err
 			}
-		}
-
-		if err := s.orderRepo.UpdatePayment(ctx, payment); err != nil {
+		} else if err := s.saveUnsettledPayment(ctx, payment); err != nil {
 			return nil, err
 		}
 	}
@@ -209,50 +296,578 @@ func (s *paymentService) QueryPayment(ctx context.Context, paymentID string) (*d
 	return payment, nil
 }
 
-// Refund processes a refund
-func (s *paymentService) Refund(ctx context.Context, paymentID string, amount float64, reason string) error {
+// Refund starts a refund with the payment's provider
+func (s *paymentService) Refund(ctx context.Context, paymentID string, amount float64, reason string, refundRequestID string) (*domain.PaymentRefund, error) {
 	// Get payment
 	payment, err := s.orderRepo.GetPaymentByID(ctx, paymentID)
 	if err != nil {
-		return fmt.Errorf("payment not found: %w", err)
+		return nil, fmt.Errorf("payment not found: %w", err)
 	}
 
 	// Verify payment is successful
 	if payment.Status != domain.PaymentStatusSuccess {
-		return fmt.Errorf("cannot refund payment in status %s", payment.Status)
+		return nil, fmt.Errorf("cannot refund payment in status %s", payment.Status)
 	}
 
 	// Get provider
 	provider, exists := s.providers[payment.PaymentMethod]
 	if !exists {
-		return fmt.Errorf("payment provider not found: %s", payment.PaymentMethod)
+		return nil, fmt.Errorf("payment provider not found: %s", payment.PaymentMethod)
 	}
 
-	// Process refund
-	result, err := provider.Refund(ctx, payment, amount, reason)
+	return s.startRefund(ctx, provider, payment, amount, reason, refundRequestID)
+}
+
+// ProcessRefundCallback processes a refund notification
+func (s *paymentService) ProcessRefundCallback(ctx context.Context, provider string, body []byte, signature string) (*domain.PaymentRefund, error) {
+	prov, exists := s.providers[provider]
+	if !exists {
+		return nil, fmt.Errorf("unsupported payment provider: %s", provider)
+	}
+	notifier, ok := prov.(RefundNotifier)
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not send refund notifications", provider)
+	}
+
+	result, err := notifier.ProcessRefundCallback(ctx, body, signature)
 	if err != nil {
-		return fmt.Errorf("refund failed: %w", err)
+		return nil, fmt.Errorf("failed to process refund callback: %w", err)
 	}
+	traceCallback(ctx, result.RefundNo)
 
-	// Update payment status
-	if result.Status == "SUCCESS" {
-		payment.Status = domain.PaymentStatusRefunded
-		if err := s.orderRepo.UpdatePayment(ctx, payment); err != nil {
+	refund, err := s.orderRepo.GetPaymentRefundByNo(ctx, result.RefundNo)
+	if err != nil {
+		return nil, fmt.Errorf("refund not found: %w", err)
+	}
+	if refund.Provider != provider {
+		return nil, fmt.Errorf("refund %s was not made with %s", refund.RefundNo, provider)
+	}
+
+	// Providers that report the refunded amount must agree with the request
+	if result.Amount > 0 && currency.ToMinor(result.Amount, refund.Currency) != currency.ToMinor(refund.Amount, refund.Currency) {
+		return nil, fmt.Errorf("refund amount mismatch: callback=%0.2f refund=%0.2f", result.Amount, refund.Amount)
+	}
+
+	payment, err := s.orderRepo.GetPaymentByID(ctx, refund.PaymentID)
+	if err != nil {
+		return nil, fmt.Errorf("payment not found: %w", err)
+	}
+
+	if err := s.applyRefundResult(ctx, payment, refund, result); err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// SyncRefund queries the provider for a refund whose notification has not
+// arrived
+func (s *paymentService) SyncRefund(ctx context.Context, refundID string) (*domain.PaymentRefund, error) {
+	refund, err := s.orderRepo.GetPaymentRefundByID(ctx, refundID)
+	if err != nil {
+		return nil, fmt.Errorf("refund not found: %w", err)
+	}
+	if refund.IsSettled() {
+		return refund, nil
+	}
+
+	payment, err := s.orderRepo.GetPaymentByID(ctx, refund.PaymentID)
+	if err != nil {
+		return nil, fmt.Errorf("payment not found: %w", err)
+	}
+
+	provider, exists := s.providers[refund.Provider]
+	if !exists {
+		return nil, fmt.Errorf("payment provider not found: %s", refund.Provider)
+	}
+	querier, ok := provider.(RefundQuerier)
+	if !ok {
+		return nil, fmt.Errorf("provider %s cannot query refunds", refund.Provider)
+	}
+
+	now := time.Now()
+	refund.QueryCount++
+	refund.LastQueriedAt = &now
+
+	result, err := querier.QueryRefund(ctx, payment, refund.RefundNo)
+	if err != nil {
+		if updateErr := s.orderRepo.UpdatePaymentRefund(ctx, refund); updateErr != nil {
+			log.Printf("[WARN] Failed to record query of refund %s: %v", refund.RefundNo, updateErr)
+		}
+		return nil, err
+	}
+
+	if err := s.applyRefundResult(ctx, payment, refund, result); err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// startRefund requests a refund from the provider and records the attempt.
+// The payment stays locked from checking what it has left to refund until the
+// refund is recorded, so concurrent refunds cannot together return more than
+// was paid
+func (s *paymentService) startRefund(ctx context.Context, provider PaymentProvider, payment *domain.Payment, amount float64, reason string, refundRequestID string) (*domain.PaymentRefund, error) {
+	var refund *domain.PaymentRefund
+	var result *RefundResult
+	err := s.orderRepo.WithTransaction(ctx, func(txRepo repository.OrderRepository, _ *gorm.DB) error {
+		current, available, err := lockRefundable(ctx, txRepo, payment.ID.String())
+		if err != nil {
 			return err
 		}
+		code := currencyOrBase(current.Currency)
+		if currency.ToMinor(amount, code) > available {
+			return fmt.Errorf("%w: %0.2f requested, %0.2f refundable", ErrRefundExceedsPaid, amount, currency.FromMinor(available, code))
+		}
+
+		result, err = provider.Refund(ctx, current, amount, reason)
+		if err != nil {
+			return fmt.Errorf("refund failed: %w", err)
+		}
+		refund = newPaymentRefund(current, result, amount, reason, refundRequestID)
+		if err := txRepo.CreatePaymentRefund(ctx, refund); err != nil {
+			return fmt.Errorf("failed to record refund %s: %w", result.RefundNo, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.applyRefundResult(ctx, payment, refund, result); err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// lockRefundable locks a payment and returns it with how much of it, in minor
+// units, is neither refunded nor being refunded. txRepo should be the
+// transaction that records the refund
+func lockRefundable(ctx context.Context, txRepo repository.OrderRepository, paymentID string) (*domain.Payment, int64, error) {
+	payment, err := txRepo.GetPaymentByIDForUpdate(ctx, paymentID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("payment not found: %w", err)
+	}
+	refunds, err := txRepo.ListPaymentRefundsByOrder(ctx, payment.OrderID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list refunds: %w", err)
+	}
+
+	code := currencyOrBase(payment.Currency)
+	available := currency.ToMinor(payment.RefundableAmount(), code) - currency.ToMinor(processingRefunds(refunds)[paymentID], code)
+	return payment, max(available, 0), nil
+}
+
+// processingRefunds sums the refunds still awaiting their outcome by payment
+func processingRefunds(refunds []*domain.PaymentRefund) map[string]float64 {
+	processing := make(map[string]float64)
+	for _, refund := range refunds {
+		if refund.Status == domain.PaymentRefundStatusProcessing {
+			processing[refund.PaymentID] += refund.Amount
+		}
+	}
+	return processing
+}
+
+// newPaymentRefund records a provider refund that has been started
+func newPaymentRefund(payment *domain.Payment, result *RefundResult, amount float64, reason string, refundRequestID string) *domain.PaymentRefund {
+	refund := &domain.PaymentRefund{
+		PaymentID:    payment.ID.String(),
+		RefundNo:     result.RefundNo,
+		ThirdPartyID: result.ThirdPartyID,
+		Provider:     payment.PaymentMethod,
+		Amount:       amount,
+		Currency:     payment.Currency,
+		Reason:       reason,
+		Status:       domain.PaymentRefundStatusProcessing,
+	}
+	if refundRequestID != "" {
+		refund.RefundRequestID = &refundRequestID
+	}
+	return refund
+}
+
+// refundLeg is the part of an order refund returned to one payment
+type refundLeg struct {
+	payment *domain.Payment
+	amount  float64
+}
+
+// RefundOrder refunds an amount of an order across its payments
+func (s *paymentService) RefundOrder(ctx context.Context, orderID string, amount float64, reason string, refundRequestID string) ([]*domain.PaymentRefund, error) {
+	payments, err := s.orderRepo.ListPaymentsByOrder(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payments: %w", err)
+	}
+	refunds, err := s.orderRepo.ListPaymentRefundsByOrder(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refunds: %w", err)
+	}
+
+	legs, err := allocateRefund(payments, refunds, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	var started []*domain.PaymentRefund
+	for _, leg := range legs {
+		provider, exists := s.providers[leg.payment.PaymentMethod]
+		if !exists {
+			return started, fmt.Errorf("payment provider not found: %s", leg.payment.PaymentMethod)
+		}
+
+		refund, err := s.startRefund(ctx, provider, leg.payment, leg.amount, reason, refundRequestID)
+		if err != nil {
+			return started, fmt.Errorf("payment %s: %w", leg.payment.PaymentNo, err)
+		}
+		started = append(started, refund)
+	}
+	return started, nil
+}
+
+// allocateRefund splits a refund across successful payments in the order
+// they were made, each giving back at most what has not been refunded or is
+// not being refunded already
+func allocateRefund(payments []*domain.Payment, refunds []*domain.PaymentRefund, amount float64) ([]refundLeg, error) {
+	processing := processingRefunds(refunds)
+
+	var legs []refundLeg
+	var code string
+	var remaining int64
+	for _, payment := range payments {
+		if !payment.IsSuccessful() {
+			continue
+		}
+		if code == "" {
+			code = currencyOrBase(payment.Currency)
+			remaining = currency.ToMinor(amount, code)
+		}
+		if remaining <= 0 {
+			break
+		}
+
+		available := currency.ToMinor(payment.RefundableAmount(), code) - currency.ToMinor(processing[payment.ID.String()], code)
+		if available <= 0 {
+			continue
+		}
+		part := min(available, remaining)
+		legs = append(legs, refundLeg{payment: payment, amount: currency.FromMinor(part, code)})
+		remaining -= part
+	}
 
-		// Update order status
-		if err := s.orderRepo.UpdateStatus(ctx, payment.OrderID, domain.OrderStatusRefunded); err != nil {
+	if code == "" || remaining > 0 {
+		return nil, fmt.Errorf("%w: %0.2f requested", ErrRefundExceedsPaid, amount)
+	}
+	return legs, nil
+}
+
+// RefundCancelledOrder refunds every payment still holding money for a
+// cancelled order, recording a refund request for each
+func (s *paymentService) RefundCancelledOrder(ctx context.Context, orderID string) error {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("order not found: %w", err)
+	}
+	if order.Status != domain.OrderStatusCancelled {
+		return fmt.Errorf("order %s is %s, not cancelled", order.ID, order.Status)
+	}
+
+	payments, err := s.orderRepo.ListPaymentsByOrder(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to list payments: %w", err)
+	}
+
+	var errs []error
+	for _, payment := range payments {
+		if payment.RefundableAmount() <= 0 {
+			continue
+		}
+		if err := s.refundLatePayment(ctx, order, payment, cancelledRefundReason); err != nil {
+			errs = append(errs, fmt.Errorf("payment %s: %w", payment.PaymentNo, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// applyRefundResult records the provider's view of a refund. The payment and
+// the refund are re-read under row locks, so a notification and a query that
+// report the same outcome concurrently count it once. Settled refunds are
+// final, so repeated notifications change nothing. payment and refund are
+// refreshed from the stored rows.
+func (s *paymentService) applyRefundResult(ctx context.Context, payment *domain.Payment, refund *domain.PaymentRefund, result *RefundResult) error {
+	if refund.IsSettled() {
+		return nil
+	}
+
+	var current *domain.Payment
+	var stored *domain.PaymentRefund
+	err := s.orderRepo.WithTransaction(ctx, func(txRepo repository.OrderRepository, _ *gorm.DB) error {
+		// The payment is locked before its refund, as when a refund starts
+		var err error
+		current, err = txRepo.GetPaymentByIDForUpdate(ctx, refund.PaymentID)
+		if err != nil {
+			return err
+		}
+		stored, err = txRepo.GetPaymentRefundByIDForUpdate(ctx, refund.ID.String())
+		if err != nil {
 			return err
 		}
+		if stored.IsSettled() {
+			return nil
+		}
+
+		// Keep the caller's record of provider queries
+		stored.QueryCount, stored.LastQueriedAt = refund.QueryCount, refund.LastQueriedAt
+		stored.ProviderStatus = result.Status
+		if result.ThirdPartyID != "" {
+			stored.ThirdPartyID = result.ThirdPartyID
+		}
+		if result.RawData != "" {
+			stored.NotifyData = result.RawData
+		}
+
+		switch result.Status {
+		case "SUCCESS":
+			now := time.Now()
+			stored.Status = domain.PaymentRefundStatusSuccess
+			stored.SucceededAt = &now
+		case "PROCESSING":
+		default:
+			// ABNORMAL, CLOSED or NOTFOUND: the money did not go back
+			stored.Status = domain.PaymentRefundStatusFailed
+			stored.ErrorMessage = fmt.Sprintf("provider reported refund status %s", result.Status)
+		}
+
+		if err := txRepo.UpdatePaymentRefund(ctx, stored); err != nil {
+			return fmt.Errorf("failed to update refund: %w", err)
+		}
+
+		switch stored.Status {
+		case domain.PaymentRefundStatusSuccess:
+			// A payment is refunded once all of it has gone back
+			current.RefundedAmount = currency.Round(current.RefundedAmount+stored.Amount, current.Currency)
+			if currency.ToMinor(current.RefundedAmount, current.Currency) >= currency.ToMinor(current.Amount, current.Currency) {
+				current.Status = domain.PaymentStatusRefunded
+			}
+			if err := txRepo.UpdatePayment(ctx, current); err != nil {
+				return err
+			}
 
-		// Release inventory
-		s.publishPaymentEvent("payment.refunded", payment)
+			// and the order once none of its payments holds money
+			payments, err := txRepo.ListPaymentsByOrder(ctx, current.OrderID)
+			if err != nil {
+				return err
+			}
+			if !slices.ContainsFunc(payments, (*domain.Payment).IsSuccessful) {
+				if err := txRepo.UpdateStatus(ctx, current.OrderID, domain.OrderStatusRefunded); err != nil {
+					return err
+				}
+			}
+
+			// Release inventory
+			return addPaymentEvent(ctx, txRepo, "payment.refunded", current)
+		case domain.PaymentRefundStatusFailed:
+			log.Printf("[WARN] Refund %s of payment %s failed: %s", stored.RefundNo, current.PaymentNo, result.Status)
+			return addPaymentEvent(ctx, txRepo, "payment.refund_failed", current)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	refreshPayment(payment, current)
+	*refund = *stored
+	return nil
+}
+
+// settleOrder saves a successful payment and adds it to its order's paid
+// amount, marking the order paid once its payments cover it. A payment the
+// order no longer accepts, typically for an order cancelled by the timeout
+// job while the payment was in flight or already settled by another payment,
+// is refunded instead. A payment settled meanwhile by a concurrent callback
+// or poll is left alone
+func (s *paymentService) settleOrder(ctx context.Context, payment *domain.Payment, amount float64) error {
+	var order *domain.Order
+	var refundReason string
+
+	// The order row is locked so concurrent payments of a split order add up,
+	// and the payment row so the same payment is only counted once
+	err := s.orderRepo.WithTransaction(ctx, func(txRepo repository.OrderRepository, _ *gorm.DB) error {
+		var err error
+		order, err = txRepo.GetByIDForUpdate(ctx, payment.OrderID)
+		if err != nil {
+			return err
+		}
+		current, err := txRepo.GetPaymentByIDForUpdate(ctx, payment.ID.String())
+		if err != nil {
+			return err
+		}
+		if current.IsSettled() {
+			refreshPayment(payment, current)
+			return nil
+		}
+		if err := txRepo.UpdatePayment(ctx, payment); err != nil {
+			return fmt.Errorf("failed to update payment: %w", err)
+		}
+
+		code := currencyOrBase(order.Currency)
+		paid := currency.ToMinor(order.PaidAmount, code) + currency.ToMinor(amount, code)
+		payable := currency.ToMinor(order.PayableAmount(), code)
+		switch {
+		case order.Status != domain.OrderStatusPending:
+			refundReason = lateRefundReason
+			return nil
+		case paid > payable:
+			refundReason = overpaidRefundReason
+			return nil
+		}
+
+		status, eventType := domain.PaymentStatusPartial, "payment.partial"
+		if paid == payable {
+			status, eventType = domain.PaymentStatusPaid, "payment.success"
+		}
+		if err := txRepo.UpdatePaymentStatus(ctx, payment.OrderID, status, currency.FromMinor(paid, code)); err != nil {
+			return err
+		}
+
+		// Record payment event
+		return addPaymentEvent(ctx, txRepo, eventType, payment)
+	})
+	if err != nil {
+		return err
 	}
 
+	if refundReason != "" {
+		return s.refundLatePayment(ctx, order, payment, refundReason)
+	}
 	return nil
 }
 
+// saveUnsettledPayment saves a payment's new in-flight or failed status
+// unless it was settled meanwhile, in which case payment is refreshed from
+// the stored row
+func (s *paymentService) saveUnsettledPayment(ctx context.Context, payment *domain.Payment) error {
+	return s.orderRepo.WithTransaction(ctx, func(txRepo repository.OrderRepository, _ *gorm.DB) error {
+		current, err := txRepo.GetPaymentByIDForUpdate(ctx, payment.ID.String())
+		if err != nil {
+			return err
+		}
+		if current.IsSettled() {
+			refreshPayment(payment, current)
+			return nil
+		}
+		return txRepo.UpdatePayment(ctx, payment)
+	})
+}
+
+// refreshPayment replaces payment's fields with the stored row's, keeping
+// its preloaded order
+func refreshPayment(payment, stored *domain.Payment) {
+	order := payment.Order
+	*payment = *stored
+	payment.Order = order
+}
+
+// refundLatePayment returns a payment that succeeded after its order stopped
+// accepting payment. A refund request is recorded either way; when the
+// provider refund cannot be started it stays pending for manual review. The
+// payment stays locked from sizing the refund until it is recorded, so a
+// payment refunded from two paths at once, such as a callback and the
+// timeout job, goes back once
+func (s *paymentService) refundLatePayment(ctx context.Context, order *domain.Order, payment *domain.Payment, reason string) error {
+	log.Printf("[WARN] Payment %s succeeded for %s order %s, refunding: %s", payment.PaymentNo, order.Status, order.ID, reason)
+
+	provider, exists := s.providers[payment.PaymentMethod]
+
+	var refund *domain.RefundRequest
+	var paymentRefund *domain.PaymentRefund
+	var result *RefundResult
+	var refundErr error
+	err := s.orderRepo.WithTransaction(ctx, func(txRepo repository.OrderRepository, _ *gorm.DB) error {
+		current, available, err := lockRefundable(ctx, txRepo, payment.ID.String())
+		if err != nil {
+			return err
+		}
+		refreshPayment(payment, current)
+		if available <= 0 {
+			return nil
+		}
+
+		// Record the request first so the provider refund can be linked to it
+		refund = &domain.RefundRequest{
+			OrderID:            payment.OrderID,
+			UserID:             order.UserID,
+			RefundAmount:       currency.FromMinor(available, currencyOrBase(current.Currency)),
+			RefundReason:       reason,
+			RefundType:         domain.RefundTypeFull,
+			RefundMethod:       domain.RefundMethodOriginal,
+			Status:             domain.RefundStatusPending,
+			RequestedAt:        time.Now(),
+			CancellationReason: domain.CancellationReasonOther,
+		}
+		if err := txRepo.CreateRefundRequest(ctx, refund); err != nil {
+			return err
+		}
+		if err := addPaymentEvent(ctx, txRepo, "payment.late", payment); err != nil {
+			return err
+		}
+
+		if !exists {
+			refundErr = fmt.Errorf("provider %s not found", payment.PaymentMethod)
+			return nil
+		}
+		result, err = provider.Refund(ctx, current, refund.RefundAmount, reason)
+		if err != nil {
+			refundErr = fmt.Errorf("refund failed: %w", err)
+			return nil
+		}
+		paymentRefund = newPaymentRefund(current, result, refund.RefundAmount, reason, refund.ID.String())
+		if err := txRepo.CreatePaymentRefund(ctx, paymentRefund); err != nil {
+			return fmt.Errorf("failed to record refund %s: %w", result.RefundNo, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record late payment refund: %w", err)
+	}
+	if refund == nil {
+		log.Printf("[WARN] Late payment %s has nothing left to refund", payment.PaymentNo)
+		return nil
+	}
+	if refundErr != nil {
+		return s.queueRefundReview(ctx, payment, refundErr.Error())
+	}
+
+	if err := s.applyRefundResult(ctx, payment, paymentRefund, result); err != nil {
+		return err
+	}
+
+	refund.MarkProcessing()
+	refund.PaymentRefundID = paymentRefund.RefundNo
+	refund.ThirdPartyRefundID = paymentRefund.ThirdPartyID
+	switch paymentRefund.Status {
+	case domain.PaymentRefundStatusSuccess:
+		refund.MarkCompleted(paymentRefund.RefundNo, paymentRefund.ThirdPartyID)
+	case domain.PaymentRefundStatusFailed:
+		refund.MarkFailed()
+	}
+	return s.orderRepo.UpdateRefundRequest(ctx, refund)
+}
+
+// queueRefundReview leaves a late payment's refund request pending so staff
+// can refund it by hand
+func (s *paymentService) queueRefundReview(ctx context.Context, payment *domain.Payment, reason string) error {
+	payment.ErrorMessage = "late payment needs manual refund: " + reason
+	log.Printf("[WARN] Late payment %s queued for manual refund review: %s", payment.PaymentNo, reason)
+
+	return s.orderRepo.WithTransaction(ctx, func(txRepo repository.OrderRepository, _ *gorm.DB) error {
+		if err := txRepo.UpdatePayment(ctx, payment); err != nil {
+			return err
+		}
+		return addPaymentEvent(ctx, txRepo, "payment.refund_review", payment)
+	})
+}
+
 // GetPaymentByOrder gets payment by order ID
 func (s *paymentService) GetPaymentByOrder(ctx context.Context, orderID string) (*domain.Payment, error) {
 	order, err := s.orderRepo.GetOrderWithDetails(ctx, orderID)
@@ -268,28 +883,48 @@ func (s *paymentService) GetPaymentByOrder(ctx context.Context, orderID string)
 	return &order.Payments[len(order.Payments)-1], nil
 }
 
-func (s *paymentService) publishPaymentEvent(eventType string, payment *domain.Payment) {
-	if s.natsConn == nil {
-		return
+// GetOrderPayments lists the payments of an order
+func (s *paymentService) GetOrderPayments(ctx context.Context, orderID string) (*OrderPayments, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	payments, err := s.orderRepo.ListPaymentsByOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	code := currencyOrBase(order.Currency)
+	outstanding := currency.ToMinor(order.PayableAmount(), code) - currency.ToMinor(order.PaidAmount, code)
+	if order.Status != domain.OrderStatusPending {
+		outstanding = 0
 	}
+	return &OrderPayments{
+		OrderID:           orderID,
+		Currency:          code,
+		PaymentStatus:     order.PaymentStatus,
+		PayableAmount:     order.PayableAmount(),
+		PaidAmount:        order.PaidAmount,
+		OutstandingAmount: currency.FromMinor(max(outstanding, 0), code),
+		Payments:          payments,
+	}, nil
+}
 
-	event := map[string]interface{}{
+// addPaymentEvent records a payment event in the outbox; repo should be the
+// transaction the payment change is written in
+func addPaymentEvent(ctx context.Context, repo repository.OrderRepository, eventType string, payment *domain.Payment) error {
+	event, err := domain.NewOutboxEvent(domain.AggregateOrder, payment.OrderID, eventType, map[string]interface{}{
 		"type":       eventType,
 		"payment_id": payment.ID,
 		"order_id":   payment.OrderID,
 		"amount":     payment.Amount,
 		"status":     payment.Status,
 		"timestamp":  time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
 	}
-
-	data, _ := json.Marshal(event)
-	if err := s.natsConn.Publish(eventType, data); err != nil {
-		log.Printf("[WARN] Failed to publish payment event %s: %v", eventType, err)
-	}
-}
-
-func toCents(amount float64) int64 {
-	return int64(math.Round(amount * 100))
+	return repo.AddOutboxEvent(ctx, event)
 }
 
 func buildIdempotencyValue(orderID, paymentMethod, paidAt, nonce string) string {
@@ -297,3 +932,22 @@ func buildIdempotencyValue(orderID, paymentMethod, paidAt, nonce string) string
 	hash := sha256.Sum256([]byte(payload))
 	return hex.EncodeToString(hash[:])
 }
+
+// GetCheckout returns the checkout recorded when the payment was created
+func (s *paymentService) GetCheckout(ctx context.Context, paymentID string) (*PaymentResult, error) {
+	payment, err := s.orderRepo.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPaymentNotFound, err)
+	}
+	if payment.Status == domain.PaymentStatusSuccess || payment.Status == domain.PaymentStatusRefunded {
+		return nil, ErrPaymentAlreadyPaid
+	}
+
+	result := &PaymentResult{PaymentNo: payment.PaymentNo, TradeType: payment.PaymentChannel}
+	if payment.ThirdPartyResponse != "" {
+		if err := json.Unmarshal([]byte(payment.ThirdPartyResponse), result); err != nil {
+			return nil, fmt.Errorf("failed to read checkout of %s: %w", payment.PaymentNo, err)
+		}
+	}
+	return result, nil
+}
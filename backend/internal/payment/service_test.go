@@ -7,11 +7,13 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/nats-io/nats.go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
 )
 
@@ -33,6 +35,14 @@ func (m *MockPaymentOrderRepository) GetByID(ctx context.Context, id string) (*d
 	return args.Get(0).(*domain.Order), args.Error(1)
 }
 
+func (m *MockPaymentOrderRepository) GetByIDForUpdate(ctx context.Context, id string) (*domain.Order, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Order), args.Error(1)
+}
+
 func (m *MockPaymentOrderRepository) GetByOrderNumber(ctx context.Context, orderNumber string) (*domain.Order, error) {
 	args := m.Called(ctx, orderNumber)
 	if args.Get(0) == nil {
@@ -51,6 +61,11 @@ func (m *MockPaymentOrderRepository) UpdateStatus(ctx context.Context, id string
 	return args.Error(0)
 }
 
+func (m *MockPaymentOrderRepository) UpdateStatusFrom(ctx context.Context, id string, from, status string) (bool, error) {
+	args := m.Called(ctx, id, from, status)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockPaymentOrderRepository) UpdatePaymentStatus(ctx context.Context, id string, paymentStatus string, paidAmount float64) error {
 	args := m.Called(ctx, id, paymentStatus, paidAmount)
 	return args.Error(0)
@@ -77,6 +92,14 @@ func (m *MockPaymentOrderRepository) GetPaymentByID(ctx context.Context, id stri
 	return args.Get(0).(*domain.Payment), args.Error(1)
 }
 
+func (m *MockPaymentOrderRepository) GetPaymentByIDForUpdate(ctx context.Context, id string) (*domain.Payment, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Payment), args.Error(1)
+}
+
 func (m *MockPaymentOrderRepository) GetPaymentByNo(ctx context.Context, paymentNo string) (*domain.Payment, error) {
 	args := m.Called(ctx, paymentNo)
 	if args.Get(0) == nil {
@@ -90,6 +113,22 @@ func (m *MockPaymentOrderRepository) UpdatePayment(ctx context.Context, payment
 	return args.Error(0)
 }
 
+func (m *MockPaymentOrderRepository) ListPaymentsByOrder(ctx context.Context, orderID string) ([]*domain.Payment, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Payment), args.Error(1)
+}
+
+func (m *MockPaymentOrderRepository) ListPendingPayments(ctx context.Context, from, to time.Time, limit int) ([]*domain.Payment, error) {
+	args := m.Called(ctx, from, to, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Payment), args.Error(1)
+}
+
 func (m *MockPaymentOrderRepository) List(ctx context.Context, filters repository.OrderFilters, paginator *pagination.Paginator) ([]*domain.Order, error) {
 	args := m.Called(ctx, filters, paginator)
 	if args.Get(0) == nil {
@@ -233,6 +272,98 @@ func (m *MockPaymentOrderRepository) UpdateRefundRequest(ctx context.Context, re
 	return args.Error(0)
 }
 
+func (m *MockPaymentOrderRepository) CreatePaymentRefund(ctx context.Context, refund *domain.PaymentRefund) error {
+	args := m.Called(ctx, refund)
+	return args.Error(0)
+}
+
+func (m *MockPaymentOrderRepository) GetPaymentRefundByID(ctx context.Context, id string) (*domain.PaymentRefund, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PaymentRefund), args.Error(1)
+}
+
+func (m *MockPaymentOrderRepository) GetPaymentRefundByIDForUpdate(ctx context.Context, id string) (*domain.PaymentRefund, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PaymentRefund), args.Error(1)
+}
+
+func (m *MockPaymentOrderRepository) GetPaymentRefundByNo(ctx context.Context, refundNo string) (*domain.PaymentRefund, error) {
+	args := m.Called(ctx, refundNo)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PaymentRefund), args.Error(1)
+}
+
+func (m *MockPaymentOrderRepository) UpdatePaymentRefund(ctx context.Context, refund *domain.PaymentRefund) error {
+	args := m.Called(ctx, refund)
+	return args.Error(0)
+}
+
+func (m *MockPaymentOrderRepository) ListPaymentRefundsByOrder(ctx context.Context, orderID string) ([]*domain.PaymentRefund, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.PaymentRefund), args.Error(1)
+}
+
+func (m *MockPaymentOrderRepository) ListProcessingPaymentRefunds(ctx context.Context, before time.Time, limit int) ([]*domain.PaymentRefund, error) {
+	args := m.Called(ctx, before, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.PaymentRefund), args.Error(1)
+}
+
+func (m *MockPaymentOrderRepository) AddOutboxEvent(ctx context.Context, event *domain.OutboxEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+// outboxEvent matches an outbox event recorded for subject
+func outboxEvent(subject string) interface{} {
+	return mock.MatchedBy(func(e *domain.OutboxEvent) bool {
+		return e.Subject == subject && e.AggregateType == domain.AggregateOrder
+	})
+}
+
+// storedPayment copies a payment as the locked row reads before it settles
+func storedPayment(payment *domain.Payment) *domain.Payment {
+	stored := *payment
+	return &stored
+}
+
+// settledPayment is the stored row of a payment once it has succeeded
+func settledPayment(payment *domain.Payment) *domain.Payment {
+	stored := storedPayment(payment)
+	stored.Status = domain.PaymentStatusSuccess
+	return stored
+}
+
+// expectPaymentRefund records the provider refund the service creates and
+// returns it to be read back under lock when its outcome is recorded
+func expectPaymentRefund(repo *MockPaymentOrderRepository) {
+	stored := &domain.PaymentRefund{}
+	repo.On("CreatePaymentRefund", mock.Anything, mock.AnythingOfType("*domain.PaymentRefund")).Run(func(args mock.Arguments) {
+		*stored = *args.Get(1).(*domain.PaymentRefund)
+	}).Return(nil).Once()
+	repo.On("GetPaymentRefundByIDForUpdate", mock.Anything, mock.Anything).Return(stored, nil).Once()
+}
+
+// paymentAmount matches a context asking the provider for the given amount
+func paymentAmount(amount float64) interface{} {
+	return mock.MatchedBy(func(ctx context.Context) bool {
+		return amountFrom(ctx, &domain.Order{}) == amount
+	})
+}
+
 func (m *MockPaymentOrderRepository) WithTransaction(ctx context.Context, fn func(repo repository.OrderRepository, tx *gorm.DB) error) error {
 	return fn(m, nil)
 }
@@ -287,6 +418,10 @@ func (m *MockPaymentProvider) Refund(ctx context.Context, payment *domain.Paymen
 	return args.Get(0).(*RefundResult), args.Error(1)
 }
 
+func (m *MockPaymentProvider) TradeTypes() []string {
+	return []string{WechatTradeJSAPI, WechatTradeNative, WechatTradeH5}
+}
+
 // Mock NATS Connection
 type MockNatsConn struct {
 	mock.Mock
@@ -305,7 +440,7 @@ func (m *MockNatsConn) Subscribe(subj string, cb nats.MsgHandler) (*nats.Subscri
 func TestPaymentService_CreatePayment(t *testing.T) {
 	mockOrderRepo := new(MockPaymentOrderRepository)
 	mockProvider := new(MockPaymentProvider)
-	service := NewPaymentService(mockOrderRepo, nil)
+	service := NewPaymentService(mockOrderRepo)
 	service.RegisterProvider("wechat", mockProvider)
 
 	ctx := context.Background()
@@ -329,7 +464,7 @@ func TestPaymentService_CreatePayment(t *testing.T) {
 		}
 
 		mockOrderRepo.On("GetByID", ctx, "order-1").Return(order, nil).Once()
-		mockProvider.On("CreatePayment", ctx, order, mock.AnythingOfType("string")).Return(paymentResult, nil).Once()
+		mockProvider.On("CreatePayment", paymentAmount(1000), order, mock.AnythingOfType("string")).Return(paymentResult, nil).Once()
 		mockOrderRepo.On("GetPaymentByNo", ctx, "PAY20240101123456").Return(payment, nil).Once()
 
 		result, err := service.CreatePayment(ctx, "order-1", "wechat", "订单支付")
@@ -369,12 +504,27 @@ func TestPaymentService_CreatePayment(t *testing.T) {
 		assert.Nil(t, result)
 		mockOrderRepo.AssertExpectations(t)
 	})
+
+	t.Run("should reject a trade type the provider does not offer", func(t *testing.T) {
+		order := &domain.Order{
+			BaseModel: domain.BaseModel{ID: uuid.New()},
+			Status:    domain.OrderStatusPending,
+		}
+
+		mockOrderRepo.On("GetByID", mock.Anything, "order-1").Return(order, nil).Once()
+
+		result, err := service.CreatePayment(WithChannel(ctx, AlipayChannelWAP), "order-1", "wechat", "订单支付")
+
+		assert.ErrorIs(t, err, ErrUnsupportedTradeType)
+		assert.Nil(t, result)
+		mockProvider.AssertNumberOfCalls(t, "CreatePayment", 1)
+	})
 }
 
 func TestPaymentService_ProcessCallback(t *testing.T) {
 	mockOrderRepo := new(MockPaymentOrderRepository)
 	mockProvider := new(MockPaymentProvider)
-	service := NewPaymentService(mockOrderRepo, nil)
+	service := NewPaymentService(mockOrderRepo)
 	service.RegisterProvider("wechat", mockProvider)
 
 	ctx := context.Background()
@@ -402,7 +552,10 @@ func TestPaymentService_ProcessCallback(t *testing.T) {
 		mockProvider.On("ProcessCallback", ctx, callbackBody, signature).Return(callbackResult, nil).Once()
 		mockOrderRepo.On("GetPaymentByNo", ctx, "PAY20240101123456").Return(payment, nil).Once()
 		mockOrderRepo.On("UpdatePayment", ctx, mock.AnythingOfType("*domain.Payment")).Return(nil).Once()
+		mockOrderRepo.On("GetByIDForUpdate", ctx, "order-1").Return(&domain.Order{Status: domain.OrderStatusPending, TotalAmount: 1000}, nil).Once()
+		mockOrderRepo.On("GetPaymentByIDForUpdate", ctx, payment.ID.String()).Return(storedPayment(payment), nil).Once()
 		mockOrderRepo.On("UpdatePaymentStatus", ctx, "order-1", domain.PaymentStatusPaid, float64(1000)).Return(nil).Once()
+		mockOrderRepo.On("AddOutboxEvent", ctx, outboxEvent("payment.success")).Return(nil).Once()
 
 		err := service.ProcessCallback(ctx, "wechat", callbackBody, signature)
 
@@ -436,7 +589,7 @@ func TestPaymentService_ProcessCallback(t *testing.T) {
 func TestPaymentService_QueryPayment(t *testing.T) {
 	mockOrderRepo := new(MockPaymentOrderRepository)
 	mockProvider := new(MockPaymentProvider)
-	service := NewPaymentService(mockOrderRepo, nil)
+	service := NewPaymentService(mockOrderRepo)
 	service.RegisterProvider("wechat", mockProvider)
 
 	ctx := context.Background()
@@ -459,8 +612,11 @@ func TestPaymentService_QueryPayment(t *testing.T) {
 
 		mockOrderRepo.On("GetPaymentByID", ctx, "payment-1").Return(payment, nil).Once()
 		mockProvider.On("QueryPayment", ctx, "PAY20240101123456").Return(queryResult, nil).Once()
+		mockOrderRepo.On("GetByIDForUpdate", ctx, payment.OrderID).Return(&domain.Order{Status: domain.OrderStatusPending, TotalAmount: 1000}, nil).Once()
+		mockOrderRepo.On("GetPaymentByIDForUpdate", ctx, payment.ID.String()).Return(storedPayment(payment), nil).Once()
 		mockOrderRepo.On("UpdatePaymentStatus", ctx, payment.OrderID, domain.PaymentStatusPaid, float64(1000)).Return(nil).Once()
 		mockOrderRepo.On("UpdatePayment", ctx, mock.AnythingOfType("*domain.Payment")).Return(nil).Once()
+		mockOrderRepo.On("AddOutboxEvent", ctx, outboxEvent("payment.success")).Return(nil).Once()
 
 		result, err := service.QueryPayment(ctx, "payment-1")
 
@@ -481,10 +637,168 @@ func TestPaymentService_QueryPayment(t *testing.T) {
 	})
 }
 
+func TestPaymentService_LatePayment(t *testing.T) {
+	ctx := context.Background()
+	cancelled := &domain.Order{BaseModel: domain.BaseModel{ID: uuid.New()}, Status: domain.OrderStatusCancelled}
+	queryResult := &PaymentQueryResult{Status: domain.PaymentStatusSuccess, Amount: 1000, ThirdPartyID: "WXP20240101123456"}
+	newPayment := func() *domain.Payment {
+		return &domain.Payment{
+			BaseModel:     domain.BaseModel{ID: uuid.New()},
+			OrderID:       "order-1",
+			PaymentNo:     "PAY20240101123456",
+			PaymentMethod: "wechat",
+			Status:        domain.PaymentStatusPending,
+			Amount:        1000,
+			Currency:      "CNY",
+		}
+	}
+
+	t.Run("refunds a payment that succeeds after the order was cancelled", func(t *testing.T) {
+		mockOrderRepo := new(MockPaymentOrderRepository)
+		mockProvider := new(MockPaymentProvider)
+		service := NewPaymentService(mockOrderRepo)
+		service.RegisterProvider("wechat", mockProvider)
+		payment := newPayment()
+
+		var refund *domain.RefundRequest
+		mockOrderRepo.On("GetPaymentByID", ctx, "payment-1").Return(payment, nil).Once()
+		mockProvider.On("QueryPayment", ctx, payment.PaymentNo).Return(queryResult, nil).Once()
+		mockOrderRepo.On("GetByIDForUpdate", ctx, "order-1").Return(cancelled, nil).Once()
+		mockOrderRepo.On("GetPaymentByIDForUpdate", ctx, payment.ID.String()).Return(storedPayment(payment), nil).Once()
+		mockOrderRepo.On("GetPaymentByIDForUpdate", ctx, payment.ID.String()).Return(settledPayment(payment), nil).Twice()
+		mockOrderRepo.On("ListPaymentRefundsByOrder", ctx, "order-1").Return([]*domain.PaymentRefund{}, nil).Once()
+		mockProvider.On("Refund", ctx, payment, float64(1000), lateRefundReason).
+			Return(&RefundResult{RefundNo: "REF1", ThirdPartyID: "WXR1", Status: "SUCCESS"}, nil).Once()
+		expectPaymentRefund(mockOrderRepo)
+		mockOrderRepo.On("CreateRefundRequest", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			refund = args.Get(1).(*domain.RefundRequest)
+		}).Return(nil).Once()
+		mockOrderRepo.On("UpdatePaymentRefund", ctx, mock.AnythingOfType("*domain.PaymentRefund")).Return(nil).Once()
+		mockOrderRepo.On("UpdatePayment", ctx, mock.AnythingOfType("*domain.Payment")).Return(nil).Twice()
+		mockOrderRepo.On("ListPaymentsByOrder", ctx, "order-1").Return([]*domain.Payment{}, nil).Once()
+		mockOrderRepo.On("UpdateStatus", ctx, "order-1", domain.OrderStatusRefunded).Return(nil).Once()
+		mockOrderRepo.On("UpdateRefundRequest", ctx, mock.Anything).Return(nil).Once()
+		mockOrderRepo.On("AddOutboxEvent", ctx, outboxEvent("payment.late")).Return(nil).Once()
+		mockOrderRepo.On("AddOutboxEvent", ctx, outboxEvent("payment.refunded")).Return(nil).Once()
+
+		result, err := service.QueryPayment(ctx, "payment-1")
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.PaymentStatusRefunded, result.Status)
+		require.NotNil(t, refund)
+		assert.Equal(t, domain.RefundStatusCompleted, refund.Status)
+		assert.Equal(t, 1000.0, refund.RefundAmount)
+		assert.Equal(t, "REF1", refund.PaymentRefundID)
+		mockOrderRepo.AssertNotCalled(t, "UpdatePaymentStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	t.Run("queues a manual review when the refund cannot be started", func(t *testing.T) {
+		mockOrderRepo := new(MockPaymentOrderRepository)
+		mockProvider := new(MockPaymentProvider)
+		service := NewPaymentService(mockOrderRepo)
+		service.RegisterProvider("wechat", mockProvider)
+		payment := newPayment()
+
+		var refund *domain.RefundRequest
+		mockOrderRepo.On("GetPaymentByID", ctx, "payment-1").Return(payment, nil).Once()
+		mockProvider.On("QueryPayment", ctx, payment.PaymentNo).Return(queryResult, nil).Once()
+		mockOrderRepo.On("GetByIDForUpdate", ctx, "order-1").Return(cancelled, nil).Once()
+		mockOrderRepo.On("GetPaymentByIDForUpdate", ctx, payment.ID.String()).Return(storedPayment(payment), nil).Once()
+		mockOrderRepo.On("GetPaymentByIDForUpdate", ctx, payment.ID.String()).Return(settledPayment(payment), nil).Once()
+		mockOrderRepo.On("ListPaymentRefundsByOrder", ctx, "order-1").Return([]*domain.PaymentRefund{}, nil).Once()
+		mockProvider.On("Refund", ctx, payment, float64(1000), lateRefundReason).Return(nil, errors.New("gateway timeout")).Once()
+		mockOrderRepo.On("CreateRefundRequest", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			refund = args.Get(1).(*domain.RefundRequest)
+		}).Return(nil).Once()
+		mockOrderRepo.On("UpdatePayment", ctx, payment).Return(nil).Twice()
+		mockOrderRepo.On("AddOutboxEvent", ctx, outboxEvent("payment.late")).Return(nil).Once()
+		mockOrderRepo.On("AddOutboxEvent", ctx, outboxEvent("payment.refund_review")).Return(nil).Once()
+
+		result, err := service.QueryPayment(ctx, "payment-1")
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.PaymentStatusSuccess, result.Status)
+		assert.Contains(t, result.ErrorMessage, "gateway timeout")
+		require.NotNil(t, refund)
+		assert.Equal(t, domain.RefundStatusPending, refund.Status)
+		mockOrderRepo.AssertNotCalled(t, "UpdatePaymentStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("a late payment already being refunded is not refunded again", func(t *testing.T) {
+		mockOrderRepo := new(MockPaymentOrderRepository)
+		mockProvider := new(MockPaymentProvider)
+		service := NewPaymentService(mockOrderRepo)
+		service.RegisterProvider("wechat", mockProvider)
+		payment := newPayment()
+		processing := &domain.PaymentRefund{PaymentID: payment.ID.String(), Amount: 1000, Status: domain.PaymentRefundStatusProcessing}
+
+		mockOrderRepo.On("GetPaymentByID", ctx, "payment-1").Return(payment, nil).Once()
+		mockProvider.On("QueryPayment", ctx, payment.PaymentNo).Return(queryResult, nil).Once()
+		mockOrderRepo.On("GetByIDForUpdate", ctx, "order-1").Return(cancelled, nil).Once()
+		mockOrderRepo.On("GetPaymentByIDForUpdate", ctx, payment.ID.String()).Return(storedPayment(payment), nil).Once()
+		mockOrderRepo.On("GetPaymentByIDForUpdate", ctx, payment.ID.String()).Return(settledPayment(payment), nil).Once()
+		mockOrderRepo.On("ListPaymentRefundsByOrder", ctx, "order-1").Return([]*domain.PaymentRefund{processing}, nil).Once()
+		mockOrderRepo.On("UpdatePayment", ctx, mock.AnythingOfType("*domain.Payment")).Return(nil).Once()
+
+		result, err := service.QueryPayment(ctx, "payment-1")
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.PaymentStatusSuccess, result.Status)
+		mockProvider.AssertNotCalled(t, "Refund", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		mockOrderRepo.AssertNotCalled(t, "CreateRefundRequest", mock.Anything, mock.Anything)
+	})
+
+	t.Run("a poll that loses the race to the callback leaves the payment alone", func(t *testing.T) {
+		mockOrderRepo := new(MockPaymentOrderRepository)
+		mockProvider := new(MockPaymentProvider)
+		service := NewPaymentService(mockOrderRepo)
+		service.RegisterProvider("wechat", mockProvider)
+		payment := newPayment()
+		settled := storedPayment(payment)
+		settled.Status = domain.PaymentStatusSuccess
+		paid := &domain.Order{BaseModel: domain.BaseModel{ID: uuid.New()}, Status: domain.OrderStatusPaid, TotalAmount: 1000, PaidAmount: 1000}
+
+		mockOrderRepo.On("GetPaymentByID", ctx, "payment-1").Return(payment, nil).Once()
+		mockProvider.On("QueryPayment", ctx, payment.PaymentNo).Return(queryResult, nil).Once()
+		mockOrderRepo.On("GetByIDForUpdate", ctx, "order-1").Return(paid, nil).Once()
+		mockOrderRepo.On("GetPaymentByIDForUpdate", ctx, payment.ID.String()).Return(settled, nil).Once()
+
+		result, err := service.QueryPayment(ctx, "payment-1")
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.PaymentStatusSuccess, result.Status)
+		mockOrderRepo.AssertExpectations(t)
+		mockOrderRepo.AssertNotCalled(t, "UpdatePayment", mock.Anything, mock.Anything)
+		mockOrderRepo.AssertNotCalled(t, "CreateRefundRequest", mock.Anything, mock.Anything)
+		mockProvider.AssertNotCalled(t, "Refund", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("a poll does not overwrite a payment settled meanwhile", func(t *testing.T) {
+		mockOrderRepo := new(MockPaymentOrderRepository)
+		mockProvider := new(MockPaymentProvider)
+		service := NewPaymentService(mockOrderRepo)
+		service.RegisterProvider("wechat", mockProvider)
+		payment := newPayment()
+		settled := storedPayment(payment)
+		settled.Status = domain.PaymentStatusSuccess
+
+		mockOrderRepo.On("GetPaymentByID", ctx, "payment-1").Return(payment, nil).Once()
+		mockProvider.On("QueryPayment", ctx, payment.PaymentNo).Return(&PaymentQueryResult{Status: domain.PaymentStatusCancelled}, nil).Once()
+		mockOrderRepo.On("GetPaymentByIDForUpdate", ctx, payment.ID.String()).Return(settled, nil).Once()
+
+		result, err := service.QueryPayment(ctx, "payment-1")
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.PaymentStatusSuccess, result.Status)
+		mockOrderRepo.AssertNotCalled(t, "UpdatePayment", mock.Anything, mock.Anything)
+	})
+}
+
 func TestPaymentService_Refund(t *testing.T) {
 	mockOrderRepo := new(MockPaymentOrderRepository)
 	mockProvider := new(MockPaymentProvider)
-	service := NewPaymentService(mockOrderRepo, nil)
+	service := NewPaymentService(mockOrderRepo)
 	service.RegisterProvider("wechat", mockProvider)
 
 	ctx := context.Background()
@@ -507,17 +821,93 @@ func TestPaymentService_Refund(t *testing.T) {
 		}
 
 		mockOrderRepo.On("GetPaymentByID", ctx, "payment-1").Return(payment, nil).Once()
-		mockProvider.On("Refund", ctx, payment, float64(500), "Customer request").Return(refundResult, nil).Once()
+		mockOrderRepo.On("GetPaymentByIDForUpdate", ctx, payment.ID.String()).Return(storedPayment(payment), nil).Twice()
+		mockOrderRepo.On("ListPaymentRefundsByOrder", ctx, "order-1").Return([]*domain.PaymentRefund{}, nil).Once()
+		mockProvider.On("Refund", ctx, payment, float64(1000), "Customer request").Return(refundResult, nil).Once()
+		expectPaymentRefund(mockOrderRepo)
+		mockOrderRepo.On("UpdatePaymentRefund", ctx, mock.AnythingOfType("*domain.PaymentRefund")).Return(nil).Once()
 		mockOrderRepo.On("UpdatePayment", ctx, mock.AnythingOfType("*domain.Payment")).Return(nil).Once()
+		mockOrderRepo.On("ListPaymentsByOrder", ctx, "order-1").Return([]*domain.Payment{}, nil).Once()
 		mockOrderRepo.On("UpdateStatus", ctx, "order-1", domain.OrderStatusRefunded).Return(nil).Once()
+		mockOrderRepo.On("AddOutboxEvent", ctx, outboxEvent("payment.refunded")).Return(nil).Once()
 
-		err := service.Refund(ctx, "payment-1", 500, "Customer request")
+		refund, err := service.Refund(ctx, "payment-1", 1000, "Customer request", "refund-1")
 
 		assert.NoError(t, err)
+		assert.Equal(t, domain.PaymentRefundStatusSuccess, refund.Status)
+		assert.Equal(t, "refund-1", *refund.RefundRequestID)
+		assert.Equal(t, domain.PaymentStatusRefunded, payment.Status)
 		mockOrderRepo.AssertExpectations(t)
 		mockProvider.AssertExpectations(t)
 	})
 
+	t.Run("partial refund keeps the payment and its order", func(t *testing.T) {
+		mockOrderRepo := new(MockPaymentOrderRepository)
+		mockProvider := new(MockPaymentProvider)
+		service := NewPaymentService(mockOrderRepo)
+		service.RegisterProvider("wechat", mockProvider)
+
+		payment := &domain.Payment{
+			BaseModel:     domain.BaseModel{ID: uuid.New()},
+			OrderID:       "order-1",
+			PaymentNo:     "PAY20240101123456",
+			PaymentMethod: "wechat",
+			Status:        domain.PaymentStatusSuccess,
+			Amount:        1000,
+			Currency:      "CNY",
+		}
+
+		mockOrderRepo.On("GetPaymentByID", ctx, "payment-1").Return(payment, nil)
+		mockOrderRepo.On("GetPaymentByIDForUpdate", ctx, payment.ID.String()).Return(storedPayment(payment), nil).Twice()
+		mockOrderRepo.On("ListPaymentRefundsByOrder", ctx, "order-1").Return([]*domain.PaymentRefund{}, nil)
+		mockProvider.On("Refund", ctx, payment, float64(500), "Customer request").
+			Return(&RefundResult{RefundNo: "REF1", Status: "SUCCESS"}, nil).Once()
+		expectPaymentRefund(mockOrderRepo)
+		mockOrderRepo.On("UpdatePaymentRefund", ctx, mock.AnythingOfType("*domain.PaymentRefund")).Return(nil).Once()
+		mockOrderRepo.On("UpdatePayment", ctx, mock.AnythingOfType("*domain.Payment")).Return(nil).Once()
+		mockOrderRepo.On("ListPaymentsByOrder", ctx, "order-1").Return([]*domain.Payment{payment}, nil).Once()
+		mockOrderRepo.On("AddOutboxEvent", ctx, outboxEvent("payment.refunded")).Return(nil).Once()
+
+		_, err := service.Refund(ctx, "payment-1", 500, "Customer request", "")
+		require.NoError(t, err)
+		assert.Equal(t, domain.PaymentStatusSuccess, payment.Status)
+		assert.Equal(t, 500.0, payment.RefundedAmount)
+		mockOrderRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+
+		mockOrderRepo.On("GetPaymentByIDForUpdate", ctx, payment.ID.String()).Return(storedPayment(payment), nil).Once()
+		_, err = service.Refund(ctx, "payment-1", 600, "Customer request", "")
+		assert.ErrorIs(t, err, ErrRefundExceedsPaid)
+		mockProvider.AssertExpectations(t)
+	})
+
+	t.Run("refunds still in progress are not refunded again", func(t *testing.T) {
+		mockOrderRepo := new(MockPaymentOrderRepository)
+		mockProvider := new(MockPaymentProvider)
+		service := NewPaymentService(mockOrderRepo)
+		service.RegisterProvider("wechat", mockProvider)
+
+		payment := &domain.Payment{
+			BaseModel:     domain.BaseModel{ID: uuid.New()},
+			OrderID:       "order-1",
+			PaymentNo:     "PAY20240101123456",
+			PaymentMethod: "wechat",
+			Status:        domain.PaymentStatusSuccess,
+			Amount:        1000,
+			Currency:      "CNY",
+		}
+		processing := &domain.PaymentRefund{PaymentID: payment.ID.String(), Amount: 800, Status: domain.PaymentRefundStatusProcessing}
+
+		mockOrderRepo.On("GetPaymentByID", ctx, "payment-1").Return(payment, nil).Once()
+		mockOrderRepo.On("GetPaymentByIDForUpdate", ctx, payment.ID.String()).Return(storedPayment(payment), nil).Once()
+		mockOrderRepo.On("ListPaymentRefundsByOrder", ctx, "order-1").Return([]*domain.PaymentRefund{processing}, nil).Once()
+
+		_, err := service.Refund(ctx, "payment-1", 500, "Customer request", "")
+
+		assert.ErrorIs(t, err, ErrRefundExceedsPaid)
+		mockProvider.AssertNotCalled(t, "Refund", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		mockOrderRepo.AssertNotCalled(t, "CreatePaymentRefund", mock.Anything, mock.Anything)
+	})
+
 	t.Run("should return error for non-successful payment", func(t *testing.T) {
 		payment := &domain.Payment{
 			BaseModel: domain.BaseModel{ID: uuid.New()},
@@ -526,16 +916,104 @@ func TestPaymentService_Refund(t *testing.T) {
 
 		mockOrderRepo.On("GetPaymentByID", ctx, "payment-1").Return(payment, nil).Once()
 
-		err := service.Refund(ctx, "payment-1", 500, "Customer request")
+		_, err := service.Refund(ctx, "payment-1", 500, "Customer request", "")
 
 		assert.Error(t, err)
 		mockOrderRepo.AssertExpectations(t)
 	})
 }
 
+// mockRefundNotifier is a provider that sends refund notifications
+type mockRefundNotifier struct {
+	*MockPaymentProvider
+}
+
+func (m mockRefundNotifier) ProcessRefundCallback(ctx context.Context, body []byte, signature string) (*RefundResult, error) {
+	args := m.Called(ctx, body, signature)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*RefundResult), args.Error(1)
+}
+
+func TestPaymentService_ProcessRefundCallback(t *testing.T) {
+	ctx := context.Background()
+	newFixture := func() (*MockPaymentOrderRepository, *MockPaymentProvider, PaymentService, *domain.PaymentRefund) {
+		mockOrderRepo := new(MockPaymentOrderRepository)
+		mockProvider := new(MockPaymentProvider)
+		service := NewPaymentService(mockOrderRepo)
+		service.RegisterProvider("wechat", mockRefundNotifier{mockProvider})
+
+		refund := &domain.PaymentRefund{
+			BaseModel: domain.BaseModel{ID: uuid.New()},
+			PaymentID: "payment-1",
+			RefundNo:  "REF1",
+			Provider:  "wechat",
+			Amount:    500,
+			Currency:  "CNY",
+			Status:    domain.PaymentRefundStatusProcessing,
+		}
+		payment := &domain.Payment{OrderID: "order-1", PaymentNo: "PAY1", Status: domain.PaymentStatusSuccess, Amount: 1000, Currency: "CNY"}
+		mockOrderRepo.On("GetPaymentRefundByNo", ctx, "REF1").Return(refund, nil)
+		mockOrderRepo.On("GetPaymentByID", ctx, "payment-1").Return(payment, nil)
+		mockOrderRepo.On("GetPaymentByIDForUpdate", ctx, "payment-1").Return(storedPayment(payment), nil)
+		return mockOrderRepo, mockProvider, service, refund
+	}
+
+	t.Run("abnormal refund is marked failed and later notifications are ignored", func(t *testing.T) {
+		mockOrderRepo, mockProvider, service, refund := newFixture()
+		mockProvider.On("ProcessRefundCallback", ctx, []byte("abnormal"), "meta").
+			Return(&RefundResult{RefundNo: "REF1", ThirdPartyID: "WXR1", Status: "ABNORMAL", Amount: 500}, nil).Once()
+		mockProvider.On("ProcessRefundCallback", ctx, []byte("success"), "meta").
+			Return(&RefundResult{RefundNo: "REF1", ThirdPartyID: "WXR1", Status: "SUCCESS", Amount: 500}, nil).Once()
+		stored := *refund
+		mockOrderRepo.On("GetPaymentRefundByIDForUpdate", ctx, refund.ID.String()).Return(&stored, nil).Once()
+		mockOrderRepo.On("UpdatePaymentRefund", ctx, mock.AnythingOfType("*domain.PaymentRefund")).Return(nil).Once()
+		mockOrderRepo.On("AddOutboxEvent", ctx, outboxEvent("payment.refund_failed")).Return(nil).Once()
+
+		result, err := service.ProcessRefundCallback(ctx, "wechat", []byte("abnormal"), "meta")
+		require.NoError(t, err)
+		assert.Equal(t, domain.PaymentRefundStatusFailed, result.Status)
+		assert.Equal(t, "ABNORMAL", result.ProviderStatus)
+
+		result, err = service.ProcessRefundCallback(ctx, "wechat", []byte("success"), "meta")
+		require.NoError(t, err)
+		assert.Equal(t, domain.PaymentRefundStatusFailed, result.Status)
+		mockOrderRepo.AssertNotCalled(t, "UpdatePayment", mock.Anything, mock.Anything)
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	t.Run("a success already recorded by a concurrent query is counted once", func(t *testing.T) {
+		mockOrderRepo, mockProvider, service, refund := newFixture()
+		mockProvider.On("ProcessRefundCallback", ctx, []byte("success"), "meta").
+			Return(&RefundResult{RefundNo: "REF1", Status: "SUCCESS", Amount: 500}, nil).Once()
+		settled := *refund
+		settled.Status = domain.PaymentRefundStatusSuccess
+		mockOrderRepo.On("GetPaymentRefundByIDForUpdate", ctx, refund.ID.String()).Return(&settled, nil).Once()
+
+		result, err := service.ProcessRefundCallback(ctx, "wechat", []byte("success"), "meta")
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.PaymentRefundStatusSuccess, result.Status)
+		mockOrderRepo.AssertNotCalled(t, "UpdatePaymentRefund", mock.Anything, mock.Anything)
+		mockOrderRepo.AssertNotCalled(t, "UpdatePayment", mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejects a notification for a different amount", func(t *testing.T) {
+		mockOrderRepo, mockProvider, service, _ := newFixture()
+		mockProvider.On("ProcessRefundCallback", ctx, []byte("success"), "meta").
+			Return(&RefundResult{RefundNo: "REF1", Status: "SUCCESS", Amount: 5000}, nil).Once()
+
+		_, err := service.ProcessRefundCallback(ctx, "wechat", []byte("success"), "meta")
+
+		assert.Error(t, err)
+		mockOrderRepo.AssertNotCalled(t, "UpdatePaymentRefund", mock.Anything, mock.Anything)
+	})
+}
+
 func TestPaymentService_GetPaymentByOrder(t *testing.T) {
 	mockOrderRepo := new(MockPaymentOrderRepository)
-	service := NewPaymentService(mockOrderRepo, nil)
+	service := NewPaymentService(mockOrderRepo)
 
 	ctx := context.Background()
 
@@ -575,3 +1053,194 @@ func TestPaymentService_GetPaymentByOrder(t *testing.T) {
 		mockOrderRepo.AssertExpectations(t)
 	})
 }
+
+func TestPaymentService_SplitPayment(t *testing.T) {
+	ctx := context.Background()
+	newOrder := func(paid float64) *domain.Order {
+		return &domain.Order{
+			BaseModel:   domain.BaseModel{ID: uuid.New()},
+			Status:      domain.OrderStatusPending,
+			TotalAmount: 1000,
+			PaidAmount:  paid,
+			Currency:    "CNY",
+		}
+	}
+	newPayment := func(amount float64) *domain.Payment {
+		return &domain.Payment{
+			BaseModel:     domain.BaseModel{ID: uuid.New()},
+			OrderID:       "order-1",
+			PaymentNo:     "PAY-" + uuid.NewString(),
+			PaymentMethod: "wechat",
+			Status:        domain.PaymentStatusPending,
+			Amount:        amount,
+			Currency:      "CNY",
+		}
+	}
+
+	t.Run("a payment covers the rest of a partly paid order by default", func(t *testing.T) {
+		mockOrderRepo := new(MockPaymentOrderRepository)
+		mockProvider := new(MockPaymentProvider)
+		service := NewPaymentService(mockOrderRepo)
+		service.RegisterProvider("wechat", mockProvider)
+		order := newOrder(400)
+
+		mockOrderRepo.On("GetByID", ctx, "order-1").Return(order, nil).Once()
+		mockProvider.On("CreatePayment", paymentAmount(600), order, mock.Anything).Return(&PaymentResult{PaymentNo: "PAY2"}, nil).Once()
+		mockOrderRepo.On("GetPaymentByNo", ctx, "PAY2").Return(&domain.Payment{PaymentNo: "PAY2"}, nil).Once()
+
+		_, err := service.CreatePayment(ctx, "order-1", "wechat", "订单支付")
+
+		require.NoError(t, err)
+		mockProvider.AssertExpectations(t)
+	})
+
+	t.Run("rejects a payment larger than the outstanding balance", func(t *testing.T) {
+		mockOrderRepo := new(MockPaymentOrderRepository)
+		mockProvider := new(MockPaymentProvider)
+		service := NewPaymentService(mockOrderRepo)
+		service.RegisterProvider("wechat", mockProvider)
+
+		mockOrderRepo.On("GetByID", mock.Anything, "order-1").Return(newOrder(400), nil).Once()
+
+		_, err := service.CreatePayment(WithAmount(ctx, 700), "order-1", "wechat", "订单支付")
+
+		assert.ErrorIs(t, err, ErrAmountExceedsBalance)
+		mockProvider.AssertNotCalled(t, "CreatePayment", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("settles partial payments until the order is paid", func(t *testing.T) {
+		mockOrderRepo := new(MockPaymentOrderRepository)
+		mockProvider := new(MockPaymentProvider)
+		service := NewPaymentService(mockOrderRepo)
+		service.RegisterProvider("wechat", mockProvider)
+		first, second := newPayment(400), newPayment(600)
+
+		mockProvider.On("ProcessCallback", ctx, []byte("first"), "sig").
+			Return(&CallbackResult{PaymentNo: first.PaymentNo, Amount: 400, Status: domain.PaymentStatusSuccess}, nil).Once()
+		mockProvider.On("ProcessCallback", ctx, []byte("second"), "sig").
+			Return(&CallbackResult{PaymentNo: second.PaymentNo, Amount: 600, Status: domain.PaymentStatusSuccess}, nil).Once()
+		mockOrderRepo.On("GetPaymentByNo", ctx, first.PaymentNo).Return(first, nil).Once()
+		mockOrderRepo.On("GetPaymentByNo", ctx, second.PaymentNo).Return(second, nil).Once()
+		mockOrderRepo.On("GetByIDForUpdate", ctx, "order-1").Return(newOrder(0), nil).Once()
+		mockOrderRepo.On("GetPaymentByIDForUpdate", ctx, first.ID.String()).Return(storedPayment(first), nil).Once()
+		mockOrderRepo.On("GetByIDForUpdate", ctx, "order-1").Return(newOrder(400), nil).Once()
+		mockOrderRepo.On("GetPaymentByIDForUpdate", ctx, second.ID.String()).Return(storedPayment(second), nil).Once()
+		mockOrderRepo.On("UpdatePayment", ctx, mock.Anything).Return(nil).Twice()
+		mockOrderRepo.On("UpdatePaymentStatus", ctx, "order-1", domain.PaymentStatusPartial, 400.0).Return(nil).Once()
+		mockOrderRepo.On("UpdatePaymentStatus", ctx, "order-1", domain.PaymentStatusPaid, 1000.0).Return(nil).Once()
+		mockOrderRepo.On("AddOutboxEvent", ctx, outboxEvent("payment.partial")).Return(nil).Once()
+		mockOrderRepo.On("AddOutboxEvent", ctx, outboxEvent("payment.success")).Return(nil).Once()
+
+		require.NoError(t, service.ProcessCallback(ctx, "wechat", []byte("first"), "sig"))
+		require.NoError(t, service.ProcessCallback(ctx, "wechat", []byte("second"), "sig"))
+
+		mockOrderRepo.AssertExpectations(t)
+	})
+
+	t.Run("settles a payment once when two notifications race", func(t *testing.T) {
+		mockOrderRepo := new(MockPaymentOrderRepository)
+		mockProvider := new(MockPaymentProvider)
+		service := NewPaymentService(mockOrderRepo)
+		service.RegisterProvider("wechat", mockProvider)
+		// Each notification read the payment before the other settled it
+		payment := newPayment(1000)
+		first, second := storedPayment(payment), storedPayment(payment)
+		settled := storedPayment(payment)
+		settled.Status = domain.PaymentStatusSuccess
+
+		mockProvider.On("ProcessCallback", ctx, []byte("notify"), "sig").
+			Return(&CallbackResult{PaymentNo: payment.PaymentNo, Amount: 1000, Status: domain.PaymentStatusSuccess}, nil).Twice()
+		mockOrderRepo.On("GetPaymentByNo", ctx, payment.PaymentNo).Return(first, nil).Once()
+		mockOrderRepo.On("GetPaymentByNo", ctx, payment.PaymentNo).Return(second, nil).Once()
+		mockOrderRepo.On("GetByIDForUpdate", ctx, "order-1").Return(newOrder(0), nil).Once()
+		mockOrderRepo.On("GetByIDForUpdate", ctx, "order-1").Return(newOrder(1000), nil).Once()
+		mockOrderRepo.On("GetPaymentByIDForUpdate", ctx, payment.ID.String()).Return(storedPayment(payment), nil).Once()
+		mockOrderRepo.On("GetPaymentByIDForUpdate", ctx, payment.ID.String()).Return(settled, nil).Once()
+		mockOrderRepo.On("UpdatePayment", ctx, first).Return(nil).Once()
+		mockOrderRepo.On("UpdatePaymentStatus", ctx, "order-1", domain.PaymentStatusPaid, 1000.0).Return(nil).Once()
+		mockOrderRepo.On("AddOutboxEvent", ctx, outboxEvent("payment.success")).Return(nil).Once()
+
+		require.NoError(t, service.ProcessCallback(ctx, "wechat", []byte("notify"), "sig"))
+		require.NoError(t, service.ProcessCallback(ctx, "wechat", []byte("notify"), "sig"))
+
+		mockOrderRepo.AssertExpectations(t)
+		mockOrderRepo.AssertNotCalled(t, "CreateRefundRequest", mock.Anything, mock.Anything)
+		mockProvider.AssertNotCalled(t, "Refund", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("refunds a payment that would overpay the order", func(t *testing.T) {
+		mockOrderRepo := new(MockPaymentOrderRepository)
+		mockProvider := new(MockPaymentProvider)
+		service := NewPaymentService(mockOrderRepo)
+		service.RegisterProvider("wechat", mockProvider)
+		payment := newPayment(600)
+
+		mockProvider.On("ProcessCallback", ctx, []byte("late"), "sig").
+			Return(&CallbackResult{PaymentNo: payment.PaymentNo, Amount: 600, Status: domain.PaymentStatusSuccess}, nil).Once()
+		mockOrderRepo.On("GetPaymentByNo", ctx, payment.PaymentNo).Return(payment, nil).Once()
+		mockOrderRepo.On("GetByIDForUpdate", ctx, "order-1").Return(newOrder(600), nil).Once()
+		mockOrderRepo.On("GetPaymentByIDForUpdate", ctx, payment.ID.String()).Return(storedPayment(payment), nil).Once()
+		mockOrderRepo.On("UpdatePayment", ctx, payment).Return(nil).Twice()
+		mockOrderRepo.On("CreateRefundRequest", ctx, mock.Anything).Return(nil).Once()
+		mockOrderRepo.On("AddOutboxEvent", ctx, outboxEvent("payment.late")).Return(nil).Once()
+		mockOrderRepo.On("GetPaymentByIDForUpdate", ctx, payment.ID.String()).Return(settledPayment(payment), nil).Twice()
+		mockOrderRepo.On("ListPaymentRefundsByOrder", ctx, "order-1").Return([]*domain.PaymentRefund{}, nil).Once()
+		mockProvider.On("Refund", ctx, payment, 600.0, overpaidRefundReason).
+			Return(&RefundResult{RefundNo: "REF1", Status: "PROCESSING"}, nil).Once()
+		expectPaymentRefund(mockOrderRepo)
+		mockOrderRepo.On("UpdatePaymentRefund", ctx, mock.Anything).Return(nil).Once()
+		mockOrderRepo.On("UpdateRefundRequest", ctx, mock.Anything).Return(nil).Once()
+
+		require.NoError(t, service.ProcessCallback(ctx, "wechat", []byte("late"), "sig"))
+
+		mockOrderRepo.AssertNotCalled(t, "UpdatePaymentStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		mockProvider.AssertExpectations(t)
+	})
+}
+
+func TestAllocateRefund(t *testing.T) {
+	paid := func(amount, refunded float64) *domain.Payment {
+		return &domain.Payment{
+			BaseModel:      domain.BaseModel{ID: uuid.New()},
+			Status:         domain.PaymentStatusSuccess,
+			Amount:         amount,
+			RefundedAmount: refunded,
+			Currency:       "CNY",
+		}
+	}
+	first, second, third := paid(300, 0), paid(500, 100), paid(200, 0)
+	failed := &domain.Payment{BaseModel: domain.BaseModel{ID: uuid.New()}, Status: domain.PaymentStatusFailed, Amount: 1000}
+	payments := []*domain.Payment{first, failed, second, third}
+	processing := []*domain.PaymentRefund{
+		{PaymentID: first.ID.String(), Amount: 100, Status: domain.PaymentRefundStatusProcessing},
+		{PaymentID: third.ID.String(), Amount: 50, Status: domain.PaymentRefundStatusFailed},
+	}
+
+	tests := []struct {
+		name   string
+		amount float64
+		want   []float64
+	}{
+		{name: "fits in the first payment", amount: 150, want: []float64{150}},
+		{name: "spills into later payments in order", amount: 500, want: []float64{200, 300}},
+		{name: "uses every refundable payment", amount: 800, want: []float64{200, 400, 200}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			legs, err := allocateRefund(payments, processing, tt.amount)
+			require.NoError(t, err)
+
+			var got []float64
+			for _, leg := range legs {
+				assert.NotSame(t, failed, leg.payment)
+				got = append(got, leg.amount)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("more than the payments can return", func(t *testing.T) {
+		_, err := allocateRefund(payments, processing, 800.01)
+		assert.ErrorIs(t, err, ErrRefundExceedsPaid)
+	})
+}
@@ -0,0 +1,223 @@
+package payment
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Statement entry kinds
+const (
+	StatementTrade  = "trade"
+	StatementRefund = "refund"
+)
+
+var ErrInvalidStatement = errors.New("invalid statement file")
+
+// StatementEntry is one line of a provider's daily statement
+type StatementEntry struct {
+	Kind          string
+	Time          string
+	TransactionID string // provider trade number
+	PaymentNo     string // our payment number
+	RefundID      string // provider refund number
+	RefundNo      string // our refund number
+	Amount        float64
+	Fee           float64
+	Status        string
+	Currency      string
+}
+
+// Settled reports whether the provider considers the entry settled
+func (e StatementEntry) Settled() bool {
+	switch strings.ToUpper(e.Status) {
+	case "", "SUCCESS", "REFUND", "TRADE_SUCCESS", "TRADE_FINISHED", "REFUND_SUCCESS", "交易成功", "退款成功":
+		return true
+	}
+	return false
+}
+
+// statementColumns maps each field to the headers providers use for it, in
+// order of preference. WeChat Pay bills use the Chinese names; the English
+// names cover Alipay exports and hand-built files.
+var statementColumns = map[string][]string{
+	"time":           {"交易时间", "退款成功时间", "trade_time", "time"},
+	"transaction_id": {"微信订单号", "支付宝交易号", "transaction_id", "trade_no"},
+	"payment_no":     {"商户订单号", "out_trade_no", "payment_no"},
+	"refund_id":      {"微信退款单号", "refund_id"},
+	"refund_no":      {"商户退款单号", "out_refund_no", "out_request_no", "refund_no"},
+	"amount":         {"应结订单金额", "订单金额", "订单金额（元）", "total_amount", "amount"},
+	"refund_amount":  {"退款金额", "退款金额（元）", "refund_amount"},
+	"fee":            {"手续费", "服务费（元）", "fee"},
+	"trade_status":   {"交易状态", "trade_status", "status"},
+	"refund_status":  {"退款状态", "refund_status", "status"},
+	"currency":       {"货币种类", "currency"},
+}
+
+// statementFooters start the summary block that closes a statement
+var statementFooters = []string{"总交易单数", "总笔数", "交易合计", "合计"}
+
+// ParseStatement reads a trade or refund statement in CSV form. Cells may
+// carry WeChat's leading backtick, lines starting with # are comments and
+// parsing stops at the summary block. Refund lines of a combined trade bill
+// are returned with the refund kind.
+func ParseStatement(r io.Reader, kind string) ([]StatementEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.Comment = '#'
+
+	var columns map[string]int
+	var entries []StatementEntry
+	line := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidStatement, err)
+		}
+		line++
+
+		for i := range record {
+			record[i] = cleanStatementCell(record[i])
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+		if isStatementFooter(record[0]) {
+			break
+		}
+
+		if columns == nil {
+			columns = statementHeader(record)
+			if err := requireStatementColumns(columns, kind); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		entry, err := statementEntry(record, columns, kind)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidStatement, line, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if columns == nil {
+		return nil, fmt.Errorf("%w: missing header", ErrInvalidStatement)
+	}
+	return entries, nil
+}
+
+func statementHeader(record []string) map[string]int {
+	index := make(map[string]int, len(record))
+	for i, name := range record {
+		index[name] = i
+	}
+
+	columns := make(map[string]int)
+	for field, names := range statementColumns {
+		for _, name := range names {
+			if i, ok := index[name]; ok {
+				columns[field] = i
+				break
+			}
+		}
+	}
+	return columns
+}
+
+func requireStatementColumns(columns map[string]int, kind string) error {
+	required := []string{"transaction_id", "amount"}
+	if kind == StatementRefund {
+		required = []string{"refund_amount"}
+		if _, ok := columns["refund_id"]; !ok {
+			required = append(required, "refund_no")
+		}
+	}
+
+	for _, field := range required {
+		if _, ok := columns[field]; !ok {
+			return fmt.Errorf("%w: missing %s column", ErrInvalidStatement, field)
+		}
+	}
+	return nil
+}
+
+func statementEntry(record []string, columns map[string]int, kind string) (StatementEntry, error) {
+	cell := func(field string) string {
+		if i, ok := columns[field]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+	amount := func(field string) (float64, error) {
+		value := strings.ReplaceAll(cell(field), ",", "")
+		if value == "" {
+			return 0, nil
+		}
+		return strconv.ParseFloat(value, 64)
+	}
+
+	entry := StatementEntry{
+		Kind:          kind,
+		Time:          cell("time"),
+		TransactionID: cell("transaction_id"),
+		PaymentNo:     cell("payment_no"),
+		RefundID:      cell("refund_id"),
+		RefundNo:      cell("refund_no"),
+		Currency:      cell("currency"),
+	}
+
+	// WeChat's combined bill lists refunds among trades with state REFUND
+	if kind == StatementTrade && strings.EqualFold(cell("trade_status"), "REFUND") && (entry.RefundID != "" || entry.RefundNo != "") {
+		entry.Kind = StatementRefund
+	}
+
+	var err error
+	if entry.Fee, err = amount("fee"); err != nil {
+		return entry, fmt.Errorf("invalid fee: %v", err)
+	}
+	if entry.Kind == StatementRefund {
+		entry.Status = cell("refund_status")
+		if entry.Amount, err = amount("refund_amount"); err != nil {
+			return entry, fmt.Errorf("invalid refund amount: %v", err)
+		}
+		return entry, nil
+	}
+
+	entry.Status = cell("trade_status")
+	if entry.Amount, err = amount("amount"); err != nil {
+		return entry, fmt.Errorf("invalid amount: %v", err)
+	}
+	return entry, nil
+}
+
+func cleanStatementCell(value string) string {
+	value = strings.TrimPrefix(value, "\ufeff")
+	value = strings.TrimSpace(value)
+	return strings.TrimSpace(strings.TrimPrefix(value, "`"))
+}
+
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if value != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func isStatementFooter(first string) bool {
+	for _, footer := range statementFooters {
+		if strings.HasPrefix(first, footer) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package payment
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStatement_WechatBill(t *testing.T) {
+	bill := "\ufeff交易时间,公众账号ID,商户号,微信订单号,商户订单号,交易类型,交易状态,货币种类,应结订单金额,微信退款单号,商户退款单号,退款金额,退款状态,手续费,订单金额\n" +
+		"`2026-06-01 10:00:00,`wx01,`1900000001,`4200000001,`PAY20260601a,`NATIVE,`SUCCESS,`CNY,`12000.00,`0,`0,`0.00,`,`72.00,`12000.00\n" +
+		"`2026-06-01 11:00:00,`wx01,`1900000001,`4200000002,`PAY20260601b,`NATIVE,`REFUND,`CNY,`0.00,`5030000001,`REF20260601a,`500.00,`SUCCESS,`-3.00,`8000.00\n" +
+		"总交易单数,应结订单总金额,退款总金额,充值券退款总金额,手续费总金额,订单总金额\n" +
+		"`2,`12000.00,`500.00,`0.00,`69.00,`20000.00\n"
+
+	entries, err := ParseStatement(strings.NewReader(bill), StatementTrade)
+
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, StatementEntry{
+		Kind: StatementTrade, Time: "2026-06-01 10:00:00", TransactionID: "4200000001", PaymentNo: "PAY20260601a",
+		RefundID: "0", RefundNo: "0", Amount: 12000, Fee: 72, Status: "SUCCESS", Currency: "CNY",
+	}, entries[0])
+	assert.Equal(t, StatementRefund, entries[1].Kind)
+	assert.Equal(t, 500.0, entries[1].Amount)
+	assert.Equal(t, -3.0, entries[1].Fee)
+	assert.True(t, entries[1].Settled())
+}
+
+func TestParseStatement_RefundFile(t *testing.T) {
+	file := "# refunds for 2026-06-01\nrefund_id,out_refund_no,out_trade_no,refund_amount,refund_status\n" +
+		"5030000009,REF20260601z,PAY20260601c,\"1,200.00\",PROCESSING\n"
+
+	entries, err := ParseStatement(strings.NewReader(file), StatementRefund)
+
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 1200.0, entries[0].Amount)
+	assert.False(t, entries[0].Settled())
+
+	_, err = ParseStatement(strings.NewReader("out_trade_no,amount\nPAY1,10.00\n"), StatementTrade)
+	assert.ErrorIs(t, err, ErrInvalidStatement)
+}
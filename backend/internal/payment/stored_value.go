@@ -0,0 +1,238 @@
+package payment
+
+import (
+	"backend/internal/currency"
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Errors returned by the gift card and points tenders
+var (
+	ErrTenderUnavailable   = errors.New("tender cannot pay this order")
+	ErrInsufficientBalance = errors.New("insufficient gift card or points balance")
+)
+
+// defaultPointsPerUnit is how many points pay one unit of the base currency
+// unless configured otherwise
+const defaultPointsPerUnit = 100
+
+// StoredValueConfig represents the gift card and points tender configuration
+type StoredValueConfig struct {
+	PointsPerUnit int64 // points redeemed per unit of the base currency
+}
+
+type giftCardKey struct{}
+
+// WithGiftCard sets the code of the card a gift card payment draws on
+func WithGiftCard(ctx context.Context, code string) context.Context {
+	return context.WithValue(ctx, giftCardKey{}, code)
+}
+
+// giftCardFrom returns the gift card code on the context
+func giftCardFrom(ctx context.Context) string {
+	code, _ := ctx.Value(giftCardKey{}).(string)
+	return code
+}
+
+// storedValue implements PaymentProvider for balances held by the platform.
+// The balance is debited in the transaction that records the payment, so a
+// stored payment has always been charged; CreatePayment reports it successful
+// and the payment service settles it at once. Refunds credit the balance back
+// immediately.
+type storedValue struct {
+	method        string
+	pointsPerUnit int64
+	paymentRepo   repository.OrderRepository
+	wallet        func(tx *gorm.DB) repository.StoredValueRepository
+	now           func() time.Time
+}
+
+// NewGiftCard creates the gift card tender. The card is chosen with
+// WithGiftCard and must be in the order's currency
+func NewGiftCard(paymentRepo repository.OrderRepository) PaymentProvider {
+	return newStoredValue(domain.PaymentMethodGiftCard, StoredValueConfig{}, paymentRepo)
+}
+
+// NewPoints creates the points tender. Points are valued in the base
+// currency and only pay orders of the customer holding them, identified with
+// WithPayer
+func NewPoints(config StoredValueConfig, paymentRepo repository.OrderRepository) PaymentProvider {
+	return newStoredValue(domain.PaymentMethodPoints, config, paymentRepo)
+}
+
+func newStoredValue(method string, config StoredValueConfig, paymentRepo repository.OrderRepository) *storedValue {
+	if config.PointsPerUnit <= 0 {
+		config.PointsPerUnit = defaultPointsPerUnit
+	}
+	return &storedValue{
+		method:        method,
+		pointsPerUnit: config.PointsPerUnit,
+		paymentRepo:   paymentRepo,
+		wallet:        repository.NewStoredValueRepository,
+		now:           time.Now,
+	}
+}
+
+// CreatePayment debits the tender and records the payment
+func (s *storedValue) CreatePayment(ctx context.Context, order *domain.Order, description string) (*PaymentResult, error) {
+	payment := &domain.Payment{
+		OrderID:        order.ID.String(),
+		PaymentNo:      generatePaymentNo(),
+		PaymentMethod:  s.method,
+		PaymentChannel: s.method,
+		Amount:         amountFrom(ctx, order),
+		Currency:       currencyOrBase(order.Currency),
+		Status:         domain.PaymentStatusPending,
+	}
+	payment.ApplyExchangeRate(order.BaseCurrency, order.ExchangeRate)
+
+	switch s.method {
+	case domain.PaymentMethodGiftCard:
+		payment.TenderRef = giftCardFrom(ctx)
+		if payment.TenderRef == "" {
+			return nil, fmt.Errorf("%w: no gift card code given", ErrTenderUnavailable)
+		}
+	case domain.PaymentMethodPoints:
+		if payer := payerFrom(ctx); order.UserID == nil || payer.UserID != *order.UserID {
+			return nil, fmt.Errorf("%w: points only pay the holder's own orders", ErrTenderUnavailable)
+		}
+		payment.TenderRef = *order.UserID
+		payment.Points = s.pointsFor(payment)
+	}
+
+	err := s.paymentRepo.WithTransaction(ctx, func(txRepo repository.OrderRepository, tx *gorm.DB) error {
+		if err := s.debit(ctx, s.wallet(tx), payment); err != nil {
+			return err
+		}
+		if err := txRepo.CreatePayment(ctx, payment); err != nil {
+			return fmt.Errorf("failed to create payment record: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaymentResult{
+		PaymentNo: payment.PaymentNo,
+		Status:    domain.PaymentStatusSuccess,
+	}, nil
+}
+
+// debit takes a payment's amount off the gift card or the payer's points
+func (s *storedValue) debit(ctx context.Context, wallet repository.StoredValueRepository, payment *domain.Payment) error {
+	if s.method == domain.PaymentMethodPoints {
+		ok, err := wallet.DebitPoints(ctx, payment.TenderRef, payment.Points)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%w: %d points needed", ErrInsufficientBalance, payment.Points)
+		}
+		return nil
+	}
+
+	now := s.now()
+	card, err := wallet.GetGiftCard(ctx, payment.TenderRef)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: gift card not found", ErrTenderUnavailable)
+	}
+	if err != nil {
+		return err
+	}
+	if !card.UsableAt(now) {
+		return fmt.Errorf("%w: gift card is disabled or expired", ErrTenderUnavailable)
+	}
+	if card.Currency != payment.Currency {
+		return fmt.Errorf("%w: gift card is in %s, the order in %s", ErrTenderUnavailable, card.Currency, payment.Currency)
+	}
+
+	ok, err := wallet.DebitGiftCard(ctx, payment.TenderRef, payment.Amount, now)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: %0.2f needed", ErrInsufficientBalance, payment.Amount)
+	}
+	return nil
+}
+
+// pointsFor returns the points that pay a payment's base currency amount,
+// rounding up to a whole point
+func (s *storedValue) pointsFor(payment *domain.Payment) int64 {
+	unit := currency.ToMinor(1, payment.BaseCurrency)
+	minor := currency.ToMinor(payment.BaseAmount, payment.BaseCurrency)
+	return (minor*s.pointsPerUnit + unit - 1) / unit
+}
+
+// refundPoints returns the points given back for refunding amount of a
+// points payment, in proportion to the amount paid. Refunding the whole
+// payment, at once or in parts, gives back every point redeemed
+func refundPoints(payment *domain.Payment, amount float64) int64 {
+	paid := currency.ToMinor(payment.Amount, payment.Currency)
+	if paid <= 0 {
+		return 0
+	}
+	pointsAt := func(refunded int64) int64 {
+		if refunded >= paid {
+			return payment.Points
+		}
+		return payment.Points * refunded / paid
+	}
+	before := currency.ToMinor(payment.RefundedAmount, payment.Currency)
+	return pointsAt(before+currency.ToMinor(amount, payment.Currency)) - pointsAt(before)
+}
+
+// TradeTypes reports no trade types: stored value payments need no checkout
+func (s *storedValue) TradeTypes() []string {
+	return nil
+}
+
+// QueryPayment reports a stored payment as successful, as its balance was
+// debited when it was recorded. A payment whose settlement was interrupted
+// is settled by the next poll
+func (s *storedValue) QueryPayment(ctx context.Context, paymentNo string) (*PaymentQueryResult, error) {
+	payment, err := s.paymentRepo.GetPaymentByNo(ctx, paymentNo)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPaymentNotFound, err)
+	}
+
+	status := payment.Status
+	if payment.IsInFlight() {
+		status = domain.PaymentStatusSuccess
+	}
+	return &PaymentQueryResult{Status: status, Amount: payment.Amount}, nil
+}
+
+// ProcessCallback rejects notifications: stored value payments have none
+func (s *storedValue) ProcessCallback(ctx context.Context, body []byte, signature string) (*CallbackResult, error) {
+	return nil, fmt.Errorf("%s payments have no callbacks", s.method)
+}
+
+// VerifySignature rejects every notification
+func (s *storedValue) VerifySignature(body []byte, signature string) bool {
+	return false
+}
+
+// Refund credits the refunded amount back to the gift card or the payer's
+// points, settling at once
+func (s *storedValue) Refund(ctx context.Context, payment *domain.Payment, amount float64, reason string) (*RefundResult, error) {
+	err := s.paymentRepo.WithTransaction(ctx, func(_ repository.OrderRepository, tx *gorm.DB) error {
+		wallet := s.wallet(tx)
+		if s.method == domain.PaymentMethodPoints {
+			return wallet.CreditPoints(ctx, payment.TenderRef, refundPoints(payment, amount))
+		}
+		return wallet.CreditGiftCard(ctx, payment.TenderRef, amount)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPaymentFailed, err)
+	}
+
+	return &RefundResult{RefundNo: generateRefundNo(), Status: "SUCCESS"}, nil
+}
@@ -0,0 +1,285 @@
+package payment
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// memWallet keeps gift card and points balances in memory
+type memWallet struct {
+	cards  map[string]*domain.GiftCard
+	points map[string]int64
+}
+
+func (w *memWallet) GetGiftCard(ctx context.Context, code string) (*domain.GiftCard, error) {
+	card, ok := w.cards[code]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *card
+	return &copied, nil
+}
+
+func (w *memWallet) DebitGiftCard(ctx context.Context, code string, amount float64, at time.Time) (bool, error) {
+	card, ok := w.cards[code]
+	if !ok || !card.UsableAt(at) || card.Balance < amount {
+		return false, nil
+	}
+	card.Balance -= amount
+	return true, nil
+}
+
+func (w *memWallet) CreditGiftCard(ctx context.Context, code string, amount float64) error {
+	card, ok := w.cards[code]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	card.Balance += amount
+	return nil
+}
+
+func (w *memWallet) GetPoints(ctx context.Context, userID string) (int64, error) {
+	return w.points[userID], nil
+}
+
+func (w *memWallet) DebitPoints(ctx context.Context, userID string, points int64) (bool, error) {
+	if w.points[userID] < points {
+		return false, nil
+	}
+	w.points[userID] -= points
+	return true, nil
+}
+
+func (w *memWallet) CreditPoints(ctx context.Context, userID string, points int64) error {
+	w.points[userID] += points
+	return nil
+}
+
+// newTestTender creates a gift card or points tender drawing on wallet
+func newTestTender(method string, repo repository.OrderRepository, wallet *memWallet) *storedValue {
+	tender := newStoredValue(method, StoredValueConfig{}, repo)
+	tender.wallet = func(*gorm.DB) repository.StoredValueRepository { return wallet }
+	return tender
+}
+
+// expectStoredPayment records the payment a tender creates, and returns it
+// for the payment service to read back and settle
+func expectStoredPayment(repo *MockPaymentOrderRepository) *domain.Payment {
+	created, stored := &domain.Payment{}, &domain.Payment{}
+	repo.On("CreatePayment", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		*created = *args.Get(1).(*domain.Payment)
+		created.ID = uuid.New()
+		*stored = *created
+	}).Return(nil).Once()
+	repo.On("GetPaymentByNo", mock.Anything, mock.Anything).Return(created, nil).Once()
+	repo.On("GetPaymentByIDForUpdate", mock.Anything, mock.Anything).Return(stored, nil).Once()
+	return created
+}
+
+func TestStoredValue_CombinedWithOtherPayments(t *testing.T) {
+	ctx := context.Background()
+	userID := "user-1"
+	newOrder := func(paid float64) *domain.Order {
+		return &domain.Order{
+			BaseModel:   domain.BaseModel{ID: uuid.New()},
+			UserID:      &userID,
+			Status:      domain.OrderStatusPending,
+			TotalAmount: 1000,
+			PaidAmount:  paid,
+			Currency:    "CNY",
+		}
+	}
+	wallet := &memWallet{
+		cards:  map[string]*domain.GiftCard{"GC-1": {Code: "GC-1", Currency: "CNY", Balance: 500, Status: domain.GiftCardStatusActive}},
+		points: map[string]int64{userID: 30000},
+	}
+
+	mockOrderRepo := new(MockPaymentOrderRepository)
+	mockWechat := new(MockPaymentProvider)
+	service := NewPaymentService(mockOrderRepo)
+	service.RegisterProvider(domain.PaymentMethodGiftCard, newTestTender(domain.PaymentMethodGiftCard, mockOrderRepo, wallet))
+	service.RegisterProvider(domain.PaymentMethodPoints, newTestTender(domain.PaymentMethodPoints, mockOrderRepo, wallet))
+	service.RegisterProvider(domain.PaymentMethodWechat, mockWechat)
+
+	// 300 from the gift card
+	mockOrderRepo.On("GetByID", mock.Anything, "order-1").Return(newOrder(0), nil).Once()
+	giftCard := expectStoredPayment(mockOrderRepo)
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, mock.Anything).Return(newOrder(0), nil).Once()
+	mockOrderRepo.On("UpdatePayment", mock.Anything, mock.Anything).Return(nil).Once()
+	mockOrderRepo.On("UpdatePaymentStatus", mock.Anything, mock.Anything, domain.PaymentStatusPartial, 300.0).Return(nil).Once()
+	mockOrderRepo.On("AddOutboxEvent", mock.Anything, outboxEvent("payment.partial")).Return(nil).Once()
+
+	payment, err := service.CreatePayment(WithGiftCard(WithAmount(ctx, 300), "GC-1"), "order-1", domain.PaymentMethodGiftCard, "订单支付")
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusSuccess, payment.Status)
+	assert.Equal(t, "GC-1", giftCard.TenderRef)
+	assert.Equal(t, 200.0, wallet.cards["GC-1"].Balance)
+
+	// 250 in points, at 100 points per yuan
+	mockOrderRepo.On("GetByID", mock.Anything, "order-1").Return(newOrder(300), nil).Once()
+	points := expectStoredPayment(mockOrderRepo)
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, mock.Anything).Return(newOrder(300), nil).Once()
+	mockOrderRepo.On("UpdatePayment", mock.Anything, mock.Anything).Return(nil).Once()
+	mockOrderRepo.On("UpdatePaymentStatus", mock.Anything, mock.Anything, domain.PaymentStatusPartial, 550.0).Return(nil).Once()
+	mockOrderRepo.On("AddOutboxEvent", mock.Anything, outboxEvent("payment.partial")).Return(nil).Once()
+
+	_, err = service.CreatePayment(WithPayer(WithAmount(ctx, 250), Payer{UserID: userID}), "order-1", domain.PaymentMethodPoints, "订单支付")
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(25000), points.Points)
+	assert.Equal(t, int64(5000), wallet.points[userID])
+
+	// and the rest with WeChat Pay
+	mockOrderRepo.On("GetByID", mock.Anything, "order-1").Return(newOrder(550), nil).Once()
+	mockWechat.On("CreatePayment", paymentAmount(450), mock.Anything, mock.Anything).Return(&PaymentResult{PaymentNo: "PAY-WX"}, nil).Once()
+	mockOrderRepo.On("GetPaymentByNo", mock.Anything, "PAY-WX").
+		Return(&domain.Payment{PaymentNo: "PAY-WX", Status: domain.PaymentStatusPending}, nil).Once()
+
+	payment, err = service.CreatePayment(ctx, "order-1", domain.PaymentMethodWechat, "订单支付")
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusPending, payment.Status)
+	mockOrderRepo.AssertExpectations(t)
+	mockWechat.AssertExpectations(t)
+}
+
+func TestStoredValue_CreatePaymentRejected(t *testing.T) {
+	ctx := context.Background()
+	userID := "user-1"
+	expired := time.Now().Add(-time.Hour)
+	order := &domain.Order{
+		BaseModel:   domain.BaseModel{ID: uuid.New()},
+		UserID:      &userID,
+		Status:      domain.OrderStatusPending,
+		TotalAmount: 1000,
+		Currency:    "CNY",
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		ctx     context.Context
+		wantErr error
+	}{
+		{"gift card without a code", domain.PaymentMethodGiftCard, ctx, ErrTenderUnavailable},
+		{"unknown gift card", domain.PaymentMethodGiftCard, WithGiftCard(ctx, "GC-404"), ErrTenderUnavailable},
+		{"expired gift card", domain.PaymentMethodGiftCard, WithGiftCard(ctx, "GC-OLD"), ErrTenderUnavailable},
+		{"gift card in another currency", domain.PaymentMethodGiftCard, WithGiftCard(ctx, "GC-USD"), ErrTenderUnavailable},
+		{"gift card balance too low", domain.PaymentMethodGiftCard, WithGiftCard(ctx, "GC-1"), ErrInsufficientBalance},
+		{"points of another customer", domain.PaymentMethodPoints, WithPayer(ctx, Payer{UserID: "user-2"}), ErrTenderUnavailable},
+		{"not enough points", domain.PaymentMethodPoints, WithPayer(ctx, Payer{UserID: userID}), ErrInsufficientBalance},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wallet := &memWallet{
+				cards: map[string]*domain.GiftCard{
+					"GC-1":   {Code: "GC-1", Currency: "CNY", Balance: 500, Status: domain.GiftCardStatusActive},
+					"GC-OLD": {Code: "GC-OLD", Currency: "CNY", Balance: 5000, Status: domain.GiftCardStatusActive, ExpiresAt: &expired},
+					"GC-USD": {Code: "GC-USD", Currency: "USD", Balance: 5000, Status: domain.GiftCardStatusActive},
+				},
+				points: map[string]int64{userID: 500, "user-2": 1000000},
+			}
+			mockOrderRepo := new(MockPaymentOrderRepository)
+
+			_, err := newTestTender(tt.method, mockOrderRepo, wallet).CreatePayment(tt.ctx, order, "订单支付")
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			mockOrderRepo.AssertNotCalled(t, "CreatePayment", mock.Anything, mock.Anything)
+			assert.Equal(t, 500.0, wallet.cards["GC-1"].Balance)
+			assert.Equal(t, int64(1000000), wallet.points["user-2"])
+		})
+	}
+}
+
+func TestStoredValue_Refund(t *testing.T) {
+	ctx := context.Background()
+	wallet := &memWallet{
+		cards:  map[string]*domain.GiftCard{"GC-1": {Code: "GC-1", Currency: "CNY", Balance: 100, Status: domain.GiftCardStatusActive}},
+		points: map[string]int64{"user-1": 0},
+	}
+	mockOrderRepo := new(MockPaymentOrderRepository)
+
+	giftCard := &domain.Payment{PaymentNo: "PAY-GC", Amount: 300, Currency: "CNY", TenderRef: "GC-1", Status: domain.PaymentStatusSuccess}
+	result, err := newTestTender(domain.PaymentMethodGiftCard, mockOrderRepo, wallet).Refund(ctx, giftCard, 120, "退款")
+	require.NoError(t, err)
+	assert.Equal(t, "SUCCESS", result.Status)
+	assert.Equal(t, 220.0, wallet.cards["GC-1"].Balance)
+
+	points := &domain.Payment{PaymentNo: "PAY-PT", Amount: 250, Currency: "CNY", TenderRef: "user-1", Points: 25000, Status: domain.PaymentStatusSuccess}
+	_, err = newTestTender(domain.PaymentMethodPoints, mockOrderRepo, wallet).Refund(ctx, points, 100, "退款")
+	require.NoError(t, err)
+	assert.Equal(t, int64(10000), wallet.points["user-1"])
+}
+
+func TestRefundPoints(t *testing.T) {
+	tests := []struct {
+		name                   string
+		points                 int64
+		paid, refunded, amount float64
+		want                   int64
+	}{
+		{"whole payment", 25000, 250, 0, 250, 25000},
+		{"part of the payment", 25000, 250, 0, 100, 10000},
+		{"part worth less than a point", 100, 30, 0, 0.01, 0},
+		{"last part returns what is left", 1000, 10, 0.33, 9.67, 967},
+		{"uneven thirds add up", 100, 30, 10, 10, 33},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payment := &domain.Payment{Amount: tt.paid, Currency: "CNY", Points: tt.points, RefundedAmount: tt.refunded}
+			assert.Equal(t, tt.want, refundPoints(payment, tt.amount))
+		})
+	}
+}
+
+func TestStoredValue_PointsFor(t *testing.T) {
+	tests := []struct {
+		name         string
+		amount, rate float64
+		perUnit      int64
+		want         int64
+	}{
+		{"base currency", 250, 1, 100, 25000},
+		{"rounds up to a whole point", 0.05, 1, 10, 1},
+		{"foreign currency is valued in the base currency", 100, 0.5, 100, 20000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tender := newStoredValue(domain.PaymentMethodPoints, StoredValueConfig{PointsPerUnit: tt.perUnit}, nil)
+			payment := &domain.Payment{Amount: tt.amount, Currency: "USD"}
+			payment.ApplyExchangeRate("CNY", tt.rate)
+			assert.Equal(t, tt.want, tender.pointsFor(payment))
+		})
+	}
+}
+
+func TestStoredValue_QueryPayment(t *testing.T) {
+	ctx := context.Background()
+	mockOrderRepo := new(MockPaymentOrderRepository)
+	tender := newTestTender(domain.PaymentMethodGiftCard, mockOrderRepo, &memWallet{})
+
+	mockOrderRepo.On("GetPaymentByNo", ctx, "PAY-1").Return(&domain.Payment{Amount: 300, Status: domain.PaymentStatusPending}, nil).Once()
+	mockOrderRepo.On("GetPaymentByNo", ctx, "PAY-2").Return(&domain.Payment{Amount: 300, Status: domain.PaymentStatusRefunded}, nil).Once()
+
+	// A recorded payment was charged, even if its settlement was interrupted
+	result, err := tender.QueryPayment(ctx, "PAY-1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusSuccess, result.Status)
+
+	result, err = tender.QueryPayment(ctx, "PAY-2")
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusRefunded, result.Status)
+}
@@ -1,6 +1,7 @@
 package payment
 
 import (
+	"backend/internal/currency"
 	"backend/internal/domain"
 	"backend/internal/repository"
 	"bytes"
@@ -25,13 +26,25 @@ import (
 )
 
 var (
-	ErrPaymentFailed       = errors.New("payment processing failed")
-	ErrInvalidSignature    = errors.New("invalid payment signature")
-	ErrPaymentNotFound     = errors.New("payment not found")
-	ErrPaymentAlreadyPaid  = errors.New("payment already completed")
-	ErrInsufficientPayment = errors.New("insufficient payment amount")
+	ErrPaymentFailed        = errors.New("payment processing failed")
+	ErrInvalidSignature     = errors.New("invalid payment signature")
+	ErrPaymentNotFound      = errors.New("payment not found")
+	ErrPaymentAlreadyPaid   = errors.New("payment already completed")
+	ErrInsufficientPayment  = errors.New("insufficient payment amount")
+	ErrUnsupportedTradeType = errors.New("unsupported trade type")
+	ErrAmountExceedsBalance = errors.New("payment amount exceeds the order's outstanding balance")
+	ErrRefundExceedsPaid    = errors.New("refund amount exceeds what the order's payments can return")
 )
 
+// WeChat Pay trade types
+const (
+	WechatTradeJSAPI  = "jsapi"  // mini program, paid with wx.requestPayment
+	WechatTradeNative = "native" // desktop web, paid by scanning a QR code
+	WechatTradeH5     = "h5"     // mobile browser, redirects to the WeChat app
+)
+
+const wechatAPI = "https://api.mch.weixin.qq.com"
+
 // PaymentProvider defines the interface for payment providers
 type PaymentProvider interface {
 	// CreatePayment creates a new payment request
@@ -48,11 +61,28 @@ type PaymentProvider interface {
 
 	// Refund processes a refund
 	Refund(ctx context.Context, payment *domain.Payment, amount float64, reason string) (*RefundResult, error)
+
+	// TradeTypes lists the trade types CreatePayment accepts through
+	// WithChannel, default first
+	TradeTypes() []string
+}
+
+// RefundQuerier is implemented by providers that can report the state of a
+// previously requested refund
+type RefundQuerier interface {
+	QueryRefund(ctx context.Context, payment *domain.Payment, refundNo string) (*RefundResult, error)
+}
+
+// RefundNotifier is implemented by providers that report refund outcomes
+// through asynchronous notifications
+type RefundNotifier interface {
+	ProcessRefundCallback(ctx context.Context, body []byte, signature string) (*RefundResult, error)
 }
 
 // PaymentResult represents the result of creating a payment
 type PaymentResult struct {
 	PaymentNo    string `json:"payment_no"`
+	TradeType    string `json:"trade_type,omitempty"`
 	ThirdPartyID string `json:"third_party_id,omitempty"`
 	PayURL       string `json:"pay_url,omitempty"`
 	PrepayID     string `json:"prepay_id,omitempty"`
@@ -64,6 +94,7 @@ type PaymentResult struct {
 	SignType     string `json:"sign_type,omitempty"`
 	PaySign      string `json:"pay_sign,omitempty"`
 	ExpiresAt    string `json:"expires_at,omitempty"`
+	Status       string `json:"status,omitempty"` // set by tenders that charge the payment when it is created
 }
 
 // PaymentQueryResult represents the result of querying payment
@@ -84,11 +115,17 @@ type CallbackResult struct {
 	PaidAt       string  `json:"paid_at"`
 }
 
-// RefundResult represents the result of a refund
+// RefundResult represents the result of a refund. Status is the provider's
+// refund state in WeChat Pay's terms: SUCCESS, PROCESSING, ABNORMAL, CLOSED,
+// or NOTFOUND when the provider has no such refund.
 type RefundResult struct {
-	RefundNo     string `json:"refund_no"`
-	ThirdPartyID string `json:"third_party_id,omitempty"`
-	Status       string `json:"status"`
+	RefundNo     string  `json:"refund_no"`
+	ThirdPartyID string  `json:"third_party_id,omitempty"`
+	Status       string  `json:"status"`
+	PaymentNo    string  `json:"payment_no,omitempty"`
+	Amount       float64 `json:"amount,omitempty"` // Set by notifications and queries
+	SucceededAt  string  `json:"succeeded_at,omitempty"`
+	RawData      string  `json:"-"` // Decrypted notification, kept for auditing
 }
 
 // WechatPayConfig represents WeChat Pay V3 configuration
@@ -101,6 +138,7 @@ type WechatPayConfig struct {
 	Certificate string
 	SerialNo    string
 	NotifyURL   string
+	BaseURL     string // overrides the production/sandbox API host when set
 	Sandbox     bool
 }
 
@@ -119,6 +157,13 @@ func NewWechatPay(config WechatPayConfig, paymentRepo repository.OrderRepository
 		redisClient = redisClients[0]
 	}
 
+	if config.BaseURL == "" {
+		config.BaseURL = wechatAPI
+		if config.Sandbox {
+			config.BaseURL = wechatAPI + "/sandboxnew"
+		}
+	}
+
 	return &wechatPay{
 		config:      config,
 		client:      &http.Client{Timeout: 30 * time.Second},
@@ -127,23 +172,21 @@ func NewWechatPay(config WechatPayConfig, paymentRepo repository.OrderRepository
 	}
 }
 
-// CreatePayment creates a WeChat Pay native/App payment
+// CreatePayment creates a WeChat Pay JSAPI, Native or H5 payment. JSAPI
+// payments need the payer's openid and H5 payments the payer's IP, both
+// passed with WithPayer. The QR code or H5 URL is kept in the payment's
+// ThirdPartyResponse.
 func (w *wechatPay) CreatePayment(ctx context.Context, order *domain.Order, description string) (*PaymentResult, error) {
-	paymentNo := generatePaymentNo()
-
-	// Create payment record in database
-	payment := &domain.Payment{
-		OrderID:       order.ID.String(),
-		PaymentNo:     paymentNo,
-		PaymentMethod: domain.PaymentMethodWechat,
-		Amount:        order.TotalAmount - order.DiscountAmount,
-		Currency:      order.Currency,
-		Status:        domain.PaymentStatusPending,
+	tradeType := channelFrom(ctx)
+	if tradeType == "" {
+		tradeType = WechatTradeJSAPI
 	}
 
-	if err := w.paymentRepo.CreatePayment(ctx, payment); err != nil {
-		return nil, fmt.Errorf("failed to create payment record: %w", err)
-	}
+	paymentNo := generatePaymentNo()
+	amount := amountFrom(ctx, order)
+	code := currencyOrBase(order.Currency)
+	expiresAt := time.Now().Add(30 * time.Minute)
+	payer := payerFrom(ctx)
 
 	// Build payment request
 	params := map[string]interface{}{
@@ -153,33 +196,80 @@ func (w *wechatPay) CreatePayment(ctx context.Context, order *domain.Order, desc
 		"out_trade_no": paymentNo,
 		"notify_url":   w.config.NotifyURL,
 		"amount": map[string]interface{}{
-			"total":    int64(order.TotalAmount * 100), // Convert to cents
-			"currency": order.Currency,
+			"total":    currency.ToMinor(amount, code),
+			"currency": code,
 		},
-		"time_expire": time.Now().Add(30 * time.Minute).Format(time.RFC3339),
+		"time_expire": expiresAt.Format(time.RFC3339),
+	}
+
+	switch tradeType {
+	case WechatTradeJSAPI:
+		if payer.OpenID == "" {
+			return nil, fmt.Errorf("%w: JSAPI payments need the payer's openid", ErrPaymentFailed)
+		}
+		params["payer"] = map[string]interface{}{"openid": payer.OpenID}
+	case WechatTradeH5:
+		if payer.ClientIP == "" {
+			return nil, fmt.Errorf("%w: H5 payments need the payer's IP", ErrPaymentFailed)
+		}
+		params["scene_info"] = map[string]interface{}{
+			"payer_client_ip": payer.ClientIP,
+			"h5_info":         map[string]interface{}{"type": "Wap"},
+		}
+	case WechatTradeNative:
+	default:
+		return nil, fmt.Errorf("%w: WeChat Pay %s", ErrUnsupportedTradeType, tradeType)
 	}
 
 	// Make API request to WeChat Pay
-	result, err := w.request(ctx, "POST", "/v3/pay/transactions/native", params)
+	resp, err := w.request(ctx, "POST", "/v3/pay/transactions/"+tradeType, params)
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse response
-	codeURL, _ := result["code_url"].(string)
-	prepayID, _ := result["prepay_id"].(string)
-
-	return &PaymentResult{
+	result := &PaymentResult{
 		PaymentNo: paymentNo,
-		PayURL:    codeURL,
-		PrepayID:  prepayID,
+		TradeType: tradeType,
 		AppID:     w.config.AppID,
-		Timestamp: fmt.Sprintf("%d", time.Now().Unix()),
-		NonceStr:  generateNonceStr(),
-		Package:   "prepay_id=" + prepayID,
-		SignType:  "RSA",
-		ExpiresAt: time.Now().Add(30 * time.Minute).Format(time.RFC3339),
-	}, nil
+		ExpiresAt: expiresAt.Format(time.RFC3339),
+	}
+	switch tradeType {
+	case WechatTradeJSAPI:
+		result.PrepayID, _ = resp["prepay_id"].(string)
+		result.Timestamp = fmt.Sprintf("%d", time.Now().Unix())
+		result.NonceStr = generateNonceStr()
+		result.Package = "prepay_id=" + result.PrepayID
+		result.SignType = "RSA"
+		result.PaySign = w.sign(fmt.Sprintf("%s\n%s\n%s\n%s\n", result.AppID, result.Timestamp, result.NonceStr, result.Package))
+	case WechatTradeNative:
+		result.QRCodeURL, _ = resp["code_url"].(string)
+	case WechatTradeH5:
+		result.PayURL, _ = resp["h5_url"].(string)
+	}
+
+	raw, _ := json.Marshal(result)
+	payment := &domain.Payment{
+		OrderID:            order.ID.String(),
+		PaymentNo:          paymentNo,
+		PaymentMethod:      domain.PaymentMethodWechat,
+		PaymentChannel:     tradeType,
+		Amount:             amount,
+		Currency:           code,
+		Status:             domain.PaymentStatusPending,
+		ThirdPartyResponse: string(raw),
+	}
+	payment.ApplyExchangeRate(order.BaseCurrency, order.ExchangeRate)
+
+	if err := w.paymentRepo.CreatePayment(ctx, payment); err != nil {
+		return nil, fmt.Errorf("failed to create payment record: %w", err)
+	}
+
+	return result, nil
+}
+
+// TradeTypes lists the WeChat Pay trade types, JSAPI first
+func (w *wechatPay) TradeTypes() []string {
+	return []string{WechatTradeJSAPI, WechatTradeNative, WechatTradeH5}
 }
 
 // QueryPayment queries WeChat Pay transaction status
@@ -196,7 +286,8 @@ func (w *wechatPay) QueryPayment(ctx context.Context, paymentNo string) (*Paymen
 	totalAmount := 0.0
 	if amount != nil {
 		total, _ := amount["total"].(float64)
-		totalAmount = total / 100 // Convert from cents
+		code, _ := amount["currency"].(string)
+		totalAmount = currency.FromMinor(int64(total), currencyOrBase(code))
 	}
 
 	status := w.mapTradeState(tradeState)
@@ -210,55 +301,9 @@ func (w *wechatPay) QueryPayment(ctx context.Context, paymentNo string) (*Paymen
 
 // ProcessCallback processes WeChat Pay notification
 func (w *wechatPay) ProcessCallback(ctx context.Context, body []byte, signature string) (*CallbackResult, error) {
-	var meta struct {
-		Signature string `json:"signature"`
-		Timestamp string `json:"timestamp"`
-		Nonce     string `json:"nonce"`
-		Serial    string `json:"serial"`
-	}
-
-	if err := json.Unmarshal([]byte(signature), &meta); err != nil {
-		return nil, fmt.Errorf("invalid callback metadata: %w", err)
-	}
-
-	if meta.Signature == "" || meta.Timestamp == "" || meta.Nonce == "" || meta.Serial == "" {
-		return nil, ErrInvalidSignature
-	}
-
-	if w.config.SerialNo != "" && meta.Serial != w.config.SerialNo {
-		return nil, ErrInvalidSignature
-	}
-
-	if !w.consumeNonce(ctx, meta.Nonce) {
-		return nil, ErrInvalidSignature
-	}
-
-	// Verify signature
-	if !w.VerifySignatureWithContext(body, meta.Signature, meta.Timestamp, meta.Nonce) {
-		return nil, ErrInvalidSignature
-	}
-
-	// Parse callback body
-	var notification struct {
-		ID         string `json:"id"`
-		CreateTime string `json:"create_time"`
-		EventType  string `json:"event_type"`
-		Resource   struct {
-			Algorithm      string `json:"algorithm"`
-			Ciphertext     string `json:"ciphertext"`
-			AssociatedData string `json:"associated_data"`
-			Nonce          string `json:"nonce"`
-		} `json:"resource"`
-	}
-
-	if err := json.Unmarshal(body, &notification); err != nil {
-		return nil, fmt.Errorf("failed to parse notification: %w", err)
-	}
-
-	// Decrypt resource
-	plaintext, err := w.decrypt(notification.Resource.Ciphertext, notification.Resource.AssociatedData, notification.Resource.Nonce)
+	notification, plaintext, err := w.openNotification(ctx, body, signature)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt notification: %w", err)
+		return nil, err
 	}
 
 	var tradeData struct {
@@ -296,12 +341,72 @@ func (w *wechatPay) ProcessCallback(ctx context.Context, body []byte, signature
 	return &CallbackResult{
 		PaymentNo:    tradeData.OutTradeNo,
 		ThirdPartyID: tradeData.TransactionID,
-		Amount:       float64(tradeData.Amount.Total) / 100,
+		Amount:       currency.FromMinor(int64(tradeData.Amount.Total), currencyOrBase(tradeData.Amount.Currency)),
 		Status:       status,
 		PaidAt:       paidAt,
 	}, nil
 }
 
+// wechatNotification is the envelope of a WeChat Pay V3 notification
+type wechatNotification struct {
+	ID         string `json:"id"`
+	CreateTime string `json:"create_time"`
+	EventType  string `json:"event_type"`
+	Resource   struct {
+		Algorithm      string `json:"algorithm"`
+		Ciphertext     string `json:"ciphertext"`
+		AssociatedData string `json:"associated_data"`
+		Nonce          string `json:"nonce"`
+	} `json:"resource"`
+}
+
+// openNotification verifies a notification's signature and decrypts its
+// resource. The signature argument carries the Wechatpay-* headers as JSON.
+func (w *wechatPay) openNotification(ctx context.Context, body []byte, signature string) (*wechatNotification, []byte, error) {
+	var meta struct {
+		Signature string `json:"signature"`
+		Timestamp string `json:"timestamp"`
+		Nonce     string `json:"nonce"`
+		Serial    string `json:"serial"`
+	}
+
+	if err := json.Unmarshal([]byte(signature), &meta); err != nil {
+		return nil, nil, fmt.Errorf("invalid callback metadata: %w", err)
+	}
+
+	if meta.Signature == "" || meta.Timestamp == "" || meta.Nonce == "" || meta.Serial == "" {
+		return nil, nil, ErrInvalidSignature
+	}
+
+	if w.config.SerialNo != "" && meta.Serial != w.config.SerialNo {
+		return nil, nil, ErrInvalidSignature
+	}
+
+	// A replayed notification has used its nonce already
+	if !isReplay(ctx) && !w.consumeNonce(ctx, meta.Nonce) {
+		return nil, nil, ErrInvalidSignature
+	}
+
+	// Verify signature
+	if !w.VerifySignatureWithContext(body, meta.Signature, meta.Timestamp, meta.Nonce) {
+		return nil, nil, ErrInvalidSignature
+	}
+
+	// Parse callback body
+	var notification wechatNotification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse notification: %w", err)
+	}
+
+	// Decrypt resource
+	plaintext, err := w.decrypt(notification.Resource.Ciphertext, notification.Resource.AssociatedData, notification.Resource.Nonce)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt notification: %w", err)
+	}
+
+	return &notification, plaintext, nil
+}
+
 // VerifySignature verifies WeChat Pay callback signature
 func (w *wechatPay) VerifySignature(body []byte, signature string) bool {
 	return w.VerifySignatureWithContext(body, signature, "", "")
@@ -349,8 +454,8 @@ func (w *wechatPay) Refund(ctx context.Context, payment *domain.Payment, amount
 		"out_refund_no": refundNo,
 		"reason":        reason,
 		"amount": map[string]interface{}{
-			"refund":   int64(amount * 100),
-			"total":    int64(payment.Amount * 100),
+			"refund":   currency.ToMinor(amount, payment.Currency),
+			"total":    currency.ToMinor(payment.Amount, payment.Currency),
 			"currency": payment.Currency,
 		},
 		"notify_url": w.config.NotifyURL + "/refund",
@@ -371,6 +476,67 @@ func (w *wechatPay) Refund(ctx context.Context, payment *domain.Payment, amount
 	}, nil
 }
 
+// ProcessRefundCallback processes a WeChat Pay refund notification
+// (REFUND.SUCCESS, REFUND.ABNORMAL or REFUND.CLOSED)
+func (w *wechatPay) ProcessRefundCallback(ctx context.Context, body []byte, signature string) (*RefundResult, error) {
+	_, plaintext, err := w.openNotification(ctx, body, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	var refundData struct {
+		OutTradeNo   string `json:"out_trade_no"`
+		OutRefundNo  string `json:"out_refund_no"`
+		RefundID     string `json:"refund_id"`
+		RefundStatus string `json:"refund_status"`
+		SuccessTime  string `json:"success_time"`
+		Amount       struct {
+			Refund int64 `json:"refund"`
+		} `json:"amount"`
+	}
+
+	if err := json.Unmarshal(plaintext, &refundData); err != nil {
+		return nil, fmt.Errorf("failed to parse refund data: %w", err)
+	}
+
+	return &RefundResult{
+		RefundNo:     refundData.OutRefundNo,
+		ThirdPartyID: refundData.RefundID,
+		Status:       refundData.RefundStatus,
+		PaymentNo:    refundData.OutTradeNo,
+		Amount:       currency.FromMinor(refundData.Amount.Refund, currencyOrBase("")),
+		SucceededAt:  refundData.SuccessTime,
+		RawData:      string(plaintext),
+	}, nil
+}
+
+// QueryRefund queries a WeChat Pay refund by its merchant refund number
+func (w *wechatPay) QueryRefund(ctx context.Context, payment *domain.Payment, refundNo string) (*RefundResult, error) {
+	result, err := w.request(ctx, "GET", "/v3/refund/domestic/refunds/"+refundNo, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	status, _ := result["status"].(string)
+	refundID, _ := result["refund_id"].(string)
+	successTime, _ := result["success_time"].(string)
+	amount := 0.0
+	if amounts, ok := result["amount"].(map[string]interface{}); ok {
+		refund, _ := amounts["refund"].(float64)
+		code, _ := amounts["currency"].(string)
+		amount = currency.FromMinor(int64(refund), currencyOrBase(code))
+	}
+
+	return &RefundResult{
+		RefundNo:     refundNo,
+		ThirdPartyID: refundID,
+		Status:       status,
+		PaymentNo:    payment.PaymentNo,
+		Amount:       amount,
+		SucceededAt:  successTime,
+	}, nil
+}
+
 // Helper methods
 
 func (w *wechatPay) request(ctx context.Context, method, path string, body interface{}) (map[string]interface{}, error) {
@@ -380,12 +546,7 @@ func (w *wechatPay) request(ctx context.Context, method, path string, body inter
 		bodyReader = bytes.NewReader(jsonBody)
 	}
 
-	baseURL := "https://api.mch.weixin.qq.com"
-	if w.config.Sandbox {
-		baseURL = "https://api.mch.weixin.qq.com/sandboxnew"
-	}
-
-	req, err := http.NewRequestWithContext(ctx, method, baseURL+path, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, w.config.BaseURL+path, bodyReader)
 	if err != nil {
 		return nil, err
 	}
@@ -530,3 +691,12 @@ func (w *wechatPay) consumeNonce(ctx context.Context, nonce string) bool {
 	}
 	return ok
 }
+
+// currencyOrBase returns the currency reported by WeChat Pay, which omits
+// it for CNY transactions
+func currencyOrBase(code string) string {
+	if code == "" {
+		return currency.Base
+	}
+	return code
+}
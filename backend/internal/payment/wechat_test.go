@@ -0,0 +1,156 @@
+package payment
+
+import (
+	"backend/internal/domain"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// wechatStub is a local stand-in for the WeChat Pay V3 API. It records
+// request bodies by path and answers with canned responses.
+type wechatStub struct {
+	t         *testing.T
+	key       *rsa.PrivateKey
+	responses map[string]map[string]interface{}
+	requests  map[string]map[string]interface{}
+}
+
+func newWechatStub(t *testing.T) (*wechatStub, *httptest.Server) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	stub := &wechatStub{
+		t:         t,
+		key:       key,
+		responses: make(map[string]map[string]interface{}),
+		requests:  make(map[string]map[string]interface{}),
+	}
+	server := httptest.NewServer(http.HandlerFunc(stub.serve))
+	t.Cleanup(server.Close)
+	return stub, server
+}
+
+func (s *wechatStub) serve(w http.ResponseWriter, r *http.Request) {
+	assert.True(s.t, strings.HasPrefix(r.Header.Get("Authorization"), "WECHATPAY2-SHA256-RSA2048 "), "authorization")
+
+	var body map[string]interface{}
+	require.NoError(s.t, json.NewDecoder(r.Body).Decode(&body))
+	s.requests[r.URL.Path] = body
+
+	resp, ok := s.responses[r.URL.Path]
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"code":"PARAM_ERROR","message":"参数错误"}`)
+		return
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (s *wechatStub) config(baseURL string) WechatPayConfig {
+	der, err := x509.MarshalPKCS8PrivateKey(s.key)
+	require.NoError(s.t, err)
+	return WechatPayConfig{
+		AppID:      "wx0000000000000001",
+		MchID:      "1900000001",
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		SerialNo:   "SERIAL01",
+		NotifyURL:  "https://example.com/api/v1/payments/callback/wechat",
+		BaseURL:    baseURL,
+	}
+}
+
+func TestWechatPay_CreatePayment(t *testing.T) {
+	stub, server := newWechatStub(t)
+	order := &domain.Order{TotalAmount: 12800, DiscountAmount: 800, Currency: "CNY"}
+	order.ID = uuid.New()
+
+	t.Run("native payment returns the QR code URL", func(t *testing.T) {
+		stub.responses["/v3/pay/transactions/native"] = map[string]interface{}{"code_url": "weixin://wxpay/bizpayurl?pr=abc123"}
+		repo := new(MockPaymentOrderRepository)
+		repo.On("CreatePayment", mock.Anything, mock.MatchedBy(func(p *domain.Payment) bool {
+			return p.PaymentChannel == WechatTradeNative && p.Amount == 12000 && strings.Contains(p.ThirdPartyResponse, "pr=abc123")
+		})).Return(nil)
+		provider := NewWechatPay(stub.config(server.URL), repo)
+
+		result, err := provider.CreatePayment(WithChannel(context.Background(), WechatTradeNative), order, "Cruise booking")
+
+		require.NoError(t, err)
+		assert.Equal(t, WechatTradeNative, result.TradeType)
+		assert.Equal(t, "weixin://wxpay/bizpayurl?pr=abc123", result.QRCodeURL)
+		request := stub.requests["/v3/pay/transactions/native"]
+		assert.Equal(t, result.PaymentNo, request["out_trade_no"])
+		assert.Equal(t, float64(1200000), request["amount"].(map[string]interface{})["total"])
+		assert.NotContains(t, request, "payer")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("H5 payment sends the scene info and returns the H5 URL", func(t *testing.T) {
+		stub.responses["/v3/pay/transactions/h5"] = map[string]interface{}{"h5_url": "https://wx.tenpay.com/cgi-bin/mmpayweb-bin/checkmweb?prepay_id=wx01"}
+		repo := new(MockPaymentOrderRepository)
+		repo.On("CreatePayment", mock.Anything, mock.MatchedBy(func(p *domain.Payment) bool {
+			return p.PaymentChannel == WechatTradeH5
+		})).Return(nil)
+		provider := NewWechatPay(stub.config(server.URL), repo)
+
+		ctx := WithPayer(WithChannel(context.Background(), WechatTradeH5), Payer{ClientIP: "203.0.113.7"})
+		result, err := provider.CreatePayment(ctx, order, "Cruise booking")
+
+		require.NoError(t, err)
+		assert.Equal(t, "https://wx.tenpay.com/cgi-bin/mmpayweb-bin/checkmweb?prepay_id=wx01", result.PayURL)
+		sceneInfo := stub.requests["/v3/pay/transactions/h5"]["scene_info"].(map[string]interface{})
+		assert.Equal(t, "203.0.113.7", sceneInfo["payer_client_ip"])
+		assert.Equal(t, "Wap", sceneInfo["h5_info"].(map[string]interface{})["type"])
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("H5 payment needs the payer's IP", func(t *testing.T) {
+		repo := new(MockPaymentOrderRepository)
+		provider := NewWechatPay(stub.config(server.URL), repo)
+
+		_, err := provider.CreatePayment(WithChannel(context.Background(), WechatTradeH5), order, "Cruise booking")
+
+		assert.ErrorIs(t, err, ErrPaymentFailed)
+		repo.AssertNotCalled(t, "CreatePayment", mock.Anything, mock.Anything)
+	})
+
+	t.Run("JSAPI payment is signed for wx.requestPayment", func(t *testing.T) {
+		stub.responses["/v3/pay/transactions/jsapi"] = map[string]interface{}{"prepay_id": "wx201410272009395522657a690389285100"}
+		repo := new(MockPaymentOrderRepository)
+		repo.On("CreatePayment", mock.Anything, mock.Anything).Return(nil)
+		provider := NewWechatPay(stub.config(server.URL), repo)
+
+		result, err := provider.CreatePayment(WithPayer(context.Background(), Payer{OpenID: "oUpF8uMuAJO_M2pxb1Q9zNjWeS6o"}), order, "Cruise booking")
+
+		require.NoError(t, err)
+		assert.Equal(t, WechatTradeJSAPI, result.TradeType)
+		assert.Equal(t, "prepay_id=wx201410272009395522657a690389285100", result.Package)
+		assert.Equal(t, "oUpF8uMuAJO_M2pxb1Q9zNjWeS6o", stub.requests["/v3/pay/transactions/jsapi"]["payer"].(map[string]interface{})["openid"])
+		message := fmt.Sprintf("%s\n%s\n%s\n%s\n", result.AppID, result.Timestamp, result.NonceStr, result.Package)
+		assert.NoError(t, verifyWith(&stub.key.PublicKey, message, result.PaySign))
+	})
+
+	t.Run("API errors are surfaced", func(t *testing.T) {
+		delete(stub.responses, "/v3/pay/transactions/native")
+		repo := new(MockPaymentOrderRepository)
+		provider := NewWechatPay(stub.config(server.URL), repo)
+
+		_, err := provider.CreatePayment(WithChannel(context.Background(), WechatTradeNative), order, "Cruise booking")
+
+		assert.ErrorContains(t, err, "PARAM_ERROR")
+		repo.AssertNotCalled(t, "CreatePayment", mock.Anything, mock.Anything)
+	})
+}
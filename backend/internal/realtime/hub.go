@@ -0,0 +1,216 @@
+package realtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internal/domain"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATS subjects used for cross-replica fan-out
+const (
+	SubjectInventoryUpdated = "inventory.updated"
+	SubjectViewersUpdated   = "realtime.viewers"
+)
+
+// Message types pushed to WebSocket clients
+const (
+	MessageTypeInventory = "inventory"
+	MessageTypeViewers   = "viewers"
+	MessageTypeError     = "error"
+)
+
+// Message is the envelope sent to WebSocket clients
+type Message struct {
+	Type      string      `json:"type"`
+	VoyageID  string      `json:"voyage_id"`
+	Data      interface{} `json:"data,omitempty"`
+	Timestamp string      `json:"timestamp"`
+}
+
+// InventoryDelta describes a change of cabin inventory for a voyage
+type InventoryDelta struct {
+	VoyageID        string `json:"voyage_id"`
+	CabinTypeID     string `json:"cabin_type_id"`
+	Action          string `json:"action"` // lock, unlock, confirm, cancel, update
+	Quantity        int    `json:"quantity"`
+	TotalCabins     int    `json:"total_cabins"`
+	AvailableCabins int    `json:"available_cabins"`
+	LockedCabins    int    `json:"locked_cabins"`
+	BookedCabins    int    `json:"booked_cabins"`
+}
+
+// InventoryAction constants
+const (
+	InventoryActionLock    = domain.InventoryActionLock
+	InventoryActionUnlock  = domain.InventoryActionUnlock
+	InventoryActionConfirm = domain.InventoryActionConfirm
+	InventoryActionCancel  = domain.InventoryActionCancel
+	InventoryActionUpdate  = domain.InventoryActionUpdate
+)
+
+// ViewerCount describes how many people are viewing a voyage or cabin type
+type ViewerCount struct {
+	VoyageID    string `json:"voyage_id"`
+	CabinTypeID string `json:"cabin_type_id,omitempty"`
+	Count       int64  `json:"count"`
+}
+
+// Client is a single subscriber attached to the hub
+type Client struct {
+	ID       string
+	VoyageID string
+	send     chan []byte
+}
+
+// NewClient creates a client subscribed to a voyage
+func NewClient(id, voyageID string) *Client {
+	return &Client{
+		ID:       id,
+		VoyageID: voyageID,
+		send:     make(chan []byte, 32),
+	}
+}
+
+// Send returns the outbound message channel of the client
+func (c *Client) Send() <-chan []byte {
+	return c.send
+}
+
+// Hub fans out voyage events to locally connected clients.
+// Events are published on NATS so every API replica delivers them to its own
+// clients; without NATS the hub dispatches in-process.
+type Hub struct {
+	natsConn *nats.Conn
+	mu       sync.RWMutex
+	clients  map[string]map[*Client]struct{}
+	subs     []*nats.Subscription
+}
+
+// NewHub creates a new realtime hub
+func NewHub(natsConn *nats.Conn) *Hub {
+	return &Hub{
+		natsConn: natsConn,
+		clients:  make(map[string]map[*Client]struct{}),
+	}
+}
+
+// Start subscribes the hub to NATS subjects
+func (h *Hub) Start() error {
+	if h.natsConn == nil {
+		return nil
+	}
+
+	for _, subject := range []string{SubjectInventoryUpdated, SubjectViewersUpdated} {
+		sub, err := h.natsConn.Subscribe(subject+".*", func(msg *nats.Msg) {
+			voyageID := msg.Subject[strings.LastIndex(msg.Subject, ".")+1:]
+			h.dispatch(voyageID, msg.Data)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to subscribe to %s: %w", subject, err)
+		}
+		h.subs = append(h.subs, sub)
+	}
+
+	log.Println("Realtime hub subscribed to NATS events")
+	return nil
+}
+
+// Stop unsubscribes the hub from NATS
+func (h *Hub) Stop() {
+	for _, sub := range h.subs {
+		_ = sub.Unsubscribe()
+	}
+	h.subs = nil
+}
+
+// Register attaches a client to the hub
+func (h *Hub) Register(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.clients[client.VoyageID] == nil {
+		h.clients[client.VoyageID] = make(map[*Client]struct{})
+	}
+	h.clients[client.VoyageID][client] = struct{}{}
+}
+
+// Unregister detaches a client and closes its send channel
+func (h *Hub) Unregister(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	clients, ok := h.clients[client.VoyageID]
+	if !ok {
+		return
+	}
+	if _, ok := clients[client]; !ok {
+		return
+	}
+
+	delete(clients, client)
+	close(client.send)
+	if len(clients) == 0 {
+		delete(h.clients, client.VoyageID)
+	}
+}
+
+// ClientCount returns the number of local clients subscribed to a voyage
+func (h *Hub) ClientCount(voyageID string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients[voyageID])
+}
+
+// PublishInventory broadcasts an inventory delta to all replicas
+func (h *Hub) PublishInventory(delta InventoryDelta) {
+	h.publish(SubjectInventoryUpdated, MessageTypeInventory, delta.VoyageID, delta)
+}
+
+// PublishViewers broadcasts a viewer count to all replicas
+func (h *Hub) PublishViewers(count ViewerCount) {
+	h.publish(SubjectViewersUpdated, MessageTypeViewers, count.VoyageID, count)
+}
+
+func (h *Hub) publish(subject, messageType, voyageID string, data interface{}) {
+	payload, err := json.Marshal(Message{
+		Type:      messageType,
+		VoyageID:  voyageID,
+		Data:      data,
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		log.Printf("[WARN] Failed to encode realtime message: %v", err)
+		return
+	}
+
+	if h.natsConn == nil {
+		h.dispatch(voyageID, payload)
+		return
+	}
+
+	if err := h.natsConn.Publish(fmt.Sprintf("%s.%s", subject, voyageID), payload); err != nil {
+		log.Printf("[WARN] Failed to publish realtime event %s: %v", subject, err)
+	}
+}
+
+// dispatch delivers a payload to every local client of a voyage.
+// Slow clients are skipped rather than blocking the fan-out.
+func (h *Hub) dispatch(voyageID string, payload []byte) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.clients[voyageID] {
+		select {
+		case client.send <- payload:
+		default:
+			log.Printf("[WARN] Dropping realtime message for slow client %s", client.ID)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package realtime
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receive decodes the next message queued for client
+func receive(t *testing.T, client *Client) Message {
+	t.Helper()
+	select {
+	case payload := <-client.Send():
+		var msg Message
+		require.NoError(t, json.Unmarshal(payload, &msg))
+		return msg
+	default:
+		t.Fatal("no message queued")
+		return Message{}
+	}
+}
+
+func TestHub_BroadcastReachesVoyageClientsOnly(t *testing.T) {
+	hub := NewHub(nil)
+	require.NoError(t, hub.Start())
+	first, second := NewClient("c-1", "voyage-1"), NewClient("c-2", "voyage-1")
+	other := NewClient("c-3", "voyage-2")
+	hub.Register(first)
+	hub.Register(second)
+	hub.Register(other)
+
+	hub.PublishInventory(InventoryDelta{VoyageID: "voyage-1", CabinTypeID: "ct-1", Action: InventoryActionLock, Quantity: 1, AvailableCabins: 4})
+
+	for _, client := range []*Client{first, second} {
+		msg := receive(t, client)
+		assert.Equal(t, MessageTypeInventory, msg.Type)
+		assert.Equal(t, "voyage-1", msg.VoyageID)
+		data := msg.Data.(map[string]interface{})
+		assert.Equal(t, InventoryActionLock, data["action"])
+		assert.Equal(t, 4.0, data["available_cabins"])
+	}
+	assert.Empty(t, other.Send())
+}
+
+func TestHub_PublishViewers(t *testing.T) {
+	hub := NewHub(nil)
+	client := NewClient("c-1", "voyage-1")
+	hub.Register(client)
+
+	hub.PublishViewers(ViewerCount{VoyageID: "voyage-1", CabinTypeID: "ct-1", Count: 3})
+
+	msg := receive(t, client)
+	assert.Equal(t, MessageTypeViewers, msg.Type)
+	assert.Equal(t, 3.0, msg.Data.(map[string]interface{})["count"])
+}
+
+func TestHub_Unregister(t *testing.T) {
+	hub := NewHub(nil)
+	client := NewClient("c-1", "voyage-1")
+	hub.Register(client)
+	assert.Equal(t, 1, hub.ClientCount("voyage-1"))
+
+	hub.Unregister(client)
+	hub.Unregister(client) // a second call is a no-op
+
+	assert.Equal(t, 0, hub.ClientCount("voyage-1"))
+	_, open := <-client.Send()
+	assert.False(t, open)
+	// Later broadcasts skip the client rather than writing to its closed channel
+	hub.PublishInventory(InventoryDelta{VoyageID: "voyage-1"})
+}
+
+func TestHub_SlowClientDoesNotBlock(t *testing.T) {
+	hub := NewHub(nil)
+	slow := NewClient("c-1", "voyage-1")
+	hub.Register(slow)
+
+	for i := 0; i < cap(slow.send)+5; i++ {
+		hub.PublishInventory(InventoryDelta{VoyageID: "voyage-1", Quantity: i})
+	}
+
+	assert.Len(t, slow.send, cap(slow.send))
+}
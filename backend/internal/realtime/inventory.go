@@ -0,0 +1,91 @@
+package realtime
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"context"
+	"log"
+)
+
+// InventoryNotifier publishes inventory changes made outside the wrapped
+// repository, e.g. by a transaction-scoped repository once it commits
+type InventoryNotifier interface {
+	NotifyInventoryChanged(ctx context.Context, voyageID, cabinTypeID, action string, quantity int)
+}
+
+// publishingInventoryRepository decorates an InventoryRepository and pushes
+// an InventoryDelta to the hub after every successful mutation
+type publishingInventoryRepository struct {
+	repository.InventoryRepository
+	hub *Hub
+}
+
+// NewPublishingInventoryRepository wraps repo so inventory changes are broadcast
+func NewPublishingInventoryRepository(repo repository.InventoryRepository, hub *Hub) repository.InventoryRepository {
+	if hub == nil {
+		return repo
+	}
+	return &publishingInventoryRepository{InventoryRepository: repo, hub: hub}
+}
+
+func (r *publishingInventoryRepository) LockCabin(ctx context.Context, voyageID, cabinTypeID string, quantity int) error {
+	if err := r.InventoryRepository.LockCabin(ctx, voyageID, cabinTypeID, quantity); err != nil {
+		return err
+	}
+	r.NotifyInventoryChanged(ctx, voyageID, cabinTypeID, InventoryActionLock, quantity)
+	return nil
+}
+
+func (r *publishingInventoryRepository) UnlockCabin(ctx context.Context, voyageID, cabinTypeID string, quantity int) error {
+	if err := r.InventoryRepository.UnlockCabin(ctx, voyageID, cabinTypeID, quantity); err != nil {
+		return err
+	}
+	r.NotifyInventoryChanged(ctx, voyageID, cabinTypeID, InventoryActionUnlock, quantity)
+	return nil
+}
+
+func (r *publishingInventoryRepository) ConfirmBooking(ctx context.Context, voyageID, cabinTypeID string, quantity int) error {
+	if err := r.InventoryRepository.ConfirmBooking(ctx, voyageID, cabinTypeID, quantity); err != nil {
+		return err
+	}
+	r.NotifyInventoryChanged(ctx, voyageID, cabinTypeID, InventoryActionConfirm, quantity)
+	return nil
+}
+
+func (r *publishingInventoryRepository) CancelBooking(ctx context.Context, voyageID, cabinTypeID string, quantity int) error {
+	if err := r.InventoryRepository.CancelBooking(ctx, voyageID, cabinTypeID, quantity); err != nil {
+		return err
+	}
+	r.NotifyInventoryChanged(ctx, voyageID, cabinTypeID, InventoryActionCancel, quantity)
+	return nil
+}
+
+func (r *publishingInventoryRepository) UpdateInventory(ctx context.Context, inventory *domain.CabinInventory) error {
+	if err := r.InventoryRepository.UpdateInventory(ctx, inventory); err != nil {
+		return err
+	}
+	r.NotifyInventoryChanged(ctx, inventory.VoyageID, inventory.CabinTypeID, InventoryActionUpdate, 0)
+	return nil
+}
+
+// NotifyInventoryChanged reloads the inventory row and broadcasts its counts
+func (r *publishingInventoryRepository) NotifyInventoryChanged(ctx context.Context, voyageID, cabinTypeID, action string, quantity int) {
+	delta := InventoryDelta{
+		VoyageID:    voyageID,
+		CabinTypeID: cabinTypeID,
+		Action:      action,
+		Quantity:    quantity,
+	}
+
+	inventory, err := r.InventoryRepository.GetInventory(ctx, voyageID, cabinTypeID)
+	if err != nil {
+		log.Printf("[WARN] Failed to reload inventory for realtime event: %v", err)
+	} else {
+		delta.TotalCabins = inventory.TotalCabins
+		delta.AvailableCabins = inventory.AvailableCabins
+		delta.LockedCabins = inventory.LockedCabins
+		delta.BookedCabins = inventory.BookedCabins
+	}
+
+	r.hub.PublishInventory(delta)
+}
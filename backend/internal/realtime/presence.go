@@ -0,0 +1,143 @@
+package realtime
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// PresenceTTL is how long a viewer stays counted without a heartbeat
+	PresenceTTL = 45 * time.Second
+
+	presenceKeyPrefix = "realtime:viewers:"
+)
+
+// Presence tracks who is viewing a voyage or cabin type.
+// Viewers are stored in a Redis sorted set scored by expiry so that every API
+// replica sees the same counts and crashed replicas age out automatically.
+type Presence interface {
+	// Touch registers or refreshes a viewer; cabinTypeID may be empty
+	Touch(ctx context.Context, voyageID, cabinTypeID, connID string) error
+
+	// Leave removes a viewer
+	Leave(ctx context.Context, voyageID, cabinTypeID, connID string) error
+
+	// Count returns the number of live viewers; cabinTypeID may be empty
+	Count(ctx context.Context, voyageID, cabinTypeID string) (int64, error)
+}
+
+// redisPresence implements Presence on top of Redis sorted sets
+type redisPresence struct {
+	redis *redis.Client
+	ttl   time.Duration
+}
+
+// memoryPresence implements Presence for single-instance deployments
+type memoryPresence struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	viewers map[string]map[string]time.Time
+}
+
+// NewPresence creates a presence tracker, backed by Redis when available
+func NewPresence(redisClients ...*redis.Client) Presence {
+	if len(redisClients) > 0 && redisClients[0] != nil {
+		return &redisPresence{redis: redisClients[0], ttl: PresenceTTL}
+	}
+	return &memoryPresence{ttl: PresenceTTL, viewers: make(map[string]map[string]time.Time)}
+}
+
+func presenceKey(voyageID, cabinTypeID string) string {
+	if cabinTypeID == "" {
+		return presenceKeyPrefix + voyageID
+	}
+	return fmt.Sprintf("%s%s:%s", presenceKeyPrefix, voyageID, cabinTypeID)
+}
+
+func (p *redisPresence) Touch(ctx context.Context, voyageID, cabinTypeID, connID string) error {
+	expiresAt := float64(time.Now().Add(p.ttl).Unix())
+	pipe := p.redis.TxPipeline()
+	for _, key := range presenceKeys(voyageID, cabinTypeID) {
+		pipe.ZAdd(ctx, key, redis.Z{Score: expiresAt, Member: connID})
+		pipe.Expire(ctx, key, 2*p.ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (p *redisPresence) Leave(ctx context.Context, voyageID, cabinTypeID, connID string) error {
+	pipe := p.redis.TxPipeline()
+	for _, key := range presenceKeys(voyageID, cabinTypeID) {
+		pipe.ZRem(ctx, key, connID)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (p *redisPresence) Count(ctx context.Context, voyageID, cabinTypeID string) (int64, error) {
+	key := presenceKey(voyageID, cabinTypeID)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+
+	pipe := p.redis.TxPipeline()
+	pipe.ZRemRangeByScore(ctx, key, "-inf", "("+now)
+	count := pipe.ZCard(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return count.Val(), nil
+}
+
+func (p *memoryPresence) Touch(ctx context.Context, voyageID, cabinTypeID, connID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	expiresAt := time.Now().Add(p.ttl)
+	for _, key := range presenceKeys(voyageID, cabinTypeID) {
+		if p.viewers[key] == nil {
+			p.viewers[key] = make(map[string]time.Time)
+		}
+		p.viewers[key][connID] = expiresAt
+	}
+	return nil
+}
+
+func (p *memoryPresence) Leave(ctx context.Context, voyageID, cabinTypeID, connID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, key := range presenceKeys(voyageID, cabinTypeID) {
+		delete(p.viewers[key], connID)
+		if len(p.viewers[key]) == 0 {
+			delete(p.viewers, key)
+		}
+	}
+	return nil
+}
+
+func (p *memoryPresence) Count(ctx context.Context, voyageID, cabinTypeID string) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := presenceKey(voyageID, cabinTypeID)
+	now := time.Now()
+	for connID, expiresAt := range p.viewers[key] {
+		if expiresAt.Before(now) {
+			delete(p.viewers[key], connID)
+		}
+	}
+	return int64(len(p.viewers[key])), nil
+}
+
+// presenceKeys returns the voyage-level key plus the cabin-type key if any
+func presenceKeys(voyageID, cabinTypeID string) []string {
+	keys := []string{presenceKey(voyageID, "")}
+	if cabinTypeID != "" {
+		keys = append(keys, presenceKey(voyageID, cabinTypeID))
+	}
+	return keys
+}
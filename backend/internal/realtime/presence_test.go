@@ -0,0 +1,55 @@
+package realtime
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryPresence(t *testing.T) {
+	ctx := context.Background()
+	presence := NewPresence()
+
+	require.NoError(t, presence.Touch(ctx, "voyage-1", "", "conn-1"))
+	require.NoError(t, presence.Touch(ctx, "voyage-1", "ct-1", "conn-2"))
+	require.NoError(t, presence.Touch(ctx, "voyage-1", "ct-1", "conn-2")) // heartbeat
+	require.NoError(t, presence.Touch(ctx, "voyage-2", "", "conn-3"))
+
+	tests := []struct {
+		name                  string
+		voyageID, cabinTypeID string
+		want                  int64
+	}{
+		{"voyage counts viewers of its cabin types", "voyage-1", "", 2},
+		{"cabin type", "voyage-1", "ct-1", 1},
+		{"cabin type without viewers", "voyage-1", "ct-2", 0},
+		{"other voyage", "voyage-2", "", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, err := presence.Count(ctx, tt.voyageID, tt.cabinTypeID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, count)
+		})
+	}
+
+	require.NoError(t, presence.Leave(ctx, "voyage-1", "ct-1", "conn-2"))
+	count, _ := presence.Count(ctx, "voyage-1", "")
+	assert.Equal(t, int64(1), count)
+	count, _ = presence.Count(ctx, "voyage-1", "ct-1")
+	assert.Equal(t, int64(0), count)
+}
+
+func TestMemoryPresence_ViewersExpireWithoutHeartbeat(t *testing.T) {
+	ctx := context.Background()
+	presence := &memoryPresence{ttl: -time.Second, viewers: make(map[string]map[string]time.Time)}
+
+	require.NoError(t, presence.Touch(ctx, "voyage-1", "ct-1", "conn-1"))
+
+	count, err := presence.Count(ctx, "voyage-1", "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
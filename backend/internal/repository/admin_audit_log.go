@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"backend/internal/domain"
+	"backend/internal/pagination"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AdminAuditLogRepository defines the interface for the append-only admin
+// audit log
+type AdminAuditLogRepository interface {
+	Create(ctx context.Context, entry *domain.AdminAuditLog) error
+	GetByID(ctx context.Context, id string) (*domain.AdminAuditLog, error)
+	List(ctx context.Context, filters AdminAuditLogFilters, paginator *pagination.Paginator) ([]*domain.AdminAuditLog, error)
+	// Each calls fn with the matching entries, oldest first, in batches
+	Each(ctx context.Context, filters AdminAuditLogFilters, fn func([]*domain.AdminAuditLog) error) error
+	// DeleteBefore purges entries created before cutoff, returning how many
+	DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// AdminAuditLogFilters represents filters for admin audit log queries
+type AdminAuditLogFilters struct {
+	ActorID  string
+	Resource string
+	TargetID string
+	Method   string
+	Result   string
+	Keyword  string // contained in the path
+	From     *time.Time
+	To       *time.Time
+}
+
+// auditExportBatch is how many entries Each loads at a time
+const auditExportBatch = 500
+
+// adminAuditLogRepository implements AdminAuditLogRepository
+type adminAuditLogRepository struct {
+	db *gorm.DB
+}
+
+// NewAdminAuditLogRepository creates a new admin audit log repository
+func NewAdminAuditLogRepository(db *gorm.DB) AdminAuditLogRepository {
+	return &adminAuditLogRepository{db: db}
+}
+
+func (r *adminAuditLogRepository) Create(ctx context.Context, entry *domain.AdminAuditLog) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *adminAuditLogRepository) GetByID(ctx context.Context, id string) (*domain.AdminAuditLog, error) {
+	var entry domain.AdminAuditLog
+	if err := r.db.WithContext(ctx).First(&entry, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *adminAuditLogRepository) List(ctx context.Context, filters AdminAuditLogFilters, paginator *pagination.Paginator) ([]*domain.AdminAuditLog, error) {
+	query := r.filter(r.db.WithContext(ctx).Model(&domain.AdminAuditLog{}), filters)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+	paginator.SetTotal(total)
+
+	var entries []*domain.AdminAuditLog
+	err := pagination.Paginate(query.Order("created_at DESC"), paginator).Find(&entries).Error
+	return entries, err
+}
+
+func (r *adminAuditLogRepository) Each(ctx context.Context, filters AdminAuditLogFilters, fn func([]*domain.AdminAuditLog) error) error {
+	query := r.filter(r.db.WithContext(ctx).Model(&domain.AdminAuditLog{}), filters)
+
+	var entries []*domain.AdminAuditLog
+	return query.Order("created_at").FindInBatches(&entries, auditExportBatch, func(tx *gorm.DB, batch int) error {
+		return fn(entries)
+	}).Error
+}
+
+func (r *adminAuditLogRepository) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	var deleted int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The table only accepts deletes from the retention purge
+		if err := tx.Exec("SET LOCAL audit.purge = 'on'").Error; err != nil {
+			return err
+		}
+		result := tx.Where("created_at < ?", cutoff).Delete(&domain.AdminAuditLog{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	return deleted, err
+}
+
+func (r *adminAuditLogRepository) filter(query *gorm.DB, filters AdminAuditLogFilters) *gorm.DB {
+	if filters.ActorID != "" {
+		query = query.Where("actor_id = ?", filters.ActorID)
+	}
+	if filters.Resource != "" {
+		query = query.Where("resource = ?", filters.Resource)
+	}
+	if filters.TargetID != "" {
+		query = query.Where("target_id = ?", filters.TargetID)
+	}
+	if filters.Method != "" {
+		query = query.Where("method = ?", filters.Method)
+	}
+	if filters.Result != "" {
+		query = query.Where("result = ?", filters.Result)
+	}
+	if filters.Keyword != "" {
+		query = query.Where("path LIKE ?", "%"+filters.Keyword+"%")
+	}
+	if filters.From != nil {
+		query = query.Where("created_at >= ?", *filters.From)
+	}
+	if filters.To != nil {
+		query = query.Where("created_at < ?", *filters.To)
+	}
+	return query
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"backend/internal/domain"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BookingHistoryRepository reads historical bookings for demand forecasting
+type BookingHistoryRepository interface {
+	// ListVoyages returns voyages departing in [from, to), optionally on one route
+	ListVoyages(ctx context.Context, routeID, from, to string) ([]*domain.Voyage, error)
+
+	// ListBookings returns one record per booked cabin of the voyages
+	ListBookings(ctx context.Context, voyageIDs []string) ([]BookingRecord, error)
+
+	// ListCapacity returns the cabin inventory of the voyages
+	ListCapacity(ctx context.Context, voyageIDs []string) ([]*domain.CabinInventory, error)
+}
+
+// BookingRecord is a single booked cabin with its booking time
+type BookingRecord struct {
+	VoyageID    string
+	CabinTypeID string
+	AdultPrice  float64
+	BookedAt    time.Time
+}
+
+// bookedOrderStatuses are the order states that hold a cabin
+var bookedOrderStatuses = []string{
+	domain.OrderStatusPaid,
+	domain.OrderStatusConfirmed,
+	domain.OrderStatusCompleted,
+}
+
+// bookingHistoryRepository implements BookingHistoryRepository
+type bookingHistoryRepository struct {
+	db *gorm.DB
+}
+
+// NewBookingHistoryRepository creates a new booking history repository
+func NewBookingHistoryRepository(db *gorm.DB) BookingHistoryRepository {
+	return &bookingHistoryRepository{db: db}
+}
+
+func (r *bookingHistoryRepository) ListVoyages(ctx context.Context, routeID, from, to string) ([]*domain.Voyage, error) {
+	query := r.db.WithContext(ctx).Where("departure_date >= ? AND departure_date < ?", from, to)
+	if routeID != "" {
+		query = query.Where("route_id = ?", routeID)
+	}
+
+	var voyages []*domain.Voyage
+	err := query.Order("departure_date ASC").Find(&voyages).Error
+	return voyages, err
+}
+
+func (r *bookingHistoryRepository) ListBookings(ctx context.Context, voyageIDs []string) ([]BookingRecord, error) {
+	records := make([]BookingRecord, 0)
+	if len(voyageIDs) == 0 {
+		return records, nil
+	}
+
+	err := r.db.WithContext(ctx).
+		Table("order_items").
+		Select("order_items.voyage_id, order_items.cabin_type_id, order_items.adult_price, orders.created_at AS booked_at").
+		Joins("JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NULL").
+		Where("order_items.voyage_id IN ?", voyageIDs).
+		Where("order_items.status = ?", domain.OrderItemStatusConfirmed).
+		Where("orders.status IN ?", bookedOrderStatuses).
+		Where("order_items.deleted_at IS NULL").
+		Order("orders.created_at ASC").
+		Scan(&records).Error
+	return records, err
+}
+
+func (r *bookingHistoryRepository) ListCapacity(ctx context.Context, voyageIDs []string) ([]*domain.CabinInventory, error) {
+	var inventories []*domain.CabinInventory
+	if len(voyageIDs) == 0 {
+		return inventories, nil
+	}
+	err := r.db.WithContext(ctx).
+		Where("voyage_id IN ?", voyageIDs).
+		Find(&inventories).Error
+	return inventories, err
+}
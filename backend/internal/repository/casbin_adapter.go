@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
+	"gorm.io/gorm"
+)
+
+// casbinRule is a stored Casbin policy line: the policy type followed by up
+// to six values
+type casbinRule struct {
+	ID    uint   `gorm:"primaryKey;autoIncrement"`
+	Ptype string `gorm:"size:100;not null"`
+	V0    string `gorm:"size:100;not null;default:''"`
+	V1    string `gorm:"size:100;not null;default:''"`
+	V2    string `gorm:"size:100;not null;default:''"`
+	V3    string `gorm:"size:100;not null;default:''"`
+	V4    string `gorm:"size:100;not null;default:''"`
+	V5    string `gorm:"size:100;not null;default:''"`
+}
+
+// TableName returns the table name for casbinRule
+func (casbinRule) TableName() string {
+	return "casbin_rules"
+}
+
+// casbinFields are the value columns of casbin_rules in order
+var casbinFields = []string{"v0", "v1", "v2", "v3", "v4", "v5"}
+
+// casbinAdapter stores Casbin policies in PostgreSQL. It saves each change
+// as it is made, so the enforcer's auto-save keeps the table current
+type casbinAdapter struct {
+	db *gorm.DB
+}
+
+// NewCasbinAdapter creates a Casbin adapter backed by the casbin_rules table
+func NewCasbinAdapter(db *gorm.DB) persist.BatchAdapter {
+	return &casbinAdapter{db: db}
+}
+
+func (a *casbinAdapter) LoadPolicy(m model.Model) error {
+	var rules []casbinRule
+	if err := a.db.Order("id").Find(&rules).Error; err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		if err := persist.LoadPolicyArray(rule.line(), m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *casbinAdapter) SavePolicy(m model.Model) error {
+	var rules []casbinRule
+	for _, sec := range []string{"p", "g"} {
+		for ptype, assertion := range m[sec] {
+			for _, values := range assertion.Policy {
+				rule, err := newCasbinRule(ptype, values)
+				if err != nil {
+					return err
+				}
+				rules = append(rules, rule)
+			}
+		}
+	}
+
+	return a.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&casbinRule{}).Error; err != nil {
+			return err
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+		return tx.Create(&rules).Error
+	})
+}
+
+func (a *casbinAdapter) AddPolicy(sec string, ptype string, values []string) error {
+	return a.AddPolicies(sec, ptype, [][]string{values})
+}
+
+func (a *casbinAdapter) AddPolicies(sec string, ptype string, policies [][]string) error {
+	rules := make([]casbinRule, 0, len(policies))
+	for _, values := range policies {
+		rule, err := newCasbinRule(ptype, values)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, rule)
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	return a.db.Create(&rules).Error
+}
+
+func (a *casbinAdapter) RemovePolicy(sec string, ptype string, values []string) error {
+	return a.RemovePolicies(sec, ptype, [][]string{values})
+}
+
+func (a *casbinAdapter) RemovePolicies(sec string, ptype string, policies [][]string) error {
+	return a.db.Transaction(func(tx *gorm.DB) error {
+		for _, values := range policies {
+			rule, err := newCasbinRule(ptype, values)
+			if err != nil {
+				return err
+			}
+			err = tx.Where("ptype = ? AND v0 = ? AND v1 = ? AND v2 = ? AND v3 = ? AND v4 = ? AND v5 = ?",
+				rule.Ptype, rule.V0, rule.V1, rule.V2, rule.V3, rule.V4, rule.V5).
+				Delete(&casbinRule{}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (a *casbinAdapter) RemoveFilteredPolicy(sec string, ptype string, fieldIndex int, fieldValues ...string) error {
+	if fieldIndex < 0 || fieldIndex+len(fieldValues) > len(casbinFields) {
+		return fmt.Errorf("invalid policy filter at field %d", fieldIndex)
+	}
+
+	query := a.db.Where("ptype = ?", ptype)
+	for i, value := range fieldValues {
+		// An empty value matches anything
+		if value != "" {
+			query = query.Where(casbinFields[fieldIndex+i]+" = ?", value)
+		}
+	}
+	return query.Delete(&casbinRule{}).Error
+}
+
+func newCasbinRule(ptype string, values []string) (casbinRule, error) {
+	if len(values) > len(casbinFields) {
+		return casbinRule{}, errors.New("policy has too many values")
+	}
+
+	padded := make([]string, len(casbinFields))
+	copy(padded, values)
+	return casbinRule{
+		Ptype: ptype,
+		V0:    padded[0],
+		V1:    padded[1],
+		V2:    padded[2],
+		V3:    padded[3],
+		V4:    padded[4],
+		V5:    padded[5],
+	}, nil
+}
+
+// line returns the rule as a policy line: its type and non-empty values
+func (r casbinRule) line() []string {
+	line := []string{r.Ptype, r.V0, r.V1, r.V2, r.V3, r.V4, r.V5}
+	for len(line) > 1 && line[len(line)-1] == "" {
+		line = line[:len(line)-1]
+	}
+	return line
+}
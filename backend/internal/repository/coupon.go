@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"backend/internal/domain"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CouponRepository defines the interface for coupon, wallet and redemption operations
+type CouponRepository interface {
+	Create(ctx context.Context, coupon *domain.Coupon) error
+	GetByID(ctx context.Context, id string) (*domain.Coupon, error)
+	GetByCode(ctx context.Context, code string) (*domain.Coupon, error)
+	List(ctx context.Context, filters CouponFilters) ([]*domain.Coupon, error)
+	Update(ctx context.Context, coupon *domain.Coupon) error
+	Delete(ctx context.Context, id string) error
+
+	// TryIncrementRedeemed counts a redemption unless the global cap is reached
+	TryIncrementRedeemed(ctx context.Context, couponID string) (bool, error)
+	// DecrementRedeemed gives back a released redemption
+	DecrementRedeemed(ctx context.Context, couponID string) error
+
+	// Wallet
+	CreateUserCoupon(ctx context.Context, userCoupon *domain.UserCoupon) error
+	ListUserCoupons(ctx context.Context, userID string) ([]*domain.UserCoupon, error)
+	// GetUsableUserCoupon returns the wallet entry of a coupon that expires first
+	GetUsableUserCoupon(ctx context.Context, userID, couponID string, at time.Time) (*domain.UserCoupon, error)
+	CountUserCoupons(ctx context.Context, userID, couponID string) (int64, error)
+	// MarkUserCouponUsed moves an available wallet entry to used
+	MarkUserCouponUsed(ctx context.Context, id, orderID string, at time.Time) (bool, error)
+	// RestoreUserCoupon moves a used wallet entry back to available
+	RestoreUserCoupon(ctx context.Context, id string) error
+
+	// Redemptions
+	CreateRedemption(ctx context.Context, redemption *domain.CouponRedemption) error
+	ListRedemptionsByOrder(ctx context.Context, orderID, status string) ([]*domain.CouponRedemption, error)
+	// CountActiveRedemptions counts applied redemptions of a coupon by a user
+	CountActiveRedemptions(ctx context.Context, couponID, userID string) (int64, error)
+	// LockUser locks the user's row until the transaction ends, so the
+	// user's redemptions are counted and created one order at a time
+	LockUser(ctx context.Context, userID string) error
+	// ReleaseRedemption moves an applied redemption to released
+	ReleaseRedemption(ctx context.Context, id string, at time.Time) (bool, error)
+
+	// CountPaidOrders counts a user's orders that were paid, for new-user checks
+	CountPaidOrders(ctx context.Context, userID string) (int64, error)
+
+	WithTransaction(ctx context.Context, fn func(repo CouponRepository) error) error
+}
+
+// CouponFilters represents filters for coupon queries
+type CouponFilters struct {
+	Code     string
+	IsActive *bool
+}
+
+// couponRepository implements CouponRepository
+type couponRepository struct {
+	db *gorm.DB
+}
+
+// NewCouponRepository creates a new coupon repository
+func NewCouponRepository(db *gorm.DB) CouponRepository {
+	return &couponRepository{db: db}
+}
+
+func (r *couponRepository) Create(ctx context.Context, coupon *domain.Coupon) error {
+	return r.db.WithContext(ctx).Create(coupon).Error
+}
+
+func (r *couponRepository) GetByID(ctx context.Context, id string) (*domain.Coupon, error) {
+	var coupon domain.Coupon
+	if err := r.db.WithContext(ctx).First(&coupon, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+func (r *couponRepository) GetByCode(ctx context.Context, code string) (*domain.Coupon, error) {
+	var coupon domain.Coupon
+	if err := r.db.WithContext(ctx).First(&coupon, "code = ?", code).Error; err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+func (r *couponRepository) List(ctx context.Context, filters CouponFilters) ([]*domain.Coupon, error) {
+	query := r.db.WithContext(ctx).Model(&domain.Coupon{})
+
+	if filters.Code != "" {
+		query = query.Where("code ILIKE ?", "%"+filters.Code+"%")
+	}
+	if filters.IsActive != nil {
+		query = query.Where("is_active = ?", *filters.IsActive)
+	}
+
+	var coupons []*domain.Coupon
+	err := query.Order("created_at DESC").Find(&coupons).Error
+	return coupons, err
+}
+
+func (r *couponRepository) Update(ctx context.Context, coupon *domain.Coupon) error {
+	return r.db.WithContext(ctx).Save(coupon).Error
+}
+
+func (r *couponRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Delete(&domain.Coupon{}, "id = ?", id).Error
+}
+
+func (r *couponRepository) TryIncrementRedeemed(ctx context.Context, couponID string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.Coupon{}).
+		Where("id = ? AND (total_limit = 0 OR redeemed_count < total_limit)", couponID).
+		Update("redeemed_count", gorm.Expr("redeemed_count + 1"))
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *couponRepository) DecrementRedeemed(ctx context.Context, couponID string) error {
+	return r.db.WithContext(ctx).Model(&domain.Coupon{}).
+		Where("id = ? AND redeemed_count > 0", couponID).
+		Update("redeemed_count", gorm.Expr("redeemed_count - 1")).Error
+}
+
+// ==================== Wallet Operations ====================
+
+func (r *couponRepository) CreateUserCoupon(ctx context.Context, userCoupon *domain.UserCoupon) error {
+	return r.db.WithContext(ctx).Create(userCoupon).Error
+}
+
+func (r *couponRepository) ListUserCoupons(ctx context.Context, userID string) ([]*domain.UserCoupon, error) {
+	var userCoupons []*domain.UserCoupon
+	err := r.db.WithContext(ctx).
+		Preload("Coupon").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&userCoupons).Error
+	return userCoupons, err
+}
+
+func (r *couponRepository) GetUsableUserCoupon(ctx context.Context, userID, couponID string, at time.Time) (*domain.UserCoupon, error) {
+	var userCoupon domain.UserCoupon
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND coupon_id = ? AND status = ?", userID, couponID, domain.UserCouponStatusAvailable).
+		Where("expires_at IS NULL OR expires_at >= ?", at).
+		Order("expires_at ASC NULLS LAST").
+		First(&userCoupon).Error
+	if err != nil {
+		return nil, err
+	}
+	return &userCoupon, nil
+}
+
+func (r *couponRepository) CountUserCoupons(ctx context.Context, userID, couponID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.UserCoupon{}).
+		Where("user_id = ? AND coupon_id = ?", userID, couponID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *couponRepository) MarkUserCouponUsed(ctx context.Context, id, orderID string, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.UserCoupon{}).
+		Where("id = ? AND status = ?", id, domain.UserCouponStatusAvailable).
+		Updates(map[string]interface{}{
+			"status":        domain.UserCouponStatusUsed,
+			"used_order_id": orderID,
+			"used_at":       at,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *couponRepository) RestoreUserCoupon(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Model(&domain.UserCoupon{}).
+		Where("id = ? AND status = ?", id, domain.UserCouponStatusUsed).
+		Updates(map[string]interface{}{
+			"status":        domain.UserCouponStatusAvailable,
+			"used_order_id": nil,
+			"used_at":       nil,
+		}).Error
+}
+
+// ==================== Redemption Operations ====================
+
+func (r *couponRepository) CreateRedemption(ctx context.Context, redemption *domain.CouponRedemption) error {
+	return r.db.WithContext(ctx).Create(redemption).Error
+}
+
+func (r *couponRepository) ListRedemptionsByOrder(ctx context.Context, orderID, status string) ([]*domain.CouponRedemption, error) {
+	query := r.db.WithContext(ctx).Where("order_id = ?", orderID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var redemptions []*domain.CouponRedemption
+	err := query.Order("created_at ASC").Find(&redemptions).Error
+	return redemptions, err
+}
+
+func (r *couponRepository) CountActiveRedemptions(ctx context.Context, couponID, userID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.CouponRedemption{}).
+		Where("coupon_id = ? AND user_id = ? AND status = ?", couponID, userID, domain.CouponRedemptionApplied).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *couponRepository) LockUser(ctx context.Context, userID string) error {
+	var user domain.User
+	return r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		First(&user, "id = ?", userID).Error
+}
+
+func (r *couponRepository) ReleaseRedemption(ctx context.Context, id string, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.CouponRedemption{}).
+		Where("id = ? AND status = ?", id, domain.CouponRedemptionApplied).
+		Updates(map[string]interface{}{
+			"status":      domain.CouponRedemptionReleased,
+			"released_at": at,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *couponRepository) CountPaidOrders(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.Order{}).
+		Where("user_id = ?", userID).
+		Where("status IN ?", []string{
+			domain.OrderStatusPaid,
+			domain.OrderStatusConfirmed,
+			domain.OrderStatusCompleted,
+		}).
+		Count(&count).Error
+	return count, err
+}
+
+// WithTransaction executes fn within a database transaction
+func (r *couponRepository) WithTransaction(ctx context.Context, fn func(repo CouponRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&couponRepository{db: tx})
+	})
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"backend/internal/domain"
+	"backend/internal/pagination"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// InvoiceRepository defines the interface for invoice data access
+type InvoiceRepository interface {
+	Create(ctx context.Context, invoice *domain.Invoice) error
+	GetByID(ctx context.Context, id string) (*domain.Invoice, error)
+	List(ctx context.Context, filters InvoiceFilters, paginator *pagination.Paginator) ([]*domain.Invoice, error)
+	// ListByOrder lists an order's invoices, oldest first
+	ListByOrder(ctx context.Context, orderID string) ([]*domain.Invoice, error)
+	Update(ctx context.Context, invoice *domain.Invoice) error
+}
+
+// InvoiceFilters represents filters for invoice queries
+type InvoiceFilters struct {
+	Status    string
+	Kind      string
+	BuyerType string
+	OrderID   string
+}
+
+// invoiceRepository implements InvoiceRepository
+type invoiceRepository struct {
+	db *gorm.DB
+}
+
+// NewInvoiceRepository creates a new invoice repository
+func NewInvoiceRepository(db *gorm.DB) InvoiceRepository {
+	return &invoiceRepository{db: db}
+}
+
+func (r *invoiceRepository) Create(ctx context.Context, invoice *domain.Invoice) error {
+	return r.db.WithContext(ctx).Create(invoice).Error
+}
+
+func (r *invoiceRepository) GetByID(ctx context.Context, id string) (*domain.Invoice, error) {
+	var invoice domain.Invoice
+	if err := r.db.WithContext(ctx).First(&invoice, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+func (r *invoiceRepository) List(ctx context.Context, filters InvoiceFilters, paginator *pagination.Paginator) ([]*domain.Invoice, error) {
+	query := r.db.WithContext(ctx).Model(&domain.Invoice{})
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.Kind != "" {
+		query = query.Where("kind = ?", filters.Kind)
+	}
+	if filters.BuyerType != "" {
+		query = query.Where("buyer_type = ?", filters.BuyerType)
+	}
+	if filters.OrderID != "" {
+		query = query.Where("order_id = ?", filters.OrderID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+	paginator.SetTotal(total)
+
+	// Oldest first, so the review queue is worked in order
+	var invoices []*domain.Invoice
+	err := pagination.Paginate(query.Order("created_at ASC"), paginator).Find(&invoices).Error
+	return invoices, err
+}
+
+func (r *invoiceRepository) ListByOrder(ctx context.Context, orderID string) ([]*domain.Invoice, error) {
+	var invoices []*domain.Invoice
+	err := r.db.WithContext(ctx).
+		Where("order_id = ?", orderID).
+		Order("created_at ASC").
+		Find(&invoices).Error
+	return invoices, err
+}
+
+func (r *invoiceRepository) Update(ctx context.Context, invoice *domain.Invoice) error {
+	return r.db.WithContext(ctx).Save(invoice).Error
+}
@@ -8,12 +8,16 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // OrderCoreRepository defines core order CRUD operations
 type OrderCoreRepository interface {
 	Create(ctx context.Context, order *domain.Order) error
 	GetByID(ctx context.Context, id string) (*domain.Order, error)
+	// GetByIDForUpdate gets an order and locks its row until the surrounding
+	// transaction ends
+	GetByIDForUpdate(ctx context.Context, id string) (*domain.Order, error)
 	GetByOrderNumber(ctx context.Context, orderNumber string) (*domain.Order, error)
 	List(ctx context.Context, filters OrderFilters, paginator *pagination.Paginator) ([]*domain.Order, error)
 	Count(ctx context.Context, filters OrderFilters) (int64, error)
@@ -22,6 +26,9 @@ type OrderCoreRepository interface {
 	ListByVoyage(ctx context.Context, voyageID string) ([]*domain.Order, error)
 	Update(ctx context.Context, order *domain.Order) error
 	UpdateStatus(ctx context.Context, id string, status string) error
+	// UpdateStatusFrom moves an order to status only while it is still in
+	// from, reporting whether it did
+	UpdateStatusFrom(ctx context.Context, id string, from, status string) (bool, error)
 	UpdatePaymentStatus(ctx context.Context, id string, paymentStatus string, paidAmount float64) error
 	Delete(ctx context.Context, id string) error
 	GetOrderWithDetails(ctx context.Context, id string) (*domain.Order, error)
@@ -52,8 +59,17 @@ type PassengerRepository interface {
 type PaymentRepository interface {
 	CreatePayment(ctx context.Context, payment *domain.Payment) error
 	GetPaymentByID(ctx context.Context, id string) (*domain.Payment, error)
+	// GetPaymentByIDForUpdate gets a payment and locks its row until the
+	// transaction ends
+	GetPaymentByIDForUpdate(ctx context.Context, id string) (*domain.Payment, error)
 	GetPaymentByNo(ctx context.Context, paymentNo string) (*domain.Payment, error)
 	UpdatePayment(ctx context.Context, payment *domain.Payment) error
+	// ListPaymentsByOrder lists an order's payments in the order they were
+	// created
+	ListPaymentsByOrder(ctx context.Context, orderID string) ([]*domain.Payment, error)
+	// ListPendingPayments lists in-flight payments created within [from, to),
+	// oldest first
+	ListPendingPayments(ctx context.Context, from, to time.Time, limit int) ([]*domain.Payment, error)
 }
 
 // RefundRepository defines refund operations
@@ -62,6 +78,26 @@ type RefundRepository interface {
 	GetRefundRequestByID(ctx context.Context, id string) (*domain.RefundRequest, error)
 	ListRefundRequests(ctx context.Context, filters RefundFilters, paginator *pagination.Paginator) ([]*domain.RefundRequest, error)
 	UpdateRefundRequest(ctx context.Context, refund *domain.RefundRequest) error
+
+	CreatePaymentRefund(ctx context.Context, refund *domain.PaymentRefund) error
+	GetPaymentRefundByID(ctx context.Context, id string) (*domain.PaymentRefund, error)
+	// GetPaymentRefundByIDForUpdate gets a provider refund and locks its row
+	// until the transaction ends
+	GetPaymentRefundByIDForUpdate(ctx context.Context, id string) (*domain.PaymentRefund, error)
+	GetPaymentRefundByNo(ctx context.Context, refundNo string) (*domain.PaymentRefund, error)
+	UpdatePaymentRefund(ctx context.Context, refund *domain.PaymentRefund) error
+	// ListPaymentRefundsByOrder lists the provider refunds of all of an
+	// order's payments, oldest first
+	ListPaymentRefundsByOrder(ctx context.Context, orderID string) ([]*domain.PaymentRefund, error)
+	// ListProcessingPaymentRefunds lists refunds still awaiting their outcome
+	// that were created before the given time, oldest first
+	ListProcessingPaymentRefunds(ctx context.Context, before time.Time, limit int) ([]*domain.PaymentRefund, error)
+}
+
+// OutboxEventRepository records domain events in the outbox. Call it on the
+// repository passed to WithTransaction so the event commits with the change.
+type OutboxEventRepository interface {
+	AddOutboxEvent(ctx context.Context, event *domain.OutboxEvent) error
 }
 
 // OrderRepository combines all order-related repository interfaces
@@ -72,6 +108,7 @@ type OrderRepository interface {
 	PassengerRepository
 	PaymentRepository
 	RefundRepository
+	OutboxEventRepository
 
 	// DD-004: Transaction support for atomic operations
 	WithTransaction(ctx context.Context, fn func(repo OrderRepository, tx *gorm.DB) error) error
@@ -133,6 +170,17 @@ func (r *orderRepository) GetByID(ctx context.Context, id string) (*domain.Order
 	return &order, nil
 }
 
+func (r *orderRepository) GetByIDForUpdate(ctx context.Context, id string) (*domain.Order, error) {
+	var order domain.Order
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&order, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
 func (r *orderRepository) GetByOrderNumber(ctx context.Context, orderNumber string) (*domain.Order, error) {
 	var order domain.Order
 	err := r.db.WithContext(ctx).
@@ -234,6 +282,20 @@ func (r *orderRepository) Update(ctx context.Context, order *domain.Order) error
 }
 
 func (r *orderRepository) UpdateStatus(ctx context.Context, id string, status string) error {
+	return r.db.WithContext(ctx).Model(&domain.Order{}).
+		Where("id = ?", id).
+		Updates(statusUpdates(status)).Error
+}
+
+func (r *orderRepository) UpdateStatusFrom(ctx context.Context, id string, from, status string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.Order{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(statusUpdates(status))
+	return result.RowsAffected > 0, result.Error
+}
+
+// statusUpdates returns the columns written when an order moves to status
+func statusUpdates(status string) map[string]interface{} {
 	now := getCurrentTimestamp()
 	updates := map[string]interface{}{
 		"status": status,
@@ -244,10 +306,7 @@ func (r *orderRepository) UpdateStatus(ctx context.Context, id string, status st
 	} else if status == domain.OrderStatusCancelled {
 		updates["cancelled_at"] = now
 	}
-
-	return r.db.WithContext(ctx).Model(&domain.Order{}).
-		Where("id = ?", id).
-		Updates(updates).Error
+	return updates
 }
 
 func (r *orderRepository) UpdatePaymentStatus(ctx context.Context, id string, paymentStatus string, paidAmount float64) error {
@@ -382,7 +441,19 @@ func (r *orderRepository) GetOrderWithDetails(ctx context.Context, id string) (*
 
 // ==================== Payment Operations ====================
 
+// CreatePayment creates a payment, recording its base currency equivalent
+// from the rate locked on the order when the caller has not
 func (r *orderRepository) CreatePayment(ctx context.Context, payment *domain.Payment) error {
+	if payment.BaseCurrency == "" {
+		var order domain.Order
+		err := r.db.WithContext(ctx).
+			Select("base_currency", "exchange_rate").
+			First(&order, "id = ?", payment.OrderID).Error
+		if err != nil {
+			return err
+		}
+		payment.ApplyExchangeRate(order.BaseCurrency, order.ExchangeRate)
+	}
 	return r.db.WithContext(ctx).Create(payment).Error
 }
 
@@ -397,6 +468,17 @@ func (r *orderRepository) GetPaymentByID(ctx context.Context, id string) (*domai
 	return &payment, nil
 }
 
+func (r *orderRepository) GetPaymentByIDForUpdate(ctx context.Context, id string) (*domain.Payment, error) {
+	var payment domain.Payment
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&payment, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
 func (r *orderRepository) GetPaymentByNo(ctx context.Context, paymentNo string) (*domain.Payment, error) {
 	var payment domain.Payment
 	err := r.db.WithContext(ctx).
@@ -412,6 +494,26 @@ func (r *orderRepository) UpdatePayment(ctx context.Context, payment *domain.Pay
 	return r.db.WithContext(ctx).Save(payment).Error
 }
 
+func (r *orderRepository) ListPaymentsByOrder(ctx context.Context, orderID string) ([]*domain.Payment, error) {
+	var payments []*domain.Payment
+	err := r.db.WithContext(ctx).
+		Where("order_id = ?", orderID).
+		Order("created_at").
+		Find(&payments).Error
+	return payments, err
+}
+
+func (r *orderRepository) ListPendingPayments(ctx context.Context, from, to time.Time, limit int) ([]*domain.Payment, error) {
+	var payments []*domain.Payment
+	err := r.db.WithContext(ctx).
+		Where("status IN ? AND created_at >= ? AND created_at < ?",
+			[]string{domain.PaymentStatusPending, domain.PaymentStatusProcessing}, from, to).
+		Order("created_at").
+		Limit(limit).
+		Find(&payments).Error
+	return payments, err
+}
+
 // ==================== Refund Operations ====================
 
 func (r *orderRepository) CreateRefundRequest(ctx context.Context, refund *domain.RefundRequest) error {
@@ -461,6 +563,67 @@ func (r *orderRepository) UpdateRefundRequest(ctx context.Context, refund *domai
 	return r.db.WithContext(ctx).Save(refund).Error
 }
 
+func (r *orderRepository) CreatePaymentRefund(ctx context.Context, refund *domain.PaymentRefund) error {
+	return r.db.WithContext(ctx).Create(refund).Error
+}
+
+func (r *orderRepository) GetPaymentRefundByID(ctx context.Context, id string) (*domain.PaymentRefund, error) {
+	var refund domain.PaymentRefund
+	if err := r.db.WithContext(ctx).First(&refund, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (r *orderRepository) GetPaymentRefundByIDForUpdate(ctx context.Context, id string) (*domain.PaymentRefund, error) {
+	var refund domain.PaymentRefund
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&refund, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (r *orderRepository) GetPaymentRefundByNo(ctx context.Context, refundNo string) (*domain.PaymentRefund, error) {
+	var refund domain.PaymentRefund
+	if err := r.db.WithContext(ctx).First(&refund, "refund_no = ?", refundNo).Error; err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (r *orderRepository) UpdatePaymentRefund(ctx context.Context, refund *domain.PaymentRefund) error {
+	return r.db.WithContext(ctx).Save(refund).Error
+}
+
+func (r *orderRepository) ListPaymentRefundsByOrder(ctx context.Context, orderID string) ([]*domain.PaymentRefund, error) {
+	var refunds []*domain.PaymentRefund
+	err := r.db.WithContext(ctx).
+		Joins("JOIN payments ON payments.id = payment_refunds.payment_id").
+		Where("payments.order_id = ?", orderID).
+		Order("payment_refunds.created_at").
+		Find(&refunds).Error
+	return refunds, err
+}
+
+func (r *orderRepository) ListProcessingPaymentRefunds(ctx context.Context, before time.Time, limit int) ([]*domain.PaymentRefund, error) {
+	var refunds []*domain.PaymentRefund
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND created_at < ?", domain.PaymentRefundStatusProcessing, before).
+		Order("created_at").
+		Limit(limit).
+		Find(&refunds).Error
+	return refunds, err
+}
+
+// ==================== Outbox Operations ====================
+
+func (r *orderRepository) AddOutboxEvent(ctx context.Context, event *domain.OutboxEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
 // Helper function
 func getCurrentTimestamp() string {
 	return time.Now().UTC().Format(time.RFC3339)
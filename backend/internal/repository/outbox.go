@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"backend/internal/domain"
+	"backend/internal/pagination"
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OutboxRepository defines the interface for relaying and inspecting outbox
+// events. Events are written through OrderRepository.AddOutboxEvent so they
+// share the transaction of the change they describe.
+type OutboxRepository interface {
+	GetByID(ctx context.Context, id string) (*domain.OutboxEvent, error)
+	List(ctx context.Context, filters OutboxFilters, paginator *pagination.Paginator) ([]*domain.OutboxEvent, error)
+	// ListPending lists unpublished events due by now in publishing order,
+	// leaving out events queued behind a dead event or an event waiting for
+	// its retry of the same aggregate
+	ListPending(ctx context.Context, now time.Time, limit int) ([]*domain.OutboxEvent, error)
+	Update(ctx context.Context, event *domain.OutboxEvent) error
+
+	// WithRelayLock runs fn while holding the relay lock, so one instance
+	// relays at a time and events keep their order. It reports false
+	// without running fn when another instance holds the lock
+	WithRelayLock(ctx context.Context, fn func() error) (bool, error)
+}
+
+// outboxRelayLock names the relay's PostgreSQL advisory lock
+const outboxRelayLock = "outbox_relay"
+
+// OutboxFilters represents filters for outbox event queries
+type OutboxFilters struct {
+	Status        string
+	AggregateType string
+	AggregateID   string
+	Subject       string
+}
+
+// outboxRepository implements OutboxRepository
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+// NewOutboxRepository creates a new outbox repository
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) GetByID(ctx context.Context, id string) (*domain.OutboxEvent, error) {
+	var event domain.OutboxEvent
+	if err := r.db.WithContext(ctx).First(&event, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (r *outboxRepository) List(ctx context.Context, filters OutboxFilters, paginator *pagination.Paginator) ([]*domain.OutboxEvent, error) {
+	query := r.db.WithContext(ctx).Model(&domain.OutboxEvent{})
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.AggregateType != "" {
+		query = query.Where("aggregate_type = ?", filters.AggregateType)
+	}
+	if filters.AggregateID != "" {
+		query = query.Where("aggregate_id = ?", filters.AggregateID)
+	}
+	if filters.Subject != "" {
+		query = query.Where("subject = ?", filters.Subject)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+	paginator.SetTotal(total)
+
+	var events []*domain.OutboxEvent
+	err := pagination.Paginate(query.Order("sequence DESC"), paginator).Find(&events).Error
+	return events, err
+}
+
+func (r *outboxRepository) ListPending(ctx context.Context, now time.Time, limit int) ([]*domain.OutboxEvent, error) {
+	var events []*domain.OutboxEvent
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", domain.OutboxStatusPending, now).
+		Where(`NOT EXISTS (SELECT 1 FROM outbox_events earlier
+			WHERE earlier.aggregate_type = outbox_events.aggregate_type
+			AND earlier.aggregate_id = outbox_events.aggregate_id AND earlier.sequence < outbox_events.sequence
+			AND (earlier.status = ? OR (earlier.status = ? AND earlier.next_attempt_at > ?)))`,
+			domain.OutboxStatusDead, domain.OutboxStatusPending, now).
+		Order("sequence ASC").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+func (r *outboxRepository) Update(ctx context.Context, event *domain.OutboxEvent) error {
+	return r.db.WithContext(ctx).Model(event).
+		Select("status", "attempts", "next_attempt_at", "published_at", "last_error", "updated_at").
+		Updates(event).Error
+}
+
+func (r *outboxRepository) WithRelayLock(ctx context.Context, fn func() error) (bool, error) {
+	var acquired bool
+	// A session lock on one pooled connection, so the relay's updates commit
+	// as they go
+	err := r.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		if err := conn.Raw("SELECT pg_try_advisory_lock(hashtext(?))", outboxRelayLock).Scan(&acquired).Error; err != nil {
+			return err
+		}
+		if !acquired {
+			return nil
+		}
+		defer func() {
+			// Unlock even if ctx is done; the connection returns to the pool
+			unlock := conn.WithContext(context.WithoutCancel(ctx))
+			if err := unlock.Exec("SELECT pg_advisory_unlock(hashtext(?))", outboxRelayLock).Error; err != nil {
+				log.Printf("Failed to release outbox relay lock: %v", err)
+			}
+		}()
+		return fn()
+	})
+	return acquired, err
+}
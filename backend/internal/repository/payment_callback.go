@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"backend/internal/domain"
+	"backend/internal/pagination"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PaymentCallbackRepository defines the interface for the payment callback log
+type PaymentCallbackRepository interface {
+	Create(ctx context.Context, callback *domain.PaymentCallback) error
+	GetByID(ctx context.Context, id string) (*domain.PaymentCallback, error)
+	List(ctx context.Context, filters PaymentCallbackFilters, paginator *pagination.Paginator) ([]*domain.PaymentCallback, error)
+	// UpdateOutcome saves the verification and processing result
+	UpdateOutcome(ctx context.Context, callback *domain.PaymentCallback) error
+}
+
+// PaymentCallbackFilters represents filters for payment callback queries
+type PaymentCallbackFilters struct {
+	Provider   string
+	Kind       string
+	Status     string
+	Verified   *bool
+	Reference  string
+	Keyword    string // contained in the body
+	ReplayOfID string
+	From       *time.Time
+	To         *time.Time
+}
+
+// paymentCallbackRepository implements PaymentCallbackRepository
+type paymentCallbackRepository struct {
+	db *gorm.DB
+}
+
+// NewPaymentCallbackRepository creates a new payment callback repository
+func NewPaymentCallbackRepository(db *gorm.DB) PaymentCallbackRepository {
+	return &paymentCallbackRepository{db: db}
+}
+
+func (r *paymentCallbackRepository) Create(ctx context.Context, callback *domain.PaymentCallback) error {
+	return r.db.WithContext(ctx).Create(callback).Error
+}
+
+func (r *paymentCallbackRepository) GetByID(ctx context.Context, id string) (*domain.PaymentCallback, error) {
+	var callback domain.PaymentCallback
+	if err := r.db.WithContext(ctx).First(&callback, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &callback, nil
+}
+
+func (r *paymentCallbackRepository) List(ctx context.Context, filters PaymentCallbackFilters, paginator *pagination.Paginator) ([]*domain.PaymentCallback, error) {
+	query := r.db.WithContext(ctx).Model(&domain.PaymentCallback{})
+	if filters.Provider != "" {
+		query = query.Where("provider = ?", filters.Provider)
+	}
+	if filters.Kind != "" {
+		query = query.Where("kind = ?", filters.Kind)
+	}
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.Verified != nil {
+		query = query.Where("verified = ?", *filters.Verified)
+	}
+	if filters.Reference != "" {
+		query = query.Where("reference = ?", filters.Reference)
+	}
+	if filters.Keyword != "" {
+		query = query.Where("body LIKE ?", "%"+filters.Keyword+"%")
+	}
+	if filters.ReplayOfID != "" {
+		query = query.Where("replay_of_id = ?", filters.ReplayOfID)
+	}
+	if filters.From != nil {
+		query = query.Where("created_at >= ?", *filters.From)
+	}
+	if filters.To != nil {
+		query = query.Where("created_at < ?", *filters.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+	paginator.SetTotal(total)
+
+	var callbacks []*domain.PaymentCallback
+	err := pagination.Paginate(query.Order("created_at DESC"), paginator).Find(&callbacks).Error
+	return callbacks, err
+}
+
+func (r *paymentCallbackRepository) UpdateOutcome(ctx context.Context, callback *domain.PaymentCallback) error {
+	return r.db.WithContext(ctx).Model(callback).
+		Select("verified", "reference", "status", "error", "processed_at", "updated_at").
+		Updates(callback).Error
+}
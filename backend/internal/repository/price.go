@@ -4,8 +4,11 @@ import (
 	"backend/internal/domain"
 	"backend/internal/pagination"
 	"context"
+	"errors"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // PriceRepository defines the interface for price data operations
@@ -22,8 +25,16 @@ type PriceRepository interface {
 	UpdatePrice(ctx context.Context, id string, adultPrice, childPrice, infantPrice float64) error
 	Delete(ctx context.Context, id string) error
 	BatchCreate(ctx context.Context, prices []*domain.CabinPrice) error
+	// Reprice writes a new price version if the row is still at expectedVersion,
+	// not manually overridden and not on promotion; returns
+	// ErrPriceVersionConflict otherwise
+	Reprice(ctx context.Context, id string, expectedVersion int, adultPrice, childPrice, infantPrice float64, repricedAt string) error
 }
 
+// ErrPriceVersionConflict is returned when a price changed between reading
+// it and writing a new version
+var ErrPriceVersionConflict = errors.New("price version conflict")
+
 // PriceFilters represents filters for price queries
 type PriceFilters struct {
 	VoyageID    string
@@ -43,7 +54,16 @@ func NewPriceRepository(db *gorm.DB) PriceRepository {
 }
 
 func (r *priceRepository) Create(ctx context.Context, price *domain.CabinPrice) error {
-	return r.db.WithContext(ctx).Create(price).Error
+	change := priceChangeFrom(ctx, domain.PriceSourceManual)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if price.Version == 0 {
+			price.Version = 1
+		}
+		if err := tx.Create(price).Error; err != nil {
+			return err
+		}
+		return recordPriceHistory(tx, price, change, time.Now())
+	})
 }
 
 func (r *priceRepository) GetByID(ctx context.Context, id string) (*domain.CabinPrice, error) {
@@ -136,11 +156,23 @@ func (r *priceRepository) ListByVoyageWithTypes(ctx context.Context, voyageID st
 }
 
 func (r *priceRepository) GetCurrentPrice(ctx context.Context, voyageID, cabinTypeID string) (*domain.CabinPrice, error) {
+	// Prefer a promotion running today, then regular rows; promotion rows
+	// outside their window are only used when nothing else exists
+	today := time.Now().Format("2006-01-02")
 	var price domain.CabinPrice
 	err := r.db.WithContext(ctx).
 		Preload("CabinType").
 		Where("voyage_id = ? AND cabin_type_id = ?", voyageID, cabinTypeID).
-		First(&price).Error
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL: `CASE
+				WHEN NOT is_promotion THEN 1
+				WHEN (promotion_start_date = '' OR LEFT(promotion_start_date, 10) <= ?)
+					AND (promotion_end_date = '' OR LEFT(promotion_end_date, 10) >= ?) THEN 0
+				ELSE 2 END, created_at DESC`,
+			Vars:               []interface{}{today, today},
+			WithoutParentheses: true,
+		}}).
+		Take(&price).Error
 	if err != nil {
 		return nil, err
 	}
@@ -148,24 +180,116 @@ func (r *priceRepository) GetCurrentPrice(ctx context.Context, voyageID, cabinTy
 }
 
 func (r *priceRepository) Update(ctx context.Context, price *domain.CabinPrice) error {
-	return r.db.WithContext(ctx).Save(price).Error
+	change := priceChangeFrom(ctx, domain.PriceSourceManual)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current domain.CabinPrice
+		if err := tx.First(&current, "id = ?", price.ID).Error; err != nil {
+			return err
+		}
+		if priceChanged(&current, price) && price.Version <= current.Version {
+			price.Version = current.Version + 1
+		}
+		if err := tx.Omit("Voyage", "CabinType").Save(price).Error; err != nil {
+			return err
+		}
+		if price.Version == current.Version {
+			return nil
+		}
+		return recordPriceHistory(tx, price, change, time.Now())
+	})
 }
 
 func (r *priceRepository) UpdatePrice(ctx context.Context, id string, adultPrice, childPrice, infantPrice float64) error {
-	updates := map[string]interface{}{
-		"adult_price":  adultPrice,
-		"child_price":  childPrice,
-		"infant_price": infantPrice,
-	}
-	return r.db.WithContext(ctx).Model(&domain.CabinPrice{}).
-		Where("id = ?", id).
-		Updates(updates).Error
+	change := priceChangeFrom(ctx, domain.PriceSourceManual)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"adult_price":  adultPrice,
+			"child_price":  childPrice,
+			"infant_price": infantPrice,
+			"version":      gorm.Expr("version + 1"),
+		}
+		if err := tx.Model(&domain.CabinPrice{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+		return r.recordCurrent(tx, id, change)
+	})
 }
 
 func (r *priceRepository) Delete(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Delete(&domain.CabinPrice{}, "id = ?", id).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&domain.CabinPrice{}, "id = ?", id).Error; err != nil {
+			return err
+		}
+		return closePriceHistory(tx, id, time.Now())
+	})
 }
 
 func (r *priceRepository) BatchCreate(ctx context.Context, prices []*domain.CabinPrice) error {
-	return r.db.WithContext(ctx).Create(prices).Error
+	change := priceChangeFrom(ctx, domain.PriceSourceManual)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, price := range prices {
+			if price.Version == 0 {
+				price.Version = 1
+			}
+		}
+		if err := tx.Create(prices).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, price := range prices {
+			if err := recordPriceHistory(tx, price, change, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *priceRepository) Reprice(ctx context.Context, id string, expectedVersion int, adultPrice, childPrice, infantPrice float64, repricedAt string) error {
+	change := priceChangeFrom(ctx, domain.PriceSourceRule)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.CabinPrice{}).
+			Where("id = ? AND version = ? AND manual_override = ? AND is_promotion = ?", id, expectedVersion, false, false).
+			Updates(map[string]interface{}{
+				// Pin the admin price as base on first repricing so rules never compound
+				"base_adult_price":  gorm.Expr("COALESCE(NULLIF(base_adult_price, 0), adult_price)"),
+				"base_child_price":  gorm.Expr("COALESCE(NULLIF(base_child_price, 0), child_price)"),
+				"base_infant_price": gorm.Expr("COALESCE(NULLIF(base_infant_price, 0), infant_price)"),
+				"adult_price":       adultPrice,
+				"child_price":       childPrice,
+				"infant_price":      infantPrice,
+				"version":           gorm.Expr("version + 1"),
+				"last_repriced_at":  repricedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrPriceVersionConflict
+		}
+		return r.recordCurrent(tx, id, change)
+	})
+}
+
+// recordCurrent reloads a price inside tx and snapshots it into history
+func (r *priceRepository) recordCurrent(tx *gorm.DB, id string, change PriceChange) error {
+	var price domain.CabinPrice
+	if err := tx.First(&price, "id = ?", id).Error; err != nil {
+		return err
+	}
+	return recordPriceHistory(tx, &price, change, time.Now())
+}
+
+// priceChanged reports whether any priced component differs
+func priceChanged(a, b *domain.CabinPrice) bool {
+	return a.AdultPrice != b.AdultPrice ||
+		a.ChildPrice != b.ChildPrice ||
+		a.InfantPrice != b.InfantPrice ||
+		a.SingleSupplement != b.SingleSupplement ||
+		a.ExtraAdultPrice != b.ExtraAdultPrice ||
+		a.ExtraChildPrice != b.ExtraChildPrice ||
+		a.PortFee != b.PortFee ||
+		a.ServiceFee != b.ServiceFee ||
+		a.IsPromotion != b.IsPromotion ||
+		a.PriceType != b.PriceType
 }
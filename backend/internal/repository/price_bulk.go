@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"backend/internal/domain"
+	"backend/internal/pagination"
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PriceBulkRepository persists bulk price edits atomically with their audit record
+type PriceBulkRepository interface {
+	// Apply creates and updates prices and records the operation in one
+	// transaction. Updates carry the version they were planned from plus one;
+	// if any price has moved on since, nothing is written and
+	// ErrPriceVersionConflict is returned
+	Apply(ctx context.Context, op *domain.PriceBulkOperation, creates, updates []*domain.CabinPrice) error
+	ListOperations(ctx context.Context, paginator *pagination.Paginator) ([]*domain.PriceBulkOperation, int64, error)
+}
+
+// priceBulkRepository implements PriceBulkRepository
+type priceBulkRepository struct {
+	db *gorm.DB
+}
+
+// NewPriceBulkRepository creates a new bulk price repository
+func NewPriceBulkRepository(db *gorm.DB) PriceBulkRepository {
+	return &priceBulkRepository{db: db}
+}
+
+func (r *priceBulkRepository) Apply(ctx context.Context, op *domain.PriceBulkOperation, creates, updates []*domain.CabinPrice) error {
+	change := priceChangeFrom(ctx, domain.PriceSourceManual)
+	now := time.Now()
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(creates) > 0 {
+			if err := tx.Omit("Voyage", "CabinType").Create(creates).Error; err != nil {
+				return err
+			}
+		}
+		for _, price := range updates {
+			result := tx.Model(price).
+				Where("version = ?", price.Version-1).
+				Select("*").
+				Omit("ID", "CreatedAt", "Voyage", "CabinType").
+				Updates(price)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("%w: price %s", ErrPriceVersionConflict, price.ID)
+			}
+		}
+		for _, price := range append(creates, updates...) {
+			if err := recordPriceHistory(tx, price, change, now); err != nil {
+				return err
+			}
+		}
+		return tx.Create(op).Error
+	})
+}
+
+func (r *priceBulkRepository) ListOperations(ctx context.Context, paginator *pagination.Paginator) ([]*domain.PriceBulkOperation, int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&domain.PriceBulkOperation{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var ops []*domain.PriceBulkOperation
+	err := r.db.WithContext(ctx).
+		Order("created_at DESC").
+		Offset(paginator.Offset()).
+		Limit(paginator.Limit()).
+		Find(&ops).Error
+	return ops, total, err
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"backend/internal/domain"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PriceHistoryRepository reads versioned cabin price history.
+// History is written by the price repositories on every price change.
+type PriceHistoryRepository interface {
+	// ListByPrice returns all versions of a price, oldest first
+	ListByPrice(ctx context.Context, priceID string) ([]*domain.CabinPriceHistory, error)
+
+	// ListByVoyages returns all versions for the voyages, oldest first
+	ListByVoyages(ctx context.Context, voyageIDs []string) ([]*domain.CabinPriceHistory, error)
+}
+
+// PriceChange attributes a price write to a source and actor
+type PriceChange struct {
+	Source  string
+	ActorID *string
+	Note    string
+}
+
+type priceChangeKey struct{}
+
+// WithPriceChange attaches attribution to ctx for the price writes made with it
+func WithPriceChange(ctx context.Context, source, actorID, note string) context.Context {
+	change := PriceChange{Source: source, Note: note}
+	if actorID != "" {
+		change.ActorID = &actorID
+	}
+	return context.WithValue(ctx, priceChangeKey{}, change)
+}
+
+// priceChangeFrom returns the attribution on ctx, defaulting to a manual change
+func priceChangeFrom(ctx context.Context, defaultSource string) PriceChange {
+	if change, ok := ctx.Value(priceChangeKey{}).(PriceChange); ok && change.Source != "" {
+		return change
+	}
+	return PriceChange{Source: defaultSource}
+}
+
+// recordPriceHistory closes the open version of a price and opens a new one
+func recordPriceHistory(tx *gorm.DB, price *domain.CabinPrice, change PriceChange, at time.Time) error {
+	if err := closePriceHistory(tx, price.ID.String(), at); err != nil {
+		return err
+	}
+	return tx.Create(domain.NewCabinPriceHistory(price, change.Source, change.ActorID, change.Note, at)).Error
+}
+
+// closePriceHistory ends the open version of a price
+func closePriceHistory(tx *gorm.DB, priceID string, at time.Time) error {
+	return tx.Model(&domain.CabinPriceHistory{}).
+		Where("price_id = ? AND effective_to IS NULL", priceID).
+		Update("effective_to", at).Error
+}
+
+// priceHistoryRepository implements PriceHistoryRepository
+type priceHistoryRepository struct {
+	db *gorm.DB
+}
+
+// NewPriceHistoryRepository creates a new price history repository
+func NewPriceHistoryRepository(db *gorm.DB) PriceHistoryRepository {
+	return &priceHistoryRepository{db: db}
+}
+
+func (r *priceHistoryRepository) ListByPrice(ctx context.Context, priceID string) ([]*domain.CabinPriceHistory, error) {
+	var history []*domain.CabinPriceHistory
+	err := r.db.WithContext(ctx).
+		Where("price_id = ?", priceID).
+		Order("effective_from ASC, version ASC").
+		Find(&history).Error
+	return history, err
+}
+
+func (r *priceHistoryRepository) ListByVoyages(ctx context.Context, voyageIDs []string) ([]*domain.CabinPriceHistory, error) {
+	var history []*domain.CabinPriceHistory
+	if len(voyageIDs) == 0 {
+		return history, nil
+	}
+	err := r.db.WithContext(ctx).
+		Where("voyage_id IN ?", voyageIDs).
+		Order("effective_from ASC, version ASC").
+		Find(&history).Error
+	return history, err
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"backend/internal/domain"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PriceWatchRepository defines the interface for price watch data operations
+type PriceWatchRepository interface {
+	Create(ctx context.Context, watch *domain.PriceWatch) error
+	GetByID(ctx context.Context, id string) (*domain.PriceWatch, error)
+	ListByUser(ctx context.Context, userID string) ([]*domain.PriceWatch, error)
+	CountByUser(ctx context.Context, userID string) (int64, error)
+	Update(ctx context.Context, watch *domain.PriceWatch) error
+	Delete(ctx context.Context, id string) error
+
+	// ListMatching returns active watches on the voyage or its route for a cabin type
+	ListMatching(ctx context.Context, voyageID, routeID, cabinTypeID string) ([]*domain.PriceWatch, error)
+	// MarkNotified records a notified price unless one as far in the watch's
+	// direction was already recorded; returns false when the watch was
+	// already notified
+	MarkNotified(ctx context.Context, watch *domain.PriceWatch, price float64, at time.Time) (bool, error)
+	// LowestRoutePrice returns the lowest adult price of a cabin type across
+	// the route's voyages, or nil when the route has no prices yet
+	LowestRoutePrice(ctx context.Context, routeID, cabinTypeID string) (*float64, error)
+}
+
+// priceWatchRepository implements PriceWatchRepository
+type priceWatchRepository struct {
+	db *gorm.DB
+}
+
+// NewPriceWatchRepository creates a new price watch repository
+func NewPriceWatchRepository(db *gorm.DB) PriceWatchRepository {
+	return &priceWatchRepository{db: db}
+}
+
+func (r *priceWatchRepository) Create(ctx context.Context, watch *domain.PriceWatch) error {
+	return r.db.WithContext(ctx).Create(watch).Error
+}
+
+func (r *priceWatchRepository) GetByID(ctx context.Context, id string) (*domain.PriceWatch, error) {
+	var watch domain.PriceWatch
+	if err := r.db.WithContext(ctx).First(&watch, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &watch, nil
+}
+
+func (r *priceWatchRepository) ListByUser(ctx context.Context, userID string) ([]*domain.PriceWatch, error) {
+	var watches []*domain.PriceWatch
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&watches).Error
+	return watches, err
+}
+
+func (r *priceWatchRepository) CountByUser(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.PriceWatch{}).
+		Where("user_id = ?", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *priceWatchRepository) Update(ctx context.Context, watch *domain.PriceWatch) error {
+	return r.db.WithContext(ctx).Save(watch).Error
+}
+
+func (r *priceWatchRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Delete(&domain.PriceWatch{}, "id = ?", id).Error
+}
+
+func (r *priceWatchRepository) ListMatching(ctx context.Context, voyageID, routeID, cabinTypeID string) ([]*domain.PriceWatch, error) {
+	var watches []*domain.PriceWatch
+	err := r.db.WithContext(ctx).
+		Where("cabin_type_id = ? AND is_active = ?", cabinTypeID, true).
+		Where("voyage_id = ? OR route_id = ?", voyageID, routeID).
+		Find(&watches).Error
+	return watches, err
+}
+
+func (r *priceWatchRepository) MarkNotified(ctx context.Context, watch *domain.PriceWatch, price float64, at time.Time) (bool, error) {
+	beyond := "last_notified_price > ?"
+	switch watch.Direction {
+	case domain.PriceWatchDirectionRise:
+		beyond = "last_notified_price < ?"
+	case domain.PriceWatchDirectionEither:
+		beyond = "ABS(last_notified_price - baseline_price) < ABS(? - baseline_price)"
+	}
+
+	result := r.db.WithContext(ctx).Model(&domain.PriceWatch{}).
+		Where("id = ? AND (last_notified_price IS NULL OR "+beyond+")", watch.ID, price).
+		Updates(map[string]interface{}{
+			"last_notified_price": price,
+			"last_notified_at":    at,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *priceWatchRepository) LowestRoutePrice(ctx context.Context, routeID, cabinTypeID string) (*float64, error) {
+	var lowest *float64
+	err := r.db.WithContext(ctx).Model(&domain.CabinPrice{}).
+		Select("MIN(cabin_prices.adult_price)").
+		Joins("JOIN voyages ON voyages.id = cabin_prices.voyage_id AND voyages.deleted_at IS NULL").
+		Where("voyages.route_id = ? AND cabin_prices.cabin_type_id = ?", routeID, cabinTypeID).
+		Scan(&lowest).Error
+	return lowest, err
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"backend/internal/domain"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// PricingRuleRepository defines the interface for dynamic pricing rule operations
+type PricingRuleRepository interface {
+	Create(ctx context.Context, rule *domain.PricingRule) error
+	GetByID(ctx context.Context, id string) (*domain.PricingRule, error)
+	List(ctx context.Context, filters PricingRuleFilters) ([]*domain.PricingRule, error)
+	// ListActive returns active rules applicable to a voyage, ordered by priority
+	ListActive(ctx context.Context, voyageID string) ([]*domain.PricingRule, error)
+	Update(ctx context.Context, rule *domain.PricingRule) error
+	Delete(ctx context.Context, id string) error
+}
+
+// PricingRuleFilters represents filters for pricing rule queries
+type PricingRuleFilters struct {
+	VoyageID    string
+	CabinTypeID string
+	IsActive    *bool
+}
+
+// pricingRuleRepository implements PricingRuleRepository
+type pricingRuleRepository struct {
+	db *gorm.DB
+}
+
+// NewPricingRuleRepository creates a new pricing rule repository
+func NewPricingRuleRepository(db *gorm.DB) PricingRuleRepository {
+	return &pricingRuleRepository{db: db}
+}
+
+func (r *pricingRuleRepository) Create(ctx context.Context, rule *domain.PricingRule) error {
+	return r.db.WithContext(ctx).Create(rule).Error
+}
+
+func (r *pricingRuleRepository) GetByID(ctx context.Context, id string) (*domain.PricingRule, error) {
+	var rule domain.PricingRule
+	if err := r.db.WithContext(ctx).First(&rule, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *pricingRuleRepository) List(ctx context.Context, filters PricingRuleFilters) ([]*domain.PricingRule, error) {
+	query := r.db.WithContext(ctx).Model(&domain.PricingRule{})
+
+	if filters.VoyageID != "" {
+		query = query.Where("voyage_id = ?", filters.VoyageID)
+	}
+	if filters.CabinTypeID != "" {
+		query = query.Where("cabin_type_id = ?", filters.CabinTypeID)
+	}
+	if filters.IsActive != nil {
+		query = query.Where("is_active = ?", *filters.IsActive)
+	}
+
+	var rules []*domain.PricingRule
+	err := query.Order("priority ASC, created_at ASC").Find(&rules).Error
+	return rules, err
+}
+
+func (r *pricingRuleRepository) ListActive(ctx context.Context, voyageID string) ([]*domain.PricingRule, error) {
+	var rules []*domain.PricingRule
+	err := r.db.WithContext(ctx).
+		Where("is_active = ?", true).
+		Where("voyage_id IS NULL OR voyage_id = ?", voyageID).
+		Order("priority ASC, created_at ASC").
+		Find(&rules).Error
+	return rules, err
+}
+
+func (r *pricingRuleRepository) Update(ctx context.Context, rule *domain.PricingRule) error {
+	return r.db.WithContext(ctx).Save(rule).Error
+}
+
+func (r *pricingRuleRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Delete(&domain.PricingRule{}, "id = ?", id).Error
+}
@@ -0,0 +1,405 @@
+package repository
+
+import (
+	"backend/internal/domain"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrPromotionStateConflict is returned when a campaign left the expected
+// status before a transition, e.g. because another scheduler instance won
+var ErrPromotionStateConflict = errors.New("promotion campaign status changed")
+
+// PromotionRepository defines the interface for promotion campaign operations
+type PromotionRepository interface {
+	Create(ctx context.Context, campaign *domain.PromotionCampaign) error
+	GetByID(ctx context.Context, id string) (*domain.PromotionCampaign, error)
+	List(ctx context.Context, filters PromotionFilters) ([]*domain.PromotionCampaign, error)
+	Update(ctx context.Context, campaign *domain.PromotionCampaign) error
+	Delete(ctx context.Context, id string) error
+
+	// ListOverlapping returns campaigns whose window intersects [from, to)
+	ListOverlapping(ctx context.Context, from, to time.Time) ([]*domain.PromotionCampaign, error)
+	// ListDue returns scheduled campaigns whose start time has passed
+	ListDue(ctx context.Context, at time.Time) ([]*domain.PromotionCampaign, error)
+	// ListExpiring returns active campaigns whose end time has passed
+	ListExpiring(ctx context.Context, at time.Time) ([]*domain.PromotionCampaign, error)
+	ListPromotionPrices(ctx context.Context, campaignID string) ([]*domain.PromotionPrice, error)
+
+	// Activate switches a scheduled campaign on and discounts its cabin prices
+	// in one transaction. Prices already on promotion are skipped.
+	Activate(ctx context.Context, campaign *domain.PromotionCampaign, at time.Time) ([]*domain.PromotionPrice, error)
+	// Finish moves a scheduled or active campaign to status and restores the
+	// prices it changed in one transaction. Prices edited during the campaign
+	// keep the edit and only lose the promotion flag.
+	Finish(ctx context.Context, campaign *domain.PromotionCampaign, status string, at time.Time) ([]*domain.PromotionPrice, error)
+	// ClearStalePromotions unflags promotion prices outside any campaign whose
+	// promotion end date is before today (YYYY-MM-DD)
+	ClearStalePromotions(ctx context.Context, today string) ([]*domain.CabinPrice, error)
+
+	// Uptake aggregates bookings made on the campaign's prices while it ran
+	Uptake(ctx context.Context, campaignID string) ([]PromotionUptakeRow, error)
+}
+
+// PromotionFilters represents filters for campaign queries
+type PromotionFilters struct {
+	Status string
+}
+
+// PromotionUptakeRow is the booking aggregate of one promoted cabin price
+type PromotionUptakeRow struct {
+	VoyageID       string
+	CabinTypeID    string
+	Orders         int64
+	Cabins         int64
+	Passengers     int64
+	Revenue        float64
+	DiscountAmount float64
+}
+
+// promotionRepository implements PromotionRepository
+type promotionRepository struct {
+	db *gorm.DB
+}
+
+// NewPromotionRepository creates a new promotion repository
+func NewPromotionRepository(db *gorm.DB) PromotionRepository {
+	return &promotionRepository{db: db}
+}
+
+func (r *promotionRepository) Create(ctx context.Context, campaign *domain.PromotionCampaign) error {
+	return r.db.WithContext(ctx).Create(campaign).Error
+}
+
+func (r *promotionRepository) GetByID(ctx context.Context, id string) (*domain.PromotionCampaign, error) {
+	var campaign domain.PromotionCampaign
+	if err := r.db.WithContext(ctx).First(&campaign, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+func (r *promotionRepository) List(ctx context.Context, filters PromotionFilters) ([]*domain.PromotionCampaign, error) {
+	query := r.db.WithContext(ctx).Model(&domain.PromotionCampaign{})
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+
+	var campaigns []*domain.PromotionCampaign
+	err := query.Order("starts_at DESC").Find(&campaigns).Error
+	return campaigns, err
+}
+
+func (r *promotionRepository) Update(ctx context.Context, campaign *domain.PromotionCampaign) error {
+	return r.db.WithContext(ctx).Save(campaign).Error
+}
+
+func (r *promotionRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Delete(&domain.PromotionCampaign{}, "id = ?", id).Error
+}
+
+func (r *promotionRepository) ListOverlapping(ctx context.Context, from, to time.Time) ([]*domain.PromotionCampaign, error) {
+	var campaigns []*domain.PromotionCampaign
+	err := r.db.WithContext(ctx).
+		Where("starts_at < ? AND ends_at > ?", to, from).
+		Order("starts_at ASC").
+		Find(&campaigns).Error
+	return campaigns, err
+}
+
+func (r *promotionRepository) ListDue(ctx context.Context, at time.Time) ([]*domain.PromotionCampaign, error) {
+	var campaigns []*domain.PromotionCampaign
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND starts_at <= ?", domain.PromotionStatusScheduled, at).
+		Order("starts_at ASC").
+		Find(&campaigns).Error
+	return campaigns, err
+}
+
+func (r *promotionRepository) ListExpiring(ctx context.Context, at time.Time) ([]*domain.PromotionCampaign, error) {
+	var campaigns []*domain.PromotionCampaign
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND ends_at <= ?", domain.PromotionStatusActive, at).
+		Order("ends_at ASC").
+		Find(&campaigns).Error
+	return campaigns, err
+}
+
+func (r *promotionRepository) ListPromotionPrices(ctx context.Context, campaignID string) ([]*domain.PromotionPrice, error) {
+	var prices []*domain.PromotionPrice
+	err := r.db.WithContext(ctx).
+		Where("campaign_id = ?", campaignID).
+		Order("voyage_id, cabin_type_id").
+		Find(&prices).Error
+	return prices, err
+}
+
+func (r *promotionRepository) Activate(ctx context.Context, campaign *domain.PromotionCampaign, at time.Time) ([]*domain.PromotionPrice, error) {
+	var applied []*domain.PromotionPrice
+	skipped := 0
+	change := PriceChange{Source: domain.PriceSourcePromotion, Note: campaign.Name}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.PromotionCampaign{}).
+			Where("id = ? AND status = ?", campaign.ID, domain.PromotionStatusScheduled).
+			Updates(map[string]interface{}{
+				"status":       domain.PromotionStatusActive,
+				"activated_at": at,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrPromotionStateConflict
+		}
+
+		query := tx.Where("voyage_id IN ?", campaign.GetVoyageIDs())
+		if cabinTypes := campaign.GetCabinTypeIDs(); len(cabinTypes) > 0 {
+			query = query.Where("cabin_type_id IN ?", cabinTypes)
+		}
+		var prices []*domain.CabinPrice
+		if err := query.Find(&prices).Error; err != nil {
+			return err
+		}
+
+		for _, price := range prices {
+			if price.IsPromotion {
+				skipped++
+				continue
+			}
+
+			adult, child, infant := campaign.Discount(price.AdultPrice, price.ChildPrice, price.InfantPrice)
+			promo := &domain.PromotionPrice{
+				CampaignID:          campaign.ID.String(),
+				PriceID:             price.ID.String(),
+				VoyageID:            price.VoyageID,
+				CabinTypeID:         price.CabinTypeID,
+				OriginalAdultPrice:  price.AdultPrice,
+				OriginalChildPrice:  price.ChildPrice,
+				OriginalInfantPrice: price.InfantPrice,
+				PromoAdultPrice:     adult,
+				PromoChildPrice:     child,
+				PromoInfantPrice:    infant,
+				PromoVersion:        price.Version + 1,
+				Status:              domain.PromotionPriceApplied,
+				AppliedAt:           at,
+			}
+
+			// Guard on the version so a concurrent edit is skipped, not overwritten
+			result := tx.Model(&domain.CabinPrice{}).
+				Where("id = ? AND version = ? AND is_promotion = ?", price.ID, price.Version, false).
+				Updates(map[string]interface{}{
+					"adult_price":          adult,
+					"child_price":          child,
+					"infant_price":         infant,
+					"is_promotion":         true,
+					"promotion_start_date": campaign.StartsAt.Format("2006-01-02"),
+					"promotion_end_date":   campaign.EndsAt.Format("2006-01-02"),
+					"version":              promo.PromoVersion,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				skipped++
+				continue
+			}
+
+			if err := tx.Create(promo).Error; err != nil {
+				return err
+			}
+			if err := recordPromotionHistory(tx, price.ID.String(), change, at); err != nil {
+				return err
+			}
+			applied = append(applied, promo)
+		}
+
+		return tx.Model(&domain.PromotionCampaign{}).
+			Where("id = ?", campaign.ID).
+			Updates(map[string]interface{}{
+				"applied_count": len(applied),
+				"skipped_count": skipped,
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	campaign.Status = domain.PromotionStatusActive
+	campaign.ActivatedAt = &at
+	campaign.AppliedCount = len(applied)
+	campaign.SkippedCount = skipped
+	return applied, nil
+}
+
+func (r *promotionRepository) Finish(ctx context.Context, campaign *domain.PromotionCampaign, status string, at time.Time) ([]*domain.PromotionPrice, error) {
+	var restored []*domain.PromotionPrice
+	change := PriceChange{Source: domain.PriceSourcePromotion, Note: campaign.Name + " ended"}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.PromotionCampaign{}).
+			Where("id = ? AND status IN ?", campaign.ID, []string{domain.PromotionStatusScheduled, domain.PromotionStatusActive}).
+			Updates(map[string]interface{}{
+				"status":   status,
+				"ended_at": at,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrPromotionStateConflict
+		}
+
+		var promos []*domain.PromotionPrice
+		if err := tx.Where("campaign_id = ? AND status = ?", campaign.ID, domain.PromotionPriceApplied).
+			Find(&promos).Error; err != nil {
+			return err
+		}
+
+		for _, promo := range promos {
+			updates := map[string]interface{}{
+				"is_promotion":         false,
+				"promotion_start_date": "",
+				"promotion_end_date":   "",
+				"version":              gorm.Expr("version + 1"),
+			}
+			// Only roll back prices nobody touched since the campaign wrote them
+			restore := tx.Model(&domain.CabinPrice{}).
+				Where("id = ? AND version = ?", promo.PriceID, promo.PromoVersion).
+				Updates(withRestoredPrices(updates, promo))
+			if restore.Error != nil {
+				return restore.Error
+			}
+			if restore.RowsAffected == 0 {
+				unflag := tx.Model(&domain.CabinPrice{}).
+					Where("id = ? AND is_promotion = ?", promo.PriceID, true).
+					Updates(updates)
+				if unflag.Error != nil {
+					return unflag.Error
+				}
+				if unflag.RowsAffected == 0 {
+					// Deleted or already unflagged; nothing to snapshot
+					if err := markPromotionRestored(tx, promo, at); err != nil {
+						return err
+					}
+					continue
+				}
+			}
+
+			if err := recordPromotionHistory(tx, promo.PriceID, change, at); err != nil {
+				return err
+			}
+			if err := markPromotionRestored(tx, promo, at); err != nil {
+				return err
+			}
+			restored = append(restored, promo)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	campaign.Status = status
+	campaign.EndedAt = &at
+	return restored, nil
+}
+
+func (r *promotionRepository) ClearStalePromotions(ctx context.Context, today string) ([]*domain.CabinPrice, error) {
+	var cleared []*domain.CabinPrice
+	change := PriceChange{Source: domain.PriceSourcePromotion, Note: "promotion window ended"}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var stale []*domain.CabinPrice
+		err := tx.
+			Where("is_promotion = ? AND promotion_end_date <> '' AND LEFT(promotion_end_date, 10) < ?", true, today).
+			Where("NOT EXISTS (SELECT 1 FROM promotion_prices pp WHERE pp.price_id = cabin_prices.id AND pp.status = ? AND pp.deleted_at IS NULL)",
+				domain.PromotionPriceApplied).
+			Find(&stale).Error
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, price := range stale {
+			result := tx.Model(&domain.CabinPrice{}).
+				Where("id = ? AND version = ?", price.ID, price.Version).
+				Updates(map[string]interface{}{
+					"is_promotion": false,
+					"version":      price.Version + 1,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+			if err := recordPromotionHistory(tx, price.ID.String(), change, now); err != nil {
+				return err
+			}
+			cleared = append(cleared, price)
+		}
+		return nil
+	})
+	return cleared, err
+}
+
+func (r *promotionRepository) Uptake(ctx context.Context, campaignID string) ([]PromotionUptakeRow, error) {
+	var rows []PromotionUptakeRow
+	err := r.db.WithContext(ctx).
+		Table("promotion_prices AS pp").
+		Select(`pp.voyage_id, pp.cabin_type_id,
+			COUNT(DISTINCT o.id) AS orders,
+			COUNT(oi.id) AS cabins,
+			COALESCE(SUM(oi.adult_count + oi.child_count + oi.infant_count), 0) AS passengers,
+			COALESCE(SUM(oi.subtotal), 0) AS revenue,
+			COALESCE(SUM((pp.original_adult_price - oi.adult_price) * oi.adult_count
+				+ (COALESCE(pp.original_child_price, 0) - COALESCE(oi.child_price, 0)) * oi.child_count
+				+ (COALESCE(pp.original_infant_price, 0) - COALESCE(oi.infant_price, 0)) * oi.infant_count), 0) AS discount_amount`).
+		Joins("JOIN order_items oi ON oi.voyage_id = pp.voyage_id AND oi.cabin_type_id = pp.cabin_type_id AND oi.deleted_at IS NULL").
+		Joins("JOIN orders o ON o.id = oi.order_id AND o.deleted_at IS NULL").
+		Where("pp.campaign_id = ? AND pp.deleted_at IS NULL", campaignID).
+		Where("o.status NOT IN ?", []string{domain.OrderStatusCancelled, domain.OrderStatusRefunded}).
+		Where("o.created_at >= pp.applied_at AND (pp.restored_at IS NULL OR o.created_at < pp.restored_at)").
+		Where("oi.adult_price = pp.promo_adult_price").
+		Group("pp.voyage_id, pp.cabin_type_id").
+		Order("revenue DESC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// withRestoredPrices adds the pre-campaign prices to a price update
+func withRestoredPrices(updates map[string]interface{}, promo *domain.PromotionPrice) map[string]interface{} {
+	restored := make(map[string]interface{}, len(updates)+3)
+	for k, v := range updates {
+		restored[k] = v
+	}
+	restored["adult_price"] = promo.OriginalAdultPrice
+	restored["child_price"] = promo.OriginalChildPrice
+	restored["infant_price"] = promo.OriginalInfantPrice
+	return restored
+}
+
+// recordPromotionHistory snapshots the current row of a price changed by a
+// promotion
+func recordPromotionHistory(tx *gorm.DB, priceID string, change PriceChange, at time.Time) error {
+	var price domain.CabinPrice
+	if err := tx.First(&price, "id = ?", priceID).Error; err != nil {
+		return err
+	}
+	return recordPriceHistory(tx, &price, change, at)
+}
+
+func markPromotionRestored(tx *gorm.DB, promo *domain.PromotionPrice, at time.Time) error {
+	promo.Status = domain.PromotionPriceRestored
+	promo.RestoredAt = &at
+	return tx.Model(&domain.PromotionPrice{}).
+		Where("id = ?", promo.ID).
+		Updates(map[string]interface{}{
+			"status":      domain.PromotionPriceRestored,
+			"restored_at": at,
+		}).Error
+}
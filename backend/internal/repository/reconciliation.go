@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"backend/internal/domain"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReconciliationRepository defines the interface for reconciliation data access
+type ReconciliationRepository interface {
+	// CreateRun stores a run together with its items
+	CreateRun(ctx context.Context, run *domain.ReconciliationRun, items []*domain.ReconciliationItem) error
+	GetRun(ctx context.Context, id string) (*domain.ReconciliationRun, error)
+	ListRuns(ctx context.Context, filters ReconciliationFilters) ([]*domain.ReconciliationRun, error)
+
+	// ListItems lists a run's items, optionally only those with one result
+	ListItems(ctx context.Context, runID string, result string) ([]*domain.ReconciliationItem, error)
+
+	// ListSettledPayments lists a provider's successful or refunded payments
+	// paid within [from, to)
+	ListSettledPayments(ctx context.Context, method string, from, to time.Time) ([]*domain.Payment, error)
+
+	// ListPaymentsByReference finds payments by provider transaction ID or
+	// payment number
+	ListPaymentsByReference(ctx context.Context, transactionIDs, paymentNos []string) ([]*domain.Payment, error)
+
+	// ListCompletedRefunds lists a provider's payment refunds that succeeded
+	// within [from, to)
+	ListCompletedRefunds(ctx context.Context, provider string, from, to time.Time) ([]*domain.PaymentRefund, error)
+
+	// ListRefundsByReference finds payment refunds by provider refund ID,
+	// refund number, or the payment number of the refunded payment
+	ListRefundsByReference(ctx context.Context, refundIDs, refundNos, paymentNos []string) ([]*domain.PaymentRefund, error)
+}
+
+// ReconciliationFilters represents filters for run queries
+type ReconciliationFilters struct {
+	Provider string
+	From     string // statement date, YYYY-MM-DD
+	To       string
+}
+
+// reconciliationRepository implements ReconciliationRepository
+type reconciliationRepository struct {
+	db *gorm.DB
+}
+
+// NewReconciliationRepository creates a new reconciliation repository
+func NewReconciliationRepository(db *gorm.DB) ReconciliationRepository {
+	return &reconciliationRepository{db: db}
+}
+
+func (r *reconciliationRepository) CreateRun(ctx context.Context, run *domain.ReconciliationRun, items []*domain.ReconciliationItem) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(run).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		for _, item := range items {
+			item.RunID = run.ID.String()
+		}
+		return tx.CreateInBatches(items, 500).Error
+	})
+}
+
+func (r *reconciliationRepository) GetRun(ctx context.Context, id string) (*domain.ReconciliationRun, error) {
+	var run domain.ReconciliationRun
+	if err := r.db.WithContext(ctx).First(&run, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *reconciliationRepository) ListRuns(ctx context.Context, filters ReconciliationFilters) ([]*domain.ReconciliationRun, error) {
+	query := r.db.WithContext(ctx).Model(&domain.ReconciliationRun{})
+	if filters.Provider != "" {
+		query = query.Where("provider = ?", filters.Provider)
+	}
+	if filters.From != "" {
+		query = query.Where("statement_date >= ?", filters.From)
+	}
+	if filters.To != "" {
+		query = query.Where("statement_date <= ?", filters.To)
+	}
+
+	var runs []*domain.ReconciliationRun
+	err := query.Order("statement_date DESC, created_at DESC").Limit(366).Find(&runs).Error
+	return runs, err
+}
+
+func (r *reconciliationRepository) ListItems(ctx context.Context, runID string, result string) ([]*domain.ReconciliationItem, error) {
+	query := r.db.WithContext(ctx).Where("run_id = ?", runID)
+	if result != "" {
+		query = query.Where("result = ?", result)
+	}
+
+	var items []*domain.ReconciliationItem
+	err := query.Order("kind, result, transaction_id").Find(&items).Error
+	return items, err
+}
+
+func (r *reconciliationRepository) ListSettledPayments(ctx context.Context, method string, from, to time.Time) ([]*domain.Payment, error) {
+	var payments []*domain.Payment
+	err := r.db.WithContext(ctx).
+		Where("payment_method = ? AND status IN ? AND paid_at >= ? AND paid_at < ?",
+			method, []string{domain.PaymentStatusSuccess, domain.PaymentStatusRefunded}, from, to).
+		Find(&payments).Error
+	return payments, err
+}
+
+func (r *reconciliationRepository) ListPaymentsByReference(ctx context.Context, transactionIDs, paymentNos []string) ([]*domain.Payment, error) {
+	match := r.db.Where("1 = 0")
+	if len(transactionIDs) > 0 {
+		match = match.Or("third_party_transaction_id IN ?", transactionIDs)
+	}
+	if len(paymentNos) > 0 {
+		match = match.Or("payment_no IN ?", paymentNos)
+	}
+
+	var payments []*domain.Payment
+	err := r.db.WithContext(ctx).Where(match).Find(&payments).Error
+	return payments, err
+}
+
+func (r *reconciliationRepository) ListCompletedRefunds(ctx context.Context, provider string, from, to time.Time) ([]*domain.PaymentRefund, error) {
+	var refunds []*domain.PaymentRefund
+	err := r.db.WithContext(ctx).
+		Where("provider = ? AND status = ? AND succeeded_at >= ? AND succeeded_at < ?",
+			provider, domain.PaymentRefundStatusSuccess, from, to).
+		Find(&refunds).Error
+	return refunds, err
+}
+
+func (r *reconciliationRepository) ListRefundsByReference(ctx context.Context, refundIDs, refundNos, paymentNos []string) ([]*domain.PaymentRefund, error) {
+	match := r.db.Where("1 = 0")
+	if len(refundIDs) > 0 {
+		match = match.Or("third_party_id IN ?", refundIDs)
+	}
+	if len(refundNos) > 0 {
+		match = match.Or("refund_no IN ?", refundNos)
+	}
+	if len(paymentNos) > 0 {
+		match = match.Or("payment_id IN (?)", r.db.Model(&domain.Payment{}).Select("id").Where("payment_no IN ?", paymentNos))
+	}
+
+	var refunds []*domain.PaymentRefund
+	err := r.db.WithContext(ctx).Where(match).Find(&refunds).Error
+	return refunds, err
+}
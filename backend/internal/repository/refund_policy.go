@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"backend/internal/domain"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// RefundPolicyRepository defines the interface for refund policy operations
+type RefundPolicyRepository interface {
+	Create(ctx context.Context, policy *domain.RefundPolicy) error
+	GetByID(ctx context.Context, id string) (*domain.RefundPolicy, error)
+	List(ctx context.Context, filters RefundPolicyFilters) ([]*domain.RefundPolicy, error)
+	// ListActive returns the active policies, ordered by priority
+	ListActive(ctx context.Context) ([]*domain.RefundPolicy, error)
+	Update(ctx context.Context, policy *domain.RefundPolicy) error
+	// CreateVersion stores next and retires previous in one transaction
+	CreateVersion(ctx context.Context, previous, next *domain.RefundPolicy) error
+}
+
+// RefundPolicyFilters represents filters for refund policy queries
+type RefundPolicyFilters struct {
+	Code     string
+	RouteID  string
+	CruiseID string
+	IsActive *bool
+	// AllVersions includes versions that have been superseded
+	AllVersions bool
+}
+
+// refundPolicyRepository implements RefundPolicyRepository
+type refundPolicyRepository struct {
+	db *gorm.DB
+}
+
+// NewRefundPolicyRepository creates a new refund policy repository
+func NewRefundPolicyRepository(db *gorm.DB) RefundPolicyRepository {
+	return &refundPolicyRepository{db: db}
+}
+
+func (r *refundPolicyRepository) Create(ctx context.Context, policy *domain.RefundPolicy) error {
+	return r.db.WithContext(ctx).Create(policy).Error
+}
+
+func (r *refundPolicyRepository) GetByID(ctx context.Context, id string) (*domain.RefundPolicy, error) {
+	var policy domain.RefundPolicy
+	if err := r.db.WithContext(ctx).First(&policy, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (r *refundPolicyRepository) List(ctx context.Context, filters RefundPolicyFilters) ([]*domain.RefundPolicy, error) {
+	query := r.db.WithContext(ctx).Model(&domain.RefundPolicy{})
+
+	if filters.Code != "" {
+		query = query.Where("code = ?", filters.Code)
+	}
+	if filters.RouteID != "" {
+		query = query.Where("route_id = ?", filters.RouteID)
+	}
+	if filters.CruiseID != "" {
+		query = query.Where("cruise_id = ?", filters.CruiseID)
+	}
+	if filters.IsActive != nil {
+		query = query.Where("is_active = ?", *filters.IsActive)
+	}
+	if !filters.AllVersions {
+		query = query.Where("superseded_by IS NULL")
+	}
+
+	var policies []*domain.RefundPolicy
+	err := query.Order("priority ASC, code ASC, version DESC").Find(&policies).Error
+	return policies, err
+}
+
+func (r *refundPolicyRepository) ListActive(ctx context.Context) ([]*domain.RefundPolicy, error) {
+	var policies []*domain.RefundPolicy
+	err := r.db.WithContext(ctx).
+		Where("is_active = ? AND superseded_by IS NULL", true).
+		Order("priority ASC, created_at ASC").
+		Find(&policies).Error
+	return policies, err
+}
+
+func (r *refundPolicyRepository) Update(ctx context.Context, policy *domain.RefundPolicy) error {
+	return r.db.WithContext(ctx).Save(policy).Error
+}
+
+func (r *refundPolicyRepository) CreateVersion(ctx context.Context, previous, next *domain.RefundPolicy) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+		id := next.ID.String()
+		previous.SupersededBy = &id
+		previous.IsActive = false
+		return tx.Save(previous).Error
+	})
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"backend/internal/domain"
+	"backend/internal/pagination"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// StaffRepository defines the interface for staff account and role data
+// operations. Role assignments and permissions are kept by the RBAC
+// policies, not here
+type StaffRepository interface {
+	Create(ctx context.Context, staff *domain.Staff) error
+	GetByID(ctx context.Context, id string) (*domain.Staff, error)
+	GetByUsername(ctx context.Context, username string) (*domain.Staff, error)
+	Update(ctx context.Context, staff *domain.Staff) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, filters StaffFilters, paginator *pagination.Paginator) ([]*domain.Staff, error)
+	Count(ctx context.Context) (int64, error)
+
+	CreateRole(ctx context.Context, role *domain.StaffRole) error
+	GetRole(ctx context.Context, name string) (*domain.StaffRole, error)
+	ListRoles(ctx context.Context) ([]*domain.StaffRole, error)
+	UpdateRole(ctx context.Context, role *domain.StaffRole) error
+	DeleteRole(ctx context.Context, name string) error
+}
+
+// StaffFilters represents filters for staff queries
+type StaffFilters struct {
+	Status  string
+	Keyword string // username or name contains
+}
+
+// staffRepository implements StaffRepository
+type staffRepository struct {
+	db *gorm.DB
+}
+
+// NewStaffRepository creates a new staff repository
+func NewStaffRepository(db *gorm.DB) StaffRepository {
+	return &staffRepository{db: db}
+}
+
+func (r *staffRepository) Create(ctx context.Context, staff *domain.Staff) error {
+	return r.db.WithContext(ctx).Create(staff).Error
+}
+
+func (r *staffRepository) GetByID(ctx context.Context, id string) (*domain.Staff, error) {
+	var staff domain.Staff
+	if err := r.db.WithContext(ctx).First(&staff, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &staff, nil
+}
+
+func (r *staffRepository) GetByUsername(ctx context.Context, username string) (*domain.Staff, error) {
+	var staff domain.Staff
+	if err := r.db.WithContext(ctx).Where("username = ?", username).First(&staff).Error; err != nil {
+		return nil, err
+	}
+	return &staff, nil
+}
+
+func (r *staffRepository) Update(ctx context.Context, staff *domain.Staff) error {
+	return r.db.WithContext(ctx).Save(staff).Error
+}
+
+func (r *staffRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Delete(&domain.Staff{}, "id = ?", id).Error
+}
+
+func (r *staffRepository) List(ctx context.Context, filters StaffFilters, paginator *pagination.Paginator) ([]*domain.Staff, error) {
+	query := r.db.WithContext(ctx).Model(&domain.Staff{})
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.Keyword != "" {
+		keyword := "%" + filters.Keyword + "%"
+		query = query.Where("username ILIKE ? OR name ILIKE ?", keyword, keyword)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+	paginator.SetTotal(total)
+
+	var staff []*domain.Staff
+	err := pagination.Paginate(query.Order("created_at DESC"), paginator).Find(&staff).Error
+	return staff, err
+}
+
+func (r *staffRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.Staff{}).Count(&count).Error
+	return count, err
+}
+
+func (r *staffRepository) CreateRole(ctx context.Context, role *domain.StaffRole) error {
+	return r.db.WithContext(ctx).Create(role).Error
+}
+
+func (r *staffRepository) GetRole(ctx context.Context, name string) (*domain.StaffRole, error) {
+	var role domain.StaffRole
+	if err := r.db.WithContext(ctx).Where("name = ?", name).First(&role).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *staffRepository) ListRoles(ctx context.Context) ([]*domain.StaffRole, error) {
+	var roles []*domain.StaffRole
+	err := r.db.WithContext(ctx).Order("builtin DESC, name ASC").Find(&roles).Error
+	return roles, err
+}
+
+func (r *staffRepository) UpdateRole(ctx context.Context, role *domain.StaffRole) error {
+	return r.db.WithContext(ctx).Save(role).Error
+}
+
+func (r *staffRepository) DeleteRole(ctx context.Context, name string) error {
+	return r.db.WithContext(ctx).Where("name = ?", name).Delete(&domain.StaffRole{}).Error
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"backend/internal/domain"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StoredValueRepository defines balance operations for tenders held by the
+// platform: gift cards and loyalty points
+type StoredValueRepository interface {
+	GetGiftCard(ctx context.Context, code string) (*domain.GiftCard, error)
+	// DebitGiftCard takes amount off a card that is usable at t and holds
+	// enough balance
+	DebitGiftCard(ctx context.Context, code string, amount float64, at time.Time) (bool, error)
+	// CreditGiftCard puts a refunded amount back on a card
+	CreditGiftCard(ctx context.Context, code string, amount float64) error
+
+	GetPoints(ctx context.Context, userID string) (int64, error)
+	// DebitPoints takes points off a user holding enough of them
+	DebitPoints(ctx context.Context, userID string, points int64) (bool, error)
+	// CreditPoints gives refunded points back to a user
+	CreditPoints(ctx context.Context, userID string, points int64) error
+}
+
+// storedValueRepository implements StoredValueRepository
+type storedValueRepository struct {
+	db *gorm.DB
+}
+
+// NewStoredValueRepository creates a new stored value repository
+func NewStoredValueRepository(db *gorm.DB) StoredValueRepository {
+	return &storedValueRepository{db: db}
+}
+
+func (r *storedValueRepository) GetGiftCard(ctx context.Context, code string) (*domain.GiftCard, error) {
+	var card domain.GiftCard
+	if err := r.db.WithContext(ctx).First(&card, "code = ?", code).Error; err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+func (r *storedValueRepository) DebitGiftCard(ctx context.Context, code string, amount float64, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.GiftCard{}).
+		Where("code = ? AND status = ? AND (expires_at IS NULL OR expires_at > ?) AND balance >= ?",
+			code, domain.GiftCardStatusActive, at, amount).
+		Update("balance", gorm.Expr("balance - ?", amount))
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *storedValueRepository) CreditGiftCard(ctx context.Context, code string, amount float64) error {
+	result := r.db.WithContext(ctx).Model(&domain.GiftCard{}).
+		Where("code = ?", code).
+		Update("balance", gorm.Expr("balance + ?", amount))
+	if result.Error == nil && result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return result.Error
+}
+
+func (r *storedValueRepository) GetPoints(ctx context.Context, userID string) (int64, error) {
+	var user domain.User
+	if err := r.db.WithContext(ctx).Select("points").First(&user, "id = ?", userID).Error; err != nil {
+		return 0, err
+	}
+	return user.Points, nil
+}
+
+func (r *storedValueRepository) DebitPoints(ctx context.Context, userID string, points int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.User{}).
+		Where("id = ? AND points >= ?", userID, points).
+		Update("points", gorm.Expr("points - ?", points))
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *storedValueRepository) CreditPoints(ctx context.Context, userID string, points int64) error {
+	result := r.db.WithContext(ctx).Model(&domain.User{}).
+		Where("id = ?", userID).
+		Update("points", gorm.Expr("points + ?", points))
+	if result.Error == nil && result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return result.Error
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // UserRepository defines the interface for user data operations
@@ -18,12 +19,27 @@ type UserRepository interface {
 	GetByEmail(ctx context.Context, email string) (*domain.User, error)
 	GetByUsername(ctx context.Context, username string) (*domain.User, error)
 	GetByWechatOpenID(ctx context.Context, openID string) (*domain.User, error)
+	GetByWechatUnionID(ctx context.Context, unionID string) (*domain.User, error)
+	GetByAlipayUserID(ctx context.Context, alipayUserID string) (*domain.User, error)
 	Update(ctx context.Context, user *domain.User) error
 	Delete(ctx context.Context, id string) error
 	List(ctx context.Context, filters UserFilters, paginator *pagination.Paginator) ([]*domain.User, error)
 	Count(ctx context.Context, filters UserFilters) (int64, error)
 	ListAdminUsers(ctx context.Context) ([]*domain.User, error)
 
+	// Identity operations
+	// UpdateIdentities saves the user's login identities and status
+	UpdateIdentities(ctx context.Context, user *domain.User) error
+	// MergeUsers moves the orders, frequent passengers, notifications, refund
+	// requests, invoices, coupons, price watches and points of source to
+	// target and saves both users' identities, recording the counts on entry,
+	// which is created in the same transaction
+	MergeUsers(ctx context.Context, source, target *domain.User, entry *domain.AccountLinkLog) error
+	CreateAccountLinkLog(ctx context.Context, entry *domain.AccountLinkLog) error
+	// ListAccountLinkLogs lists the audit records of a user, including
+	// merges of the user into another account
+	ListAccountLinkLogs(ctx context.Context, userID string) ([]*domain.AccountLinkLog, error)
+
 	// FrequentPassenger operations
 	CreateFrequentPassenger(ctx context.Context, passenger *domain.FrequentPassenger) error
 	GetFrequentPassengerByID(ctx context.Context, id string) (*domain.FrequentPassenger, error)
@@ -97,6 +113,28 @@ func (r *userRepository) GetByWechatOpenID(ctx context.Context, openID string) (
 	return &user, nil
 }
 
+func (r *userRepository) GetByWechatUnionID(ctx context.Context, unionID string) (*domain.User, error) {
+	var user domain.User
+	err := r.db.WithContext(ctx).
+		Where("wechat_unionid = ?", unionID).
+		First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) GetByAlipayUserID(ctx context.Context, alipayUserID string) (*domain.User, error) {
+	var user domain.User
+	err := r.db.WithContext(ctx).
+		Where("alipay_user_id = ?", alipayUserID).
+		First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (r *userRepository) Update(ctx context.Context, user *domain.User) error {
 	return r.db.WithContext(ctx).Save(user).Error
 }
@@ -198,5 +236,129 @@ func (r *userRepository) ListAdminUsers(ctx context.Context) ([]*domain.User, er
 	return users, err
 }
 
+// ==================== Identity Operations ====================
+
+func (r *userRepository) UpdateIdentities(ctx context.Context, user *domain.User) error {
+	return updateIdentities(r.db.WithContext(ctx), user)
+}
+
+func (r *userRepository) MergeUsers(ctx context.Context, source, target *domain.User, entry *domain.AccountLinkLog) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sourceID, targetID := source.ID.String(), target.ID.String()
+
+		orders := tx.Model(&domain.Order{}).Where("user_id = ?", sourceID).Update("user_id", targetID)
+		if orders.Error != nil {
+			return orders.Error
+		}
+		// The target keeps its own default passenger
+		passengers := tx.Model(&domain.FrequentPassenger{}).Where("user_id = ?", sourceID).
+			Updates(map[string]interface{}{"user_id": targetID, "is_default": false})
+		if passengers.Error != nil {
+			return passengers.Error
+		}
+		notifications := tx.Model(&domain.Notification{}).Where("user_id = ?", sourceID).Update("user_id", targetID)
+		if notifications.Error != nil {
+			return notifications.Error
+		}
+		refunds := tx.Model(&domain.RefundRequest{}).Where("user_id = ?", sourceID).Update("user_id", targetID)
+		if refunds.Error != nil {
+			return refunds.Error
+		}
+		invoices := tx.Model(&domain.Invoice{}).Where("user_id = ?", sourceID).Update("user_id", targetID)
+		if invoices.Error != nil {
+			return invoices.Error
+		}
+		coupons := tx.Model(&domain.UserCoupon{}).Where("user_id = ?", sourceID).Update("user_id", targetID)
+		if coupons.Error != nil {
+			return coupons.Error
+		}
+		// Redemptions move too, so per-user coupon limits count the source's uses
+		if err := tx.Model(&domain.CouponRedemption{}).Where("user_id = ?", sourceID).Update("user_id", targetID).Error; err != nil {
+			return err
+		}
+		watches := tx.Model(&domain.PriceWatch{}).Where("user_id = ?", sourceID).Update("user_id", targetID)
+		if watches.Error != nil {
+			return watches.Error
+		}
+
+		// Points go to the target, and refunds of the source's points payments
+		// are credited there. The source row is locked so points it spends
+		// meanwhile are not counted twice
+		var balance domain.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("points").
+			First(&balance, "id = ?", sourceID).Error; err != nil {
+			return err
+		}
+		if balance.Points > 0 {
+			if err := tx.Model(&domain.User{}).Where("id = ?", targetID).
+				Update("points", gorm.Expr("points + ?", balance.Points)).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&domain.User{}).Where("id = ?", sourceID).Update("points", 0).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&domain.Payment{}).
+			Where("payment_method = ? AND tender_ref = ?", domain.PaymentMethodPoints, sourceID).
+			Update("tender_ref", targetID).Error; err != nil {
+			return err
+		}
+
+		entry.OrdersMoved = int(orders.RowsAffected)
+		entry.PassengersMoved = int(passengers.RowsAffected)
+		entry.NotificationsMoved = int(notifications.RowsAffected)
+		entry.RefundsMoved = int(refunds.RowsAffected)
+		entry.InvoicesMoved = int(invoices.RowsAffected)
+		entry.CouponsMoved = int(coupons.RowsAffected)
+		entry.PriceWatchesMoved = int(watches.RowsAffected)
+		entry.PointsMoved = balance.Points
+		source.Points, target.Points = 0, target.Points+balance.Points
+
+		// The source gives up its identities before the target takes them
+		if err := updateIdentities(tx, source); err != nil {
+			return err
+		}
+		if err := updateIdentities(tx, target); err != nil {
+			return err
+		}
+		return tx.Create(entry).Error
+	})
+}
+
+func (r *userRepository) CreateAccountLinkLog(ctx context.Context, entry *domain.AccountLinkLog) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *userRepository) ListAccountLinkLogs(ctx context.Context, userID string) ([]*domain.AccountLinkLog, error) {
+	var entries []*domain.AccountLinkLog
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? OR merged_user_id = ?", userID, userID).
+		Order("created_at DESC").
+		Find(&entries).Error
+	return entries, err
+}
+
+// updateIdentities saves identity columns, writing unbound identities as
+// NULL so they do not collide under the unique constraints
+func updateIdentities(db *gorm.DB, user *domain.User) error {
+	return db.Model(user).Updates(map[string]interface{}{
+		"phone":             nullIfEmpty(user.Phone),
+		"phone_verified":    user.PhoneVerified,
+		"wechat_openid":     nullIfEmpty(user.WechatOpenID),
+		"wechat_unionid":    nullIfEmpty(user.WechatUnionID),
+		"wechat_nickname":   user.WechatNickname,
+		"wechat_avatar_url": user.WechatAvatarURL,
+		"alipay_user_id":    user.AlipayUserID,
+		"status":            user.Status,
+	}).Error
+}
+
+func nullIfEmpty(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
 // ErrUserNotFound is returned when a user is not found
 var ErrUserNotFound = errors.New("user not found")
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/pagination"
+	"backend/internal/repository"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var ErrAuditLogNotFound = errors.New("audit log entry not found")
+
+// AuditLoader loads the current state of an audited target by ID
+type AuditLoader func(ctx context.Context, id string) (interface{}, error)
+
+// AdminAuditService defines the interface for the back-office audit log
+type AdminAuditService interface {
+	// Snapshot returns the current state of a target as JSON, or nil when
+	// the resource has no loader or the target cannot be loaded
+	Snapshot(ctx context.Context, resource, targetID string) []byte
+	// Record stores an entry, working out which fields changed between its
+	// before and after snapshots
+	Record(ctx context.Context, entry *domain.AdminAuditLog) error
+
+	ListLogs(ctx context.Context, filters repository.AdminAuditLogFilters, paginator *pagination.Paginator) ([]*domain.AdminAuditLog, error)
+	GetLog(ctx context.Context, id string) (*domain.AdminAuditLog, error)
+	// Export writes the matching entries as CSV, oldest first
+	Export(ctx context.Context, filters repository.AdminAuditLogFilters, w io.Writer) error
+
+	// Purge deletes entries older than the retention period. Nothing is
+	// deleted when retention is zero
+	Purge(ctx context.Context) (int64, error)
+}
+
+// adminAuditService implements AdminAuditService
+type adminAuditService struct {
+	repo      repository.AdminAuditLogRepository
+	retention time.Duration
+	loaders   map[string]AuditLoader
+	now       func() time.Time
+}
+
+// NewAdminAuditService creates a new admin audit service. Entries are kept
+// for retention; loaders snapshot targets by resource, as the audit
+// middleware names it (e.g. "cruises", "access/staff")
+func NewAdminAuditService(repo repository.AdminAuditLogRepository, retention time.Duration, loaders map[string]AuditLoader) AdminAuditService {
+	return &adminAuditService{repo: repo, retention: retention, loaders: loaders, now: time.Now}
+}
+
+func (s *adminAuditService) Snapshot(ctx context.Context, resource, targetID string) []byte {
+	load, ok := s.loaders[resource]
+	if !ok || targetID == "" {
+		return nil
+	}
+	target, err := load(ctx, targetID)
+	if err != nil {
+		return nil
+	}
+	data, err := json.Marshal(target)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+func (s *adminAuditService) Record(ctx context.Context, entry *domain.AdminAuditLog) error {
+	if diff := auditDiff(entry.Before, entry.After); diff != nil {
+		entry.Diff = diff
+	}
+	if err := s.repo.Create(ctx, entry); err != nil {
+		return fmt.Errorf("failed to record audit log: %w", err)
+	}
+	return nil
+}
+
+func (s *adminAuditService) ListLogs(ctx context.Context, filters repository.AdminAuditLogFilters, paginator *pagination.Paginator) ([]*domain.AdminAuditLog, error) {
+	return s.repo.List(ctx, filters, paginator)
+}
+
+func (s *adminAuditService) GetLog(ctx context.Context, id string) (*domain.AdminAuditLog, error) {
+	entry, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrAuditLogNotFound
+	}
+	return entry, nil
+}
+
+func (s *adminAuditService) Export(ctx context.Context, filters repository.AdminAuditLogFilters, w io.Writer) error {
+	// Excel needs the BOM to read UTF-8
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	header := []string{"时间", "操作人ID", "操作人", "角色", "IP", "方法", "路由", "路径", "对象类型", "对象ID", "结果", "状态码", "失败原因", "变更"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	err := s.repo.Each(ctx, filters, func(entries []*domain.AdminAuditLog) error {
+		for _, entry := range entries {
+			row := []string{
+				entry.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+				entry.ActorID, entry.ActorName, entry.ActorRole, entry.IP,
+				entry.Method, entry.Route, entry.Path, entry.Resource, entry.TargetID,
+				entry.Result, strconv.Itoa(entry.StatusCode), entry.Error, string(entry.Diff),
+			}
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func (s *adminAuditService) Purge(ctx context.Context) (int64, error) {
+	if s.retention <= 0 {
+		return 0, nil
+	}
+	return s.repo.DeleteBefore(ctx, s.now().Add(-s.retention))
+}
+
+// auditDiff lists the top-level fields that differ between two JSON object
+// snapshots as {"field": {"from": .., "to": ..}}. It returns nil unless
+// both snapshots are objects and something changed
+func auditDiff(before, after []byte) []byte {
+	if len(before) == 0 || len(after) == 0 {
+		return nil
+	}
+
+	var from, to map[string]interface{}
+	if json.Unmarshal(before, &from) != nil || json.Unmarshal(after, &to) != nil {
+		return nil
+	}
+
+	type change struct {
+		From interface{} `json:"from"`
+		To   interface{} `json:"to"`
+	}
+	changes := map[string]change{}
+	for field, value := range from {
+		if field == "updated_at" {
+			continue
+		}
+		if next, ok := to[field]; !ok || !reflect.DeepEqual(value, next) {
+			changes[field] = change{From: value, To: to[field]}
+		}
+	}
+	for field, value := range to {
+		if _, ok := from[field]; !ok && field != "updated_at" {
+			changes[field] = change{To: value}
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	diff, err := json.Marshal(changes)
+	if err != nil {
+		return nil
+	}
+	return diff
+}
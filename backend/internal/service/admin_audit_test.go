@@ -0,0 +1,129 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockAdminAuditLogRepository mocks AdminAuditLogRepository
+type MockAdminAuditLogRepository struct {
+	repository.AdminAuditLogRepository
+	mock.Mock
+}
+
+func (m *MockAdminAuditLogRepository) Create(ctx context.Context, entry *domain.AdminAuditLog) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockAdminAuditLogRepository) Each(ctx context.Context, filters repository.AdminAuditLogFilters, fn func([]*domain.AdminAuditLog) error) error {
+	args := m.Called(ctx, filters)
+	if err := fn(args.Get(0).([]*domain.AdminAuditLog)); err != nil {
+		return err
+	}
+	return args.Error(1)
+}
+
+func (m *MockAdminAuditLogRepository) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	args := m.Called(ctx, cutoff)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestAdminAuditService_RecordDiffsSnapshots(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockAdminAuditLogRepository)
+	repo.On("Create", ctx, mock.Anything).Return(nil)
+	entry := &domain.AdminAuditLog{
+		Before: []byte(`{"id":"c-1","name":"Aurora","status":"active","updated_at":"2026-01-01T00:00:00Z"}`),
+		After:  []byte(`{"id":"c-1","name":"Aurora","status":"inactive","updated_at":"2026-02-01T00:00:00Z"}`),
+	}
+
+	require.NoError(t, NewAdminAuditService(repo, 0, nil).Record(ctx, entry))
+
+	assert.JSONEq(t, `{"status":{"from":"active","to":"inactive"}}`, string(entry.Diff))
+	repo.AssertCalled(t, "Create", ctx, entry)
+}
+
+func TestAdminAuditService_RecordWithoutBeforeHasNoDiff(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockAdminAuditLogRepository)
+	repo.On("Create", ctx, mock.Anything).Return(nil)
+	entry := &domain.AdminAuditLog{After: []byte(`{"id":"c-1"}`)}
+
+	require.NoError(t, NewAdminAuditService(repo, 0, nil).Record(ctx, entry))
+
+	assert.Nil(t, entry.Diff)
+}
+
+func TestAdminAuditService_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	svc := NewAdminAuditService(new(MockAdminAuditLogRepository), 0, map[string]AuditLoader{
+		"cruises": func(ctx context.Context, id string) (interface{}, error) {
+			if id != "c-1" {
+				return nil, errors.New("not found")
+			}
+			return map[string]string{"id": id}, nil
+		},
+	})
+
+	assert.JSONEq(t, `{"id":"c-1"}`, string(svc.Snapshot(ctx, "cruises", "c-1")))
+	assert.Nil(t, svc.Snapshot(ctx, "cruises", "c-2"))
+	assert.Nil(t, svc.Snapshot(ctx, "orders", "o-1"))
+}
+
+func TestAdminAuditService_Export(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockAdminAuditLogRepository)
+	filters := repository.AdminAuditLogFilters{ActorID: "staff-1"}
+	repo.On("Each", ctx, filters).Return([]*domain.AdminAuditLog{{
+		ActorID: "staff-1", ActorName: "alice", Method: "PUT", Route: "/api/v1/admin/cruises/:id",
+		Resource: "cruises", TargetID: "c-1", Result: domain.AuditResultSuccess, StatusCode: 200,
+		Diff: []byte(`{"status":{"from":"active","to":"inactive"}}`),
+	}}, nil)
+
+	var buf bytes.Buffer
+	require.NoError(t, NewAdminAuditService(repo, 0, nil).Export(ctx, filters, &buf))
+
+	lines := strings.Split(strings.TrimSpace(strings.TrimPrefix(buf.String(), "\ufeff")), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "时间,操作人ID"))
+	assert.Contains(t, lines[1], "staff-1,alice")
+	assert.Contains(t, lines[1], "cruises,c-1,success,200")
+}
+
+func TestAdminAuditService_Purge(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("deletes entries past retention", func(t *testing.T) {
+		repo := new(MockAdminAuditLogRepository)
+		now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+		repo.On("DeleteBefore", ctx, now.AddDate(0, 0, -30)).Return(int64(4), nil)
+		svc := NewAdminAuditService(repo, 30*24*time.Hour, nil).(*adminAuditService)
+		svc.now = func() time.Time { return now }
+
+		purged, err := svc.Purge(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), purged)
+	})
+
+	t.Run("keeps everything without retention", func(t *testing.T) {
+		repo := new(MockAdminAuditLogRepository)
+
+		purged, err := NewAdminAuditService(repo, 0, nil).Purge(ctx)
+
+		require.NoError(t, err)
+		assert.Zero(t, purged)
+		repo.AssertNotCalled(t, "DeleteBefore", mock.Anything, mock.Anything)
+	})
+}
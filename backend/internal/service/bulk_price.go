@@ -0,0 +1,451 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/pagination"
+	"backend/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// MaxBulkPriceVoyages caps how many voyages one bulk edit may touch
+const MaxBulkPriceVoyages = 500
+
+var (
+	ErrInvalidBulkPriceRequest = errors.New("invalid bulk price request")
+	ErrBulkPriceTooManyVoyages = errors.New("too many voyages matched the bulk price request")
+	ErrBulkPriceNoVoyages      = errors.New("no voyages matched the bulk price request")
+	ErrBulkPriceConflict       = errors.New("prices changed while the bulk edit was applied")
+)
+
+// BulkPriceService edits cabin prices across many voyages at once
+type BulkPriceService interface {
+	// Preview computes the affected voyages and prices without writing
+	Preview(ctx context.Context, req BulkPriceRequest) (*BulkPriceResult, error)
+
+	// Apply writes all changes and the audit record in one transaction. It
+	// fails with ErrBulkPriceConflict if a price was edited after planning
+	Apply(ctx context.Context, req BulkPriceRequest, operatorID string) (*BulkPriceResult, error)
+
+	// ListOperations lists previously applied bulk edits
+	ListOperations(ctx context.Context, paginator *pagination.Paginator) (*pagination.Result, error)
+}
+
+// BulkPriceRequest selects voyages and cabin types and describes the edit
+type BulkPriceRequest struct {
+	RouteID       string   `json:"route_id" validate:"omitempty,uuid"`
+	CruiseID      string   `json:"cruise_id" validate:"omitempty,uuid"`
+	DepartureFrom string   `json:"departure_from" validate:"required,datetime=2006-01-02"`
+	DepartureTo   string   `json:"departure_to" validate:"required,datetime=2006-01-02"`
+	CabinTypeIDs  []string `json:"cabin_type_ids" validate:"omitempty,dive,uuid"`
+	PriceType     string   `json:"price_type" validate:"omitempty,oneof=standard early_bird last_minute group"`
+	Mode          string   `json:"mode" validate:"required,oneof=set adjust"`
+
+	// Mode "set": one row per cabin type
+	Matrix []BulkPriceMatrixEntry `json:"matrix" validate:"omitempty,dive"`
+
+	// Mode "adjust": percentage applied to fares of existing prices (-100, +inf)
+	AdjustmentPercent float64 `json:"adjustment_percent"`
+
+	// Extra percentage on fares for voyages departing on listed dates; with
+	// the adjustment it must stay above -100 for every matched voyage
+	Surcharges []DateSurcharge `json:"surcharges" validate:"omitempty,dive"`
+
+	// Promotion window, e.g. for early-bird prices
+	IsPromotion        bool   `json:"is_promotion"`
+	PromotionStartDate string `json:"promotion_start_date" validate:"omitempty,datetime=2006-01-02"`
+	PromotionEndDate   string `json:"promotion_end_date" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// BulkPriceMatrixEntry is the price set for one cabin type
+type BulkPriceMatrixEntry struct {
+	CabinTypeID string `json:"cabin_type_id" validate:"required,uuid"`
+	PriceMatrix
+	MinPassengers int `json:"min_passengers" validate:"gte=0"`
+	MaxPassengers int `json:"max_passengers" validate:"gte=0"`
+}
+
+// PriceMatrix holds the price components of a cabin price
+type PriceMatrix struct {
+	AdultPrice       float64 `json:"adult_price" validate:"gte=0"`
+	ChildPrice       float64 `json:"child_price" validate:"gte=0"`
+	InfantPrice      float64 `json:"infant_price" validate:"gte=0"`
+	SingleSupplement float64 `json:"single_supplement" validate:"gte=0"`
+	ExtraAdultPrice  float64 `json:"extra_adult_price" validate:"gte=0"` // 3rd/4th guest, 0 = adult price
+	ExtraChildPrice  float64 `json:"extra_child_price" validate:"gte=0"` // 3rd/4th guest, 0 = child price
+	PortFee          float64 `json:"port_fee" validate:"gte=0"`
+	ServiceFee       float64 `json:"service_fee" validate:"gte=0"`
+}
+
+// DateSurcharge adds a percentage to fares for departures within a date range
+type DateSurcharge struct {
+	Name    string  `json:"name"`
+	From    string  `json:"from" validate:"required,datetime=2006-01-02"`
+	To      string  `json:"to" validate:"required,datetime=2006-01-02"`
+	Percent float64 `json:"percent"`
+}
+
+// BulkPriceResult summarises a bulk edit
+type BulkPriceResult struct {
+	OperationID  string            `json:"operation_id,omitempty"`
+	DryRun       bool              `json:"dry_run"`
+	VoyageCount  int               `json:"voyage_count"`
+	CreatedCount int               `json:"created_count"`
+	UpdatedCount int               `json:"updated_count"`
+	Changes      []BulkPriceChange `json:"changes"`
+}
+
+// BulkPriceChange is the change to one voyage/cabin type price
+type BulkPriceChange struct {
+	VoyageID         string       `json:"voyage_id"`
+	VoyageNumber     string       `json:"voyage_number"`
+	DepartureDate    string       `json:"departure_date"`
+	CabinTypeID      string       `json:"cabin_type_id"`
+	PriceType        string       `json:"price_type"`
+	Action           string       `json:"action"` // create, update
+	SurchargePercent float64      `json:"surcharge_percent,omitempty"`
+	Old              *PriceMatrix `json:"old,omitempty"`
+	New              PriceMatrix  `json:"new"`
+}
+
+// bulkPriceService implements BulkPriceService
+type bulkPriceService struct {
+	priceRepo  repository.PriceRepository
+	voyageRepo repository.VoyageRepository
+	bulkRepo   repository.PriceBulkRepository
+}
+
+// NewBulkPriceService creates a new bulk price service
+func NewBulkPriceService(
+	priceRepo repository.PriceRepository,
+	voyageRepo repository.VoyageRepository,
+	bulkRepo repository.PriceBulkRepository,
+) BulkPriceService {
+	return &bulkPriceService{
+		priceRepo:  priceRepo,
+		voyageRepo: voyageRepo,
+		bulkRepo:   bulkRepo,
+	}
+}
+
+func (s *bulkPriceService) Preview(ctx context.Context, req BulkPriceRequest) (*BulkPriceResult, error) {
+	result, _, _, err := s.plan(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+	result.DryRun = true
+	return result, nil
+}
+
+func (s *bulkPriceService) Apply(ctx context.Context, req BulkPriceRequest, operatorID string) (*BulkPriceResult, error) {
+	result, creates, updates, err := s.plan(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	op := &domain.PriceBulkOperation{
+		OperatorID:    operatorID,
+		Mode:          req.Mode,
+		PriceType:     req.PriceType,
+		DepartureFrom: req.DepartureFrom,
+		DepartureTo:   req.DepartureTo,
+		Request:       payload,
+		VoyageCount:   result.VoyageCount,
+		CreatedCount:  result.CreatedCount,
+		UpdatedCount:  result.UpdatedCount,
+	}
+
+	ctx = repository.WithPriceChange(ctx, domain.PriceSourceManual, operatorID, "bulk price edit")
+	if err := s.bulkRepo.Apply(ctx, op, creates, updates); err != nil {
+		if errors.Is(err, repository.ErrPriceVersionConflict) {
+			return nil, fmt.Errorf("%w: %v", ErrBulkPriceConflict, err)
+		}
+		return nil, fmt.Errorf("failed to apply bulk prices: %w", err)
+	}
+
+	result.OperationID = op.ID.String()
+	return result, nil
+}
+
+func (s *bulkPriceService) ListOperations(ctx context.Context, paginator *pagination.Paginator) (*pagination.Result, error) {
+	ops, total, err := s.bulkRepo.ListOperations(ctx, paginator)
+	if err != nil {
+		return nil, err
+	}
+	paginator.SetTotal(total)
+	result := pagination.NewResult(ops, *paginator)
+	return &result, nil
+}
+
+// plan resolves voyages and computes every create/update without writing
+func (s *bulkPriceService) plan(ctx context.Context, req *BulkPriceRequest) (*BulkPriceResult, []*domain.CabinPrice, []*domain.CabinPrice, error) {
+	if err := validateBulkPriceRequest(req); err != nil {
+		return nil, nil, nil, err
+	}
+
+	voyages, err := s.matchVoyages(ctx, *req)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	cabinTypeFilter := make(map[string]bool, len(req.CabinTypeIDs))
+	for _, id := range req.CabinTypeIDs {
+		cabinTypeFilter[id] = true
+	}
+
+	result := &BulkPriceResult{VoyageCount: len(voyages), Changes: []BulkPriceChange{}}
+	var creates, updates []*domain.CabinPrice
+
+	for _, voyage := range voyages {
+		existing, err := s.priceRepo.ListByVoyage(ctx, voyage.ID.String())
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to load prices for voyage %s: %w", voyage.ID, err)
+		}
+		byCabinType := make(map[string]*domain.CabinPrice)
+		for _, price := range existing {
+			if price.PriceType == req.PriceType {
+				byCabinType[price.CabinTypeID] = price
+			}
+		}
+
+		surcharge := surchargeFor(req.Surcharges, voyage.DepartureDate)
+		percent := surcharge
+		if req.Mode == domain.BulkPriceModeAdjust {
+			percent += req.AdjustmentPercent
+		}
+		// Surcharges may be discounts; together they must leave a positive fare
+		if percent <= -100 {
+			return nil, nil, nil, fmt.Errorf("%w: fares of voyage %s would change by %.2f%%, which must be greater than -100%%",
+				ErrInvalidBulkPriceRequest, voyage.VoyageNumber, percent)
+		}
+
+		switch req.Mode {
+		case domain.BulkPriceModeSet:
+			for _, entry := range req.Matrix {
+				if len(cabinTypeFilter) > 0 && !cabinTypeFilter[entry.CabinTypeID] {
+					continue
+				}
+				matrix := applyFareAdjustment(entry.PriceMatrix, surcharge)
+				change := BulkPriceChange{
+					VoyageID:         voyage.ID.String(),
+					VoyageNumber:     voyage.VoyageNumber,
+					DepartureDate:    voyage.DepartureDate,
+					CabinTypeID:      entry.CabinTypeID,
+					PriceType:        req.PriceType,
+					SurchargePercent: surcharge,
+					New:              matrix,
+				}
+
+				if price, ok := byCabinType[entry.CabinTypeID]; ok {
+					old := matrixOf(price)
+					change.Action = "update"
+					change.Old = &old
+					writeMatrix(price, matrix, *req)
+					if entry.MinPassengers > 0 {
+						price.MinPassengers = entry.MinPassengers
+					}
+					if entry.MaxPassengers > 0 {
+						price.MaxPassengers = entry.MaxPassengers
+					}
+					updates = append(updates, price)
+				} else {
+					price := &domain.CabinPrice{
+						VoyageID:      voyage.ID.String(),
+						CabinTypeID:   entry.CabinTypeID,
+						PriceType:     req.PriceType,
+						MinPassengers: firstPositiveInt(entry.MinPassengers, 1),
+						MaxPassengers: firstPositiveInt(entry.MaxPassengers, 4),
+						Version:       1,
+					}
+					writeMatrix(price, matrix, *req)
+					change.Action = "create"
+					creates = append(creates, price)
+				}
+				result.Changes = append(result.Changes, change)
+			}
+
+		case domain.BulkPriceModeAdjust:
+			for _, price := range existing {
+				if price.PriceType != req.PriceType {
+					continue
+				}
+				if len(cabinTypeFilter) > 0 && !cabinTypeFilter[price.CabinTypeID] {
+					continue
+				}
+				old := matrixOf(price)
+				base := old
+				base.AdultPrice = firstPositive(price.BaseAdultPrice, price.AdultPrice)
+				base.ChildPrice = firstPositive(price.BaseChildPrice, price.ChildPrice)
+				base.InfantPrice = firstPositive(price.BaseInfantPrice, price.InfantPrice)
+
+				matrix := applyFareAdjustment(base, percent)
+				writeMatrix(price, matrix, *req)
+				updates = append(updates, price)
+				result.Changes = append(result.Changes, BulkPriceChange{
+					VoyageID:         voyage.ID.String(),
+					VoyageNumber:     voyage.VoyageNumber,
+					DepartureDate:    voyage.DepartureDate,
+					CabinTypeID:      price.CabinTypeID,
+					PriceType:        req.PriceType,
+					Action:           "update",
+					SurchargePercent: surcharge,
+					Old:              &old,
+					New:              matrix,
+				})
+			}
+		}
+	}
+
+	result.CreatedCount = len(creates)
+	result.UpdatedCount = len(updates)
+	return result, creates, updates, nil
+}
+
+// matchVoyages lists the voyages selected by route/cruise and departure range
+func (s *bulkPriceService) matchVoyages(ctx context.Context, req BulkPriceRequest) ([]*domain.Voyage, error) {
+	filters := repository.VoyageFilters{
+		RouteID:       req.RouteID,
+		CruiseID:      req.CruiseID,
+		DepartureFrom: req.DepartureFrom,
+		DepartureTo:   req.DepartureTo,
+	}
+
+	total, err := s.voyageRepo.Count(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+	if total == 0 {
+		return nil, ErrBulkPriceNoVoyages
+	}
+	if total > MaxBulkPriceVoyages {
+		return nil, ErrBulkPriceTooManyVoyages
+	}
+
+	paginator := &pagination.Paginator{Page: 1, PageSize: MaxBulkPriceVoyages}
+	return s.voyageRepo.List(ctx, filters, paginator)
+}
+
+// validateBulkPriceRequest checks cross-field rules and fills defaults
+func validateBulkPriceRequest(req *BulkPriceRequest) error {
+	if req.PriceType == "" {
+		req.PriceType = domain.PriceTypeStandard
+	}
+	if req.DepartureFrom > req.DepartureTo {
+		return fmt.Errorf("%w: departure_from is after departure_to", ErrInvalidBulkPriceRequest)
+	}
+	if req.RouteID == "" && req.CruiseID == "" {
+		return fmt.Errorf("%w: route_id or cruise_id is required", ErrInvalidBulkPriceRequest)
+	}
+
+	switch req.Mode {
+	case domain.BulkPriceModeSet:
+		if len(req.Matrix) == 0 {
+			return fmt.Errorf("%w: matrix is required in set mode", ErrInvalidBulkPriceRequest)
+		}
+		seen := make(map[string]bool, len(req.Matrix))
+		for _, entry := range req.Matrix {
+			if entry.AdultPrice <= 0 {
+				return fmt.Errorf("%w: adult_price must be positive", ErrInvalidBulkPriceRequest)
+			}
+			if seen[entry.CabinTypeID] {
+				return fmt.Errorf("%w: duplicate cabin type %s in matrix", ErrInvalidBulkPriceRequest, entry.CabinTypeID)
+			}
+			seen[entry.CabinTypeID] = true
+		}
+	case domain.BulkPriceModeAdjust:
+		if req.AdjustmentPercent <= -100 {
+			return fmt.Errorf("%w: adjustment_percent must be greater than -100", ErrInvalidBulkPriceRequest)
+		}
+	default:
+		return fmt.Errorf("%w: unknown mode %q", ErrInvalidBulkPriceRequest, req.Mode)
+	}
+
+	for _, sc := range req.Surcharges {
+		if sc.From > sc.To {
+			return fmt.Errorf("%w: surcharge %q has from after to", ErrInvalidBulkPriceRequest, sc.Name)
+		}
+	}
+	return nil
+}
+
+// surchargeFor sums the surcharge percentages covering a departure date
+func surchargeFor(surcharges []DateSurcharge, departureDate string) float64 {
+	date := dateOnly(departureDate)
+
+	var total float64
+	for _, sc := range surcharges {
+		if date >= sc.From && date <= sc.To {
+			total += sc.Percent
+		}
+	}
+	return total
+}
+
+// applyFareAdjustment scales fares (not fees) by a percentage
+func applyFareAdjustment(m PriceMatrix, percent float64) PriceMatrix {
+	if percent == 0 {
+		return m
+	}
+	factor := 1 + percent/100
+	m.AdultPrice = roundMoney(m.AdultPrice * factor)
+	m.ChildPrice = roundMoney(m.ChildPrice * factor)
+	m.InfantPrice = roundMoney(m.InfantPrice * factor)
+	m.SingleSupplement = roundMoney(m.SingleSupplement * factor)
+	m.ExtraAdultPrice = roundMoney(m.ExtraAdultPrice * factor)
+	m.ExtraChildPrice = roundMoney(m.ExtraChildPrice * factor)
+	return m
+}
+
+func matrixOf(p *domain.CabinPrice) PriceMatrix {
+	return PriceMatrix{
+		AdultPrice:       p.AdultPrice,
+		ChildPrice:       p.ChildPrice,
+		InfantPrice:      p.InfantPrice,
+		SingleSupplement: p.SingleSupplement,
+		ExtraAdultPrice:  p.ExtraAdultPrice,
+		ExtraChildPrice:  p.ExtraChildPrice,
+		PortFee:          p.PortFee,
+		ServiceFee:       p.ServiceFee,
+	}
+}
+
+// writeMatrix sets prices and makes them the new base for dynamic pricing
+func writeMatrix(p *domain.CabinPrice, m PriceMatrix, req BulkPriceRequest) {
+	p.AdultPrice = m.AdultPrice
+	p.ChildPrice = m.ChildPrice
+	p.InfantPrice = m.InfantPrice
+	p.SingleSupplement = m.SingleSupplement
+	p.ExtraAdultPrice = m.ExtraAdultPrice
+	p.ExtraChildPrice = m.ExtraChildPrice
+	p.PortFee = m.PortFee
+	p.ServiceFee = m.ServiceFee
+	p.BaseAdultPrice = m.AdultPrice
+	p.BaseChildPrice = m.ChildPrice
+	p.BaseInfantPrice = m.InfantPrice
+	if req.IsPromotion {
+		p.IsPromotion = true
+		p.PromotionStartDate = req.PromotionStartDate
+		p.PromotionEndDate = req.PromotionEndDate
+	}
+	if p.ID != uuid.Nil {
+		p.Version++
+	}
+}
+
+func firstPositiveInt(values ...int) int {
+	for _, v := range values {
+		if v > 0 {
+			return v
+		}
+	}
+	return 0
+}
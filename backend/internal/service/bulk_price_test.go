@@ -0,0 +1,171 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockPriceBulkRepository mocks PriceBulkRepository
+type MockPriceBulkRepository struct {
+	repository.PriceBulkRepository
+	mock.Mock
+}
+
+func (m *MockPriceBulkRepository) Apply(ctx context.Context, op *domain.PriceBulkOperation, creates, updates []*domain.CabinPrice) error {
+	args := m.Called(ctx, op, creates, updates)
+	return args.Error(0)
+}
+
+func TestBulkPriceService_FarePercentBounds(t *testing.T) {
+	ctx := context.Background()
+	cabinTypeID := uuid.New().String()
+	voyage := &domain.Voyage{BaseModel: domain.BaseModel{ID: uuid.New()}, VoyageNumber: "V001", DepartureDate: "2026-10-02"}
+	price := &domain.CabinPrice{
+		BaseModel:   domain.BaseModel{ID: uuid.New()},
+		VoyageID:    voyage.ID.String(),
+		CabinTypeID: cabinTypeID,
+		PriceType:   domain.PriceTypeStandard,
+		AdultPrice:  1000,
+		ChildPrice:  500,
+	}
+	holiday := func(percent float64) []DateSurcharge {
+		return []DateSurcharge{{Name: "holiday", From: "2026-10-01", To: "2026-10-07", Percent: percent}}
+	}
+
+	tests := []struct {
+		name      string
+		req       BulkPriceRequest
+		wantErr   bool
+		wantAdult float64
+	}{
+		{
+			name:      "adjustment and discount add up",
+			req:       BulkPriceRequest{Mode: domain.BulkPriceModeAdjust, AdjustmentPercent: -40, Surcharges: holiday(-30)},
+			wantAdult: 300,
+		},
+		{
+			name:    "adjustment and discount reaching -100 are rejected",
+			req:     BulkPriceRequest{Mode: domain.BulkPriceModeAdjust, AdjustmentPercent: -60, Surcharges: holiday(-40)},
+			wantErr: true,
+		},
+		{
+			name: "a set price discounted to nothing is rejected",
+			req: BulkPriceRequest{Mode: domain.BulkPriceModeSet, Surcharges: holiday(-100), Matrix: []BulkPriceMatrixEntry{
+				{CabinTypeID: cabinTypeID, PriceMatrix: PriceMatrix{AdultPrice: 800}},
+			}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			voyageRepo := new(MockVoyageRepository)
+			priceRepo := new(MockPriceRepository)
+			voyageRepo.On("Count", ctx, mock.Anything).Return(int64(1), nil)
+			voyageRepo.On("List", ctx, mock.Anything, mock.Anything).Return([]*domain.Voyage{voyage}, nil)
+			existing := *price
+			priceRepo.On("ListByVoyage", ctx, voyage.ID.String()).Return([]*domain.CabinPrice{&existing}, nil)
+
+			req := tt.req
+			req.RouteID = uuid.New().String()
+			req.DepartureFrom, req.DepartureTo = "2026-10-01", "2026-10-31"
+			result, err := NewBulkPriceService(priceRepo, voyageRepo, nil).Preview(ctx, req)
+
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidBulkPriceRequest)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, result.Changes, 1)
+			assert.Equal(t, tt.wantAdult, result.Changes[0].New.AdultPrice)
+		})
+	}
+}
+
+func TestBulkPriceService_SetsExtraGuestRates(t *testing.T) {
+	ctx := context.Background()
+	cabinTypeID := uuid.New().String()
+	voyage := &domain.Voyage{BaseModel: domain.BaseModel{ID: uuid.New()}, VoyageNumber: "V001", DepartureDate: "2026-10-02"}
+	price := &domain.CabinPrice{
+		BaseModel:       domain.BaseModel{ID: uuid.New()},
+		VoyageID:        voyage.ID.String(),
+		CabinTypeID:     cabinTypeID,
+		PriceType:       domain.PriceTypeStandard,
+		AdultPrice:      1000,
+		ExtraAdultPrice: 600,
+		Version:         1,
+	}
+
+	voyageRepo := new(MockVoyageRepository)
+	priceRepo := new(MockPriceRepository)
+	voyageRepo.On("Count", ctx, mock.Anything).Return(int64(1), nil)
+	voyageRepo.On("List", ctx, mock.Anything, mock.Anything).Return([]*domain.Voyage{voyage}, nil)
+	priceRepo.On("ListByVoyage", ctx, voyage.ID.String()).Return([]*domain.CabinPrice{price}, nil)
+
+	result, err := NewBulkPriceService(priceRepo, voyageRepo, nil).Preview(ctx, BulkPriceRequest{
+		RouteID:       uuid.New().String(),
+		DepartureFrom: "2026-10-01",
+		DepartureTo:   "2026-10-31",
+		Mode:          domain.BulkPriceModeSet,
+		Surcharges:    []DateSurcharge{{Name: "holiday", From: "2026-10-01", To: "2026-10-07", Percent: 10}},
+		Matrix: []BulkPriceMatrixEntry{{
+			CabinTypeID:   cabinTypeID,
+			PriceMatrix:   PriceMatrix{AdultPrice: 1200, ChildPrice: 600, ExtraAdultPrice: 700, ExtraChildPrice: 400},
+			MaxPassengers: 4,
+		}},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, result.Changes, 1)
+	assert.Equal(t, 600.0, result.Changes[0].Old.ExtraAdultPrice)
+	// Extra guest fares take the surcharge like the other fares
+	assert.Equal(t, 770.0, result.Changes[0].New.ExtraAdultPrice)
+	assert.Equal(t, 440.0, result.Changes[0].New.ExtraChildPrice)
+	assert.Equal(t, 770.0, price.ExtraAdultPrice)
+	assert.Equal(t, 4, price.MaxPassengers)
+}
+
+func TestBulkPriceService_ApplyConflict(t *testing.T) {
+	ctx := context.Background()
+	cabinTypeID := uuid.New().String()
+	voyage := &domain.Voyage{BaseModel: domain.BaseModel{ID: uuid.New()}, VoyageNumber: "V001", DepartureDate: "2026-10-02"}
+	price := &domain.CabinPrice{
+		BaseModel:   domain.BaseModel{ID: uuid.New()},
+		VoyageID:    voyage.ID.String(),
+		CabinTypeID: cabinTypeID,
+		PriceType:   domain.PriceTypeStandard,
+		AdultPrice:  1000,
+		Version:     3,
+	}
+
+	voyageRepo := new(MockVoyageRepository)
+	priceRepo := new(MockPriceRepository)
+	bulkRepo := new(MockPriceBulkRepository)
+	voyageRepo.On("Count", ctx, mock.Anything).Return(int64(1), nil)
+	voyageRepo.On("List", ctx, mock.Anything, mock.Anything).Return([]*domain.Voyage{voyage}, nil)
+	priceRepo.On("ListByVoyage", ctx, voyage.ID.String()).Return([]*domain.CabinPrice{price}, nil)
+	var updates []*domain.CabinPrice
+	bulkRepo.On("Apply", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		updates = args.Get(3).([]*domain.CabinPrice)
+	}).Return(repository.ErrPriceVersionConflict)
+
+	_, err := NewBulkPriceService(priceRepo, voyageRepo, bulkRepo).Apply(ctx, BulkPriceRequest{
+		RouteID:           uuid.New().String(),
+		DepartureFrom:     "2026-10-01",
+		DepartureTo:       "2026-10-31",
+		Mode:              domain.BulkPriceModeAdjust,
+		AdjustmentPercent: 10,
+	}, "op-1")
+
+	assert.ErrorIs(t, err, ErrBulkPriceConflict)
+	require.Len(t, updates, 1)
+	// The update is planned as the next version of the price that was read
+	assert.Equal(t, 4, updates[0].Version)
+}
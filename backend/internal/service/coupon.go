@@ -0,0 +1,596 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCouponNotFound       = errors.New("coupon not found")
+	ErrInvalidCouponData    = errors.New("invalid coupon data")
+	ErrCouponInactive       = errors.New("coupon is not active")
+	ErrCouponExhausted      = errors.New("coupon usage limit reached")
+	ErrCouponUserLimit      = errors.New("coupon per-user limit reached")
+	ErrCouponNotOwned       = errors.New("coupon is not in the user's wallet")
+	ErrCouponAlreadyClaimed = errors.New("coupon already claimed")
+	ErrCouponNewUsersOnly   = errors.New("coupon is for new users only")
+	ErrCouponNotApplicable  = errors.New("coupon does not apply to this booking")
+	ErrCouponMinSpend       = errors.New("order does not reach the coupon minimum spend")
+	ErrCouponNotStackable   = errors.New("coupons cannot be combined")
+	ErrCouponLoginRequired  = errors.New("login required to use this coupon")
+)
+
+// CouponService defines the interface for coupon issuance, wallets and redemption
+type CouponService interface {
+	CreateCoupon(ctx context.Context, req CouponRequest) (*domain.Coupon, error)
+	GetCoupon(ctx context.Context, id string) (*domain.Coupon, error)
+	ListCoupons(ctx context.Context, filters repository.CouponFilters) ([]*domain.Coupon, error)
+	UpdateCoupon(ctx context.Context, id string, req CouponRequest) (*domain.Coupon, error)
+	DeleteCoupon(ctx context.Context, id string) error
+
+	// IssueCoupon puts a coupon into the wallets of the given users
+	IssueCoupon(ctx context.Context, couponID string, userIDs []string) (int, error)
+
+	// ClaimCoupon puts a coupon into the user's wallet by code
+	ClaimCoupon(ctx context.Context, userID, code string) (*domain.UserCoupon, error)
+
+	// ListWallet lists the user's coupons, optionally by status
+	ListWallet(ctx context.Context, userID, status string) ([]*domain.UserCoupon, error)
+
+	// Evaluate validates coupon codes against a booking and prices them
+	Evaluate(ctx context.Context, booking CouponBooking, codes []string) ([]AppliedCoupon, error)
+
+	// Redeem records evaluated coupons against an order within tx
+	Redeem(ctx context.Context, tx *gorm.DB, orderID string, booking CouponBooking, applied []AppliedCoupon) error
+
+	// ReleaseOrder releases the coupons of a cancelled or expired order
+	ReleaseOrder(ctx context.Context, orderID string) error
+}
+
+// CouponRequest represents a request to create or update a coupon
+type CouponRequest struct {
+	Code           string     `json:"code" validate:"required,max=50"`
+	Name           string     `json:"name" validate:"required,max=100"`
+	Description    string     `json:"description"`
+	DiscountType   string     `json:"discount_type" validate:"required,oneof=fixed percent"`
+	DiscountValue  float64    `json:"discount_value" validate:"gt=0"`
+	MaxDiscount    *float64   `json:"max_discount" validate:"omitempty,gt=0"`
+	MinSpend       float64    `json:"min_spend" validate:"gte=0"`
+	RouteIDs       []string   `json:"route_ids" validate:"omitempty,dive,uuid"`
+	CruiseIDs      []string   `json:"cruise_ids" validate:"omitempty,dive,uuid"`
+	CabinTypeIDs   []string   `json:"cabin_type_ids" validate:"omitempty,dive,uuid"`
+	DepartureFrom  *string    `json:"departure_from"`
+	DepartureTo    *string    `json:"departure_to"`
+	NewUsersOnly   bool       `json:"new_users_only"`
+	RequiresClaim  bool       `json:"requires_claim"`
+	PerUserLimit   *int       `json:"per_user_limit" validate:"omitempty,gte=0"`
+	TotalLimit     int        `json:"total_limit" validate:"gte=0"`
+	Stackable      bool       `json:"stackable"`
+	ValidFrom      *time.Time `json:"valid_from"`
+	ValidUntil     *time.Time `json:"valid_until"`
+	ClaimValidDays int        `json:"claim_valid_days" validate:"gte=0"`
+	IsActive       *bool      `json:"is_active"`
+}
+
+// IssueCouponRequest represents a request to issue a coupon to users
+type IssueCouponRequest struct {
+	UserIDs []string `json:"user_ids" validate:"required,min=1,dive,uuid"`
+}
+
+// CouponBooking is what a coupon is evaluated against. Item amounts are in
+// the base currency.
+type CouponBooking struct {
+	UserID string
+	Voyage *domain.Voyage
+	Items  []CouponItem
+}
+
+// CouponItem is the subtotal of one order item
+type CouponItem struct {
+	CabinTypeID string
+	Amount      float64
+}
+
+// AppliedCoupon is a coupon accepted for a booking
+type AppliedCoupon struct {
+	CouponID       string  `json:"coupon_id"`
+	Code           string  `json:"code"`
+	Name           string  `json:"name"`
+	DiscountAmount float64 `json:"discount_amount"` // in the order currency once converted
+
+	userCouponID *string
+	perUserLimit int
+	baseDiscount float64
+}
+
+// BaseDiscount returns the discount in the base currency
+func (a AppliedCoupon) BaseDiscount() float64 {
+	return a.baseDiscount
+}
+
+// TotalCouponDiscount sums the base currency discounts of applied coupons
+func TotalCouponDiscount(applied []AppliedCoupon) float64 {
+	var total float64
+	for _, a := range applied {
+		total += a.baseDiscount
+	}
+	return math.Round(total*100) / 100
+}
+
+// couponService implements CouponService
+type couponService struct {
+	couponRepo repository.CouponRepository
+	now        func() time.Time
+}
+
+// NewCouponService creates a new coupon service
+func NewCouponService(couponRepo repository.CouponRepository) CouponService {
+	return &couponService{couponRepo: couponRepo, now: time.Now}
+}
+
+func (s *couponService) CreateCoupon(ctx context.Context, req CouponRequest) (*domain.Coupon, error) {
+	coupon := &domain.Coupon{IsActive: true, PerUserLimit: 1}
+	if err := applyCouponRequest(coupon, req); err != nil {
+		return nil, err
+	}
+	if _, err := s.couponRepo.GetByCode(ctx, coupon.Code); err == nil {
+		return nil, fmt.Errorf("%w: code %s already exists", ErrInvalidCouponData, coupon.Code)
+	}
+	if err := s.couponRepo.Create(ctx, coupon); err != nil {
+		return nil, err
+	}
+	return coupon, nil
+}
+
+func (s *couponService) GetCoupon(ctx context.Context, id string) (*domain.Coupon, error) {
+	coupon, err := s.couponRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrCouponNotFound
+	}
+	return coupon, nil
+}
+
+func (s *couponService) ListCoupons(ctx context.Context, filters repository.CouponFilters) ([]*domain.Coupon, error) {
+	return s.couponRepo.List(ctx, filters)
+}
+
+func (s *couponService) UpdateCoupon(ctx context.Context, id string, req CouponRequest) (*domain.Coupon, error) {
+	coupon, err := s.couponRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrCouponNotFound
+	}
+	code := coupon.Code
+	if err := applyCouponRequest(coupon, req); err != nil {
+		return nil, err
+	}
+	if coupon.Code != code {
+		if _, err := s.couponRepo.GetByCode(ctx, coupon.Code); err == nil {
+			return nil, fmt.Errorf("%w: code %s already exists", ErrInvalidCouponData, coupon.Code)
+		}
+	}
+	if err := s.couponRepo.Update(ctx, coupon); err != nil {
+		return nil, err
+	}
+	return coupon, nil
+}
+
+func (s *couponService) DeleteCoupon(ctx context.Context, id string) error {
+	if _, err := s.couponRepo.GetByID(ctx, id); err != nil {
+		return ErrCouponNotFound
+	}
+	return s.couponRepo.Delete(ctx, id)
+}
+
+func (s *couponService) IssueCoupon(ctx context.Context, couponID string, userIDs []string) (int, error) {
+	coupon, err := s.couponRepo.GetByID(ctx, couponID)
+	if err != nil {
+		return 0, ErrCouponNotFound
+	}
+	now := s.now()
+	// Coupons may be issued ahead of ValidFrom but not once they have ended
+	if !coupon.IsActive || (coupon.ValidUntil != nil && now.After(*coupon.ValidUntil)) {
+		return 0, ErrCouponInactive
+	}
+
+	issued := 0
+	err = s.couponRepo.WithTransaction(ctx, func(repo repository.CouponRepository) error {
+		for _, userID := range userIDs {
+			userCoupon := &domain.UserCoupon{
+				UserID:    userID,
+				CouponID:  coupon.ID.String(),
+				Status:    domain.UserCouponStatusAvailable,
+				Source:    domain.UserCouponSourceIssued,
+				ExpiresAt: walletExpiry(coupon, now),
+			}
+			if err := repo.CreateUserCoupon(ctx, userCoupon); err != nil {
+				return err
+			}
+			issued++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return issued, nil
+}
+
+func (s *couponService) ClaimCoupon(ctx context.Context, userID, code string) (*domain.UserCoupon, error) {
+	coupon, err := s.couponRepo.GetByCode(ctx, normalizeCouponCode(code))
+	if err != nil {
+		return nil, ErrCouponNotFound
+	}
+	now := s.now()
+	if !coupon.ActiveAt(now) {
+		return nil, ErrCouponInactive
+	}
+
+	// A claim is one use, so a user may hold up to the per-user limit
+	if coupon.PerUserLimit > 0 {
+		held, err := s.couponRepo.CountUserCoupons(ctx, userID, coupon.ID.String())
+		if err != nil {
+			return nil, err
+		}
+		if held >= int64(coupon.PerUserLimit) {
+			return nil, ErrCouponAlreadyClaimed
+		}
+	}
+
+	userCoupon := &domain.UserCoupon{
+		UserID:    userID,
+		CouponID:  coupon.ID.String(),
+		Coupon:    *coupon,
+		Status:    domain.UserCouponStatusAvailable,
+		Source:    domain.UserCouponSourceClaimed,
+		ExpiresAt: walletExpiry(coupon, now),
+	}
+	if err := s.couponRepo.CreateUserCoupon(ctx, userCoupon); err != nil {
+		return nil, err
+	}
+	return userCoupon, nil
+}
+
+func (s *couponService) ListWallet(ctx context.Context, userID, status string) ([]*domain.UserCoupon, error) {
+	userCoupons, err := s.couponRepo.ListUserCoupons(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	wallet := make([]*domain.UserCoupon, 0, len(userCoupons))
+	for _, uc := range userCoupons {
+		// Entries past their expiry are reported as expired without a write
+		if uc.Status == domain.UserCouponStatusAvailable && !uc.UsableAt(now) {
+			uc.Status = domain.UserCouponStatusExpired
+		}
+		if status == "" || uc.Status == status {
+			wallet = append(wallet, uc)
+		}
+	}
+	return wallet, nil
+}
+
+func (s *couponService) Evaluate(ctx context.Context, booking CouponBooking, codes []string) ([]AppliedCoupon, error) {
+	codes = uniqueCouponCodes(codes)
+	if len(codes) == 0 {
+		return nil, nil
+	}
+
+	now := s.now()
+	coupons := make([]*domain.Coupon, 0, len(codes))
+	for _, code := range codes {
+		coupon, err := s.couponRepo.GetByCode(ctx, code)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrCouponNotFound, code)
+		}
+		coupons = append(coupons, coupon)
+	}
+
+	applied, err := applyCoupons(coupons, booking, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// Checks that need the user's history and wallet
+	for i, coupon := range coupons {
+		if booking.UserID == "" {
+			if coupon.RequiresClaim || coupon.NewUsersOnly || coupon.PerUserLimit > 0 {
+				return nil, fmt.Errorf("%w: %s", ErrCouponLoginRequired, coupon.Code)
+			}
+			continue
+		}
+
+		if coupon.NewUsersOnly {
+			paid, err := s.couponRepo.CountPaidOrders(ctx, booking.UserID)
+			if err != nil {
+				return nil, err
+			}
+			if paid > 0 {
+				return nil, fmt.Errorf("%w: %s", ErrCouponNewUsersOnly, coupon.Code)
+			}
+		}
+
+		if coupon.PerUserLimit > 0 {
+			used, err := s.couponRepo.CountActiveRedemptions(ctx, coupon.ID.String(), booking.UserID)
+			if err != nil {
+				return nil, err
+			}
+			if used >= int64(coupon.PerUserLimit) {
+				return nil, fmt.Errorf("%w: %s", ErrCouponUserLimit, coupon.Code)
+			}
+		}
+
+		// Use a held wallet entry when there is one, even for public codes
+		userCoupon, err := s.couponRepo.GetUsableUserCoupon(ctx, booking.UserID, coupon.ID.String(), now)
+		switch {
+		case err == nil:
+			id := userCoupon.ID.String()
+			applied[i].userCouponID = &id
+		case coupon.RequiresClaim:
+			return nil, fmt.Errorf("%w: %s", ErrCouponNotOwned, coupon.Code)
+		}
+	}
+
+	return applied, nil
+}
+
+func (s *couponService) Redeem(ctx context.Context, tx *gorm.DB, orderID string, booking CouponBooking, applied []AppliedCoupon) error {
+	if len(applied) == 0 {
+		return nil
+	}
+
+	repo := repository.NewCouponRepository(tx)
+	now := s.now()
+
+	// Concurrent orders of one user would each count the other's redemption
+	// as not yet made, so per-user limits are checked under the user's lock,
+	// taken before any coupon row
+	if booking.UserID != "" && hasPerUserLimit(applied) {
+		if err := repo.LockUser(ctx, booking.UserID); err != nil {
+			return fmt.Errorf("failed to lock user %s: %w", booking.UserID, err)
+		}
+	}
+
+	for _, a := range applied {
+		ok, err := repo.TryIncrementRedeemed(ctx, a.CouponID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrCouponExhausted, a.Code)
+		}
+
+		redemption := &domain.CouponRedemption{
+			CouponID:       a.CouponID,
+			UserCouponID:   a.userCouponID,
+			OrderID:        orderID,
+			Code:           a.Code,
+			DiscountAmount: a.baseDiscount,
+			Status:         domain.CouponRedemptionApplied,
+		}
+
+		if booking.UserID != "" {
+			userID := booking.UserID
+			redemption.UserID = &userID
+
+			// Re-check under the user's lock in case of concurrent orders
+			if a.perUserLimit > 0 {
+				used, err := repo.CountActiveRedemptions(ctx, a.CouponID, userID)
+				if err != nil {
+					return err
+				}
+				if used >= int64(a.perUserLimit) {
+					return fmt.Errorf("%w: %s", ErrCouponUserLimit, a.Code)
+				}
+			}
+		}
+
+		if a.userCouponID != nil {
+			ok, err := repo.MarkUserCouponUsed(ctx, *a.userCouponID, orderID, now)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("%w: %s", ErrCouponNotOwned, a.Code)
+			}
+		}
+
+		if err := repo.CreateRedemption(ctx, redemption); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasPerUserLimit reports whether any applied coupon limits uses per user
+func hasPerUserLimit(applied []AppliedCoupon) bool {
+	for _, a := range applied {
+		if a.perUserLimit > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *couponService) ReleaseOrder(ctx context.Context, orderID string) error {
+	now := s.now()
+	return s.couponRepo.WithTransaction(ctx, func(repo repository.CouponRepository) error {
+		redemptions, err := repo.ListRedemptionsByOrder(ctx, orderID, domain.CouponRedemptionApplied)
+		if err != nil {
+			return err
+		}
+		for _, redemption := range redemptions {
+			ok, err := repo.ReleaseRedemption(ctx, redemption.ID.String(), now)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				// Already released by a concurrent cancellation
+				continue
+			}
+			if err := repo.DecrementRedeemed(ctx, redemption.CouponID); err != nil {
+				return err
+			}
+			if redemption.UserCouponID != nil {
+				if err := repo.RestoreUserCoupon(ctx, *redemption.UserCouponID); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// applyCoupons checks the booking-level conditions of coupons and prices
+// them in order. Each coupon discounts its in-scope subtotal; together they
+// never discount more than the booking total.
+func applyCoupons(coupons []*domain.Coupon, booking CouponBooking, now time.Time) ([]AppliedCoupon, error) {
+	if len(coupons) > 1 {
+		for _, coupon := range coupons {
+			if !coupon.Stackable {
+				return nil, fmt.Errorf("%w: %s", ErrCouponNotStackable, coupon.Code)
+			}
+		}
+	}
+
+	var remaining float64
+	for _, item := range booking.Items {
+		remaining += item.Amount
+	}
+
+	applied := make([]AppliedCoupon, 0, len(coupons))
+	for _, coupon := range coupons {
+		if !coupon.ActiveAt(now) {
+			return nil, fmt.Errorf("%w: %s", ErrCouponInactive, coupon.Code)
+		}
+		if coupon.Exhausted() {
+			return nil, fmt.Errorf("%w: %s", ErrCouponExhausted, coupon.Code)
+		}
+		if !coupon.MatchesVoyage(booking.Voyage) {
+			return nil, fmt.Errorf("%w: %s", ErrCouponNotApplicable, coupon.Code)
+		}
+
+		var eligible float64
+		for _, item := range booking.Items {
+			if coupon.AppliesToCabinType(item.CabinTypeID) {
+				eligible += item.Amount
+			}
+		}
+		if eligible <= 0 {
+			return nil, fmt.Errorf("%w: %s", ErrCouponNotApplicable, coupon.Code)
+		}
+		if eligible < coupon.MinSpend {
+			return nil, fmt.Errorf("%w: %s requires %.2f", ErrCouponMinSpend, coupon.Code, coupon.MinSpend)
+		}
+
+		discount := math.Min(coupon.Discount(eligible), remaining)
+		remaining -= discount
+
+		applied = append(applied, AppliedCoupon{
+			CouponID:       coupon.ID.String(),
+			Code:           coupon.Code,
+			Name:           coupon.Name,
+			DiscountAmount: discount,
+			perUserLimit:   coupon.PerUserLimit,
+			baseDiscount:   discount,
+		})
+	}
+	return applied, nil
+}
+
+// walletExpiry returns when a wallet entry issued at now expires
+func walletExpiry(coupon *domain.Coupon, now time.Time) *time.Time {
+	expiry := coupon.ValidUntil
+	if coupon.ClaimValidDays > 0 {
+		t := now.AddDate(0, 0, coupon.ClaimValidDays)
+		if expiry == nil || t.Before(*expiry) {
+			expiry = &t
+		}
+	}
+	return expiry
+}
+
+func applyCouponRequest(coupon *domain.Coupon, req CouponRequest) error {
+	if req.DiscountType == domain.CouponDiscountPercent && req.DiscountValue > 100 {
+		return fmt.Errorf("%w: percent discount cannot exceed 100", ErrInvalidCouponData)
+	}
+	if req.ValidFrom != nil && req.ValidUntil != nil && req.ValidUntil.Before(*req.ValidFrom) {
+		return fmt.Errorf("%w: valid_until is before valid_from", ErrInvalidCouponData)
+	}
+	for _, d := range []*string{req.DepartureFrom, req.DepartureTo} {
+		if d == nil {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", *d); err != nil {
+			return fmt.Errorf("%w: departure dates must be YYYY-MM-DD", ErrInvalidCouponData)
+		}
+	}
+	if req.DepartureFrom != nil && req.DepartureTo != nil && *req.DepartureTo < *req.DepartureFrom {
+		return fmt.Errorf("%w: departure_to is before departure_from", ErrInvalidCouponData)
+	}
+
+	coupon.Code = normalizeCouponCode(req.Code)
+	coupon.Name = req.Name
+	coupon.Description = req.Description
+	coupon.DiscountType = req.DiscountType
+	coupon.DiscountValue = req.DiscountValue
+	coupon.MaxDiscount = req.MaxDiscount
+	coupon.MinSpend = req.MinSpend
+	coupon.SetScope(req.RouteIDs, req.CruiseIDs, req.CabinTypeIDs)
+	coupon.DepartureFrom = req.DepartureFrom
+	coupon.DepartureTo = req.DepartureTo
+	coupon.NewUsersOnly = req.NewUsersOnly
+	coupon.RequiresClaim = req.RequiresClaim
+	if req.PerUserLimit != nil {
+		coupon.PerUserLimit = *req.PerUserLimit
+	}
+	coupon.TotalLimit = req.TotalLimit
+	coupon.Stackable = req.Stackable
+	coupon.ValidFrom = req.ValidFrom
+	coupon.ValidUntil = req.ValidUntil
+	coupon.ClaimValidDays = req.ClaimValidDays
+	if req.IsActive != nil {
+		coupon.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func uniqueCouponCodes(codes []string) []string {
+	seen := make(map[string]bool, len(codes))
+	unique := make([]string, 0, len(codes))
+	for _, code := range codes {
+		code = normalizeCouponCode(code)
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		unique = append(unique, code)
+	}
+	return unique
+}
+
+// IsCouponError reports whether err is a coupon validation failure
+func IsCouponError(err error) bool {
+	for _, target := range []error{
+		ErrCouponNotFound, ErrCouponInactive, ErrCouponExhausted, ErrCouponUserLimit,
+		ErrCouponNotOwned, ErrCouponNewUsersOnly, ErrCouponNotApplicable,
+		ErrCouponMinSpend, ErrCouponNotStackable, ErrCouponLoginRequired,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyCoupons(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	voyage := &domain.Voyage{RouteID: "route-1", CruiseID: "cruise-1", DepartureDate: "2026-05-10"}
+	booking := CouponBooking{
+		UserID: "user-1",
+		Voyage: voyage,
+		Items: []CouponItem{
+			{CabinTypeID: "balcony", Amount: 6000},
+			{CabinTypeID: "inside", Amount: 2000},
+		},
+	}
+	newCoupon := func(code, discountType string, value float64) *domain.Coupon {
+		c := &domain.Coupon{Code: code, DiscountType: discountType, DiscountValue: value, IsActive: true}
+		c.ID = uuid.New()
+		return c
+	}
+
+	t.Run("percentage discount is capped", func(t *testing.T) {
+		coupon := newCoupon("SPRING10", domain.CouponDiscountPercent, 10)
+		maxDiscount := 500.0
+		coupon.MaxDiscount = &maxDiscount
+
+		applied, err := applyCoupons([]*domain.Coupon{coupon}, booking, now)
+
+		require.NoError(t, err)
+		assert.Equal(t, 500.0, TotalCouponDiscount(applied))
+	})
+
+	t.Run("cabin type scope limits the discounted subtotal", func(t *testing.T) {
+		coupon := newCoupon("INSIDE20", domain.CouponDiscountPercent, 20)
+		coupon.SetScope(nil, nil, []string{"inside"})
+
+		applied, err := applyCoupons([]*domain.Coupon{coupon}, booking, now)
+
+		require.NoError(t, err)
+		assert.Equal(t, 400.0, TotalCouponDiscount(applied))
+	})
+
+	t.Run("minimum spend applies to the scoped subtotal", func(t *testing.T) {
+		coupon := newCoupon("INSIDE300", domain.CouponDiscountFixed, 300)
+		coupon.MinSpend = 3000
+		coupon.SetScope(nil, nil, []string{"inside"})
+
+		_, err := applyCoupons([]*domain.Coupon{coupon}, booking, now)
+
+		assert.ErrorIs(t, err, ErrCouponMinSpend)
+	})
+
+	t.Run("route and departure window must match", func(t *testing.T) {
+		coupon := newCoupon("JUNE", domain.CouponDiscountFixed, 100)
+		from := "2026-06-01"
+		coupon.DepartureFrom = &from
+
+		_, err := applyCoupons([]*domain.Coupon{coupon}, booking, now)
+		assert.ErrorIs(t, err, ErrCouponNotApplicable)
+
+		coupon = newCoupon("OTHER", domain.CouponDiscountFixed, 100)
+		coupon.SetScope([]string{"route-2"}, nil, nil)
+
+		_, err = applyCoupons([]*domain.Coupon{coupon}, booking, now)
+		assert.ErrorIs(t, err, ErrCouponNotApplicable)
+	})
+
+	t.Run("only stackable coupons combine", func(t *testing.T) {
+		first := newCoupon("A", domain.CouponDiscountFixed, 100)
+		second := newCoupon("B", domain.CouponDiscountFixed, 200)
+
+		_, err := applyCoupons([]*domain.Coupon{first, second}, booking, now)
+		assert.ErrorIs(t, err, ErrCouponNotStackable)
+
+		first.Stackable, second.Stackable = true, true
+		applied, err := applyCoupons([]*domain.Coupon{first, second}, booking, now)
+		require.NoError(t, err)
+		assert.Equal(t, 300.0, TotalCouponDiscount(applied))
+	})
+
+	t.Run("combined discounts never exceed the total", func(t *testing.T) {
+		first := newCoupon("BIG1", domain.CouponDiscountFixed, 5000)
+		second := newCoupon("BIG2", domain.CouponDiscountFixed, 5000)
+		first.Stackable, second.Stackable = true, true
+
+		applied, err := applyCoupons([]*domain.Coupon{first, second}, booking, now)
+
+		require.NoError(t, err)
+		assert.Equal(t, 8000.0, TotalCouponDiscount(applied))
+	})
+
+	t.Run("expired and exhausted coupons are rejected", func(t *testing.T) {
+		expired := newCoupon("OLD", domain.CouponDiscountFixed, 100)
+		until := now.Add(-time.Hour)
+		expired.ValidUntil = &until
+
+		_, err := applyCoupons([]*domain.Coupon{expired}, booking, now)
+		assert.ErrorIs(t, err, ErrCouponInactive)
+
+		exhausted := newCoupon("GONE", domain.CouponDiscountFixed, 100)
+		exhausted.TotalLimit, exhausted.RedeemedCount = 10, 10
+
+		_, err = applyCoupons([]*domain.Coupon{exhausted}, booking, now)
+		assert.ErrorIs(t, err, ErrCouponExhausted)
+	})
+}
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"backend/internal/currency"
+	"context"
+)
+
+// QuoteFromBase returns the rate from the base currency to the requested
+// one. Without a converter only the base currency is accepted. Handlers use
+// it to show catalog prices, which are held in the base currency.
+func QuoteFromBase(ctx context.Context, converter currency.Converter, code string) (currency.Quote, error) {
+	code, err := currency.Normalize(code)
+	if err != nil {
+		return currency.Quote{}, err
+	}
+	if code == currency.Base {
+		return currency.Identity(code), nil
+	}
+	if converter == nil {
+		return currency.Quote{}, currency.ErrUnsupportedCurrency
+	}
+	return converter.Quote(ctx, currency.Base, code)
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"errors"
+	"fmt"
+)
+
+// maxInfantsPerAdult is how many infants one adult may accompany in a cabin
+const maxInfantsPerAdult = 1
+
+var (
+	ErrCabinOverCapacity  = errors.New("too many guests for cabin")
+	ErrInfantRatio        = errors.New("too many infants per adult")
+	ErrBelowMinOccupancy  = errors.New("too few guests for cabin")
+	ErrAdultRequired      = errors.New("each cabin requires at least one adult")
+	ErrInvalidGuestCounts = errors.New("invalid guest counts")
+)
+
+// itemFare holds the priced components of a single order item
+// CS-003: Shared by Create and CalculateTotal
+type itemFare struct {
+	AdultTotal       float64
+	ChildTotal       float64
+	InfantTotal      float64
+	SingleSupplement float64
+	ExtraGuestCount  int
+	ExtraGuestTotal  float64
+	PortFee          float64
+	ServiceFee       float64
+	Subtotal         float64
+}
+
+// Fare returns the fare before port and service fees
+func (f itemFare) Fare() float64 {
+	return f.AdultTotal + f.ChildTotal + f.InfantTotal + f.SingleSupplement + f.ExtraGuestTotal
+}
+
+// calculateItemFare enforces the occupancy rules of a cabin and prices an item.
+//
+// Guests count against the cabin type's MaxGuests and the price's
+// MaxPassengers; infants occupy a berth but pay the infant fare. A single
+// paying guest in a cabin built for two or more pays the single supplement
+// on top of the adult fare. Paying guests beyond the cabin type's
+// StandardGuests (adults first, then children) pay the 3rd/4th-guest fares
+// when set.
+func calculateItemFare(price *domain.CabinPrice, adultCount, childCount, infantCount int) (itemFare, error) {
+	if adultCount < 0 || childCount < 0 || infantCount < 0 {
+		return itemFare{}, ErrInvalidGuestCounts
+	}
+	if adultCount == 0 {
+		return itemFare{}, ErrAdultRequired
+	}
+	if infantCount > adultCount*maxInfantsPerAdult {
+		return itemFare{}, ErrInfantRatio
+	}
+
+	standardGuests, maxGuests := price.CabinType.StandardGuests, price.CabinType.MaxGuests
+	if price.MaxPassengers > 0 && (maxGuests == 0 || price.MaxPassengers < maxGuests) {
+		maxGuests = price.MaxPassengers
+	}
+	guests := adultCount + childCount + infantCount
+	if maxGuests > 0 && guests > maxGuests {
+		return itemFare{}, fmt.Errorf("%w: %d guests, cabin allows %d", ErrCabinOverCapacity, guests, maxGuests)
+	}
+
+	paying := adultCount + childCount
+	fare := itemFare{
+		InfantTotal: price.InfantPrice * float64(infantCount),
+		PortFee:     price.PortFee * float64(paying),
+		ServiceFee:  price.ServiceFee * float64(paying),
+	}
+
+	solo := paying == 1 && (standardGuests >= 2 || price.MinPassengers >= 2)
+	switch {
+	case solo && price.SingleSupplement > 0:
+		fare.SingleSupplement = price.SingleSupplement
+	case price.MinPassengers > 0 && paying < price.MinPassengers:
+		return itemFare{}, fmt.Errorf("%w: %d guests, cabin requires %d", ErrBelowMinOccupancy, paying, price.MinPassengers)
+	}
+
+	// Guests up to the standard occupancy pay the regular fare
+	regularAdults, regularChildren := adultCount, childCount
+	if standardGuests > 0 && paying > standardGuests {
+		regularAdults = min(adultCount, standardGuests)
+		regularChildren = min(childCount, standardGuests-regularAdults)
+		extraAdults := adultCount - regularAdults
+		extraChildren := childCount - regularChildren
+
+		fare.ExtraGuestCount = extraAdults + extraChildren
+		fare.ExtraGuestTotal = firstPositive(price.ExtraAdultPrice, price.AdultPrice)*float64(extraAdults) +
+			firstPositive(price.ExtraChildPrice, price.ChildPrice)*float64(extraChildren)
+	}
+	fare.AdultTotal = price.AdultPrice * float64(regularAdults)
+	fare.ChildTotal = price.ChildPrice * float64(regularChildren)
+
+	fare.Subtotal = fare.Fare() + fare.PortFee + fare.ServiceFee
+	return fare, nil
+}
+
+// IsFareRuleError reports whether err is a guest count or occupancy violation
+func IsFareRuleError(err error) bool {
+	return errors.Is(err, ErrCabinOverCapacity) ||
+		errors.Is(err, ErrInfantRatio) ||
+		errors.Is(err, ErrBelowMinOccupancy) ||
+		errors.Is(err, ErrAdultRequired) ||
+		errors.Is(err, ErrInvalidGuestCounts)
+}
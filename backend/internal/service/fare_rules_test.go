@@ -0,0 +1,95 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateItemFare(t *testing.T) {
+	price := &domain.CabinPrice{
+		AdultPrice:       1000,
+		ChildPrice:       600,
+		InfantPrice:      100,
+		SingleSupplement: 500,
+		ExtraAdultPrice:  700,
+		ExtraChildPrice:  400,
+		PortFee:          50,
+		ServiceFee:       20,
+		MinPassengers:    1,
+		MaxPassengers:    4,
+		CabinType:        domain.CabinType{StandardGuests: 2, MaxGuests: 4},
+	}
+
+	tests := []struct {
+		name                     string
+		adults, children, infant int
+		want                     itemFare
+		wantErr                  error
+	}{
+		{
+			name:   "two adults pay the regular fare",
+			adults: 2,
+			want:   itemFare{AdultTotal: 2000, PortFee: 100, ServiceFee: 40, Subtotal: 2140},
+		},
+		{
+			name:   "solo adult pays the single supplement",
+			adults: 1,
+			want:   itemFare{AdultTotal: 1000, SingleSupplement: 500, PortFee: 50, ServiceFee: 20, Subtotal: 1570},
+		},
+		{
+			name:     "third and fourth guests pay the extra fares",
+			adults:   3,
+			children: 1,
+			want: itemFare{
+				AdultTotal: 2000, ExtraGuestCount: 2, ExtraGuestTotal: 1100,
+				PortFee: 200, ServiceFee: 80, Subtotal: 3380,
+			},
+		},
+		{
+			name:   "infant occupies a berth at the infant fare",
+			adults: 2, infant: 1,
+			want: itemFare{AdultTotal: 2000, InfantTotal: 100, PortFee: 100, ServiceFee: 40, Subtotal: 2240},
+		},
+		{
+			name:   "over capacity",
+			adults: 4, infant: 1,
+			wantErr: ErrCabinOverCapacity,
+		},
+		{
+			name:   "more infants than adults",
+			adults: 1, infant: 2,
+			wantErr: ErrInfantRatio,
+		},
+		{
+			name:     "cabin needs an adult",
+			children: 2,
+			wantErr:  ErrAdultRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fare, err := calculateItemFare(price, tt.adults, tt.children, tt.infant)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.True(t, IsFareRuleError(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, fare)
+		})
+	}
+
+	t.Run("below minimum occupancy without a supplement", func(t *testing.T) {
+		couplesOnly := *price
+		couplesOnly.SingleSupplement = 0
+		couplesOnly.MinPassengers = 2
+
+		_, err := calculateItemFare(&couplesOnly, 1, 0, 0)
+
+		assert.ErrorIs(t, err, ErrBelowMinOccupancy)
+	})
+}
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/payment"
+	"backend/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"gorm.io/datatypes"
+)
+
+var (
+	ErrIdentityInUse       = errors.New("identity is bound to another account")
+	ErrIdentityTypeBound   = errors.New("an identity of this type is already bound")
+	ErrIdentityNotBound    = errors.New("identity is not bound")
+	ErrLastLoginMethod     = errors.New("cannot unbind the only way to log in")
+	ErrInvalidIdentityType = errors.New("invalid identity type")
+	ErrAccountMergeBlocked = errors.New("account cannot be merged")
+)
+
+// IdentityService defines the interface for binding login identities to a
+// user account.
+//
+// Each identity is verified before it is bound: WeChat by a mini program
+// login code, phone by an SMS code and Alipay by an authorization code.
+// When the identity already belongs to another account, binding fails with
+// ErrIdentityInUse unless merge is set, in which case that account is
+// merged into the user's: its orders, frequent passengers and notifications
+// move over, the identities the user lacks come along, and it is deactivated.
+type IdentityService interface {
+	ListIdentities(ctx context.Context, userID string) ([]*IdentityView, error)
+
+	BindWechat(ctx context.Context, userID, code string, merge bool) (*BindIdentityResult, error)
+	BindPhone(ctx context.Context, userID, phone, code string, merge bool) (*BindIdentityResult, error)
+	BindAlipay(ctx context.Context, userID, authCode string, merge bool) (*BindIdentityResult, error)
+
+	// Unbind detaches an identity, keeping at least one way to log in
+	Unbind(ctx context.Context, userID, kind string) (*domain.User, error)
+
+	// ListLinkLogs lists the binding and merge history of a user
+	ListLinkLogs(ctx context.Context, userID string) ([]*domain.AccountLinkLog, error)
+}
+
+// IdentityView is a bound or unbound identity as shown to its user
+type IdentityView struct {
+	Type     string `json:"type"`
+	Bound    bool   `json:"bound"`
+	Identity string `json:"identity,omitempty"` // masked
+}
+
+// BindIdentityResult is the outcome of binding an identity
+type BindIdentityResult struct {
+	User  *domain.User           `json:"user"`
+	Merge *domain.AccountLinkLog `json:"merge,omitempty"` // set when another account was merged in
+}
+
+// wechatSessions exchanges WeChat mini program login codes
+type wechatSessions interface {
+	code2Session(code string) (*WechatSessionInfo, error)
+}
+
+// smsCodes checks SMS verification codes
+type smsCodes interface {
+	VerifyCode(ctx context.Context, phone, code string) error
+}
+
+// identityService implements IdentityService
+type identityService struct {
+	repo   repository.UserRepository
+	wechat wechatSessions
+	sms    smsCodes
+	alipay payment.AlipayAuth
+}
+
+// NewIdentityService creates a new identity service
+func NewIdentityService(repo repository.UserRepository, wechat *WechatAuthService, sms *SMSService, alipay payment.AlipayAuth) IdentityService {
+	return &identityService{repo: repo, wechat: wechat, sms: sms, alipay: alipay}
+}
+
+func (s *identityService) ListIdentities(ctx context.Context, userID string) ([]*IdentityView, error) {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	views := make([]*IdentityView, 0, len(domain.IdentityTypes))
+	for _, kind := range domain.IdentityTypes {
+		view := &IdentityView{Type: kind}
+		if identity := user.Identity(kind); identity != "" {
+			view.Bound = true
+			view.Identity = domain.MaskIdentity(kind, identity)
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+func (s *identityService) BindWechat(ctx context.Context, userID, code string, merge bool) (*BindIdentityResult, error) {
+	session, err := s.wechat.code2Session(code)
+	if err != nil {
+		return nil, ErrWechatAuthFailed
+	}
+
+	owner, err := s.repo.GetByWechatOpenID(ctx, session.OpenID)
+	if err != nil && session.UnionID != "" {
+		owner, err = s.repo.GetByWechatUnionID(ctx, session.UnionID)
+	}
+	if err != nil {
+		owner = nil
+	}
+
+	return s.bind(ctx, userID, domain.IdentityWechat, session.OpenID, owner, merge, func(user *domain.User) {
+		user.WechatOpenID = session.OpenID
+		user.WechatUnionID = session.UnionID
+	})
+}
+
+func (s *identityService) BindPhone(ctx context.Context, userID, phone, code string, merge bool) (*BindIdentityResult, error) {
+	if err := s.sms.VerifyCode(ctx, phone, code); err != nil {
+		return nil, err
+	}
+
+	owner, err := s.repo.GetByPhone(ctx, phone)
+	if err != nil {
+		owner = nil
+	}
+
+	return s.bind(ctx, userID, domain.IdentityPhone, phone, owner, merge, func(user *domain.User) {
+		user.Phone = phone
+		user.PhoneVerified = true
+	})
+}
+
+func (s *identityService) BindAlipay(ctx context.Context, userID, authCode string, merge bool) (*BindIdentityResult, error) {
+	alipayUserID, err := s.alipay.UserID(ctx, authCode)
+	if err != nil {
+		return nil, payment.ErrAlipayAuthFailed
+	}
+
+	owner, err := s.repo.GetByAlipayUserID(ctx, alipayUserID)
+	if err != nil {
+		owner = nil
+	}
+
+	return s.bind(ctx, userID, domain.IdentityAlipay, alipayUserID, owner, merge, func(user *domain.User) {
+		user.AlipayUserID = &alipayUserID
+	})
+}
+
+// bind attaches a verified identity to the user. owner is the account the
+// identity is bound to, if any
+func (s *identityService) bind(ctx context.Context, userID, kind, identity string, owner *domain.User, merge bool, attach func(*domain.User)) (*BindIdentityResult, error) {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	if owner != nil && owner.ID == user.ID {
+		// Bound already; this refreshes e.g. the WeChat UnionID
+		attach(user)
+		if err := s.repo.UpdateIdentities(ctx, user); err != nil {
+			return nil, fmt.Errorf("failed to update identity: %w", err)
+		}
+		return &BindIdentityResult{User: user}, nil
+	}
+	if user.Identity(kind) != "" {
+		return nil, ErrIdentityTypeBound
+	}
+
+	if owner == nil {
+		attach(user)
+		if err := s.repo.UpdateIdentities(ctx, user); err != nil {
+			return nil, fmt.Errorf("failed to bind identity: %w", err)
+		}
+		s.audit(ctx, &domain.AccountLinkLog{
+			UserID:       userID,
+			Action:       domain.AccountLinkBind,
+			IdentityType: kind,
+			Identity:     domain.MaskIdentity(kind, identity),
+		})
+		return &BindIdentityResult{User: user}, nil
+	}
+
+	if !merge {
+		return nil, ErrIdentityInUse
+	}
+	if owner.Status == domain.UserStatusBanned || !user.CanLogin() {
+		return nil, ErrAccountMergeBlocked
+	}
+
+	entry := mergeIdentities(user, owner)
+	attach(user)
+	entry.IdentityType = kind
+	entry.Identity = domain.MaskIdentity(kind, identity)
+	if err := s.repo.MergeUsers(ctx, owner, user, entry); err != nil {
+		return nil, fmt.Errorf("failed to merge accounts: %w", err)
+	}
+
+	log.Printf("Merged account %s into %s via %s: %d orders, %d passengers, %d notifications, %d refunds, %d invoices, %d coupons, %d price watches, %d points",
+		owner.ID, user.ID, kind, entry.OrdersMoved, entry.PassengersMoved, entry.NotificationsMoved,
+		entry.RefundsMoved, entry.InvoicesMoved, entry.CouponsMoved, entry.PriceWatchesMoved, entry.PointsMoved)
+	return &BindIdentityResult{User: user, Merge: entry}, nil
+}
+
+func (s *identityService) Unbind(ctx context.Context, userID, kind string) (*domain.User, error) {
+	if !domain.ValidIdentityType(kind) {
+		return nil, ErrInvalidIdentityType
+	}
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	identity := user.Identity(kind)
+	if identity == "" {
+		return nil, ErrIdentityNotBound
+	}
+	if user.LoginMethods() <= 1 {
+		return nil, ErrLastLoginMethod
+	}
+
+	user.ClearIdentity(kind)
+	if err := s.repo.UpdateIdentities(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to unbind identity: %w", err)
+	}
+	s.audit(ctx, &domain.AccountLinkLog{
+		UserID:       userID,
+		Action:       domain.AccountLinkUnbind,
+		IdentityType: kind,
+		Identity:     domain.MaskIdentity(kind, identity),
+	})
+	return user, nil
+}
+
+func (s *identityService) ListLinkLogs(ctx context.Context, userID string) ([]*domain.AccountLinkLog, error) {
+	return s.repo.ListAccountLinkLogs(ctx, userID)
+}
+
+// audit records a bind or unbind. The change stands even if its record
+// cannot be written
+func (s *identityService) audit(ctx context.Context, entry *domain.AccountLinkLog) {
+	if err := s.repo.CreateAccountLinkLog(ctx, entry); err != nil {
+		log.Printf("Failed to record %s of %s identity for user %s: %v", entry.Action, entry.IdentityType, entry.UserID, err)
+	}
+}
+
+// mergeIdentities moves the identities of source that user lacks to user,
+// drops the rest and deactivates source. It returns the merge record, with
+// the dropped identities as its detail
+func mergeIdentities(user, source *domain.User) *domain.AccountLinkLog {
+	dropped := make(map[string]string)
+	for _, kind := range domain.IdentityTypes {
+		identity := source.Identity(kind)
+		if identity == "" {
+			continue
+		}
+		if user.Identity(kind) == "" {
+			user.TakeIdentity(kind, source)
+			continue
+		}
+		dropped[kind] = domain.MaskIdentity(kind, identity)
+		source.ClearIdentity(kind)
+	}
+	source.Status = domain.UserStatusInactive
+
+	sourceID := source.ID.String()
+	entry := &domain.AccountLinkLog{
+		UserID:       user.ID.String(),
+		Action:       domain.AccountLinkMerge,
+		MergedUserID: &sourceID,
+	}
+	if len(dropped) > 0 {
+		if detail, err := json.Marshal(map[string]interface{}{"dropped_identities": dropped}); err == nil {
+			entry.Detail = datatypes.JSON(detail)
+		}
+	}
+	return entry
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockUserRepository mocks UserRepository
+type MockUserRepository struct {
+	repository.UserRepository
+	mock.Mock
+}
+
+func (m *MockUserRepository) GetByID(ctx context.Context, id string) (*domain.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByPhone(ctx context.Context, phone string) (*domain.User, error) {
+	args := m.Called(ctx, phone)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByAlipayUserID(ctx context.Context, alipayUserID string) (*domain.User, error) {
+	args := m.Called(ctx, alipayUserID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateIdentities(ctx context.Context, user *domain.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) MergeUsers(ctx context.Context, source, target *domain.User, entry *domain.AccountLinkLog) error {
+	args := m.Called(ctx, source, target, entry)
+	entry.OrdersMoved = 2
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) CreateAccountLinkLog(ctx context.Context, entry *domain.AccountLinkLog) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+// stubSMSCodes accepts the code 123456
+type stubSMSCodes struct{}
+
+func (stubSMSCodes) VerifyCode(ctx context.Context, phone, code string) error {
+	if code != "123456" {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+// stubAlipayAuth authorizes every code as one Alipay user
+type stubAlipayAuth struct {
+	userID string
+}
+
+func (s stubAlipayAuth) UserID(ctx context.Context, authCode string) (string, error) {
+	return s.userID, nil
+}
+
+func newIdentityUser(phone string) *domain.User {
+	user := &domain.User{Phone: phone, Status: domain.UserStatusActive}
+	user.ID = uuid.New()
+	return user
+}
+
+func TestIdentityService_BindPhone(t *testing.T) {
+	ctx := context.Background()
+	notFound := errors.New("record not found")
+
+	t.Run("binds an unused phone", func(t *testing.T) {
+		repo := new(MockUserRepository)
+		svc := &identityService{repo: repo, sms: stubSMSCodes{}}
+		user := newIdentityUser("")
+		user.WechatOpenID = "o-wechat-user"
+
+		repo.On("GetByPhone", ctx, "13800138000").Return(nil, notFound)
+		repo.On("GetByID", ctx, user.ID.String()).Return(user, nil)
+		repo.On("UpdateIdentities", ctx, user).Return(nil)
+		repo.On("CreateAccountLinkLog", ctx, mock.MatchedBy(func(e *domain.AccountLinkLog) bool {
+			return e.Action == domain.AccountLinkBind && e.IdentityType == domain.IdentityPhone && e.Identity == "138****8000"
+		})).Return(nil)
+
+		result, err := svc.BindPhone(ctx, user.ID.String(), "13800138000", "123456", false)
+		require.NoError(t, err)
+		assert.Equal(t, "13800138000", result.User.Phone)
+		assert.True(t, result.User.PhoneVerified)
+		assert.Nil(t, result.Merge)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("rejects a wrong code", func(t *testing.T) {
+		repo := new(MockUserRepository)
+		svc := &identityService{repo: repo, sms: stubSMSCodes{}}
+
+		_, err := svc.BindPhone(ctx, uuid.NewString(), "13800138000", "000000", false)
+		assert.ErrorIs(t, err, ErrInvalidCode)
+		repo.AssertNotCalled(t, "UpdateIdentities", mock.Anything, mock.Anything)
+	})
+
+	t.Run("reports a phone bound to another account", func(t *testing.T) {
+		repo := new(MockUserRepository)
+		svc := &identityService{repo: repo, sms: stubSMSCodes{}}
+		user := newIdentityUser("")
+		owner := newIdentityUser("13800138000")
+
+		repo.On("GetByPhone", ctx, "13800138000").Return(owner, nil)
+		repo.On("GetByID", ctx, user.ID.String()).Return(user, nil)
+
+		_, err := svc.BindPhone(ctx, user.ID.String(), "13800138000", "123456", false)
+		assert.ErrorIs(t, err, ErrIdentityInUse)
+		repo.AssertNotCalled(t, "MergeUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("refuses a second phone", func(t *testing.T) {
+		repo := new(MockUserRepository)
+		svc := &identityService{repo: repo, sms: stubSMSCodes{}}
+		user := newIdentityUser("13900139000")
+
+		repo.On("GetByPhone", ctx, "13800138000").Return(nil, notFound)
+		repo.On("GetByID", ctx, user.ID.String()).Return(user, nil)
+
+		_, err := svc.BindPhone(ctx, user.ID.String(), "13800138000", "123456", false)
+		assert.ErrorIs(t, err, ErrIdentityTypeBound)
+	})
+
+	t.Run("merges the account that holds the phone", func(t *testing.T) {
+		repo := new(MockUserRepository)
+		svc := &identityService{repo: repo, sms: stubSMSCodes{}}
+		alipayID := "2088102150477652"
+		user := newIdentityUser("")
+		user.WechatOpenID = "o-mini-program"
+		owner := newIdentityUser("13800138000")
+		owner.PhoneVerified = true
+		owner.WechatOpenID = "o-other-app"
+		owner.AlipayUserID = &alipayID
+
+		repo.On("GetByPhone", ctx, "13800138000").Return(owner, nil)
+		repo.On("GetByID", ctx, user.ID.String()).Return(user, nil)
+		repo.On("MergeUsers", ctx, owner, user, mock.Anything).Return(nil)
+
+		result, err := svc.BindPhone(ctx, user.ID.String(), "13800138000", "123456", true)
+		require.NoError(t, err)
+
+		// The user takes the phone and the Alipay identity it lacked, and
+		// keeps its own WeChat identity
+		assert.Equal(t, "13800138000", user.Phone)
+		assert.Equal(t, &alipayID, user.AlipayUserID)
+		assert.Equal(t, "o-mini-program", user.WechatOpenID)
+		assert.Zero(t, owner.LoginMethods())
+		assert.Equal(t, domain.UserStatusInactive, owner.Status)
+
+		require.NotNil(t, result.Merge)
+		assert.Equal(t, domain.AccountLinkMerge, result.Merge.Action)
+		assert.Equal(t, owner.ID.String(), *result.Merge.MergedUserID)
+		assert.Equal(t, 2, result.Merge.OrdersMoved)
+		assert.JSONEq(t, `{"dropped_identities":{"wechat":"o-ot****-app"}}`, string(result.Merge.Detail))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("does not merge a banned account", func(t *testing.T) {
+		repo := new(MockUserRepository)
+		svc := &identityService{repo: repo, sms: stubSMSCodes{}}
+		user := newIdentityUser("")
+		owner := newIdentityUser("13800138000")
+		owner.Status = domain.UserStatusBanned
+
+		repo.On("GetByPhone", ctx, "13800138000").Return(owner, nil)
+		repo.On("GetByID", ctx, user.ID.String()).Return(user, nil)
+
+		_, err := svc.BindPhone(ctx, user.ID.String(), "13800138000", "123456", true)
+		assert.ErrorIs(t, err, ErrAccountMergeBlocked)
+	})
+}
+
+func TestIdentityService_BindAlipay(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockUserRepository)
+	svc := &identityService{repo: repo, alipay: stubAlipayAuth{userID: "2088102150477652"}}
+	user := newIdentityUser("13800138000")
+
+	repo.On("GetByAlipayUserID", ctx, "2088102150477652").Return(nil, errors.New("record not found"))
+	repo.On("GetByID", ctx, user.ID.String()).Return(user, nil)
+	repo.On("UpdateIdentities", ctx, user).Return(nil)
+	repo.On("CreateAccountLinkLog", ctx, mock.Anything).Return(nil)
+
+	result, err := svc.BindAlipay(ctx, user.ID.String(), "auth-code", false)
+	require.NoError(t, err)
+	assert.Equal(t, "2088102150477652", result.User.Identity(domain.IdentityAlipay))
+}
+
+func TestIdentityService_Unbind(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("unbinds one of several identities", func(t *testing.T) {
+		repo := new(MockUserRepository)
+		svc := &identityService{repo: repo}
+		user := newIdentityUser("13800138000")
+		user.WechatOpenID = "o-mini-program"
+		user.WechatUnionID = "u-union"
+
+		repo.On("GetByID", ctx, user.ID.String()).Return(user, nil)
+		repo.On("UpdateIdentities", ctx, user).Return(nil)
+		repo.On("CreateAccountLinkLog", ctx, mock.MatchedBy(func(e *domain.AccountLinkLog) bool {
+			return e.Action == domain.AccountLinkUnbind && e.IdentityType == domain.IdentityWechat
+		})).Return(nil)
+
+		updated, err := svc.Unbind(ctx, user.ID.String(), domain.IdentityWechat)
+		require.NoError(t, err)
+		assert.Empty(t, updated.WechatOpenID)
+		assert.Empty(t, updated.WechatUnionID)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("keeps the last way to log in", func(t *testing.T) {
+		repo := new(MockUserRepository)
+		svc := &identityService{repo: repo}
+		user := newIdentityUser("13800138000")
+
+		repo.On("GetByID", ctx, user.ID.String()).Return(user, nil)
+
+		_, err := svc.Unbind(ctx, user.ID.String(), domain.IdentityPhone)
+		assert.ErrorIs(t, err, ErrLastLoginMethod)
+	})
+
+	t.Run("rejects unknown and unbound identities", func(t *testing.T) {
+		repo := new(MockUserRepository)
+		svc := &identityService{repo: repo}
+		user := newIdentityUser("13800138000")
+
+		repo.On("GetByID", ctx, user.ID.String()).Return(user, nil)
+
+		_, err := svc.Unbind(ctx, user.ID.String(), "email")
+		assert.ErrorIs(t, err, ErrInvalidIdentityType)
+		_, err = svc.Unbind(ctx, user.ID.String(), domain.IdentityAlipay)
+		assert.ErrorIs(t, err, ErrIdentityNotBound)
+	})
+}
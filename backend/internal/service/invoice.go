@@ -0,0 +1,321 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/invoice"
+	"backend/internal/pagination"
+	"backend/internal/repository"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvoiceNotFound      = errors.New("invoice not found")
+	ErrInvalidInvoice       = errors.New("invalid invoice request")
+	ErrOrderNotInvoiceable  = errors.New("order cannot be invoiced")
+	ErrInvoiceExists        = errors.New("order already has an invoice")
+	ErrInvoiceNotReviewable = errors.New("invoice cannot be reviewed")
+	ErrInvoiceIssueFailed   = errors.New("invoice could not be issued")
+)
+
+// InvoiceService defines the interface for electronic invoices (fapiao)
+type InvoiceService interface {
+	// RequestInvoice files an invoice request for a paid order; finance
+	// reviews it before it is issued
+	RequestInvoice(ctx context.Context, userID, orderID string, req InvoiceRequest) (*domain.Invoice, error)
+
+	// ListOrderInvoices lists an order's invoices, including red-letter ones
+	ListOrderInvoices(ctx context.Context, orderID string) ([]*domain.Invoice, error)
+
+	ListInvoices(ctx context.Context, filters repository.InvoiceFilters, paginator *pagination.Paginator) ([]*domain.Invoice, error)
+	GetInvoice(ctx context.Context, id string) (*domain.Invoice, error)
+
+	// Approve issues a pending invoice, or retries a failed one
+	Approve(ctx context.Context, id, reviewerID, note string) (*domain.Invoice, error)
+	Reject(ctx context.Context, id, reviewerID, note string) (*domain.Invoice, error)
+
+	// ReconcileRefund brings an order's invoices in line with its refunds:
+	// an issued invoice exceeding what the customer still paid is reversed
+	// by a red-letter invoice and the remainder queued for reissue
+	ReconcileRefund(ctx context.Context, orderID string) error
+}
+
+// InvoiceRequest represents a customer's invoice request
+type InvoiceRequest struct {
+	BuyerType string `json:"buyer_type" validate:"required,oneof=personal company"`
+	Title     string `json:"title" validate:"required,max=100"`
+	TaxID     string `json:"tax_id,omitempty"` // required for companies
+	Email     string `json:"email" validate:"required,email"`
+}
+
+// invoiceService implements InvoiceService
+type invoiceService struct {
+	repo     repository.InvoiceRepository
+	orders   repository.OrderRepository
+	provider invoice.Provider
+	storage  StorageService
+	now      func() time.Time
+}
+
+// NewInvoiceService creates a new invoice service
+func NewInvoiceService(
+	repo repository.InvoiceRepository,
+	orders repository.OrderRepository,
+	provider invoice.Provider,
+	storage StorageService,
+) InvoiceService {
+	return &invoiceService{
+		repo:     repo,
+		orders:   orders,
+		provider: provider,
+		storage:  storage,
+		now:      time.Now,
+	}
+}
+
+func (s *invoiceService) RequestInvoice(ctx context.Context, userID, orderID string, req InvoiceRequest) (*domain.Invoice, error) {
+	req.Title = strings.TrimSpace(req.Title)
+	req.TaxID = strings.ToUpper(strings.TrimSpace(req.TaxID))
+	if req.Title == "" {
+		return nil, fmt.Errorf("%w: title is required", ErrInvalidInvoice)
+	}
+	switch req.BuyerType {
+	case domain.InvoiceBuyerCompany:
+		if !domain.ValidTaxID(req.TaxID) {
+			return nil, fmt.Errorf("%w: a valid tax ID is required for companies", ErrInvalidInvoice)
+		}
+	case domain.InvoiceBuyerPersonal:
+		if req.TaxID != "" && !domain.ValidTaxID(req.TaxID) {
+			return nil, fmt.Errorf("%w: tax ID is malformed", ErrInvalidInvoice)
+		}
+	default:
+		return nil, fmt.Errorf("%w: buyer type must be personal or company", ErrInvalidInvoice)
+	}
+
+	order, err := s.orders.GetOrderWithDetails(ctx, orderID)
+	if err != nil {
+		return nil, ErrOrderNotFound
+	}
+	if !order.IsPaid() {
+		return nil, ErrOrderNotInvoiceable
+	}
+
+	existing, err := s.repo.ListByOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	for _, inv := range existing {
+		if inv.Kind == domain.InvoiceKindBlue && inv.IsOpen() {
+			return nil, ErrInvoiceExists
+		}
+	}
+
+	amount, err := s.invoiceable(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+	if amount <= 0 {
+		return nil, ErrOrderNotInvoiceable
+	}
+
+	inv := &domain.Invoice{
+		OrderID:   orderID,
+		UserID:    &userID,
+		Kind:      domain.InvoiceKindBlue,
+		BuyerType: req.BuyerType,
+		Title:     req.Title,
+		TaxID:     req.TaxID,
+		Email:     req.Email,
+		Amount:    amount,
+		Currency:  order.Currency,
+		Status:    domain.InvoiceStatusPending,
+	}
+	if err := s.repo.Create(ctx, inv); err != nil {
+		return nil, fmt.Errorf("failed to create invoice: %w", err)
+	}
+	return inv, nil
+}
+
+func (s *invoiceService) ListOrderInvoices(ctx context.Context, orderID string) ([]*domain.Invoice, error) {
+	return s.repo.ListByOrder(ctx, orderID)
+}
+
+func (s *invoiceService) ListInvoices(ctx context.Context, filters repository.InvoiceFilters, paginator *pagination.Paginator) ([]*domain.Invoice, error) {
+	return s.repo.List(ctx, filters, paginator)
+}
+
+func (s *invoiceService) GetInvoice(ctx context.Context, id string) (*domain.Invoice, error) {
+	inv, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrInvoiceNotFound
+	}
+	return inv, nil
+}
+
+func (s *invoiceService) Approve(ctx context.Context, id, reviewerID, note string) (*domain.Invoice, error) {
+	inv, err := s.GetInvoice(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !inv.CanIssue() {
+		return nil, ErrInvoiceNotReviewable
+	}
+
+	inv.Review(reviewerID, note, s.now())
+	if err := s.issue(ctx, inv); err != nil {
+		return inv, err
+	}
+	return inv, nil
+}
+
+func (s *invoiceService) Reject(ctx context.Context, id, reviewerID, note string) (*domain.Invoice, error) {
+	inv, err := s.GetInvoice(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Red-letter invoices must go through once the order is refunded
+	if inv.Kind != domain.InvoiceKindBlue || !inv.CanIssue() {
+		return nil, ErrInvoiceNotReviewable
+	}
+
+	inv.Reject(reviewerID, note, s.now())
+	if err := s.repo.Update(ctx, inv); err != nil {
+		return nil, fmt.Errorf("failed to reject invoice: %w", err)
+	}
+	return inv, nil
+}
+
+func (s *invoiceService) ReconcileRefund(ctx context.Context, orderID string) error {
+	invoices, err := s.repo.ListByOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	var open *domain.Invoice
+	for _, inv := range invoices {
+		if inv.Kind == domain.InvoiceKindBlue && inv.IsOpen() {
+			open = inv
+		}
+	}
+	if open == nil {
+		return nil
+	}
+
+	order, err := s.orders.GetOrderWithDetails(ctx, orderID)
+	if err != nil {
+		return ErrOrderNotFound
+	}
+	amount, err := s.invoiceable(ctx, order)
+	if err != nil {
+		return err
+	}
+	if open.Amount <= amount {
+		return nil
+	}
+
+	// Not issued yet: invoice what is left, if anything
+	if open.Status != domain.InvoiceStatusIssued {
+		if amount <= 0 {
+			open.Status = domain.InvoiceStatusRejected
+			open.ReviewNote = "订单已全额退款"
+		} else {
+			open.Amount = amount
+		}
+		return s.repo.Update(ctx, open)
+	}
+
+	// Issued: reverse it, unless a reversal failed earlier and awaits a retry
+	for _, inv := range invoices {
+		if inv.Kind == domain.InvoiceKindRed && inv.OriginalID != nil && *inv.OriginalID == open.ID.String() {
+			return nil
+		}
+	}
+	red := open.Reversal()
+	if err := s.repo.Create(ctx, red); err != nil {
+		return fmt.Errorf("failed to create red-letter invoice: %w", err)
+	}
+	return s.issue(ctx, red)
+}
+
+// issue sends an invoice to the provider and stores the PDF. Once a
+// red-letter invoice is issued, the invoice it reverses is marked reversed
+// and whatever the customer still paid is queued for reissue.
+func (s *invoiceService) issue(ctx context.Context, inv *domain.Invoice) error {
+	order, err := s.orders.GetByID(ctx, inv.OrderID)
+	if err != nil {
+		return ErrOrderNotFound
+	}
+
+	req := invoice.IssueRequest{Invoice: inv, Order: order}
+	if inv.OriginalID != nil {
+		if req.Original, err = s.repo.GetByID(ctx, *inv.OriginalID); err != nil {
+			return fmt.Errorf("failed to load reversed invoice: %w", err)
+		}
+	}
+
+	result, err := s.provider.Issue(ctx, req)
+	if err == nil {
+		var fileURL string
+		fileURL, err = s.storage.UploadFile(ctx, bytes.NewReader(result.PDF), result.InvoiceNo+".pdf", "application/pdf", int64(len(result.PDF)))
+		if err == nil {
+			inv.MarkIssued(s.provider.Name(), result.InvoiceNo, fileURL, result.IssuedAt)
+		}
+	}
+	if err != nil {
+		log.Printf("Failed to issue invoice %s: %v", inv.ID, err)
+		inv.MarkFailed(err)
+	}
+
+	if updateErr := s.repo.Update(ctx, inv); updateErr != nil {
+		return fmt.Errorf("failed to update invoice: %w", updateErr)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvoiceIssueFailed, err)
+	}
+
+	if req.Original == nil {
+		return nil
+	}
+	req.Original.Status = domain.InvoiceStatusReversed
+	if err := s.repo.Update(ctx, req.Original); err != nil {
+		return fmt.Errorf("failed to mark invoice %s reversed: %w", req.Original.ID, err)
+	}
+
+	amount, err := s.invoiceable(ctx, order)
+	if err != nil {
+		return err
+	}
+	if amount <= 0 {
+		return nil
+	}
+	if err := s.repo.Create(ctx, req.Original.Reissue(amount)); err != nil {
+		return fmt.Errorf("failed to queue reissued invoice: %w", err)
+	}
+	return nil
+}
+
+// invoiceable returns what the customer paid for the order net of refunds,
+// counting refunds still under way
+func (s *invoiceService) invoiceable(ctx context.Context, order *domain.Order) (float64, error) {
+	paginator := &pagination.Paginator{Page: 1, PageSize: 1000}
+	refunds, err := s.orders.ListRefundRequests(ctx, repository.RefundFilters{OrderID: order.ID.String()}, paginator)
+	if err != nil {
+		return 0, err
+	}
+
+	var refunded float64
+	for _, refund := range refunds {
+		switch refund.Status {
+		case domain.RefundStatusPending, domain.RefundStatusApproved,
+			domain.RefundStatusProcessing, domain.RefundStatusCompleted:
+			refunded += refund.RefundAmount
+		}
+	}
+
+	return roundMoney(max(order.TotalAmount-order.DiscountAmount-refunded, 0)), nil
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/invoice"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryInvoiceRepository keeps invoices in memory
+type memoryInvoiceRepository struct {
+	repository.InvoiceRepository
+	invoices []*domain.Invoice
+}
+
+func (r *memoryInvoiceRepository) Create(ctx context.Context, inv *domain.Invoice) error {
+	inv.ID = uuid.New()
+	r.invoices = append(r.invoices, inv)
+	return nil
+}
+
+func (r *memoryInvoiceRepository) GetByID(ctx context.Context, id string) (*domain.Invoice, error) {
+	for _, inv := range r.invoices {
+		if inv.ID.String() == id {
+			return inv, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (r *memoryInvoiceRepository) ListByOrder(ctx context.Context, orderID string) ([]*domain.Invoice, error) {
+	var invoices []*domain.Invoice
+	for _, inv := range r.invoices {
+		if inv.OrderID == orderID {
+			invoices = append(invoices, inv)
+		}
+	}
+	return invoices, nil
+}
+
+func (r *memoryInvoiceRepository) Update(ctx context.Context, inv *domain.Invoice) error {
+	return nil
+}
+
+// MockStorageService mocks StorageService
+type MockStorageService struct {
+	StorageService
+	mock.Mock
+}
+
+func (m *MockStorageService) UploadFile(ctx context.Context, file io.Reader, filename string, contentType string, size int64) (string, error) {
+	args := m.Called(ctx, file, filename, contentType, size)
+	return args.String(0), args.Error(1)
+}
+
+func TestInvoiceService_RequestInvoice(t *testing.T) {
+	ctx := context.Background()
+	order := &domain.Order{TotalAmount: 10000, DiscountAmount: 400, Currency: "CNY", PaymentStatus: domain.PaymentStatusPaid}
+	order.ID = uuid.New()
+	orderID := order.ID.String()
+
+	tests := []struct {
+		name string
+		req  InvoiceRequest
+		err  error
+	}{
+		{name: "personal", req: InvoiceRequest{BuyerType: "personal", Title: "张三", Email: "a@example.com"}},
+		{name: "company", req: InvoiceRequest{BuyerType: "company", Title: "上海某某旅游有限公司", TaxID: "91310000MA1FL8XQ3A", Email: "a@example.com"}},
+		{name: "company without tax ID", req: InvoiceRequest{BuyerType: "company", Title: "上海某某旅游有限公司", Email: "a@example.com"}, err: ErrInvalidInvoice},
+		{name: "malformed tax ID", req: InvoiceRequest{BuyerType: "company", Title: "上海某某旅游有限公司", TaxID: "9131-0000", Email: "a@example.com"}, err: ErrInvalidInvoice},
+		{name: "blank title", req: InvoiceRequest{BuyerType: "personal", Title: "  ", Email: "a@example.com"}, err: ErrInvalidInvoice},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders := new(MockOrderRepository)
+			orders.On("GetOrderWithDetails", ctx, orderID).Return(order, nil)
+			orders.On("ListRefundRequests", ctx, mock.Anything, mock.Anything).Return([]*domain.RefundRequest{
+				{RefundAmount: 1000, Status: domain.RefundStatusCompleted},
+				{RefundAmount: 500, Status: domain.RefundStatusRejected},
+			}, nil)
+			svc := NewInvoiceService(&memoryInvoiceRepository{}, orders, nil, nil)
+
+			inv, err := svc.RequestInvoice(ctx, "user-1", orderID, tt.req)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 8600.0, inv.Amount)
+			assert.Equal(t, domain.InvoiceStatusPending, inv.Status)
+
+			_, err = svc.RequestInvoice(ctx, "user-1", orderID, tt.req)
+			assert.ErrorIs(t, err, ErrInvoiceExists)
+		})
+	}
+}
+
+func TestInvoiceService_ReconcileRefund(t *testing.T) {
+	ctx := context.Background()
+	order := &domain.Order{OrderNumber: "CB20260601001", TotalAmount: 10000, Currency: "CNY", PaymentStatus: domain.PaymentStatusPaid}
+	order.ID = uuid.New()
+	orderID := order.ID.String()
+
+	// Refunds start out rejected and complete as the test goes on
+	partial := &domain.RefundRequest{RefundAmount: 3000, Status: domain.RefundStatusRejected}
+	rest := &domain.RefundRequest{RefundAmount: 7000, Status: domain.RefundStatusRejected}
+	orders := new(MockOrderRepository)
+	orders.On("GetOrderWithDetails", ctx, orderID).Return(order, nil)
+	orders.On("GetByID", ctx, orderID).Return(order, nil)
+	orders.On("ListRefundRequests", ctx, mock.Anything, mock.Anything).Return([]*domain.RefundRequest{partial, rest}, nil)
+
+	provider, err := invoice.NewFileProvider(t.TempDir(), invoice.Seller{Name: "邮轮旅行社", TaxID: "91310000MA1FL8XQ3A"})
+	require.NoError(t, err)
+	storage := new(MockStorageService)
+	storage.On("UploadFile", ctx, mock.Anything, mock.Anything, "application/pdf", mock.Anything).Return("https://files.example.com/invoice.pdf", nil)
+
+	repo := &memoryInvoiceRepository{}
+	svc := NewInvoiceService(repo, orders, provider, storage)
+
+	blue, err := svc.RequestInvoice(ctx, "user-1", orderID, InvoiceRequest{BuyerType: "personal", Title: "张三", Email: "a@example.com"})
+	require.NoError(t, err)
+	_, err = svc.Approve(ctx, blue.ID.String(), "finance-1", "")
+	require.NoError(t, err)
+	assert.Equal(t, domain.InvoiceStatusIssued, blue.Status)
+	assert.NotEmpty(t, blue.InvoiceNo)
+
+	// Refunding part of the order reverses the invoice and queues the rest
+	partial.Status = domain.RefundStatusCompleted
+	require.NoError(t, svc.ReconcileRefund(ctx, orderID))
+
+	require.Len(t, repo.invoices, 3)
+	red, reissued := repo.invoices[1], repo.invoices[2]
+	assert.Equal(t, domain.InvoiceStatusReversed, blue.Status)
+	assert.Equal(t, domain.InvoiceKindRed, red.Kind)
+	assert.Equal(t, domain.InvoiceStatusIssued, red.Status)
+	assert.Equal(t, -10000.0, red.Amount)
+	assert.Equal(t, blue.ID.String(), *red.OriginalID)
+	assert.Equal(t, domain.InvoiceKindBlue, reissued.Kind)
+	assert.Equal(t, domain.InvoiceStatusPending, reissued.Status)
+	assert.Equal(t, 7000.0, reissued.Amount)
+
+	// Refunding the rest before the reissue is approved withdraws it
+	rest.Status = domain.RefundStatusCompleted
+	require.NoError(t, svc.ReconcileRefund(ctx, orderID))
+	assert.Equal(t, domain.InvoiceStatusRejected, reissued.Status)
+	assert.Len(t, repo.invoices, 3)
+}
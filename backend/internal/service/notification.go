@@ -70,6 +70,12 @@ type NotificationService interface {
 	// SendRefundApprovedNotification sends notification when refund is approved
 	SendRefundApprovedNotification(ctx context.Context, userID string, refund *domain.RefundRequest) error
 
+	// SendRefundCompletedNotification sends notification when the refund has reached the customer
+	SendRefundCompletedNotification(ctx context.Context, userID string, refund *domain.RefundRequest) error
+
+	// SendRefundFailedNotification sends notification when the provider could not return the money
+	SendRefundFailedNotification(ctx context.Context, userID string, refund *domain.RefundRequest) error
+
 	// SendInventoryAlertNotification sends inventory alert to admins
 	SendInventoryAlertNotification(ctx context.Context, voyageID string, cabinTypeID string, remaining int) error
 }
@@ -410,6 +416,50 @@ func (s *notificationService) SendRefundApprovedNotification(ctx context.Context
 	return err
 }
 
+// SendRefundCompletedNotification sends notification when refund is completed
+func (s *notificationService) SendRefundCompletedNotification(ctx context.Context, userID string, refund *domain.RefundRequest) error {
+	refundID := refund.ID.String()
+	req := CreateNotificationRequest{
+		UserID:  userID,
+		Type:    domain.NotificationTypeRefund,
+		Title:   "退款已到账",
+		Content: fmt.Sprintf("您的退款 %.2f 已原路退回，请注意查收。", refund.RefundAmount),
+		Data: &domain.NotificationData{
+			RefundID:     &refundID,
+			RefundAmount: refund.RefundAmount,
+		},
+		Priority:   domain.NotificationPriorityHigh,
+		ActionType: domain.NotificationActionViewRefund,
+		SourceID:   &refundID,
+		SourceType: domain.NotificationTypeRefund,
+	}
+
+	_, err := s.Create(ctx, req)
+	return err
+}
+
+// SendRefundFailedNotification sends notification when refund failed
+func (s *notificationService) SendRefundFailedNotification(ctx context.Context, userID string, refund *domain.RefundRequest) error {
+	refundID := refund.ID.String()
+	req := CreateNotificationRequest{
+		UserID:  userID,
+		Type:    domain.NotificationTypeRefund,
+		Title:   "退款失败",
+		Content: fmt.Sprintf("您的退款 %.2f 未能原路退回，客服将尽快与您联系处理。", refund.RefundAmount),
+		Data: &domain.NotificationData{
+			RefundID:     &refundID,
+			RefundAmount: refund.RefundAmount,
+		},
+		Priority:   domain.NotificationPriorityHigh,
+		ActionType: domain.NotificationActionViewRefund,
+		SourceID:   &refundID,
+		SourceType: domain.NotificationTypeRefund,
+	}
+
+	_, err := s.Create(ctx, req)
+	return err
+}
+
 // SendInventoryAlertNotification sends inventory alert to admins
 func (s *notificationService) SendInventoryAlertNotification(ctx context.Context, voyageID string, cabinTypeID string, remaining int) error {
 	// Query admin users from repository instead of using hardcoded ID
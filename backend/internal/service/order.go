@@ -1,12 +1,14 @@
 package service
 
 import (
+	"backend/internal/currency"
 	"backend/internal/domain"
 	"backend/internal/pagination"
 	"backend/internal/repository"
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
@@ -57,8 +59,9 @@ type OrderService interface {
 	// Delete deletes an order (admin only)
 	Delete(ctx context.Context, id string) error
 
-	// CalculateTotal calculates order total from items
-	CalculateTotal(ctx context.Context, items []OrderItemRequest) (OrderCalculation, error)
+	// CalculateTotal calculates order total from items, applying coupons and
+	// converting to the requested currency
+	CalculateTotal(ctx context.Context, req CalculateOrderRequest) (OrderCalculation, error)
 }
 
 // CreateOrderRequest represents a request to create an order
@@ -72,6 +75,17 @@ type CreateOrderRequest struct {
 	ContactPhone string             `json:"contact_phone" validate:"required"`
 	ContactEmail string             `json:"contact_email" validate:"required,email"`
 	Remark       string             `json:"remark,omitempty"`
+	Currency     string             `json:"currency,omitempty"`
+	CouponCodes  []string           `json:"coupon_codes,omitempty"`
+}
+
+// CalculateOrderRequest represents a request to price order items
+type CalculateOrderRequest struct {
+	UserID      string             `json:"-"`
+	VoyageID    string             `json:"voyage_id"`
+	Items       []OrderItemRequest `json:"items"`
+	CouponCodes []string           `json:"coupon_codes,omitempty"`
+	Currency    string             `json:"currency,omitempty"` // empty means the base currency
 }
 
 // OrderItemRequest represents an item in an order
@@ -126,24 +140,33 @@ type ListOrdersRequest struct {
 
 // OrderCalculation represents the result of order calculation
 type OrderCalculation struct {
-	Subtotal       float64           `json:"subtotal"`
-	PortFee        float64           `json:"port_fee"`
-	ServiceFee     float64           `json:"service_fee"`
-	DiscountAmount float64           `json:"discount_amount"`
-	TotalAmount    float64           `json:"total_amount"`
-	Items          []ItemCalculation `json:"items"`
+	Currency        string            `json:"currency"`
+	ExchangeRate    float64           `json:"exchange_rate"` // Currency units per BaseCurrency unit
+	RateAsOf        *time.Time        `json:"rate_as_of,omitempty"`
+	Subtotal        float64           `json:"subtotal"`
+	PortFee         float64           `json:"port_fee"`
+	ServiceFee      float64           `json:"service_fee"`
+	DiscountAmount  float64           `json:"discount_amount"`
+	TotalAmount     float64           `json:"total_amount"`
+	BaseCurrency    string            `json:"base_currency"`
+	BaseTotalAmount float64           `json:"base_total_amount"`
+	Items           []ItemCalculation `json:"items"`
+	Coupons         []AppliedCoupon   `json:"coupons,omitempty"`
 }
 
 // ItemCalculation represents calculation for a single item
 type ItemCalculation struct {
-	CabinTypeID string  `json:"cabin_type_id"`
-	CabinNumber string  `json:"cabin_number"`
-	AdultPrice  float64 `json:"adult_price"`
-	ChildPrice  float64 `json:"child_price"`
-	InfantPrice float64 `json:"infant_price"`
-	PortFee     float64 `json:"port_fee"`
-	ServiceFee  float64 `json:"service_fee"`
-	Subtotal    float64 `json:"subtotal"`
+	CabinTypeID      string  `json:"cabin_type_id"`
+	CabinNumber      string  `json:"cabin_number"`
+	AdultPrice       float64 `json:"adult_price"`
+	ChildPrice       float64 `json:"child_price"`
+	InfantPrice      float64 `json:"infant_price"`
+	SingleSupplement float64 `json:"single_supplement"`
+	ExtraGuestCount  int     `json:"extra_guest_count"`
+	ExtraGuestPrice  float64 `json:"extra_guest_price"` // 3rd/4th guests
+	PortFee          float64 `json:"port_fee"`
+	ServiceFee       float64 `json:"service_fee"`
+	Subtotal         float64 `json:"subtotal"`
 }
 
 // orderService implements OrderService
@@ -154,9 +177,18 @@ type orderService struct {
 	priceRepo     repository.PriceRepository
 	inventoryRepo repository.InventoryRepository
 	stateService  OrderStateService
+	currency      currency.Converter
+	coupons       CouponService
+	policies      RefundPolicyService
 	redis         *redis.Client
 }
 
+// inventoryChangeNotifier is implemented by inventory repositories that
+// broadcast availability changes (see realtime.NewPublishingInventoryRepository)
+type inventoryChangeNotifier interface {
+	NotifyInventoryChanged(ctx context.Context, voyageID, cabinTypeID, action string, quantity int)
+}
+
 // NewOrderService creates a new order service
 func NewOrderService(
 	orderRepo repository.OrderRepository,
@@ -164,6 +196,9 @@ func NewOrderService(
 	cabinRepo repository.CabinRepository,
 	priceRepo repository.PriceRepository,
 	inventoryRepo repository.InventoryRepository,
+	converter currency.Converter,
+	coupons CouponService,
+	policies RefundPolicyService,
 	redisClients ...*redis.Client,
 ) OrderService {
 	stateService := NewOrderStateService(orderRepo, inventoryRepo)
@@ -178,38 +213,13 @@ func NewOrderService(
 		priceRepo:     priceRepo,
 		inventoryRepo: inventoryRepo,
 		stateService:  stateService,
+		currency:      converter,
+		coupons:       coupons,
+		policies:      policies,
 		redis:         redisClient,
 	}
 }
 
-// itemSubtotal holds the breakdown of a calculated item subtotal
-// CS-003: Extracted from duplicated logic in Create and CalculateTotal
-type itemSubtotal struct {
-	AdultTotal  float64
-	ChildTotal  float64
-	InfantTotal float64
-	PortFee     float64
-	ServiceFee  float64
-	Subtotal    float64
-}
-
-// calculateItemSubtotal computes the price breakdown for a single order item
-func calculateItemSubtotal(price *domain.CabinPrice, adultCount, childCount, infantCount int) itemSubtotal {
-	adultTotal := price.AdultPrice * float64(adultCount)
-	childTotal := price.ChildPrice * float64(childCount)
-	infantTotal := price.InfantPrice * float64(infantCount)
-	portFee := price.PortFee * float64(adultCount+childCount)
-	serviceFee := price.ServiceFee * float64(adultCount+childCount)
-	return itemSubtotal{
-		AdultTotal:  adultTotal,
-		ChildTotal:  childTotal,
-		InfantTotal: infantTotal,
-		PortFee:     portFee,
-		ServiceFee:  serviceFee,
-		Subtotal:    adultTotal + childTotal + infantTotal + portFee + serviceFee,
-	}
-}
-
 func (s *orderService) Create(ctx context.Context, req CreateOrderRequest) (*domain.Order, error) {
 	// Validate voyage exists
 	voyage, err := s.voyageRepo.GetByID(ctx, req.VoyageID)
@@ -231,14 +241,26 @@ func (s *orderService) Create(ctx context.Context, req CreateOrderRequest) (*dom
 		return nil, ErrInvalidPassengerCount
 	}
 
+	// Lock the exchange rate before any inventory is held
+	quote, err := QuoteFromBase(ctx, s.currency, req.Currency)
+	if err != nil {
+		return nil, err
+	}
+
 	now := time.Now().UTC()
 	expiresAt := now.Add(15 * time.Minute) // 15 minutes to pay (aligned with lock timeout)
+	rateLockedAt := now.Format(time.RFC3339)
 
 	order := &domain.Order{
 		OrderNumber:    generateOrderNumber(),
 		VoyageID:       req.VoyageID,
 		CruiseID:       req.CruiseID,
 		TotalAmount:    0,
+		Currency:       quote.To,
+		BaseCurrency:   quote.From,
+		ExchangeRate:   quote.Rate,
+		RateSource:     quote.Source,
+		RateLockedAt:   &rateLockedAt,
 		Status:         domain.OrderStatusPending,
 		PaymentStatus:  domain.PaymentStatusUnpaid,
 		PassengerCount: totalPassengers,
@@ -264,6 +286,8 @@ func (s *orderService) Create(ctx context.Context, req CreateOrderRequest) (*dom
 
 		var totalAmount float64
 		var cabinCount int
+		var priceTypes []string
+		booking := CouponBooking{UserID: req.UserID, Voyage: voyage}
 
 		// Process each item and lock inventory
 		for _, itemReq := range req.Items {
@@ -294,26 +318,32 @@ func (s *orderService) Create(ctx context.Context, req CreateOrderRequest) (*dom
 				return fmt.Errorf("failed to lock cabin: %w", err)
 			}
 
-			// CS-003: Use extracted helper for price calculation
-			calc := calculateItemSubtotal(price, itemReq.AdultCount, itemReq.ChildCount, itemReq.InfantCount)
+			// CS-003: Shared occupancy and fare rules
+			calc, err := calculateItemFare(price, itemReq.AdultCount, itemReq.ChildCount, itemReq.InfantCount)
+			if err != nil {
+				return err
+			}
 
 			orderItem := &domain.OrderItem{
-				OrderID:       order.ID.String(),
-				CabinID:       itemReq.CabinID,
-				CabinTypeID:   itemReq.CabinTypeID,
-				VoyageID:      req.VoyageID,
-				CabinNumber:   cabin.CabinNumber,
-				PriceSnapshot: price.AdultPrice,
-				AdultCount:    itemReq.AdultCount,
-				ChildCount:    itemReq.ChildCount,
-				InfantCount:   itemReq.InfantCount,
-				AdultPrice:    price.AdultPrice,
-				ChildPrice:    price.ChildPrice,
-				InfantPrice:   price.InfantPrice,
-				PortFee:       calc.PortFee,
-				ServiceFee:    calc.ServiceFee,
-				Subtotal:      calc.Subtotal,
-				Status:        domain.OrderItemStatusConfirmed,
+				OrderID:          order.ID.String(),
+				CabinID:          itemReq.CabinID,
+				CabinTypeID:      itemReq.CabinTypeID,
+				VoyageID:         req.VoyageID,
+				CabinNumber:      cabin.CabinNumber,
+				PriceSnapshot:    price.AdultPrice,
+				AdultCount:       itemReq.AdultCount,
+				ChildCount:       itemReq.ChildCount,
+				InfantCount:      itemReq.InfantCount,
+				AdultPrice:       price.AdultPrice,
+				ChildPrice:       price.ChildPrice,
+				InfantPrice:      price.InfantPrice,
+				PortFee:          calc.PortFee,
+				ServiceFee:       calc.ServiceFee,
+				SingleSupplement: calc.SingleSupplement,
+				ExtraGuestCount:  calc.ExtraGuestCount,
+				ExtraGuestAmount: calc.ExtraGuestTotal,
+				Subtotal:         calc.Subtotal,
+				Status:           domain.OrderItemStatusConfirmed,
 			}
 
 			if err := txRepo.CreateOrderItem(ctx, orderItem); err != nil {
@@ -324,10 +354,30 @@ func (s *orderService) Create(ctx context.Context, req CreateOrderRequest) (*dom
 
 			totalAmount += calc.Subtotal
 			cabinCount++
+			priceTypes = append(priceTypes, price.PriceType)
+			booking.Items = append(booking.Items, CouponItem{CabinTypeID: itemReq.CabinTypeID, Amount: calc.Subtotal})
 		}
 
-		// Update order total
-		order.TotalAmount = totalAmount
+		// Coupons are validated against the priced items and redeemed in the
+		// same transaction so a failed order never consumes them
+		applied, err := s.evaluateCoupons(ctx, booking, req.CouponCodes)
+		if err != nil {
+			return err
+		}
+		if len(applied) > 0 {
+			if err := s.coupons.Redeem(ctx, tx, order.ID.String(), booking, applied); err != nil {
+				return err
+			}
+			order.DiscountAmount = quote.Convert(TotalCouponDiscount(applied))
+		}
+
+		if err := s.snapshotRefundPolicy(ctx, order, voyage, priceTypes); err != nil {
+			return err
+		}
+
+		// Update order total; items are priced in the base currency
+		order.BaseTotalAmount = totalAmount
+		order.TotalAmount = quote.Convert(totalAmount)
 		order.CabinCount = cabinCount
 		if err := txRepo.Update(ctx, order); err != nil {
 			return fmt.Errorf("failed to update order total: %w", err)
@@ -375,9 +425,43 @@ func (s *orderService) Create(ctx context.Context, req CreateOrderRequest) (*dom
 		return nil, err
 	}
 
+	// Inventory was locked through a transaction-scoped repository, so
+	// broadcast the changes only once the transaction has committed
+	if notifier, ok := s.inventoryRepo.(inventoryChangeNotifier); ok {
+		for _, itemReq := range req.Items {
+			notifier.NotifyInventoryChanged(ctx, req.VoyageID, itemReq.CabinTypeID, domain.InventoryActionLock, 1)
+		}
+	}
+
 	return order, nil
 }
 
+// snapshotRefundPolicy stores the refund policy the order is booked under. A
+// price-type policy only applies when every cabin is booked at that price type.
+func (s *orderService) snapshotRefundPolicy(ctx context.Context, order *domain.Order, voyage *domain.Voyage, priceTypes []string) error {
+	if s.policies == nil {
+		return nil
+	}
+
+	priceType := ""
+	for i, t := range priceTypes {
+		if i > 0 && t != priceType {
+			priceType = ""
+			break
+		}
+		priceType = t
+	}
+
+	policy, err := s.policies.Resolve(ctx, voyage.RouteID, voyage.CruiseID, priceType)
+	if err != nil {
+		return fmt.Errorf("failed to resolve refund policy: %w", err)
+	}
+	if policy != nil {
+		order.SetRefundPolicy(policy)
+	}
+	return nil
+}
+
 func (s *orderService) GetByID(ctx context.Context, id string) (*domain.Order, error) {
 	order, err := s.orderRepo.GetByID(ctx, id)
 	if err != nil {
@@ -482,7 +566,16 @@ func (s *orderService) Cancel(ctx context.Context, id string) error {
 		return ErrOrderNotFound
 	}
 
-	return s.stateService.TransitionToCancelled(ctx, order)
+	if err := s.stateService.TransitionToCancelled(ctx, order); err != nil {
+		return err
+	}
+
+	if s.coupons != nil {
+		if err := s.coupons.ReleaseOrder(ctx, id); err != nil {
+			log.Printf("[WARN] Failed to release coupons for order %s: %v", id, err)
+		}
+	}
+	return nil
 }
 
 func (s *orderService) Confirm(ctx context.Context, id string) error {
@@ -517,38 +610,93 @@ func (s *orderService) Delete(ctx context.Context, id string) error {
 	return s.orderRepo.Delete(ctx, id)
 }
 
-func (s *orderService) CalculateTotal(ctx context.Context, items []OrderItemRequest) (OrderCalculation, error) {
+func (s *orderService) CalculateTotal(ctx context.Context, req CalculateOrderRequest) (OrderCalculation, error) {
 	var calculation OrderCalculation
 
-	for _, item := range items {
-		price, err := s.priceRepo.GetCurrentPrice(ctx, "", item.CabinTypeID)
+	quote, err := QuoteFromBase(ctx, s.currency, req.Currency)
+	if err != nil {
+		return calculation, err
+	}
+
+	booking := CouponBooking{UserID: req.UserID}
+	if req.VoyageID != "" {
+		voyage, err := s.voyageRepo.GetByID(ctx, req.VoyageID)
+		if err != nil {
+			return calculation, fmt.Errorf("voyage not found: %w", err)
+		}
+		booking.Voyage = voyage
+	}
+
+	for _, item := range req.Items {
+		price, err := s.priceRepo.GetCurrentPrice(ctx, req.VoyageID, item.CabinTypeID)
 		if err != nil {
 			return calculation, fmt.Errorf("price not found for cabin type %s: %w", item.CabinTypeID, err)
 		}
 
-		// CS-003: Use extracted helper for price calculation
-		calc := calculateItemSubtotal(price, item.AdultCount, item.ChildCount, item.InfantCount)
+		// CS-003: Shared occupancy and fare rules
+		calc, err := calculateItemFare(price, item.AdultCount, item.ChildCount, item.InfantCount)
+		if err != nil {
+			return calculation, err
+		}
 
 		calculation.Items = append(calculation.Items, ItemCalculation{
-			CabinTypeID: item.CabinTypeID,
-			AdultPrice:  calc.AdultTotal,
-			ChildPrice:  calc.ChildTotal,
-			InfantPrice: calc.InfantTotal,
-			PortFee:     calc.PortFee,
-			ServiceFee:  calc.ServiceFee,
-			Subtotal:    calc.Subtotal,
+			CabinTypeID:      item.CabinTypeID,
+			AdultPrice:       quote.Convert(calc.AdultTotal),
+			ChildPrice:       quote.Convert(calc.ChildTotal),
+			InfantPrice:      quote.Convert(calc.InfantTotal),
+			SingleSupplement: quote.Convert(calc.SingleSupplement),
+			ExtraGuestCount:  calc.ExtraGuestCount,
+			ExtraGuestPrice:  quote.Convert(calc.ExtraGuestTotal),
+			PortFee:          quote.Convert(calc.PortFee),
+			ServiceFee:       quote.Convert(calc.ServiceFee),
+			Subtotal:         quote.Convert(calc.Subtotal),
 		})
 
-		calculation.Subtotal += calc.AdultTotal + calc.ChildTotal + calc.InfantTotal
+		calculation.Subtotal += calc.Fare()
 		calculation.PortFee += calc.PortFee
 		calculation.ServiceFee += calc.ServiceFee
+		booking.Items = append(booking.Items, CouponItem{CabinTypeID: item.CabinTypeID, Amount: calc.Subtotal})
 	}
 
-	calculation.TotalAmount = calculation.Subtotal + calculation.PortFee + calculation.ServiceFee - calculation.DiscountAmount
+	applied, err := s.evaluateCoupons(ctx, booking, req.CouponCodes)
+	if err != nil {
+		return calculation, err
+	}
+	for i := range applied {
+		applied[i].DiscountAmount = quote.Convert(applied[i].BaseDiscount())
+	}
+	calculation.Coupons = applied
+	calculation.DiscountAmount = TotalCouponDiscount(applied)
+
+	// Totals are summed in the base currency and converted once, matching
+	// how Create prices the order
+	calculation.BaseCurrency = quote.From
+	calculation.BaseTotalAmount = calculation.Subtotal + calculation.PortFee + calculation.ServiceFee - calculation.DiscountAmount
+	calculation.Currency = quote.To
+	calculation.ExchangeRate = quote.Rate
+	if !quote.AsOf.IsZero() {
+		calculation.RateAsOf = &quote.AsOf
+	}
+	calculation.Subtotal = quote.Convert(calculation.Subtotal)
+	calculation.PortFee = quote.Convert(calculation.PortFee)
+	calculation.ServiceFee = quote.Convert(calculation.ServiceFee)
+	calculation.DiscountAmount = quote.Convert(calculation.DiscountAmount)
+	calculation.TotalAmount = quote.Convert(calculation.BaseTotalAmount)
 
 	return calculation, nil
 }
 
+// evaluateCoupons validates coupon codes when any are given
+func (s *orderService) evaluateCoupons(ctx context.Context, booking CouponBooking, codes []string) ([]AppliedCoupon, error) {
+	if len(codes) == 0 {
+		return nil, nil
+	}
+	if s.coupons == nil {
+		return nil, ErrCouponNotFound
+	}
+	return s.coupons.Evaluate(ctx, booking, codes)
+}
+
 func generateOrderNumber() string {
 	return fmt.Sprintf("ORD%s%s", time.Now().Format("20060102"), uuid.New().String()[:8])
 }
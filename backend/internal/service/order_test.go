@@ -6,6 +6,7 @@ import (
 	"backend/internal/repository"
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -31,6 +32,14 @@ func (m *MockOrderRepository) GetByID(ctx context.Context, id string) (*domain.O
 	return args.Get(0).(*domain.Order), args.Error(1)
 }
 
+func (m *MockOrderRepository) GetByIDForUpdate(ctx context.Context, id string) (*domain.Order, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Order), args.Error(1)
+}
+
 func (m *MockOrderRepository) GetByOrderNumber(ctx context.Context, orderNumber string) (*domain.Order, error) {
 	args := m.Called(ctx, orderNumber)
 	if args.Get(0) == nil {
@@ -86,6 +95,11 @@ func (m *MockOrderRepository) UpdateStatus(ctx context.Context, id string, statu
 	return args.Error(0)
 }
 
+func (m *MockOrderRepository) UpdateStatusFrom(ctx context.Context, id string, from, status string) (bool, error) {
+	args := m.Called(ctx, id, from, status)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockOrderRepository) UpdatePaymentStatus(ctx context.Context, id string, paymentStatus string, paidAmount float64) error {
 	args := m.Called(ctx, id, paymentStatus, paidAmount)
 	return args.Error(0)
@@ -197,6 +211,14 @@ func (m *MockOrderRepository) GetPaymentByID(ctx context.Context, id string) (*d
 	return args.Get(0).(*domain.Payment), args.Error(1)
 }
 
+func (m *MockOrderRepository) GetPaymentByIDForUpdate(ctx context.Context, id string) (*domain.Payment, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Payment), args.Error(1)
+}
+
 func (m *MockOrderRepository) GetPaymentByNo(ctx context.Context, paymentNo string) (*domain.Payment, error) {
 	args := m.Called(ctx, paymentNo)
 	if args.Get(0) == nil {
@@ -210,6 +232,22 @@ func (m *MockOrderRepository) UpdatePayment(ctx context.Context, payment *domain
 	return args.Error(0)
 }
 
+func (m *MockOrderRepository) ListPaymentsByOrder(ctx context.Context, orderID string) ([]*domain.Payment, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Payment), args.Error(1)
+}
+
+func (m *MockOrderRepository) ListPendingPayments(ctx context.Context, from, to time.Time, limit int) ([]*domain.Payment, error) {
+	args := m.Called(ctx, from, to, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Payment), args.Error(1)
+}
+
 func (m *MockOrderRepository) CreateRefundRequest(ctx context.Context, refund *domain.RefundRequest) error {
 	args := m.Called(ctx, refund)
 	return args.Error(0)
@@ -236,6 +274,61 @@ func (m *MockOrderRepository) UpdateRefundRequest(ctx context.Context, refund *d
 	return args.Error(0)
 }
 
+func (m *MockOrderRepository) CreatePaymentRefund(ctx context.Context, refund *domain.PaymentRefund) error {
+	args := m.Called(ctx, refund)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) GetPaymentRefundByID(ctx context.Context, id string) (*domain.PaymentRefund, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PaymentRefund), args.Error(1)
+}
+
+func (m *MockOrderRepository) GetPaymentRefundByIDForUpdate(ctx context.Context, id string) (*domain.PaymentRefund, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PaymentRefund), args.Error(1)
+}
+
+func (m *MockOrderRepository) GetPaymentRefundByNo(ctx context.Context, refundNo string) (*domain.PaymentRefund, error) {
+	args := m.Called(ctx, refundNo)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PaymentRefund), args.Error(1)
+}
+
+func (m *MockOrderRepository) UpdatePaymentRefund(ctx context.Context, refund *domain.PaymentRefund) error {
+	args := m.Called(ctx, refund)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) ListPaymentRefundsByOrder(ctx context.Context, orderID string) ([]*domain.PaymentRefund, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.PaymentRefund), args.Error(1)
+}
+
+func (m *MockOrderRepository) ListProcessingPaymentRefunds(ctx context.Context, before time.Time, limit int) ([]*domain.PaymentRefund, error) {
+	args := m.Called(ctx, before, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.PaymentRefund), args.Error(1)
+}
+
+func (m *MockOrderRepository) AddOutboxEvent(ctx context.Context, event *domain.OutboxEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
 func (m *MockOrderRepository) WithTransaction(ctx context.Context, fn func(repo repository.OrderRepository, tx *gorm.DB) error) error {
 	return fn(m, nil)
 }
@@ -532,6 +625,11 @@ func (m *MockPriceRepository) BatchCreate(ctx context.Context, prices []*domain.
 	return args.Error(0)
 }
 
+func (m *MockPriceRepository) Reprice(ctx context.Context, id string, expectedVersion int, adultPrice, childPrice, infantPrice float64, repricedAt string) error {
+	args := m.Called(ctx, id, expectedVersion, adultPrice, childPrice, infantPrice, repricedAt)
+	return args.Error(0)
+}
+
 // Mock Order State Service
 type MockOrderStateService struct {
 	mock.Mock
@@ -594,7 +692,7 @@ func TestOrderService_GetByID(t *testing.T) {
 	mockPriceRepo := new(MockPriceRepository)
 	mockInventoryRepo := new(MockInventoryRepository)
 
-	service := NewOrderService(mockOrderRepo, mockVoyageRepo, mockCabinRepo, mockPriceRepo, mockInventoryRepo)
+	service := NewOrderService(mockOrderRepo, mockVoyageRepo, mockCabinRepo, mockPriceRepo, mockInventoryRepo, nil, nil, nil)
 	ctx := context.Background()
 
 	t.Run("should return order by ID", func(t *testing.T) {
@@ -631,7 +729,7 @@ func TestOrderService_Cancel(t *testing.T) {
 	mockPriceRepo := new(MockPriceRepository)
 	mockInventoryRepo := new(MockInventoryRepository)
 
-	service := NewOrderService(mockOrderRepo, mockVoyageRepo, mockCabinRepo, mockPriceRepo, mockInventoryRepo)
+	service := NewOrderService(mockOrderRepo, mockVoyageRepo, mockCabinRepo, mockPriceRepo, mockInventoryRepo, nil, nil, nil)
 	ctx := context.Background()
 
 	t.Run("should cancel pending order successfully", func(t *testing.T) {
@@ -667,7 +765,7 @@ func TestOrderService_List(t *testing.T) {
 	mockPriceRepo := new(MockPriceRepository)
 	mockInventoryRepo := new(MockInventoryRepository)
 
-	service := NewOrderService(mockOrderRepo, mockVoyageRepo, mockCabinRepo, mockPriceRepo, mockInventoryRepo)
+	service := NewOrderService(mockOrderRepo, mockVoyageRepo, mockCabinRepo, mockPriceRepo, mockInventoryRepo, nil, nil, nil)
 	ctx := context.Background()
 
 	t.Run("should return paginated orders", func(t *testing.T) {
@@ -700,7 +798,7 @@ func TestOrderService_Update(t *testing.T) {
 	mockPriceRepo := new(MockPriceRepository)
 	mockInventoryRepo := new(MockInventoryRepository)
 
-	service := NewOrderService(mockOrderRepo, mockVoyageRepo, mockCabinRepo, mockPriceRepo, mockInventoryRepo)
+	service := NewOrderService(mockOrderRepo, mockVoyageRepo, mockCabinRepo, mockPriceRepo, mockInventoryRepo, nil, nil, nil)
 	ctx := context.Background()
 
 	t.Run("should update pending order", func(t *testing.T) {
@@ -754,7 +852,7 @@ func TestOrderService_CalculateTotal(t *testing.T) {
 	mockPriceRepo := new(MockPriceRepository)
 	mockInventoryRepo := new(MockInventoryRepository)
 
-	service := NewOrderService(mockOrderRepo, mockVoyageRepo, mockCabinRepo, mockPriceRepo, mockInventoryRepo)
+	service := NewOrderService(mockOrderRepo, mockVoyageRepo, mockCabinRepo, mockPriceRepo, mockInventoryRepo, nil, nil, nil)
 	ctx := context.Background()
 
 	t.Run("should calculate total for items", func(t *testing.T) {
@@ -776,7 +874,7 @@ func TestOrderService_CalculateTotal(t *testing.T) {
 
 		mockPriceRepo.On("GetCurrentPrice", ctx, "", "cabin-type-1").Return(price, nil).Once()
 
-		result, err := service.CalculateTotal(ctx, items)
+		result, err := service.CalculateTotal(ctx, CalculateOrderRequest{Items: items})
 
 		assert.NoError(t, err)
 		assert.Equal(t, 2500.0, result.Subtotal)  // 2*1000 + 1*500
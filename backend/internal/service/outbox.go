@@ -0,0 +1,150 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/messaging"
+	"backend/internal/pagination"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+var (
+	ErrOutboxEventNotFound = errors.New("outbox event not found")
+	ErrOutboxEventNotDead  = errors.New("outbox event is not dead")
+)
+
+const (
+	// outboxBatchSize is how many pending events one relay run reads
+	outboxBatchSize = 200
+	// outboxMaxAttempts is how many failed publishes move an event to the
+	// dead letters
+	outboxMaxAttempts = 10
+	// outboxBaseBackoff and outboxMaxBackoff bound the delay between retries
+	outboxBaseBackoff = 5 * time.Second
+	outboxMaxBackoff  = 10 * time.Minute
+)
+
+// OutboxService defines the interface for relaying and inspecting outbox events
+type OutboxService interface {
+	// RelayPending publishes due events and returns how many were published.
+	// An aggregate's events are published in order: once one of them is not
+	// published, the rest of that aggregate waits for the next run. A dead
+	// event holds back its aggregate until it is retried. Only one instance
+	// relays at a time; the others return at once
+	RelayPending(ctx context.Context) (int, error)
+
+	ListEvents(ctx context.Context, filters repository.OutboxFilters, paginator *pagination.Paginator) ([]*domain.OutboxEvent, error)
+	GetEvent(ctx context.Context, id string) (*domain.OutboxEvent, error)
+
+	// RetryEvent puts a dead event back in line for publishing, releasing the
+	// events of its aggregate queued behind it
+	RetryEvent(ctx context.Context, id string) (*domain.OutboxEvent, error)
+}
+
+// outboxService implements OutboxService
+type outboxService struct {
+	repo      repository.OutboxRepository
+	publisher messaging.Publisher
+	now       func() time.Time
+}
+
+// NewOutboxService creates a new outbox service
+func NewOutboxService(repo repository.OutboxRepository, publisher messaging.Publisher) OutboxService {
+	return &outboxService{repo: repo, publisher: publisher, now: time.Now}
+}
+
+func (s *outboxService) RelayPending(ctx context.Context) (int, error) {
+	if s.publisher == nil || !s.publisher.Connected() {
+		return 0, nil
+	}
+
+	var published int
+	_, err := s.repo.WithRelayLock(ctx, func() error {
+		var err error
+		published, err = s.relay(ctx)
+		return err
+	})
+	return published, err
+}
+
+// relay publishes one batch of due events while holding the relay lock
+func (s *outboxService) relay(ctx context.Context) (int, error) {
+	now := s.now()
+	events, err := s.repo.ListPending(ctx, now, outboxBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	blocked := make(map[string]bool)
+	published := 0
+	for _, event := range events {
+		key := event.AggregateKey()
+		if blocked[key] {
+			continue
+		}
+		if event.NextAttemptAt.After(now) {
+			blocked[key] = true
+			continue
+		}
+
+		if err := s.publisher.Publish(ctx, event.Subject, event.Payload, event.ID.String()); err != nil {
+			event.MarkFailed(err, now.Add(outboxBackoff(event.Attempts)), outboxMaxAttempts)
+			if event.Status == domain.OutboxStatusDead {
+				log.Printf("Outbox event %s (%s) moved to dead letters, holding back later %s events: %v",
+					event.ID, event.Subject, key, err)
+			}
+			// Later events of the aggregate wait for this one
+			blocked[key] = true
+		} else {
+			event.MarkPublished(now)
+			published++
+		}
+
+		if err := s.repo.Update(ctx, event); err != nil {
+			// Publishing again later is safe: the server drops duplicates
+			log.Printf("Failed to update outbox event %s: %v", event.ID, err)
+			blocked[key] = true
+		}
+	}
+
+	return published, nil
+}
+
+func (s *outboxService) ListEvents(ctx context.Context, filters repository.OutboxFilters, paginator *pagination.Paginator) ([]*domain.OutboxEvent, error) {
+	return s.repo.List(ctx, filters, paginator)
+}
+
+func (s *outboxService) GetEvent(ctx context.Context, id string) (*domain.OutboxEvent, error) {
+	event, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrOutboxEventNotFound
+	}
+	return event, nil
+}
+
+func (s *outboxService) RetryEvent(ctx context.Context, id string) (*domain.OutboxEvent, error) {
+	event, err := s.GetEvent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if event.Status != domain.OutboxStatusDead {
+		return nil, ErrOutboxEventNotDead
+	}
+
+	event.Requeue(s.now())
+	if err := s.repo.Update(ctx, event); err != nil {
+		return nil, fmt.Errorf("failed to requeue outbox event: %w", err)
+	}
+	return event, nil
+}
+
+// outboxBackoff returns the delay before retrying an event that has failed
+// attempts times before
+func outboxBackoff(attempts int) time.Duration {
+	delay := outboxBaseBackoff << min(attempts, 10)
+	return min(delay, outboxMaxBackoff)
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockOutboxRepository mocks OutboxRepository
+type MockOutboxRepository struct {
+	repository.OutboxRepository
+	mock.Mock
+}
+
+func (m *MockOutboxRepository) ListPending(ctx context.Context, now time.Time, limit int) ([]*domain.OutboxEvent, error) {
+	args := m.Called(ctx, now, limit)
+	return args.Get(0).([]*domain.OutboxEvent), args.Error(1)
+}
+
+// WithRelayLock runs fn unless the lock is held elsewhere
+func (m *MockOutboxRepository) WithRelayLock(ctx context.Context, fn func() error) (bool, error) {
+	args := m.Called(ctx)
+	if !args.Bool(0) {
+		return false, args.Error(1)
+	}
+	return true, fn()
+}
+
+func (m *MockOutboxRepository) Update(ctx context.Context, event *domain.OutboxEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+// MockPublisher mocks messaging.Publisher
+type MockPublisher struct {
+	mock.Mock
+}
+
+func (m *MockPublisher) Publish(ctx context.Context, subject string, data []byte, msgID string) error {
+	args := m.Called(ctx, subject, data, msgID)
+	return args.Error(0)
+}
+
+func (m *MockPublisher) Connected() bool {
+	return true
+}
+
+func TestOutboxService_RelayPending(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	newEvent := func(orderID, subject string) *domain.OutboxEvent {
+		event, err := domain.NewOutboxEvent(domain.AggregateOrder, orderID, subject, map[string]string{"type": subject})
+		require.NoError(t, err)
+		event.ID = uuid.New()
+		event.NextAttemptAt = now
+		return event
+	}
+
+	failing := newEvent("order-1", "payment.success")
+	blocked := newEvent("order-1", "refund.requested")
+	other := newEvent("order-2", "order.cancelled")
+	exhausted := newEvent("order-3", "payment.late")
+	exhausted.Attempts = outboxMaxAttempts - 1
+	afterDead := newEvent("order-3", "payment.refunded")
+	waiting := newEvent("order-4", "payment.success")
+	waiting.NextAttemptAt = now.Add(time.Minute)
+	afterWaiting := newEvent("order-4", "refund.requested")
+
+	repo := new(MockOutboxRepository)
+	repo.On("WithRelayLock", ctx).Return(true, nil)
+	repo.On("ListPending", ctx, now, outboxBatchSize).
+		Return([]*domain.OutboxEvent{failing, blocked, other, exhausted, afterDead, waiting, afterWaiting}, nil)
+	repo.On("Update", ctx, mock.Anything).Return(nil)
+
+	publisher := new(MockPublisher)
+	publisher.On("Publish", ctx, "payment.success", mock.Anything, failing.ID.String()).Return(errors.New("timeout"))
+	publisher.On("Publish", ctx, "order.cancelled", mock.Anything, other.ID.String()).Return(nil)
+	publisher.On("Publish", ctx, "payment.late", mock.Anything, exhausted.ID.String()).Return(errors.New("timeout"))
+
+	svc := &outboxService{repo: repo, publisher: publisher, now: func() time.Time { return now }}
+	published, err := svc.RelayPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+
+	// A failed event holds back the rest of its order
+	assert.Equal(t, domain.OutboxStatusPending, failing.Status)
+	assert.Equal(t, 1, failing.Attempts)
+	assert.Equal(t, now.Add(outboxBaseBackoff), failing.NextAttemptAt)
+	assert.Equal(t, domain.OutboxStatusPending, blocked.Status)
+	assert.Zero(t, blocked.Attempts)
+
+	assert.Equal(t, domain.OutboxStatusPublished, other.Status)
+
+	// A dead event holds back the events after it until it is retried
+	assert.Equal(t, domain.OutboxStatusDead, exhausted.Status)
+	assert.Equal(t, domain.OutboxStatusPending, afterDead.Status)
+	assert.Zero(t, afterDead.Attempts)
+
+	// An event waiting for its retry holds back the rest of its order too
+	assert.Zero(t, waiting.Attempts)
+	assert.Equal(t, domain.OutboxStatusPending, afterWaiting.Status)
+
+	publisher.AssertNumberOfCalls(t, "Publish", 3)
+}
+
+func TestOutboxService_RelayPending_LockedElsewhere(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockOutboxRepository)
+	repo.On("WithRelayLock", ctx).Return(false, nil)
+
+	published, err := NewOutboxService(repo, new(MockPublisher)).RelayPending(ctx)
+
+	require.NoError(t, err)
+	assert.Zero(t, published)
+	repo.AssertNotCalled(t, "ListPending", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/pagination"
+	"backend/internal/payment"
+	"backend/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+var (
+	ErrPaymentCallbackNotFound  = errors.New("payment callback not found")
+	ErrPaymentCallbackProcessed = errors.New("payment callback was processed")
+)
+
+// PaymentCallbackService defines the interface for logging, processing and
+// replaying provider callbacks
+type PaymentCallbackService interface {
+	// HandleCallback logs a payment or refund callback, processes it and
+	// records the outcome. The processing error is returned so the provider
+	// is told to deliver the callback again
+	HandleCallback(ctx context.Context, kind, provider string, headers map[string]string, body []byte, signature string) error
+	// RecordRejected logs a callback turned away before processing, such as
+	// one without signature headers
+	RecordRejected(ctx context.Context, kind, provider string, headers map[string]string, body []byte, reason string)
+
+	ListCallbacks(ctx context.Context, filters repository.PaymentCallbackFilters, paginator *pagination.Paginator) ([]*domain.PaymentCallback, error)
+	GetCallback(ctx context.Context, id string) (*domain.PaymentCallback, error)
+
+	// ReplayCallback processes a stored callback again, e.g. after fixing
+	// what made it fail. The replay is logged as a new callback carrying
+	// the operator and note; a processing failure is recorded on it rather
+	// than returned. Payments and refunds already settled are left alone
+	ReplayCallback(ctx context.Context, id, operatorID, note string) (*domain.PaymentCallback, error)
+}
+
+// paymentCallbackService implements PaymentCallbackService
+type paymentCallbackService struct {
+	repo     repository.PaymentCallbackRepository
+	payments payment.PaymentService
+	refunds  RefundService
+	now      func() time.Time
+}
+
+// NewPaymentCallbackService creates a new payment callback service
+func NewPaymentCallbackService(repo repository.PaymentCallbackRepository, payments payment.PaymentService, refunds RefundService) PaymentCallbackService {
+	return &paymentCallbackService{repo: repo, payments: payments, refunds: refunds, now: time.Now}
+}
+
+func (s *paymentCallbackService) HandleCallback(ctx context.Context, kind, provider string, headers map[string]string, body []byte, signature string) error {
+	callback := &domain.PaymentCallback{
+		Provider:  provider,
+		Kind:      kind,
+		Headers:   encodeHeaders(headers),
+		Body:      string(body),
+		Signature: signature,
+		Status:    domain.CallbackStatusReceived,
+	}
+	return s.process(ctx, callback)
+}
+
+func (s *paymentCallbackService) RecordRejected(ctx context.Context, kind, provider string, headers map[string]string, body []byte, reason string) {
+	callback := &domain.PaymentCallback{
+		Provider: provider,
+		Kind:     kind,
+		Headers:  encodeHeaders(headers),
+		Body:     string(body),
+	}
+	callback.MarkProcessed(errors.New(reason), s.now())
+	if err := s.repo.Create(ctx, callback); err != nil {
+		log.Printf("Failed to log rejected %s %s callback: %v", provider, kind, err)
+	}
+}
+
+func (s *paymentCallbackService) ListCallbacks(ctx context.Context, filters repository.PaymentCallbackFilters, paginator *pagination.Paginator) ([]*domain.PaymentCallback, error) {
+	return s.repo.List(ctx, filters, paginator)
+}
+
+func (s *paymentCallbackService) GetCallback(ctx context.Context, id string) (*domain.PaymentCallback, error) {
+	callback, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrPaymentCallbackNotFound
+	}
+	return callback, nil
+}
+
+func (s *paymentCallbackService) ReplayCallback(ctx context.Context, id, operatorID, note string) (*domain.PaymentCallback, error) {
+	original, err := s.GetCallback(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if original.Status == domain.CallbackStatusProcessed {
+		return nil, ErrPaymentCallbackProcessed
+	}
+
+	replay := &domain.PaymentCallback{
+		Provider:   original.Provider,
+		Kind:       original.Kind,
+		Headers:    original.Headers,
+		Body:       original.Body,
+		Signature:  original.Signature,
+		Status:     domain.CallbackStatusReceived,
+		ReplayOfID: &id,
+		ReplayNote: note,
+	}
+	if operatorID != "" {
+		replay.ReplayedBy = &operatorID
+	}
+	// Unlike a delivery, a replay is not processed without its log entry
+	if err := s.repo.Create(ctx, replay); err != nil {
+		return nil, fmt.Errorf("failed to log callback replay: %w", err)
+	}
+
+	s.apply(payment.WithReplay(ctx), replay)
+	return replay, nil
+}
+
+// process logs a delivered callback and processes it. A callback that
+// cannot be logged is still processed, so a database hiccup does not hold
+// up payments
+func (s *paymentCallbackService) process(ctx context.Context, callback *domain.PaymentCallback) error {
+	logged := true
+	if err := s.repo.Create(ctx, callback); err != nil {
+		log.Printf("Failed to log %s %s callback: %v", callback.Provider, callback.Kind, err)
+		logged = false
+	}
+
+	err := s.run(ctx, callback)
+	if logged {
+		s.record(ctx, callback, err)
+	}
+	return err
+}
+
+// apply processes a logged callback and records the outcome
+func (s *paymentCallbackService) apply(ctx context.Context, callback *domain.PaymentCallback) {
+	s.record(ctx, callback, s.run(ctx, callback))
+}
+
+// run hands the callback to the payment or refund service, filling in what
+// verification found out
+func (s *paymentCallbackService) run(ctx context.Context, callback *domain.PaymentCallback) error {
+	trace := &payment.CallbackTrace{}
+	ctx = payment.WithCallbackTrace(ctx, trace)
+	body := []byte(callback.Body)
+
+	var err error
+	switch callback.Kind {
+	case domain.CallbackKindRefund:
+		err = s.refunds.HandleRefundCallback(ctx, callback.Provider, body, callback.Signature)
+	default:
+		err = s.payments.ProcessCallback(ctx, callback.Provider, body, callback.Signature)
+	}
+
+	callback.Verified = trace.Verified
+	callback.Reference = trace.Reference
+	return err
+}
+
+func (s *paymentCallbackService) record(ctx context.Context, callback *domain.PaymentCallback, err error) {
+	callback.MarkProcessed(err, s.now())
+	if updateErr := s.repo.UpdateOutcome(context.WithoutCancel(ctx), callback); updateErr != nil {
+		log.Printf("Failed to record outcome of callback %s: %v", callback.ID, updateErr)
+	}
+}
+
+// encodeHeaders stores callback headers as JSON
+func encodeHeaders(headers map[string]string) datatypes.JSON {
+	data, err := json.Marshal(headers)
+	if err != nil {
+		return nil
+	}
+	return datatypes.JSON(data)
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/payment"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockPaymentCallbackRepository mocks PaymentCallbackRepository
+type MockPaymentCallbackRepository struct {
+	repository.PaymentCallbackRepository
+	mock.Mock
+}
+
+func (m *MockPaymentCallbackRepository) Create(ctx context.Context, callback *domain.PaymentCallback) error {
+	args := m.Called(ctx, callback)
+	if callback.ID == uuid.Nil {
+		callback.ID = uuid.New()
+	}
+	return args.Error(0)
+}
+
+func (m *MockPaymentCallbackRepository) GetByID(ctx context.Context, id string) (*domain.PaymentCallback, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PaymentCallback), args.Error(1)
+}
+
+func (m *MockPaymentCallbackRepository) UpdateOutcome(ctx context.Context, callback *domain.PaymentCallback) error {
+	args := m.Called(ctx, callback)
+	return args.Error(0)
+}
+
+// MockCallbackPaymentService mocks payment.PaymentService
+type MockCallbackPaymentService struct {
+	payment.PaymentService
+	mock.Mock
+}
+
+func (m *MockCallbackPaymentService) ProcessCallback(ctx context.Context, provider string, body []byte, signature string) error {
+	args := m.Called(ctx, provider, body, signature)
+	return args.Error(0)
+}
+
+// MockCallbackRefundService mocks RefundService
+type MockCallbackRefundService struct {
+	RefundService
+	mock.Mock
+}
+
+func (m *MockCallbackRefundService) HandleRefundCallback(ctx context.Context, provider string, body []byte, signature string) error {
+	args := m.Called(ctx, provider, body, signature)
+	return args.Error(0)
+}
+
+func TestPaymentCallbackService_HandleCallback(t *testing.T) {
+	ctx := context.Background()
+	headers := map[string]string{"Content-Type": "application/json"}
+	body := []byte(`{"id":"evt-1"}`)
+
+	t.Run("logs and processes a payment callback", func(t *testing.T) {
+		repo := new(MockPaymentCallbackRepository)
+		payments := new(MockCallbackPaymentService)
+		svc := NewPaymentCallbackService(repo, payments, nil)
+
+		repo.On("Create", ctx, mock.MatchedBy(func(c *domain.PaymentCallback) bool {
+			return c.Status == domain.CallbackStatusReceived && c.Provider == "wechat" &&
+				c.Kind == domain.CallbackKindPayment && c.Body == string(body) && c.Signature == "meta"
+		})).Return(nil)
+		payments.On("ProcessCallback", mock.Anything, "wechat", body, "meta").Return(nil)
+		repo.On("UpdateOutcome", mock.Anything, mock.MatchedBy(func(c *domain.PaymentCallback) bool {
+			return c.Status == domain.CallbackStatusProcessed && c.ProcessedAt != nil && c.Error == ""
+		})).Return(nil)
+
+		err := svc.HandleCallback(ctx, domain.CallbackKindPayment, "wechat", headers, body, "meta")
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+		payments.AssertExpectations(t)
+	})
+
+	t.Run("records a failure and returns it to the provider", func(t *testing.T) {
+		repo := new(MockPaymentCallbackRepository)
+		refunds := new(MockCallbackRefundService)
+		svc := NewPaymentCallbackService(repo, nil, refunds)
+
+		repo.On("Create", ctx, mock.Anything).Return(nil)
+		refunds.On("HandleRefundCallback", mock.Anything, "alipay", body, "").Return(errors.New("refund not found"))
+		repo.On("UpdateOutcome", mock.Anything, mock.MatchedBy(func(c *domain.PaymentCallback) bool {
+			return c.Status == domain.CallbackStatusFailed && c.Error == "refund not found"
+		})).Return(nil)
+
+		err := svc.HandleCallback(ctx, domain.CallbackKindRefund, "alipay", headers, body, "")
+		assert.EqualError(t, err, "refund not found")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("processes a callback that cannot be logged", func(t *testing.T) {
+		repo := new(MockPaymentCallbackRepository)
+		payments := new(MockCallbackPaymentService)
+		svc := NewPaymentCallbackService(repo, payments, nil)
+
+		repo.On("Create", ctx, mock.Anything).Return(errors.New("connection refused"))
+		payments.On("ProcessCallback", mock.Anything, "alipay", body, "").Return(nil)
+
+		err := svc.HandleCallback(ctx, domain.CallbackKindPayment, "alipay", headers, body, "")
+		require.NoError(t, err)
+		repo.AssertNotCalled(t, "UpdateOutcome", mock.Anything, mock.Anything)
+	})
+}
+
+func TestPaymentCallbackService_ReplayCallback(t *testing.T) {
+	ctx := context.Background()
+	originalID := uuid.New()
+	newOriginal := func(status string) *domain.PaymentCallback {
+		callback := &domain.PaymentCallback{
+			Provider:  "wechat",
+			Kind:      domain.CallbackKindPayment,
+			Body:      `{"id":"evt-1"}`,
+			Signature: "meta",
+			Status:    status,
+			Error:     "payment not found",
+		}
+		callback.ID = originalID
+		return callback
+	}
+
+	t.Run("replays a failed callback as a new logged callback", func(t *testing.T) {
+		repo := new(MockPaymentCallbackRepository)
+		payments := new(MockCallbackPaymentService)
+		svc := &paymentCallbackService{repo: repo, payments: payments, now: time.Now}
+
+		repo.On("GetByID", ctx, originalID.String()).Return(newOriginal(domain.CallbackStatusFailed), nil)
+		repo.On("Create", ctx, mock.MatchedBy(func(c *domain.PaymentCallback) bool {
+			return c.ReplayOfID != nil && *c.ReplayOfID == originalID.String() &&
+				c.ReplayedBy != nil && *c.ReplayedBy == "admin-1" && c.ReplayNote == "order restored"
+		})).Return(nil)
+		payments.On("ProcessCallback", mock.Anything, "wechat", []byte(`{"id":"evt-1"}`), "meta").Return(nil)
+		repo.On("UpdateOutcome", mock.Anything, mock.Anything).Return(nil)
+
+		replay, err := svc.ReplayCallback(ctx, originalID.String(), "admin-1", "order restored")
+		require.NoError(t, err)
+		assert.NotEqual(t, originalID, replay.ID)
+		assert.Equal(t, domain.CallbackStatusProcessed, replay.Status)
+		payments.AssertExpectations(t)
+	})
+
+	t.Run("records a failed replay on the replay", func(t *testing.T) {
+		repo := new(MockPaymentCallbackRepository)
+		payments := new(MockCallbackPaymentService)
+		svc := &paymentCallbackService{repo: repo, payments: payments, now: time.Now}
+
+		repo.On("GetByID", ctx, originalID.String()).Return(newOriginal(domain.CallbackStatusFailed), nil)
+		repo.On("Create", ctx, mock.Anything).Return(nil)
+		payments.On("ProcessCallback", mock.Anything, "wechat", mock.Anything, "meta").Return(errors.New("payment not found"))
+		repo.On("UpdateOutcome", mock.Anything, mock.Anything).Return(nil)
+
+		replay, err := svc.ReplayCallback(ctx, originalID.String(), "admin-1", "retry")
+		require.NoError(t, err)
+		assert.Equal(t, domain.CallbackStatusFailed, replay.Status)
+		assert.Equal(t, "payment not found", replay.Error)
+	})
+
+	t.Run("refuses to replay a processed callback", func(t *testing.T) {
+		repo := new(MockPaymentCallbackRepository)
+		svc := &paymentCallbackService{repo: repo, now: time.Now}
+
+		repo.On("GetByID", ctx, originalID.String()).Return(newOriginal(domain.CallbackStatusProcessed), nil)
+
+		_, err := svc.ReplayCallback(ctx, originalID.String(), "admin-1", "retry")
+		assert.ErrorIs(t, err, ErrPaymentCallbackProcessed)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown callback", func(t *testing.T) {
+		repo := new(MockPaymentCallbackRepository)
+		svc := &paymentCallbackService{repo: repo, now: time.Now}
+
+		repo.On("GetByID", ctx, "missing").Return(nil, errors.New("record not found"))
+
+		_, err := svc.ReplayCallback(ctx, "missing", "admin-1", "retry")
+		assert.ErrorIs(t, err, ErrPaymentCallbackNotFound)
+	})
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"backend/internal/currency"
 	"backend/internal/domain"
 	"backend/internal/pagination"
 	"backend/internal/repository"
@@ -33,6 +34,10 @@ type PriceService interface {
 	// ListByVoyage retrieves all prices for a voyage
 	ListByVoyage(ctx context.Context, voyageID string) ([]*domain.CabinPrice, error)
 
+	// ListVoyagePrices retrieves the prices of a voyage for display in the
+	// given currency (empty means the base currency)
+	ListVoyagePrices(ctx context.Context, voyageID, currencyCode string) (*VoyagePriceList, error)
+
 	// GetCurrentPrice retrieves the effective price (considering promotions)
 	GetCurrentPrice(ctx context.Context, voyageID, cabinTypeID string) (*domain.CabinPrice, error)
 
@@ -61,6 +66,34 @@ type PriceCalculation struct {
 	Total            float64 `json:"total"`
 }
 
+// VoyagePriceList is the price list of a voyage in a display currency
+type VoyagePriceList struct {
+	VoyageID     string         `json:"voyage_id"`
+	Currency     string         `json:"currency"`
+	BaseCurrency string         `json:"base_currency"`
+	ExchangeRate float64        `json:"exchange_rate"` // Currency units per BaseCurrency unit
+	RateSource   string         `json:"rate_source"`
+	RateAsOf     *time.Time     `json:"rate_as_of,omitempty"`
+	Prices       []DisplayPrice `json:"prices"`
+}
+
+// DisplayPrice is a cabin price converted to a display currency
+type DisplayPrice struct {
+	ID               string  `json:"id"`
+	CabinTypeID      string  `json:"cabin_type_id"`
+	CabinTypeName    string  `json:"cabin_type_name,omitempty"`
+	PriceType        string  `json:"price_type"`
+	AdultPrice       float64 `json:"adult_price"`
+	ChildPrice       float64 `json:"child_price"`
+	InfantPrice      float64 `json:"infant_price"`
+	SingleSupplement float64 `json:"single_supplement"`
+	ExtraAdultPrice  float64 `json:"extra_adult_price"`
+	ExtraChildPrice  float64 `json:"extra_child_price"`
+	PortFee          float64 `json:"port_fee"`
+	ServiceFee       float64 `json:"service_fee"`
+	IsPromotion      bool    `json:"is_promotion"`
+}
+
 // CreatePriceRequest represents a request to create a price
 type CreatePriceRequest struct {
 	CabinTypeID        string  `json:"cabin_type_id" validate:"required"`
@@ -69,6 +102,8 @@ type CreatePriceRequest struct {
 	ChildPrice         float64 `json:"child_price" validate:"gte=0"`
 	InfantPrice        float64 `json:"infant_price" validate:"gte=0"`
 	SingleSupplement   float64 `json:"single_supplement" validate:"gte=0"`
+	ExtraAdultPrice    float64 `json:"extra_adult_price" validate:"gte=0"`
+	ExtraChildPrice    float64 `json:"extra_child_price" validate:"gte=0"`
 	PortFee            float64 `json:"port_fee" validate:"gte=0"`
 	ServiceFee         float64 `json:"service_fee" validate:"gte=0"`
 	IsPromotion        bool    `json:"is_promotion"`
@@ -85,6 +120,8 @@ type UpdatePriceRequest struct {
 	ChildPrice         float64 `json:"child_price,omitempty" validate:"omitempty,gte=0"`
 	InfantPrice        float64 `json:"infant_price,omitempty" validate:"omitempty,gte=0"`
 	SingleSupplement   float64 `json:"single_supplement,omitempty" validate:"omitempty,gte=0"`
+	ExtraAdultPrice    float64 `json:"extra_adult_price,omitempty" validate:"omitempty,gte=0"`
+	ExtraChildPrice    float64 `json:"extra_child_price,omitempty" validate:"omitempty,gte=0"`
 	PortFee            float64 `json:"port_fee,omitempty" validate:"omitempty,gte=0"`
 	ServiceFee         float64 `json:"service_fee,omitempty" validate:"omitempty,gte=0"`
 	IsPromotion        *bool   `json:"is_promotion,omitempty"`
@@ -108,6 +145,7 @@ type priceService struct {
 	priceRepo     repository.PriceRepository
 	voyageRepo    repository.VoyageRepository
 	cabinTypeRepo repository.CabinTypeRepository
+	currency      currency.Converter
 }
 
 // NewPriceService creates a new price service
@@ -115,11 +153,13 @@ func NewPriceService(
 	priceRepo repository.PriceRepository,
 	voyageRepo repository.VoyageRepository,
 	cabinTypeRepo repository.CabinTypeRepository,
+	converter currency.Converter,
 ) PriceService {
 	return &priceService{
 		priceRepo:     priceRepo,
 		voyageRepo:    voyageRepo,
 		cabinTypeRepo: cabinTypeRepo,
+		currency:      converter,
 	}
 }
 
@@ -164,6 +204,8 @@ func (s *priceService) CreateWithVoyage(ctx context.Context, voyageID string, re
 		ChildPrice:         req.ChildPrice,
 		InfantPrice:        req.InfantPrice,
 		SingleSupplement:   req.SingleSupplement,
+		ExtraAdultPrice:    req.ExtraAdultPrice,
+		ExtraChildPrice:    req.ExtraChildPrice,
 		PortFee:            req.PortFee,
 		ServiceFee:         req.ServiceFee,
 		IsPromotion:        req.IsPromotion,
@@ -234,6 +276,48 @@ func (s *priceService) ListByVoyage(ctx context.Context, voyageID string) ([]*do
 	return s.priceRepo.ListByVoyage(ctx, voyageID)
 }
 
+func (s *priceService) ListVoyagePrices(ctx context.Context, voyageID, currencyCode string) (*VoyagePriceList, error) {
+	quote, err := QuoteFromBase(ctx, s.currency, currencyCode)
+	if err != nil {
+		return nil, err
+	}
+
+	prices, err := s.priceRepo.ListByVoyage(ctx, voyageID)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &VoyagePriceList{
+		VoyageID:     voyageID,
+		Currency:     quote.To,
+		BaseCurrency: quote.From,
+		ExchangeRate: quote.Rate,
+		RateSource:   quote.Source,
+		Prices:       make([]DisplayPrice, 0, len(prices)),
+	}
+	if !quote.AsOf.IsZero() {
+		list.RateAsOf = &quote.AsOf
+	}
+	for _, price := range prices {
+		list.Prices = append(list.Prices, DisplayPrice{
+			ID:               price.ID.String(),
+			CabinTypeID:      price.CabinTypeID,
+			CabinTypeName:    price.CabinType.Name,
+			PriceType:        price.PriceType,
+			AdultPrice:       quote.Convert(price.AdultPrice),
+			ChildPrice:       quote.Convert(price.ChildPrice),
+			InfantPrice:      quote.Convert(price.InfantPrice),
+			SingleSupplement: quote.Convert(price.SingleSupplement),
+			ExtraAdultPrice:  quote.Convert(price.ExtraAdultPrice),
+			ExtraChildPrice:  quote.Convert(price.ExtraChildPrice),
+			PortFee:          quote.Convert(price.PortFee),
+			ServiceFee:       quote.Convert(price.ServiceFee),
+			IsPromotion:      price.IsPromotion,
+		})
+	}
+	return list, nil
+}
+
 func (s *priceService) GetCurrentPrice(ctx context.Context, voyageID, cabinTypeID string) (*domain.CabinPrice, error) {
 	price, err := s.priceRepo.GetCurrentPrice(ctx, voyageID, cabinTypeID)
 	if err != nil {
@@ -261,6 +345,8 @@ func (s *priceService) Update(ctx context.Context, id string, req UpdatePriceReq
 		return nil, ErrPriceNotFound
 	}
 
+	adult, child, infant := price.AdultPrice, price.ChildPrice, price.InfantPrice
+
 	// Update fields if provided
 	if req.PriceType != "" {
 		if !isValidPriceType(req.PriceType) {
@@ -280,6 +366,12 @@ func (s *priceService) Update(ctx context.Context, id string, req UpdatePriceReq
 	if req.SingleSupplement >= 0 {
 		price.SingleSupplement = req.SingleSupplement
 	}
+	if req.ExtraAdultPrice >= 0 {
+		price.ExtraAdultPrice = req.ExtraAdultPrice
+	}
+	if req.ExtraChildPrice >= 0 {
+		price.ExtraChildPrice = req.ExtraChildPrice
+	}
 	if req.PortFee >= 0 {
 		price.PortFee = req.PortFee
 	}
@@ -301,6 +393,13 @@ func (s *priceService) Update(ctx context.Context, id string, req UpdatePriceReq
 	if req.MaxPassengers > 0 {
 		price.MaxPassengers = req.MaxPassengers
 	}
+	// Edited prices become the new base for dynamic pricing, so the next
+	// repricing starts from them rather than from the old base
+	if price.AdultPrice != adult || price.ChildPrice != child || price.InfantPrice != infant {
+		price.BaseAdultPrice = price.AdultPrice
+		price.BaseChildPrice = price.ChildPrice
+		price.BaseInfantPrice = price.InfantPrice
+	}
 
 	if err := s.priceRepo.Update(ctx, price); err != nil {
 		return nil, err
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+)
+
+var (
+	ErrPriceWatchNotFound = errors.New("price watch not found")
+	ErrInvalidPriceWatch  = errors.New("invalid price watch")
+	ErrPriceWatchLimit    = errors.New("price watch limit reached")
+)
+
+// maxPriceWatchesPerUser caps the subscriptions a single user can hold
+const maxPriceWatchesPerUser = 50
+
+// PriceWatchService defines the interface for price change subscriptions
+type PriceWatchService interface {
+	Create(ctx context.Context, userID string, req CreatePriceWatchRequest) (*domain.PriceWatch, error)
+	List(ctx context.Context, userID string) ([]*domain.PriceWatch, error)
+	Delete(ctx context.Context, userID, id string) error
+
+	// PriceChanged evaluates the watches matching a changed price and sends
+	// promotion notifications for those that fire. Failures are logged, never
+	// returned, so price writes are not affected.
+	PriceChanged(ctx context.Context, priceID string)
+}
+
+// CreatePriceWatchRequest represents a request to watch a voyage or route.
+// Exactly one of VoyageID and RouteID, and at least one of ThresholdPercent
+// and TargetPrice must be set; an either watch takes only ThresholdPercent.
+// Direction defaults to drop.
+type CreatePriceWatchRequest struct {
+	VoyageID         *string  `json:"voyage_id" validate:"omitempty,uuid"`
+	RouteID          *string  `json:"route_id" validate:"omitempty,uuid"`
+	CabinTypeID      string   `json:"cabin_type_id" validate:"required,uuid"`
+	Direction        string   `json:"direction" validate:"omitempty,oneof=drop rise either"`
+	ThresholdPercent *float64 `json:"threshold_percent" validate:"omitempty,gt=0,lt=100"`
+	TargetPrice      *float64 `json:"target_price" validate:"omitempty,gt=0"`
+}
+
+// priceWatchService implements PriceWatchService
+type priceWatchService struct {
+	watchRepo     repository.PriceWatchRepository
+	priceRepo     repository.PriceRepository
+	voyageRepo    repository.VoyageRepository
+	notifications NotificationService
+	now           func() time.Time
+}
+
+// NewPriceWatchService creates a new price watch service
+func NewPriceWatchService(
+	watchRepo repository.PriceWatchRepository,
+	priceRepo repository.PriceRepository,
+	voyageRepo repository.VoyageRepository,
+	notifications NotificationService,
+) PriceWatchService {
+	return &priceWatchService{
+		watchRepo:     watchRepo,
+		priceRepo:     priceRepo,
+		voyageRepo:    voyageRepo,
+		notifications: notifications,
+		now:           time.Now,
+	}
+}
+
+func (s *priceWatchService) Create(ctx context.Context, userID string, req CreatePriceWatchRequest) (*domain.PriceWatch, error) {
+	if (req.VoyageID == nil) == (req.RouteID == nil) {
+		return nil, fmt.Errorf("%w: exactly one of voyage_id and route_id is required", ErrInvalidPriceWatch)
+	}
+	if req.ThresholdPercent == nil && req.TargetPrice == nil {
+		return nil, fmt.Errorf("%w: threshold_percent or target_price is required", ErrInvalidPriceWatch)
+	}
+	if req.Direction == "" {
+		req.Direction = domain.PriceWatchDirectionDrop
+	}
+	if req.Direction == domain.PriceWatchDirectionEither && (req.TargetPrice != nil || req.ThresholdPercent == nil) {
+		return nil, fmt.Errorf("%w: an either watch takes threshold_percent and no target_price", ErrInvalidPriceWatch)
+	}
+
+	count, err := s.watchRepo.CountByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxPriceWatchesPerUser {
+		return nil, ErrPriceWatchLimit
+	}
+
+	watch := &domain.PriceWatch{
+		UserID:           userID,
+		VoyageID:         req.VoyageID,
+		RouteID:          req.RouteID,
+		CabinTypeID:      req.CabinTypeID,
+		Direction:        req.Direction,
+		ThresholdPercent: req.ThresholdPercent,
+		TargetPrice:      req.TargetPrice,
+		IsActive:         true,
+	}
+
+	// The baseline is the price the user saw when subscribing
+	if req.VoyageID != nil {
+		if _, err := s.voyageRepo.GetByID(ctx, *req.VoyageID); err != nil {
+			return nil, fmt.Errorf("%w: voyage not found", ErrInvalidPriceWatch)
+		}
+		if price, err := s.priceRepo.GetCurrentPrice(ctx, *req.VoyageID, req.CabinTypeID); err == nil {
+			watch.BaselinePrice = &price.AdultPrice
+		}
+	} else {
+		lowest, err := s.watchRepo.LowestRoutePrice(ctx, *req.RouteID, req.CabinTypeID)
+		if err != nil {
+			return nil, err
+		}
+		watch.BaselinePrice = lowest
+	}
+
+	if err := s.watchRepo.Create(ctx, watch); err != nil {
+		return nil, err
+	}
+	return watch, nil
+}
+
+func (s *priceWatchService) List(ctx context.Context, userID string) ([]*domain.PriceWatch, error) {
+	return s.watchRepo.ListByUser(ctx, userID)
+}
+
+func (s *priceWatchService) Delete(ctx context.Context, userID, id string) error {
+	watch, err := s.watchRepo.GetByID(ctx, id)
+	if err != nil || watch.UserID != userID {
+		return ErrPriceWatchNotFound
+	}
+	return s.watchRepo.Delete(ctx, id)
+}
+
+func (s *priceWatchService) PriceChanged(ctx context.Context, priceID string) {
+	price, err := s.priceRepo.GetByID(ctx, priceID)
+	if err != nil {
+		log.Printf("[WARN] price watch: failed to load price %s: %v", priceID, err)
+		return
+	}
+
+	watches, err := s.watchRepo.ListMatching(ctx, price.VoyageID, price.Voyage.RouteID, price.CabinTypeID)
+	if err != nil {
+		log.Printf("[WARN] price watch: failed to list watches for price %s: %v", priceID, err)
+		return
+	}
+
+	for _, watch := range watches {
+		if !watch.Triggered(price.AdultPrice) {
+			continue
+		}
+		if err := s.notify(ctx, watch, price); err != nil {
+			log.Printf("[WARN] price watch: failed to notify watch %s: %v", watch.ID, err)
+		}
+	}
+}
+
+// notify sends a price alert for a fired watch. Users who turned off
+// promotion notifications are skipped without consuming the price, so they
+// are alerted if they opt in before the next change.
+func (s *priceWatchService) notify(ctx context.Context, watch *domain.PriceWatch, price *domain.CabinPrice) error {
+	settings, err := s.notifications.GetSettings(ctx, watch.UserID)
+	if err != nil {
+		return err
+	}
+	if !settings.IsTypeEnabled(domain.NotificationTypePromotion) {
+		return nil
+	}
+
+	// Claim the price first so concurrent evaluations notify only once
+	claimed, err := s.watchRepo.MarkNotified(ctx, watch, price.AdultPrice, s.now())
+	if err != nil || !claimed {
+		return err
+	}
+
+	watchID := watch.ID.String()
+	voyageID := price.VoyageID
+	title, content := priceWatchMessage(watch, price)
+
+	_, err = s.notifications.Create(ctx, CreateNotificationRequest{
+		UserID:  watch.UserID,
+		Type:    domain.NotificationTypePromotion,
+		Title:   title,
+		Content: content,
+		Data: &domain.NotificationData{
+			VoyageID:   &voyageID,
+			VoyageName: price.Voyage.VoyageNumber,
+			Amount:     price.AdultPrice,
+		},
+		ActionURL:  fmt.Sprintf("/voyages/%s", voyageID),
+		ActionType: domain.NotificationActionViewVoyage,
+		SourceID:   &watchID,
+		SourceType: domain.PriceWatchSourceType,
+	})
+	return err
+}
+
+// priceWatchMessage words the alert for the way the price moved; an either
+// watch is told which way it went
+func priceWatchMessage(watch *domain.PriceWatch, price *domain.CabinPrice) (string, string) {
+	rose := watch.Direction == domain.PriceWatchDirectionRise
+	if watch.Direction == domain.PriceWatchDirectionEither && watch.BaselinePrice != nil {
+		rose = price.AdultPrice > *watch.BaselinePrice
+	}
+
+	title, moved, change := "降价提醒", "降至", "下降"
+	if rose {
+		title, moved, change = "涨价提醒", "涨至", "上涨"
+	}
+	content := fmt.Sprintf("您关注的航次 %s %s 成人价已%s ¥%.2f", price.Voyage.VoyageNumber, price.CabinType.Name, moved, price.AdultPrice)
+	if watch.BaselinePrice != nil && *watch.BaselinePrice != price.AdultPrice && (*watch.BaselinePrice < price.AdultPrice) == rose {
+		content += fmt.Sprintf("，较关注时%s ¥%.2f", change, math.Abs(price.AdultPrice-*watch.BaselinePrice))
+	}
+	return title, content + "。"
+}
+
+// watchedPriceRepository decorates a PriceRepository and evaluates price
+// watches after every successful price write
+type watchedPriceRepository struct {
+	repository.PriceRepository
+	watches PriceWatchService
+}
+
+// NewWatchedPriceRepository wraps repo so price changes trigger watch alerts
+func NewWatchedPriceRepository(repo repository.PriceRepository, watches PriceWatchService) repository.PriceRepository {
+	if watches == nil {
+		return repo
+	}
+	return &watchedPriceRepository{PriceRepository: repo, watches: watches}
+}
+
+func (r *watchedPriceRepository) Create(ctx context.Context, price *domain.CabinPrice) error {
+	if err := r.PriceRepository.Create(ctx, price); err != nil {
+		return err
+	}
+	r.watches.PriceChanged(ctx, price.ID.String())
+	return nil
+}
+
+func (r *watchedPriceRepository) Update(ctx context.Context, price *domain.CabinPrice) error {
+	if err := r.PriceRepository.Update(ctx, price); err != nil {
+		return err
+	}
+	r.watches.PriceChanged(ctx, price.ID.String())
+	return nil
+}
+
+func (r *watchedPriceRepository) UpdatePrice(ctx context.Context, id string, adultPrice, childPrice, infantPrice float64) error {
+	if err := r.PriceRepository.UpdatePrice(ctx, id, adultPrice, childPrice, infantPrice); err != nil {
+		return err
+	}
+	r.watches.PriceChanged(ctx, id)
+	return nil
+}
+
+func (r *watchedPriceRepository) BatchCreate(ctx context.Context, prices []*domain.CabinPrice) error {
+	if err := r.PriceRepository.BatchCreate(ctx, prices); err != nil {
+		return err
+	}
+	for _, price := range prices {
+		r.watches.PriceChanged(ctx, price.ID.String())
+	}
+	return nil
+}
+
+func (r *watchedPriceRepository) Reprice(ctx context.Context, id string, expectedVersion int, adultPrice, childPrice, infantPrice float64, repricedAt string) error {
+	if err := r.PriceRepository.Reprice(ctx, id, expectedVersion, adultPrice, childPrice, infantPrice, repricedAt); err != nil {
+		return err
+	}
+	r.watches.PriceChanged(ctx, id)
+	return nil
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriceWatchTriggered(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	rise, either := domain.PriceWatchDirectionRise, domain.PriceWatchDirectionEither
+
+	tests := []struct {
+		name  string
+		watch domain.PriceWatch
+		price float64
+		want  bool
+	}{
+		{"target reached", domain.PriceWatch{IsActive: true, TargetPrice: f(5000)}, 4999, true},
+		{"target not reached", domain.PriceWatch{IsActive: true, TargetPrice: f(5000)}, 5001, false},
+		{"threshold reached", domain.PriceWatch{IsActive: true, ThresholdPercent: f(10), BaselinePrice: f(6000)}, 5400, true},
+		{"threshold not reached", domain.PriceWatch{IsActive: true, ThresholdPercent: f(10), BaselinePrice: f(6000)}, 5500, false},
+		{"threshold without baseline", domain.PriceWatch{IsActive: true, ThresholdPercent: f(10)}, 100, false},
+		{"same price already notified", domain.PriceWatch{IsActive: true, TargetPrice: f(5000), LastNotifiedPrice: f(4800)}, 4800, false},
+		{"lower than last notified", domain.PriceWatch{IsActive: true, TargetPrice: f(5000), LastNotifiedPrice: f(4800)}, 4700, true},
+		{"inactive", domain.PriceWatch{TargetPrice: f(5000)}, 4000, false},
+		{"drop watch ignores a rise", domain.PriceWatch{IsActive: true, ThresholdPercent: f(10), BaselinePrice: f(6000)}, 7000, false},
+
+		{"rise target reached", domain.PriceWatch{IsActive: true, Direction: rise, TargetPrice: f(7000)}, 7000, true},
+		{"rise target not reached", domain.PriceWatch{IsActive: true, Direction: rise, TargetPrice: f(7000)}, 6999, false},
+		{"rise threshold reached", domain.PriceWatch{IsActive: true, Direction: rise, ThresholdPercent: f(10), BaselinePrice: f(6000)}, 6600, true},
+		{"rise watch ignores a drop", domain.PriceWatch{IsActive: true, Direction: rise, ThresholdPercent: f(10), BaselinePrice: f(6000)}, 5000, false},
+		{"higher than last notified", domain.PriceWatch{IsActive: true, Direction: rise, TargetPrice: f(7000), LastNotifiedPrice: f(7200)}, 7300, true},
+		{"rise already notified higher", domain.PriceWatch{IsActive: true, Direction: rise, TargetPrice: f(7000), LastNotifiedPrice: f(7200)}, 7100, false},
+
+		{"either fires on a drop", domain.PriceWatch{IsActive: true, Direction: either, ThresholdPercent: f(10), BaselinePrice: f(6000)}, 5400, true},
+		{"either fires on a rise", domain.PriceWatch{IsActive: true, Direction: either, ThresholdPercent: f(10), BaselinePrice: f(6000)}, 6600, true},
+		{"either below threshold", domain.PriceWatch{IsActive: true, Direction: either, ThresholdPercent: f(10), BaselinePrice: f(6000)}, 6300, false},
+		{"either further than last notified", domain.PriceWatch{IsActive: true, Direction: either, ThresholdPercent: f(10), BaselinePrice: f(6000), LastNotifiedPrice: f(5400)}, 6800, true},
+		{"either closer than last notified", domain.PriceWatch{IsActive: true, Direction: either, ThresholdPercent: f(10), BaselinePrice: f(6000), LastNotifiedPrice: f(5000)}, 6700, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.watch.Triggered(tt.price))
+		})
+	}
+}
+
+func TestPriceWatchMessage(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	price := func(adult float64) *domain.CabinPrice {
+		return &domain.CabinPrice{
+			AdultPrice: adult,
+			Voyage:     domain.Voyage{VoyageNumber: "V001"},
+			CabinType:  domain.CabinType{Name: "海景房"},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		watch       *domain.PriceWatch
+		price       *domain.CabinPrice
+		wantTitle   string
+		wantContent string
+	}{
+		{
+			name:        "drop",
+			watch:       &domain.PriceWatch{Direction: domain.PriceWatchDirectionDrop, BaselinePrice: f(6000)},
+			price:       price(5400),
+			wantTitle:   "降价提醒",
+			wantContent: "您关注的航次 V001 海景房 成人价已降至 ¥5400.00，较关注时下降 ¥600.00。",
+		},
+		{
+			name:        "rise",
+			watch:       &domain.PriceWatch{Direction: domain.PriceWatchDirectionRise, BaselinePrice: f(6000)},
+			price:       price(6600),
+			wantTitle:   "涨价提醒",
+			wantContent: "您关注的航次 V001 海景房 成人价已涨至 ¥6600.00，较关注时上涨 ¥600.00。",
+		},
+		{
+			name:        "either says which way",
+			watch:       &domain.PriceWatch{Direction: domain.PriceWatchDirectionEither, BaselinePrice: f(6000)},
+			price:       price(6600),
+			wantTitle:   "涨价提醒",
+			wantContent: "您关注的航次 V001 海景房 成人价已涨至 ¥6600.00，较关注时上涨 ¥600.00。",
+		},
+		{
+			name:        "rise to a target below the baseline",
+			watch:       &domain.PriceWatch{Direction: domain.PriceWatchDirectionRise, BaselinePrice: f(6000)},
+			price:       price(5800),
+			wantTitle:   "涨价提醒",
+			wantContent: "您关注的航次 V001 海景房 成人价已涨至 ¥5800.00。",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, content := priceWatchMessage(tt.watch, tt.price)
+
+			assert.Equal(t, tt.wantTitle, title)
+			assert.Equal(t, tt.wantContent, content)
+		})
+	}
+}
+
+func TestPriceWatchService_CreateRejectsEitherWithTarget(t *testing.T) {
+	routeID := "00000000-0000-0000-0000-000000000001"
+	target := 5000.0
+
+	_, err := NewPriceWatchService(nil, nil, nil, nil).Create(context.Background(), "user-1", CreatePriceWatchRequest{
+		RouteID:     &routeID,
+		CabinTypeID: "00000000-0000-0000-0000-000000000002",
+		Direction:   domain.PriceWatchDirectionEither,
+		TargetPrice: &target,
+	})
+
+	assert.ErrorIs(t, err, ErrInvalidPriceWatch)
+}
@@ -0,0 +1,453 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/pagination"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+)
+
+var (
+	ErrPricingRuleNotFound = errors.New("pricing rule not found")
+	ErrInvalidPricingRule  = errors.New("invalid pricing rule")
+)
+
+// Skip reasons reported by the pricing engine
+const (
+	RepricingSkipManualOverride = "manual_override"
+	RepricingSkipNoInventory    = "no_inventory"
+	RepricingSkipUnchanged      = "unchanged"
+	RepricingSkipConflict       = "version_conflict"
+	RepricingSkipPromotion      = "promotion"
+)
+
+// PricingEngine evaluates dynamic pricing rules and writes new price versions
+type PricingEngine interface {
+	// Preview evaluates rules for a voyage without writing anything
+	Preview(ctx context.Context, voyageID string) (*RepricingResult, error)
+
+	// Apply evaluates rules for a voyage and writes changed prices
+	Apply(ctx context.Context, voyageID string) (*RepricingResult, error)
+
+	// RunAll applies rules to every voyage open for booking
+	RunAll(ctx context.Context) ([]*RepricingResult, error)
+
+	// Rule management
+	CreateRule(ctx context.Context, req PricingRuleRequest) (*domain.PricingRule, error)
+	GetRule(ctx context.Context, id string) (*domain.PricingRule, error)
+	ListRules(ctx context.Context, filters repository.PricingRuleFilters) ([]*domain.PricingRule, error)
+	UpdateRule(ctx context.Context, id string, req PricingRuleRequest) (*domain.PricingRule, error)
+	DeleteRule(ctx context.Context, id string) error
+
+	// SetManualOverride pins (or releases) a price so the engine leaves it alone
+	SetManualOverride(ctx context.Context, priceID string, req ManualOverrideRequest) (*domain.CabinPrice, error)
+}
+
+// PricingRuleRequest represents a request to create or update a pricing rule
+type PricingRuleRequest struct {
+	Name              string   `json:"name" validate:"required,max=100"`
+	Description       string   `json:"description"`
+	VoyageID          *string  `json:"voyage_id" validate:"omitempty,uuid"`
+	CabinTypeID       *string  `json:"cabin_type_id" validate:"omitempty,uuid"`
+	Priority          int      `json:"priority"`
+	IsActive          *bool    `json:"is_active"`
+	Exclusive         bool     `json:"exclusive"`
+	AvailabilityBelow *float64 `json:"availability_below" validate:"omitempty,gte=0,lte=1"`
+	AvailabilityAbove *float64 `json:"availability_above" validate:"omitempty,gte=0,lte=1"`
+	LoadFactorBelow   *float64 `json:"load_factor_below" validate:"omitempty,gte=0,lte=1"`
+	LoadFactorAbove   *float64 `json:"load_factor_above" validate:"omitempty,gte=0,lte=1"`
+	DaysToDepartureLE *int     `json:"days_to_departure_le" validate:"omitempty,gte=0"`
+	DaysToDepartureGE *int     `json:"days_to_departure_ge" validate:"omitempty,gte=0"`
+	AdjustmentType    string   `json:"adjustment_type" validate:"omitempty,oneof=percent fixed"`
+	AdjustmentValue   float64  `json:"adjustment_value"`
+	FloorPrice        *float64 `json:"floor_price" validate:"omitempty,gte=0"`
+	CeilingPrice      *float64 `json:"ceiling_price" validate:"omitempty,gt=0"`
+}
+
+// ManualOverrideRequest represents a request to pin or release a price
+type ManualOverrideRequest struct {
+	Enabled     bool    `json:"enabled"`
+	AdultPrice  float64 `json:"adult_price,omitempty" validate:"omitempty,gt=0"`
+	ChildPrice  float64 `json:"child_price,omitempty" validate:"omitempty,gte=0"`
+	InfantPrice float64 `json:"infant_price,omitempty" validate:"omitempty,gte=0"`
+}
+
+// RepricingResult is the outcome of evaluating rules for one voyage
+type RepricingResult struct {
+	VoyageID        string        `json:"voyage_id"`
+	DepartureDate   string        `json:"departure_date"`
+	DaysToDeparture int           `json:"days_to_departure"`
+	DryRun          bool          `json:"dry_run"`
+	Changes         []PriceChange `json:"changes"`
+}
+
+// PriceChange describes the proposed or applied change to one cabin price
+type PriceChange struct {
+	PriceID           string   `json:"price_id"`
+	CabinTypeID       string   `json:"cabin_type_id"`
+	PriceType         string   `json:"price_type"`
+	Version           int      `json:"version"`
+	AvailabilityRatio float64  `json:"availability_ratio"`
+	LoadFactor        float64  `json:"load_factor"`
+	AppliedRules      []string `json:"applied_rules"`
+	BaseAdultPrice    float64  `json:"base_adult_price"`
+	CurrentAdultPrice float64  `json:"current_adult_price"`
+	NewAdultPrice     float64  `json:"new_adult_price"`
+	NewChildPrice     float64  `json:"new_child_price"`
+	NewInfantPrice    float64  `json:"new_infant_price"`
+	Changed           bool     `json:"changed"`
+	Skipped           string   `json:"skipped,omitempty"`
+}
+
+// pricingEngine implements PricingEngine
+type pricingEngine struct {
+	ruleRepo      repository.PricingRuleRepository
+	priceRepo     repository.PriceRepository
+	voyageRepo    repository.VoyageRepository
+	inventoryRepo repository.InventoryRepository
+	now           func() time.Time
+}
+
+// NewPricingEngine creates a new dynamic pricing engine
+func NewPricingEngine(
+	ruleRepo repository.PricingRuleRepository,
+	priceRepo repository.PriceRepository,
+	voyageRepo repository.VoyageRepository,
+	inventoryRepo repository.InventoryRepository,
+) PricingEngine {
+	return &pricingEngine{
+		ruleRepo:      ruleRepo,
+		priceRepo:     priceRepo,
+		voyageRepo:    voyageRepo,
+		inventoryRepo: inventoryRepo,
+		now:           time.Now,
+	}
+}
+
+func (e *pricingEngine) Preview(ctx context.Context, voyageID string) (*RepricingResult, error) {
+	return e.evaluate(ctx, voyageID, true)
+}
+
+func (e *pricingEngine) Apply(ctx context.Context, voyageID string) (*RepricingResult, error) {
+	return e.evaluate(ctx, voyageID, false)
+}
+
+func (e *pricingEngine) RunAll(ctx context.Context) ([]*RepricingResult, error) {
+	var results []*RepricingResult
+	paginator := &pagination.Paginator{Page: 1, PageSize: 100}
+	filters := repository.VoyageFilters{BookingStatus: domain.BookingStatusOpen}
+
+	for {
+		voyages, err := e.voyageRepo.List(ctx, filters, paginator)
+		if err != nil {
+			return results, fmt.Errorf("failed to list voyages: %w", err)
+		}
+
+		for _, voyage := range voyages {
+			result, err := e.evaluate(ctx, voyage.ID.String(), false)
+			if err != nil {
+				log.Printf("Dynamic pricing failed for voyage %s: %v", voyage.ID, err)
+				continue
+			}
+			results = append(results, result)
+		}
+
+		if len(voyages) < paginator.PageSize {
+			break
+		}
+		paginator.Page++
+	}
+
+	return results, nil
+}
+
+// evaluate computes new prices for every cabin price of the voyage
+func (e *pricingEngine) evaluate(ctx context.Context, voyageID string, dryRun bool) (*RepricingResult, error) {
+	voyage, err := e.voyageRepo.GetByID(ctx, voyageID)
+	if err != nil {
+		return nil, fmt.Errorf("voyage not found: %w", err)
+	}
+
+	rules, err := e.ruleRepo.ListActive(ctx, voyageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pricing rules: %w", err)
+	}
+
+	prices, err := e.priceRepo.ListByVoyage(ctx, voyageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load prices: %w", err)
+	}
+
+	inventories, err := e.inventoryRepo.ListInventoryByVoyage(ctx, voyageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load inventory: %w", err)
+	}
+	inventoryByType := make(map[string]*domain.CabinInventory, len(inventories))
+	for _, inv := range inventories {
+		inventoryByType[inv.CabinTypeID] = inv
+	}
+
+	now := e.now()
+	result := &RepricingResult{
+		VoyageID:        voyageID,
+		DepartureDate:   voyage.DepartureDate,
+		DaysToDeparture: daysUntil(voyage.DepartureDate, now),
+		DryRun:          dryRun,
+		Changes:         make([]PriceChange, 0, len(prices)),
+	}
+
+	for _, price := range prices {
+		change := PriceChange{
+			PriceID:           price.ID.String(),
+			CabinTypeID:       price.CabinTypeID,
+			PriceType:         price.PriceType,
+			Version:           price.Version,
+			CurrentAdultPrice: price.AdultPrice,
+			NewAdultPrice:     price.AdultPrice,
+			NewChildPrice:     price.ChildPrice,
+			NewInfantPrice:    price.InfantPrice,
+			AppliedRules:      []string{},
+		}
+
+		if price.ManualOverride {
+			change.Skipped = RepricingSkipManualOverride
+			result.Changes = append(result.Changes, change)
+			continue
+		}
+
+		// Campaign prices are restored from their pre-promotion values, so
+		// rules resume once the promotion ends
+		if price.IsPromotion {
+			change.Skipped = RepricingSkipPromotion
+			result.Changes = append(result.Changes, change)
+			continue
+		}
+
+		inv, ok := inventoryByType[price.CabinTypeID]
+		if !ok {
+			change.Skipped = RepricingSkipNoInventory
+			result.Changes = append(result.Changes, change)
+			continue
+		}
+
+		snapshot := domain.PricingSnapshot{
+			TotalCabins:     inv.TotalCabins,
+			AvailableCabins: inv.AvailableCabins,
+			BookedCabins:    inv.BookedCabins,
+			LockedCabins:    inv.LockedCabins,
+			DaysToDeparture: result.DaysToDeparture,
+		}
+		change.AvailabilityRatio = roundRatio(snapshot.AvailabilityRatio())
+		change.LoadFactor = roundRatio(snapshot.LoadFactor())
+
+		e.computePrice(price, rules, snapshot, &change)
+
+		if !change.Changed {
+			change.Skipped = RepricingSkipUnchanged
+		} else if !dryRun {
+			ruleCtx := repository.WithPriceChange(ctx, domain.PriceSourceRule, "", strings.Join(change.AppliedRules, ", "))
+			err := e.priceRepo.Reprice(ruleCtx, price.ID.String(), price.Version,
+				change.NewAdultPrice, change.NewChildPrice, change.NewInfantPrice, now.Format(time.RFC3339))
+			if errors.Is(err, repository.ErrPriceVersionConflict) {
+				change.Skipped = RepricingSkipConflict
+				change.Changed = false
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to reprice %s: %w", price.ID, err)
+			} else {
+				change.Version = price.Version + 1
+			}
+		}
+
+		result.Changes = append(result.Changes, change)
+	}
+
+	return result, nil
+}
+
+// computePrice applies matching rules to the base price of a cabin.
+// Child and infant prices move by the same factor as the adult price.
+func (e *pricingEngine) computePrice(price *domain.CabinPrice, rules []*domain.PricingRule, snapshot domain.PricingSnapshot, change *PriceChange) {
+	baseAdult := firstPositive(price.BaseAdultPrice, price.AdultPrice)
+	baseChild := firstPositive(price.BaseChildPrice, price.ChildPrice)
+	baseInfant := firstPositive(price.BaseInfantPrice, price.InfantPrice)
+	change.BaseAdultPrice = baseAdult
+
+	adult := baseAdult
+	for _, rule := range rules {
+		if !rule.AppliesTo(price.VoyageID, price.CabinTypeID) || !rule.Matches(snapshot) {
+			continue
+		}
+
+		adult = rule.Apply(adult)
+		if rule.FloorPrice != nil && adult < *rule.FloorPrice {
+			adult = *rule.FloorPrice
+		}
+		if rule.CeilingPrice != nil && adult > *rule.CeilingPrice {
+			adult = *rule.CeilingPrice
+		}
+		change.AppliedRules = append(change.AppliedRules, rule.Name)
+
+		if rule.Exclusive {
+			break
+		}
+	}
+
+	factor := 1.0
+	if baseAdult > 0 {
+		factor = adult / baseAdult
+	}
+
+	change.NewAdultPrice = roundMoney(adult)
+	change.NewChildPrice = roundMoney(baseChild * factor)
+	change.NewInfantPrice = roundMoney(baseInfant * factor)
+	change.Changed = change.NewAdultPrice != price.AdultPrice ||
+		change.NewChildPrice != price.ChildPrice ||
+		change.NewInfantPrice != price.InfantPrice
+}
+
+func (e *pricingEngine) CreateRule(ctx context.Context, req PricingRuleRequest) (*domain.PricingRule, error) {
+	rule := &domain.PricingRule{IsActive: true}
+	if err := applyPricingRuleRequest(rule, req); err != nil {
+		return nil, err
+	}
+	if err := e.ruleRepo.Create(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (e *pricingEngine) GetRule(ctx context.Context, id string) (*domain.PricingRule, error) {
+	rule, err := e.ruleRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrPricingRuleNotFound
+	}
+	return rule, nil
+}
+
+func (e *pricingEngine) ListRules(ctx context.Context, filters repository.PricingRuleFilters) ([]*domain.PricingRule, error) {
+	return e.ruleRepo.List(ctx, filters)
+}
+
+func (e *pricingEngine) UpdateRule(ctx context.Context, id string, req PricingRuleRequest) (*domain.PricingRule, error) {
+	rule, err := e.ruleRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrPricingRuleNotFound
+	}
+	if err := applyPricingRuleRequest(rule, req); err != nil {
+		return nil, err
+	}
+	if err := e.ruleRepo.Update(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (e *pricingEngine) DeleteRule(ctx context.Context, id string) error {
+	if _, err := e.ruleRepo.GetByID(ctx, id); err != nil {
+		return ErrPricingRuleNotFound
+	}
+	return e.ruleRepo.Delete(ctx, id)
+}
+
+func (e *pricingEngine) SetManualOverride(ctx context.Context, priceID string, req ManualOverrideRequest) (*domain.CabinPrice, error) {
+	price, err := e.priceRepo.GetByID(ctx, priceID)
+	if err != nil {
+		return nil, ErrPriceNotFound
+	}
+
+	price.ManualOverride = req.Enabled
+	if req.Enabled && req.AdultPrice > 0 {
+		price.AdultPrice = req.AdultPrice
+		price.ChildPrice = req.ChildPrice
+		price.InfantPrice = req.InfantPrice
+		// Pinned prices become the base, so rules resume from them once the
+		// override is released
+		price.BaseAdultPrice = req.AdultPrice
+		price.BaseChildPrice = req.ChildPrice
+		price.BaseInfantPrice = req.InfantPrice
+		price.Version++
+	}
+
+	if err := e.priceRepo.Update(ctx, price); err != nil {
+		return nil, err
+	}
+	return price, nil
+}
+
+// applyPricingRuleRequest copies and validates request fields onto a rule
+func applyPricingRuleRequest(rule *domain.PricingRule, req PricingRuleRequest) error {
+	if req.AdjustmentType == "" {
+		req.AdjustmentType = domain.AdjustmentTypePercent
+	}
+	if req.AdjustmentType == domain.AdjustmentTypePercent && req.AdjustmentValue <= -100 {
+		return fmt.Errorf("%w: percent adjustment must be greater than -100", ErrInvalidPricingRule)
+	}
+	if req.FloorPrice != nil && req.CeilingPrice != nil && *req.FloorPrice > *req.CeilingPrice {
+		return fmt.Errorf("%w: floor price exceeds ceiling price", ErrInvalidPricingRule)
+	}
+	if req.Priority == 0 {
+		req.Priority = 100
+	}
+
+	rule.Name = req.Name
+	rule.Description = req.Description
+	rule.VoyageID = req.VoyageID
+	rule.CabinTypeID = req.CabinTypeID
+	rule.Priority = req.Priority
+	rule.Exclusive = req.Exclusive
+	rule.AvailabilityBelow = req.AvailabilityBelow
+	rule.AvailabilityAbove = req.AvailabilityAbove
+	rule.LoadFactorBelow = req.LoadFactorBelow
+	rule.LoadFactorAbove = req.LoadFactorAbove
+	rule.DaysToDepartureLE = req.DaysToDepartureLE
+	rule.DaysToDepartureGE = req.DaysToDepartureGE
+	rule.AdjustmentType = req.AdjustmentType
+	rule.AdjustmentValue = req.AdjustmentValue
+	rule.FloorPrice = req.FloorPrice
+	rule.CeilingPrice = req.CeilingPrice
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+// daysUntil returns whole days from now until a departure date
+func daysUntil(date string, now time.Time) int {
+	departure, err := time.ParseInLocation("2006-01-02", dateOnly(date), now.Location())
+	if err != nil {
+		return 0
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return int(departure.Sub(today).Hours() / 24)
+}
+
+// dateOnly trims a DATE column value (which may come back as RFC3339) to 2006-01-02
+func dateOnly(date string) string {
+	if len(date) > 10 {
+		return date[:10]
+	}
+	return date
+}
+
+func firstPositive(values ...float64) float64 {
+	for _, v := range values {
+		if v > 0 {
+			return v
+		}
+	}
+	return 0
+}
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func roundRatio(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock Pricing Rule Repository
+type MockPricingRuleRepository struct {
+	mock.Mock
+}
+
+func (m *MockPricingRuleRepository) Create(ctx context.Context, rule *domain.PricingRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+
+func (m *MockPricingRuleRepository) GetByID(ctx context.Context, id string) (*domain.PricingRule, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PricingRule), args.Error(1)
+}
+
+func (m *MockPricingRuleRepository) List(ctx context.Context, filters repository.PricingRuleFilters) ([]*domain.PricingRule, error) {
+	args := m.Called(ctx, filters)
+	return args.Get(0).([]*domain.PricingRule), args.Error(1)
+}
+
+func (m *MockPricingRuleRepository) ListActive(ctx context.Context, voyageID string) ([]*domain.PricingRule, error) {
+	args := m.Called(ctx, voyageID)
+	return args.Get(0).([]*domain.PricingRule), args.Error(1)
+}
+
+func (m *MockPricingRuleRepository) Update(ctx context.Context, rule *domain.PricingRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+
+func (m *MockPricingRuleRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func floatPtr(v float64) *float64 { return &v }
+func intPtr(v int) *int           { return &v }
+
+func TestPricingEngine_Evaluate(t *testing.T) {
+	ctx := context.Background()
+	voyageID := uuid.New().String()
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	voyage := &domain.Voyage{DepartureDate: "2026-03-11"} // 10 days out
+	rules := []*domain.PricingRule{
+		{Name: "scarcity", Priority: 10, AvailabilityBelow: floatPtr(0.2), AdjustmentType: domain.AdjustmentTypePercent, AdjustmentValue: 10},
+		{Name: "last-minute", Priority: 20, DaysToDepartureLE: intPtr(14), LoadFactorBelow: floatPtr(0.6), AdjustmentType: domain.AdjustmentTypePercent, AdjustmentValue: -15, FloorPrice: floatPtr(900)},
+	}
+
+	scarceType, emptyType, pinnedType, campaignType := uuid.New().String(), uuid.New().String(), uuid.New().String(), uuid.New().String()
+	scarce := &domain.CabinPrice{BaseModel: domain.BaseModel{ID: uuid.New()}, VoyageID: voyageID, CabinTypeID: scarceType, AdultPrice: 1000, ChildPrice: 500, Version: 3}
+	empty := &domain.CabinPrice{BaseModel: domain.BaseModel{ID: uuid.New()}, VoyageID: voyageID, CabinTypeID: emptyType, AdultPrice: 1000, ChildPrice: 600, Version: 1}
+	pinned := &domain.CabinPrice{BaseModel: domain.BaseModel{ID: uuid.New()}, VoyageID: voyageID, CabinTypeID: pinnedType, AdultPrice: 1000, ManualOverride: true}
+	campaign := &domain.CabinPrice{BaseModel: domain.BaseModel{ID: uuid.New()}, VoyageID: voyageID, CabinTypeID: campaignType, AdultPrice: 800, IsPromotion: true}
+
+	inventories := []*domain.CabinInventory{
+		{CabinTypeID: scarceType, TotalCabins: 10, AvailableCabins: 1, BookedCabins: 9},
+		{CabinTypeID: emptyType, TotalCabins: 10, AvailableCabins: 8, BookedCabins: 2},
+		{CabinTypeID: pinnedType, TotalCabins: 10, AvailableCabins: 1, BookedCabins: 9},
+		{CabinTypeID: campaignType, TotalCabins: 10, AvailableCabins: 1, BookedCabins: 9},
+	}
+
+	newEngine := func() (*pricingEngine, *MockPriceRepository) {
+		ruleRepo := new(MockPricingRuleRepository)
+		priceRepo := new(MockPriceRepository)
+		voyageRepo := new(MockVoyageRepository)
+		inventoryRepo := new(MockInventoryRepository)
+
+		ruleRepo.On("ListActive", ctx, voyageID).Return(rules, nil)
+		priceRepo.On("ListByVoyage", ctx, voyageID).Return([]*domain.CabinPrice{scarce, empty, pinned, campaign}, nil)
+		voyageRepo.On("GetByID", ctx, voyageID).Return(voyage, nil)
+		inventoryRepo.On("ListInventoryByVoyage", ctx, voyageID).Return(inventories, nil)
+
+		engine := NewPricingEngine(ruleRepo, priceRepo, voyageRepo, inventoryRepo).(*pricingEngine)
+		engine.now = func() time.Time { return now }
+		return engine, priceRepo
+	}
+
+	t.Run("preview does not write prices", func(t *testing.T) {
+		engine, priceRepo := newEngine()
+
+		result, err := engine.Preview(ctx, voyageID)
+
+		assert.NoError(t, err)
+		assert.True(t, result.DryRun)
+		assert.Equal(t, 10, result.DaysToDeparture)
+		assert.Len(t, result.Changes, 4)
+
+		// Scarce cabin: +10%
+		assert.Equal(t, 1100.0, result.Changes[0].NewAdultPrice)
+		assert.Equal(t, 550.0, result.Changes[0].NewChildPrice)
+		assert.Equal(t, []string{"scarcity"}, result.Changes[0].AppliedRules)
+
+		// Empty cabin close to departure: -15% clamped to floor 900
+		assert.Equal(t, 900.0, result.Changes[1].NewAdultPrice)
+		assert.Equal(t, 540.0, result.Changes[1].NewChildPrice)
+
+		// Manual override and campaign price untouched
+		assert.Equal(t, RepricingSkipManualOverride, result.Changes[2].Skipped)
+		assert.Equal(t, RepricingSkipPromotion, result.Changes[3].Skipped)
+		assert.Equal(t, 800.0, result.Changes[3].NewAdultPrice)
+
+		priceRepo.AssertNotCalled(t, "Reprice", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("apply writes new versions", func(t *testing.T) {
+		engine, priceRepo := newEngine()
+		repricedAt := now.Format(time.RFC3339)
+		priceRepo.On("Reprice", mock.Anything, scarce.ID.String(), 3, 1100.0, 550.0, 0.0, repricedAt).Return(nil).Once()
+		priceRepo.On("Reprice", mock.Anything, empty.ID.String(), 1, 900.0, 540.0, 0.0, repricedAt).Return(repository.ErrPriceVersionConflict).Once()
+
+		result, err := engine.Apply(ctx, voyageID)
+
+		assert.NoError(t, err)
+		assert.False(t, result.DryRun)
+		assert.Equal(t, 4, result.Changes[0].Version)
+		assert.True(t, result.Changes[0].Changed)
+		assert.Equal(t, RepricingSkipConflict, result.Changes[1].Skipped)
+		priceRepo.AssertExpectations(t)
+	})
+}
+
+func TestPricingEngine_RepriceAfterManualEdit(t *testing.T) {
+	ctx := context.Background()
+	voyageID := uuid.New().String()
+	cabinTypeID := uuid.New().String()
+	rules := []*domain.PricingRule{
+		{Name: "scarcity", AdjustmentType: domain.AdjustmentTypePercent, AdjustmentValue: 10},
+	}
+	// Repriced once from a base of 1000
+	price := &domain.CabinPrice{
+		BaseModel:      domain.BaseModel{ID: uuid.New()},
+		VoyageID:       voyageID,
+		CabinTypeID:    cabinTypeID,
+		AdultPrice:     1100,
+		ChildPrice:     550,
+		BaseAdultPrice: 1000,
+		BaseChildPrice: 500,
+		Version:        2,
+		LastRepricedAt: "2026-03-01T10:00:00Z",
+	}
+
+	priceRepo := new(MockPriceRepository)
+	priceRepo.On("GetByID", ctx, price.ID.String()).Return(price, nil).Once()
+	priceRepo.On("Update", ctx, price).Return(nil).Once()
+	prices := NewPriceService(priceRepo, nil, nil, nil)
+
+	_, err := prices.Update(ctx, price.ID.String(), UpdatePriceRequest{AdultPrice: 2000, ChildPrice: 900})
+	assert.NoError(t, err)
+	assert.Equal(t, 2000.0, price.BaseAdultPrice)
+	assert.Equal(t, 900.0, price.BaseChildPrice)
+
+	engine := &pricingEngine{}
+	change := PriceChange{}
+	engine.computePrice(price, rules, domain.PricingSnapshot{}, &change)
+
+	assert.Equal(t, 2200.0, change.NewAdultPrice)
+	assert.Equal(t, 990.0, change.NewChildPrice)
+	priceRepo.AssertExpectations(t)
+}
+
+func TestPricingEngine_ManualOverrideResetsBase(t *testing.T) {
+	ctx := context.Background()
+	price := &domain.CabinPrice{
+		BaseModel:      domain.BaseModel{ID: uuid.New()},
+		AdultPrice:     1100,
+		ChildPrice:     550,
+		BaseAdultPrice: 1000,
+		BaseChildPrice: 500,
+		Version:        2,
+	}
+
+	priceRepo := new(MockPriceRepository)
+	priceRepo.On("GetByID", ctx, price.ID.String()).Return(price, nil).Once()
+	priceRepo.On("Update", ctx, price).Return(nil).Once()
+	engine := NewPricingEngine(nil, priceRepo, nil, nil)
+
+	_, err := engine.SetManualOverride(ctx, price.ID.String(), ManualOverrideRequest{Enabled: true, AdultPrice: 1500, ChildPrice: 700})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1500.0, price.BaseAdultPrice)
+	assert.Equal(t, 700.0, price.BaseChildPrice)
+	assert.Equal(t, 3, price.Version)
+	priceRepo.AssertExpectations(t)
+}
+
+func TestPriceService_UpdateKeepsBaseWithoutPriceChange(t *testing.T) {
+	ctx := context.Background()
+	price := &domain.CabinPrice{
+		BaseModel:      domain.BaseModel{ID: uuid.New()},
+		AdultPrice:     1100,
+		BaseAdultPrice: 1000,
+	}
+
+	priceRepo := new(MockPriceRepository)
+	priceRepo.On("GetByID", ctx, price.ID.String()).Return(price, nil).Once()
+	priceRepo.On("Update", ctx, price).Return(nil).Once()
+
+	_, err := NewPriceService(priceRepo, nil, nil, nil).Update(ctx, price.ID.String(), UpdatePriceRequest{MaxPassengers: 3})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1000.0, price.BaseAdultPrice)
+}
@@ -0,0 +1,354 @@
+package service
+
+import (
+	"backend/internal/cache"
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrPromotionNotFound    = errors.New("promotion campaign not found")
+	ErrInvalidPromotion     = errors.New("invalid promotion campaign")
+	ErrPromotionNotEditable = errors.New("only scheduled campaigns can be edited")
+	ErrPromotionFinished    = errors.New("promotion campaign has already finished")
+)
+
+// maxCalendarDays bounds the range of a promotion calendar request
+const maxCalendarDays = 92
+
+// PromotionService defines the interface for scheduled promotion campaigns
+type PromotionService interface {
+	CreateCampaign(ctx context.Context, actorID string, req PromotionCampaignRequest) (*domain.PromotionCampaign, error)
+	GetCampaign(ctx context.Context, id string) (*domain.PromotionCampaign, error)
+	ListCampaigns(ctx context.Context, filters repository.PromotionFilters) ([]*domain.PromotionCampaign, error)
+	ListCampaignPrices(ctx context.Context, id string) ([]*domain.PromotionPrice, error)
+	UpdateCampaign(ctx context.Context, id string, req PromotionCampaignRequest) (*domain.PromotionCampaign, error)
+
+	// CancelCampaign cancels a scheduled campaign, or ends a running one early
+	// and restores its prices
+	CancelCampaign(ctx context.Context, id string) (*domain.PromotionCampaign, error)
+
+	// Calendar lists the campaigns running on each day of [from, to]
+	Calendar(ctx context.Context, from, to time.Time) (*PromotionCalendar, error)
+
+	// RunSchedule activates due campaigns, ends expired ones and clears
+	// promotion flags whose window has passed
+	RunSchedule(ctx context.Context) (*PromotionRunResult, error)
+}
+
+// PromotionCampaignRequest represents a request to create or update a campaign
+type PromotionCampaignRequest struct {
+	Name          string    `json:"name" validate:"required,max=100"`
+	Description   string    `json:"description"`
+	VoyageIDs     []string  `json:"voyage_ids" validate:"required,min=1,dive,uuid"`
+	CabinTypeIDs  []string  `json:"cabin_type_ids" validate:"omitempty,dive,uuid"`
+	DiscountType  string    `json:"discount_type" validate:"required,oneof=percent fixed"`
+	DiscountValue float64   `json:"discount_value" validate:"gt=0"`
+	StartsAt      time.Time `json:"starts_at" validate:"required"`
+	EndsAt        time.Time `json:"ends_at" validate:"required"`
+}
+
+// PromotionCalendar is the day-by-day view of campaigns in a date range
+type PromotionCalendar struct {
+	From string                 `json:"from"`
+	To   string                 `json:"to"`
+	Days []PromotionCalendarDay `json:"days"`
+}
+
+// PromotionCalendarDay lists the campaigns running on a day
+type PromotionCalendarDay struct {
+	Date      string                   `json:"date"`
+	Campaigns []PromotionCalendarEntry `json:"campaigns"`
+}
+
+// PromotionCalendarEntry summarizes a campaign in the calendar
+type PromotionCalendarEntry struct {
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	Status        string  `json:"status"`
+	DiscountType  string  `json:"discount_type"`
+	DiscountValue float64 `json:"discount_value"`
+}
+
+// PromotionRunResult summarizes a scheduler run
+type PromotionRunResult struct {
+	Activated      []string `json:"activated"`
+	Ended          []string `json:"ended"`
+	PricesApplied  int      `json:"prices_applied"`
+	PricesRestored int      `json:"prices_restored"`
+	StaleCleared   int      `json:"stale_cleared"`
+}
+
+// promotionService implements PromotionService
+type promotionService struct {
+	promotionRepo repository.PromotionRepository
+	voyageRepo    repository.VoyageRepository
+	watches       PriceWatchService
+	redis         *redis.Client
+	now           func() time.Time
+}
+
+// NewPromotionService creates a new promotion service. Price changes made by
+// campaigns are reported to watches (optional) and evict cached prices when
+// a Redis client is given.
+func NewPromotionService(
+	promotionRepo repository.PromotionRepository,
+	voyageRepo repository.VoyageRepository,
+	watches PriceWatchService,
+	redisClients ...*redis.Client,
+) PromotionService {
+	s := &promotionService{
+		promotionRepo: promotionRepo,
+		voyageRepo:    voyageRepo,
+		watches:       watches,
+		now:           time.Now,
+	}
+	if len(redisClients) > 0 {
+		s.redis = redisClients[0]
+	}
+	return s
+}
+
+func (s *promotionService) CreateCampaign(ctx context.Context, actorID string, req PromotionCampaignRequest) (*domain.PromotionCampaign, error) {
+	if err := s.validateRequest(ctx, req); err != nil {
+		return nil, err
+	}
+	if !req.EndsAt.After(s.now()) {
+		return nil, fmt.Errorf("%w: campaign ends in the past", ErrInvalidPromotion)
+	}
+
+	campaign := &domain.PromotionCampaign{Status: domain.PromotionStatusScheduled}
+	applyPromotionRequest(campaign, req)
+	if actorID != "" {
+		campaign.CreatedBy = &actorID
+	}
+
+	if err := s.promotionRepo.Create(ctx, campaign); err != nil {
+		return nil, err
+	}
+	return campaign, nil
+}
+
+func (s *promotionService) GetCampaign(ctx context.Context, id string) (*domain.PromotionCampaign, error) {
+	campaign, err := s.promotionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrPromotionNotFound
+	}
+	return campaign, nil
+}
+
+func (s *promotionService) ListCampaigns(ctx context.Context, filters repository.PromotionFilters) ([]*domain.PromotionCampaign, error) {
+	return s.promotionRepo.List(ctx, filters)
+}
+
+func (s *promotionService) ListCampaignPrices(ctx context.Context, id string) ([]*domain.PromotionPrice, error) {
+	if _, err := s.GetCampaign(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.promotionRepo.ListPromotionPrices(ctx, id)
+}
+
+func (s *promotionService) UpdateCampaign(ctx context.Context, id string, req PromotionCampaignRequest) (*domain.PromotionCampaign, error) {
+	campaign, err := s.GetCampaign(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if campaign.Status != domain.PromotionStatusScheduled {
+		return nil, ErrPromotionNotEditable
+	}
+	if err := s.validateRequest(ctx, req); err != nil {
+		return nil, err
+	}
+
+	applyPromotionRequest(campaign, req)
+	if err := s.promotionRepo.Update(ctx, campaign); err != nil {
+		return nil, err
+	}
+	return campaign, nil
+}
+
+func (s *promotionService) CancelCampaign(ctx context.Context, id string) (*domain.PromotionCampaign, error) {
+	campaign, err := s.GetCampaign(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	restored, err := s.promotionRepo.Finish(ctx, campaign, domain.PromotionStatusCancelled, s.now())
+	if errors.Is(err, repository.ErrPromotionStateConflict) {
+		return nil, ErrPromotionFinished
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.pricesChanged(ctx, restored)
+	return campaign, nil
+}
+
+func (s *promotionService) Calendar(ctx context.Context, from, to time.Time) (*PromotionCalendar, error) {
+	from = startOfDay(from)
+	to = startOfDay(to)
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: calendar end is before start", ErrInvalidPromotion)
+	}
+	if to.Sub(from) >= maxCalendarDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: calendar range exceeds %d days", ErrInvalidPromotion, maxCalendarDays)
+	}
+
+	campaigns, err := s.promotionRepo.ListOverlapping(ctx, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := &PromotionCalendar{
+		From: from.Format("2006-01-02"),
+		To:   to.Format("2006-01-02"),
+	}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		entry := PromotionCalendarDay{
+			Date:      day.Format("2006-01-02"),
+			Campaigns: []PromotionCalendarEntry{},
+		}
+		for _, campaign := range campaigns {
+			if campaign.Status == domain.PromotionStatusCancelled && campaign.ActivatedAt == nil {
+				continue
+			}
+			if !campaign.Overlaps(day, day.AddDate(0, 0, 1)) {
+				continue
+			}
+			entry.Campaigns = append(entry.Campaigns, PromotionCalendarEntry{
+				ID:            campaign.ID.String(),
+				Name:          campaign.Name,
+				Status:        campaign.Status,
+				DiscountType:  campaign.DiscountType,
+				DiscountValue: campaign.DiscountValue,
+			})
+		}
+		calendar.Days = append(calendar.Days, entry)
+	}
+
+	return calendar, nil
+}
+
+func (s *promotionService) RunSchedule(ctx context.Context) (*PromotionRunResult, error) {
+	now := s.now()
+	result := &PromotionRunResult{Activated: []string{}, Ended: []string{}}
+
+	due, err := s.promotionRepo.ListDue(ctx, now)
+	if err != nil {
+		return result, fmt.Errorf("failed to list due campaigns: %w", err)
+	}
+	for _, campaign := range due {
+		// A campaign whose whole window passed while the scheduler was down
+		// is closed without touching prices
+		if campaign.ExpiredAt(now) {
+			if _, err := s.promotionRepo.Finish(ctx, campaign, domain.PromotionStatusEnded, now); err != nil {
+				logPromotionError("end", campaign, err)
+				continue
+			}
+			result.Ended = append(result.Ended, campaign.ID.String())
+			continue
+		}
+
+		applied, err := s.promotionRepo.Activate(ctx, campaign, now)
+		if err != nil {
+			logPromotionError("activate", campaign, err)
+			continue
+		}
+		result.Activated = append(result.Activated, campaign.ID.String())
+		result.PricesApplied += len(applied)
+		s.pricesChanged(ctx, applied)
+	}
+
+	expiring, err := s.promotionRepo.ListExpiring(ctx, now)
+	if err != nil {
+		return result, fmt.Errorf("failed to list expiring campaigns: %w", err)
+	}
+	for _, campaign := range expiring {
+		restored, err := s.promotionRepo.Finish(ctx, campaign, domain.PromotionStatusEnded, now)
+		if err != nil {
+			logPromotionError("end", campaign, err)
+			continue
+		}
+		result.Ended = append(result.Ended, campaign.ID.String())
+		result.PricesRestored += len(restored)
+		s.pricesChanged(ctx, restored)
+	}
+
+	cleared, err := s.promotionRepo.ClearStalePromotions(ctx, now.Format("2006-01-02"))
+	if err != nil {
+		return result, fmt.Errorf("failed to clear stale promotions: %w", err)
+	}
+	for _, price := range cleared {
+		s.evictPrice(ctx, price.VoyageID, price.CabinTypeID)
+	}
+	result.StaleCleared = len(cleared)
+
+	return result, nil
+}
+
+func (s *promotionService) validateRequest(ctx context.Context, req PromotionCampaignRequest) error {
+	if !req.EndsAt.After(req.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidPromotion)
+	}
+	if req.DiscountType == domain.AdjustmentTypePercent && req.DiscountValue >= 100 {
+		return fmt.Errorf("%w: percentage discount must be below 100", ErrInvalidPromotion)
+	}
+	for _, voyageID := range req.VoyageIDs {
+		if _, err := s.voyageRepo.GetByID(ctx, voyageID); err != nil {
+			return fmt.Errorf("%w: voyage %s not found", ErrInvalidPromotion, voyageID)
+		}
+	}
+	return nil
+}
+
+// pricesChanged evicts cached prices and evaluates price watches for prices
+// changed by a campaign
+func (s *promotionService) pricesChanged(ctx context.Context, prices []*domain.PromotionPrice) {
+	for _, price := range prices {
+		s.evictPrice(ctx, price.VoyageID, price.CabinTypeID)
+		if s.watches != nil {
+			s.watches.PriceChanged(ctx, price.PriceID)
+		}
+	}
+}
+
+func (s *promotionService) evictPrice(ctx context.Context, voyageID, cabinTypeID string) {
+	if s.redis == nil {
+		return
+	}
+	key := cache.GenerateKey(cache.CacheKeyPrice, voyageID, cabinTypeID)
+	if err := s.redis.Del(ctx, key).Err(); err != nil {
+		log.Printf("[WARN] failed to evict cached price %s: %v", key, err)
+	}
+}
+
+func applyPromotionRequest(campaign *domain.PromotionCampaign, req PromotionCampaignRequest) {
+	campaign.Name = req.Name
+	campaign.Description = req.Description
+	campaign.DiscountType = req.DiscountType
+	campaign.DiscountValue = req.DiscountValue
+	campaign.StartsAt = req.StartsAt
+	campaign.EndsAt = req.EndsAt
+	campaign.SetTargets(req.VoyageIDs, req.CabinTypeIDs)
+}
+
+func logPromotionError(action string, campaign *domain.PromotionCampaign, err error) {
+	// Another scheduler instance handled the campaign first
+	if errors.Is(err, repository.ErrPromotionStateConflict) {
+		return
+	}
+	log.Printf("Promotion scheduler failed to %s campaign %s: %v", action, campaign.ID, err)
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockPromotionRepository mocks the scheduler side of PromotionRepository
+type MockPromotionRepository struct {
+	repository.PromotionRepository
+	mock.Mock
+}
+
+func (m *MockPromotionRepository) ListDue(ctx context.Context, at time.Time) ([]*domain.PromotionCampaign, error) {
+	args := m.Called(ctx, at)
+	return args.Get(0).([]*domain.PromotionCampaign), args.Error(1)
+}
+
+func (m *MockPromotionRepository) ListExpiring(ctx context.Context, at time.Time) ([]*domain.PromotionCampaign, error) {
+	args := m.Called(ctx, at)
+	return args.Get(0).([]*domain.PromotionCampaign), args.Error(1)
+}
+
+func (m *MockPromotionRepository) Activate(ctx context.Context, campaign *domain.PromotionCampaign, at time.Time) ([]*domain.PromotionPrice, error) {
+	args := m.Called(ctx, campaign, at)
+	return args.Get(0).([]*domain.PromotionPrice), args.Error(1)
+}
+
+func (m *MockPromotionRepository) Finish(ctx context.Context, campaign *domain.PromotionCampaign, status string, at time.Time) ([]*domain.PromotionPrice, error) {
+	args := m.Called(ctx, campaign, status, at)
+	return args.Get(0).([]*domain.PromotionPrice), args.Error(1)
+}
+
+func (m *MockPromotionRepository) ClearStalePromotions(ctx context.Context, today string) ([]*domain.CabinPrice, error) {
+	args := m.Called(ctx, today)
+	return args.Get(0).([]*domain.CabinPrice), args.Error(1)
+}
+
+// recordingPriceWatches records the prices reported as changed
+type recordingPriceWatches struct {
+	PriceWatchService
+	changed []string
+}
+
+func (r *recordingPriceWatches) PriceChanged(ctx context.Context, priceID string) {
+	r.changed = append(r.changed, priceID)
+}
+
+func TestPromotionRunSchedule(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	newCampaign := func(status string, startsAt, endsAt time.Time) *domain.PromotionCampaign {
+		c := &domain.PromotionCampaign{Name: "summer", Status: status, StartsAt: startsAt, EndsAt: endsAt}
+		c.ID = uuid.New()
+		return c
+	}
+
+	starting := newCampaign(domain.PromotionStatusScheduled, now.Add(-time.Minute), now.Add(24*time.Hour))
+	missed := newCampaign(domain.PromotionStatusScheduled, now.Add(-48*time.Hour), now.Add(-24*time.Hour))
+	racing := newCampaign(domain.PromotionStatusScheduled, now.Add(-time.Minute), now.Add(time.Hour))
+	ending := newCampaign(domain.PromotionStatusActive, now.Add(-72*time.Hour), now)
+
+	repo := new(MockPromotionRepository)
+	repo.On("ListDue", ctx, now).Return([]*domain.PromotionCampaign{starting, missed, racing}, nil)
+	repo.On("Activate", ctx, starting, now).Return([]*domain.PromotionPrice{{PriceID: "p1"}, {PriceID: "p2"}}, nil)
+	repo.On("Activate", ctx, racing, now).Return([]*domain.PromotionPrice(nil), repository.ErrPromotionStateConflict)
+	repo.On("Finish", ctx, missed, domain.PromotionStatusEnded, now).Return([]*domain.PromotionPrice{}, nil)
+	repo.On("ListExpiring", ctx, now).Return([]*domain.PromotionCampaign{ending}, nil)
+	repo.On("Finish", ctx, ending, domain.PromotionStatusEnded, now).Return([]*domain.PromotionPrice{{PriceID: "p3"}}, nil)
+	repo.On("ClearStalePromotions", ctx, "2026-06-01").Return([]*domain.CabinPrice{{}}, nil)
+
+	watches := &recordingPriceWatches{}
+	svc := NewPromotionService(repo, nil, watches).(*promotionService)
+	svc.now = func() time.Time { return now }
+
+	result, err := svc.RunSchedule(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{starting.ID.String()}, result.Activated)
+	assert.Equal(t, []string{missed.ID.String(), ending.ID.String()}, result.Ended)
+	assert.Equal(t, 2, result.PricesApplied)
+	assert.Equal(t, 1, result.PricesRestored)
+	assert.Equal(t, 1, result.StaleCleared)
+	assert.Equal(t, []string{"p1", "p2", "p3"}, watches.changed)
+	repo.AssertNotCalled(t, "Activate", ctx, missed, now)
+	repo.AssertExpectations(t)
+}
+
+func TestPromotionCampaignDiscount(t *testing.T) {
+	percent := &domain.PromotionCampaign{DiscountType: domain.AdjustmentTypePercent, DiscountValue: 15}
+	adult, child, infant := percent.Discount(6000, 3000, 0)
+	assert.Equal(t, []float64{5100, 2550, 0}, []float64{adult, child, infant})
+
+	fixed := &domain.PromotionCampaign{DiscountType: domain.AdjustmentTypeFixed, DiscountValue: 1000}
+	adult, child, _ = fixed.Discount(4000, 2000, 0)
+	assert.Equal(t, []float64{3000, 1500}, []float64{adult, child})
+
+	adult, child, _ = fixed.Discount(800, 400, 0)
+	assert.Equal(t, []float64{0, 0}, []float64{adult, child})
+}
@@ -0,0 +1,412 @@
+package service
+
+import (
+	"backend/internal/currency"
+	"backend/internal/domain"
+	"backend/internal/payment"
+	"backend/internal/repository"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrReconciliationNotFound = errors.New("reconciliation run not found")
+	ErrInvalidReconciliation  = errors.New("invalid reconciliation request")
+)
+
+// ReconciliationService defines the interface for daily payment reconciliation
+type ReconciliationService interface {
+	// Import compares a provider's daily statement with local payments and
+	// refunds and stores the result as a run
+	Import(ctx context.Context, userID string, req ImportStatementRequest) (*domain.ReconciliationRun, error)
+
+	GetRun(ctx context.Context, id string) (*domain.ReconciliationRun, error)
+	ListRuns(ctx context.Context, filters repository.ReconciliationFilters) ([]*domain.ReconciliationRun, error)
+	ListItems(ctx context.Context, runID string, result string) ([]*domain.ReconciliationItem, error)
+
+	// Export writes a run's summary and items as CSV
+	Export(ctx context.Context, runID string, w io.Writer) error
+}
+
+// ImportStatementRequest represents a statement import
+type ImportStatementRequest struct {
+	Provider string    `validate:"required,oneof=wechat alipay"`
+	Date     string    `validate:"required"` // statement date, YYYY-MM-DD
+	Trades   io.Reader // trade statement, or WeChat's combined bill
+	Refunds  io.Reader // optional refund statement
+}
+
+// reconciliationService implements ReconciliationService
+type reconciliationService struct {
+	repo repository.ReconciliationRepository
+}
+
+// NewReconciliationService creates a new reconciliation service
+func NewReconciliationService(repo repository.ReconciliationRepository) ReconciliationService {
+	return &reconciliationService{repo: repo}
+}
+
+func (s *reconciliationService) Import(ctx context.Context, userID string, req ImportStatementRequest) (*domain.ReconciliationRun, error) {
+	day, err := time.ParseInLocation("2006-01-02", req.Date, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("%w: date must be YYYY-MM-DD", ErrInvalidReconciliation)
+	}
+	if req.Trades == nil {
+		return nil, fmt.Errorf("%w: trade statement is required", ErrInvalidReconciliation)
+	}
+
+	entries, err := payment.ParseStatement(req.Trades, payment.StatementTrade)
+	if err != nil {
+		return nil, fmt.Errorf("%w: trade statement: %v", ErrInvalidReconciliation, err)
+	}
+	if req.Refunds != nil {
+		refunds, err := payment.ParseStatement(req.Refunds, payment.StatementRefund)
+		if err != nil {
+			return nil, fmt.Errorf("%w: refund statement: %v", ErrInvalidReconciliation, err)
+		}
+		entries = append(entries, refunds...)
+	}
+
+	var trades, refunds []payment.StatementEntry
+	for _, entry := range entries {
+		if entry.Kind == payment.StatementRefund {
+			refunds = append(refunds, entry)
+		} else {
+			trades = append(trades, entry)
+		}
+	}
+
+	run := &domain.ReconciliationRun{
+		Provider:      req.Provider,
+		StatementDate: req.Date,
+		TradeCount:    len(trades),
+		RefundCount:   len(refunds),
+	}
+	if userID != "" {
+		run.CreatedBy = &userID
+	}
+
+	from, to := day, day.AddDate(0, 0, 1)
+	tradeItems, err := s.reconcileTrades(ctx, run, trades, from, to)
+	if err != nil {
+		return nil, err
+	}
+	refundItems, err := s.reconcileRefunds(ctx, run, refunds, from, to)
+	if err != nil {
+		return nil, err
+	}
+	items := append(tradeItems, refundItems...)
+
+	for _, item := range items {
+		run.Count(item.Result)
+	}
+	run.TradeAmount = currency.Round(run.TradeAmount, currency.Base)
+	run.RefundAmount = currency.Round(run.RefundAmount, currency.Base)
+	run.FeeAmount = currency.Round(run.FeeAmount, currency.Base)
+	run.NetAmount = currency.Round(run.TradeAmount-run.RefundAmount-run.FeeAmount, currency.Base)
+	run.LocalTradeAmount = currency.Round(run.LocalTradeAmount, currency.Base)
+	run.LocalRefundAmount = currency.Round(run.LocalRefundAmount, currency.Base)
+	run.Status = domain.ReconciliationStatusBalanced
+	if run.MismatchCount() > 0 {
+		run.Status = domain.ReconciliationStatusUnbalanced
+	}
+
+	if err := s.repo.CreateRun(ctx, run, items); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// reconcileTrades matches statement trades to payments by transaction ID,
+// falling back to the payment number for payments whose notification never
+// arrived
+func (s *reconciliationService) reconcileTrades(ctx context.Context, run *domain.ReconciliationRun, entries []payment.StatementEntry, from, to time.Time) ([]*domain.ReconciliationItem, error) {
+	transactionIDs := make([]string, 0, len(entries))
+	paymentNos := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.TransactionID != "" {
+			transactionIDs = append(transactionIDs, entry.TransactionID)
+		}
+		if entry.PaymentNo != "" {
+			paymentNos = append(paymentNos, entry.PaymentNo)
+		}
+	}
+
+	referenced, err := s.repo.ListPaymentsByReference(ctx, transactionIDs, paymentNos)
+	if err != nil {
+		return nil, err
+	}
+	settled, err := s.repo.ListSettledPayments(ctx, run.Provider, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	byTransaction := make(map[string]*domain.Payment)
+	byPaymentNo := make(map[string]*domain.Payment)
+	for _, p := range append(referenced, settled...) {
+		if p.ThirdPartyTransactionID != "" {
+			byTransaction[p.ThirdPartyTransactionID] = p
+		}
+		byPaymentNo[p.PaymentNo] = p
+	}
+	for _, p := range settled {
+		run.LocalTradeAmount += p.Amount
+	}
+
+	items := make([]*domain.ReconciliationItem, 0, len(entries))
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		run.TradeAmount += entry.Amount
+		run.FeeAmount += entry.Fee
+
+		statementAmount := entry.Amount
+		item := &domain.ReconciliationItem{
+			Kind:            domain.ReconciliationKindTrade,
+			TransactionID:   entry.TransactionID,
+			MerchantNo:      entry.PaymentNo,
+			StatementAmount: &statementAmount,
+			Fee:             entry.Fee,
+			StatementStatus: entry.Status,
+		}
+		items = append(items, item)
+
+		local := byTransaction[entry.TransactionID]
+		if local == nil && entry.PaymentNo != "" {
+			local = byPaymentNo[entry.PaymentNo]
+		}
+		if local == nil {
+			item.Result = domain.ReconciliationMissingLocal
+			continue
+		}
+
+		seen[local.ID.String()] = true
+		localID, localAmount := local.ID.String(), local.Amount
+		item.LocalID = &localID
+		item.LocalAmount = &localAmount
+		item.LocalStatus = local.Status
+		item.MerchantNo = local.PaymentNo
+		item.Result = compareEntry(entry, local.Amount, local.Currency,
+			local.Status == domain.PaymentStatusSuccess || local.Status == domain.PaymentStatusRefunded)
+	}
+
+	for _, p := range settled {
+		if seen[p.ID.String()] {
+			continue
+		}
+		localID, localAmount := p.ID.String(), p.Amount
+		items = append(items, &domain.ReconciliationItem{
+			Kind:          domain.ReconciliationKindTrade,
+			Result:        domain.ReconciliationMissingRemote,
+			TransactionID: p.ThirdPartyTransactionID,
+			MerchantNo:    p.PaymentNo,
+			LocalID:       &localID,
+			LocalAmount:   &localAmount,
+			LocalStatus:   p.Status,
+		})
+	}
+
+	return items, nil
+}
+
+// reconcileRefunds matches statement refunds to payment refunds, one per
+// provider refund, by provider refund ID or our refund number, falling back
+// to an unmatched refund of the same amount on the refunded payment
+func (s *reconciliationService) reconcileRefunds(ctx context.Context, run *domain.ReconciliationRun, entries []payment.StatementEntry, from, to time.Time) ([]*domain.ReconciliationItem, error) {
+	var refundIDs, refundNos, paymentNos []string
+	for _, entry := range entries {
+		if entry.RefundID != "" {
+			refundIDs = append(refundIDs, entry.RefundID)
+		}
+		if entry.RefundNo != "" {
+			refundNos = append(refundNos, entry.RefundNo)
+		}
+		if entry.PaymentNo != "" {
+			paymentNos = append(paymentNos, entry.PaymentNo)
+		}
+	}
+
+	var referenced []*domain.PaymentRefund
+	var payments []*domain.Payment
+	if len(entries) > 0 {
+		var err error
+		if referenced, err = s.repo.ListRefundsByReference(ctx, refundIDs, refundNos, paymentNos); err != nil {
+			return nil, err
+		}
+		if payments, err = s.repo.ListPaymentsByReference(ctx, nil, paymentNos); err != nil {
+			return nil, err
+		}
+	}
+	completed, err := s.repo.ListCompletedRefunds(ctx, run.Provider, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	paymentByNo := make(map[string]string, len(payments))
+	for _, p := range payments {
+		paymentByNo[p.PaymentNo] = p.ID.String()
+	}
+	candidates := make(map[string]*domain.PaymentRefund)
+	for _, refund := range append(referenced, completed...) {
+		if refund.Provider == run.Provider {
+			candidates[refund.ID.String()] = refund
+		}
+	}
+	for _, refund := range completed {
+		run.LocalRefundAmount += refund.Amount
+	}
+
+	seen := make(map[string]bool)
+	find := func(entry payment.StatementEntry) *domain.PaymentRefund {
+		for _, refund := range candidates {
+			if seen[refund.ID.String()] {
+				continue
+			}
+			if (entry.RefundID != "" && refund.ThirdPartyID == entry.RefundID) ||
+				(entry.RefundNo != "" && refund.RefundNo == entry.RefundNo) {
+				return refund
+			}
+		}
+		paymentID := paymentByNo[entry.PaymentNo]
+		if paymentID == "" {
+			return nil
+		}
+		for _, refund := range candidates {
+			code := refund.Currency
+			if !seen[refund.ID.String()] && refund.PaymentID == paymentID && refund.ThirdPartyID == "" &&
+				currency.ToMinor(refund.Amount, code) == currency.ToMinor(entry.Amount, code) {
+				return refund
+			}
+		}
+		return nil
+	}
+
+	items := make([]*domain.ReconciliationItem, 0, len(entries))
+	for _, entry := range entries {
+		run.RefundAmount += entry.Amount
+		run.FeeAmount += entry.Fee
+
+		statementAmount := entry.Amount
+		item := &domain.ReconciliationItem{
+			Kind:            domain.ReconciliationKindRefund,
+			TransactionID:   entry.RefundID,
+			MerchantNo:      entry.RefundNo,
+			StatementAmount: &statementAmount,
+			Fee:             entry.Fee,
+			StatementStatus: entry.Status,
+		}
+		items = append(items, item)
+
+		local := find(entry)
+		if local == nil {
+			item.Result = domain.ReconciliationMissingLocal
+			continue
+		}
+
+		seen[local.ID.String()] = true
+		localID, localAmount := local.ID.String(), local.Amount
+		item.LocalID = &localID
+		item.LocalAmount = &localAmount
+		item.LocalStatus = local.Status
+		item.MerchantNo = local.RefundNo
+		item.Result = compareEntry(entry, local.Amount, local.Currency, local.Status == domain.PaymentRefundStatusSuccess)
+	}
+
+	for _, refund := range completed {
+		if seen[refund.ID.String()] {
+			continue
+		}
+		localID, localAmount := refund.ID.String(), refund.Amount
+		items = append(items, &domain.ReconciliationItem{
+			Kind:          domain.ReconciliationKindRefund,
+			Result:        domain.ReconciliationMissingRemote,
+			TransactionID: refund.ThirdPartyID,
+			MerchantNo:    refund.RefundNo,
+			LocalID:       &localID,
+			LocalAmount:   &localAmount,
+			LocalStatus:   refund.Status,
+		})
+	}
+
+	return items, nil
+}
+
+// compareEntry classifies a statement entry against its local record
+func compareEntry(entry payment.StatementEntry, localAmount float64, code string, localSettled bool) string {
+	if currency.ToMinor(entry.Amount, code) != currency.ToMinor(localAmount, code) {
+		return domain.ReconciliationAmountMismatch
+	}
+	if entry.Settled() != localSettled {
+		return domain.ReconciliationStatusMismatch
+	}
+	return domain.ReconciliationMatched
+}
+
+func (s *reconciliationService) GetRun(ctx context.Context, id string) (*domain.ReconciliationRun, error) {
+	run, err := s.repo.GetRun(ctx, id)
+	if err != nil {
+		return nil, ErrReconciliationNotFound
+	}
+	return run, nil
+}
+
+func (s *reconciliationService) ListRuns(ctx context.Context, filters repository.ReconciliationFilters) ([]*domain.ReconciliationRun, error) {
+	return s.repo.ListRuns(ctx, filters)
+}
+
+func (s *reconciliationService) ListItems(ctx context.Context, runID string, result string) ([]*domain.ReconciliationItem, error) {
+	if _, err := s.GetRun(ctx, runID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListItems(ctx, runID, result)
+}
+
+func (s *reconciliationService) Export(ctx context.Context, runID string, w io.Writer) error {
+	run, err := s.GetRun(ctx, runID)
+	if err != nil {
+		return err
+	}
+	items, err := s.repo.ListItems(ctx, runID, "")
+	if err != nil {
+		return err
+	}
+
+	// Excel needs the BOM to read UTF-8
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return err
+	}
+
+	money := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+	optional := func(v *float64) string {
+		if v == nil {
+			return ""
+		}
+		return money(*v)
+	}
+
+	writer := csv.NewWriter(w)
+	rows := [][]string{
+		{"渠道", "对账日期", "结果", "交易笔数", "交易金额", "退款笔数", "退款金额", "手续费", "净额", "本地交易金额", "本地退款金额"},
+		{run.Provider, run.StatementDate, run.Status, strconv.Itoa(run.TradeCount), money(run.TradeAmount),
+			strconv.Itoa(run.RefundCount), money(run.RefundAmount), money(run.FeeAmount), money(run.NetAmount),
+			money(run.LocalTradeAmount), money(run.LocalRefundAmount)},
+		{},
+		{"类型", "比对结果", "渠道单号", "商户单号", "对账单金额", "本地金额", "手续费", "对账单状态", "本地状态"},
+	}
+	for _, item := range items {
+		rows = append(rows, []string{
+			item.Kind, item.Result, item.TransactionID, item.MerchantNo,
+			optional(item.StatementAmount), optional(item.LocalAmount), money(item.Fee),
+			item.StatementStatus, item.LocalStatus,
+		})
+	}
+
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockReconciliationRepository mocks ReconciliationRepository
+type MockReconciliationRepository struct {
+	repository.ReconciliationRepository
+	mock.Mock
+}
+
+func (m *MockReconciliationRepository) CreateRun(ctx context.Context, run *domain.ReconciliationRun, items []*domain.ReconciliationItem) error {
+	args := m.Called(ctx, run, items)
+	return args.Error(0)
+}
+
+func (m *MockReconciliationRepository) ListSettledPayments(ctx context.Context, method string, from, to time.Time) ([]*domain.Payment, error) {
+	args := m.Called(ctx, method, from, to)
+	return args.Get(0).([]*domain.Payment), args.Error(1)
+}
+
+func (m *MockReconciliationRepository) ListPaymentsByReference(ctx context.Context, transactionIDs, paymentNos []string) ([]*domain.Payment, error) {
+	args := m.Called(ctx, transactionIDs, paymentNos)
+	return args.Get(0).([]*domain.Payment), args.Error(1)
+}
+
+func (m *MockReconciliationRepository) ListCompletedRefunds(ctx context.Context, provider string, from, to time.Time) ([]*domain.PaymentRefund, error) {
+	args := m.Called(ctx, provider, from, to)
+	return args.Get(0).([]*domain.PaymentRefund), args.Error(1)
+}
+
+func (m *MockReconciliationRepository) ListRefundsByReference(ctx context.Context, refundIDs, refundNos, paymentNos []string) ([]*domain.PaymentRefund, error) {
+	args := m.Called(ctx, refundIDs, refundNos, paymentNos)
+	return args.Get(0).([]*domain.PaymentRefund), args.Error(1)
+}
+
+func TestReconciliationImport(t *testing.T) {
+	ctx := context.Background()
+	newPayment := func(no, transactionID, status string, amount float64) *domain.Payment {
+		p := &domain.Payment{PaymentNo: no, ThirdPartyTransactionID: transactionID, Status: status, Amount: amount, Currency: "CNY", OrderID: "order-" + no}
+		p.ID = uuid.New()
+		return p
+	}
+	newRefund := func(p *domain.Payment, no, refundID string, amount float64) *domain.PaymentRefund {
+		r := &domain.PaymentRefund{
+			PaymentID:    p.ID.String(),
+			RefundNo:     no,
+			ThirdPartyID: refundID,
+			Provider:     "wechat",
+			Amount:       amount,
+			Currency:     "CNY",
+			Status:       domain.PaymentRefundStatusSuccess,
+		}
+		r.ID = uuid.New()
+		return r
+	}
+
+	matched := newPayment("PAY1", "4200000001", domain.PaymentStatusSuccess, 12000)
+	unnotified := newPayment("PAY2", "", domain.PaymentStatusPending, 8000)
+	shortPaid := newPayment("PAY3", "4200000003", domain.PaymentStatusSuccess, 5000)
+	unreported := newPayment("PAY5", "4200000005", domain.PaymentStatusSuccess, 3000)
+	// One refund request refunded across two payments is two provider refunds
+	refunded := newRefund(matched, "REF1", "5030000001", 500)
+	splitRefund := newRefund(shortPaid, "REF2", "", 300)
+	unreportedRefund := newRefund(shortPaid, "REF3", "5030000003", 200)
+
+	trades := "微信订单号,商户订单号,交易状态,应结订单金额,手续费\n" +
+		"`4200000001,`PAY1,`SUCCESS,`12000.00,`72.00\n" +
+		"`4200000002,`PAY2,`SUCCESS,`8000.00,`48.00\n" +
+		"`4200000003,`PAY3,`SUCCESS,`4800.00,`28.80\n" +
+		"`4200000004,`PAY4,`SUCCESS,`100.00,`0.60\n"
+	refunds := "refund_id,out_refund_no,out_trade_no,refund_amount,refund_status,fee\n" +
+		"5030000001,REF1,PAY1,500.00,SUCCESS,-3.00\n" +
+		"5030000002,,PAY3,300.00,SUCCESS,-1.80\n"
+
+	from := time.Date(2026, 6, 1, 0, 0, 0, 0, time.Local)
+	to := from.AddDate(0, 0, 1)
+	repo := new(MockReconciliationRepository)
+	repo.On("ListPaymentsByReference", ctx, []string{"4200000001", "4200000002", "4200000003", "4200000004"}, []string{"PAY1", "PAY2", "PAY3", "PAY4"}).
+		Return([]*domain.Payment{matched, unnotified, shortPaid}, nil)
+	repo.On("ListSettledPayments", ctx, "wechat", from, to).Return([]*domain.Payment{matched, shortPaid, unreported}, nil)
+	repo.On("ListRefundsByReference", ctx, []string{"5030000001", "5030000002"}, []string{"REF1"}, []string{"PAY1", "PAY3"}).
+		Return([]*domain.PaymentRefund{refunded, splitRefund, unreportedRefund}, nil)
+	repo.On("ListPaymentsByReference", ctx, []string(nil), []string{"PAY1", "PAY3"}).Return([]*domain.Payment{matched, shortPaid}, nil)
+	repo.On("ListCompletedRefunds", ctx, "wechat", from, to).Return([]*domain.PaymentRefund{refunded, splitRefund, unreportedRefund}, nil)
+
+	var items []*domain.ReconciliationItem
+	repo.On("CreateRun", ctx, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		items = args.Get(2).([]*domain.ReconciliationItem)
+	}).Return(nil)
+
+	svc := NewReconciliationService(repo)
+	run, err := svc.Import(ctx, "", ImportStatementRequest{
+		Provider: "wechat",
+		Date:     "2026-06-01",
+		Trades:   strings.NewReader(trades),
+		Refunds:  strings.NewReader(refunds),
+	})
+
+	require.NoError(t, err)
+	results := make(map[string]string)
+	for _, item := range items {
+		results[item.Kind+":"+item.MerchantNo] = item.Result
+	}
+	assert.Equal(t, map[string]string{
+		"trade:PAY1":  domain.ReconciliationMatched,
+		"trade:PAY2":  domain.ReconciliationStatusMismatch,
+		"trade:PAY3":  domain.ReconciliationAmountMismatch,
+		"trade:PAY4":  domain.ReconciliationMissingLocal,
+		"trade:PAY5":  domain.ReconciliationMissingRemote,
+		"refund:REF1": domain.ReconciliationMatched,
+		"refund:REF2": domain.ReconciliationMatched,
+		"refund:REF3": domain.ReconciliationMissingRemote,
+	}, results)
+
+	assert.Equal(t, domain.ReconciliationStatusUnbalanced, run.Status)
+	assert.Equal(t, 4, run.TradeCount)
+	assert.Equal(t, 2, run.RefundCount)
+	assert.Equal(t, 24900.0, run.TradeAmount)
+	assert.Equal(t, 800.0, run.RefundAmount)
+	assert.Equal(t, 144.6, run.FeeAmount)
+	assert.Equal(t, 23955.4, run.NetAmount)
+	assert.Equal(t, 20000.0, run.LocalTradeAmount)
+	assert.Equal(t, 1000.0, run.LocalRefundAmount)
+	assert.Equal(t, 3, run.MatchedCount)
+	assert.Equal(t, 5, run.MismatchCount())
+}
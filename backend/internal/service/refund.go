@@ -1,13 +1,20 @@
 package service
 
 import (
+	"backend/internal/currency"
 	"backend/internal/domain"
 	"backend/internal/pagination"
 	"backend/internal/payment"
 	"backend/internal/repository"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 var (
@@ -17,6 +24,7 @@ var (
 	ErrRefundNotReviewable  = errors.New("refund request cannot be reviewed")
 	ErrRefundNotProcessable = errors.New("refund request cannot be processed")
 	ErrRefundAmountInvalid  = errors.New("refund amount is invalid")
+	ErrNothingRefundable    = errors.New("nothing is refundable under the refund policy")
 )
 
 // RefundService defines the interface for refund workflow
@@ -41,14 +49,29 @@ type RefundService interface {
 
 	// GetRefundableAmount calculates the maximum refundable amount for an order
 	GetRefundableAmount(ctx context.Context, orderID string) (float64, error)
+
+	// PreviewRefund itemizes what a customer would get back by cancelling
+	// now under the order's refund policy
+	PreviewRefund(ctx context.Context, orderID string) (*RefundQuote, error)
+
+	// HandleRefundCallback applies a provider's refund notification
+	HandleRefundCallback(ctx context.Context, provider string, body []byte, signature string) error
+
+	// SyncProcessingRefunds queries providers for refunds whose notification
+	// is overdue and returns how many were settled
+	SyncProcessingRefunds(ctx context.Context) (int, error)
 }
 
+// refundSyncDelay gives a refund notification time to arrive before the
+// provider is queried
+const refundSyncDelay = 10 * time.Minute
+
 // CreateRefundRequest represents a request to create a refund
 type CreateRefundRequest struct {
 	OrderID            string  `json:"order_id" validate:"required"`
 	OrderItemID        *string `json:"order_item_id,omitempty"`
 	UserID             *string `json:"user_id,omitempty"`
-	RefundAmount       float64 `json:"refund_amount" validate:"required,gt=0"`
+	RefundAmount       float64 `json:"refund_amount" validate:"omitempty,gt=0"` // defaults to the refundable amount
 	RefundReason       string  `json:"refund_reason" validate:"required"`
 	RefundType         string  `json:"refund_type" validate:"omitempty,oneof=full partial"`
 	RefundMethod       string  `json:"refund_method" validate:"omitempty,oneof=original bank alipay wechat"`
@@ -63,6 +86,9 @@ type refundService struct {
 	repo           repository.OrderRepository
 	paymentService payment.PaymentService
 	orderService   OrderService
+	notifications  NotificationService
+	invoices       InvoiceService
+	now            func() time.Time
 }
 
 // NewRefundService creates a new refund service
@@ -70,11 +96,16 @@ func NewRefundService(
 	repo repository.OrderRepository,
 	paymentService payment.PaymentService,
 	orderService OrderService,
+	notifications NotificationService,
+	invoices InvoiceService,
 ) RefundService {
 	return &refundService{
 		repo:           repo,
 		paymentService: paymentService,
 		orderService:   orderService,
+		notifications:  notifications,
+		invoices:       invoices,
+		now:            time.Now,
 	}
 }
 
@@ -90,20 +121,30 @@ func (s *refundService) CreateRefundRequest(ctx context.Context, req CreateRefun
 		return nil, ErrOrderNotRefundable
 	}
 
-	// Check refund amount
-	maxRefundable, err := s.GetRefundableAmount(ctx, req.OrderID)
+	// Check refund amount against the order's refund policy; a voyage
+	// cancelled by the operator is refunded in full
+	quote, err := s.quote(ctx, req.OrderID, req.CancellationReason == domain.CancellationReasonVoyageCancelled)
 	if err != nil {
 		return nil, err
 	}
-
-	if req.RefundAmount > maxRefundable {
+	if quote.RefundableAmount <= 0 {
+		return nil, ErrNothingRefundable
+	}
+	if req.RefundAmount == 0 {
+		req.RefundAmount = quote.RefundableAmount
+	}
+	if req.RefundAmount > quote.RefundableAmount {
 		return nil, ErrRefundAmountInvalid
 	}
+	breakdown, err := json.Marshal(quote)
+	if err != nil {
+		return nil, err
+	}
 
 	// Determine refund type
 	refundType := req.RefundType
 	if refundType == "" {
-		if req.RefundAmount >= order.TotalAmount {
+		if req.RefundAmount >= quote.PaidAmount {
 			refundType = domain.RefundTypeFull
 		} else {
 			refundType = domain.RefundTypePartial
@@ -123,10 +164,17 @@ func (s *refundService) CreateRefundRequest(ctx context.Context, req CreateRefun
 		AccountHolder:      req.AccountHolder,
 		CancellationReason: req.CancellationReason,
 		Status:             domain.RefundStatusPending,
+		Breakdown:          breakdown,
 	}
 
-	if err := s.repo.CreateRefundRequest(ctx, refund); err != nil {
-		return nil, fmt.Errorf("failed to create refund request: %w", err)
+	err = s.repo.WithTransaction(ctx, func(txRepo repository.OrderRepository, _ *gorm.DB) error {
+		if err := txRepo.CreateRefundRequest(ctx, refund); err != nil {
+			return fmt.Errorf("failed to create refund request: %w", err)
+		}
+		return addRefundEvent(ctx, txRepo, "refund.requested", refund)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return refund, nil
@@ -198,50 +246,210 @@ func (s *refundService) ProcessRefund(ctx context.Context, id string) error {
 		return err
 	}
 
-	// Get payment for the order
-	payment, err := s.paymentService.GetPaymentByOrder(ctx, refund.OrderID)
-	if err != nil {
+	// Refund the order's payments, split across them when it was paid with
+	// more than one
+	paymentRefunds, err := s.paymentService.RefundOrder(ctx, refund.OrderID, refund.RefundAmount, refund.RefundReason, refund.ID.String())
+	if err != nil && len(paymentRefunds) == 0 {
 		refund.MarkFailed()
 		s.repo.UpdateRefundRequest(ctx, refund)
-		return fmt.Errorf("failed to get payment: %w", err)
+		return fmt.Errorf("failed to process refund payment: %w", err)
+	}
+	if err != nil {
+		// Refunds already started cannot be taken back; the request fails
+		// once they settle short of its amount
+		log.Printf("Refund %s was only partly started: %v", refund.ID, err)
 	}
 
-	// Process refund through payment provider
-	// DD-006: Convert UUID to string
-	if err := s.paymentService.Refund(ctx, payment.ID.String(), refund.RefundAmount, refund.RefundReason); err != nil {
-		refund.MarkFailed()
-		s.repo.UpdateRefundRequest(ctx, refund)
-		return fmt.Errorf("failed to process refund payment: %w", err)
+	// Most providers settle later; the request stays processing until the
+	// refund notification or the refund sync job reports the outcome
+	refund.PaymentRefundID, refund.ThirdPartyRefundID = joinRefundIDs(paymentRefunds)
+	return s.settle(ctx, refund, paymentRefunds)
+}
+
+func (s *refundService) HandleRefundCallback(ctx context.Context, provider string, body []byte, signature string) error {
+	paymentRefund, err := s.paymentService.ProcessRefundCallback(ctx, provider, body, signature)
+	if err != nil {
+		return err
 	}
+	return s.settleRequest(ctx, paymentRefund)
+}
 
-	// Mark as completed
-	refund.MarkCompleted("", "") // IDs will be updated by payment callback
-	if err := s.repo.UpdateRefundRequest(ctx, refund); err != nil {
+func (s *refundService) SyncProcessingRefunds(ctx context.Context) (int, error) {
+	refunds, err := s.repo.ListProcessingPaymentRefunds(ctx, time.Now().Add(-refundSyncDelay), 100)
+	if err != nil {
+		return 0, err
+	}
+
+	settled := 0
+	for _, pending := range refunds {
+		paymentRefund, err := s.paymentService.SyncRefund(ctx, pending.ID.String())
+		if err != nil {
+			log.Printf("Failed to query refund %s: %v", pending.RefundNo, err)
+			continue
+		}
+		if !paymentRefund.IsSettled() {
+			continue
+		}
+
+		settled++
+		if err := s.settleRequest(ctx, paymentRefund); err != nil {
+			log.Printf("Failed to settle refund request of %s: %v", paymentRefund.RefundNo, err)
+		}
+	}
+
+	return settled, nil
+}
+
+// settleRequest applies a provider refund's outcome to the refund request it
+// was made for
+func (s *refundService) settleRequest(ctx context.Context, paymentRefund *domain.PaymentRefund) error {
+	if paymentRefund.RefundRequestID == nil || !paymentRefund.IsSettled() {
+		return nil
+	}
+
+	refund, err := s.repo.GetRefundRequestByID(ctx, *paymentRefund.RefundRequestID)
+	if err != nil {
+		return ErrRefundNotFound
+	}
+	// Already settled by an earlier notification or query
+	if refund.Status != domain.RefundStatusProcessing {
+		return nil
+	}
+
+	// A request split across payments settles with its last provider refund
+	orderRefunds, err := s.repo.ListPaymentRefundsByOrder(ctx, refund.OrderID)
+	if err != nil {
 		return err
 	}
+	var paymentRefunds []*domain.PaymentRefund
+	for _, r := range orderRefunds {
+		if r.RefundRequestID != nil && *r.RefundRequestID == refund.ID.String() {
+			paymentRefunds = append(paymentRefunds, r)
+		}
+	}
+
+	return s.settle(ctx, refund, paymentRefunds)
+}
 
-	// Update order status to refunded
-	order, _ := s.orderService.GetByID(ctx, refund.OrderID)
-	if order != nil {
-		// DD-006: Convert UUID to string
-		s.repo.UpdateStatus(ctx, order.ID.String(), domain.OrderStatusRefunded)
+// settle records the outcome of the provider refunds of a refund request,
+// reverses any invoice it affects and tells the customer. The request is
+// completed when its provider refunds returned its whole amount and failed
+// when they settled short of it; until all have settled it stays processing
+func (s *refundService) settle(ctx context.Context, refund *domain.RefundRequest, paymentRefunds []*domain.PaymentRefund) error {
+	if allSettled(paymentRefunds) {
+		code := paymentRefunds[0].Currency
+		var returned int64
+		for _, r := range paymentRefunds {
+			if r.Status == domain.PaymentRefundStatusSuccess {
+				returned += currency.ToMinor(r.Amount, code)
+			}
+		}
+		if returned >= currency.ToMinor(refund.RefundAmount, code) {
+			refund.MarkCompleted(joinRefundIDs(paymentRefunds))
+		} else {
+			refund.MarkFailed()
+		}
 	}
 
+	err := s.repo.WithTransaction(ctx, func(txRepo repository.OrderRepository, _ *gorm.DB) error {
+		if err := txRepo.UpdateRefundRequest(ctx, refund); err != nil {
+			return err
+		}
+		switch refund.Status {
+		case domain.RefundStatusCompleted:
+			return addRefundEvent(ctx, txRepo, "refund.completed", refund)
+		case domain.RefundStatusFailed:
+			return addRefundEvent(ctx, txRepo, "refund.failed", refund)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// An invoice issued for the refunded amount is reversed
+	if s.invoices != nil && refund.Status == domain.RefundStatusCompleted {
+		if err := s.invoices.ReconcileRefund(ctx, refund.OrderID); err != nil {
+			log.Printf("Failed to reconcile invoices of order %s after refund %s: %v", refund.OrderID, refund.ID, err)
+		}
+	}
+
+	if s.notifications == nil || refund.UserID == nil {
+		return nil
+	}
+
+	switch refund.Status {
+	case domain.RefundStatusCompleted:
+		err = s.notifications.SendRefundCompletedNotification(ctx, *refund.UserID, refund)
+	case domain.RefundStatusFailed:
+		err = s.notifications.SendRefundFailedNotification(ctx, *refund.UserID, refund)
+	}
+	if err != nil {
+		log.Printf("Failed to notify refund %s: %v", refund.ID, err)
+	}
 	return nil
 }
 
+// allSettled reports whether a refund request has provider refunds and all
+// of them have settled
+func allSettled(paymentRefunds []*domain.PaymentRefund) bool {
+	for _, r := range paymentRefunds {
+		if !r.IsSettled() {
+			return false
+		}
+	}
+	return len(paymentRefunds) > 0
+}
+
+// joinRefundIDs lists the refund numbers and provider refund IDs of a refund
+// request's provider refunds
+func joinRefundIDs(paymentRefunds []*domain.PaymentRefund) (refundNos, thirdPartyIDs string) {
+	var nos, ids []string
+	for _, r := range paymentRefunds {
+		nos = append(nos, r.RefundNo)
+		if r.ThirdPartyID != "" {
+			ids = append(ids, r.ThirdPartyID)
+		}
+	}
+	return strings.Join(nos, ","), strings.Join(ids, ",")
+}
+
+// addRefundEvent records a refund event in the outbox of repo's transaction
+func addRefundEvent(ctx context.Context, repo repository.OrderRepository, eventType string, refund *domain.RefundRequest) error {
+	event, err := domain.NewOutboxEvent(domain.AggregateOrder, refund.OrderID, eventType, map[string]interface{}{
+		"type":          eventType,
+		"refund_id":     refund.ID,
+		"order_id":      refund.OrderID,
+		"refund_amount": refund.RefundAmount,
+		"status":        refund.Status,
+		"timestamp":     time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+	return repo.AddOutboxEvent(ctx, event)
+}
+
 func (s *refundService) GetRefundableAmount(ctx context.Context, orderID string) (float64, error) {
-	order, err := s.orderService.GetByID(ctx, orderID)
+	quote, err := s.quote(ctx, orderID, false)
 	if err != nil {
 		return 0, err
 	}
+	return quote.RefundableAmount, nil
+}
 
-	// Check if order is paid
-	if !order.IsPaid() {
-		return 0, nil
+func (s *refundService) PreviewRefund(ctx context.Context, orderID string) (*RefundQuote, error) {
+	return s.quote(ctx, orderID, false)
+}
+
+// quote prices a refund of the order as of now, net of refunds already
+// completed or under way
+func (s *refundService) quote(ctx context.Context, orderID string, fullRefund bool) (*RefundQuote, error) {
+	order, err := s.orderService.GetWithDetails(ctx, orderID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Get existing refund requests
 	filters := repository.RefundFilters{
 		OrderID: orderID,
 	}
@@ -249,22 +457,18 @@ func (s *refundService) GetRefundableAmount(ctx context.Context, orderID string)
 
 	refunds, err := s.repo.ListRefundRequests(ctx, filters, paginator)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	// Calculate already refunded amount
+	// Requests still awaiting review count too, so they cannot be stacked
 	var alreadyRefunded float64
 	for _, refund := range refunds {
-		if refund.Status == domain.RefundStatusCompleted || refund.Status == domain.RefundStatusProcessing {
+		switch refund.Status {
+		case domain.RefundStatusPending, domain.RefundStatusApproved,
+			domain.RefundStatusProcessing, domain.RefundStatusCompleted:
 			alreadyRefunded += refund.RefundAmount
 		}
 	}
 
-	// Maximum refundable is total amount minus already refunded
-	maxRefundable := order.TotalAmount - alreadyRefunded
-	if maxRefundable < 0 {
-		maxRefundable = 0
-	}
-
-	return maxRefundable, nil
+	return quoteRefund(order, s.now(), alreadyRefunded, fullRefund), nil
 }
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRefundPolicyNotFound   = errors.New("refund policy not found")
+	ErrInvalidRefundPolicy    = errors.New("invalid refund policy")
+	ErrRefundPolicySuperseded = errors.New("refund policy has a newer version")
+)
+
+// RefundPolicyService manages the refund policies orders are booked under
+type RefundPolicyService interface {
+	CreatePolicy(ctx context.Context, actorID string, req RefundPolicyRequest) (*domain.RefundPolicy, error)
+	GetPolicy(ctx context.Context, id string) (*domain.RefundPolicy, error)
+	ListPolicies(ctx context.Context, filters repository.RefundPolicyFilters) ([]*domain.RefundPolicy, error)
+
+	// UpdatePolicy publishes a new version of a policy; orders already booked
+	// keep the version they were booked under
+	UpdatePolicy(ctx context.Context, actorID, id string, req RefundPolicyRequest) (*domain.RefundPolicy, error)
+
+	// SetActive enables or disables a policy for new bookings
+	SetActive(ctx context.Context, id string, active bool) (*domain.RefundPolicy, error)
+
+	// Resolve picks the policy a new booking falls under, or nil when no
+	// active policy applies
+	Resolve(ctx context.Context, routeID, cruiseID, priceType string) (*domain.RefundPolicy, error)
+}
+
+// RefundPolicyRequest represents a request to create or update a refund policy
+type RefundPolicyRequest struct {
+	Code                 string              `json:"code" validate:"omitempty,max=64"` // required on create, ignored on update
+	Name                 string              `json:"name" validate:"required,max=100"`
+	Description          string              `json:"description"`
+	RouteID              *string             `json:"route_id,omitempty" validate:"omitempty,uuid"`
+	CruiseID             *string             `json:"cruise_id,omitempty" validate:"omitempty,uuid"`
+	PriceType            *string             `json:"price_type,omitempty" validate:"omitempty,oneof=standard early_bird last_minute group"`
+	Priority             int                 `json:"priority"`
+	Tiers                []domain.RefundTier `json:"tiers" validate:"required,min=1"`
+	PortFeeRefundable    bool                `json:"port_fee_refundable"`
+	ServiceFeeRefundable bool                `json:"service_fee_refundable"`
+}
+
+// refundPolicyService implements RefundPolicyService
+type refundPolicyService struct {
+	repo repository.RefundPolicyRepository
+}
+
+// NewRefundPolicyService creates a new refund policy service
+func NewRefundPolicyService(repo repository.RefundPolicyRepository) RefundPolicyService {
+	return &refundPolicyService{repo: repo}
+}
+
+func (s *refundPolicyService) CreatePolicy(ctx context.Context, actorID string, req RefundPolicyRequest) (*domain.RefundPolicy, error) {
+	if req.Code == "" {
+		return nil, fmt.Errorf("%w: code is required", ErrInvalidRefundPolicy)
+	}
+	if err := validateRefundTiers(req.Tiers); err != nil {
+		return nil, err
+	}
+	existing, err := s.repo.List(ctx, repository.RefundPolicyFilters{Code: req.Code, AllVersions: true})
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("%w: code %s already exists", ErrInvalidRefundPolicy, req.Code)
+	}
+
+	policy := &domain.RefundPolicy{Code: req.Code, Version: 1, IsActive: true}
+	applyRefundPolicyRequest(policy, req)
+	if actorID != "" {
+		policy.CreatedBy = &actorID
+	}
+
+	if err := s.repo.Create(ctx, policy); err != nil {
+		return nil, fmt.Errorf("failed to create refund policy: %w", err)
+	}
+	return policy, nil
+}
+
+func (s *refundPolicyService) GetPolicy(ctx context.Context, id string) (*domain.RefundPolicy, error) {
+	policy, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRefundPolicyNotFound
+		}
+		return nil, err
+	}
+	return policy, nil
+}
+
+func (s *refundPolicyService) ListPolicies(ctx context.Context, filters repository.RefundPolicyFilters) ([]*domain.RefundPolicy, error) {
+	return s.repo.List(ctx, filters)
+}
+
+func (s *refundPolicyService) UpdatePolicy(ctx context.Context, actorID, id string, req RefundPolicyRequest) (*domain.RefundPolicy, error) {
+	current, err := s.GetPolicy(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !current.IsCurrent() {
+		return nil, ErrRefundPolicySuperseded
+	}
+	if err := validateRefundTiers(req.Tiers); err != nil {
+		return nil, err
+	}
+
+	next := &domain.RefundPolicy{Code: current.Code, Version: current.Version + 1, IsActive: current.IsActive}
+	applyRefundPolicyRequest(next, req)
+	if actorID != "" {
+		next.CreatedBy = &actorID
+	}
+
+	if err := s.repo.CreateVersion(ctx, current, next); err != nil {
+		return nil, fmt.Errorf("failed to publish refund policy version: %w", err)
+	}
+	return next, nil
+}
+
+func (s *refundPolicyService) SetActive(ctx context.Context, id string, active bool) (*domain.RefundPolicy, error) {
+	policy, err := s.GetPolicy(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !policy.IsCurrent() {
+		return nil, ErrRefundPolicySuperseded
+	}
+
+	policy.IsActive = active
+	if err := s.repo.Update(ctx, policy); err != nil {
+		return nil, fmt.Errorf("failed to update refund policy: %w", err)
+	}
+	return policy, nil
+}
+
+func (s *refundPolicyService) Resolve(ctx context.Context, routeID, cruiseID, priceType string) (*domain.RefundPolicy, error) {
+	policies, err := s.repo.ListActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []*domain.RefundPolicy
+	for _, p := range policies {
+		if p.AppliesTo(routeID, cruiseID, priceType) {
+			matched = append(matched, p)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, nil
+	}
+
+	// Lowest priority value first; a narrower scope breaks ties
+	sort.SliceStable(matched, func(i, j int) bool {
+		if matched[i].Priority != matched[j].Priority {
+			return matched[i].Priority < matched[j].Priority
+		}
+		return matched[i].Specificity() > matched[j].Specificity()
+	})
+	return matched[0], nil
+}
+
+func applyRefundPolicyRequest(policy *domain.RefundPolicy, req RefundPolicyRequest) {
+	policy.Name = req.Name
+	policy.Description = req.Description
+	policy.RouteID = req.RouteID
+	policy.CruiseID = req.CruiseID
+	policy.PriceType = req.PriceType
+	policy.Priority = req.Priority
+	policy.PortFeeRefundable = req.PortFeeRefundable
+	policy.ServiceFeeRefundable = req.ServiceFeeRefundable
+	policy.SetTiers(req.Tiers)
+}
+
+func validateRefundTiers(tiers []domain.RefundTier) error {
+	if len(tiers) == 0 {
+		return fmt.Errorf("%w: at least one tier is required", ErrInvalidRefundPolicy)
+	}
+	seen := make(map[int]bool, len(tiers))
+	for _, t := range tiers {
+		if t.MinDays < 0 {
+			return fmt.Errorf("%w: min_days must not be negative", ErrInvalidRefundPolicy)
+		}
+		if t.Percent < 0 || t.Percent > 100 {
+			return fmt.Errorf("%w: percent must be between 0 and 100", ErrInvalidRefundPolicy)
+		}
+		if seen[t.MinDays] {
+			return fmt.Errorf("%w: duplicate tier for %d days", ErrInvalidRefundPolicy, t.MinDays)
+		}
+		seen[t.MinDays] = true
+	}
+	return nil
+}
+
+// RefundQuote itemizes what an order would get back if refunded now. All
+// amounts are in the order currency.
+type RefundQuote struct {
+	OrderID         string                       `json:"order_id"`
+	Currency        string                       `json:"currency"`
+	Policy          *domain.RefundPolicySnapshot `json:"policy,omitempty"` // nil for orders booked before refund policies
+	DepartureDate   string                       `json:"departure_date"`
+	DaysToDeparture int                          `json:"days_to_departure"`
+	Percent         float64                      `json:"percent"` // share of the fare refunded
+
+	PaidAmount float64 `json:"paid_amount"`
+	FareAmount float64 `json:"fare_amount"` // paid amount less port and service fees
+	PortFee    float64 `json:"port_fee"`
+	ServiceFee float64 `json:"service_fee"`
+
+	RefundableFare       float64 `json:"refundable_fare"`
+	RefundablePortFee    float64 `json:"refundable_port_fee"`
+	RefundableServiceFee float64 `json:"refundable_service_fee"`
+	Deduction            float64 `json:"deduction"`        // kept under the policy
+	AlreadyRefunded      float64 `json:"already_refunded"` // refunded or awaiting refund
+	RefundableAmount     float64 `json:"refundable_amount"`
+}
+
+// quoteRefund applies the refund policy snapshot of an order (loaded with its
+// voyage and items) as of now. Orders without a snapshot, and full refunds
+// such as a cancelled voyage, get everything back.
+func quoteRefund(order *domain.Order, now time.Time, alreadyRefunded float64, fullRefund bool) *RefundQuote {
+	quote := &RefundQuote{
+		OrderID:         order.ID.String(),
+		Currency:        order.Currency,
+		DepartureDate:   dateOnly(order.Voyage.DepartureDate),
+		DaysToDeparture: daysUntil(order.Voyage.DepartureDate, now),
+		AlreadyRefunded: roundMoney(alreadyRefunded),
+	}
+	if !order.IsPaid() {
+		return quote
+	}
+
+	rate := order.ExchangeRate
+	if rate <= 0 {
+		rate = 1
+	}
+	var portFee, serviceFee float64
+	for _, item := range order.Items {
+		portFee += item.PortFee
+		serviceFee += item.ServiceFee
+	}
+
+	// Items are priced in the base currency; discounts only reduce the fare
+	quote.PaidAmount = roundMoney(order.TotalAmount - order.DiscountAmount)
+	quote.PortFee = roundMoney(portFee * rate)
+	quote.ServiceFee = roundMoney(serviceFee * rate)
+	quote.FareAmount = roundMoney(max(quote.PaidAmount-quote.PortFee-quote.ServiceFee, 0))
+
+	policy, ok := order.GetRefundPolicy()
+	switch {
+	case fullRefund || !ok:
+		quote.Percent = 100
+		quote.RefundablePortFee = quote.PortFee
+		quote.RefundableServiceFee = quote.ServiceFee
+	default:
+		quote.Policy = &policy
+		if tier, ok := policy.TierAt(quote.DaysToDeparture); ok {
+			quote.Percent = tier.Percent
+		}
+		if policy.PortFeeRefundable {
+			quote.RefundablePortFee = quote.PortFee
+		}
+		if policy.ServiceFeeRefundable {
+			quote.RefundableServiceFee = quote.ServiceFee
+		}
+	}
+	quote.RefundableFare = roundMoney(quote.FareAmount * quote.Percent / 100)
+
+	refundable := quote.RefundableFare + quote.RefundablePortFee + quote.RefundableServiceFee
+	quote.Deduction = roundMoney(quote.PaidAmount - refundable)
+	quote.RefundableAmount = roundMoney(max(refundable-quote.AlreadyRefunded, 0))
+	return quote
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockRefundPolicyRepository mocks RefundPolicyRepository
+type MockRefundPolicyRepository struct {
+	repository.RefundPolicyRepository
+	mock.Mock
+}
+
+func (m *MockRefundPolicyRepository) ListActive(ctx context.Context) ([]*domain.RefundPolicy, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*domain.RefundPolicy), args.Error(1)
+}
+
+func newRefundPolicy(priority int, routeID, priceType *string) *domain.RefundPolicy {
+	p := &domain.RefundPolicy{Code: "p", Version: 1, Name: "policy", Priority: priority, RouteID: routeID, PriceType: priceType, IsActive: true}
+	p.ID = uuid.New()
+	p.SetTiers([]domain.RefundTier{{MinDays: 0, Percent: 0}, {MinDays: 31, Percent: 100}, {MinDays: 7, Percent: 50}, {MinDays: 15, Percent: 80}})
+	return p
+}
+
+func TestRefundPolicyService_Resolve(t *testing.T) {
+	ctx := context.Background()
+	route, other, earlyBird := "route-1", "route-2", domain.PriceTypeEarlyBird
+
+	global := newRefundPolicy(100, nil, nil)
+	routeWide := newRefundPolicy(100, &route, nil)
+	earlyBirdOnly := newRefundPolicy(100, &route, &earlyBird)
+	otherRoute := newRefundPolicy(10, &other, nil)
+
+	repo := new(MockRefundPolicyRepository)
+	repo.On("ListActive", ctx).Return([]*domain.RefundPolicy{otherRoute, global, routeWide, earlyBirdOnly}, nil)
+	svc := NewRefundPolicyService(repo)
+
+	policy, err := svc.Resolve(ctx, route, "cruise-1", domain.PriceTypeEarlyBird)
+	require.NoError(t, err)
+	assert.Same(t, earlyBirdOnly, policy)
+
+	policy, err = svc.Resolve(ctx, route, "cruise-1", domain.PriceTypeStandard)
+	require.NoError(t, err)
+	assert.Same(t, routeWide, policy)
+
+	policy, err = svc.Resolve(ctx, "route-3", "cruise-1", "")
+	require.NoError(t, err)
+	assert.Same(t, global, policy)
+}
+
+func TestQuoteRefund(t *testing.T) {
+	now := time.Date(2026, 6, 1, 15, 0, 0, 0, time.UTC)
+	newOrder := func(departure string, withPolicy bool) *domain.Order {
+		order := &domain.Order{
+			TotalAmount:    10000,
+			DiscountAmount: 400,
+			Currency:       "CNY",
+			ExchangeRate:   1,
+			PaymentStatus:  domain.PaymentStatusPaid,
+			Voyage:         domain.Voyage{DepartureDate: departure},
+			Items: []domain.OrderItem{
+				{PortFee: 400, ServiceFee: 200},
+				{PortFee: 400, ServiceFee: 0},
+			},
+		}
+		order.ID = uuid.New()
+		if withPolicy {
+			order.SetRefundPolicy(newRefundPolicy(100, nil, nil))
+		}
+		return order
+	}
+
+	tests := []struct {
+		name       string
+		departure  string
+		withPolicy bool
+		full       bool
+		refunded   float64
+		percent    float64
+		refundable float64
+	}{
+		{name: "more than 30 days", departure: "2026-07-15", withPolicy: true, percent: 100, refundable: 8600},
+		{name: "exactly 30 days", departure: "2026-07-01", withPolicy: true, percent: 80, refundable: 6880},
+		{name: "15 days", departure: "2026-06-16", withPolicy: true, percent: 80, refundable: 6880},
+		{name: "10 days", departure: "2026-06-11", withPolicy: true, percent: 50, refundable: 4300},
+		{name: "day before sailing", departure: "2026-06-02", withPolicy: true, percent: 0, refundable: 0},
+		{name: "net of earlier refunds", departure: "2026-06-11", withPolicy: true, refunded: 1000, percent: 50, refundable: 3300},
+		{name: "voyage cancelled", departure: "2026-06-02", withPolicy: true, full: true, percent: 100, refundable: 9600},
+		{name: "booked without a policy", departure: "2026-06-02", percent: 100, refundable: 9600},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote := quoteRefund(newOrder(tt.departure, tt.withPolicy), now, tt.refunded, tt.full)
+
+			assert.Equal(t, 9600.0, quote.PaidAmount)
+			assert.Equal(t, 800.0, quote.PortFee)
+			assert.Equal(t, 200.0, quote.ServiceFee)
+			assert.Equal(t, 8600.0, quote.FareAmount)
+			assert.Equal(t, tt.percent, quote.Percent)
+			assert.Equal(t, tt.refundable, quote.RefundableAmount)
+		})
+	}
+
+	unpaid := newOrder("2026-07-15", true)
+	unpaid.PaymentStatus = domain.PaymentStatusUnpaid
+	assert.Zero(t, quoteRefund(unpaid, now, 0, false).RefundableAmount)
+}
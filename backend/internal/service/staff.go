@@ -0,0 +1,393 @@
+package service
+
+import (
+	"backend/internal/auth"
+	"backend/internal/domain"
+	"backend/internal/pagination"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrStaffNotFound         = errors.New("staff not found")
+	ErrStaffUsernameTaken    = errors.New("staff username is taken")
+	ErrStaffDisabled         = errors.New("staff account is disabled")
+	ErrStaffSelfDelete       = errors.New("staff cannot delete their own account")
+	ErrStaffSelfDisable      = errors.New("staff cannot disable their own account")
+	ErrStaffLastSuperAdmin   = errors.New("the last active super admin cannot be removed")
+	ErrRoleNotGrantable      = errors.New("role grants permissions the operator does not have")
+	ErrInvalidCredentials    = errors.New("invalid username or password")
+	ErrStaffPasswordMismatch = errors.New("old password is incorrect")
+)
+
+// StaffService defines the interface for staff accounts and their roles
+type StaffService interface {
+	// Authenticate checks a staff login and records it. The staff's roles
+	// are filled in
+	Authenticate(ctx context.Context, username, password, ip string) (*domain.Staff, error)
+
+	GetStaff(ctx context.Context, id string) (*domain.Staff, error)
+	// IsActive checks that a staff account exists and is not disabled.
+	// Disabled staff keep their roles so re-enabling them restores access
+	IsActive(ctx context.Context, id string) (bool, error)
+	ListStaff(ctx context.Context, filters repository.StaffFilters, paginator *pagination.Paginator) ([]*domain.Staff, error)
+	// CreateStaff creates a staff account. The operator must hold every
+	// permission the requested roles grant
+	CreateStaff(ctx context.Context, req *CreateStaffRequest, operatorID string) (*domain.Staff, error)
+	// UpdateStaff updates a staff account. The operator must hold every
+	// permission of the roles the account has and is given, and the last
+	// active super admin cannot be disabled or demoted
+	UpdateStaff(ctx context.Context, id string, req *UpdateStaffRequest, operatorID string) (*domain.Staff, error)
+	ResetPassword(ctx context.Context, id, password string) error
+	ChangePassword(ctx context.Context, id, oldPassword, newPassword string) error
+	// DeleteStaff removes a staff account and its role assignments, under
+	// the same rules as UpdateStaff
+	DeleteStaff(ctx context.Context, id, operatorID string) error
+
+	// EnsureAdmin creates a super admin account while there are no staff
+	// accounts yet, so a new installation can be signed in to
+	EnsureAdmin(ctx context.Context, username, password string) error
+}
+
+// CreateStaffRequest represents a new staff account
+type CreateStaffRequest struct {
+	Username string   `json:"username" binding:"required,min=3,max=50"`
+	Password string   `json:"password" binding:"required,min=8"`
+	Name     string   `json:"name" binding:"required,max=100"`
+	Email    string   `json:"email" binding:"omitempty,email"`
+	Phone    string   `json:"phone" binding:"omitempty,max=20"`
+	Roles    []string `json:"roles" binding:"required,min=1"`
+}
+
+// UpdateStaffRequest represents changes to a staff account; empty fields
+// are left as they are
+type UpdateStaffRequest struct {
+	Name   string   `json:"name" binding:"omitempty,max=100"`
+	Email  string   `json:"email" binding:"omitempty,email"`
+	Phone  string   `json:"phone" binding:"omitempty,max=20"`
+	Status string   `json:"status" binding:"omitempty,oneof=active disabled"`
+	Roles  []string `json:"roles"` // replaces the roles when given
+}
+
+// staffService implements StaffService
+type staffService struct {
+	repo repository.StaffRepository
+	rbac *auth.RBAC
+	now  func() time.Time
+}
+
+// NewStaffService creates a new staff service
+func NewStaffService(repo repository.StaffRepository, rbac *auth.RBAC) StaffService {
+	return &staffService{repo: repo, rbac: rbac, now: time.Now}
+}
+
+func (s *staffService) Authenticate(ctx context.Context, username, password, ip string) (*domain.Staff, error) {
+	staff, err := s.repo.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if err := auth.CheckPassword(password, staff.PasswordHash); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if !staff.CanLogin() {
+		return nil, ErrStaffDisabled
+	}
+
+	if err := s.withRoles(staff); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	staff.LastLoginAt = &now
+	staff.LastLoginIP = ip
+	if err := s.repo.Update(ctx, staff); err != nil {
+		log.Printf("Failed to record login of staff %s: %v", staff.ID, err)
+	}
+	return staff, nil
+}
+
+func (s *staffService) GetStaff(ctx context.Context, id string) (*domain.Staff, error) {
+	staff, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrStaffNotFound
+	}
+	if err := s.withRoles(staff); err != nil {
+		return nil, err
+	}
+	return staff, nil
+}
+
+func (s *staffService) IsActive(ctx context.Context, id string) (bool, error) {
+	staff, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return staff.CanLogin(), nil
+}
+
+func (s *staffService) ListStaff(ctx context.Context, filters repository.StaffFilters, paginator *pagination.Paginator) ([]*domain.Staff, error) {
+	staff, err := s.repo.List(ctx, filters, paginator)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range staff {
+		if err := s.withRoles(member); err != nil {
+			return nil, err
+		}
+	}
+	return staff, nil
+}
+
+func (s *staffService) CreateStaff(ctx context.Context, req *CreateStaffRequest, operatorID string) (*domain.Staff, error) {
+	if _, err := s.repo.GetByUsername(ctx, req.Username); err == nil {
+		return nil, ErrStaffUsernameTaken
+	}
+	if err := s.checkRoles(ctx, req.Roles); err != nil {
+		return nil, err
+	}
+	if err := s.checkGrant(operatorID, req.Roles); err != nil {
+		return nil, err
+	}
+	return s.createStaff(ctx, req)
+}
+
+// createStaff creates a staff account with roles that have been checked
+func (s *staffService) createStaff(ctx context.Context, req *CreateStaffRequest) (*domain.Staff, error) {
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	staff := &domain.Staff{
+		Username:     req.Username,
+		PasswordHash: hash,
+		Name:         req.Name,
+		Email:        req.Email,
+		Phone:        req.Phone,
+		Status:       domain.StaffStatusActive,
+	}
+	if err := s.repo.Create(ctx, staff); err != nil {
+		return nil, fmt.Errorf("failed to create staff: %w", err)
+	}
+	if err := s.rbac.SetRolesForUser(staff.ID.String(), req.Roles); err != nil {
+		return nil, fmt.Errorf("failed to assign roles: %w", err)
+	}
+
+	staff.Roles = req.Roles
+	return staff, nil
+}
+
+func (s *staffService) UpdateStaff(ctx context.Context, id string, req *UpdateStaffRequest, operatorID string) (*domain.Staff, error) {
+	if id == operatorID && req.Status == domain.StaffStatusDisabled {
+		return nil, ErrStaffSelfDisable
+	}
+	staff, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrStaffNotFound
+	}
+	current, err := s.rbac.GetRolesForUser(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkGrant(operatorID, current); err != nil {
+		return nil, err
+	}
+	if req.Roles != nil {
+		if err := s.checkRoles(ctx, req.Roles); err != nil {
+			return nil, err
+		}
+		if err := s.checkGrant(operatorID, req.Roles); err != nil {
+			return nil, err
+		}
+	}
+
+	disabling := req.Status == domain.StaffStatusDisabled
+	demoting := req.Roles != nil && !slices.Contains(req.Roles, auth.RoleSuperAdmin)
+	if disabling || demoting {
+		if err := s.keepSuperAdmin(ctx, staff, current); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Name != "" {
+		staff.Name = req.Name
+	}
+	if req.Email != "" {
+		staff.Email = req.Email
+	}
+	if req.Phone != "" {
+		staff.Phone = req.Phone
+	}
+	if req.Status != "" {
+		staff.Status = req.Status
+	}
+	if err := s.repo.Update(ctx, staff); err != nil {
+		return nil, fmt.Errorf("failed to update staff: %w", err)
+	}
+
+	if req.Roles != nil {
+		if err := s.rbac.SetRolesForUser(id, req.Roles); err != nil {
+			return nil, fmt.Errorf("failed to assign roles: %w", err)
+		}
+	}
+	if err := s.withRoles(staff); err != nil {
+		return nil, err
+	}
+	return staff, nil
+}
+
+func (s *staffService) ResetPassword(ctx context.Context, id, password string) error {
+	staff, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return ErrStaffNotFound
+	}
+	return s.setPassword(ctx, staff, password)
+}
+
+func (s *staffService) ChangePassword(ctx context.Context, id, oldPassword, newPassword string) error {
+	staff, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return ErrStaffNotFound
+	}
+	if err := auth.CheckPassword(oldPassword, staff.PasswordHash); err != nil {
+		return ErrStaffPasswordMismatch
+	}
+	return s.setPassword(ctx, staff, newPassword)
+}
+
+func (s *staffService) DeleteStaff(ctx context.Context, id, operatorID string) error {
+	if id == operatorID {
+		return ErrStaffSelfDelete
+	}
+	staff, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return ErrStaffNotFound
+	}
+	current, err := s.rbac.GetRolesForUser(id)
+	if err != nil {
+		return err
+	}
+	if err := s.checkGrant(operatorID, current); err != nil {
+		return err
+	}
+	if err := s.keepSuperAdmin(ctx, staff, current); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete staff: %w", err)
+	}
+	if err := s.rbac.SetRolesForUser(id, nil); err != nil {
+		return fmt.Errorf("failed to remove roles: %w", err)
+	}
+	return nil
+}
+
+func (s *staffService) EnsureAdmin(ctx context.Context, username, password string) error {
+	count, err := s.repo.Count(ctx)
+	if err != nil || count > 0 {
+		return err
+	}
+
+	_, err = s.createStaff(ctx, &CreateStaffRequest{
+		Username: username,
+		Password: password,
+		Name:     username,
+		Roles:    []string{auth.RoleSuperAdmin},
+	})
+	return err
+}
+
+func (s *staffService) setPassword(ctx context.Context, staff *domain.Staff, password string) error {
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	staff.PasswordHash = hash
+	if err := s.repo.Update(ctx, staff); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	return nil
+}
+
+// checkRoles ensures every role exists
+func (s *staffService) checkRoles(ctx context.Context, roles []string) error {
+	for _, role := range roles {
+		if _, err := s.repo.GetRole(ctx, role); err != nil {
+			return fmt.Errorf("%w: %s", ErrRoleNotFound, role)
+		}
+	}
+	return nil
+}
+
+// checkGrant ensures the operator holds every permission the roles grant,
+// so staff cannot hand out more access than they have. Only a super admin
+// holds the wildcard permission of the super admin role
+func (s *staffService) checkGrant(operatorID string, roles []string) error {
+	for _, role := range roles {
+		permissions, err := s.rbac.GetPermissionsForRole(role)
+		if err != nil {
+			return err
+		}
+		for _, permission := range permissions {
+			allowed, err := s.rbac.CheckPermission(operatorID, permission.Resource, permission.Action)
+			if err != nil {
+				return err
+			}
+			if !allowed {
+				return fmt.Errorf("%w: %s", ErrRoleNotGrantable, role)
+			}
+		}
+	}
+	return nil
+}
+
+// keepSuperAdmin refuses to take away staff's super admin access when no
+// other active super admin is left to sign in
+func (s *staffService) keepSuperAdmin(ctx context.Context, staff *domain.Staff, roles []string) error {
+	if !staff.CanLogin() || !slices.Contains(roles, auth.RoleSuperAdmin) {
+		return nil
+	}
+
+	admins, err := s.rbac.GetUsersForRole(auth.RoleSuperAdmin)
+	if err != nil {
+		return err
+	}
+	for _, id := range admins {
+		if id == staff.ID.String() {
+			continue
+		}
+		admin, err := s.repo.GetByID(ctx, id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if admin.CanLogin() {
+			return nil
+		}
+	}
+	return ErrStaffLastSuperAdmin
+}
+
+// withRoles fills in the roles assigned to staff
+func (s *staffService) withRoles(staff *domain.Staff) error {
+	roles, err := s.rbac.GetRolesForUser(staff.ID.String())
+	if err != nil {
+		return err
+	}
+	staff.Roles = roles
+	return nil
+}
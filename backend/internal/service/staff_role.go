@@ -0,0 +1,194 @@
+package service
+
+import (
+	"backend/internal/auth"
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+var (
+	ErrRoleNotFound      = errors.New("role not found")
+	ErrRoleExists        = errors.New("role already exists")
+	ErrRoleBuiltin       = errors.New("built-in roles cannot be deleted")
+	ErrRoleInUse         = errors.New("role is assigned to staff")
+	ErrRoleImmutable     = errors.New("super admin permissions cannot be changed")
+	ErrInvalidRoleName   = errors.New("invalid role name")
+	ErrInvalidPermission = errors.New("invalid permission")
+)
+
+// roleNamePattern matches role names, which are RBAC policy subjects
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+// RoleService defines the interface for managing staff roles and the
+// permissions they grant. Changes take effect at once on every instance
+type RoleService interface {
+	ListRoles(ctx context.Context) ([]*RoleDetail, error)
+	GetRole(ctx context.Context, name string) (*RoleDetail, error)
+	CreateRole(ctx context.Context, req *RoleRequest) (*RoleDetail, error)
+	// UpdateRole updates a role's description and replaces its permissions
+	UpdateRole(ctx context.Context, name string, req *RoleRequest) (*RoleDetail, error)
+	// DeleteRole deletes a custom role that is not assigned to anyone
+	DeleteRole(ctx context.Context, name string) error
+
+	// ListPermissions lists what roles can be granted
+	ListPermissions() *PermissionCatalog
+}
+
+// RoleDetail is a role with its permissions
+type RoleDetail struct {
+	*domain.StaffRole
+	Permissions []auth.Permission `json:"permissions"`
+	StaffIDs    []string          `json:"staff_ids"` // staff the role is assigned to
+}
+
+// RoleRequest represents a role to create or update
+type RoleRequest struct {
+	Name        string            `json:"name"` // on create only
+	DisplayName string            `json:"display_name" binding:"required,max=100"`
+	Description string            `json:"description"`
+	Permissions []auth.Permission `json:"permissions"`
+}
+
+// PermissionCatalog lists the resources and actions permissions combine
+type PermissionCatalog struct {
+	Resources []string `json:"resources"`
+	Actions   []string `json:"actions"`
+}
+
+// roleService implements RoleService
+type roleService struct {
+	repo repository.StaffRepository
+	rbac *auth.RBAC
+}
+
+// NewRoleService creates a new role service
+func NewRoleService(repo repository.StaffRepository, rbac *auth.RBAC) RoleService {
+	return &roleService{repo: repo, rbac: rbac}
+}
+
+func (s *roleService) ListRoles(ctx context.Context) ([]*RoleDetail, error) {
+	roles, err := s.repo.ListRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	details := make([]*RoleDetail, 0, len(roles))
+	for _, role := range roles {
+		detail, err := s.detail(role)
+		if err != nil {
+			return nil, err
+		}
+		details = append(details, detail)
+	}
+	return details, nil
+}
+
+func (s *roleService) GetRole(ctx context.Context, name string) (*RoleDetail, error) {
+	role, err := s.repo.GetRole(ctx, name)
+	if err != nil {
+		return nil, ErrRoleNotFound
+	}
+	return s.detail(role)
+}
+
+func (s *roleService) CreateRole(ctx context.Context, req *RoleRequest) (*RoleDetail, error) {
+	if !roleNamePattern.MatchString(req.Name) {
+		return nil, ErrInvalidRoleName
+	}
+	if _, err := s.repo.GetRole(ctx, req.Name); err == nil {
+		return nil, ErrRoleExists
+	}
+	if err := checkPermissions(req.Permissions); err != nil {
+		return nil, err
+	}
+
+	role := &domain.StaffRole{
+		Name:        req.Name,
+		DisplayName: req.DisplayName,
+		Description: req.Description,
+	}
+	if err := s.repo.CreateRole(ctx, role); err != nil {
+		return nil, fmt.Errorf("failed to create role: %w", err)
+	}
+	if err := s.rbac.SetPermissionsForRole(role.Name, req.Permissions); err != nil {
+		return nil, fmt.Errorf("failed to grant permissions: %w", err)
+	}
+	return s.detail(role)
+}
+
+func (s *roleService) UpdateRole(ctx context.Context, name string, req *RoleRequest) (*RoleDetail, error) {
+	role, err := s.repo.GetRole(ctx, name)
+	if err != nil {
+		return nil, ErrRoleNotFound
+	}
+	if name == auth.RoleSuperAdmin && req.Permissions != nil {
+		return nil, ErrRoleImmutable
+	}
+	if err := checkPermissions(req.Permissions); err != nil {
+		return nil, err
+	}
+
+	role.DisplayName = req.DisplayName
+	role.Description = req.Description
+	if err := s.repo.UpdateRole(ctx, role); err != nil {
+		return nil, fmt.Errorf("failed to update role: %w", err)
+	}
+	if req.Permissions != nil {
+		if err := s.rbac.SetPermissionsForRole(name, req.Permissions); err != nil {
+			return nil, fmt.Errorf("failed to grant permissions: %w", err)
+		}
+	}
+	return s.detail(role)
+}
+
+func (s *roleService) DeleteRole(ctx context.Context, name string) error {
+	role, err := s.repo.GetRole(ctx, name)
+	if err != nil {
+		return ErrRoleNotFound
+	}
+	if role.Builtin {
+		return ErrRoleBuiltin
+	}
+	staff, err := s.rbac.GetUsersForRole(name)
+	if err != nil {
+		return err
+	}
+	if len(staff) > 0 {
+		return ErrRoleInUse
+	}
+
+	if err := s.repo.DeleteRole(ctx, name); err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+	return s.rbac.DeleteRole(name)
+}
+
+func (s *roleService) ListPermissions() *PermissionCatalog {
+	return &PermissionCatalog{Resources: auth.Resources, Actions: auth.Permissions}
+}
+
+func (s *roleService) detail(role *domain.StaffRole) (*RoleDetail, error) {
+	permissions, err := s.rbac.GetPermissionsForRole(role.Name)
+	if err != nil {
+		return nil, err
+	}
+	staff, err := s.rbac.GetUsersForRole(role.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &RoleDetail{StaffRole: role, Permissions: permissions, StaffIDs: staff}, nil
+}
+
+// checkPermissions ensures permissions name known resources and actions
+func checkPermissions(permissions []auth.Permission) error {
+	for _, permission := range permissions {
+		if !auth.IsValidPermission(permission) {
+			return fmt.Errorf("%w: %s %s", ErrInvalidPermission, permission.Action, permission.Resource)
+		}
+	}
+	return nil
+}
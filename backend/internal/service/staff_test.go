@@ -0,0 +1,454 @@
+package service
+
+import (
+	"backend/internal/auth"
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// MockStaffRepository mocks StaffRepository
+type MockStaffRepository struct {
+	repository.StaffRepository
+	mock.Mock
+}
+
+func (m *MockStaffRepository) Create(ctx context.Context, staff *domain.Staff) error {
+	args := m.Called(ctx, staff)
+	staff.ID = uuid.New()
+	return args.Error(0)
+}
+
+func (m *MockStaffRepository) GetByID(ctx context.Context, id string) (*domain.Staff, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Staff), args.Error(1)
+}
+
+func (m *MockStaffRepository) GetByUsername(ctx context.Context, username string) (*domain.Staff, error) {
+	args := m.Called(ctx, username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Staff), args.Error(1)
+}
+
+func (m *MockStaffRepository) Update(ctx context.Context, staff *domain.Staff) error {
+	args := m.Called(ctx, staff)
+	return args.Error(0)
+}
+
+func (m *MockStaffRepository) Count(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockStaffRepository) CreateRole(ctx context.Context, role *domain.StaffRole) error {
+	args := m.Called(ctx, role)
+	return args.Error(0)
+}
+
+func (m *MockStaffRepository) GetRole(ctx context.Context, name string) (*domain.StaffRole, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.StaffRole), args.Error(1)
+}
+
+func (m *MockStaffRepository) DeleteRole(ctx context.Context, name string) error {
+	args := m.Called(ctx, name)
+	return args.Error(0)
+}
+
+func newTestStaff(t *testing.T, password string) *domain.Staff {
+	hash, err := auth.HashPassword(password)
+	require.NoError(t, err)
+	return &domain.Staff{
+		BaseModel:    domain.BaseModel{ID: uuid.New()},
+		Username:     "alice",
+		PasswordHash: hash,
+		Name:         "Alice",
+		Status:       domain.StaffStatusActive,
+	}
+}
+
+func newTestRBAC(t *testing.T) *auth.RBAC {
+	rbac, err := auth.NewRBAC()
+	require.NoError(t, err)
+	return rbac
+}
+
+func TestStaffService_Authenticate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("returns staff with roles and records the login", func(t *testing.T) {
+		repo := new(MockStaffRepository)
+		rbac := newTestRBAC(t)
+		staff := newTestStaff(t, "s3cret-pass")
+		require.NoError(t, rbac.SetRolesForUser(staff.ID.String(), []string{auth.RoleFinance}))
+		repo.On("GetByUsername", ctx, "alice").Return(staff, nil)
+		repo.On("Update", ctx, staff).Return(nil)
+
+		got, err := NewStaffService(repo, rbac).Authenticate(ctx, "alice", "s3cret-pass", "10.0.0.1")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{auth.RoleFinance}, got.Roles)
+		assert.Equal(t, auth.RoleFinance, got.PrimaryRole())
+		assert.Equal(t, "10.0.0.1", got.LastLoginIP)
+		assert.NotNil(t, got.LastLoginAt)
+	})
+
+	t.Run("rejects a wrong password", func(t *testing.T) {
+		repo := new(MockStaffRepository)
+		repo.On("GetByUsername", ctx, "alice").Return(newTestStaff(t, "s3cret-pass"), nil)
+
+		_, err := NewStaffService(repo, newTestRBAC(t)).Authenticate(ctx, "alice", "wrong", "")
+
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("rejects an unknown username", func(t *testing.T) {
+		repo := new(MockStaffRepository)
+		repo.On("GetByUsername", ctx, "bob").Return(nil, errors.New("record not found"))
+
+		_, err := NewStaffService(repo, newTestRBAC(t)).Authenticate(ctx, "bob", "s3cret-pass", "")
+
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("rejects a disabled account", func(t *testing.T) {
+		repo := new(MockStaffRepository)
+		staff := newTestStaff(t, "s3cret-pass")
+		staff.Status = domain.StaffStatusDisabled
+		repo.On("GetByUsername", ctx, "alice").Return(staff, nil)
+
+		_, err := NewStaffService(repo, newTestRBAC(t)).Authenticate(ctx, "alice", "s3cret-pass", "")
+
+		assert.ErrorIs(t, err, ErrStaffDisabled)
+	})
+}
+
+func TestStaffService_CreateStaff(t *testing.T) {
+	ctx := context.Background()
+	req := &CreateStaffRequest{Username: "carol", Password: "s3cret-pass", Name: "Carol", Roles: []string{"auditor"}}
+
+	t.Run("assigns the roles", func(t *testing.T) {
+		repo := new(MockStaffRepository)
+		rbac := newTestRBAC(t)
+		repo.On("GetByUsername", ctx, "carol").Return(nil, errors.New("record not found"))
+		repo.On("GetRole", ctx, "auditor").Return(&domain.StaffRole{Name: "auditor"}, nil)
+		repo.On("Create", ctx, mock.AnythingOfType("*domain.Staff")).Return(nil)
+
+		staff, err := NewStaffService(repo, rbac).CreateStaff(ctx, req, "op-1")
+
+		require.NoError(t, err)
+		assert.NotEqual(t, "s3cret-pass", staff.PasswordHash)
+		roles, _ := rbac.GetRolesForUser(staff.ID.String())
+		assert.Equal(t, []string{"auditor"}, roles)
+	})
+
+	t.Run("rejects a taken username", func(t *testing.T) {
+		repo := new(MockStaffRepository)
+		repo.On("GetByUsername", ctx, "carol").Return(newTestStaff(t, "x"), nil)
+
+		_, err := NewStaffService(repo, newTestRBAC(t)).CreateStaff(ctx, req, "op-1")
+
+		assert.ErrorIs(t, err, ErrStaffUsernameTaken)
+	})
+
+	t.Run("rejects an unknown role", func(t *testing.T) {
+		repo := new(MockStaffRepository)
+		repo.On("GetByUsername", ctx, "carol").Return(nil, errors.New("record not found"))
+		repo.On("GetRole", ctx, "auditor").Return(nil, errors.New("record not found"))
+
+		_, err := NewStaffService(repo, newTestRBAC(t)).CreateStaff(ctx, req, "op-1")
+
+		assert.ErrorIs(t, err, ErrRoleNotFound)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejects a role with permissions the operator lacks", func(t *testing.T) {
+		repo := new(MockStaffRepository)
+		rbac := newTestRBAC(t)
+		require.NoError(t, rbac.SetRolesForUser("op-1", []string{auth.RoleOperations}))
+		repo.On("GetByUsername", ctx, "carol").Return(nil, errors.New("record not found"))
+		repo.On("GetRole", ctx, auth.RoleSuperAdmin).Return(&domain.StaffRole{Name: auth.RoleSuperAdmin}, nil)
+		admin := &CreateStaffRequest{Username: "carol", Password: "s3cret-pass", Name: "Carol", Roles: []string{auth.RoleSuperAdmin}}
+
+		_, err := NewStaffService(repo, rbac).CreateStaff(ctx, admin, "op-1")
+
+		assert.ErrorIs(t, err, ErrRoleNotGrantable)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("a super admin can create another", func(t *testing.T) {
+		repo := new(MockStaffRepository)
+		rbac := newTestRBAC(t)
+		require.NoError(t, rbac.SetRolesForUser("op-1", []string{auth.RoleSuperAdmin}))
+		repo.On("GetByUsername", ctx, "carol").Return(nil, errors.New("record not found"))
+		repo.On("GetRole", ctx, auth.RoleSuperAdmin).Return(&domain.StaffRole{Name: auth.RoleSuperAdmin}, nil)
+		repo.On("Create", ctx, mock.AnythingOfType("*domain.Staff")).Return(nil)
+		admin := &CreateStaffRequest{Username: "carol", Password: "s3cret-pass", Name: "Carol", Roles: []string{auth.RoleSuperAdmin}}
+
+		staff, err := NewStaffService(repo, rbac).CreateStaff(ctx, admin, "op-1")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{auth.RoleSuperAdmin}, staff.Roles)
+	})
+}
+
+func TestStaffService_UpdateStaff(t *testing.T) {
+	ctx := context.Background()
+
+	// newAdmins returns an RBAC where operator and target are super admins
+	newAdmins := func(t *testing.T) (*auth.RBAC, *domain.Staff, *domain.Staff) {
+		rbac := newTestRBAC(t)
+		operator := newTestStaff(t, "s3cret-pass")
+		target := newTestStaff(t, "s3cret-pass")
+		require.NoError(t, rbac.SetRolesForUser(operator.ID.String(), []string{auth.RoleSuperAdmin}))
+		require.NoError(t, rbac.SetRolesForUser(target.ID.String(), []string{auth.RoleSuperAdmin}))
+		return rbac, operator, target
+	}
+
+	t.Run("rejects disabling one's own account", func(t *testing.T) {
+		req := &UpdateStaffRequest{Status: domain.StaffStatusDisabled}
+
+		_, err := NewStaffService(new(MockStaffRepository), newTestRBAC(t)).UpdateStaff(ctx, "s-1", req, "s-1")
+
+		assert.ErrorIs(t, err, ErrStaffSelfDisable)
+	})
+
+	t.Run("rejects changes to staff with more access than the operator", func(t *testing.T) {
+		repo := new(MockStaffRepository)
+		rbac, _, target := newAdmins(t)
+		require.NoError(t, rbac.SetRolesForUser("op-1", []string{auth.RoleOperations}))
+		repo.On("GetByID", ctx, target.ID.String()).Return(target, nil)
+		req := &UpdateStaffRequest{Roles: []string{auth.RoleOperations}}
+
+		_, err := NewStaffService(repo, rbac).UpdateStaff(ctx, target.ID.String(), req, "op-1")
+
+		assert.ErrorIs(t, err, ErrRoleNotGrantable)
+		roles, _ := rbac.GetRolesForUser(target.ID.String())
+		assert.Equal(t, []string{auth.RoleSuperAdmin}, roles)
+	})
+
+	t.Run("disables a super admin while another is active", func(t *testing.T) {
+		repo := new(MockStaffRepository)
+		rbac, operator, target := newAdmins(t)
+		repo.On("GetByID", ctx, target.ID.String()).Return(target, nil)
+		repo.On("GetByID", ctx, operator.ID.String()).Return(operator, nil)
+		repo.On("Update", ctx, target).Return(nil)
+		req := &UpdateStaffRequest{Status: domain.StaffStatusDisabled}
+
+		staff, err := NewStaffService(repo, rbac).UpdateStaff(ctx, target.ID.String(), req, operator.ID.String())
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.StaffStatusDisabled, staff.Status)
+	})
+
+	t.Run("rejects demoting the last active super admin", func(t *testing.T) {
+		repo := new(MockStaffRepository)
+		rbac, operator, target := newAdmins(t)
+		operator.Status = domain.StaffStatusDisabled
+		repo.On("GetByID", ctx, target.ID.String()).Return(target, nil)
+		repo.On("GetByID", ctx, operator.ID.String()).Return(operator, nil)
+		repo.On("GetRole", ctx, auth.RoleFinance).Return(&domain.StaffRole{Name: auth.RoleFinance}, nil)
+		req := &UpdateStaffRequest{Roles: []string{auth.RoleFinance}}
+
+		_, err := NewStaffService(repo, rbac).UpdateStaff(ctx, target.ID.String(), req, operator.ID.String())
+
+		assert.ErrorIs(t, err, ErrStaffLastSuperAdmin)
+		repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
+
+func TestStaffService_IsActive(t *testing.T) {
+	ctx := context.Background()
+	active := newTestStaff(t, "s3cret-pass")
+	disabled := newTestStaff(t, "s3cret-pass")
+	disabled.Status = domain.StaffStatusDisabled
+
+	repo := new(MockStaffRepository)
+	repo.On("GetByID", ctx, "active").Return(active, nil)
+	repo.On("GetByID", ctx, "disabled").Return(disabled, nil)
+	repo.On("GetByID", ctx, "removed").Return(nil, gorm.ErrRecordNotFound)
+	repo.On("GetByID", ctx, "broken").Return(nil, errors.New("connection refused"))
+	service := NewStaffService(repo, newTestRBAC(t))
+
+	tests := []struct {
+		id      string
+		want    bool
+		wantErr bool
+	}{
+		{"active", true, false},
+		{"disabled", false, false},
+		{"removed", false, false},
+		{"broken", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			got, err := service.IsActive(ctx, tt.id)
+
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestStaffService_DeleteStaff(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rejects deleting one's own account", func(t *testing.T) {
+		err := NewStaffService(new(MockStaffRepository), newTestRBAC(t)).DeleteStaff(ctx, "s-1", "s-1")
+
+		assert.ErrorIs(t, err, ErrStaffSelfDelete)
+	})
+
+	t.Run("rejects deleting the last active super admin", func(t *testing.T) {
+		repo := new(MockStaffRepository)
+		rbac := newTestRBAC(t)
+		target := newTestStaff(t, "s3cret-pass")
+		require.NoError(t, rbac.SetRolesForUser(target.ID.String(), []string{auth.RoleSuperAdmin}))
+		require.NoError(t, rbac.SetRolesForUser("op-1", []string{auth.RoleSuperAdmin}))
+		repo.On("GetByID", ctx, target.ID.String()).Return(target, nil)
+		repo.On("GetByID", ctx, "op-1").Return(nil, gorm.ErrRecordNotFound)
+
+		err := NewStaffService(repo, rbac).DeleteStaff(ctx, target.ID.String(), "op-1")
+
+		assert.ErrorIs(t, err, ErrStaffLastSuperAdmin)
+		repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
+
+func TestStaffService_EnsureAdmin(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("creates a super admin when there are no staff", func(t *testing.T) {
+		repo := new(MockStaffRepository)
+		rbac := newTestRBAC(t)
+		repo.On("Count", ctx).Return(int64(0), nil)
+		repo.On("GetByUsername", ctx, "admin").Return(nil, errors.New("record not found"))
+		repo.On("GetRole", ctx, auth.RoleSuperAdmin).Return(&domain.StaffRole{Name: auth.RoleSuperAdmin}, nil)
+		var created *domain.Staff
+		repo.On("Create", ctx, mock.AnythingOfType("*domain.Staff")).Run(func(args mock.Arguments) {
+			created = args.Get(1).(*domain.Staff)
+		}).Return(nil)
+
+		require.NoError(t, NewStaffService(repo, rbac).EnsureAdmin(ctx, "admin", "s3cret-pass"))
+
+		require.NotNil(t, created)
+		allowed, err := rbac.CheckPermission(created.ID.String(), auth.ResourceOrders, auth.PermRefund)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	})
+
+	t.Run("does nothing once staff exist", func(t *testing.T) {
+		repo := new(MockStaffRepository)
+		repo.On("Count", ctx).Return(int64(3), nil)
+
+		require.NoError(t, NewStaffService(repo, newTestRBAC(t)).EnsureAdmin(ctx, "admin", "s3cret-pass"))
+
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestRoleService_CreateRole(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("grants the permissions", func(t *testing.T) {
+		repo := new(MockStaffRepository)
+		rbac := newTestRBAC(t)
+		repo.On("GetRole", ctx, "auditor").Return(nil, errors.New("record not found"))
+		repo.On("CreateRole", ctx, mock.AnythingOfType("*domain.StaffRole")).Return(nil)
+		req := &RoleRequest{
+			Name:        "auditor",
+			DisplayName: "Auditor",
+			Permissions: []auth.Permission{{Resource: auth.ResourceOrders, Action: auth.PermRead}},
+		}
+
+		role, err := NewRoleService(repo, rbac).CreateRole(ctx, req)
+
+		require.NoError(t, err)
+		assert.Equal(t, req.Permissions, role.Permissions)
+		require.NoError(t, rbac.SetRolesForUser("s-1", []string{"auditor"}))
+		allowed, _ := rbac.CheckPermission("s-1", auth.ResourceOrders, auth.PermRead)
+		assert.True(t, allowed)
+		allowed, _ = rbac.CheckPermission("s-1", auth.ResourceOrders, auth.PermRefund)
+		assert.False(t, allowed)
+	})
+
+	t.Run("rejects an invalid name", func(t *testing.T) {
+		_, err := NewRoleService(new(MockStaffRepository), newTestRBAC(t)).CreateRole(ctx, &RoleRequest{Name: "Bad Name"})
+
+		assert.ErrorIs(t, err, ErrInvalidRoleName)
+	})
+
+	t.Run("rejects an unknown permission", func(t *testing.T) {
+		repo := new(MockStaffRepository)
+		repo.On("GetRole", ctx, "auditor").Return(nil, errors.New("record not found"))
+		req := &RoleRequest{Name: "auditor", Permissions: []auth.Permission{{Resource: "spaceships", Action: auth.PermRead}}}
+
+		_, err := NewRoleService(repo, newTestRBAC(t)).CreateRole(ctx, req)
+
+		assert.ErrorIs(t, err, ErrInvalidPermission)
+	})
+}
+
+func TestRoleService_UpdateRole_SuperAdminImmutable(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockStaffRepository)
+	repo.On("GetRole", ctx, auth.RoleSuperAdmin).Return(&domain.StaffRole{Name: auth.RoleSuperAdmin, Builtin: true}, nil)
+	req := &RoleRequest{DisplayName: "Root", Permissions: []auth.Permission{}}
+
+	_, err := NewRoleService(repo, newTestRBAC(t)).UpdateRole(ctx, auth.RoleSuperAdmin, req)
+
+	assert.ErrorIs(t, err, ErrRoleImmutable)
+}
+
+func TestRoleService_DeleteRole(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rejects a built-in role", func(t *testing.T) {
+		repo := new(MockStaffRepository)
+		repo.On("GetRole", ctx, auth.RoleFinance).Return(&domain.StaffRole{Name: auth.RoleFinance, Builtin: true}, nil)
+
+		err := NewRoleService(repo, newTestRBAC(t)).DeleteRole(ctx, auth.RoleFinance)
+
+		assert.ErrorIs(t, err, ErrRoleBuiltin)
+	})
+
+	t.Run("rejects an assigned role", func(t *testing.T) {
+		repo := new(MockStaffRepository)
+		rbac := newTestRBAC(t)
+		require.NoError(t, rbac.SetRolesForUser("s-1", []string{"auditor"}))
+		repo.On("GetRole", ctx, "auditor").Return(&domain.StaffRole{Name: "auditor"}, nil)
+
+		err := NewRoleService(repo, rbac).DeleteRole(ctx, "auditor")
+
+		assert.ErrorIs(t, err, ErrRoleInUse)
+	})
+
+	t.Run("removes the role's permissions", func(t *testing.T) {
+		repo := new(MockStaffRepository)
+		rbac := newTestRBAC(t)
+		require.NoError(t, rbac.SetPermissionsForRole("auditor", []auth.Permission{{Resource: auth.ResourceOrders, Action: auth.PermRead}}))
+		repo.On("GetRole", ctx, "auditor").Return(&domain.StaffRole{Name: "auditor"}, nil)
+		repo.On("DeleteRole", ctx, "auditor").Return(nil)
+
+		require.NoError(t, NewRoleService(repo, rbac).DeleteRole(ctx, "auditor"))
+
+		permissions, _ := rbac.GetPermissionsForRole("auditor")
+		assert.Empty(t, permissions)
+	})
+}
@@ -0,0 +1,15 @@
+-- Migration: Drop pricing_rules table and dynamic pricing columns
+-- Down Migration
+
+ALTER TABLE cabin_prices
+    DROP COLUMN IF EXISTS last_repriced_at,
+    DROP COLUMN IF EXISTS version,
+    DROP COLUMN IF EXISTS manual_override,
+    DROP COLUMN IF EXISTS base_infant_price,
+    DROP COLUMN IF EXISTS base_child_price,
+    DROP COLUMN IF EXISTS base_adult_price;
+
+DROP INDEX IF EXISTS idx_pricing_rules_active;
+DROP INDEX IF EXISTS idx_pricing_rules_cabin_type_id;
+DROP INDEX IF EXISTS idx_pricing_rules_voyage_id;
+DROP TABLE IF EXISTS pricing_rules;
@@ -0,0 +1,52 @@
+-- Migration: Create pricing_rules table and dynamic pricing columns
+-- Up Migration
+
+CREATE TABLE IF NOT EXISTS pricing_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    voyage_id UUID REFERENCES voyages(id) ON DELETE CASCADE,
+    cabin_type_id UUID REFERENCES cabin_types(id) ON DELETE CASCADE,
+    priority INTEGER NOT NULL DEFAULT 100,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    exclusive BOOLEAN NOT NULL DEFAULT false,
+    availability_below DECIMAL(5,4),
+    availability_above DECIMAL(5,4),
+    load_factor_below DECIMAL(5,4),
+    load_factor_above DECIMAL(5,4),
+    days_to_departure_le INTEGER,
+    days_to_departure_ge INTEGER,
+    adjustment_type VARCHAR(20) NOT NULL DEFAULT 'percent',
+    adjustment_value DECIMAL(10,2) NOT NULL,
+    floor_price DECIMAL(10,2),
+    ceiling_price DECIMAL(10,2),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT chk_pricing_rules_adjustment_type CHECK (adjustment_type IN ('percent', 'fixed'))
+);
+
+CREATE INDEX idx_pricing_rules_voyage_id ON pricing_rules(voyage_id);
+CREATE INDEX idx_pricing_rules_cabin_type_id ON pricing_rules(cabin_type_id);
+CREATE INDEX idx_pricing_rules_active ON pricing_rules(is_active, priority) WHERE deleted_at IS NULL;
+
+ALTER TABLE cabin_prices
+    ADD COLUMN IF NOT EXISTS base_adult_price DECIMAL(10,2),
+    ADD COLUMN IF NOT EXISTS base_child_price DECIMAL(10,2),
+    ADD COLUMN IF NOT EXISTS base_infant_price DECIMAL(10,2),
+    ADD COLUMN IF NOT EXISTS manual_override BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS last_repriced_at VARCHAR(40);
+
+COMMENT ON TABLE pricing_rules IS '动态定价规则表';
+COMMENT ON COLUMN pricing_rules.priority IS '优先级，数值越小越先执行';
+COMMENT ON COLUMN pricing_rules.exclusive IS '命中后是否停止执行后续规则';
+COMMENT ON COLUMN pricing_rules.availability_below IS '条件: 可售比例低于该值 (0-1)';
+COMMENT ON COLUMN pricing_rules.load_factor_below IS '条件: 上座率低于该值 (0-1)';
+COMMENT ON COLUMN pricing_rules.days_to_departure_le IS '条件: 距出发天数小于等于该值';
+COMMENT ON COLUMN pricing_rules.adjustment_type IS '调价方式: percent-百分比, fixed-固定金额';
+COMMENT ON COLUMN pricing_rules.floor_price IS '成人价下限';
+COMMENT ON COLUMN pricing_rules.ceiling_price IS '成人价上限';
+COMMENT ON COLUMN cabin_prices.base_adult_price IS '基础成人价，动态定价以此为基准';
+COMMENT ON COLUMN cabin_prices.manual_override IS '人工锁价，动态定价跳过';
+COMMENT ON COLUMN cabin_prices.version IS '价格版本号，每次调价递增';
@@ -0,0 +1,6 @@
+-- Migration: Drop price_bulk_operations table
+-- Down Migration
+
+DROP INDEX IF EXISTS idx_price_bulk_operations_created_at;
+DROP INDEX IF EXISTS idx_price_bulk_operations_operator_id;
+DROP TABLE IF EXISTS price_bulk_operations;
@@ -0,0 +1,26 @@
+-- Migration: Create price_bulk_operations table
+-- Up Migration
+
+CREATE TABLE IF NOT EXISTS price_bulk_operations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    operator_id VARCHAR(64),
+    mode VARCHAR(20) NOT NULL,
+    price_type VARCHAR(20) NOT NULL,
+    departure_from DATE NOT NULL,
+    departure_to DATE NOT NULL,
+    request JSONB NOT NULL DEFAULT '{}',
+    voyage_count INTEGER NOT NULL DEFAULT 0,
+    created_count INTEGER NOT NULL DEFAULT 0,
+    updated_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT chk_price_bulk_operations_mode CHECK (mode IN ('set', 'adjust'))
+);
+
+CREATE INDEX idx_price_bulk_operations_operator_id ON price_bulk_operations(operator_id);
+CREATE INDEX idx_price_bulk_operations_created_at ON price_bulk_operations(created_at DESC);
+
+COMMENT ON TABLE price_bulk_operations IS '批量调价审计记录表';
+COMMENT ON COLUMN price_bulk_operations.mode IS '调价方式: set-价格矩阵, adjust-百分比调整';
+COMMENT ON COLUMN price_bulk_operations.request IS '原始批量调价请求';
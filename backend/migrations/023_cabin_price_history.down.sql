@@ -0,0 +1,8 @@
+-- Migration: Drop cabin_price_history table
+-- Down Migration
+
+DROP INDEX IF EXISTS idx_price_history_open;
+DROP INDEX IF EXISTS idx_price_history_cabin_type_id;
+DROP INDEX IF EXISTS idx_price_history_voyage_id;
+DROP INDEX IF EXISTS idx_price_history_price_id;
+DROP TABLE IF EXISTS cabin_price_history;
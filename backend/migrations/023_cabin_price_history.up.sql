@@ -0,0 +1,50 @@
+-- Migration: Create cabin_price_history table
+-- Up Migration
+
+CREATE TABLE IF NOT EXISTS cabin_price_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    price_id UUID NOT NULL,
+    voyage_id UUID NOT NULL REFERENCES voyages(id) ON DELETE CASCADE,
+    cabin_type_id UUID NOT NULL REFERENCES cabin_types(id) ON DELETE CASCADE,
+    price_type VARCHAR(20) NOT NULL,
+    version INTEGER NOT NULL,
+    adult_price DECIMAL(10,2) NOT NULL,
+    child_price DECIMAL(10,2),
+    infant_price DECIMAL(10,2),
+    single_supplement DECIMAL(10,2),
+    port_fee DECIMAL(10,2),
+    service_fee DECIMAL(10,2),
+    is_promotion BOOLEAN NOT NULL DEFAULT false,
+    effective_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    effective_to TIMESTAMP WITH TIME ZONE,
+    source VARCHAR(20) NOT NULL DEFAULT 'manual',
+    actor_id VARCHAR(64),
+    note TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT chk_cabin_price_history_source CHECK (source IN ('manual', 'rule', 'promotion'))
+);
+
+CREATE INDEX idx_price_history_price_id ON cabin_price_history(price_id, effective_from);
+CREATE INDEX idx_price_history_voyage_id ON cabin_price_history(voyage_id, effective_from);
+CREATE INDEX idx_price_history_cabin_type_id ON cabin_price_history(cabin_type_id);
+CREATE UNIQUE INDEX idx_price_history_open ON cabin_price_history(price_id) WHERE effective_to IS NULL;
+
+-- Backfill the current prices as their first version
+INSERT INTO cabin_price_history (
+    price_id, voyage_id, cabin_type_id, price_type, version,
+    adult_price, child_price, infant_price, single_supplement, port_fee, service_fee,
+    is_promotion, effective_from, source, note
+)
+SELECT
+    id, voyage_id, cabin_type_id, price_type, COALESCE(version, 1),
+    adult_price, child_price, infant_price, single_supplement, port_fee, service_fee,
+    COALESCE(is_promotion, false), COALESCE(updated_at, created_at, CURRENT_TIMESTAMP), 'manual', 'backfill'
+FROM cabin_prices;
+
+COMMENT ON TABLE cabin_price_history IS '舱房价格历史版本表';
+COMMENT ON COLUMN cabin_price_history.effective_from IS '版本生效时间';
+COMMENT ON COLUMN cabin_price_history.effective_to IS '版本失效时间，NULL 表示当前版本';
+COMMENT ON COLUMN cabin_price_history.source IS '变更来源: manual-人工, rule-定价规则, promotion-促销';
+COMMENT ON COLUMN cabin_price_history.actor_id IS '操作人ID';
@@ -0,0 +1,11 @@
+-- Migration: Drop occupancy fare columns
+-- Down Migration
+
+ALTER TABLE order_items
+    DROP COLUMN IF EXISTS extra_guest_amount,
+    DROP COLUMN IF EXISTS extra_guest_count,
+    DROP COLUMN IF EXISTS single_supplement;
+
+ALTER TABLE cabin_prices
+    DROP COLUMN IF EXISTS extra_child_price,
+    DROP COLUMN IF EXISTS extra_adult_price;
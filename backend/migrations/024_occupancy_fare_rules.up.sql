@@ -0,0 +1,17 @@
+-- Migration: Add occupancy fare columns
+-- Up Migration
+
+ALTER TABLE cabin_prices
+    ADD COLUMN IF NOT EXISTS extra_adult_price DECIMAL(10,2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS extra_child_price DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE order_items
+    ADD COLUMN IF NOT EXISTS single_supplement DECIMAL(10,2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS extra_guest_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS extra_guest_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN cabin_prices.extra_adult_price IS '超出标准入住人数的第3/4位成人价格，0表示按成人价';
+COMMENT ON COLUMN cabin_prices.extra_child_price IS '超出标准入住人数的第3/4位儿童价格，0表示按儿童价';
+COMMENT ON COLUMN order_items.single_supplement IS '单人入住附加费';
+COMMENT ON COLUMN order_items.extra_guest_count IS '按第3/4位价格计费的人数';
+COMMENT ON COLUMN order_items.extra_guest_amount IS '第3/4位客人费用合计';
@@ -0,0 +1,14 @@
+-- Migration: Drop multi-currency settlement columns
+-- Down Migration
+
+ALTER TABLE payments
+    DROP COLUMN IF EXISTS exchange_rate,
+    DROP COLUMN IF EXISTS base_amount,
+    DROP COLUMN IF EXISTS base_currency;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS rate_locked_at,
+    DROP COLUMN IF EXISTS rate_source,
+    DROP COLUMN IF EXISTS base_total_amount,
+    DROP COLUMN IF EXISTS exchange_rate,
+    DROP COLUMN IF EXISTS base_currency;
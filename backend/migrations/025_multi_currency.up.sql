@@ -0,0 +1,26 @@
+-- Migration: Add multi-currency settlement columns
+-- Up Migration
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS base_currency VARCHAR(3) NOT NULL DEFAULT 'CNY',
+    ADD COLUMN IF NOT EXISTS exchange_rate DECIMAL(18,8) NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS base_total_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS rate_source VARCHAR(32),
+    ADD COLUMN IF NOT EXISTS rate_locked_at VARCHAR(40);
+
+ALTER TABLE payments
+    ADD COLUMN IF NOT EXISTS base_currency VARCHAR(3) NOT NULL DEFAULT 'CNY',
+    ADD COLUMN IF NOT EXISTS base_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS exchange_rate DECIMAL(18,8) NOT NULL DEFAULT 1;
+
+-- Existing orders and payments were all settled in CNY
+UPDATE orders SET base_total_amount = total_amount WHERE base_total_amount = 0;
+UPDATE payments SET base_amount = amount WHERE base_amount = 0;
+
+COMMENT ON COLUMN orders.base_currency IS '基准币种（价格与对账币种）';
+COMMENT ON COLUMN orders.exchange_rate IS '下单时锁定的汇率：1 基准币种 = N 结算币种';
+COMMENT ON COLUMN orders.base_total_amount IS '订单总额的基准币种金额';
+COMMENT ON COLUMN orders.rate_source IS '汇率来源';
+COMMENT ON COLUMN orders.rate_locked_at IS '汇率锁定时间';
+COMMENT ON COLUMN payments.base_amount IS '支付金额的基准币种等值，用于对账';
+COMMENT ON COLUMN payments.exchange_rate IS '支付使用的汇率：1 基准币种 = N 支付币种';
@@ -0,0 +1,6 @@
+-- Migration: Drop coupon tables
+-- Down Migration
+
+DROP TABLE IF EXISTS coupon_redemptions;
+DROP TABLE IF EXISTS user_coupons;
+DROP TABLE IF EXISTS coupons;
@@ -0,0 +1,92 @@
+-- Migration: Create coupons, user_coupons and coupon_redemptions tables
+-- Up Migration
+
+CREATE TABLE IF NOT EXISTS coupons (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(50) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    discount_type VARCHAR(20) NOT NULL DEFAULT 'fixed',
+    discount_value DECIMAL(10,2) NOT NULL,
+    max_discount DECIMAL(10,2),
+    min_spend DECIMAL(10,2) NOT NULL DEFAULT 0,
+    route_ids JSONB DEFAULT '[]',
+    cruise_ids JSONB DEFAULT '[]',
+    cabin_type_ids JSONB DEFAULT '[]',
+    departure_from VARCHAR(10),
+    departure_to VARCHAR(10),
+    new_users_only BOOLEAN NOT NULL DEFAULT false,
+    requires_claim BOOLEAN NOT NULL DEFAULT false,
+    per_user_limit INTEGER NOT NULL DEFAULT 1,
+    total_limit INTEGER NOT NULL DEFAULT 0,
+    redeemed_count INTEGER NOT NULL DEFAULT 0,
+    stackable BOOLEAN NOT NULL DEFAULT false,
+    valid_from TIMESTAMP WITH TIME ZONE,
+    valid_until TIMESTAMP WITH TIME ZONE,
+    claim_valid_days INTEGER NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT chk_coupons_discount_type CHECK (discount_type IN ('fixed', 'percent')),
+    CONSTRAINT chk_coupons_redeemed_count CHECK (redeemed_count >= 0)
+);
+
+CREATE UNIQUE INDEX idx_coupons_code ON coupons(code) WHERE deleted_at IS NULL;
+CREATE INDEX idx_coupons_active ON coupons(is_active) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS user_coupons (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    coupon_id UUID NOT NULL REFERENCES coupons(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'available',
+    source VARCHAR(20) NOT NULL DEFAULT 'issued',
+    expires_at TIMESTAMP WITH TIME ZONE,
+    used_order_id UUID REFERENCES orders(id) ON DELETE SET NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT chk_user_coupons_status CHECK (status IN ('available', 'used', 'expired'))
+);
+
+CREATE INDEX idx_user_coupons_user ON user_coupons(user_id, status);
+CREATE INDEX idx_user_coupons_coupon ON user_coupons(coupon_id);
+
+CREATE TABLE IF NOT EXISTS coupon_redemptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    coupon_id UUID NOT NULL REFERENCES coupons(id),
+    user_coupon_id UUID REFERENCES user_coupons(id) ON DELETE SET NULL,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    code VARCHAR(50) NOT NULL,
+    discount_amount DECIMAL(10,2) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'applied',
+    released_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT chk_coupon_redemptions_status CHECK (status IN ('applied', 'released'))
+);
+
+CREATE INDEX idx_coupon_redemptions_order ON coupon_redemptions(order_id);
+CREATE INDEX idx_coupon_redemptions_coupon_user ON coupon_redemptions(coupon_id, user_id, status);
+
+COMMENT ON TABLE coupons IS '优惠券表';
+COMMENT ON COLUMN coupons.discount_type IS '优惠方式: fixed-立减金额, percent-折扣百分比';
+COMMENT ON COLUMN coupons.max_discount IS '百分比优惠券的最高优惠金额';
+COMMENT ON COLUMN coupons.min_spend IS '使用门槛，按适用范围内的小计计算';
+COMMENT ON COLUMN coupons.route_ids IS '适用航线，空数组表示不限';
+COMMENT ON COLUMN coupons.cruise_ids IS '适用邮轮，空数组表示不限';
+COMMENT ON COLUMN coupons.cabin_type_ids IS '适用舱型，空数组表示不限';
+COMMENT ON COLUMN coupons.departure_from IS '适用出发日期起 (含)';
+COMMENT ON COLUMN coupons.departure_to IS '适用出发日期止 (含)';
+COMMENT ON COLUMN coupons.new_users_only IS '仅限未有过支付订单的新用户';
+COMMENT ON COLUMN coupons.requires_claim IS '需先领取或发放到券包才能使用';
+COMMENT ON COLUMN coupons.per_user_limit IS '每人限用次数，0表示不限';
+COMMENT ON COLUMN coupons.total_limit IS '总使用次数上限，0表示不限';
+COMMENT ON COLUMN coupons.stackable IS '是否可与其他可叠加优惠券同时使用';
+COMMENT ON COLUMN coupons.claim_valid_days IS '领取后有效天数，0表示以券有效期为准';
+COMMENT ON TABLE user_coupons IS '用户券包';
+COMMENT ON TABLE coupon_redemptions IS '优惠券核销记录，订单取消或超时后释放';
+COMMENT ON COLUMN coupon_redemptions.discount_amount IS '优惠金额（基准币种）';
@@ -0,0 +1,4 @@
+-- Migration: Drop price_watches table
+-- Down Migration
+
+DROP TABLE IF EXISTS price_watches;
@@ -0,0 +1,33 @@
+-- Migration: Create price_watches table
+-- Up Migration
+
+CREATE TABLE IF NOT EXISTS price_watches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    voyage_id UUID REFERENCES voyages(id) ON DELETE CASCADE,
+    route_id UUID REFERENCES routes(id) ON DELETE CASCADE,
+    cabin_type_id UUID NOT NULL REFERENCES cabin_types(id) ON DELETE CASCADE,
+    threshold_percent DECIMAL(5,2),
+    target_price DECIMAL(10,2),
+    baseline_price DECIMAL(10,2),
+    last_notified_price DECIMAL(10,2),
+    last_notified_at TIMESTAMP WITH TIME ZONE,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT chk_price_watches_scope CHECK ((voyage_id IS NULL) <> (route_id IS NULL)),
+    CONSTRAINT chk_price_watches_condition CHECK (threshold_percent IS NOT NULL OR target_price IS NOT NULL)
+);
+
+CREATE INDEX idx_price_watches_user ON price_watches(user_id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_price_watches_voyage ON price_watches(voyage_id, cabin_type_id) WHERE deleted_at IS NULL AND is_active;
+CREATE INDEX idx_price_watches_route ON price_watches(route_id, cabin_type_id) WHERE deleted_at IS NULL AND is_active;
+
+COMMENT ON TABLE price_watches IS '用户降价提醒订阅';
+COMMENT ON COLUMN price_watches.voyage_id IS '关注的航次，与 route_id 二选一';
+COMMENT ON COLUMN price_watches.route_id IS '关注的航线，航线下任一航次降价均提醒';
+COMMENT ON COLUMN price_watches.threshold_percent IS '相对订阅时价格的降幅阈值 (%)';
+COMMENT ON COLUMN price_watches.target_price IS '目标价格，成人价不高于该价格时提醒';
+COMMENT ON COLUMN price_watches.baseline_price IS '订阅时的成人价';
+COMMENT ON COLUMN price_watches.last_notified_price IS '上次提醒时的价格，仅更低价格才再次提醒';
@@ -0,0 +1,5 @@
+-- Migration: Drop promotion tables
+-- Down Migration
+
+DROP TABLE IF EXISTS promotion_prices;
+DROP TABLE IF EXISTS promotion_campaigns;
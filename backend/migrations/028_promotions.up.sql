@@ -0,0 +1,64 @@
+-- Migration: Create promotion_campaigns and promotion_prices tables
+-- Up Migration
+
+CREATE TABLE IF NOT EXISTS promotion_campaigns (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    voyage_ids JSONB NOT NULL DEFAULT '[]',
+    cabin_type_ids JSONB NOT NULL DEFAULT '[]',
+    discount_type VARCHAR(20) NOT NULL DEFAULT 'percent',
+    discount_value DECIMAL(10,2) NOT NULL,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'scheduled',
+    activated_at TIMESTAMP WITH TIME ZONE,
+    ended_at TIMESTAMP WITH TIME ZONE,
+    applied_count INTEGER NOT NULL DEFAULT 0,
+    skipped_count INTEGER NOT NULL DEFAULT 0,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT chk_promotion_campaigns_discount_type CHECK (discount_type IN ('percent', 'fixed')),
+    CONSTRAINT chk_promotion_campaigns_status CHECK (status IN ('scheduled', 'active', 'ended', 'cancelled')),
+    CONSTRAINT chk_promotion_campaigns_window CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX idx_promotion_campaigns_schedule ON promotion_campaigns(status, starts_at, ends_at) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS promotion_prices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    campaign_id UUID NOT NULL REFERENCES promotion_campaigns(id) ON DELETE CASCADE,
+    price_id UUID NOT NULL REFERENCES cabin_prices(id) ON DELETE CASCADE,
+    voyage_id UUID NOT NULL REFERENCES voyages(id) ON DELETE CASCADE,
+    cabin_type_id UUID NOT NULL REFERENCES cabin_types(id) ON DELETE CASCADE,
+    original_adult_price DECIMAL(10,2) NOT NULL,
+    original_child_price DECIMAL(10,2),
+    original_infant_price DECIMAL(10,2),
+    promo_adult_price DECIMAL(10,2) NOT NULL,
+    promo_child_price DECIMAL(10,2),
+    promo_infant_price DECIMAL(10,2),
+    promo_version INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'applied',
+    applied_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    restored_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT chk_promotion_prices_status CHECK (status IN ('applied', 'restored'))
+);
+
+CREATE INDEX idx_promotion_prices_campaign ON promotion_prices(campaign_id, status);
+CREATE INDEX idx_promotion_prices_voyage ON promotion_prices(voyage_id, cabin_type_id);
+-- A cabin price belongs to at most one running campaign
+CREATE UNIQUE INDEX idx_promotion_prices_applied ON promotion_prices(price_id) WHERE status = 'applied' AND deleted_at IS NULL;
+
+COMMENT ON TABLE promotion_campaigns IS '促销活动，按时间窗口自动生效和结束';
+COMMENT ON COLUMN promotion_campaigns.voyage_ids IS '参与活动的航次';
+COMMENT ON COLUMN promotion_campaigns.cabin_type_ids IS '参与活动的舱型，空数组表示全部舱型';
+COMMENT ON COLUMN promotion_campaigns.discount_type IS '优惠方式: percent-按成人价百分比, fixed-成人价立减';
+COMMENT ON COLUMN promotion_campaigns.status IS '状态: scheduled-待生效, active-进行中, ended-已结束, cancelled-已取消';
+COMMENT ON COLUMN promotion_campaigns.skipped_count IS '生效时已在其他促销中而跳过的价格数';
+COMMENT ON TABLE promotion_prices IS '促销活动改价记录，活动结束时按原价恢复';
+COMMENT ON COLUMN promotion_prices.promo_version IS '活动写入的价格版本，期间被人工改价则结束时不覆盖';
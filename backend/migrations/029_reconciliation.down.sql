@@ -0,0 +1,5 @@
+-- Migration: Drop reconciliation tables
+-- Down Migration
+
+DROP TABLE IF EXISTS reconciliation_items;
+DROP TABLE IF EXISTS reconciliation_runs;
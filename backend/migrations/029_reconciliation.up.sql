@@ -0,0 +1,61 @@
+-- Migration: Create reconciliation_runs and reconciliation_items tables
+-- Up Migration
+
+CREATE TABLE IF NOT EXISTS reconciliation_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    provider VARCHAR(20) NOT NULL,
+    statement_date DATE NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    trade_count INTEGER NOT NULL DEFAULT 0,
+    refund_count INTEGER NOT NULL DEFAULT 0,
+    trade_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    refund_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    fee_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    net_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    local_trade_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    local_refund_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    matched_count INTEGER NOT NULL DEFAULT 0,
+    missing_local_count INTEGER NOT NULL DEFAULT 0,
+    missing_remote_count INTEGER NOT NULL DEFAULT 0,
+    amount_mismatch_count INTEGER NOT NULL DEFAULT 0,
+    status_mismatch_count INTEGER NOT NULL DEFAULT 0,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT chk_reconciliation_runs_status CHECK (status IN ('balanced', 'unbalanced'))
+);
+
+CREATE INDEX idx_reconciliation_runs_date ON reconciliation_runs(provider, statement_date DESC) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS reconciliation_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    run_id UUID NOT NULL REFERENCES reconciliation_runs(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    result VARCHAR(20) NOT NULL,
+    transaction_id VARCHAR(64),
+    merchant_no VARCHAR(64),
+    local_id UUID,
+    statement_amount DECIMAL(12,2),
+    local_amount DECIMAL(12,2),
+    fee DECIMAL(12,2) NOT NULL DEFAULT 0,
+    statement_status VARCHAR(32),
+    local_status VARCHAR(32),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT chk_reconciliation_items_kind CHECK (kind IN ('trade', 'refund')),
+    CONSTRAINT chk_reconciliation_items_result CHECK (result IN ('matched', 'missing_local', 'missing_remote', 'amount_mismatch', 'status_mismatch'))
+);
+
+CREATE INDEX idx_reconciliation_items_run ON reconciliation_items(run_id, result);
+
+COMMENT ON TABLE reconciliation_runs IS '支付渠道日对账记录';
+COMMENT ON COLUMN reconciliation_runs.statement_date IS '对账单日期';
+COMMENT ON COLUMN reconciliation_runs.status IS '对账结果: balanced-平账, unbalanced-存在差异';
+COMMENT ON COLUMN reconciliation_runs.fee_amount IS '渠道手续费，已扣除退款退回的手续费';
+COMMENT ON COLUMN reconciliation_runs.net_amount IS '净额 = 交易金额 - 退款金额 - 手续费';
+COMMENT ON TABLE reconciliation_items IS '对账明细';
+COMMENT ON COLUMN reconciliation_items.result IS '比对结果: matched-一致, missing_local-本地缺失, missing_remote-渠道缺失, amount_mismatch-金额不符, status_mismatch-状态不符';
+COMMENT ON COLUMN reconciliation_items.transaction_id IS '渠道交易号或退款单号';
+COMMENT ON COLUMN reconciliation_items.merchant_no IS '商户支付单号或退款单号';
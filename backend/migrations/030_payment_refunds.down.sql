@@ -0,0 +1,4 @@
+-- Migration: Drop payment_refunds table
+-- Down Migration
+
+DROP TABLE IF EXISTS payment_refunds;
@@ -0,0 +1,38 @@
+-- Migration: Create payment_refunds table
+-- Up Migration
+
+CREATE TABLE IF NOT EXISTS payment_refunds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id UUID NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    refund_request_id UUID REFERENCES refund_requests(id) ON DELETE SET NULL,
+    refund_no VARCHAR(64) NOT NULL UNIQUE,
+    third_party_id VARCHAR(64),
+    provider VARCHAR(20) NOT NULL,
+    amount DECIMAL(12,2) NOT NULL,
+    currency VARCHAR(3) NOT NULL DEFAULT 'CNY',
+    reason TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'processing',
+    provider_status VARCHAR(32),
+    succeeded_at TIMESTAMP WITH TIME ZONE,
+    last_queried_at TIMESTAMP WITH TIME ZONE,
+    query_count INTEGER NOT NULL DEFAULT 0,
+    notify_data TEXT,
+    error_message TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT chk_payment_refunds_status CHECK (status IN ('processing', 'success', 'failed')),
+    CONSTRAINT chk_payment_refunds_amount CHECK (amount > 0)
+);
+
+CREATE INDEX idx_payment_refunds_payment ON payment_refunds(payment_id);
+CREATE INDEX idx_payment_refunds_request ON payment_refunds(refund_request_id);
+CREATE INDEX idx_payment_refunds_processing ON payment_refunds(created_at) WHERE status = 'processing' AND deleted_at IS NULL;
+
+COMMENT ON TABLE payment_refunds IS '支付渠道退款记录，每次向渠道发起退款生成一条';
+COMMENT ON COLUMN payment_refunds.refund_no IS '商户退款单号';
+COMMENT ON COLUMN payment_refunds.third_party_id IS '渠道退款单号';
+COMMENT ON COLUMN payment_refunds.status IS '退款状态: processing-处理中, success-退款成功, failed-退款失败';
+COMMENT ON COLUMN payment_refunds.provider_status IS '渠道返回的原始退款状态';
+COMMENT ON COLUMN payment_refunds.query_count IS '主动查询次数';
+COMMENT ON COLUMN payment_refunds.notify_data IS '最近一次退款结果通知的解密内容';
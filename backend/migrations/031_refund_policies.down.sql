@@ -0,0 +1,11 @@
+-- Migration: Drop refund_policies table
+-- Down Migration
+
+ALTER TABLE refund_requests DROP COLUMN IF EXISTS breakdown;
+
+DROP INDEX IF EXISTS idx_orders_refund_policy_id;
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS refund_policy,
+    DROP COLUMN IF EXISTS refund_policy_id;
+
+DROP TABLE IF EXISTS refund_policies;
@@ -0,0 +1,59 @@
+-- Migration: Create refund_policies table and snapshot refund terms on orders
+-- Up Migration
+
+CREATE TABLE IF NOT EXISTS refund_policies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(64) NOT NULL,
+    version INTEGER NOT NULL DEFAULT 1,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    route_id UUID REFERENCES routes(id) ON DELETE CASCADE,
+    cruise_id UUID REFERENCES cruises(id) ON DELETE CASCADE,
+    price_type VARCHAR(20),
+    priority INTEGER NOT NULL DEFAULT 100,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    tiers JSONB NOT NULL DEFAULT '[]',
+    port_fee_refundable BOOLEAN NOT NULL DEFAULT false,
+    service_fee_refundable BOOLEAN NOT NULL DEFAULT false,
+    superseded_by UUID REFERENCES refund_policies(id) ON DELETE SET NULL,
+    created_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT uq_refund_policies_code_version UNIQUE (code, version),
+    CONSTRAINT chk_refund_policies_price_type CHECK (price_type IS NULL OR price_type IN ('standard', 'early_bird', 'last_minute', 'group'))
+);
+
+CREATE INDEX idx_refund_policies_route_id ON refund_policies(route_id);
+CREATE INDEX idx_refund_policies_cruise_id ON refund_policies(cruise_id);
+CREATE INDEX idx_refund_policies_active ON refund_policies(is_active, priority) WHERE deleted_at IS NULL;
+
+-- Default schedule for bookings no narrower policy covers
+INSERT INTO refund_policies (code, name, description, tiers)
+VALUES (
+    'default',
+    '标准退改规则',
+    '出发前30天以上全额退款，15-30天退80%，7-15天退50%，7天内不退；港务费及服务费不退',
+    '[{"min_days": 31, "percent": 100}, {"min_days": 15, "percent": 80}, {"min_days": 7, "percent": 50}, {"min_days": 0, "percent": 0}]'
+);
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS refund_policy_id UUID REFERENCES refund_policies(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS refund_policy JSONB;
+
+CREATE INDEX idx_orders_refund_policy_id ON orders(refund_policy_id);
+
+ALTER TABLE refund_requests
+    ADD COLUMN IF NOT EXISTS breakdown JSONB;
+
+COMMENT ON TABLE refund_policies IS '退改规则表，修改规则生成新版本';
+COMMENT ON COLUMN refund_policies.code IS '规则编码，同一规则的各版本共用';
+COMMENT ON COLUMN refund_policies.version IS '版本号';
+COMMENT ON COLUMN refund_policies.price_type IS '适用价格类型，为空表示全部';
+COMMENT ON COLUMN refund_policies.priority IS '优先级，数值越小越优先';
+COMMENT ON COLUMN refund_policies.tiers IS '退款阶梯: 距出发天数不少于 min_days 时退还票款的 percent%';
+COMMENT ON COLUMN refund_policies.port_fee_refundable IS '港务费是否可退';
+COMMENT ON COLUMN refund_policies.service_fee_refundable IS '服务费是否可退';
+COMMENT ON COLUMN refund_policies.superseded_by IS '替代本版本的新版本';
+COMMENT ON COLUMN orders.refund_policy IS '下单时适用的退改规则快照';
+COMMENT ON COLUMN refund_requests.breakdown IS '退款金额计算明细';
@@ -0,0 +1,4 @@
+-- Migration: Drop outbox_events table
+-- Down Migration
+
+DROP TABLE IF EXISTS outbox_events;
@@ -0,0 +1,34 @@
+-- Migration: Create outbox_events table
+-- Up Migration
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    sequence BIGSERIAL NOT NULL UNIQUE,
+    aggregate_type VARCHAR(32) NOT NULL,
+    aggregate_id VARCHAR(64) NOT NULL,
+    subject VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT chk_outbox_events_status CHECK (status IN ('pending', 'published', 'dead'))
+);
+
+CREATE INDEX idx_outbox_events_pending ON outbox_events(sequence) WHERE status = 'pending' AND deleted_at IS NULL;
+CREATE INDEX idx_outbox_events_status ON outbox_events(status, sequence);
+CREATE INDEX idx_outbox_events_aggregate ON outbox_events(aggregate_type, aggregate_id, sequence);
+
+COMMENT ON TABLE outbox_events IS '事务发件箱，与业务数据同事务写入，由转发任务发布到 JetStream';
+COMMENT ON COLUMN outbox_events.sequence IS '写入顺序，同一聚合的事件按此顺序发布';
+COMMENT ON COLUMN outbox_events.aggregate_type IS '聚合类型，如 order';
+COMMENT ON COLUMN outbox_events.aggregate_id IS '聚合ID，如订单ID';
+COMMENT ON COLUMN outbox_events.subject IS 'NATS 主题';
+COMMENT ON COLUMN outbox_events.status IS '状态: pending-待发布, published-已发布, dead-多次发布失败(死信)';
+COMMENT ON COLUMN outbox_events.attempts IS '发布尝试次数';
+COMMENT ON COLUMN outbox_events.next_attempt_at IS '下次发布时间';
+COMMENT ON COLUMN outbox_events.last_error IS '最近一次发布失败原因';
@@ -0,0 +1,4 @@
+-- Migration: Drop invoices table
+-- Down Migration
+
+DROP TABLE IF EXISTS invoices;
@@ -0,0 +1,53 @@
+-- Migration: Create invoices table
+-- Up Migration
+
+CREATE TABLE IF NOT EXISTS invoices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    user_id UUID,
+    kind VARCHAR(10) NOT NULL DEFAULT 'blue',
+    original_id UUID REFERENCES invoices(id),
+    buyer_type VARCHAR(20) NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    tax_id VARCHAR(20),
+    email VARCHAR(255) NOT NULL,
+    amount DECIMAL(12,2) NOT NULL,
+    currency VARCHAR(3) NOT NULL DEFAULT 'CNY',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    reviewed_by UUID,
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    review_note TEXT,
+    provider VARCHAR(32),
+    invoice_no VARCHAR(32),
+    issued_at TIMESTAMP WITH TIME ZONE,
+    file_url TEXT,
+    error_message TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT chk_invoices_kind CHECK (kind IN ('blue', 'red')),
+    CONSTRAINT chk_invoices_buyer_type CHECK (buyer_type IN ('personal', 'company')),
+    CONSTRAINT chk_invoices_status CHECK (status IN ('pending', 'rejected', 'issued', 'failed', 'reversed')),
+    CONSTRAINT chk_invoices_company_tax_id CHECK (buyer_type <> 'company' OR tax_id IS NOT NULL),
+    CONSTRAINT chk_invoices_red_original CHECK (kind <> 'red' OR original_id IS NOT NULL)
+);
+
+CREATE INDEX idx_invoices_order ON invoices(order_id);
+CREATE INDEX idx_invoices_user ON invoices(user_id);
+CREATE INDEX idx_invoices_status ON invoices(status, created_at);
+CREATE UNIQUE INDEX idx_invoices_invoice_no ON invoices(invoice_no) WHERE invoice_no IS NOT NULL AND invoice_no <> '';
+-- 一个订单同时只能有一张有效蓝字发票
+CREATE UNIQUE INDEX idx_invoices_open_blue ON invoices(order_id)
+    WHERE kind = 'blue' AND status IN ('pending', 'issued', 'failed') AND deleted_at IS NULL;
+
+COMMENT ON TABLE invoices IS '电子发票，蓝字发票由客户申请、财务审核后开具；订单开票后退款时开具红字发票冲销';
+COMMENT ON COLUMN invoices.kind IS '发票类型: blue-蓝字发票, red-红字发票';
+COMMENT ON COLUMN invoices.original_id IS '红字发票冲销的蓝字发票';
+COMMENT ON COLUMN invoices.buyer_type IS '抬头类型: personal-个人, company-企业';
+COMMENT ON COLUMN invoices.title IS '发票抬头';
+COMMENT ON COLUMN invoices.tax_id IS '购买方纳税人识别号，企业必填';
+COMMENT ON COLUMN invoices.email IS '接收电子发票的邮箱';
+COMMENT ON COLUMN invoices.amount IS '价税合计，红字发票为负数';
+COMMENT ON COLUMN invoices.status IS '状态: pending-待审核, rejected-已驳回, issued-已开具, failed-开具失败, reversed-已红冲';
+COMMENT ON COLUMN invoices.invoice_no IS '开票平台返回的发票号码';
+COMMENT ON COLUMN invoices.file_url IS '发票 PDF 的存储地址';
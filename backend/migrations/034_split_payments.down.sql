@@ -0,0 +1,7 @@
+-- Migration: Drop per-payment refunded amounts
+-- Down Migration
+
+DROP INDEX IF EXISTS idx_payments_order_status;
+
+ALTER TABLE payments
+    DROP COLUMN IF EXISTS refunded_amount;
@@ -0,0 +1,13 @@
+-- Migration: Track refunded amounts per payment for split payments
+-- Up Migration
+
+ALTER TABLE payments
+    ADD COLUMN IF NOT EXISTS refunded_amount DECIMAL(12,2) NOT NULL DEFAULT 0;
+
+-- Payments refunded before this migration were refunded in full
+UPDATE payments SET refunded_amount = amount WHERE status = 'refunded' AND refunded_amount = 0;
+
+-- Paid and partially paid orders are settled from their successful payments
+CREATE INDEX IF NOT EXISTS idx_payments_order_status ON payments(order_id, status);
+
+COMMENT ON COLUMN payments.refunded_amount IS '已成功退回该笔支付的金额，一个订单可由多笔支付共同付清';
@@ -0,0 +1,4 @@
+-- Migration: Drop payment_callbacks table
+-- Down Migration
+
+DROP TABLE IF EXISTS payment_callbacks;
@@ -0,0 +1,43 @@
+-- Migration: Create payment_callbacks table
+-- Up Migration
+
+CREATE TABLE IF NOT EXISTS payment_callbacks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    provider VARCHAR(20) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    headers JSONB,
+    body TEXT NOT NULL,
+    signature TEXT,
+    verified BOOLEAN NOT NULL DEFAULT FALSE,
+    reference VARCHAR(64),
+    status VARCHAR(20) NOT NULL DEFAULT 'received',
+    error TEXT,
+    processed_at TIMESTAMP WITH TIME ZONE,
+    replay_of_id UUID REFERENCES payment_callbacks(id),
+    replayed_by UUID,
+    replay_note VARCHAR(500),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT chk_payment_callbacks_kind CHECK (kind IN ('payment', 'refund')),
+    CONSTRAINT chk_payment_callbacks_status CHECK (status IN ('received', 'processed', 'failed'))
+);
+
+CREATE INDEX idx_payment_callbacks_created ON payment_callbacks(created_at DESC);
+CREATE INDEX idx_payment_callbacks_status ON payment_callbacks(status, created_at DESC);
+CREATE INDEX idx_payment_callbacks_reference ON payment_callbacks(reference);
+CREATE INDEX idx_payment_callbacks_replay_of ON payment_callbacks(replay_of_id);
+
+COMMENT ON TABLE payment_callbacks IS '支付渠道回调日志，原样保存每次收到的通知及处理结果';
+COMMENT ON COLUMN payment_callbacks.provider IS '支付渠道: wechat, alipay';
+COMMENT ON COLUMN payment_callbacks.kind IS '回调类型: payment-支付结果, refund-退款结果';
+COMMENT ON COLUMN payment_callbacks.headers IS '请求头，不含认证信息';
+COMMENT ON COLUMN payment_callbacks.body IS '原始请求体';
+COMMENT ON COLUMN payment_callbacks.signature IS '交给渠道验签的签名信息';
+COMMENT ON COLUMN payment_callbacks.verified IS '是否通过验签';
+COMMENT ON COLUMN payment_callbacks.reference IS '支付单号或退款单号';
+COMMENT ON COLUMN payment_callbacks.status IS '状态: received-已接收, processed-已处理, failed-处理失败';
+COMMENT ON COLUMN payment_callbacks.error IS '处理失败原因';
+COMMENT ON COLUMN payment_callbacks.replay_of_id IS '重放的原始回调ID，人工重放时记录';
+COMMENT ON COLUMN payment_callbacks.replayed_by IS '执行重放的管理员ID';
+COMMENT ON COLUMN payment_callbacks.replay_note IS '重放说明';
@@ -0,0 +1,7 @@
+-- Migration: Drop Alipay identities and account link audit
+-- Down Migration
+
+DROP TABLE IF EXISTS account_link_logs;
+DROP INDEX IF EXISTS idx_users_wechat_unionid;
+DROP INDEX IF EXISTS idx_users_alipay_user_id;
+ALTER TABLE users DROP COLUMN IF EXISTS alipay_user_id;
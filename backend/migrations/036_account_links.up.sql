@@ -0,0 +1,44 @@
+-- Migration: Alipay identities and account link audit
+-- Up Migration
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS alipay_user_id VARCHAR(64);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_alipay_user_id ON users(alipay_user_id);
+CREATE INDEX IF NOT EXISTS idx_users_wechat_unionid ON users(wechat_unionid);
+
+-- Unbound identities are cleared to NULL, as empty strings collide under the
+-- unique constraints
+UPDATE users SET phone = NULL WHERE phone = '';
+UPDATE users SET wechat_openid = NULL WHERE wechat_openid = '';
+
+COMMENT ON COLUMN users.alipay_user_id IS '支付宝用户ID';
+
+CREATE TABLE IF NOT EXISTS account_link_logs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    action VARCHAR(20) NOT NULL,
+    identity_type VARCHAR(20) NOT NULL,
+    identity VARCHAR(100),
+    merged_user_id UUID REFERENCES users(id),
+    orders_moved INTEGER NOT NULL DEFAULT 0,
+    passengers_moved INTEGER NOT NULL DEFAULT 0,
+    notifications_moved INTEGER NOT NULL DEFAULT 0,
+    detail JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT chk_account_link_logs_action CHECK (action IN ('bind', 'unbind', 'merge')),
+    CONSTRAINT chk_account_link_logs_identity_type CHECK (identity_type IN ('wechat', 'phone', 'alipay'))
+);
+
+CREATE INDEX idx_account_link_logs_user ON account_link_logs(user_id, created_at DESC);
+CREATE INDEX idx_account_link_logs_merged_user ON account_link_logs(merged_user_id);
+
+COMMENT ON TABLE account_link_logs IS '账号身份绑定、解绑及合并审计记录';
+COMMENT ON COLUMN account_link_logs.action IS '操作: bind-绑定, unbind-解绑, merge-合并账号';
+COMMENT ON COLUMN account_link_logs.identity_type IS '身份类型: wechat, phone, alipay';
+COMMENT ON COLUMN account_link_logs.identity IS '身份标识(脱敏)';
+COMMENT ON COLUMN account_link_logs.merged_user_id IS '被合并的账号ID';
+COMMENT ON COLUMN account_link_logs.orders_moved IS '转移的订单数';
+COMMENT ON COLUMN account_link_logs.passengers_moved IS '转移的常用乘客数';
+COMMENT ON COLUMN account_link_logs.notifications_moved IS '转移的通知数';
+COMMENT ON COLUMN account_link_logs.detail IS '被合并账号上因冲突而舍弃的身份(脱敏)';
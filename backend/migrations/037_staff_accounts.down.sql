@@ -0,0 +1,6 @@
+-- Migration: Drop staff accounts, roles and persisted RBAC policies
+-- Down Migration
+
+DROP TABLE IF EXISTS casbin_rules;
+DROP TABLE IF EXISTS staff_roles;
+DROP TABLE IF EXISTS staff_users;
@@ -0,0 +1,63 @@
+-- Migration: Staff accounts, roles and persisted RBAC policies
+-- Up Migration
+
+CREATE TABLE IF NOT EXISTS staff_users (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    username VARCHAR(50) NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    email VARCHAR(100),
+    phone VARCHAR(20),
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    last_login_at TIMESTAMP WITH TIME ZONE,
+    last_login_ip VARCHAR(45),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT chk_staff_users_status CHECK (status IN ('active', 'disabled'))
+);
+
+CREATE UNIQUE INDEX idx_staff_users_username ON staff_users(username) WHERE deleted_at IS NULL;
+CREATE INDEX idx_staff_users_status ON staff_users(status);
+
+COMMENT ON TABLE staff_users IS '后台员工账号，与C端用户分开';
+COMMENT ON COLUMN staff_users.username IS '登录名';
+COMMENT ON COLUMN staff_users.status IS '状态: active-正常, disabled-停用';
+
+CREATE TABLE IF NOT EXISTS staff_roles (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(50) NOT NULL,
+    display_name VARCHAR(100) NOT NULL,
+    description TEXT,
+    builtin BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX idx_staff_roles_name ON staff_roles(name) WHERE deleted_at IS NULL;
+
+COMMENT ON TABLE staff_roles IS '后台角色，权限保存在 casbin_rules 中';
+COMMENT ON COLUMN staff_roles.name IS '角色标识，即 casbin 策略中的主体';
+COMMENT ON COLUMN staff_roles.builtin IS '是否内置角色，内置角色不可删除';
+
+INSERT INTO staff_roles (name, display_name, description, builtin) VALUES
+    ('super_admin', '超级管理员', '拥有全部权限', TRUE),
+    ('operations', '运营', '维护邮轮、航线、航次和舱位', TRUE),
+    ('finance', '财务', '退款审核、对账和发票', TRUE),
+    ('customer_service', '客服', '订单和退款申请处理、用户查询', TRUE);
+
+CREATE TABLE IF NOT EXISTS casbin_rules (
+    id SERIAL PRIMARY KEY,
+    ptype VARCHAR(100) NOT NULL,
+    v0 VARCHAR(100) NOT NULL DEFAULT '',
+    v1 VARCHAR(100) NOT NULL DEFAULT '',
+    v2 VARCHAR(100) NOT NULL DEFAULT '',
+    v3 VARCHAR(100) NOT NULL DEFAULT '',
+    v4 VARCHAR(100) NOT NULL DEFAULT '',
+    v5 VARCHAR(100) NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX idx_casbin_rules_unique ON casbin_rules(ptype, v0, v1, v2, v3, v4, v5);
+
+COMMENT ON TABLE casbin_rules IS 'RBAC 策略: p-角色对资源的操作权限, g-员工的角色分配';
@@ -0,0 +1,5 @@
+-- Migration: Drop admin_audit_logs table
+-- Down Migration
+
+DROP TABLE IF EXISTS admin_audit_logs;
+DROP FUNCTION IF EXISTS admin_audit_logs_append_only();
@@ -0,0 +1,58 @@
+-- Migration: Create admin_audit_logs table
+-- Up Migration
+
+CREATE TABLE IF NOT EXISTS admin_audit_logs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor_id VARCHAR(64),
+    actor_name VARCHAR(100),
+    actor_role VARCHAR(50),
+    ip VARCHAR(45),
+    user_agent TEXT,
+    method VARCHAR(10) NOT NULL,
+    route VARCHAR(255) NOT NULL,
+    path TEXT NOT NULL,
+    resource VARCHAR(100),
+    target_id VARCHAR(100),
+    request JSONB,
+    before JSONB,
+    after JSONB,
+    diff JSONB,
+    status_code INTEGER NOT NULL,
+    result VARCHAR(20) NOT NULL,
+    error TEXT,
+    duration_ms BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_admin_audit_logs_result CHECK (result IN ('success', 'failure'))
+);
+
+CREATE INDEX idx_admin_audit_logs_created ON admin_audit_logs(created_at DESC);
+CREATE INDEX idx_admin_audit_logs_actor ON admin_audit_logs(actor_id, created_at DESC);
+CREATE INDEX idx_admin_audit_logs_target ON admin_audit_logs(resource, target_id);
+
+-- The log is append-only: updates are refused, and deletes only pass inside
+-- the retention purge, which sets audit.purge for its transaction
+CREATE OR REPLACE FUNCTION admin_audit_logs_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND current_setting('audit.purge', TRUE) = 'on' THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'admin_audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_admin_audit_logs_append_only
+    BEFORE UPDATE OR DELETE ON admin_audit_logs
+    FOR EACH ROW EXECUTE FUNCTION admin_audit_logs_append_only();
+
+COMMENT ON TABLE admin_audit_logs IS '后台操作审计日志，只追加不修改';
+COMMENT ON COLUMN admin_audit_logs.actor_id IS '操作员工ID';
+COMMENT ON COLUMN admin_audit_logs.actor_role IS '操作时的角色';
+COMMENT ON COLUMN admin_audit_logs.route IS '路由模板';
+COMMENT ON COLUMN admin_audit_logs.resource IS '操作对象类型';
+COMMENT ON COLUMN admin_audit_logs.target_id IS '操作对象ID';
+COMMENT ON COLUMN admin_audit_logs.request IS '请求体，密码等敏感字段已脱敏';
+COMMENT ON COLUMN admin_audit_logs.before IS '操作前的对象快照';
+COMMENT ON COLUMN admin_audit_logs.after IS '操作后的对象快照';
+COMMENT ON COLUMN admin_audit_logs.diff IS '变更字段: {"字段": {"from": 旧值, "to": 新值}}';
+COMMENT ON COLUMN admin_audit_logs.result IS '结果: success-成功, failure-失败';
+COMMENT ON COLUMN admin_audit_logs.error IS '失败原因';
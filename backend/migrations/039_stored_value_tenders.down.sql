@@ -0,0 +1,12 @@
+-- Migration: Drop gift card and points tenders
+-- Down Migration
+
+ALTER TABLE payments
+    DROP COLUMN IF EXISTS points,
+    DROP COLUMN IF EXISTS tender_ref;
+
+ALTER TABLE users
+    DROP CONSTRAINT IF EXISTS chk_users_points,
+    DROP COLUMN IF EXISTS points;
+
+DROP TABLE IF EXISTS gift_cards;
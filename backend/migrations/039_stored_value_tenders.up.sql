@@ -0,0 +1,32 @@
+-- Migration: Gift card and points tenders for split payments
+-- Up Migration
+
+CREATE TABLE IF NOT EXISTS gift_cards (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(32) NOT NULL,
+    currency VARCHAR(3) NOT NULL DEFAULT 'CNY',
+    balance DECIMAL(12,2) NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT chk_gift_cards_status CHECK (status IN ('active', 'disabled')),
+    CONSTRAINT chk_gift_cards_balance CHECK (balance >= 0)
+);
+
+CREATE UNIQUE INDEX idx_gift_cards_code ON gift_cards(code) WHERE deleted_at IS NULL;
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS points BIGINT NOT NULL DEFAULT 0,
+    ADD CONSTRAINT chk_users_points CHECK (points >= 0);
+
+ALTER TABLE payments
+    ADD COLUMN IF NOT EXISTS tender_ref VARCHAR(64),
+    ADD COLUMN IF NOT EXISTS points BIGINT NOT NULL DEFAULT 0;
+
+COMMENT ON TABLE gift_cards IS '礼品卡，支付时扣减余额，退款时退回';
+COMMENT ON COLUMN gift_cards.balance IS '剩余余额（卡面币种）';
+COMMENT ON COLUMN users.points IS '积分余额，可按比例抵扣订单金额';
+COMMENT ON COLUMN payments.tender_ref IS '储值类支付扣款的礼品卡卡号或用户ID';
+COMMENT ON COLUMN payments.points IS '积分支付抵扣的积分数';
@@ -0,0 +1,9 @@
+-- Migration: Drop account merge counts added for refunds, invoices, coupons, price watches and points
+-- Down Migration
+
+ALTER TABLE account_link_logs
+    DROP COLUMN IF EXISTS points_moved,
+    DROP COLUMN IF EXISTS price_watches_moved,
+    DROP COLUMN IF EXISTS coupons_moved,
+    DROP COLUMN IF EXISTS invoices_moved,
+    DROP COLUMN IF EXISTS refunds_moved;
@@ -0,0 +1,12 @@
+-- Migration: Record everything an account merge moves
+-- Up Migration
+
+ALTER TABLE account_link_logs
+    ADD COLUMN IF NOT EXISTS refunds_moved INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS invoices_moved INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS coupons_moved INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS price_watches_moved INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS points_moved BIGINT NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN account_link_logs.coupons_moved IS '合并时转移的券包优惠券数';
+COMMENT ON COLUMN account_link_logs.points_moved IS '合并时转移的积分';
@@ -0,0 +1,6 @@
+-- Migration: Drop extra guest fares from cabin price history
+-- Down Migration
+
+ALTER TABLE cabin_price_history
+    DROP COLUMN IF EXISTS extra_child_price,
+    DROP COLUMN IF EXISTS extra_adult_price;
@@ -0,0 +1,16 @@
+-- Migration: Snapshot extra guest fares in cabin price history
+-- Up Migration
+
+ALTER TABLE cabin_price_history
+    ADD COLUMN IF NOT EXISTS extra_adult_price DECIMAL(10,2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS extra_child_price DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+-- Open versions take the current fares; closed ones predate the snapshot
+UPDATE cabin_price_history h
+SET extra_adult_price = p.extra_adult_price,
+    extra_child_price = p.extra_child_price
+FROM cabin_prices p
+WHERE h.price_id = p.id AND h.effective_to IS NULL;
+
+COMMENT ON COLUMN cabin_price_history.extra_adult_price IS '该版本的第3/4位成人价格，0表示按成人价';
+COMMENT ON COLUMN cabin_price_history.extra_child_price IS '该版本的第3/4位儿童价格，0表示按儿童价';
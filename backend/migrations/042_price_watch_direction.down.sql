@@ -0,0 +1,7 @@
+-- Migration: Drop the price watch direction
+-- Down Migration
+
+ALTER TABLE price_watches
+    DROP CONSTRAINT IF EXISTS chk_price_watches_either,
+    DROP CONSTRAINT IF EXISTS chk_price_watches_direction,
+    DROP COLUMN IF EXISTS direction;
@@ -0,0 +1,12 @@
+-- Migration: Let price watches follow rises as well as drops
+-- Up Migration
+
+ALTER TABLE price_watches
+    ADD COLUMN IF NOT EXISTS direction VARCHAR(10) NOT NULL DEFAULT 'drop',
+    ADD CONSTRAINT chk_price_watches_direction CHECK (direction IN ('drop', 'rise', 'either')),
+    ADD CONSTRAINT chk_price_watches_either CHECK (direction <> 'either' OR (target_price IS NULL AND threshold_percent IS NOT NULL));
+
+COMMENT ON COLUMN price_watches.direction IS '提醒方向：drop 降价、rise 涨价、either 任一方向 (仅按幅度)';
+COMMENT ON COLUMN price_watches.threshold_percent IS '相对订阅时价格的变动幅度阈值 (%)';
+COMMENT ON COLUMN price_watches.target_price IS '目标价格，降价提醒在成人价不高于、涨价提醒在不低于该价格时提醒';
+COMMENT ON COLUMN price_watches.last_notified_price IS '上次提醒时的价格，仅沿提醒方向更进一步时再次提醒';